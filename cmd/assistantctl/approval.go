@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"carapulse/internal/attest"
+)
+
+func runApproval(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("approval subcommand required")
+	}
+	if args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		_, _ = fmt.Fprintln(out, "Usage: assistantctl approval <verify> [flags]")
+		return nil
+	}
+	switch args[0] {
+	case "verify":
+		return runApprovalVerify(args[1:], out)
+	default:
+		return fmt.Errorf("unknown approval command: %s", args[0])
+	}
+}
+
+// runApprovalVerify checks approval attestations against a public key the
+// auditor trusts, independently of the gateway's database. Attestations
+// come from a file or from the gateway; either way only the key decides.
+func runApprovalVerify(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("approval verify", flag.ContinueOnError)
+	planID := fs.String("plan-id", "", "fetch the plan's attestations from the gateway")
+	file := fs.String("file", "", "attestation JSON (one or a list) to verify offline")
+	keyFile := fs.String("public-key", "", "ed25519 public key (PEM, or base64 as exported by Vault transit)")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (strings.TrimSpace(*planID) == "") == (strings.TrimSpace(*file) == "") {
+		return errors.New("one of plan-id or file required")
+	}
+	if strings.TrimSpace(*keyFile) == "" {
+		return errors.New("public-key required")
+	}
+	keyData, err := readFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	pub, err := attest.ParsePublicKey(keyData)
+	if err != nil {
+		return err
+	}
+	var list []attest.Attestation
+	if strings.TrimSpace(*file) != "" {
+		data, err := readFile(*file)
+		if err != nil {
+			return err
+		}
+		list, err = parseAttestations(data)
+		if err != nil {
+			return err
+		}
+	} else {
+		client, err := gatewayClientFromFlags(*gateway, *token)
+		if err != nil {
+			return err
+		}
+		list, err = client.ListAttestations(context.Background(), strings.TrimSpace(*planID))
+		if err != nil {
+			return err
+		}
+	}
+	if len(list) == 0 {
+		return errors.New("no attestations to verify")
+	}
+	failed := 0
+	for _, a := range list {
+		// The auditor's key is checked whatever key ID the attestation names.
+		err := attest.Verify(context.Background(), attest.PublicKeys{a.KeyID: pub}, a)
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintf(out, "FAIL %s %s by %s: %v\n", a.PlanID, shortHash(a.PlanHash), a.Approver.ID, err)
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "ok   %s %s by %s (%s) at %s, key %s\n", a.PlanID, shortHash(a.PlanHash), a.Approver.ID, a.Source, a.ApprovedAt.UTC().Format("2006-01-02T15:04:05Z"), a.KeyID)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d attestations failed verification", failed, len(list))
+	}
+	return nil
+}
+
+func parseAttestations(data []byte) ([]attest.Attestation, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return attest.ParseList(data)
+	}
+	a, err := attest.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return []attest.Attestation{a}, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func (c *gatewayClient) ListAttestations(ctx context.Context, planID string) ([]attest.Attestation, error) {
+	data, err := c.doRequest(ctx, http.MethodGet, "/v1/plans/"+url.PathEscape(planID)+"/attestations", nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Attestations json.RawMessage `json:"attestations"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return attest.ParseList(resp.Attestations)
+}
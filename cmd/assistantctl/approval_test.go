@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"carapulse/internal/attest"
+)
+
+func signedAttestation(t *testing.T, key ed25519.PrivateKey, approver string) attest.Attestation {
+	t.Helper()
+	steps := []attest.Step{{StepID: "s1", Action: "scale", Tool: "kubectl", Input: map[string]any{"replicas": 3}}}
+	a, err := attest.Sign(context.Background(), attest.NewLocalKey("", key), attest.Statement{
+		PlanID:     "plan_1",
+		PlanHash:   attest.PlanHash("scale api", steps),
+		Intent:     "scale api",
+		Steps:      steps,
+		Approver:   attest.Approver{ID: approver},
+		Decision:   "approved",
+		Source:     attest.SourceAPI,
+		ApprovedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return a
+}
+
+func writePublicKey(t *testing.T, pub ed25519.PublicKey) string {
+	t.Helper()
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	path := filepath.Join(t.TempDir(), "attest.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestRunApprovalVerifyFromGateway(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	list := []attest.Attestation{signedAttestation(t, priv, "alice"), signedAttestation(t, other, "mallory")}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/plans/plan_1/attestations" {
+			t.Errorf("request: %s %s", r.Method, r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"plan_id": "plan_1", "attestations": list})
+	}))
+	defer ts.Close()
+	var buf bytes.Buffer
+	err := run([]string{"approval", "verify", "--plan-id", "plan_1", "--public-key", writePublicKey(t, pub), "--gateway", ts.URL}, &buf)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("err: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ok   plan_1") || !strings.Contains(lines[0], "by alice (api)") || !strings.HasPrefix(lines[1], "FAIL plan_1") {
+		t.Fatalf("out: %s", buf.String())
+	}
+}
+
+func TestRunApprovalVerifyFile(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	a := signedAttestation(t, priv, "alice")
+	data, _ := json.Marshal(a)
+	path := filepath.Join(t.TempDir(), "attestation.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	keyPath := writePublicKey(t, pub)
+	var buf bytes.Buffer
+	if err := run([]string{"approval", "verify", "--file", path, "--public-key", keyPath}, &buf); err != nil {
+		t.Fatalf("err: %v out=%s", err, buf.String())
+	}
+
+	a.Steps[0].Input = map[string]any{"replicas": 30}
+	data, _ = json.Marshal(a)
+	_ = os.WriteFile(path, data, 0o644)
+	if err := run([]string{"approval", "verify", "--file", path, "--public-key", keyPath}, &buf); err == nil {
+		t.Fatalf("expected tampered attestation to fail")
+	}
+	for _, args := range [][]string{
+		{"approval", "verify", "--public-key", keyPath},
+		{"approval", "verify", "--file", path},
+		{"approval", "nope"},
+	} {
+		if err := run(args, &buf); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
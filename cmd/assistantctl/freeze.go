@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"carapulse/internal/freeze"
+	"carapulse/internal/web"
+)
+
+func runFreeze(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("freeze subcommand required")
+	}
+	if args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		_, _ = fmt.Fprintln(out, "Usage: assistantctl freeze <list|create|delete|import> [flags]")
+		return nil
+	}
+	switch args[0] {
+	case "list":
+		return runFreezeList(args[1:], out)
+	case "create":
+		return runFreezeCreate(args[1:], out)
+	case "delete":
+		return runFreezeDelete(args[1:], out)
+	case "import":
+		return runFreezeImport(args[1:], out)
+	default:
+		return fmt.Errorf("unknown freeze command: %s", args[0])
+	}
+}
+
+func runFreezeList(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("freeze list", flag.ContinueOnError)
+	env := fs.String("environment", "", "only freezes covering this environment")
+	service := fs.String("service", "", "only freezes covering this service")
+	active := fs.Bool("active", false, "only freezes in effect now")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	client, err := gatewayClientFromFlags(*gateway, *token)
+	if err != nil {
+		return err
+	}
+	data, err := client.ListFreezes(context.Background(), *env, *service, *active)
+	if err != nil {
+		return err
+	}
+	_, _ = out.Write(data)
+	return nil
+}
+
+func runFreezeCreate(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("freeze create", flag.ContinueOnError)
+	name := fs.String("name", "", "freeze name")
+	reason := fs.String("reason", "", "reason shown to blocked operators")
+	startsAt := fs.String("starts-at", "", "start (RFC3339)")
+	endsAt := fs.String("ends-at", "", "end (RFC3339)")
+	recurrence := fs.String("recurrence", "", "weekends, end_of_quarter or weekly")
+	days := fs.String("days", "", "weekly days, comma separated (mon,fri)")
+	from := fs.String("from", "", "weekly start time (HH:MM)")
+	to := fs.String("to", "", "weekly end time (HH:MM)")
+	lastDays := fs.Int("last-days", 0, "end_of_quarter days before quarter end")
+	timezone := fs.String("timezone", "", "IANA timezone for recurrences")
+	envs := fs.String("environments", "", "environments, comma separated (all when empty)")
+	services := fs.String("services", "", "services, comma separated (all when empty)")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f := freeze.Freeze{
+		Name:         strings.TrimSpace(*name),
+		Reason:       strings.TrimSpace(*reason),
+		Timezone:     strings.TrimSpace(*timezone),
+		Environments: splitList(*envs),
+		Services:     splitList(*services),
+	}
+	var err error
+	if f.StartsAt, err = parseFreezeTime("starts-at", *startsAt); err != nil {
+		return err
+	}
+	if f.EndsAt, err = parseFreezeTime("ends-at", *endsAt); err != nil {
+		return err
+	}
+	if kind := strings.TrimSpace(*recurrence); kind != "" {
+		f.Recurrence = &freeze.Recurrence{Kind: kind, Days: splitList(*days), From: *from, To: *to, LastDays: *lastDays}
+	}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	client, err := gatewayClientFromFlags(*gateway, *token)
+	if err != nil {
+		return err
+	}
+	freezeID, err := client.CreateFreeze(context.Background(), f)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(out, freezeID)
+	return nil
+}
+
+func runFreezeDelete(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("freeze delete", flag.ContinueOnError)
+	freezeID := fs.String("id", "", "freeze id")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*freezeID) == "" {
+		return errors.New("id required")
+	}
+	client, err := gatewayClientFromFlags(*gateway, *token)
+	if err != nil {
+		return err
+	}
+	if err := client.DeleteFreeze(context.Background(), strings.TrimSpace(*freezeID)); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(out, "ok")
+	return nil
+}
+
+func runFreezeImport(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("freeze import", flag.ContinueOnError)
+	file := fs.String("file", "", "iCalendar file to upload")
+	calURL := fs.String("url", "", "iCalendar URL for the gateway to fetch")
+	sourceRef := fs.String("source-ref", "", "calendar name; re-importing it replaces its freezes")
+	envs := fs.String("environments", "", "environments, comma separated (all when empty)")
+	services := fs.String("services", "", "services, comma separated (all when empty)")
+	timezone := fs.String("timezone", "", "IANA timezone for floating times")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, link := strings.TrimSpace(*file), strings.TrimSpace(*calURL)
+	if (path == "") == (link == "") {
+		return errors.New("one of file or url required")
+	}
+	req := web.FreezeImportRequest{
+		URL:          link,
+		SourceRef:    strings.TrimSpace(*sourceRef),
+		Environments: splitList(*envs),
+		Services:     splitList(*services),
+		Timezone:     strings.TrimSpace(*timezone),
+	}
+	if path != "" {
+		data, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		req.ICal = string(data)
+		if req.SourceRef == "" {
+			req.SourceRef = filepath.Base(path)
+		}
+	}
+	client, err := gatewayClientFromFlags(*gateway, *token)
+	if err != nil {
+		return err
+	}
+	if link != "" && client.Client == nil {
+		// The gateway fetches the calendar before answering.
+		client.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.ImportFreezes(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "imported %d freezes from %s\n", len(resp.FreezeIDs), resp.SourceRef)
+	for _, skipped := range resp.Skipped {
+		_, _ = fmt.Fprintf(out, "skipped %s: %s\n", firstNonEmptyString(skipped.Name, skipped.UID), skipped.Reason)
+	}
+	return nil
+}
+
+func parseFreezeTime(name, value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be RFC3339: %w", name, err)
+	}
+	return &t, nil
+}
+
+func (c *gatewayClient) ListFreezes(ctx context.Context, env, service string, active bool) ([]byte, error) {
+	query := url.Values{}
+	if strings.TrimSpace(env) != "" {
+		query.Set("environment", env)
+	}
+	if strings.TrimSpace(service) != "" {
+		query.Set("service", service)
+	}
+	if active {
+		query.Set("active", "true")
+	}
+	path := "/v1/freezes"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.doRequest(ctx, http.MethodGet, path, nil)
+}
+
+func (c *gatewayClient) CreateFreeze(ctx context.Context, f freeze.Freeze) (string, error) {
+	var resp struct {
+		FreezeID string `json:"freeze_id"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/v1/freezes", f, &resp); err != nil {
+		return "", err
+	}
+	if resp.FreezeID == "" {
+		return "", errors.New("missing freeze_id")
+	}
+	return resp.FreezeID, nil
+}
+
+func (c *gatewayClient) DeleteFreeze(ctx context.Context, freezeID string) error {
+	_, err := c.doRequest(ctx, http.MethodDelete, "/v1/freezes/"+url.PathEscape(freezeID), nil)
+	return err
+}
+
+func (c *gatewayClient) ImportFreezes(ctx context.Context, req web.FreezeImportRequest) (web.FreezeImportResponse, error) {
+	var resp web.FreezeImportResponse
+	err := c.doJSON(ctx, http.MethodPost, "/v1/freezes:import", req, &resp)
+	return resp, err
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"carapulse/internal/freeze"
+	"carapulse/internal/web"
+)
+
+func TestRunFreezeCreate(t *testing.T) {
+	var got freeze.Freeze
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/freezes" {
+			t.Errorf("request: %s %s", r.Method, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"freeze_id":"freeze_1"}`))
+	}))
+	defer ts.Close()
+	var buf bytes.Buffer
+	args := []string{
+		"freeze", "create",
+		"--name", "friday afternoons",
+		"--recurrence", "weekly",
+		"--days", "fri",
+		"--from", "15:00",
+		"--timezone", "Europe/Berlin",
+		"--environments", "prod, staging",
+		"--gateway", ts.URL,
+	}
+	if err := run(args, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "freeze_1" {
+		t.Fatalf("out: %s", buf.String())
+	}
+	if got.Recurrence == nil || got.Recurrence.Days[0] != "fri" || len(got.Environments) != 2 || got.Timezone != "Europe/Berlin" {
+		t.Fatalf("freeze: %+v", got)
+	}
+}
+
+func TestRunFreezeCreateValidates(t *testing.T) {
+	cases := [][]string{
+		{"freeze", "create", "--gateway", "http://x"},
+		{"freeze", "create", "--name", "x", "--starts-at", "tomorrow", "--gateway", "http://x"},
+		{"freeze", "create", "--name", "x", "--recurrence", "weekly", "--gateway", "http://x"},
+	}
+	for _, args := range cases {
+		if err := run(args, &bytes.Buffer{}); err == nil {
+			t.Fatalf("%v: expected error", args)
+		}
+	}
+}
+
+func TestRunFreezeListAndDelete(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/freezes":
+			if r.URL.Query().Get("active") != "true" || r.URL.Query().Get("environment") != "prod" {
+				t.Errorf("query: %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`[{"freeze":{"name":"q4"}}]`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/freezes/freeze_1":
+			_, _ = w.Write([]byte(`{"status":"deleted"}`))
+		default:
+			t.Errorf("request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	var buf bytes.Buffer
+	if err := run([]string{"freeze", "list", "--active", "--environment", "prod", "--gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(buf.String(), "q4") {
+		t.Fatalf("out: %s", buf.String())
+	}
+	if err := run([]string{"freeze", "delete", "--id", "freeze_1", "--gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := run([]string{"freeze", "delete", "--gateway", ts.URL}, &buf); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestRunFreezeImportFile(t *testing.T) {
+	var got web.FreezeImportRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/freezes:import" {
+			t.Errorf("path: %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		_ = json.NewEncoder(w).Encode(web.FreezeImportResponse{
+			SourceRef: got.SourceRef,
+			FreezeIDs: []string{"freeze_1"},
+			Skipped:   []freeze.Skipped{{UID: "m1", Name: "Month end", Reason: "unsupported recurrence"}},
+		})
+	}))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "freezes.ics")
+	if err := os.WriteFile(path, []byte("BEGIN:VCALENDAR\nEND:VCALENDAR\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := run([]string{"freeze", "import", "--file", path, "--environments", "prod", "--gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got.SourceRef != "freezes.ics" || !strings.HasPrefix(got.ICal, "BEGIN:VCALENDAR") || got.URL != "" || got.Environments[0] != "prod" {
+		t.Fatalf("request: %+v", got)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "imported 1 freezes from freezes.ics") || !strings.Contains(out, "skipped Month end: unsupported recurrence") {
+		t.Fatalf("out: %s", out)
+	}
+	if err := run([]string{"freeze", "import", "--gateway", ts.URL}, &buf); err == nil {
+		t.Fatalf("expected error")
+	}
+}
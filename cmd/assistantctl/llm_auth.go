@@ -37,6 +37,8 @@ func runLLM(args []string, out io.Writer) error {
 	switch args[0] {
 	case "auth":
 		return runLLMAuth(args[1:], out)
+	case "eval":
+		return runLLMEval(args[1:], out)
 	default:
 		return fmt.Errorf("unknown llm command: %s", args[0])
 	}
@@ -67,12 +69,32 @@ func runLLMAuthImport(args []string, out io.Writer) error {
 	accessToken := fs.String("access-token", "", "access token")
 	refreshToken := fs.String("refresh-token", "", "refresh token")
 	expiresAt := fs.String("expires-at", "", "expires at RFC3339")
+	awsAccessKeyID := fs.String("aws-access-key-id", "", "AWS access key id (bedrock)")
+	awsSecretAccessKey := fs.String("aws-secret-access-key", "", "AWS secret access key (bedrock)")
+	awsSessionToken := fs.String("aws-session-token", "", "AWS session token (bedrock)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
 	var profile llm.AuthProfile
-	if strings.TrimSpace(*accessToken) != "" {
+	if strings.TrimSpace(*awsAccessKeyID) != "" {
+		if strings.TrimSpace(*awsSecretAccessKey) == "" {
+			return errors.New("aws-secret-access-key required with aws-access-key-id")
+		}
+		parsedExpiry, err := parseExpiryFlag(*expiresAt)
+		if err != nil {
+			return err
+		}
+		profile = llm.AuthProfile{
+			ID:                 defaultProfileID(*provider, strings.TrimSpace(*profileID)),
+			Provider:           strings.TrimSpace(*provider),
+			AWSAccessKeyID:     strings.TrimSpace(*awsAccessKeyID),
+			AWSSecretAccessKey: strings.TrimSpace(*awsSecretAccessKey),
+			AWSSessionToken:    strings.TrimSpace(*awsSessionToken),
+			ExpiresAt:          parsedExpiry,
+			Source:             "manual",
+		}
+	} else if strings.TrimSpace(*accessToken) != "" {
 		parsedExpiry, err := parseExpiryFlag(*expiresAt)
 		if err != nil {
 			return err
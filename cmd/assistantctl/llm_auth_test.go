@@ -350,6 +350,34 @@ func TestRunLLMAuthImportManual(t *testing.T) {
 	}
 }
 
+func TestRunLLMAuthImportAWSKeys(t *testing.T) {
+	authPath := filepath.Join(t.TempDir(), "profiles.json")
+	if err := run([]string{
+		"llm", "auth", "import",
+		"--provider", "bedrock",
+		"--aws-access-key-id", "AKID",
+		"--aws-secret-access-key", "secret",
+		"--auth-path", authPath,
+	}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	profiles, err := llm.LoadAuthProfiles(authPath)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(profiles.Profiles) != 1 || profiles.Profiles[0].AWSSecretAccessKey != "secret" || profiles.Defaults["bedrock"] != "bedrock:manual" {
+		t.Fatalf("profiles: %#v", profiles)
+	}
+	if err := run([]string{
+		"llm", "auth", "import",
+		"--provider", "bedrock",
+		"--aws-access-key-id", "AKID",
+		"--auth-path", authPath,
+	}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected missing secret error")
+	}
+}
+
 func TestRunLLMAuthImportInvalidExpiry(t *testing.T) {
 	authPath := filepath.Join(t.TempDir(), "profiles.json")
 	if err := run([]string{
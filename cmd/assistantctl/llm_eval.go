@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"carapulse/internal/llm"
+	"carapulse/internal/tools"
+)
+
+// promptVersionFlags collects repeated --prompt-version trigger=N pins.
+type promptVersionFlags map[string]int
+
+func (p promptVersionFlags) String() string {
+	return fmt.Sprint(map[string]int(p))
+}
+
+func (p promptVersionFlags) Set(value string) error {
+	trigger, version, ok := strings.Cut(value, "=")
+	n, err := strconv.Atoi(strings.TrimSpace(version))
+	if !ok || err != nil || n <= 0 {
+		return fmt.Errorf("prompt-version must be trigger=N, got %q", value)
+	}
+	p[llm.PromptTrigger(trigger)] = n
+	return nil
+}
+
+func runLLMEval(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("llm eval", flag.ContinueOnError)
+	golden := fs.String("golden", "", "golden cases: JSON file or directory of JSON files")
+	promptDir := fs.String("prompt-dir", "", "prompt template directory (<trigger>/v<N>.tmpl)")
+	format := fs.String("format", "text", "output format: text or json")
+	minScore := fs.Float64("min-score", 1, "fail when the mean score is below this")
+	versions := promptVersionFlags{}
+	fs.Var(versions, "prompt-version", "pin a template version, trigger=N (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*golden) == "" {
+		return errors.New("golden required")
+	}
+	cases, err := loadEvalCases(*golden)
+	if err != nil {
+		return err
+	}
+	router := &llm.Router{PromptDir: *promptDir, PromptVersions: versions}
+	summary, err := router.Eval(cases, tools.ValidatePlanStep)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			return err
+		}
+	case "text":
+		writeEvalText(out, summary)
+	default:
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+	if summary.Stale > 0 {
+		return fmt.Errorf("eval failed: %d of %d cases recorded against an older prompt; re-record them", summary.Stale, summary.Cases)
+	}
+	if summary.MeanScore < *minScore {
+		return fmt.Errorf("eval failed: %d of %d cases passed, mean score %.2f", summary.Passed, summary.Cases, summary.MeanScore)
+	}
+	return nil
+}
+
+func loadEvalCases(path string) ([]llm.EvalCase, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+	var cases []llm.EvalCase
+	for _, file := range files {
+		data, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var batch []llm.EvalCase
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		cases = append(cases, batch...)
+	}
+	if len(cases) == 0 {
+		return nil, errors.New("no eval cases found")
+	}
+	return cases, nil
+}
+
+func writeEvalText(out io.Writer, summary llm.EvalSummary) {
+	for _, res := range summary.Results {
+		status := "PASS"
+		switch {
+		case res.PromptChanged:
+			status = "STALE"
+		case !res.Passed:
+			status = "FAIL"
+		}
+		_, _ = fmt.Fprintf(out, "%s %s %s score=%.2f tools=%.2f risk=%s\n", status, res.Name, res.PromptTemplate, res.Score, res.ToolScore, res.RiskLevel)
+		for _, problem := range res.Problems {
+			_, _ = fmt.Fprintf(out, "    %s\n", problem)
+		}
+	}
+	_, _ = fmt.Fprintf(out, "%d/%d passed, mean score %.2f\n", summary.Passed, summary.Cases, summary.MeanScore)
+}
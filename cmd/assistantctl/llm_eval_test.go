@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"carapulse/internal/llm"
+)
+
+func TestRunLLMEvalGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"llm", "eval", "--golden", "../../internal/llm/testdata/eval"}, &buf); err != nil {
+		t.Fatalf("run: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "3/3 passed") {
+		t.Fatalf("output: %s", buf.String())
+	}
+}
+
+func TestRunLLMEvalFailureJSON(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "cases.json")
+	data := `[{"name":"bad","trigger":"alert","intent":"fix it","response":"{\"summary\":\"s\",\"risk_level\":\"low\",\"steps\":[{\"action\":\"status\",\"tool\":\"helm\",\"input\":{}}]}","expect":{"tools":["helm"]}}]`
+	if err := os.WriteFile(golden, []byte(data), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var buf bytes.Buffer
+	err := run([]string{"llm", "eval", "--golden", golden, "--format", "json", "--prompt-version", "alert=1"}, &buf)
+	if err == nil || !strings.Contains(err.Error(), "0 of 1") {
+		t.Fatalf("expected failure, got %v", err)
+	}
+	var summary llm.EvalSummary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if summary.Results[0].Valid || summary.Results[0].PromptTemplate != "alert/v1" {
+		t.Fatalf("summary: %#v", summary)
+	}
+	if err := run([]string{"llm", "eval", "--golden", golden, "--min-score", "0.5"}, &buf); err != nil {
+		t.Fatalf("min-score: %v", err)
+	}
+}
+
+func TestRunLLMEvalStaleRecording(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "cases.json")
+	data := `[{"name":"old","trigger":"manual","intent":"restart checkout","response":"{\"summary\":\"s\",\"risk_level\":\"low\",\"steps\":[{\"action\":\"restart\",\"tool\":\"kubectl\",\"input\":{\"resource\":\"deployment/checkout\"}}]}","prompt_sha256":"0000"}]`
+	if err := os.WriteFile(golden, []byte(data), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var buf bytes.Buffer
+	err := run([]string{"llm", "eval", "--golden", golden, "--min-score", "0"}, &buf)
+	if err == nil || !strings.Contains(err.Error(), "older prompt") || !strings.Contains(buf.String(), "STALE old") {
+		t.Fatalf("err: %v output: %s", err, buf.String())
+	}
+}
+
+func TestRunLLMEvalFlags(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"llm", "eval"}, &buf); err == nil {
+		t.Fatalf("expected golden error")
+	}
+	if err := run([]string{"llm", "eval", "--golden", "x", "--prompt-version", "alert"}, &buf); err == nil {
+		t.Fatalf("expected prompt-version error")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"carapulse/internal/config"
 	"carapulse/internal/logging"
 	"carapulse/internal/policy"
 	"carapulse/internal/web"
@@ -61,6 +62,8 @@ func run(args []string, out io.Writer) error {
 	switch args[0] {
 	case "plan":
 		return runPlan(args[1:], out)
+	case "approval":
+		return runApproval(args[1:], out)
 	case "exec":
 		return runExec(args[1:], out)
 	case "context":
@@ -71,6 +74,8 @@ func run(args []string, out io.Writer) error {
 		return runLLM(args[1:], out)
 	case "schedule":
 		return runSchedule(args[1:], out)
+	case "freeze":
+		return runFreeze(args[1:], out)
 	case "workflow":
 		return runWorkflow(args[1:], out)
 	case "session":
@@ -81,6 +86,8 @@ func run(args []string, out io.Writer) error {
 		return runRunbook(args[1:], out)
 	case "audit":
 		return runAudit(args[1:], out)
+	case "query":
+		return runQuery(args[1:], out)
 	default:
 		return fmt.Errorf("unknown command: %s", args[0])
 	}
@@ -89,7 +96,7 @@ func run(args []string, out io.Writer) error {
 func writeUsage(out io.Writer) {
 	_, _ = fmt.Fprintln(out, "Usage: assistantctl <command> <subcommand> [flags]")
 	_, _ = fmt.Fprintln(out, "")
-	_, _ = fmt.Fprintln(out, "Commands: plan, exec, context, policy, llm, schedule, workflow, session, playbook, runbook, audit")
+	_, _ = fmt.Fprintln(out, "Commands: plan, approval, exec, context, policy, llm, schedule, freeze, workflow, session, playbook, runbook, audit, query")
 	_, _ = fmt.Fprintln(out, "Global flags: --help, --version")
 }
 
@@ -122,6 +129,8 @@ func runPlanCreate(args []string, out io.Writer) error {
 	intent := fs.String("intent", "", "intent")
 	contextValue := fs.String("context", "", "context json or @file")
 	constraintsValue := fs.String("constraints", "", "constraints json or @file")
+	session := fs.String("session", "", "session id")
+	stream := fs.Bool("stream", false, "print the draft as it is generated")
 	gateway := fs.String("gateway", "", "gateway base url")
 	token := fs.String("token", "", "gateway token")
 	if err := fs.Parse(args); err != nil {
@@ -145,13 +154,20 @@ func runPlanCreate(args []string, out io.Writer) error {
 	if err != nil {
 		return err
 	}
-	planID, err := client.CreatePlan(context.Background(), web.PlanCreateRequest{
+	req := web.PlanCreateRequest{
 		Summary:     *summary,
 		Trigger:     *trigger,
 		Intent:      *intent,
 		Context:     ctxRef,
 		Constraints: constraints,
-	})
+		SessionID:   strings.TrimSpace(*session),
+	}
+	var planID string
+	if *stream {
+		planID, err = client.CreatePlanStream(context.Background(), req, out)
+	} else {
+		planID, err = client.CreatePlan(context.Background(), req)
+	}
 	if err != nil {
 		return err
 	}
@@ -176,9 +192,14 @@ func runPlanApprove(args []string, out io.Writer) error {
 	if err != nil {
 		return err
 	}
-	if err := client.CreateApproval(context.Background(), *planID, *status, *note); err != nil {
+	resp, err := client.CreateApproval(context.Background(), *planID, *status, *note)
+	if err != nil {
 		return err
 	}
+	if resp.Status == "pending" && resp.Required > 0 {
+		_, _ = fmt.Fprintf(out, "pending: %d of %d approvals\n", resp.Approvals, resp.Required)
+		return nil
+	}
 	_, _ = fmt.Fprintln(out, "ok")
 	return nil
 }
@@ -188,12 +209,14 @@ func runExec(args []string, out io.Writer) error {
 		return errors.New("exec subcommand required")
 	}
 	if args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
-		_, _ = fmt.Fprintln(out, "Usage: assistantctl exec <logs> [flags]")
+		_, _ = fmt.Fprintln(out, "Usage: assistantctl exec <logs|report> [flags]")
 		return nil
 	}
 	switch args[0] {
 	case "logs":
 		return runExecLogs(args[1:], out)
+	case "report":
+		return runExecReport(args[1:], out)
 	default:
 		return fmt.Errorf("unknown exec command: %s", args[0])
 	}
@@ -224,6 +247,33 @@ func runExecLogs(args []string, out io.Writer) error {
 	return nil
 }
 
+func runExecReport(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("exec report", flag.ContinueOnError)
+	execID := fs.String("execution-id", "", "execution id")
+	format := fs.String("format", "markdown", "markdown|json")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*execID) == "" {
+		return errors.New("execution-id required")
+	}
+	if *format != "markdown" && *format != "json" {
+		return errors.New("format must be markdown or json")
+	}
+	client, err := gatewayClientFromFlags(*gateway, *token)
+	if err != nil {
+		return err
+	}
+	data, err := client.ExecReport(context.Background(), *execID, *format)
+	if err != nil {
+		return err
+	}
+	_, _ = out.Write(data)
+	return nil
+}
+
 func runContext(args []string, out io.Writer) error {
 	if len(args) == 0 {
 		return errors.New("context subcommand required")
@@ -395,12 +445,16 @@ func runPolicy(args []string, out io.Writer) error {
 		return errors.New("policy subcommand required")
 	}
 	if args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
-		_, _ = fmt.Fprintln(out, "Usage: assistantctl policy <test> [flags]")
+		_, _ = fmt.Fprintln(out, "Usage: assistantctl policy <test|simulate|sign> [flags]")
 		return nil
 	}
 	switch args[0] {
 	case "test":
 		return runPolicyTest(args[1:], out)
+	case "simulate":
+		return runPolicySimulate(args[1:], out)
+	case "sign":
+		return runPolicySign(args[1:], out)
 	default:
 		return fmt.Errorf("unknown policy command: %s", args[0])
 	}
@@ -412,31 +466,63 @@ func runPolicyTest(args []string, out io.Writer) error {
 	pkg := fs.String("package", defaultPolicyPackage, "policy package")
 	inputValue := fs.String("input", "", "policy input json or @file")
 	timeoutMS := fs.Int("timeout-ms", 5000, "timeout ms")
+	embedded := fs.Bool("embedded", false, "evaluate the policies built into assistantctl instead of calling OPA")
+	explain := fs.String("explain", "", "print an evaluation trace: notes|fails|full|debug")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	if strings.TrimSpace(*opaURL) == "" {
+	if !*embedded && strings.TrimSpace(*opaURL) == "" {
 		return errors.New("opa-url required")
 	}
 	if strings.TrimSpace(*inputValue) == "" {
 		return errors.New("input required")
 	}
+	switch *explain {
+	case "", "notes", "fails", "full", "debug":
+	default:
+		return errors.New("explain must be notes, fails, full or debug")
+	}
 	var input policy.PolicyInput
 	if err := parseJSONInput(*inputValue, &input); err != nil {
 		return err
 	}
-	client := &http.Client{Timeout: time.Duration(*timeoutMS) * time.Millisecond}
-	service := &policy.PolicyService{
-		OPAURL:        strings.TrimRight(*opaURL, "/"),
-		PolicyPackage: strings.TrimSpace(*pkg),
-		HTTPClient:    client,
+	var checker interface {
+		policy.Checker
+		policy.Explainer
+	}
+	if *embedded {
+		rego, err := policy.NewEmbeddedChecker(config.PolicyConfig{PolicyPackage: strings.TrimSpace(*pkg)})
+		if err != nil {
+			return err
+		}
+		checker = rego
+	} else {
+		client := &http.Client{Timeout: time.Duration(*timeoutMS) * time.Millisecond}
+		checker = &policy.PolicyService{
+			OPAURL:        strings.TrimRight(*opaURL, "/"),
+			PolicyPackage: strings.TrimSpace(*pkg),
+			HTTPClient:    client,
+		}
+	}
+	if *explain == "" {
+		decision, err := checker.Evaluate(input)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(out)
+		return enc.Encode(decision)
 	}
-	decision, err := service.Evaluate(input)
+	decision, trace, err := checker.Explain(input, *explain)
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(out)
-	return enc.Encode(decision)
+	if err := json.NewEncoder(out).Encode(decision); err != nil {
+		return err
+	}
+	for _, line := range trace {
+		_, _ = fmt.Fprintln(out, line)
+	}
+	return nil
 }
 
 func runPlanGet(args []string, out io.Writer) error {
@@ -854,9 +940,17 @@ func (c *gatewayClient) CreatePlan(ctx context.Context, req web.PlanCreateReques
 	return resp.PlanID, nil
 }
 
-func (c *gatewayClient) CreateApproval(ctx context.Context, planID, status, note string) error {
+type approvalResponse struct {
+	Status    string `json:"status"`
+	Approvals int    `json:"approvals"`
+	Required  int    `json:"required"`
+}
+
+func (c *gatewayClient) CreateApproval(ctx context.Context, planID, status, note string) (approvalResponse, error) {
 	req := web.ApprovalCreateRequest{PlanID: planID, Status: status, ApproverNote: note}
-	return c.doJSON(ctx, http.MethodPost, "/v1/approvals", req, nil)
+	var resp approvalResponse
+	err := c.doJSON(ctx, http.MethodPost, "/v1/approvals", req, &resp)
+	return resp, err
 }
 
 func (c *gatewayClient) ExecLogs(ctx context.Context, executionID, toolCallID, level string) ([]byte, error) {
@@ -874,6 +968,11 @@ func (c *gatewayClient) ExecLogs(ctx context.Context, executionID, toolCallID, l
 	return c.doRequest(ctx, http.MethodGet, path, nil)
 }
 
+func (c *gatewayClient) ExecReport(ctx context.Context, executionID, format string) ([]byte, error) {
+	path := "/v1/executions/" + executionID + "/report?format=" + url.QueryEscape(format)
+	return c.doRequest(ctx, http.MethodGet, path, nil)
+}
+
 func (c *gatewayClient) RefreshContext(ctx context.Context, service string) error {
 	req := web.ContextRefreshRequest{Service: service}
 	return c.doJSON(ctx, http.MethodPost, "/v1/context/refresh", req, nil)
@@ -243,6 +243,22 @@ func TestRunPlanApproveOK(t *testing.T) {
 	}
 }
 
+func TestRunPlanApprovePendingQuorum(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"pending","approvals":1,"required":2}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	if err := run([]string{"plan", "approve", "-plan-id", "plan_1", "-gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "pending: 1 of 2 approvals" {
+		t.Fatalf("output: %s", buf.String())
+	}
+}
+
 func TestRunPlanApproveGatewayError(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -327,6 +343,68 @@ func TestRunExecLogsGatewayError(t *testing.T) {
 	}
 }
 
+func TestRunExecReportOK(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/executions/exec_1/report" || r.URL.Query().Get("format") != "json" {
+			t.Fatalf("url: %s", r.URL.String())
+		}
+		_, _ = w.Write([]byte(`{"execution_id":"exec_1"}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	if err := run([]string{"exec", "report", "-execution-id", "exec_1", "-format", "json", "-gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if buf.String() != `{"execution_id":"exec_1"}` {
+		t.Fatalf("output: %s", buf.String())
+	}
+}
+
+func TestRunExecReportInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"exec", "report", "-gateway", "http://example"}, &buf); err == nil {
+		t.Fatalf("expected execution-id error")
+	}
+	if err := run([]string{"exec", "report", "-execution-id", "exec_1", "-format", "pdf", "-gateway", "http://example"}, &buf); err == nil {
+		t.Fatalf("expected format error")
+	}
+}
+
+func TestRunQueryOK(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req web.QueryRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v1/query" || req.Question != "5xx rate for checkout" || req.Language != "promql" || !req.DryRun || req.Context.TenantID != "t" {
+			t.Fatalf("request: %s %#v", r.URL.Path, req)
+		}
+		_, _ = w.Write([]byte(`{"language":"promql","query":"sum(rate(http_requests_total[5m]))","explanation":"request rate"}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	args := []string{"query", "-language", "promql", "-dry-run", "-context", `{"tenant_id":"t"}`, "-gateway", ts.URL, "5xx", "rate", "for", "checkout"}
+	if err := run(args, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if buf.String() != "promql: sum(rate(http_requests_total[5m]))\n# request rate\n" {
+		t.Fatalf("output: %q", buf.String())
+	}
+}
+
+func TestRunQueryInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"query", "-gateway", "http://example"}, &buf); err == nil {
+		t.Fatalf("expected question error")
+	}
+	if err := run([]string{"query", "-question", "x", "-format", "yaml", "-gateway", "http://example"}, &buf); err == nil {
+		t.Fatalf("expected format error")
+	}
+	if err := run([]string{"query", "-question", "x", "-context", "{", "-gateway", "http://example"}, &buf); err == nil {
+		t.Fatalf("expected context error")
+	}
+}
+
 func TestRunContextUnknownCommand(t *testing.T) {
 	var buf bytes.Buffer
 	if err := run([]string{"context", "nope"}, &buf); err == nil {
@@ -1349,3 +1427,47 @@ func TestMainFatalOnError(t *testing.T) {
 		t.Fatalf("expected fatal")
 	}
 }
+
+func TestRunPolicyTestEmbeddedReasons(t *testing.T) {
+	var buf bytes.Buffer
+	input := `{"actor":{"id":"a","roles":[]},"action":{"type":"write"},"context":{"environment":"dev"},"risk":{"level":"low"}}`
+	if err := run([]string{"policy", "test", "-embedded", "-input", input}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"decision":"deny"`) || !strings.Contains(buf.String(), `"rule":"write_role_required"`) {
+		t.Fatalf("output: %s", buf.String())
+	}
+}
+
+func TestRunPolicyTestEmbeddedExplain(t *testing.T) {
+	var buf bytes.Buffer
+	input := `{"actor":{"id":"a"},"action":{"type":"read"},"risk":{"level":"read"}}`
+	if err := run([]string{"policy", "test", "-embedded", "-explain", "full", "-input", input}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 || !strings.Contains(lines[0], `"decision":"allow"`) || !strings.Contains(buf.String(), "Enter data.policy.assistant.v1") {
+		t.Fatalf("output: %s", buf.String())
+	}
+	if err := run([]string{"policy", "test", "-embedded", "-explain", "loud", "-input", input}, &buf); err == nil {
+		t.Fatalf("expected explain mode error")
+	}
+}
+
+func TestRunPolicyTestRemoteExplain(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("explain") != "notes" || r.URL.Query().Get("pretty") != "true" {
+			t.Errorf("query: %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"result":{"decision":"allow"},"explanation":["query:1 Enter data.policy.assistant.v1 = _"]}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	if err := run([]string{"policy", "test", "-opa-url", ts.URL, "-explain", "notes", "-input", "{}"}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "query:1 Enter data.policy.assistant.v1 = _\n") {
+		t.Fatalf("output: %s", buf.String())
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"carapulse/internal/web"
+)
+
+// planStreamTimeout bounds a streamed plan creation; drafts can take far
+// longer than the client's default request timeout.
+const planStreamTimeout = 5 * time.Minute
+
+// planCreatedWait is how long to wait for the plan.created event after
+// the create call returned, so trailing deltas are still printed.
+var planCreatedWait = 2 * time.Second
+
+// CreatePlanStream creates a plan with streaming enabled and writes the
+// draft to out as plan.draft.delta events arrive on the session's event
+// stream. Drafts are only published to a session, so one is generated
+// when req has none. When events were dropped or the stream is
+// unavailable the final plan text is printed instead.
+func (c *gatewayClient) CreatePlanStream(ctx context.Context, req web.PlanCreateRequest, out io.Writer) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, planStreamTimeout)
+	defer cancel()
+	req.Stream = true
+	if req.DraftID == "" {
+		req.DraftID = newDraftID()
+	}
+	if strings.TrimSpace(req.SessionID) == "" {
+		req.SessionID = newStreamSessionID()
+	}
+	events, err := c.openEvents(ctx, req.SessionID)
+	if err != nil {
+		return "", err
+	}
+	defer events.Close()
+
+	render := &draftRenderer{draftID: req.DraftID, out: out, created: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = readEventStream(events, render.handle)
+	}()
+
+	post := *c
+	post.Client = &http.Client{Timeout: planStreamTimeout}
+	var resp struct {
+		PlanID string `json:"plan_id"`
+		Plan   struct {
+			PlanID   string `json:"plan_id"`
+			PlanText string `json:"plan_text"`
+		} `json:"plan"`
+	}
+	err = post.doJSON(ctx, http.MethodPost, "/v1/plans", req, &resp)
+	if err == nil {
+		select {
+		case <-render.created:
+		case <-time.After(planCreatedWait):
+		}
+	}
+	_ = events.Close()
+	<-done
+	if err != nil {
+		return "", err
+	}
+	planID := firstNonEmptyString(resp.PlanID, resp.Plan.PlanID)
+	if planID == "" {
+		return "", errors.New("missing plan_id")
+	}
+	if !render.complete() && resp.Plan.PlanText != "" {
+		render.restart()
+		_, _ = fmt.Fprint(out, resp.Plan.PlanText)
+	}
+	if render.printed() {
+		_, _ = fmt.Fprintln(out)
+	}
+	return planID, nil
+}
+
+// openEvents subscribes to the gateway's event stream and returns once
+// the gateway acknowledged the subscription.
+func (c *gatewayClient) openEvents(ctx context.Context, sessionID string) (io.ReadCloser, error) {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/events"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	if c.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if strings.TrimSpace(sessionID) != "" {
+		request.Header.Set("X-Session-Id", sessionID)
+	}
+	resp, err := (&http.Client{}).Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		payload, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("gateway status %d: %s", resp.StatusCode, string(payload))
+	}
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		if strings.TrimSpace(line) == ":ok" {
+			break
+		}
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, resp.Body}, nil
+}
+
+// draftRenderer prints the deltas of one draft. A new attempt (retry,
+// fallback or repair) starts on a fresh line; a gap in offsets stops
+// printing so the final plan text is shown instead.
+type draftRenderer struct {
+	draftID string
+	out     io.Writer
+	created chan struct{}
+	attempt int
+	offset  int
+	gap     bool
+	wrote   bool
+	done    bool
+}
+
+type draftEvent struct {
+	Data struct {
+		DraftID string `json:"draft_id"`
+		Attempt int    `json:"attempt"`
+		Offset  int    `json:"offset"`
+		Delta   string `json:"delta"`
+	} `json:"data"`
+}
+
+func (r *draftRenderer) handle(name, data string) error {
+	var ev draftEvent
+	if err := json.Unmarshal([]byte(data), &ev); err != nil || ev.Data.DraftID != r.draftID {
+		return nil
+	}
+	switch name {
+	case "plan.draft.delta":
+		if ev.Data.Attempt != r.attempt {
+			if r.wrote {
+				_, _ = fmt.Fprintf(r.out, "\n--- attempt %d ---\n", ev.Data.Attempt)
+			}
+			r.attempt, r.offset, r.gap = ev.Data.Attempt, 0, false
+		}
+		if r.gap || ev.Data.Offset != r.offset {
+			r.gap = true
+			return nil
+		}
+		_, _ = fmt.Fprint(r.out, ev.Data.Delta)
+		r.offset += len(ev.Data.Delta)
+		r.wrote = true
+	case "plan.created":
+		if !r.done {
+			r.done = true
+			close(r.created)
+		}
+		return io.EOF
+	}
+	return nil
+}
+
+func (r *draftRenderer) complete() bool {
+	return r.wrote && !r.gap && r.done
+}
+
+func (r *draftRenderer) printed() bool {
+	return r.wrote
+}
+
+// restart separates a reprinted final draft from partial output.
+func (r *draftRenderer) restart() {
+	if r.wrote {
+		_, _ = fmt.Fprintln(r.out, "\n--- final ---")
+	}
+	r.wrote = true
+}
+
+// readEventStream calls fn for every server-sent event until fn returns
+// an error or the stream ends.
+func readEventStream(body io.Reader, fn func(name, data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	var name string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := fn(name, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			name, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return scanner.Err()
+}
+
+func newDraftID() string {
+	return randomID("draft_")
+}
+
+// newStreamSessionID names an ad hoc session for one streamed plan.
+func newStreamSessionID() string {
+	return randomID("session_cli_")
+}
+
+func randomID(prefix string) string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return prefix + hex.EncodeToString(b[:])
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
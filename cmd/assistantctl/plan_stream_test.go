@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"carapulse/internal/web"
+)
+
+// streamGateway serves /v1/events and /v1/plans; deltas are sent once the
+// plan request names its draft. The plan request must name the session
+// the events were subscribed for, and session when it is set.
+func streamGateway(t *testing.T, session string, deltas []map[string]any, planText string) *httptest.Server {
+	t.Helper()
+	drafts := make(chan string, 1)
+	sessions := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/events":
+			got := r.Header.Get("X-Session-Id")
+			if got == "" || (session != "" && got != session) {
+				t.Errorf("session header: %q", got)
+			}
+			sessions <- got
+			flusher := w.(http.Flusher)
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, ":ok\n\n")
+			flusher.Flush()
+			draftID := <-drafts
+			for _, d := range deltas {
+				d["draft_id"] = draftID
+				payload, _ := json.Marshal(map[string]any{"event": "plan.draft.delta", "data": d})
+				fmt.Fprintf(w, "event: plan.draft.delta\ndata: %s\n\n", payload)
+			}
+			payload, _ := json.Marshal(map[string]any{"event": "plan.created", "data": map[string]any{"draft_id": draftID, "plan_id": "plan_1"}})
+			fmt.Fprintf(w, "event: plan.created\ndata: %s\n\n", payload)
+			flusher.Flush()
+			<-r.Context().Done()
+		case "/v1/plans":
+			var req web.PlanCreateRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if !req.Stream || req.DraftID == "" || req.SessionID != <-sessions {
+				t.Errorf("request: %#v", req)
+			}
+			drafts <- req.DraftID
+			payload, _ := json.Marshal(map[string]any{"plan_id": "plan_1", "plan": map[string]any{"plan_text": planText}})
+			_, _ = w.Write(payload)
+		default:
+			t.Errorf("path: %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRunPlanCreateStream(t *testing.T) {
+	ts := streamGateway(t, "sess", []map[string]any{
+		{"attempt": 1, "offset": 0, "delta": "bad"},
+		{"attempt": 2, "offset": 0, "delta": `{"summary":`},
+		{"attempt": 2, "offset": 11, "delta": `"s"}`},
+	}, `{"summary":"s"}`)
+	var buf bytes.Buffer
+	if err := run([]string{"plan", "create", "-summary", "s", "-session", "sess", "-stream", "-gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := "bad\n--- attempt 2 ---\n{\"summary\":\"s\"}\nplan_1\n"
+	if buf.String() != want {
+		t.Fatalf("output: %q", buf.String())
+	}
+}
+
+func TestRunPlanCreateStreamGapPrintsFinal(t *testing.T) {
+	ts := streamGateway(t, "sess", []map[string]any{
+		{"attempt": 1, "offset": 0, "delta": `{"sum`},
+		{"attempt": 1, "offset": 20, "delta": `lost"}`},
+	}, `{"summary":"s"}`)
+	var buf bytes.Buffer
+	if err := run([]string{"plan", "create", "-summary", "s", "-session", "sess", "-stream", "-gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := "{\"sum\n--- final ---\n{\"summary\":\"s\"}\nplan_1\n"
+	if buf.String() != want {
+		t.Fatalf("output: %q", buf.String())
+	}
+}
+
+func TestRunPlanCreateStreamWithoutSession(t *testing.T) {
+	ts := streamGateway(t, "", []map[string]any{
+		{"attempt": 1, "offset": 0, "delta": `{"summary":`},
+		{"attempt": 1, "offset": 11, "delta": `"s"}`},
+	}, `{"summary":"final"}`)
+	var buf bytes.Buffer
+	if err := run([]string{"plan", "create", "-summary", "s", "-stream", "-gateway", ts.URL}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := "{\"summary\":\"s\"}\nplan_1\n"
+	if buf.String() != want {
+		t.Fatalf("output: %q", buf.String())
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"carapulse/internal/policy"
+)
+
+// runPolicySign writes the manifest and signature a bundle needs before
+// the gateway and tool router will activate it. With -out the signed
+// bundle is also packed as a .tar.gz for the object store source.
+func runPolicySign(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("policy sign", flag.ContinueOnError)
+	dir := fs.String("dir", "", "bundle directory")
+	keyFile := fs.String("key", "", "ed25519 private key (PEM, PKCS#8)")
+	revision := fs.String("revision", "", "bundle revision to record in the manifest")
+	archive := fs.String("out", "", "also write the signed bundle as a .tar.gz")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*dir) == "" {
+		return errors.New("dir required")
+	}
+	if strings.TrimSpace(*keyFile) == "" {
+		return errors.New("key required")
+	}
+	keyPEM, err := readFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := policy.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(*revision) != "" {
+		manifest, err := json.Marshal(map[string]string{"revision": strings.TrimSpace(*revision)})
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(*dir, policy.BundleManifestFile), manifest, 0o644); err != nil {
+			return err
+		}
+	}
+	bundle, err := policy.ReadBundleFS(os.DirFS(*dir))
+	if err != nil {
+		return err
+	}
+	bundle.Files[policy.BundleSignatureFile] = bundle.Sign(key)
+	if err := os.WriteFile(filepath.Join(*dir, policy.BundleSignatureFile), bundle.Files[policy.BundleSignatureFile], 0o644); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*archive) != "" {
+		data, err := bundleArchive(bundle)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*archive, data, 0o644); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(out, "signed %d files, digest %s\n", len(bundle.Files)-1, bundle.Digest())
+	return nil
+}
+
+func bundleArchive(bundle policy.Bundle) ([]byte, error) {
+	names := make([]string, 0, len(bundle.Files))
+	for name := range bundle.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		data := bundle.Files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
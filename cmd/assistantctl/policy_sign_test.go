@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"carapulse/internal/policy"
+)
+
+func TestRunPolicySignWritesVerifiableBundle(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "policy.rego"), []byte("package p\n\ndecision := \"allow\"\n"), 0o644)
+	archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	var buf bytes.Buffer
+	if err := run([]string{"policy", "sign", "-dir", dir, "-key", keyFile, "-revision", "v1", "-out", archive}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(buf.String(), "signed 2 files") {
+		t.Fatalf("output: %s", buf.String())
+	}
+	bundle, err := policy.ReadBundleFS(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if bundle.Revision != "v1" || bundle.Verify(pub) != nil {
+		t.Fatalf("bundle %q does not verify", bundle.Revision)
+	}
+	data, _ := os.ReadFile(archive)
+	packed, err := policy.ReadBundleArchive(data)
+	if err != nil || packed.Verify(pub) != nil || packed.Digest() != bundle.Digest() {
+		t.Fatalf("archive: %v", err)
+	}
+
+	// Re-signing after an edit replaces the old signature.
+	_ = os.WriteFile(filepath.Join(dir, "policy.rego"), []byte("package p\n\ndecision := \"deny\"\n"), 0o644)
+	if err := run([]string{"policy", "sign", "-dir", dir, "-key", keyFile}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bundle, _ := policy.ReadBundleFS(os.DirFS(dir)); bundle.Verify(pub) != nil {
+		t.Fatalf("re-signed bundle does not verify")
+	}
+}
+
+func TestRunPolicySignValidation(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	_ = os.WriteFile(keyFile, []byte("not a key"), 0o600)
+	cases := [][]string{
+		{"-key", keyFile},
+		{"-dir", t.TempDir()},
+		{"-dir", t.TempDir(), "-key", keyFile},
+		{"-dir", t.TempDir(), "-key", filepath.Join(t.TempDir(), "missing.pem")},
+	}
+	for _, args := range cases {
+		var buf bytes.Buffer
+		if err := run(append([]string{"policy", "sign"}, args...), &buf); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
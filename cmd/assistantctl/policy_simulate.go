@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"carapulse/internal/config"
+	"carapulse/internal/policy"
+)
+
+// simulatePageSize is the gateway's maximum audit page size.
+const simulatePageSize = 200
+
+// simulatedEvent is the part of an audit event a simulation replays.
+type simulatedEvent struct {
+	EventID     string              `json:"event_id"`
+	Action      string              `json:"action"`
+	PolicyInput *policy.PolicyInput `json:"policy_input"`
+}
+
+// SimulationReport lists the recorded decisions whose outcome differs
+// between the current and the candidate bundle.
+type SimulationReport struct {
+	From      string            `json:"from"`
+	Replayed  int               `json:"replayed"`
+	Flipped   int               `json:"flipped"`
+	Unchanged int               `json:"unchanged"`
+	Flips     []SimulationFlip  `json:"flips"`
+	Errors    []SimulationError `json:"errors,omitempty"`
+}
+
+// SimulationFlip groups flipped decisions by tenant, action and transition.
+type SimulationFlip struct {
+	TenantID  string   `json:"tenant_id"`
+	Action    string   `json:"action"`
+	Current   string   `json:"current"`
+	Candidate string   `json:"candidate"`
+	Count     int      `json:"count"`
+	EventIDs  []string `json:"event_ids"`
+}
+
+// SimulationError is an event either bundle failed to evaluate.
+type SimulationError struct {
+	EventID string `json:"event_id"`
+	Error   string `json:"error"`
+}
+
+func runPolicySimulate(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("policy simulate", flag.ContinueOnError)
+	candidate := fs.String("candidate", "", "directory holding the candidate rego bundle")
+	current := fs.String("current", "", "directory holding the current bundle (default: the policies built into assistantctl)")
+	opaURL := fs.String("opa-url", "", "evaluate the current bundle on this OPA instead")
+	pkg := fs.String("package", defaultPolicyPackage, "policy package")
+	tenants := fs.String("tenant-id", "", "comma-separated tenant ids to replay")
+	days := fs.Int("days", 7, "replay decisions recorded in the last N days")
+	format := fs.String("format", "text", "output format: text or json")
+	timeoutMS := fs.Int("timeout-ms", 5000, "opa timeout ms")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*candidate) == "" {
+		return errors.New("candidate required")
+	}
+	if strings.TrimSpace(*current) != "" && strings.TrimSpace(*opaURL) != "" {
+		return errors.New("use either current or opa-url")
+	}
+	tenantIDs := splitList(*tenants)
+	if len(tenantIDs) == 0 {
+		return errors.New("tenant-id required")
+	}
+	if *days <= 0 {
+		return errors.New("days must be positive")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+	client, err := gatewayClientFromFlags(*gateway, *token)
+	if err != nil {
+		return err
+	}
+	candidateChecker, err := policy.NewRegoChecker(os.DirFS(*candidate), strings.TrimSpace(*pkg), 0)
+	if err != nil {
+		return fmt.Errorf("candidate: %w", err)
+	}
+	var currentChecker policy.Checker
+	switch {
+	case strings.TrimSpace(*opaURL) != "":
+		currentChecker = &policy.PolicyService{
+			OPAURL:        strings.TrimRight(*opaURL, "/"),
+			PolicyPackage: strings.TrimSpace(*pkg),
+			HTTPClient:    &http.Client{Timeout: time.Duration(*timeoutMS) * time.Millisecond},
+		}
+	case strings.TrimSpace(*current) != "":
+		currentChecker, err = policy.NewRegoChecker(os.DirFS(*current), strings.TrimSpace(*pkg), 0)
+	default:
+		currentChecker, err = policy.NewEmbeddedChecker(config.PolicyConfig{PolicyPackage: strings.TrimSpace(*pkg)})
+	}
+	if err != nil {
+		return fmt.Errorf("current: %w", err)
+	}
+
+	from := time.Now().UTC().AddDate(0, 0, -*days).Truncate(time.Second)
+	report := SimulationReport{From: from.Format(time.RFC3339), Flips: []SimulationFlip{}}
+	groups := map[[4]string]*SimulationFlip{}
+	seen := map[string]bool{}
+	for _, tenantID := range tenantIDs {
+		events, err := client.listPolicyAuditEvents(context.Background(), tenantID, from)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			if ev.PolicyInput == nil || seen[ev.EventID] {
+				continue
+			}
+			seen[ev.EventID] = true
+			report.Replayed++
+			before, err := currentChecker.Evaluate(*ev.PolicyInput)
+			if err != nil {
+				report.Errors = append(report.Errors, SimulationError{EventID: ev.EventID, Error: "current: " + err.Error()})
+				continue
+			}
+			after, err := candidateChecker.Evaluate(*ev.PolicyInput)
+			if err != nil {
+				report.Errors = append(report.Errors, SimulationError{EventID: ev.EventID, Error: "candidate: " + err.Error()})
+				continue
+			}
+			if before.Decision == after.Decision {
+				report.Unchanged++
+				continue
+			}
+			report.Flipped++
+			key := [4]string{tenantID, ev.Action, before.Decision, after.Decision}
+			group, ok := groups[key]
+			if !ok {
+				group = &SimulationFlip{TenantID: tenantID, Action: ev.Action, Current: before.Decision, Candidate: after.Decision}
+				groups[key] = group
+			}
+			group.Count++
+			group.EventIDs = append(group.EventIDs, ev.EventID)
+		}
+	}
+	for _, group := range groups {
+		report.Flips = append(report.Flips, *group)
+	}
+	sort.Slice(report.Flips, func(i, j int) bool {
+		a, b := report.Flips[i], report.Flips[j]
+		if a.TenantID != b.TenantID {
+			return a.TenantID < b.TenantID
+		}
+		if a.Action != b.Action {
+			return a.Action < b.Action
+		}
+		if a.Current != b.Current {
+			return a.Current < b.Current
+		}
+		return a.Candidate < b.Candidate
+	})
+	if *format == "json" {
+		return json.NewEncoder(out).Encode(report)
+	}
+	writeSimulationReport(out, report)
+	return nil
+}
+
+// listPolicyAuditEvents pages through the tenant's audit events since from
+// that were recorded with their policy input.
+func (c *gatewayClient) listPolicyAuditEvents(ctx context.Context, tenantID string, from time.Time) ([]simulatedEvent, error) {
+	var events []simulatedEvent
+	for offset := 0; ; {
+		query := url.Values{}
+		query.Set("from", from.Format(time.RFC3339))
+		query.Set("has_policy_input", "true")
+		query.Set("limit", strconv.Itoa(simulatePageSize))
+		query.Set("offset", strconv.Itoa(offset))
+		data, err := c.doRequestWithTenant(ctx, http.MethodGet, "/v1/audit/events?"+query.Encode(), nil, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Data       []simulatedEvent `json:"data"`
+			Pagination struct {
+				Total int `json:"total"`
+			} `json:"pagination"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page.Data...)
+		offset += len(page.Data)
+		if len(page.Data) == 0 || offset >= page.Pagination.Total {
+			return events, nil
+		}
+	}
+}
+
+func writeSimulationReport(out io.Writer, report SimulationReport) {
+	_, _ = fmt.Fprintf(out, "replayed %d decisions since %s: %d flipped, %d unchanged, %d errors\n",
+		report.Replayed, report.From, report.Flipped, report.Unchanged, len(report.Errors))
+	if len(report.Flips) > 0 {
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "TENANT\tACTION\tCURRENT\tCANDIDATE\tCOUNT\tEVENTS")
+		for _, f := range report.Flips {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", f.TenantID, f.Action, f.Current, f.Candidate, f.Count, strings.Join(f.EventIDs, ","))
+		}
+		_ = tw.Flush()
+	}
+	for _, e := range report.Errors {
+		_, _ = fmt.Fprintf(out, "error %s: %s\n", e.EventID, e.Error)
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
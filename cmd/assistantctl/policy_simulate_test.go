@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// auditGateway serves the given events one per page, as the gateway would
+// for has_policy_input=true.
+func auditGateway(t *testing.T, events []map[string]any) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audit/events" || r.URL.Query().Get("has_policy_input") != "true" || r.URL.Query().Get("from") == "" {
+			t.Errorf("request: %s", r.URL.String())
+		}
+		if r.Header.Get("X-Tenant-Id") != "t1" {
+			t.Errorf("tenant: %q", r.Header.Get("X-Tenant-Id"))
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		page := []map[string]any{}
+		if offset < len(events) {
+			page = events[offset : offset+1]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": page, "pagination": map[string]any{"limit": 1, "offset": offset, "total": len(events)}})
+	}))
+}
+
+func writeCandidate(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(src), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return dir
+}
+
+func TestRunPolicySimulateReportsFlips(t *testing.T) {
+	read := map[string]any{"actor": map[string]any{"id": "a"}, "action": map[string]any{"name": "plan.list", "type": "read"}, "risk": map[string]any{"level": "read"}}
+	write := map[string]any{"actor": map[string]any{"id": "a", "roles": []string{"operator"}}, "action": map[string]any{"name": "plan.create", "type": "write"}, "context": map[string]any{"environment": "dev"}, "risk": map[string]any{"level": "low", "targets": 1, "blast_radius": "service"}}
+	ts := auditGateway(t, []map[string]any{
+		{"event_id": "audit_1", "action": "plan.list", "policy_input": read},
+		{"event_id": "audit_2", "action": "plan.create", "policy_input": write},
+		{"event_id": "audit_3", "action": "plan.create", "policy_input": write},
+	})
+	defer ts.Close()
+	// The candidate requires approval for every write.
+	candidate := writeCandidate(t, `package policy.assistant.v1
+
+decision := "allow" if {
+	input.action.type == "read"
+} else := "require_approval"
+`)
+
+	var buf bytes.Buffer
+	err := run([]string{"policy", "simulate", "-gateway", ts.URL, "-tenant-id", "t1", "-candidate", candidate, "-format", "json"}, &buf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var report SimulationReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output: %s", buf.String())
+	}
+	if report.Replayed != 3 || report.Flipped != 2 || report.Unchanged != 1 || len(report.Flips) != 1 {
+		t.Fatalf("report: %#v", report)
+	}
+	flip := report.Flips[0]
+	if flip.TenantID != "t1" || flip.Action != "plan.create" || flip.Current != "allow" || flip.Candidate != "require_approval" || flip.Count != 2 {
+		t.Fatalf("flip: %#v", flip)
+	}
+
+	buf.Reset()
+	if err := run([]string{"policy", "simulate", "-gateway", ts.URL, "-tenant-id", "t1", "-candidate", candidate}, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(buf.String(), "2 flipped") || !strings.Contains(buf.String(), "audit_2,audit_3") {
+		t.Fatalf("output: %s", buf.String())
+	}
+}
+
+func TestRunPolicySimulateRecordsEvaluationErrors(t *testing.T) {
+	ts := auditGateway(t, []map[string]any{{"event_id": "audit_1", "action": "plan.list", "policy_input": map[string]any{}}})
+	defer ts.Close()
+	candidate := writeCandidate(t, "package other\n\ndecision := \"allow\"\n")
+	var buf bytes.Buffer
+	err := run([]string{"policy", "simulate", "-gateway", ts.URL, "-tenant-id", "t1", "-candidate", candidate, "-format", "json"}, &buf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var report SimulationReport
+	_ = json.Unmarshal(buf.Bytes(), &report)
+	if len(report.Errors) != 1 || !strings.HasPrefix(report.Errors[0].Error, "candidate:") {
+		t.Fatalf("report: %s", buf.String())
+	}
+}
+
+func TestRunPolicySimulateValidation(t *testing.T) {
+	candidate := writeCandidate(t, "package policy.assistant.v1\n\ndecision := \"allow\"\n")
+	cases := [][]string{
+		{"-tenant-id", "t1", "-gateway", "http://gw"},
+		{"-candidate", candidate, "-gateway", "http://gw"},
+		{"-candidate", candidate, "-tenant-id", "t1"},
+		{"-candidate", candidate, "-tenant-id", "t1", "-gateway", "http://gw", "-days", "0"},
+		{"-candidate", candidate, "-tenant-id", "t1", "-gateway", "http://gw", "-format", "yaml"},
+		{"-candidate", candidate, "-tenant-id", "t1", "-gateway", "http://gw", "-current", candidate, "-opa-url", "http://opa"},
+		{"-candidate", t.TempDir(), "-tenant-id", "t1", "-gateway", "http://gw"},
+	}
+	for _, args := range cases {
+		var buf bytes.Buffer
+		if err := run(append([]string{"policy", "simulate"}, args...), &buf); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
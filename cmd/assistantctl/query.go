@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"carapulse/internal/web"
+)
+
+func runQuery(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	question := fs.String("question", "", "what you want to see, in plain language")
+	language := fs.String("language", "", "promql or traceql (default: let the model choose)")
+	service := fs.String("service", "", "service the question is about")
+	contextValue := fs.String("context", "", "context json or @file")
+	start := fs.String("start", "", "range start (RFC3339); with --end runs a range query")
+	end := fs.String("end", "", "range end (RFC3339)")
+	step := fs.String("step", "", "range step (default 60s)")
+	dryRun := fs.Bool("dry-run", false, "only translate, do not run the query")
+	format := fs.String("format", "text", "output format: text or json")
+	gateway := fs.String("gateway", "", "gateway base url")
+	token := fs.String("token", "", "gateway token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*question) == "" && fs.NArg() > 0 {
+		*question = strings.Join(fs.Args(), " ")
+	}
+	if strings.TrimSpace(*question) == "" {
+		return errors.New("question required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+	var ctxRef web.ContextRef
+	if err := parseJSONInput(*contextValue, &ctxRef); err != nil {
+		return err
+	}
+	client, err := gatewayClientFromFlags(*gateway, *token)
+	if err != nil {
+		return err
+	}
+	req := web.QueryRequest{
+		Question: *question,
+		Language: *language,
+		Service:  *service,
+		Context:  ctxRef,
+		Start:    *start,
+		End:      *end,
+		Step:     *step,
+		DryRun:   *dryRun,
+	}
+	if *format == "json" {
+		data, err := client.doRequest(context.Background(), http.MethodPost, "/v1/query", req)
+		if err != nil {
+			return err
+		}
+		_, _ = out.Write(data)
+		return nil
+	}
+	var resp web.QueryResponse
+	if err := client.doJSON(context.Background(), http.MethodPost, "/v1/query", req, &resp); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "%s: %s\n", resp.Language, resp.Query)
+	if resp.Explanation != "" {
+		_, _ = fmt.Fprintf(out, "# %s\n", resp.Explanation)
+	}
+	if len(resp.Result) > 0 {
+		_, _ = fmt.Fprintf(out, "%s\n", resp.Result)
+	}
+	return nil
+}
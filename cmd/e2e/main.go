@@ -75,6 +75,8 @@ func run(args []string, out io.Writer) error {
 	traceID := fs.String("trace-id", envOr("E2E_TRACE_ID", ""), "trace id")
 	secretPath := fs.String("secret-path", envOr("E2E_SECRET_PATH", ""), "vault lease id for rotation")
 	annotation := fs.String("annotation", envOr("E2E_ANNOTATION", ""), "grafana annotation text")
+	alertName := fs.String("alert-name", envOr("E2E_ALERT_NAME", "E2EHighErrorRate"), "alertname for the alert flow")
+	alertService := fs.String("alert-service", envOr("E2E_ALERT_SERVICE", ""), "service label for the alert flow")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -139,12 +141,18 @@ func run(args []string, out io.Writer) error {
 		TraceID:         strings.TrimSpace(*traceID),
 		SecretPath:      strings.TrimSpace(*secretPath),
 		Annotation:      strings.TrimSpace(*annotation),
+		AlertName:       strings.TrimSpace(*alertName),
+		AlertService:    strings.TrimSpace(*alertService),
 	})
 	if err != nil {
 		return err
 	}
 	for _, flow := range flows {
-		planID, execID, err := c.startWorkflow(context.Background(), flow, ctxRef)
+		start := c.startWorkflow
+		if flow.Name == "alert" {
+			start = c.fireAlert
+		}
+		planID, execID, err := start(context.Background(), flow, ctxRef)
 		if err != nil {
 			return err
 		}
@@ -186,6 +194,8 @@ type workflowInputs struct {
 	TraceID         string
 	SecretPath      string
 	Annotation      string
+	AlertName       string
+	AlertService    string
 }
 
 func buildWorkflowRuns(names []string, inputs workflowInputs) ([]workflowRun, error) {
@@ -282,6 +292,14 @@ func buildWorkflowRuns(names []string, inputs workflowInputs) ([]workflowRun, er
 			if inputs.Annotation != "" {
 				input["annotation"] = inputs.Annotation
 			}
+		case "alert":
+			if inputs.AlertName == "" {
+				return nil, errors.New("alert-name required for alert")
+			}
+			input["alertname"] = inputs.AlertName
+			if inputs.AlertService != "" {
+				input["service"] = inputs.AlertService
+			}
 		default:
 			return nil, fmt.Errorf("unknown workflow: %s", name)
 		}
@@ -315,6 +333,46 @@ func (c *client) startWorkflow(ctx context.Context, flow workflowRun, ctxRef web
 	return out.PlanID, out.ExecutionID, nil
 }
 
+// fireAlert posts a firing Alertmanager webhook for the alert flow. The
+// gateway must run its event loop so the hook answers with the plan it
+// drafted; with llm.cassette in replay mode the draft comes from recorded
+// model responses.
+func (c *client) fireAlert(ctx context.Context, flow workflowRun, ctxRef web.ContextRef) (string, string, error) {
+	labels := map[string]any{
+		"tenant_id":      ctxRef.TenantID,
+		"environment":    ctxRef.Environment,
+		"cluster_id":     ctxRef.ClusterID,
+		"namespace":      ctxRef.Namespace,
+		"aws_account_id": ctxRef.AWSAccountID,
+		"region":         ctxRef.Region,
+		"argocd_project": ctxRef.ArgoCDProject,
+		"grafana_org_id": ctxRef.GrafanaOrgID,
+		"severity":       "critical",
+	}
+	for key, value := range flow.Input {
+		labels[key] = value
+	}
+	payload := map[string]any{
+		"status": "firing",
+		"alerts": []any{map[string]any{"status": "firing", "labels": labels}},
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/v1/hooks/alertmanager", payload)
+	if err != nil {
+		return "", "", err
+	}
+	var out struct {
+		PlanID      string `json:"plan_id"`
+		ExecutionID string `json:"execution_id"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(out.PlanID) == "" {
+		return "", "", errors.New("missing plan_id (enable gateway.enable_event_loop for alertmanager)")
+	}
+	return out.PlanID, out.ExecutionID, nil
+}
+
 func (c *client) approve(ctx context.Context, planID string) error {
 	payload := map[string]any{
 		"plan_id": planID,
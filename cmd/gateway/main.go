@@ -10,11 +10,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"carapulse/internal/approvals"
+	"carapulse/internal/attest"
+	"carapulse/internal/chatops"
 	"carapulse/internal/config"
 	ctxmodel "carapulse/internal/context"
 	"carapulse/internal/context/collectors"
@@ -23,6 +26,8 @@ import (
 	"carapulse/internal/logging"
 	"carapulse/internal/metrics"
 	"carapulse/internal/policy"
+	"carapulse/internal/retrieval"
+	"carapulse/internal/risk"
 	"carapulse/internal/storage"
 	"carapulse/internal/tools"
 	"carapulse/internal/web"
@@ -31,6 +36,8 @@ import (
 )
 
 const defaultPolicyPackage = "policy.assistant.v1"
+const defaultLLMMaxRepairs = 2
+const defaultLLMCassetteTimeout = 2 * time.Minute
 
 func main() {
 	logging.Init("gateway", nil)
@@ -53,6 +60,9 @@ var newLLMRouter = func(cfg config.LLMConfig) *llm.Router {
 		APIKey:         cfg.APIKey,
 		Model:          cfg.Model,
 		APIBase:        cfg.APIBase,
+		APIPath:        cfg.APIPath,
+		APIVersion:     cfg.APIVersion,
+		Region:         cfg.Region,
 		MaxTokens:      cfg.MaxOutputTokens,
 		AuthProfile:    cfg.AuthProfile,
 		AuthPath:       cfg.AuthPath,
@@ -61,8 +71,194 @@ var newLLMRouter = func(cfg config.LLMConfig) *llm.Router {
 	if cfg.TimeoutMS > 0 {
 		router.HTTPClient = &http.Client{Timeout: time.Duration(cfg.TimeoutMS) * time.Millisecond}
 	}
+	if cfg.Cassette.Mode != "" {
+		base := router.HTTPClient
+		if base == nil {
+			base = &http.Client{Timeout: defaultLLMCassetteTimeout}
+		}
+		client, err := llm.NewCassetteClient(cfg.Cassette.Mode, cfg.Cassette.Dir, cfg.RedactPatterns, base)
+		if err != nil {
+			slog.Error("llm cassette disabled", "error", err)
+		} else {
+			router.HTTPClient = client
+			// Replay never reaches the provider, but its client still
+			// insists on a credential.
+			if llm.IsCassetteReplay(client) && router.APIKey == "" && router.AuthProfile == "" {
+				router.APIKey = "cassette-replay"
+			}
+		}
+	}
+	router.ValidateStep = tools.ValidatePlanStep
+	router.PromptDir = cfg.PromptDir
+	router.PromptVersions = cfg.PromptVersions
+	router.EmbeddingModel = cfg.Retrieval.EmbeddingModel
+	router.MaxRepairs = cfg.MaxRepairs
+	if cfg.MaxRepairs == 0 {
+		router.MaxRepairs = defaultLLMMaxRepairs
+	}
+	if cfg.MaxRetryWaitMS > 0 {
+		router.MaxRetryWait = time.Duration(cfg.MaxRetryWaitMS) * time.Millisecond
+	}
+	for _, fb := range cfg.Fallbacks {
+		router.Fallbacks = append(router.Fallbacks, llm.Fallback{
+			Provider:    fb.Provider,
+			Model:       fb.Model,
+			APIBase:     fb.APIBase,
+			APIPath:     fb.APIPath,
+			APIVersion:  fb.APIVersion,
+			Region:      fb.Region,
+			APIKey:      fb.APIKey,
+			AuthProfile: fb.AuthProfile,
+		})
+	}
+	if len(cfg.Pricing) > 0 {
+		router.Pricing = map[string]llm.Price{}
+		for model, price := range cfg.Pricing {
+			router.Pricing[model] = llm.Price{PromptPer1K: price.PromptPer1K, CompletionPer1K: price.CompletionPer1K}
+		}
+	}
 	return router
 }
+
+// newCriticRouter builds the plan critic's router from the primary
+// settings and the critic overrides. The critic has no fallbacks.
+func newCriticRouter(cfg config.LLMConfig) *llm.Router {
+	critic := cfg.Critic
+	out := cfg
+	out.Fallbacks = nil
+	if p := strings.TrimSpace(critic.Provider); p != "" && !strings.EqualFold(p, cfg.Provider) {
+		out.Provider = p
+		out.APIBase, out.APIPath, out.APIVersion, out.Region, out.APIKey, out.AuthProfile = "", "", "", "", "", ""
+	}
+	if critic.Model != "" {
+		out.Model = critic.Model
+	}
+	if critic.APIBase != "" {
+		out.APIBase = critic.APIBase
+	}
+	if critic.APIKey != "" {
+		out.APIKey = critic.APIKey
+	}
+	if critic.AuthProfile != "" {
+		out.AuthProfile = critic.AuthProfile
+	}
+	return newLLMRouter(out)
+}
+
+// approvalQuorum converts the configured per-risk-level quorums.
+func approvalQuorum(cfg config.ApprovalsConfig) map[string]web.QuorumRule {
+	if len(cfg.Quorum) == 0 {
+		return nil
+	}
+	out := make(map[string]web.QuorumRule, len(cfg.Quorum))
+	for level, q := range cfg.Quorum {
+		out[strings.ToLower(level)] = web.QuorumRule{Required: q.Count, Groups: q.Groups}
+	}
+	return out
+}
+
+// approvalSigner builds the key approval attestations are signed with, or
+// nil when attestation is not configured.
+func approvalSigner(cfg config.Config) (attest.Signer, error) {
+	a := cfg.Approvals.Attestation
+	var vault attest.VaultClient
+	if strings.TrimSpace(a.VaultTransitKey) != "" {
+		vault = tools.BuildHTTPClients(cfg, tools.APIConfig{}).Vault
+	}
+	signer, _, err := attest.FromConfig(a, vault)
+	if err != nil {
+		return nil, err
+	}
+	if signer == nil && a.Enabled() {
+		return nil, errors.New("approvals.attestation: the gateway needs private_key_file or vault_transit_key to sign approvals")
+	}
+	return signer, nil
+}
+
+// approvalTTLs resolves approvals.ttl_hours per risk level. Levels without
+// an entry keep the Linear watcher's timeout_hours.
+func approvalTTLs(cfg config.Config) map[string]time.Duration {
+	out := map[string]time.Duration{}
+	for _, level := range []string{risk.LevelLow, risk.LevelMedium, risk.LevelHigh} {
+		hours := cfg.Connectors.Linear.TimeoutHours
+		for name, h := range cfg.Approvals.TTLHours {
+			if strings.EqualFold(name, level) && h > 0 {
+				hours = h
+			}
+		}
+		if hours > 0 {
+			out[level] = time.Duration(hours) * time.Hour
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// newApprovalSweeper expires pending approvals and, when configured,
+// reminds approvers over Slack and escalates to PagerDuty.
+func newApprovalSweeper(cfg config.Config, srv *web.Server, store web.ApprovalSweepStore) *web.ApprovalSweeper {
+	sweeper := web.NewApprovalSweeper(srv, store)
+	if r := cfg.Approvals.Reminders; r.IntervalMinutes > 0 {
+		sweeper.Notifier = chatops.NewSlackNotifier(cfg.ChatOps.SlackBotToken)
+		sweeper.RemindEvery = time.Duration(r.IntervalMinutes) * time.Minute
+		sweeper.SlackChannel = r.SlackChannel
+		sweeper.SlackChannels = r.SlackChannels
+	}
+	if e := cfg.Approvals.Escalation; strings.TrimSpace(e.PagerDutyRoutingKey) != "" {
+		sweeper.RoutingKey = e.PagerDutyRoutingKey
+		if e.IdleMinutes > 0 {
+			sweeper.EscalateAfter = time.Duration(e.IdleMinutes) * time.Minute
+		}
+	}
+	return sweeper
+}
+
+// linearApprovalStore applies what the Linear watcher sees to the plan the
+// way the approvals API would. Approvals are attested, since they never
+// pass through the API; a failed attestation is logged and the orchestrator
+// refuses the plan's writes until it is approved again. Expiries are
+// announced, and approvals of a plan that has already expired are ignored.
+type linearApprovalStore struct {
+	approvals.ApprovalStore
+	srv *web.Server
+}
+
+func (s linearApprovalStore) UpdateApprovalStatusByPlan(ctx context.Context, planID, status string) error {
+	switch status {
+	case "approved":
+		if s.srv.ApprovalExpired(ctx, planID) {
+			slog.Warn("ignoring approval of expired plan", "plan_id", planID)
+			return nil
+		}
+		if err := s.srv.AttestApproval(ctx, planID, web.Actor{ID: attest.SourceLinear}, attest.SourceLinear); err != nil {
+			slog.Warn("approval attestation failed", "plan_id", planID, "error", err)
+		}
+	case "expired":
+		return s.srv.ExpireApproval(ctx, planID, attest.SourceLinear)
+	}
+	return s.ApprovalStore.UpdateApprovalStatusByPlan(ctx, planID, status)
+}
+
+// ApprovalTTL gives the watcher the plan's per-risk-tier TTL.
+func (s linearApprovalStore) ApprovalTTL(ctx context.Context, planID string) time.Duration {
+	return s.srv.ApprovalTTL(ctx, planID)
+}
+
+// newRetriever indexes the server's documents for planning. Embeddings
+// come from router when an embedding model is configured.
+func newRetriever(cfg config.LLMRetrievalConfig, srv *web.Server, router *llm.Router) *retrieval.Retriever {
+	retriever := &retrieval.Retriever{Load: srv.RetrievalDocuments, TopK: cfg.TopK}
+	if cfg.RefreshMS > 0 {
+		retriever.Refresh = time.Duration(cfg.RefreshMS) * time.Millisecond
+	}
+	if router != nil && router.EmbeddingModel != "" {
+		retriever.Embedder = router
+	}
+	return retriever
+}
+
 var newPolicyService = func(cfg config.PolicyConfig) *policy.PolicyService {
 	pkg := cfg.PolicyPackage
 	if pkg == "" {
@@ -70,13 +266,17 @@ var newPolicyService = func(cfg config.PolicyConfig) *policy.PolicyService {
 	}
 	return &policy.PolicyService{OPAURL: cfg.OPAURL, PolicyPackage: pkg}
 }
+var newEmbeddedPolicy = func(cfg config.PolicyConfig) (policy.Checker, error) {
+	return policy.NewEmbeddedChecker(cfg)
+}
+var newBundlePolicy = func(ctx context.Context, cfg config.PolicyConfig, store policy.ObjectGetter) (*policy.BundleChecker, error) {
+	return policy.NewBundleChecker(ctx, cfg, store)
+}
 var newTemporalClient = func(cfg config.OrchestratorConfig) (client.Client, error) {
 	opts := client.Options{HostPort: cfg.TemporalAddr, Namespace: cfg.Namespace}
 	return client.Dial(opts)
 }
-var newObjectStore = func(cfg config.ObjectStoreConfig) *storage.ObjectStore {
-	return &storage.ObjectStore{Endpoint: cfg.Endpoint, Bucket: cfg.Bucket}
-}
+var newObjectStore = storage.New
 var newToolRouterClient = func(cfg config.ToolRouterConfig) *tools.RouterClient {
 	return &tools.RouterClient{BaseURL: cfg.BaseURL, Token: cfg.AuthToken}
 }
@@ -126,9 +326,6 @@ var newContextService = func(cfg config.Config, store ctxmodel.Store, router *to
 	var pollers []ctxmodel.Poller
 	var watchers []ctxmodel.Watcher
 
-	argo := &collectors.ArgoCDPoller{Base: base, Apps: cfg.Context.ArgoApps}
-	pollers = append(pollers, argo)
-
 	awsTags := []map[string]any{}
 	for key, values := range cfg.Context.AWSResourceTags {
 		if len(values) == 0 {
@@ -159,21 +356,85 @@ var newContextService = func(cfg config.Config, store ctxmodel.Store, router *to
 		var mappings []collectors.ServiceMapping
 		for _, svc := range cfg.Context.Services {
 			mappings = append(mappings, collectors.ServiceMapping{
-				Service:     svc.Service,
-				Environment: svc.Environment,
-				ClusterID:   svc.ClusterID,
-				Namespace:   svc.Namespace,
-				PromQL:      svc.PromQL,
-				TraceQL:     svc.TraceQL,
-				Dashboards:  svc.Dashboards,
+				Service:      svc.Service,
+				Environment:  svc.Environment,
+				ClusterID:    svc.ClusterID,
+				Namespace:    svc.Namespace,
+				PromQL:       svc.PromQL,
+				TraceQL:      svc.TraceQL,
+				Dashboards:   svc.Dashboards,
+				OwnerTeam:    svc.OwnerTeam,
+				Criticality:  svc.Criticality,
+				TrafficShare: svc.TrafficShare,
+				DependsOn:    svc.DependsOn,
 			})
 		}
-		pollers = append(pollers, &collectors.StaticMappingPoller{Base: base, Mappings: mappings})
+		pollers = append(pollers, &collectors.StaticMappingPoller{Base: base, Mappings: mappings, ScopeByCluster: len(cfg.Connectors.K8s.Clusters) > 0})
 	}
 	if extra, err := collectors.LoadServiceMappings(cfg.Storage.WorkspaceDir); err == nil && len(extra) > 0 {
-		pollers = append(pollers, &collectors.StaticMappingPoller{Base: base, Mappings: extra})
+		pollers = append(pollers, &collectors.StaticMappingPoller{Base: base, Mappings: extra, ScopeByCluster: len(cfg.Connectors.K8s.Clusters) > 0})
 	}
 
+	// Cluster-local collectors fan out across every registered cluster;
+	// without a registry they run once against the default kubeconfig.
+	clusterIDs := tools.BuildClusterRegistry(cfg.Connectors.K8s).IDs()
+	if len(clusterIDs) == 0 {
+		p, w := clusterCollectors(cfg, base)
+		pollers = append(pollers, p...)
+		watchers = append(watchers, w...)
+	}
+	for _, clusterID := range clusterIDs {
+		clusterBase := base
+		clusterBase.Context.ClusterID = clusterID
+		clusterBase.Labels = map[string]string{}
+		for k, v := range base.Labels {
+			clusterBase.Labels[k] = v
+		}
+		clusterBase.Labels["cluster_id"] = clusterID
+		p, w := clusterCollectors(cfg, clusterBase)
+		for _, poller := range p {
+			pollers = append(pollers, &collectors.ClusterPoller{Poller: poller, ClusterID: clusterID})
+		}
+		for _, watcher := range w {
+			watchers = append(watchers, &collectors.ClusterWatcher{Watcher: watcher, ClusterID: clusterID})
+		}
+	}
+	if cfg.Context.WatchIntervalSecs > 0 {
+		interval := time.Duration(cfg.Context.WatchIntervalSecs) * time.Second
+		for _, poller := range pollers {
+			if poller == nil {
+				continue
+			}
+			if _, ok := poller.(*collectors.K8sPoller); ok {
+				continue
+			}
+			if scoped, ok := poller.(*collectors.ClusterPoller); ok {
+				if _, ok := scoped.Poller.(*collectors.K8sPoller); ok {
+					continue
+				}
+			}
+			watchers = append(watchers, &collectors.PollingWatcher{Poller: poller, Interval: interval})
+		}
+	}
+	svc := ctxmodel.NewWithStore(store)
+	svc.Pollers = pollers
+	svc.Watchers = watchers
+	if cfg.Context.PollIntervalSecs > 0 {
+		svc.PollInterval = time.Duration(cfg.Context.PollIntervalSecs) * time.Second
+	}
+	if cfg.Context.SnapshotIntervalSecs > 0 {
+		svc.SnapshotInterval = time.Duration(cfg.Context.SnapshotIntervalSecs) * time.Second
+	}
+	return svc
+}
+
+// clusterCollectors builds the ArgoCD, Helm and Kubernetes collectors for the
+// cluster named by base.Context.
+func clusterCollectors(cfg config.Config, base collectors.Base) ([]ctxmodel.Poller, []ctxmodel.Watcher) {
+	var pollers []ctxmodel.Poller
+	var watchers []ctxmodel.Watcher
+	pollers = append(pollers, &collectors.ArgoCDPoller{Base: base, Apps: cfg.Context.ArgoApps})
+
 	helmNamespaces := cfg.Context.HelmNamespaces
 	if len(helmNamespaces) == 0 && cfg.Context.Namespace != "" {
 		helmNamespaces = []string{cfg.Context.Namespace}
@@ -211,28 +472,7 @@ var newContextService = func(cfg config.Config, store ctxmodel.Store, router *to
 			AllowBookmarks:    allowBookmarks,
 		})
 	}
-	if cfg.Context.WatchIntervalSecs > 0 {
-		interval := time.Duration(cfg.Context.WatchIntervalSecs) * time.Second
-		for _, poller := range pollers {
-			if poller == nil {
-				continue
-			}
-			if _, ok := poller.(*collectors.K8sPoller); ok {
-				continue
-			}
-			watchers = append(watchers, &collectors.PollingWatcher{Poller: poller, Interval: interval})
-		}
-	}
-	svc := ctxmodel.NewWithStore(store)
-	svc.Pollers = pollers
-	svc.Watchers = watchers
-	if cfg.Context.PollIntervalSecs > 0 {
-		svc.PollInterval = time.Duration(cfg.Context.PollIntervalSecs) * time.Second
-	}
-	if cfg.Context.SnapshotIntervalSecs > 0 {
-		svc.SnapshotInterval = time.Duration(cfg.Context.SnapshotIntervalSecs) * time.Second
-	}
-	return svc
+	return pollers, watchers
 }
 
 var startScheduler = func(ctx context.Context, wg *sync.WaitGroup, gt *web.GoroutineTracker, s *web.Scheduler) {
@@ -245,6 +485,16 @@ var startScheduler = func(ctx context.Context, wg *sync.WaitGroup, gt *web.Gorou
 	gt.Go(ctx, wg, "scheduler", func(ctx context.Context) error { return s.Run(ctx) })
 }
 
+var startApprovalSweeper = func(ctx context.Context, wg *sync.WaitGroup, gt *web.GoroutineTracker, s *web.ApprovalSweeper) {
+	if s == nil {
+		return
+	}
+	if gt == nil {
+		gt = web.NewGoroutineTracker()
+	}
+	gt.Go(ctx, wg, "approval-sweeper", func(ctx context.Context) error { return s.Run(ctx) })
+}
+
 var approvalRunContext = func(ctx context.Context) context.Context { return ctx }
 var approvalRun = func(ctx context.Context, w *approvals.Watcher) error { return w.Run(ctx) }
 var startApprovalWatcher = func(ctx context.Context, wg *sync.WaitGroup, gt *web.GoroutineTracker, client approvals.ApprovalClient, store approvals.ApprovalStore, cfg config.LinearConfig) {
@@ -314,8 +564,26 @@ func run(args []string, serve func(*http.Server) error) error {
 		}
 	}
 
+	objectStore, err := newObjectStore(cfg.Storage.ObjectStore)
+	if err != nil {
+		return err
+	}
 	evaluator := &policy.Evaluator{}
-	if cfg.Policy.OPAURL != "" {
+	var bundleChecker *policy.BundleChecker
+	switch mode := strings.TrimSpace(cfg.Policy.Mode); {
+	case strings.EqualFold(mode, policy.ModeEmbedded):
+		checker, err := newEmbeddedPolicy(cfg.Policy)
+		if err != nil {
+			return err
+		}
+		evaluator.Checker = checker
+	case strings.EqualFold(mode, policy.ModeBundle):
+		bundleChecker, err = newBundlePolicy(ctx, cfg.Policy, objectStore)
+		if err != nil {
+			return err
+		}
+		evaluator.Checker = bundleChecker
+	case cfg.Policy.OPAURL != "":
 		evaluator.Checker = newPolicyService(cfg.Policy)
 	}
 	if cfg.Gateway.OIDCIssuer != "" || cfg.Gateway.OIDCClientID != "" || cfg.Gateway.OIDCJWKSURL != "" || cfg.Gateway.DevMode {
@@ -329,7 +597,12 @@ func run(args []string, serve func(*http.Server) error) error {
 	if cfg.Gateway.JWKSCacheTTLSecs > 0 {
 		web.SetJWKSCacheTTL(time.Duration(cfg.Gateway.JWKSCacheTTLSecs) * time.Second)
 	}
+	scorer, err := risk.NewScorer(cfg.Risk)
+	if err != nil {
+		return err
+	}
 	srv := newServer(database, evaluator)
+	srv.Risk = scorer
 	srv.Goroutines = web.NewGoroutineTracker()
 	srv.TemporalHealth = func(ctx context.Context) error {
 		if temporalClient == nil {
@@ -340,8 +613,16 @@ func run(args []string, serve func(*http.Server) error) error {
 	}
 
 	var wg sync.WaitGroup
+	if bundleChecker != nil {
+		srv.Goroutines.Go(ctx, &wg, "policy-bundle", bundleChecker.Run)
+	}
 
 	srv.AutoApproveLow = cfg.Approvals.AutoApproveLow
+	srv.ApprovalQuorum = approvalQuorum(cfg.Approvals)
+	srv.ApprovalTTLs = approvalTTLs(cfg)
+	if srv.Attestations, err = approvalSigner(cfg); err != nil {
+		return err
+	}
 	srv.Approvals = approvalsClient
 	srv.EnableEventLoop = cfg.Gateway.EnableEventLoop
 	srv.EventLoopSources = cfg.Gateway.EventLoopSources
@@ -369,9 +650,10 @@ func run(args []string, serve func(*http.Server) error) error {
 	if cfg.ToolRouter.BaseURL != "" {
 		srv.Diagnostics = &web.ToolDiagnostics{
 			Router:     newToolRouterClient(cfg.ToolRouter),
-			Store:      newObjectStore(cfg.Storage.ObjectStore),
+			Store:      objectStore,
 			PresignTTL: 15 * time.Minute,
 		}
+		srv.ToolRouter = newToolRouterClient(cfg.ToolRouter)
 	}
 	if cfg.Gateway.EnableEventLoop && cfg.ToolRouter.BaseURL != "" && cfg.Connectors.Alertmanager.Addr != "" {
 		poller := &web.AlertPoller{
@@ -437,20 +719,40 @@ func run(args []string, serve func(*http.Server) error) error {
 			TaskQueue: cfg.Orchestrator.TaskQueue,
 		}
 	}
-	if cfg.Storage.ObjectStore.Bucket != "" {
-		srv.ObjectStore = newObjectStore(cfg.Storage.ObjectStore)
+	if objectStore.Enabled() {
+		srv.ObjectStore = objectStore
 	}
 	if cfg.LLM.Provider != "" {
-		srv.Planner = newLLMRouter(cfg.LLM)
+		router := newLLMRouter(cfg.LLM)
+		srv.Planner = router
+		srv.Reporter = router
+		srv.QueryTranslator = router
+		if cfg.LLM.Retrieval.Enabled {
+			srv.Retriever = newRetriever(cfg.LLM.Retrieval, srv, router)
+		}
+		if cfg.LLM.Critic.Enabled {
+			srv.Critic = newCriticRouter(cfg.LLM)
+		}
+		srv.LLMBudget = web.LLMBudget{DailyTokens: cfg.LLM.Budgets.DailyTokens, DailyCostUSD: cfg.LLM.Budgets.DailyCostUSD}
+		if len(cfg.LLM.Budgets.Tenants) > 0 {
+			srv.TenantLLMBudgets = map[string]web.LLMBudget{}
+			for tenant, budget := range cfg.LLM.Budgets.Tenants {
+				srv.TenantLLMBudgets[tenant] = web.LLMBudget{DailyTokens: budget.DailyTokens, DailyCostUSD: budget.DailyCostUSD}
+			}
+		}
 	}
 	if approvalsClient != nil && database != nil {
-		startApprovalWatcher(ctx, &wg, srv.Goroutines, approvalsClient, database, linearCfg)
+		startApprovalWatcher(ctx, &wg, srv.Goroutines, approvalsClient, linearApprovalStore{ApprovalStore: database, srv: srv}, linearCfg)
+	}
+	if database != nil {
+		startApprovalSweeper(ctx, &wg, srv.Goroutines, newApprovalSweeper(cfg, srv, database))
 	}
 	if database != nil {
 		seedWorkflowCatalog(context.Background(), database)
 	}
 	if cfg.Scheduler.Enabled && database != nil {
 		scheduler := web.NewScheduler(database)
+		scheduler.Risk = scorer
 		if cfg.Scheduler.PollIntervalSecs > 0 {
 			scheduler.PollInterval = time.Duration(cfg.Scheduler.PollIntervalSecs) * time.Second
 		}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/pem"
 	"errors"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -129,6 +134,12 @@ func TestRunSetsPlanner(t *testing.T) {
 	if _, ok := captured.Planner.(*llm.Router); !ok {
 		t.Fatalf("planner type: %#v", captured.Planner)
 	}
+	if _, ok := captured.Reporter.(*llm.Router); !ok {
+		t.Fatalf("reporter: %#v", captured.Reporter)
+	}
+	if _, ok := captured.QueryTranslator.(*llm.Router); !ok {
+		t.Fatalf("query translator: %#v", captured.QueryTranslator)
+	}
 }
 
 func TestNewLLMRouterTimeout(t *testing.T) {
@@ -157,6 +168,65 @@ func TestNewLLMRouterTimeout(t *testing.T) {
 	}
 }
 
+func TestNewLLMRouterCassette(t *testing.T) {
+	cfg := config.LLMConfig{Provider: "openai", Model: "gpt", TimeoutMS: 1500, Cassette: config.LLMCassetteConfig{Mode: "replay", Dir: t.TempDir()}}
+	router := newLLMRouter(cfg)
+	cassette, ok := router.HTTPClient.Transport.(*llm.Cassette)
+	if !ok || cassette.Mode != llm.CassetteReplay || router.HTTPClient.Timeout != 1500*time.Millisecond {
+		t.Fatalf("client: %#v", router.HTTPClient)
+	}
+	if _, err := router.Plan("restart checkout", nil, nil); err == nil || !strings.Contains(err.Error(), "no recording") {
+		t.Fatalf("expected cassette miss, got %v", err)
+	}
+	critic := newCriticRouter(cfg)
+	if _, ok := critic.HTTPClient.Transport.(*llm.Cassette); !ok {
+		t.Fatalf("critic client: %#v", critic.HTTPClient)
+	}
+}
+
+func TestNewLLMRouterFallbacksAndPricing(t *testing.T) {
+	cfg := config.LLMConfig{
+		Provider:       "openai",
+		Model:          "gpt-4o",
+		MaxRetryWaitMS: 2000,
+		Fallbacks:      []config.LLMFallbackConfig{{Provider: "anthropic", Model: "claude-3-5-sonnet-latest", APIKey: "k"}},
+		Pricing:        map[string]config.LLMPriceConfig{"gpt-4o": {PromptPer1K: 0.005, CompletionPer1K: 0.015}},
+		PromptVersions: map[string]int{"alert": 1},
+	}
+	router := newLLMRouter(cfg)
+	if router.MaxRetryWait != 2*time.Second {
+		t.Fatalf("max retry wait: %v", router.MaxRetryWait)
+	}
+	if len(router.Fallbacks) != 1 || router.Fallbacks[0].Provider != "anthropic" || router.Fallbacks[0].APIKey != "k" {
+		t.Fatalf("fallbacks: %#v", router.Fallbacks)
+	}
+	if router.Pricing["gpt-4o"].CompletionPer1K != 0.015 {
+		t.Fatalf("pricing: %#v", router.Pricing)
+	}
+	if router.PromptVersions["alert"] != 1 {
+		t.Fatalf("prompt versions: %#v", router.PromptVersions)
+	}
+	if router.MaxRepairs != defaultLLMMaxRepairs || router.ValidateStep == nil {
+		t.Fatalf("repairs: %d", router.MaxRepairs)
+	}
+	cfg.MaxRepairs = -1
+	if router := newLLMRouter(cfg); router.MaxRepairs >= 0 {
+		t.Fatalf("repairs should be disabled: %d", router.MaxRepairs)
+	}
+}
+
+func TestNewRetriever(t *testing.T) {
+	srv := &web.Server{}
+	router := newLLMRouter(config.LLMConfig{Provider: "openai", Retrieval: config.LLMRetrievalConfig{EmbeddingModel: "text-embedding-3-small"}})
+	retriever := newRetriever(config.LLMRetrievalConfig{TopK: 4, RefreshMS: 500}, srv, router)
+	if retriever.TopK != 4 || retriever.Refresh != 500*time.Millisecond || retriever.Load == nil || retriever.Embedder == nil {
+		t.Fatalf("retriever: %#v", retriever)
+	}
+	if retriever := newRetriever(config.LLMRetrievalConfig{}, srv, newLLMRouter(config.LLMConfig{Provider: "openai"})); retriever.Embedder != nil {
+		t.Fatalf("embedder without model: %#v", retriever.Embedder)
+	}
+}
+
 func TestNewPolicyServiceDefaultPackage(t *testing.T) {
 	service := newPolicyService(config.PolicyConfig{OPAURL: "http://opa"})
 	if service.PolicyPackage != defaultPolicyPackage {
@@ -411,3 +481,170 @@ func TestRunNewDBError(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestNewCriticRouter(t *testing.T) {
+	cfg := config.LLMConfig{
+		Provider:  "openai",
+		Model:     "gpt-4o",
+		APIKey:    "primary",
+		APIBase:   "https://openai.example",
+		Fallbacks: []config.LLMFallbackConfig{{Model: "gpt-4o-mini"}},
+		Critic:    config.LLMCriticConfig{Enabled: true, Model: "o3-mini"},
+	}
+	critic := newCriticRouter(cfg)
+	if critic.Provider != "openai" || critic.Model != "o3-mini" || critic.APIKey != "primary" || critic.APIBase != "https://openai.example" || len(critic.Fallbacks) != 0 {
+		t.Fatalf("critic: %#v", critic)
+	}
+	cfg.Critic = config.LLMCriticConfig{Enabled: true, Provider: "anthropic", Model: "claude-3-5-sonnet-latest"}
+	critic = newCriticRouter(cfg)
+	if critic.Provider != "anthropic" || critic.APIKey != "" || critic.APIBase != "" {
+		t.Fatalf("critic: %#v", critic)
+	}
+}
+
+func TestRunBundlePolicyWiring(t *testing.T) {
+	registerFakeDriver()
+	file := t.TempDir() + "/cfg.json"
+	data := `{"gateway":{"http_addr":":9090"},"policy":{"mode":"bundle","bundle":{"source":"object","object_ref":"s3://b/policy.tar.gz","public_key_file":"/key.pem"}},"orchestrator":{"temporal_addr":"t","namespace":"n","task_queue":"q"},"storage":{"postgres_dsn":"dsn","object_store":{"endpoint":"e","bucket":"b"}}}`
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	oldBundle := newBundlePolicy
+	oldServer := newServer
+	oldTemporal := newTemporalClient
+	defer func() {
+		newBundlePolicy = oldBundle
+		newServer = oldServer
+		newTemporalClient = oldTemporal
+	}()
+	newTemporalClient = func(cfg config.OrchestratorConfig) (client.Client, error) { return nil, nil }
+	checker := &policy.BundleChecker{}
+	newBundlePolicy = func(ctx context.Context, cfg config.PolicyConfig, store policy.ObjectGetter) (*policy.BundleChecker, error) {
+		if cfg.Bundle.ObjectRef != "s3://b/policy.tar.gz" || store == nil {
+			t.Fatalf("bundle config: %#v store: %v", cfg.Bundle, store)
+		}
+		return checker, nil
+	}
+	newServer = func(database web.DBWriter, evaluator *policy.Evaluator) *web.Server {
+		if evaluator.Checker != checker {
+			t.Fatalf("checker: %T", evaluator.Checker)
+		}
+		return web.NewServer(database, evaluator)
+	}
+
+	if err := run([]string{"-config", file}, func(srv *http.Server) error { return nil }); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	newBundlePolicy = func(ctx context.Context, cfg config.PolicyConfig, store policy.ObjectGetter) (*policy.BundleChecker, error) {
+		return nil, errors.New("bundle rejected")
+	}
+	if err := run([]string{"-config", file}, func(srv *http.Server) error { return nil }); err == nil {
+		t.Fatalf("expected bundle error")
+	}
+}
+
+func TestRunEmbeddedPolicyWiring(t *testing.T) {
+	registerFakeDriver()
+	file := t.TempDir() + "/cfg.json"
+	data := `{"gateway":{"http_addr":":9090"},"policy":{"mode":"embedded","opa_url":"http://opa"},"orchestrator":{"temporal_addr":"t","namespace":"n","task_queue":"q"},"storage":{"postgres_dsn":"dsn","object_store":{"endpoint":"e","bucket":"b"}}}`
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	oldPolicy := newPolicyService
+	oldServer := newServer
+	oldTemporal := newTemporalClient
+	defer func() {
+		newPolicyService = oldPolicy
+		newServer = oldServer
+		newTemporalClient = oldTemporal
+	}()
+	newTemporalClient = func(cfg config.OrchestratorConfig) (client.Client, error) { return nil, nil }
+	newPolicyService = func(cfg config.PolicyConfig) *policy.PolicyService {
+		t.Fatalf("remote policy used in embedded mode")
+		return nil
+	}
+	newServer = func(database web.DBWriter, evaluator *policy.Evaluator) *web.Server {
+		if _, ok := evaluator.Checker.(*policy.RegoChecker); !ok {
+			t.Fatalf("checker: %T", evaluator.Checker)
+		}
+		return web.NewServer(database, evaluator)
+	}
+
+	if err := run([]string{"-config", file}, func(srv *http.Server) error { return nil }); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestApprovalQuorum(t *testing.T) {
+	if got := approvalQuorum(config.ApprovalsConfig{}); got != nil {
+		t.Fatalf("expected nil: %#v", got)
+	}
+	got := approvalQuorum(config.ApprovalsConfig{Quorum: map[string]config.QuorumConfig{"High": {Count: 2, Groups: []string{"sre"}}}})
+	if rule := got["high"]; rule.Required != 2 || len(rule.Groups) != 1 || rule.Groups[0] != "sre" {
+		t.Fatalf("quorum: %#v", got)
+	}
+}
+
+type statusRecorder struct{ status string }
+
+func (s *statusRecorder) UpdateApprovalStatusByPlan(ctx context.Context, planID, status string) error {
+	s.status = status
+	return nil
+}
+
+func TestApprovalSigner(t *testing.T) {
+	if signer, err := approvalSigner(config.Config{}); signer != nil || err != nil {
+		t.Fatalf("unconfigured: %v %v", signer, err)
+	}
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	path := t.TempDir() + "/k1.pem"
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg := config.Config{}
+	cfg.Approvals.Attestation.PublicKeyFiles = map[string]string{"k1": path}
+	if _, err := approvalSigner(cfg); err == nil || !strings.Contains(err.Error(), "to sign approvals") {
+		t.Fatalf("expected error without a signing key, got %v", err)
+	}
+
+	// Without a signer the Linear watcher's approvals pass straight through.
+	inner := &statusRecorder{}
+	store := linearApprovalStore{ApprovalStore: inner, srv: &web.Server{}}
+	if err := store.UpdateApprovalStatusByPlan(context.Background(), "plan_1", "approved"); err != nil || inner.status != "approved" {
+		t.Fatalf("status=%s err=%v", inner.status, err)
+	}
+}
+
+func TestApprovalTTLs(t *testing.T) {
+	if got := approvalTTLs(config.Config{}); got != nil {
+		t.Fatalf("expected nil: %#v", got)
+	}
+	cfg := config.Config{}
+	cfg.Connectors.Linear.TimeoutHours = 12
+	cfg.Approvals.TTLHours = map[string]int{"High": 2}
+	got := approvalTTLs(cfg)
+	if got["high"] != 2*time.Hour || got["medium"] != 12*time.Hour || got["low"] != 12*time.Hour {
+		t.Fatalf("ttls: %#v", got)
+	}
+}
+
+func TestNewApprovalSweeper(t *testing.T) {
+	cfg := config.Config{}
+	cfg.ChatOps.SlackBotToken = "xoxb-1"
+	cfg.Approvals.Reminders = config.ReminderConfig{IntervalMinutes: 45, SlackChannels: map[string]string{"sre": "#sre"}}
+	cfg.Approvals.Escalation = config.EscalationConfig{PagerDutyRoutingKey: "R0UT1NG", IdleMinutes: 10}
+	sweeper := newApprovalSweeper(cfg, &web.Server{}, nil)
+	if sweeper.Notifier == nil || sweeper.RemindEvery != 45*time.Minute || sweeper.SlackChannels["sre"] != "#sre" {
+		t.Fatalf("reminders: %+v", sweeper)
+	}
+	if sweeper.RoutingKey != "R0UT1NG" || sweeper.EscalateAfter != 10*time.Minute {
+		t.Fatalf("escalation: %+v", sweeper)
+	}
+	if plain := newApprovalSweeper(config.Config{}, &web.Server{}, nil); plain.Notifier != nil || plain.RoutingKey != "" {
+		t.Fatalf("unconfigured sweeper: %+v", plain)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"syscall"
 	"time"
 
+	"carapulse/internal/attest"
 	"carapulse/internal/config"
 	"carapulse/internal/db"
 	"carapulse/internal/logging"
@@ -39,9 +40,7 @@ var fatalf = func(format string, args ...any) {
 }
 var loadConfig = config.LoadConfig
 var newDB = db.NewDB
-var newObjectStore = func(cfg config.ObjectStoreConfig) *storage.ObjectStore {
-	return &storage.ObjectStore{Endpoint: cfg.Endpoint, Bucket: cfg.Bucket}
-}
+var newObjectStore = storage.New
 var newTemporalClient = func(cfg config.OrchestratorConfig) (client.Client, error) {
 	opts := client.Options{HostPort: cfg.TemporalAddr, Namespace: cfg.Namespace}
 	return client.Dial(opts)
@@ -70,6 +69,10 @@ var startWorker = func(rt *workflows.Runtime, store workflows.ExecutionStore, ob
 	if cfg.Orchestrator.TemporalAddr == "" {
 		return errors.New("orchestrator.temporal_addr required")
 	}
+	_, attestations, err := attest.FromConfig(cfg.Approvals.Attestation, vaultClient(rt))
+	if err != nil {
+		return err
+	}
 	w, closer, err := newWorker(cfg.Orchestrator)
 	if err != nil {
 		return err
@@ -77,7 +80,7 @@ var startWorker = func(rt *workflows.Runtime, store workflows.ExecutionStore, ob
 	if closer != nil {
 		defer func() { _ = closer.Close() }()
 	}
-	acts := &workflows.Activities{Store: store, Runtime: rt, Objects: obj}
+	acts := &workflows.Activities{Store: store, Runtime: rt, Objects: obj, Attestations: attestations}
 	w.RegisterWorkflow(workflows.PlanExecutionWorkflow)
 	w.RegisterWorkflowWithOptions(workflows.GitOpsDeployWorkflowTemporal, workflow.RegisterOptions{Name: "GitOpsDeployWorkflow"})
 	w.RegisterWorkflowWithOptions(workflows.HelmReleaseWorkflowTemporal, workflow.RegisterOptions{Name: "HelmReleaseWorkflow"})
@@ -89,6 +92,14 @@ var startWorker = func(rt *workflows.Runtime, store workflows.ExecutionStore, ob
 	return runWorker(w)
 }
 
+// vaultClient is the runtime's Vault client, for transit attestation keys.
+func vaultClient(rt *workflows.Runtime) attest.VaultClient {
+	if rt == nil || rt.Clients.Vault == nil {
+		return nil
+	}
+	return rt.Clients.Vault
+}
+
 var startVaultAgent = secrets.StartVaultAgent
 var openBoundarySession = func(ctx context.Context, router *tools.Router, sandbox *tools.Sandbox, clients tools.HTTPClients, targetID, duration string, ctxRef tools.ContextRef) (string, error) {
 	resp, err := router.Execute(ctx, tools.ExecuteRequest{
@@ -216,6 +227,15 @@ func run(args []string) error {
 		patterns = tools.DefaultRedactPatterns()
 	}
 	router.Redactor = tools.NewRedactor(patterns)
+	router.Clusters = tools.BuildClusterRegistry(cfg.Connectors.K8s)
+	credentials, err := tools.BuildCredentialBroker(cfg, clients.Vault)
+	if err != nil {
+		return err
+	}
+	if credentials != nil {
+		credentials.Audit = database
+		router.Credentials = credentials
+	}
 	sandbox := tools.NewSandboxWithConfig(cfg.Sandbox.Enabled, cfg.Sandbox.Runtime, cfg.Sandbox.Image, cfg.Sandbox.EgressAllowlist, cfg.Sandbox.Mounts)
 	sandbox.Enforce = cfg.Sandbox.Enforce
 	sandbox.ReadOnlyRoot = cfg.Sandbox.ReadOnlyRoot
@@ -339,5 +359,10 @@ func run(args []string) error {
 	}
 	rt := workflows.NewRuntime(router, sandbox, clients)
 	rt.Redactor = router.Redactor
-	return startWorker(rt, database, newObjectStore(cfg.Storage.ObjectStore), cfg)
+	objectStore, err := newObjectStore(cfg.Storage.ObjectStore)
+	if err != nil {
+		return err
+	}
+	tools.SetObjectStore(objectStore)
+	return startWorker(rt, database, objectStore, cfg)
 }
@@ -197,6 +197,20 @@ func TestStartWorkerDefault(t *testing.T) {
 	}
 }
 
+func TestStartWorkerAttestationKeyError(t *testing.T) {
+	oldWorker := newWorker
+	defer func() { newWorker = oldWorker }()
+	newWorker = func(cfg config.OrchestratorConfig) (worker.Worker, io.Closer, error) {
+		t.Fatalf("worker started without attestation keys")
+		return nil, nil, nil
+	}
+	cfg := config.Config{Orchestrator: config.OrchestratorConfig{TemporalAddr: "t", TaskQueue: "q"}}
+	cfg.Approvals.Attestation.PublicKeyFiles = map[string]string{"k1": t.TempDir() + "/missing.pem"}
+	if err := startWorker(&workflows.Runtime{}, &db.DB{}, &storage.ObjectStore{}, cfg); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestMainFatalOnError(t *testing.T) {
 	oldFatal := fatalf
 	called := false
@@ -16,10 +16,12 @@ import (
 	"time"
 
 	"carapulse/internal/config"
+	"carapulse/internal/db"
 	"carapulse/internal/logging"
 	"carapulse/internal/metrics"
 	"carapulse/internal/policy"
 	"carapulse/internal/secrets"
+	"carapulse/internal/storage"
 	"carapulse/internal/tools"
 )
 
@@ -39,7 +41,14 @@ var loadConfig = config.LoadConfig
 var newPolicyService = func(cfg config.PolicyConfig) *policy.PolicyService {
 	return &policy.PolicyService{OPAURL: cfg.OPAURL, PolicyPackage: cfg.PolicyPackage}
 }
+var newEmbeddedPolicy = func(cfg config.PolicyConfig) (policy.Checker, error) {
+	return policy.NewEmbeddedChecker(cfg)
+}
+var newBundlePolicy = func(ctx context.Context, cfg config.PolicyConfig, store policy.ObjectGetter) (*policy.BundleChecker, error) {
+	return policy.NewBundleChecker(ctx, cfg, store)
+}
 var startVaultAgent = secrets.StartVaultAgent
+var newAuditDB = db.NewDB
 
 const defaultMaxOutputBytes = 1_000_000
 
@@ -60,6 +69,11 @@ func run(args []string, serve func(*http.Server) error) error {
 		return err
 	}
 	tools.SetWorkspaceDir(cfg.Storage.WorkspaceDir)
+	objectStore, err := storage.New(cfg.Storage.ObjectStore)
+	if err != nil {
+		return err
+	}
+	tools.SetObjectStore(objectStore)
 	addr := cfg.ToolRouter.HTTPAddr
 	if addr == "" {
 		addr = ":8081"
@@ -70,6 +84,9 @@ func run(args []string, serve func(*http.Server) error) error {
 		patterns = tools.DefaultRedactPatterns()
 	}
 	router.Redactor = tools.NewRedactor(patterns)
+	router.Clusters = tools.BuildClusterRegistry(cfg.Connectors.K8s)
+	router.Cache = tools.BuildResultCache(cfg.ToolRouter.Cache)
+	router.Guard = tools.BuildBackendGuard(cfg.ToolRouter.Breakers)
 	sandbox := tools.NewSandboxWithConfig(cfg.Sandbox.Enabled, cfg.Sandbox.Runtime, cfg.Sandbox.Image, cfg.Sandbox.EgressAllowlist, cfg.Sandbox.Mounts)
 	maxOutput := cfg.Sandbox.MaxOutputBytes
 	if maxOutput == 0 {
@@ -104,6 +121,7 @@ func run(args []string, serve func(*http.Server) error) error {
 		GrafanaOrgID:     cfg.Connectors.Grafana.OrgID,
 		EgressAllowlist:  cfg.Sandbox.EgressAllowlist,
 		MaxOutputBytes:   maxOutput,
+		Guard:            router.Guard,
 		Tokens: map[string]string{
 			"prometheus":   cfg.Connectors.Prometheus.Token,
 			"alertmanager": cfg.Connectors.Alertmanager.Token,
@@ -118,6 +136,21 @@ func run(args []string, serve func(*http.Server) error) error {
 			"aws":          cfg.Connectors.AWS.Token,
 		},
 	})
+	credentials, err := tools.BuildCredentialBroker(cfg, clients.Vault)
+	if err != nil {
+		return err
+	}
+	if credentials != nil {
+		// Brokered credentials are audited alongside gateway events.
+		auditDB, err := newAuditDB(cfg.Storage.PostgresDSN)
+		if err != nil {
+			slog.Warn("credential audit store unavailable", "error", err)
+		} else {
+			defer auditDB.Close()
+			credentials.Audit = auditDB
+		}
+		router.Credentials = credentials
+	}
 	if cfg.Connectors.Vault.Addr != "" && cfg.Connectors.Vault.SinkPath != "" {
 		templateSource, templateDest := secrets.ResolveTemplatePaths(cfg.Connectors.Vault.TemplateDir, cfg.Connectors.Vault.TemplateSource, cfg.Connectors.Vault.TemplateDest)
 		agentCfg, err := secrets.BuildVaultAgentConfigFromConnectors(
@@ -240,7 +273,23 @@ func run(args []string, serve func(*http.Server) error) error {
 		Audience: cfg.ToolRouter.OIDCClientID,
 		JWKSURL:  cfg.ToolRouter.OIDCJWKSURL,
 	}
-	if cfg.Policy.OPAURL != "" {
+	switch mode := strings.TrimSpace(cfg.Policy.Mode); {
+	case strings.EqualFold(mode, policy.ModeEmbedded):
+		checker, err := newEmbeddedPolicy(cfg.Policy)
+		if err != nil {
+			return err
+		}
+		server.Policy = &policy.Evaluator{Checker: checker}
+	case strings.EqualFold(mode, policy.ModeBundle):
+		checker, err := newBundlePolicy(ctx, cfg.Policy, objectStore)
+		if err != nil {
+			return err
+		}
+		server.Policy = &policy.Evaluator{Checker: checker}
+		go func() {
+			_ = checker.Run(ctx)
+		}()
+	case cfg.Policy.OPAURL != "":
 		server.Policy = &policy.Evaluator{Checker: newPolicyService(cfg.Policy)}
 	}
 
@@ -288,12 +337,23 @@ func run(args []string, serve func(*http.Server) error) error {
 			}
 		}
 
+		// Open breakers are reported but don't fail readiness: the router
+		// still serves every other backend.
+		breakers := router.Guard.States()
 		if ok {
+			if len(breakers) == 0 {
+				_, _ = w.Write([]byte(`{"status":"ok"}`))
+				return
+			}
+			if data, err := json.Marshal(map[string]any{"status": "ok", "breakers": breakers}); err == nil {
+				_, _ = w.Write(data)
+				return
+			}
 			_, _ = w.Write([]byte(`{"status":"ok"}`))
 			return
 		}
 		w.WriteHeader(http.StatusServiceUnavailable)
-		if data, err := json.Marshal(map[string]any{"status": "unavailable", "checks": checks}); err == nil {
+		if data, err := json.Marshal(map[string]any{"status": "unavailable", "checks": checks, "breakers": breakers}); err == nil {
 			_, _ = w.Write(data)
 			return
 		}
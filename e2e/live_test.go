@@ -526,10 +526,14 @@ func TestLiveEndpoints(t *testing.T) {
 		if planID == "" {
 			t.Skip("no plan created")
 		}
+		// The plan author cannot approve their own plan.
+		authorToken := token
+		token = devJWT("approver", "approver@carapulse.dev")
 		r := doReq("POST", "/v1/approvals", map[string]any{
 			"plan_id": planID,
 			"status":  "approved",
 		})
+		token = authorToken
 		expectStatus(r, 200)
 		t.Logf("Approval response: %s", r.Body)
 	})
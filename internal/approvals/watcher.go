@@ -10,6 +10,13 @@ type ApprovalStore interface {
 	UpdateApprovalStatusByPlan(ctx context.Context, planID, status string) error
 }
 
+// ApprovalTTLSource is implemented by stores that know how long each
+// plan's approval may stay pending, e.g. by risk level. The watcher falls
+// back to Timeout for plans without a TTL.
+type ApprovalTTLSource interface {
+	ApprovalTTL(ctx context.Context, planID string) time.Duration
+}
+
 type Watcher struct {
 	Client       ApprovalClient
 	Store        ApprovalStore
@@ -92,7 +99,7 @@ func (w *Watcher) syncOnce(ctx context.Context) error {
 		if !ok {
 			continue
 		}
-		if status == "pending" && issue.CreatedAt.Before(now.Add(-w.Timeout)) {
+		if status == "pending" && issue.CreatedAt.Before(now.Add(-w.timeout(ctx, planID))) {
 			if err := w.Client.UpdateApprovalStatus(ctx, issue.ID, "expired"); err != nil {
 				return err
 			}
@@ -111,3 +118,12 @@ func (w *Watcher) syncOnce(ctx context.Context) error {
 	}
 	return nil
 }
+
+func (w *Watcher) timeout(ctx context.Context, planID string) time.Duration {
+	if source, ok := w.Store.(ApprovalTTLSource); ok {
+		if ttl := source.ApprovalTTL(ctx, planID); ttl > 0 {
+			return ttl
+		}
+	}
+	return w.Timeout
+}
@@ -158,6 +158,36 @@ func TestWatcherSyncOnceExpired(t *testing.T) {
 	}
 }
 
+// ttlStore gives each plan its own approval TTL.
+type ttlStore struct {
+	fakeApprovalStore
+	ttls map[string]time.Duration
+}
+
+func (s *ttlStore) ApprovalTTL(ctx context.Context, planID string) time.Duration {
+	return s.ttls[planID]
+}
+
+func TestWatcherSyncOncePerPlanTTL(t *testing.T) {
+	now := time.Now()
+	client := &fakeApprovalClient{issues: []Issue{
+		{ID: "issue_1", Title: "Approval required: plan_1", Labels: []string{labelPending}, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "issue_2", Title: "Approval required: plan_2", Labels: []string{labelPending}, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "issue_3", Title: "Approval required: plan_3", Labels: []string{labelPending}, CreatedAt: now.Add(-3 * time.Hour)},
+	}}
+	store := &ttlStore{ttls: map[string]time.Duration{"plan_1": 2 * time.Hour, "plan_2": 72 * time.Hour}}
+	w := NewWatcher(client, store)
+	w.Now = func() time.Time { return now }
+	w.Timeout = time.Hour
+	w.last = map[string]string{}
+	if err := w.syncOnce(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(store.updates) != 2 || store.updates[0] != "plan_1:expired" || store.updates[1] != "plan_3:expired" {
+		t.Fatalf("store updates: %v", store.updates)
+	}
+}
+
 func TestWatcherSyncOnceDuplicate(t *testing.T) {
 	now := time.Now()
 	client := &fakeApprovalClient{issues: []Issue{{
@@ -0,0 +1,291 @@
+// Package attest signs and verifies approval attestations: an approver's
+// decision bound to the exact plan revision they saw, so that an approval
+// can be checked without trusting the database it is stored in.
+package attest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Version is the statement format this package writes.
+const Version = 1
+
+// Sources an approval can come from.
+const (
+	SourceAPI    = "api"
+	SourceAuto   = "auto"
+	SourceLinear = "linear"
+)
+
+// ErrUnknownKey is returned by a Verifier that does not hold the key an
+// attestation names.
+var ErrUnknownKey = errors.New("attest: unknown key")
+
+// Step is a plan step as covered by the plan hash. Its JSON shape matches
+// the gateway's plan steps field for field; PlanHash depends on it.
+type Step struct {
+	StepID        string `json:"step_id"`
+	Stage         string `json:"stage"`
+	Action        string `json:"action"`
+	Tool          string `json:"tool"`
+	Input         any    `json:"input"`
+	Preconditions []any  `json:"preconditions"`
+	Rollback      any    `json:"rollback"`
+}
+
+// Approver is who approved, as the gateway authenticated them.
+type Approver struct {
+	ID     string   `json:"id"`
+	Email  string   `json:"email,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Statement is what an attestation signs.
+type Statement struct {
+	Version    int       `json:"version"`
+	PlanID     string    `json:"plan_id"`
+	PlanHash   string    `json:"plan_hash"`
+	Intent     string    `json:"intent"`
+	Steps      []Step    `json:"steps"`
+	Context    any       `json:"context"`
+	Approver   Approver  `json:"approver"`
+	Decision   string    `json:"decision"`
+	Source     string    `json:"source"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// Attestation is a signed statement. Signature is the base64 ed25519
+// signature of Payload() by the key KeyID names.
+type Attestation struct {
+	Statement
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"`
+}
+
+// Signer signs attestation payloads and names the key it used.
+type Signer interface {
+	Sign(ctx context.Context, payload []byte) (keyID string, sig []byte, err error)
+}
+
+// Verifier checks a signature made by the key keyID names. It returns
+// ErrUnknownKey when it does not hold that key.
+type Verifier interface {
+	Verify(ctx context.Context, keyID string, payload, sig []byte) error
+}
+
+// PlanHash is the SHA-256 over a plan's intent and steps that approvals
+// are bound to; steps without a stage count as "act".
+func PlanHash(intent string, steps []Step) string {
+	normalized := make([]Step, len(steps))
+	copy(normalized, steps)
+	for i := range normalized {
+		if strings.TrimSpace(normalized[i].Stage) == "" {
+			normalized[i].Stage = "act"
+		}
+	}
+	canonical := struct {
+		Intent string `json:"intent"`
+		Steps  []Step `json:"steps"`
+	}{Intent: intent, Steps: normalized}
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Payload is the canonical encoding of s: JSON with object keys sorted at
+// every level, so a statement read back from any JSON store signs the same.
+func (s Statement) Payload() ([]byte, error) {
+	return canonicalJSON(s)
+}
+
+// Covers reports whether step is one of the statement's steps, compared by
+// ID, tool, action, input and rollback.
+func (s Statement) Covers(step Step) bool {
+	want, err := canonicalJSON([]any{step.StepID, step.Tool, step.Action, step.Input, step.Rollback})
+	if err != nil {
+		return false
+	}
+	for _, candidate := range s.Steps {
+		got, err := canonicalJSON([]any{candidate.StepID, candidate.Tool, candidate.Action, candidate.Input, candidate.Rollback})
+		if err == nil && bytes.Equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// SameContext reports whether ctx is the context the statement covers.
+func (s Statement) SameContext(ctx any) bool {
+	want, err := canonicalJSON(s.Context)
+	if err != nil {
+		return false
+	}
+	got, err := canonicalJSON(ctx)
+	return err == nil && bytes.Equal(got, want)
+}
+
+// Sign signs st with signer.
+func Sign(ctx context.Context, signer Signer, st Statement) (Attestation, error) {
+	if signer == nil {
+		return Attestation{}, errors.New("attest: signer required")
+	}
+	if st.Version == 0 {
+		st.Version = Version
+	}
+	payload, err := st.Payload()
+	if err != nil {
+		return Attestation{}, err
+	}
+	keyID, sig, err := signer.Sign(ctx, payload)
+	if err != nil {
+		return Attestation{}, fmt.Errorf("attest: sign: %w", err)
+	}
+	return Attestation{Statement: st, KeyID: keyID, Signature: base64.StdEncoding.EncodeToString(sig)}, nil
+}
+
+// Verify checks a's signature with verifier and that its plan hash matches
+// the intent and steps it carries.
+func Verify(ctx context.Context, verifier Verifier, a Attestation) error {
+	if verifier == nil {
+		return errors.New("attest: verifier required")
+	}
+	if a.Version != Version {
+		return fmt.Errorf("attest: unsupported version %d", a.Version)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(a.Signature))
+	if err != nil {
+		return fmt.Errorf("attest: signature: %w", err)
+	}
+	payload, err := a.Payload()
+	if err != nil {
+		return err
+	}
+	if err := verifier.Verify(ctx, a.KeyID, payload, sig); err != nil {
+		return err
+	}
+	if hash := PlanHash(a.Intent, a.Steps); hash != a.PlanHash {
+		return fmt.Errorf("attest: plan hash %s does not match the attested steps (%s)", a.PlanHash, hash)
+	}
+	return nil
+}
+
+// Parse reads one attestation, keeping numbers exactly as written.
+func Parse(data []byte) (Attestation, error) {
+	var a Attestation
+	if err := decode(data, &a); err != nil {
+		return Attestation{}, fmt.Errorf("attest: parse: %w", err)
+	}
+	return a, nil
+}
+
+// ParseList reads a JSON array of attestations; null reads as none.
+func ParseList(data []byte) ([]Attestation, error) {
+	var list []Attestation
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	if err := decode(data, &list); err != nil {
+		return nil, fmt.Errorf("attest: parse: %w", err)
+	}
+	return list, nil
+}
+
+func decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func canonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := decode(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// LocalKey signs with an ed25519 private key held in process.
+type LocalKey struct {
+	ID  string
+	Key ed25519.PrivateKey
+}
+
+// NewLocalKey returns a LocalKey named keyID, or by KeyIDFor when keyID is
+// empty.
+func NewLocalKey(keyID string, key ed25519.PrivateKey) LocalKey {
+	if strings.TrimSpace(keyID) == "" {
+		keyID = KeyIDFor(key.Public().(ed25519.PublicKey))
+	}
+	return LocalKey{ID: strings.TrimSpace(keyID), Key: key}
+}
+
+func (k LocalKey) Sign(ctx context.Context, payload []byte) (string, []byte, error) {
+	if len(k.Key) != ed25519.PrivateKeySize {
+		return "", nil, errors.New("invalid ed25519 private key")
+	}
+	return k.ID, ed25519.Sign(k.Key, payload), nil
+}
+
+// KeyIDFor names a public key by a digest of it.
+func KeyIDFor(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return "ed25519:" + hex.EncodeToString(sum[:8])
+}
+
+// PublicKeys verifies with ed25519 public keys by key ID.
+type PublicKeys map[string]ed25519.PublicKey
+
+func (p PublicKeys) Verify(ctx context.Context, keyID string, payload, sig []byte) error {
+	pub, ok := p[keyID]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return errors.New("attest: signature does not match")
+	}
+	return nil
+}
+
+// Verifiers tries each verifier in turn until one holds the key.
+type Verifiers []Verifier
+
+func (v Verifiers) Verify(ctx context.Context, keyID string, payload, sig []byte) error {
+	for _, verifier := range v {
+		err := verifier.Verify(ctx, keyID, payload, sig)
+		if !errors.Is(err, ErrUnknownKey) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+}
+
+// ParsePublicKey reads an ed25519 public key as PEM (PKIX) or as the bare
+// base64 key Vault's transit engine exports.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("-----BEGIN")) {
+		raw, err := base64.StdEncoding.DecodeString(string(trimmed))
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, errors.New("attest: public key is neither PEM nor a base64 ed25519 key")
+		}
+		return ed25519.PublicKey(raw), nil
+	}
+	return parsePEMPublicKey(trimmed)
+}
@@ -0,0 +1,207 @@
+package attest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"carapulse/internal/config"
+)
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	return priv
+}
+
+func statement() Statement {
+	steps := []Step{
+		{StepID: "s1", Action: "scale", Tool: "kubectl", Input: map[string]any{"replicas": float64(3), "deployment": "api"}},
+		{StepID: "s2", Stage: "verify", Action: "rollout-status", Tool: "kubectl", Input: map[string]any{"deployment": "api"}},
+	}
+	return Statement{
+		PlanID:     "plan_1",
+		PlanHash:   PlanHash("scale api", steps),
+		Intent:     "scale api",
+		Steps:      steps,
+		Context:    map[string]any{"environment": "prod", "cluster_id": "c1"},
+		Approver:   Approver{ID: "alice", Email: "alice@example.com", Groups: []string{"sre"}},
+		Decision:   "approved",
+		Source:     SourceAPI,
+		ApprovedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	key := NewLocalKey("", newKey(t))
+	if !strings.HasPrefix(key.ID, "ed25519:") {
+		t.Fatalf("key id: %s", key.ID)
+	}
+	a, err := Sign(context.Background(), key, statement())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if a.Version != Version || a.KeyID != key.ID {
+		t.Fatalf("attestation: %+v", a)
+	}
+	keys := PublicKeys{key.ID: key.Key.Public().(ed25519.PublicKey)}
+
+	// Stores re-encode JSON with their own key order and spacing; the
+	// signature must survive that.
+	raw, _ := json.Marshal(a)
+	var generic map[string]any
+	_ = json.Unmarshal(raw, &generic)
+	reencoded, _ := json.MarshalIndent(generic, "", "  ")
+	parsed, err := Parse(reencoded)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := Verify(context.Background(), keys, parsed); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !parsed.Covers(Step{StepID: "s1", Action: "scale", Tool: "kubectl", Input: map[string]any{"deployment": "api", "replicas": 3}}) {
+		t.Fatalf("step not covered")
+	}
+	if parsed.Covers(Step{StepID: "s1", Action: "scale", Tool: "kubectl", Input: map[string]any{"deployment": "api", "replicas": 30}}) {
+		t.Fatalf("changed step covered")
+	}
+	if !parsed.SameContext(map[string]any{"cluster_id": "c1", "environment": "prod"}) || parsed.SameContext(map[string]any{"environment": "dev"}) {
+		t.Fatalf("context comparison")
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	key := NewLocalKey("k1", newKey(t))
+	keys := PublicKeys{"k1": key.Key.Public().(ed25519.PublicKey)}
+	a, err := Sign(context.Background(), key, statement())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	approver := a
+	approver.Approver.ID = "mallory"
+	if err := Verify(context.Background(), keys, approver); err == nil {
+		t.Fatalf("expected signature mismatch for changed approver")
+	}
+
+	// Re-signing changed steps under the old hash is caught too.
+	steps := a
+	steps.Steps = append([]Step{}, a.Steps...)
+	steps.Steps[0].Input = map[string]any{"replicas": float64(30), "deployment": "api"}
+	resigned, err := Sign(context.Background(), key, steps.Statement)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := Verify(context.Background(), keys, resigned); err == nil || !strings.Contains(err.Error(), "plan hash") {
+		t.Fatalf("expected plan hash mismatch, got %v", err)
+	}
+
+	other := NewLocalKey("k1", newKey(t))
+	forged, _ := Sign(context.Background(), other, statement())
+	if err := Verify(context.Background(), keys, forged); err == nil {
+		t.Fatalf("expected mismatch for other key")
+	}
+	forged.KeyID = "k2"
+	if err := Verify(context.Background(), keys, forged); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown key, got %v", err)
+	}
+}
+
+// fakeVault answers transit sign and key reads with a local key.
+type fakeVault struct {
+	key   ed25519.PrivateKey
+	paths []string
+}
+
+func (f *fakeVault) Do(ctx context.Context, method, path string, body any) ([]byte, error) {
+	f.paths = append(f.paths, method+" "+path)
+	switch {
+	case method == "POST" && path == "/v1/pki-transit/sign/approvals":
+		input, _ := base64.StdEncoding.DecodeString(body.(map[string]any)["input"].(string))
+		sig := ed25519.Sign(f.key, input)
+		return json.Marshal(map[string]any{"data": map[string]any{"signature": "vault:v2:" + base64.StdEncoding.EncodeToString(sig)}})
+	case method == "GET" && path == "/v1/pki-transit/keys/approvals":
+		pub := f.key.Public().(ed25519.PublicKey)
+		return json.Marshal(map[string]any{"data": map[string]any{"type": "ed25519", "keys": map[string]any{
+			"2": map[string]any{"public_key": base64.StdEncoding.EncodeToString(pub)},
+		}}})
+	}
+	return []byte(`{"errors":["unsupported path"]}`), nil
+}
+
+func TestTransitSignVerify(t *testing.T) {
+	vault := &fakeVault{key: newKey(t)}
+	transit := &Transit{Client: vault, Mount: "/pki-transit/", Key: "approvals"}
+	a, err := Sign(context.Background(), transit, statement())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if a.KeyID != "vault:pki-transit/approvals:v2" {
+		t.Fatalf("key id: %s", a.KeyID)
+	}
+	verifiers := Verifiers{PublicKeys{}, transit}
+	for i := 0; i < 2; i++ {
+		if err := Verify(context.Background(), verifiers, a); err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	}
+	if len(vault.paths) != 2 {
+		t.Fatalf("public key not cached: %v", vault.paths)
+	}
+	a.KeyID = "vault:pki-transit/approvals:v3"
+	if err := Verify(context.Background(), verifiers, a); err == nil || !strings.Contains(err.Error(), "no version 3") {
+		t.Fatalf("expected missing version, got %v", err)
+	}
+	// Offline, an auditor verifies with the key Vault exports.
+	pub, err := ParsePublicKey([]byte(base64.StdEncoding.EncodeToString(vault.key.Public().(ed25519.PublicKey))))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	a.KeyID = "vault:pki-transit/approvals:v2"
+	if err := Verify(context.Background(), PublicKeys{a.KeyID: pub}, a); err != nil {
+		t.Fatalf("offline verify: %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	priv := newKey(t)
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	privPath := filepath.Join(dir, "attest.pem")
+	_ = os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	old := newKey(t)
+	pubDER, _ := x509.MarshalPKIXPublicKey(old.Public())
+	pubPath := filepath.Join(dir, "old.pem")
+	_ = os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644)
+
+	if signer, verifier, err := FromConfig(config.AttestationConfig{}, nil); signer != nil || verifier != nil || err != nil {
+		t.Fatalf("unconfigured: %v %v %v", signer, verifier, err)
+	}
+	signer, verifier, err := FromConfig(config.AttestationConfig{KeyID: "k2", PrivateKeyFile: privPath, PublicKeyFiles: map[string]string{"k1": pubPath}}, nil)
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	current, _ := Sign(context.Background(), signer, statement())
+	retired, _ := Sign(context.Background(), NewLocalKey("k1", old), statement())
+	for _, a := range []Attestation{current, retired} {
+		if err := Verify(context.Background(), verifier, a); err != nil {
+			t.Fatalf("verify %s: %v", a.KeyID, err)
+		}
+	}
+	if _, _, err := FromConfig(config.AttestationConfig{VaultTransitKey: "approvals"}, nil); err == nil {
+		t.Fatalf("expected error without vault client")
+	}
+}
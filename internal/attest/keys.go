@@ -0,0 +1,248 @@
+package attest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"carapulse/internal/config"
+)
+
+// VaultClient is the part of the tool router's Vault API client the
+// transit engine needs.
+type VaultClient interface {
+	Do(ctx context.Context, method, path string, body any) ([]byte, error)
+}
+
+var readFile = os.ReadFile
+
+// FromConfig builds the signer and verifier cfg describes. Both are nil
+// when attestation is not configured; the signer is also nil for a
+// verify-only config (retired public keys alone).
+func FromConfig(cfg config.AttestationConfig, vault VaultClient) (Signer, Verifier, error) {
+	if !cfg.Enabled() {
+		return nil, nil, nil
+	}
+	var signer Signer
+	var verifiers Verifiers
+	if path := strings.TrimSpace(cfg.PrivateKeyFile); path != "" {
+		data, err := readFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := ParsePrivateKey(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		local := NewLocalKey(cfg.KeyID, key)
+		signer = local
+		verifiers = append(verifiers, PublicKeys{local.ID: key.Public().(ed25519.PublicKey)})
+	}
+	if name := strings.TrimSpace(cfg.VaultTransitKey); name != "" {
+		if vault == nil {
+			return nil, nil, errors.New("attest: vault client required for transit key")
+		}
+		transit := &Transit{Client: vault, Mount: cfg.VaultTransitMount, Key: name}
+		signer = transit
+		verifiers = append(verifiers, transit)
+	}
+	if len(cfg.PublicKeyFiles) > 0 {
+		retired := PublicKeys{}
+		for keyID, path := range cfg.PublicKeyFiles {
+			data, err := readFile(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			pub, err := ParsePublicKey(data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", keyID, err)
+			}
+			retired[keyID] = pub
+		}
+		verifiers = append(verifiers, retired)
+	}
+	return signer, verifiers, nil
+}
+
+// Transit signs and verifies with an ed25519 key in Vault's transit
+// engine. Key IDs are "vault:<mount>/<key>:v<version>"; public keys are
+// read from Vault once per version.
+type Transit struct {
+	Client VaultClient
+	Mount  string
+	Key    string
+
+	mu   sync.Mutex
+	keys map[int]ed25519.PublicKey
+}
+
+func (t *Transit) mount() string {
+	mount := strings.Trim(strings.TrimSpace(t.Mount), "/")
+	if mount == "" {
+		mount = "transit"
+	}
+	return mount
+}
+
+func (t *Transit) keyPrefix() string {
+	return fmt.Sprintf("vault:%s/%s:v", t.mount(), t.Key)
+}
+
+func (t *Transit) keyID(version int) string {
+	return t.keyPrefix() + strconv.Itoa(version)
+}
+
+type transitResponse struct {
+	Data struct {
+		Signature string                     `json:"signature"`
+		Type      string                     `json:"type"`
+		Keys      map[string]json.RawMessage `json:"keys"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+func (t *Transit) call(ctx context.Context, method, path string, body any) (transitResponse, error) {
+	var resp transitResponse
+	if t.Client == nil {
+		return resp, errors.New("vault client required")
+	}
+	data, err := t.Client.Do(ctx, method, "/v1/"+t.mount()+path, body)
+	if err != nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return resp, fmt.Errorf("vault transit: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return resp, fmt.Errorf("vault transit: %s", strings.Join(resp.Errors, "; "))
+	}
+	return resp, nil
+}
+
+func (t *Transit) Sign(ctx context.Context, payload []byte) (string, []byte, error) {
+	resp, err := t.call(ctx, "POST", "/sign/"+url.PathEscape(t.Key), map[string]any{
+		"input": base64.StdEncoding.EncodeToString(payload),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	// Transit signatures read "vault:v<version>:<base64>".
+	parts := strings.SplitN(resp.Data.Signature, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" || !strings.HasPrefix(parts[1], "v") {
+		return "", nil, errors.New("vault transit: unexpected signature format")
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil {
+		return "", nil, errors.New("vault transit: unexpected signature version")
+	}
+	sig, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, fmt.Errorf("vault transit: signature: %w", err)
+	}
+	return t.keyID(version), sig, nil
+}
+
+func (t *Transit) Verify(ctx context.Context, keyID string, payload, sig []byte) error {
+	prefix := t.keyPrefix()
+	if !strings.HasPrefix(keyID, prefix) {
+		return fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(keyID, prefix))
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	pub, err := t.publicKey(ctx, version)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return errors.New("attest: signature does not match")
+	}
+	return nil
+}
+
+func (t *Transit) publicKey(ctx context.Context, version int) (ed25519.PublicKey, error) {
+	t.mu.Lock()
+	pub, ok := t.keys[version]
+	t.mu.Unlock()
+	if ok {
+		return pub, nil
+	}
+	resp, err := t.call(ctx, "GET", "/keys/"+url.PathEscape(t.Key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data.Type != "" && resp.Data.Type != "ed25519" {
+		return nil, fmt.Errorf("vault transit: key %s is %s, not ed25519", t.Key, resp.Data.Type)
+	}
+	keys := map[int]ed25519.PublicKey{}
+	for raw, entry := range resp.Data.Keys {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		var key struct {
+			PublicKey string `json:"public_key"`
+		}
+		if err := json.Unmarshal(entry, &key); err != nil {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(key.PublicKey)
+		if err != nil || len(decoded) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[v] = ed25519.PublicKey(decoded)
+	}
+	t.mu.Lock()
+	t.keys = keys
+	t.mu.Unlock()
+	pub, ok = keys[version]
+	if !ok {
+		return nil, fmt.Errorf("vault transit: key %s has no version %d", t.Key, version)
+	}
+	return pub, nil
+}
+
+func parsePEMPublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("attest: public key is not PEM")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("attest: public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("attest: public key is not ed25519")
+	}
+	return pub, nil
+}
+
+// ParsePrivateKey reads a PEM (PKCS#8) ed25519 private key, as written by
+// `openssl genpkey -algorithm ed25519`.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("attest: private key is not PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("attest: private key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("attest: private key is not ed25519")
+	}
+	return priv, nil
+}
@@ -0,0 +1,261 @@
+// Package awsauth signs AWS API requests with Signature Version 4 and mints
+// short-lived credentials through STS without pulling in the AWS SDK.
+package awsauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnsignedPayload skips hashing the body, for streaming S3 uploads.
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+func (c Credentials) Valid() bool {
+	return c.AccessKeyID != "" && c.SecretAccessKey != ""
+}
+
+// CredentialsFromEnv reads the standard AWS_* variables.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		AccessKeyID:     strings.TrimSpace(os.Getenv("AWS_ACCESS_KEY_ID")),
+		SecretAccessKey: strings.TrimSpace(os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		SessionToken:    strings.TrimSpace(os.Getenv("AWS_SESSION_TOKEN")),
+	}
+}
+
+type Signer struct {
+	Credentials Credentials
+	Region      string
+	Service     string
+	Now         func() time.Time
+}
+
+// PayloadHash returns the hex SHA-256 of body, as used in the canonical request.
+func PayloadHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign adds the X-Amz-Date, security token and Authorization headers to req.
+// payloadHash is PayloadHash(body) or UnsignedPayload.
+func (s Signer) Sign(req *http.Request, payloadHash string) error {
+	if req == nil || req.URL == nil {
+		return errors.New("request required")
+	}
+	if !s.Credentials.Valid() {
+		return errors.New("aws credentials required")
+	}
+	if s.Region == "" || s.Service == "" {
+		return errors.New("region and service required")
+	}
+	if payloadHash == "" {
+		payloadHash = PayloadHash(nil)
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || lk == "content-md5" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.Join(trimAll(v), ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	// The wire path is escaped once; every service except S3 expects the
+	// canonical path escaped a second time.
+	req.URL.RawPath = escapePath(path)
+	canonicalURI := req.URL.RawPath
+	if s.Service != "s3" {
+		canonicalURI = escapePath(canonicalURI)
+	}
+
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + PayloadHash([]byte(canonical))
+
+	signature := hex.EncodeToString(hmacSHA256(s.signingKey(day), toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.Credentials.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+	return nil
+}
+
+// Presign returns req's URL with a query-string signature valid for expires.
+// Only the host header is signed and the payload is left unsigned.
+func (s Signer) Presign(req *http.Request, expires time.Duration) (string, error) {
+	if req == nil || req.URL == nil {
+		return "", errors.New("request required")
+	}
+	if !s.Credentials.Valid() {
+		return "", errors.New("aws credentials required")
+	}
+	if s.Region == "" || s.Service == "" {
+		return "", errors.New("region and service required")
+	}
+	if expires <= 0 || expires > 7*24*time.Hour {
+		return "", errors.New("presign expiry must be between 1s and 7 days")
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+	scope := day + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.Credentials.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if s.Credentials.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	req.URL.RawPath = escapePath(path)
+	canonicalURI := req.URL.RawPath
+	if s.Service != "s3" {
+		canonicalURI = escapePath(canonicalURI)
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req),
+		"host:" + host + "\n",
+		"host",
+		UnsignedPayload,
+	}, "\n")
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + PayloadHash([]byte(canonical))
+	signature := hex.EncodeToString(hmacSHA256(s.signingKey(day), toSign))
+	req.URL.RawQuery = canonicalQuery(req) + "&X-Amz-Signature=" + signature
+	return req.URL.String(), nil
+}
+
+func (s Signer) signingKey(day string) []byte {
+	key := hmacSHA256([]byte("AWS4"+s.Credentials.SecretAccessKey), day)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func escapePath(path string) string {
+	return uriEncode(path, false)
+}
+
+// uriEncode escapes everything but RFC 3986 unreserved characters; slashes
+// are kept unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
+func trimAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return out
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
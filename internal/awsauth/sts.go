@@ -0,0 +1,123 @@
+package awsauth
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultSTSEndpoint = "https://sts.amazonaws.com"
+
+type AssumeRoleInput struct {
+	RoleARN         string
+	SessionName     string
+	ExternalID      string
+	DurationSeconds int
+}
+
+// STSClient calls sts:AssumeRole with the caller's long-lived credentials.
+type STSClient struct {
+	Endpoint    string
+	Region      string
+	Credentials Credentials
+	Client      *http.Client
+	Now         func() time.Time
+}
+
+type assumeRoleResponse struct {
+	Result struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+			Expiration      string `xml:"Expiration"`
+		} `xml:"Credentials"`
+	} `xml:"AssumeRoleResult"`
+}
+
+type stsErrorResponse struct {
+	Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+func (c *STSClient) AssumeRole(ctx context.Context, in AssumeRoleInput) (Credentials, error) {
+	if strings.TrimSpace(in.RoleARN) == "" {
+		return Credentials{}, errors.New("role_arn required")
+	}
+	if strings.TrimSpace(in.SessionName) == "" {
+		return Credentials{}, errors.New("session name required")
+	}
+	endpoint := strings.TrimRight(strings.TrimSpace(c.Endpoint), "/")
+	if endpoint == "" {
+		endpoint = DefaultSTSEndpoint
+	}
+	region := c.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	form := url.Values{}
+	form.Set("Action", "AssumeRole")
+	form.Set("Version", "2011-06-15")
+	form.Set("RoleArn", in.RoleARN)
+	form.Set("RoleSessionName", in.SessionName)
+	if in.DurationSeconds > 0 {
+		form.Set("DurationSeconds", strconv.Itoa(in.DurationSeconds))
+	}
+	if in.ExternalID != "" {
+		form.Set("ExternalId", in.ExternalID)
+	}
+	body := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", strings.NewReader(string(body)))
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signer := Signer{Credentials: c.Credentials, Region: region, Service: "sts", Now: c.Now}
+	if err := signer.Sign(req, PayloadHash(body)); err != nil {
+		return Credentials{}, err
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Credentials{}, err
+	}
+	if resp.StatusCode/100 != 2 {
+		var stsErr stsErrorResponse
+		if xml.Unmarshal(data, &stsErr) == nil && stsErr.Error.Code != "" {
+			return Credentials{}, fmt.Errorf("sts assume role: %s: %s", stsErr.Error.Code, stsErr.Error.Message)
+		}
+		return Credentials{}, fmt.Errorf("sts assume role: status %d", resp.StatusCode)
+	}
+	var out assumeRoleResponse
+	if err := xml.Unmarshal(data, &out); err != nil {
+		return Credentials{}, err
+	}
+	creds := out.Result.Credentials
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, errors.New("sts assume role: empty credentials")
+	}
+	expires, _ := time.Parse(time.RFC3339, creds.Expiration)
+	return Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expires:         expires,
+	}, nil
+}
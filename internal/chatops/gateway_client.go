@@ -51,6 +51,16 @@ func (c *HTTPGatewayClient) ListAudit(ctx context.Context, query url.Values) ([]
 	return c.doRequest(ctx, http.MethodGet, path, nil)
 }
 
+// StatusError is a non-2xx gateway response.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("gateway status %d: %s", e.StatusCode, string(e.Body))
+}
+
 type planCreateResponse struct {
 	PlanID string `json:"plan_id"`
 	Plan   struct {
@@ -112,7 +122,7 @@ func (c *HTTPGatewayClient) doRequest(ctx context.Context, method, path string,
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		payload, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return nil, fmt.Errorf("gateway status %d: %s", resp.StatusCode, string(payload))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: payload}
 	}
 	return io.ReadAll(resp.Body)
 }
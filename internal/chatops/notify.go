@@ -0,0 +1,79 @@
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultSlackAPIURL = "https://slack.com/api"
+
+// SlackNotifier posts messages with a bot token through chat.postMessage.
+// It implements web.ApprovalNotifier for approval reminders.
+type SlackNotifier struct {
+	Token   string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewSlackNotifier(token string) *SlackNotifier {
+	return &SlackNotifier{Token: token, BaseURL: defaultSlackAPIURL, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NotifyApproval posts text to channel, a channel name or ID or a user ID.
+func (n *SlackNotifier) NotifyApproval(ctx context.Context, channel, text string) error {
+	if strings.TrimSpace(n.Token) == "" {
+		return errors.New("slack bot token required")
+	}
+	if strings.TrimSpace(channel) == "" {
+		return errors.New("channel required")
+	}
+	body, err := json.Marshal(map[string]string{"channel": channel, "text": text})
+	if err != nil {
+		return err
+	}
+	base := n.BaseURL
+	if base == "" {
+		base = defaultSlackAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(base, "/")+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+n.Token)
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack status %d: %s", resp.StatusCode, string(data))
+	}
+	// Slack reports most failures with a 200 and ok=false.
+	var out struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	if !out.OK {
+		return fmt.Errorf("slack chat.postMessage: %s", out.Error)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package chatops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSlackNotifierPostsMessage(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/chat.postMessage" {
+			t.Errorf("request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer xoxb-1" {
+			t.Errorf("auth: %s", r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got["channel"] == "#missing" {
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	n := NewSlackNotifier("xoxb-1")
+	n.BaseURL = srv.URL + "/api"
+	if err := n.NotifyApproval(context.Background(), "#sre", "approve plan_1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got["channel"] != "#sre" || got["text"] != "approve plan_1" {
+		t.Fatalf("body: %v", got)
+	}
+	if err := n.NotifyApproval(context.Background(), "#missing", "x"); err == nil || err.Error() != "slack chat.postMessage: channel_not_found" {
+		t.Fatalf("err: %v", err)
+	}
+	if err := (&SlackNotifier{}).NotifyApproval(context.Background(), "#sre", "x"); err == nil {
+		t.Fatalf("expected error without token")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -91,6 +92,10 @@ func (h *SlackHandler) handlePlan(w http.ResponseWriter, r *http.Request, text s
 		Context: web.ContextRef{},
 	}
 	planID, err := h.Client.CreatePlan(r.Context(), req)
+	if text := policyDeniedText(err); text != "" {
+		writeText(w, text)
+		return
+	}
 	if err != nil {
 		http.Error(w, "plan error", http.StatusBadRequest)
 		return
@@ -109,6 +114,10 @@ func (h *SlackHandler) handleApprove(w http.ResponseWriter, r *http.Request, tex
 		status = rest
 	}
 	if err := h.Client.CreateApproval(r.Context(), planID, status, ""); err != nil {
+		if text := policyDeniedText(err); text != "" {
+			writeText(w, text)
+			return
+		}
 		http.Error(w, "approval error", http.StatusBadRequest)
 		return
 	}
@@ -141,6 +150,31 @@ func (h *SlackHandler) handleAudit(w http.ResponseWriter, r *http.Request, text
 	writeText(w, string(resp))
 }
 
+// policyDeniedText renders a gateway policy denial with its reasons, so the
+// user sees why instead of a bare error. It returns "" for other errors.
+func policyDeniedText(err error) string {
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusForbidden {
+		return ""
+	}
+	var body web.PolicyDeniedResponse
+	if json.Unmarshal(status.Body, &body) != nil || body.Error == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(body.Error)
+	if body.Decision != "" {
+		fmt.Fprintf(&b, " (%s)", body.Decision)
+	}
+	for _, reason := range body.Reasons {
+		fmt.Fprintf(&b, "\n• %s: %s", reason.Rule, reason.Message)
+		if reason.Field != "" {
+			fmt.Fprintf(&b, " [%s]", reason.Field)
+		}
+	}
+	return b.String()
+}
+
 func (h *SlackHandler) verifySignature(header http.Header, body []byte) bool {
 	if h.SigningSecret == "" {
 		return false
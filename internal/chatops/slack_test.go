@@ -384,3 +384,24 @@ func TestSplitFirstRest(t *testing.T) {
 		t.Fatalf("unexpected: %q %q", a, b)
 	}
 }
+
+func TestSlackHandlerPlanPolicyDenied(t *testing.T) {
+	body := []byte(`{"error":"policy denied","decision":"deny","reasons":[{"rule":"write_role_required","message":"writes require the admin or operator role","field":"actor.roles"}]}`)
+	h := NewSlackHandler("secret", &fakeGateway{err: &StatusError{StatusCode: http.StatusForbidden, Body: body}})
+	req := newSlackRequest(t, "secret", "plan deploy app")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	want := "policy denied (deny)\n• write_role_required: writes require the admin or operator role [actor.roles]"
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Fatalf("status: %d body: %q", w.Code, w.Body.String())
+	}
+}
+
+func TestPolicyDeniedTextIgnoresOtherErrors(t *testing.T) {
+	if text := policyDeniedText(&StatusError{StatusCode: http.StatusForbidden, Body: []byte("session denied")}); text != "" {
+		t.Fatalf("text: %q", text)
+	}
+	if text := policyDeniedText(errClient); text != "" {
+		t.Fatalf("text: %q", text)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -21,6 +22,7 @@ type Config struct {
 	Connectors   ConnectorsConfig   `json:"connectors"`
 	ChatOps      ChatOpsConfig      `json:"chatops"`
 	Approvals    ApprovalsConfig    `json:"approvals"`
+	Risk         RiskConfig         `json:"risk"`
 }
 
 type GatewayConfig struct {
@@ -79,6 +81,11 @@ type ServiceMapping struct {
 	PromQL      []string `json:"promql"`
 	TraceQL     []string `json:"traceql"`
 	Dashboards  []string `json:"dashboards"`
+	OwnerTeam   string   `json:"owner_team"`
+	Criticality string   `json:"criticality"`
+	// TrafficShare is the share (0-1) of total traffic the service serves.
+	TrafficShare float64  `json:"traffic_share"`
+	DependsOn    []string `json:"depends_on"`
 }
 
 type ToolRouterConfig struct {
@@ -88,24 +95,189 @@ type ToolRouterConfig struct {
 	OIDCIssuer   string `json:"oidc_issuer"`
 	OIDCClientID string `json:"oidc_client_id"`
 	OIDCJWKSURL  string `json:"oidc_jwks_url"`
+	Cache        ToolCacheConfig `json:"cache"`
+	Breakers     ToolBreakerConfig `json:"breakers"`
+	Credentials  ToolCredentialsConfig `json:"credentials"`
 }
 
+// ToolCredentialsConfig enables minting short-lived credentials for each
+// tool call instead of relying on the static connector tokens.
+type ToolCredentialsConfig struct {
+	Enabled bool                   `json:"enabled"`
+	AWS     AWSCredentialsConfig   `json:"aws"`
+	Vault   VaultCredentialsConfig `json:"vault"`
+	GitHub  GitHubAppConfig        `json:"github"`
+}
+
+// AWSCredentialsConfig assumes RoleARN for aws calls. A "{account_id}"
+// placeholder is replaced with ContextRef.AWSAccountID; an empty RoleARN
+// falls back to connectors.aws.role_arn.
+type AWSCredentialsConfig struct {
+	RoleARN     string `json:"role_arn"`
+	ExternalID  string `json:"external_id"`
+	SessionSecs int    `json:"session_secs"`
+	STSEndpoint string `json:"sts_endpoint"`
+	STSRegion   string `json:"sts_region"`
+}
+
+type VaultCredentialsConfig struct {
+	Kubernetes []VaultKubernetesCredentialsConfig `json:"kubernetes"`
+}
+
+// VaultKubernetesCredentialsConfig requests service-account tokens from a
+// Vault kubernetes secrets engine role. An empty ClusterID matches any cluster.
+type VaultKubernetesCredentialsConfig struct {
+	ClusterID      string `json:"cluster_id"`
+	Mount          string `json:"mount"`
+	Role           string `json:"role"`
+	KubernetesHost string `json:"kubernetes_host"`
+	CAFile         string `json:"ca_file"`
+	TTLSecs        int    `json:"ttl_secs"`
+}
+
+type GitHubAppConfig struct {
+	AppID          string `json:"app_id"`
+	InstallationID string `json:"installation_id"`
+	PrivateKeyPath string `json:"private_key_path"`
+	APIURL         string `json:"api_url"`
+}
+
+type ToolBreakerConfig struct {
+	Enabled           bool           `json:"enabled"`
+	FailureThreshold  int            `json:"failure_threshold"`
+	OpenSecs          int            `json:"open_secs"`
+	HalfOpenProbes    int            `json:"half_open_probes"`
+	MaxConcurrent     int            `json:"max_concurrent"`
+	ToolMaxConcurrent map[string]int `json:"tool_max_concurrent"`
+	QueueTimeoutMS    int            `json:"queue_timeout_ms"`
+}
+
+type ToolCacheConfig struct {
+	Enabled        bool           `json:"enabled"`
+	DefaultTTLSecs int            `json:"default_ttl_secs"`
+	ToolTTLSecs    map[string]int `json:"tool_ttl_secs"`
+	MaxEntries     int            `json:"max_entries"`
+}
+
+// PolicyConfig selects where policies are evaluated. Mode "remote" (the
+// default) queries the OPA at OPAURL; "embedded" compiles the bundled Rego
+// in-process and caches decisions for their TTL, up to CacheMaxEntries;
+// "bundle" does the same with signed bundles pulled from Bundle.
 type PolicyConfig struct {
-	OPAURL        string `json:"opa_url"`
-	PolicyPackage string `json:"policy_package"`
-	FailOpenReads bool   `json:"fail_open_reads"`
+	Mode            string             `json:"mode"`
+	OPAURL          string             `json:"opa_url"`
+	PolicyPackage   string             `json:"policy_package"`
+	FailOpenReads   bool               `json:"fail_open_reads"`
+	CacheMaxEntries int                `json:"cache_max_entries"`
+	Bundle          PolicyBundleConfig `json:"bundle"`
+}
+
+// PolicyBundleConfig is where bundle mode pulls policies from: a local
+// directory ("dir", Path), a git repository ("git", URL at Ref, Path inside
+// the checkout kept in CacheDir) or an object store .tar.gz ("object",
+// ObjectRef). Bundles must be signed with the ed25519 key whose public half
+// is in PublicKeyFile; the source is polled every PollSecs (default 30).
+type PolicyBundleConfig struct {
+	Source        string `json:"source"`
+	Path          string `json:"path"`
+	URL           string `json:"url"`
+	Ref           string `json:"ref"`
+	CacheDir      string `json:"cache_dir"`
+	ObjectRef     string `json:"object_ref"`
+	PublicKeyFile string `json:"public_key_file"`
+	PollSecs      int    `json:"poll_secs"`
 }
 
 type LLMConfig struct {
 	Provider        string   `json:"provider"`
 	APIKey          string   `json:"api_key"`
 	APIBase         string   `json:"api_base"`
+	APIPath         string   `json:"api_path"`
+	APIVersion      string   `json:"api_version"`
+	Region          string   `json:"region"`
 	Model           string   `json:"model"`
 	TimeoutMS       int      `json:"timeout_ms"`
 	MaxOutputTokens int      `json:"max_output_tokens"`
 	RedactPatterns  []string `json:"redact_patterns"`
 	AuthProfile     string   `json:"auth_profile"`
 	AuthPath        string   `json:"auth_path"`
+	// Fallbacks are tried in order when the primary provider fails.
+	Fallbacks      []LLMFallbackConfig       `json:"fallbacks"`
+	MaxRetryWaitMS int                       `json:"max_retry_wait_ms"`
+	Pricing        map[string]LLMPriceConfig `json:"pricing"`
+	Budgets        LLMBudgetsConfig          `json:"budgets"`
+	// MaxRepairs bounds re-prompts for invalid plan drafts; 0 uses the
+	// default of 2 and a negative value disables repair.
+	MaxRepairs int `json:"max_repairs"`
+	// PromptDir overrides the built-in planning prompts with
+	// <trigger>/v<N>.tmpl files; PromptVersions pins a version per trigger
+	// (manual, alert, schedule, workflow).
+	PromptDir      string             `json:"prompt_dir"`
+	PromptVersions map[string]int     `json:"prompt_versions"`
+	Retrieval      LLMRetrievalConfig `json:"retrieval"`
+	Critic         LLMCriticConfig    `json:"critic"`
+	Cassette       LLMCassetteConfig  `json:"cassette"`
+}
+
+// LLMCassetteConfig records provider exchanges to Dir (mode "record") or
+// serves them from Dir without network access (mode "replay") for
+// reproducible tests and demos. Empty mode talks to the provider.
+type LLMCassetteConfig struct {
+	Mode string `json:"mode"`
+	Dir  string `json:"dir"`
+}
+
+// LLMCriticConfig enables a second review of drafted plans. Empty fields
+// reuse the primary provider's; endpoint and credentials are only
+// inherited when the provider is the same.
+type LLMCriticConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Provider    string `json:"provider"`
+	Model       string `json:"model"`
+	APIBase     string `json:"api_base"`
+	APIKey      string `json:"api_key"`
+	AuthProfile string `json:"auth_profile"`
+}
+
+// LLMRetrievalConfig adds matching runbooks, playbooks and operator memory
+// to planning prompts. EmbeddingModel turns on hybrid BM25 and embedding
+// ranking through the primary provider.
+type LLMRetrievalConfig struct {
+	Enabled        bool   `json:"enabled"`
+	TopK           int    `json:"top_k"`
+	EmbeddingModel string `json:"embedding_model"`
+	RefreshMS      int    `json:"refresh_ms"`
+}
+
+type LLMFallbackConfig struct {
+	Provider    string `json:"provider"`
+	Model       string `json:"model"`
+	APIBase     string `json:"api_base"`
+	APIPath     string `json:"api_path"`
+	APIVersion  string `json:"api_version"`
+	Region      string `json:"region"`
+	APIKey      string `json:"api_key"`
+	AuthProfile string `json:"auth_profile"`
+}
+
+// LLMPriceConfig is USD per 1000 tokens. Pricing keys are model names,
+// "<provider>/*" or "*".
+type LLMPriceConfig struct {
+	PromptPer1K     float64 `json:"prompt_per_1k_usd"`
+	CompletionPer1K float64 `json:"completion_per_1k_usd"`
+}
+
+// LLMBudgetsConfig caps daily (UTC) LLM usage per tenant; zero is unlimited.
+// Tenants overrides the default for individual tenant IDs.
+type LLMBudgetsConfig struct {
+	DailyTokens  int64                      `json:"daily_tokens"`
+	DailyCostUSD float64                    `json:"daily_cost_usd"`
+	Tenants      map[string]LLMBudgetConfig `json:"tenants"`
+}
+
+type LLMBudgetConfig struct {
+	DailyTokens  int64   `json:"daily_tokens"`
+	DailyCostUSD float64 `json:"daily_cost_usd"`
 }
 
 type OrchestratorConfig struct {
@@ -125,8 +297,16 @@ type StorageConfig struct {
 }
 
 type ObjectStoreConfig struct {
+	Backend  string `json:"backend"`
 	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
 	Bucket   string `json:"bucket"`
+	LocalDir string `json:"local_dir"`
+	// SSE is "AES256" or "aws:kms"; KMSKeyID requires aws:kms.
+	SSE              string `json:"sse"`
+	KMSKeyID         string `json:"kms_key_id"`
+	ContentAddressed bool   `json:"content_addressed"`
+	PartSizeMB       int    `json:"part_size_mb"`
 }
 
 type SandboxConfig struct {
@@ -209,7 +389,19 @@ type BoundaryConfig struct {
 }
 
 type K8sConfig struct {
+	KubeconfigPath string          `json:"kubeconfig_path"`
+	Clusters       []ClusterConfig `json:"clusters"`
+}
+
+// ClusterConfig registers a cluster ID that ContextRef.ClusterID may name.
+// Context selects the kubeconfig context; ArgoCD settings override the
+// global connector for apps managed from this cluster.
+type ClusterConfig struct {
+	ID             string `json:"id"`
 	KubeconfigPath string `json:"kubeconfig_path"`
+	Context        string `json:"context"`
+	ArgoCDAddr     string `json:"argocd_addr"`
+	ArgoCDToken    string `json:"argocd_token"`
 }
 
 type ArgoCDConfig struct {
@@ -263,8 +455,77 @@ type ChatOpsConfig struct {
 	GatewayToken       string `json:"gateway_token"`
 }
 
+// ApprovalsConfig controls who may approve writes. Quorum maps a risk level
+// (low, medium or high) to the approvals a write at that level needs; levels
+// without an entry need one approval from anyone allowed to approve.
+// TTLHours maps a risk level to how long its approvals stay pending before
+// they expire; levels without an entry use connectors.linear.timeout_hours,
+// or 24.
 type ApprovalsConfig struct {
-	AutoApproveLow bool `json:"auto_approve_low"`
+	AutoApproveLow bool                    `json:"auto_approve_low"`
+	Quorum         map[string]QuorumConfig `json:"quorum"`
+	Attestation    AttestationConfig       `json:"attestation"`
+	TTLHours       map[string]int          `json:"ttl_hours"`
+	Reminders      ReminderConfig          `json:"reminders"`
+	Escalation     EscalationConfig        `json:"escalation"`
+}
+
+// ReminderConfig posts a Slack reminder for each pending approval every
+// IntervalMinutes (0 disables reminders) with chatops.slack_bot_token.
+// SlackChannels maps an approver group to the channel or user to remind;
+// approvals whose quorum names no mapped group go to SlackChannel.
+type ReminderConfig struct {
+	IntervalMinutes int               `json:"interval_minutes"`
+	SlackChannel    string            `json:"slack_channel"`
+	SlackChannels   map[string]string `json:"slack_channels"`
+}
+
+// EscalationConfig pages the PagerDuty service behind PagerDutyRoutingKey
+// (an Events API v2 integration key) through the tool router's pagerduty
+// tool when a high-risk approval has been pending for IdleMinutes (default
+// 30) while alerts are firing.
+type EscalationConfig struct {
+	PagerDutyRoutingKey string `json:"pagerduty_routing_key"`
+	IdleMinutes         int    `json:"idle_minutes"`
+}
+
+// QuorumConfig requires Count distinct approvers, none of them the plan
+// author, each in at least one of Groups (OIDC groups; any when empty).
+type QuorumConfig struct {
+	Count  int      `json:"count"`
+	Groups []string `json:"groups"`
+}
+
+// AttestationConfig signs every approval with an ed25519 key, either
+// PrivateKeyFile (PEM, PKCS#8) or the VaultTransitKey in the transit engine
+// at VaultTransitMount (default "transit") of connectors.vault. KeyID names
+// the local key in attestations (default: a digest of its public key).
+// PublicKeyFiles maps key IDs to PEM public keys: retired keys, so older
+// attestations still verify, or the gateway's key for an orchestrator that
+// should not hold it. When any key is set the orchestrator refuses write
+// steps whose plan has no valid attestation, and the gateway needs a
+// signing key.
+type AttestationConfig struct {
+	KeyID             string            `json:"key_id"`
+	PrivateKeyFile    string            `json:"private_key_file"`
+	PublicKeyFiles    map[string]string `json:"public_key_files"`
+	VaultTransitKey   string            `json:"vault_transit_key"`
+	VaultTransitMount string            `json:"vault_transit_mount"`
+}
+
+// Enabled reports whether approvals are signed and verified.
+func (a AttestationConfig) Enabled() bool {
+	return strings.TrimSpace(a.PrivateKeyFile) != "" || strings.TrimSpace(a.VaultTransitKey) != "" || len(a.PublicKeyFiles) > 0
+}
+
+// RiskConfig tunes plan risk scoring. Writes outside BusinessHours
+// ("HH:MM-HH:MM" on weekdays, in Timezone) score higher; when it is empty
+// time of day is not scored. Failed executions of a plan's services count
+// for FailureLookbackHours (default 24).
+type RiskConfig struct {
+	BusinessHours        string `json:"business_hours"`
+	Timezone             string `json:"timezone"`
+	FailureLookbackHours int    `json:"failure_lookback_hours"`
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -283,8 +544,21 @@ func (c Config) Validate() error {
 	if c.Gateway.HTTPAddr == "" {
 		return errors.New("gateway.http_addr required")
 	}
-	if c.Policy.OPAURL == "" {
-		slog.Warn("policy.opa_url not set: gateway will start without OPA policy enforcement")
+	switch strings.ToLower(strings.TrimSpace(c.Policy.Mode)) {
+	case "", "remote":
+		if c.Policy.OPAURL == "" {
+			slog.Warn("policy.opa_url not set: gateway will start without OPA policy enforcement")
+		}
+	case "embedded":
+	case "bundle":
+		if err := c.validatePolicyBundle(); err != nil {
+			return err
+		}
+	default:
+		return errors.New("policy.mode must be remote, embedded or bundle")
+	}
+	if c.Policy.CacheMaxEntries < 0 {
+		return errors.New("policy.cache_max_entries must be >= 0")
 	}
 	if c.Orchestrator.TemporalAddr == "" {
 		slog.Warn("orchestrator.temporal_addr not set: gateway will start without Temporal workflow orchestration")
@@ -305,9 +579,54 @@ func (c Config) Validate() error {
 			return errors.New("llm.model required when llm.provider is set")
 		}
 		p := strings.ToLower(strings.TrimSpace(c.LLM.Provider))
-		if (p == "openai" || p == "anthropic") && strings.TrimSpace(c.LLM.APIKey) == "" && strings.TrimSpace(c.LLM.AuthProfile) == "" {
+		replay := strings.EqualFold(strings.TrimSpace(c.LLM.Cassette.Mode), "replay")
+		if (p == "openai" || p == "anthropic" || p == "gemini") && !replay && strings.TrimSpace(c.LLM.APIKey) == "" && strings.TrimSpace(c.LLM.AuthProfile) == "" {
 			return errors.New("llm.api_key or llm.auth_profile required for llm.provider " + p)
 		}
+		if (p == "openai-compatible" || p == "azure-openai") && strings.TrimSpace(c.LLM.APIBase) == "" {
+			return errors.New("llm.api_base required for llm.provider " + p)
+		}
+		for i, fb := range c.LLM.Fallbacks {
+			if strings.TrimSpace(fb.Provider) == "" && strings.TrimSpace(fb.Model) == "" {
+				return errors.New("llm.fallbacks[" + strconv.Itoa(i) + "] requires provider or model")
+			}
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(c.LLM.Cassette.Mode)) {
+	case "":
+	case "record", "replay":
+		if strings.TrimSpace(c.LLM.Cassette.Dir) == "" {
+			return errors.New("llm.cassette.dir required when llm.cassette.mode is set")
+		}
+	default:
+		return errors.New("llm.cassette.mode must be record or replay")
+	}
+	if c.LLM.MaxRetryWaitMS < 0 {
+		return errors.New("llm.max_retry_wait_ms must not be negative")
+	}
+	if c.LLM.Budgets.DailyTokens < 0 || c.LLM.Budgets.DailyCostUSD < 0 {
+		return errors.New("llm.budgets must not be negative")
+	}
+	if cr := c.LLM.Critic; cr.Enabled && strings.TrimSpace(cr.Provider) != "" && !strings.EqualFold(cr.Provider, c.LLM.Provider) && strings.TrimSpace(cr.Model) == "" {
+		return errors.New("llm.critic.model required when llm.critic.provider differs from llm.provider")
+	}
+	if c.LLM.Retrieval.TopK < 0 || c.LLM.Retrieval.RefreshMS < 0 {
+		return errors.New("llm.retrieval must not be negative")
+	}
+	for trigger, v := range c.LLM.PromptVersions {
+		switch trigger {
+		case "manual", "alert", "schedule", "workflow":
+		default:
+			return errors.New("llm.prompt_versions: unknown trigger " + trigger)
+		}
+		if v <= 0 {
+			return errors.New("llm.prompt_versions." + trigger + " must be positive")
+		}
+	}
+	for tenant, b := range c.LLM.Budgets.Tenants {
+		if b.DailyTokens < 0 || b.DailyCostUSD < 0 {
+			return errors.New("llm.budgets.tenants." + tenant + " must not be negative")
+		}
 	}
 
 	if c.Sandbox.Enforce && strings.TrimSpace(c.Sandbox.Image) == "" {
@@ -357,6 +676,52 @@ func (c Config) Validate() error {
 		return err
 	}
 
+	seenClusters := map[string]bool{}
+	for _, cluster := range c.Connectors.K8s.Clusters {
+		id := strings.TrimSpace(cluster.ID)
+		if id == "" {
+			return errors.New("connectors.k8s.clusters id required")
+		}
+		if seenClusters[id] {
+			return errors.New("connectors.k8s.clusters duplicate id " + id)
+		}
+		seenClusters[id] = true
+		if err := validateTokenAddr("connectors.k8s.clusters."+id+".argocd", cluster.ArgoCDAddr, cluster.ArgoCDToken); err != nil {
+			return err
+		}
+	}
+
+	if err := c.validateObjectStore(); err != nil {
+		return err
+	}
+	for _, svc := range c.Context.Services {
+		if svc.TrafficShare < 0 || svc.TrafficShare > 1 {
+			return errors.New("context.services traffic_share must be between 0 and 1 for " + svc.Service)
+		}
+	}
+	if c.Risk.FailureLookbackHours < 0 {
+		return errors.New("risk.failure_lookback_hours must be >= 0")
+	}
+	for level, q := range c.Approvals.Quorum {
+		switch strings.ToLower(level) {
+		case "low", "medium", "high":
+		default:
+			return errors.New("approvals.quorum: unknown risk level " + level)
+		}
+		if q.Count < 0 {
+			return errors.New("approvals.quorum." + level + ".count must be >= 0")
+		}
+	}
+	if err := c.validateAttestation(); err != nil {
+		return err
+	}
+	if err := c.validateApprovalExpiry(); err != nil {
+		return err
+	}
+	if err := c.validateCredentials(); err != nil {
+		return err
+	}
+
 	if strings.TrimSpace(c.ChatOps.SlackSigningSecret) != "" {
 		if strings.TrimSpace(c.ChatOps.GatewayURL) == "" && strings.TrimSpace(c.Gateway.HTTPAddr) == "" {
 			return errors.New("chatops.gateway_url required when chatops.slack_signing_secret is set")
@@ -365,6 +730,133 @@ func (c Config) Validate() error {
 	return nil
 }
 
+func (c Config) validateAttestation() error {
+	a := c.Approvals.Attestation
+	if strings.TrimSpace(a.PrivateKeyFile) != "" && strings.TrimSpace(a.VaultTransitKey) != "" {
+		return errors.New("approvals.attestation: set one of private_key_file or vault_transit_key")
+	}
+	if strings.TrimSpace(a.VaultTransitKey) != "" && strings.TrimSpace(c.Connectors.Vault.Addr) == "" {
+		return errors.New("connectors.vault.addr required for approvals.attestation.vault_transit_key")
+	}
+	for keyID, path := range a.PublicKeyFiles {
+		if strings.TrimSpace(keyID) == "" || strings.TrimSpace(path) == "" {
+			return errors.New("approvals.attestation.public_key_files entries need a key id and a path")
+		}
+	}
+	return nil
+}
+
+func (c Config) validateApprovalExpiry() error {
+	for level, hours := range c.Approvals.TTLHours {
+		switch strings.ToLower(level) {
+		case "low", "medium", "high":
+		default:
+			return errors.New("approvals.ttl_hours: unknown risk level " + level)
+		}
+		if hours <= 0 {
+			return errors.New("approvals.ttl_hours." + level + " must be > 0")
+		}
+	}
+	r := c.Approvals.Reminders
+	if r.IntervalMinutes < 0 {
+		return errors.New("approvals.reminders.interval_minutes must be >= 0")
+	}
+	if r.IntervalMinutes > 0 {
+		if strings.TrimSpace(c.ChatOps.SlackBotToken) == "" {
+			return errors.New("chatops.slack_bot_token required for approvals.reminders")
+		}
+		if strings.TrimSpace(r.SlackChannel) == "" && len(r.SlackChannels) == 0 {
+			return errors.New("approvals.reminders: slack_channel or slack_channels required")
+		}
+	}
+	e := c.Approvals.Escalation
+	if e.IdleMinutes < 0 {
+		return errors.New("approvals.escalation.idle_minutes must be >= 0")
+	}
+	if strings.TrimSpace(e.PagerDutyRoutingKey) != "" && strings.TrimSpace(c.ToolRouter.BaseURL) == "" {
+		return errors.New("tool_router.base_url required for approvals.escalation")
+	}
+	return nil
+}
+
+func (c Config) validatePolicyBundle() error {
+	b := c.Policy.Bundle
+	switch strings.ToLower(strings.TrimSpace(b.Source)) {
+	case "dir":
+		if strings.TrimSpace(b.Path) == "" {
+			return errors.New("policy.bundle.path required for dir source")
+		}
+	case "git":
+		if strings.TrimSpace(b.URL) == "" {
+			return errors.New("policy.bundle.url required for git source")
+		}
+	case "object":
+		if strings.TrimSpace(b.ObjectRef) == "" {
+			return errors.New("policy.bundle.object_ref required for object source")
+		}
+	default:
+		return errors.New("policy.bundle.source must be dir, git or object")
+	}
+	if strings.TrimSpace(b.PublicKeyFile) == "" {
+		return errors.New("policy.bundle.public_key_file required")
+	}
+	if b.PollSecs < 0 {
+		return errors.New("policy.bundle.poll_secs must be >= 0")
+	}
+	return nil
+}
+
+func (c Config) validateObjectStore() error {
+	obj := c.Storage.ObjectStore
+	switch strings.ToLower(strings.TrimSpace(obj.Backend)) {
+	case "", "s3":
+	case "local":
+		if strings.TrimSpace(obj.LocalDir) == "" {
+			return errors.New("storage.object_store.local_dir required for local backend")
+		}
+	default:
+		return errors.New("storage.object_store.backend must be s3 or local")
+	}
+	switch obj.SSE {
+	case "", "AES256", "aws:kms":
+	default:
+		return errors.New("storage.object_store.sse must be AES256 or aws:kms")
+	}
+	if obj.KMSKeyID != "" && obj.SSE != "aws:kms" {
+		return errors.New("storage.object_store.kms_key_id requires sse aws:kms")
+	}
+	if obj.PartSizeMB != 0 && obj.PartSizeMB < 5 {
+		return errors.New("storage.object_store.part_size_mb must be at least 5")
+	}
+	return nil
+}
+
+func (c Config) validateCredentials() error {
+	creds := c.ToolRouter.Credentials
+	if !creds.Enabled {
+		return nil
+	}
+	if len(creds.Vault.Kubernetes) > 0 && strings.TrimSpace(c.Connectors.Vault.Addr) == "" {
+		return errors.New("connectors.vault.addr required for tool_router.credentials.vault")
+	}
+	for _, role := range creds.Vault.Kubernetes {
+		if strings.TrimSpace(role.Role) == "" || strings.TrimSpace(role.KubernetesHost) == "" {
+			return errors.New("tool_router.credentials.vault.kubernetes requires role and kubernetes_host")
+		}
+	}
+	gh := creds.GitHub
+	set := 0
+	for _, v := range []string{gh.AppID, gh.InstallationID, gh.PrivateKeyPath} {
+		if strings.TrimSpace(v) != "" {
+			set++
+		}
+	}
+	if set != 0 && set != 3 {
+		return errors.New("tool_router.credentials.github requires app_id, installation_id and private_key_path")
+	}
+	return nil
+}
+
 func validateOIDC(prefix string, issuer string, clientID string, jwksURL string) error {
 	issuer = strings.TrimSpace(issuer)
 	clientID = strings.TrimSpace(clientID)
@@ -131,3 +131,320 @@ func TestValidateChatOpsMissingGatewayURL(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestValidateClusters(t *testing.T) {
+	cfg := Config{}
+	cfg.Gateway.HTTPAddr = ":8080"
+	cfg.Storage.PostgresDSN = "dsn"
+	cfg.Connectors.K8s.Clusters = []ClusterConfig{{ID: "prod-eu", Context: "prod-eu"}, {ID: "prod-us"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.Connectors.K8s.Clusters = []ClusterConfig{{ID: "a"}, {ID: "a"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected duplicate id error")
+	}
+	cfg.Connectors.K8s.Clusters = []ClusterConfig{{Context: "x"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected missing id error")
+	}
+	cfg.Connectors.K8s.Clusters = []ClusterConfig{{ID: "a", ArgoCDToken: "t"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected argocd addr error")
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	cfg := Config{}
+	cfg.Gateway.HTTPAddr = ":8080"
+	cfg.Storage.PostgresDSN = "dsn"
+	cfg.ToolRouter.Credentials.Vault.Kubernetes = []VaultKubernetesCredentialsConfig{{Role: "ops"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("disabled credentials should not be validated: %v", err)
+	}
+	cfg.ToolRouter.Credentials.Enabled = true
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected vault addr error")
+	}
+	cfg.Connectors.Vault.Addr = "http://vault"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected kubernetes_host error")
+	}
+	cfg.ToolRouter.Credentials.Vault.Kubernetes[0].KubernetesHost = "https://k8s"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.ToolRouter.Credentials.GitHub = GitHubAppConfig{AppID: "1"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected github app error")
+	}
+}
+
+func TestValidateObjectStore(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.Storage.ObjectStore = ObjectStoreConfig{Backend: "local"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected local_dir error")
+	}
+	cfg.Storage.ObjectStore.LocalDir = "/var/lib/carapulse/objects"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.Storage.ObjectStore = ObjectStoreConfig{Backend: "gcs"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected backend error")
+	}
+	cfg.Storage.ObjectStore = ObjectStoreConfig{Bucket: "b", KMSKeyID: "k"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected kms_key_id error")
+	}
+	cfg.Storage.ObjectStore.SSE = "aws:kms"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.Storage.ObjectStore.PartSizeMB = 1
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected part_size_mb error")
+	}
+}
+
+func TestValidateLLMProviders(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.LLM.Provider = "openai-compatible"
+	cfg.LLM.Model = "llama3.1"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected api_base error")
+	}
+	cfg.LLM.APIBase = "http://ollama:11434"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("keyless local provider should validate: %v", err)
+	}
+	cfg.LLM = LLMConfig{Provider: "bedrock", Model: "anthropic.claude-3-5-sonnet-20240620-v1:0", Region: "us-east-1"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.LLM = LLMConfig{Provider: "gemini", Model: "gemini-1.5-pro"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected api key error")
+	}
+}
+
+func TestValidateLLMFallbacksAndBudgets(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.LLM = LLMConfig{Provider: "openai", Model: "gpt-4o", APIKey: "k", Fallbacks: []LLMFallbackConfig{{}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected empty fallback error")
+	}
+	cfg.LLM.Fallbacks = []LLMFallbackConfig{{Model: "gpt-4o-mini"}, {Provider: "openai-compatible", APIBase: "http://ollama:11434", Model: "llama3.1"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.LLM.Budgets.Tenants = map[string]LLMBudgetConfig{"t1": {DailyTokens: -1}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected negative budget error")
+	}
+}
+
+func TestValidateLLMPromptVersions(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.LLM.PromptVersions = map[string]int{"alert": 2}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.LLM.PromptVersions = map[string]int{"webhook": 1}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected trigger error")
+	}
+	cfg.LLM.PromptVersions = map[string]int{"manual": 0}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected version error")
+	}
+}
+
+func TestValidateLLMCritic(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.LLM.Critic = LLMCriticConfig{Enabled: true, Model: "gpt-4o-mini"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.LLM.Critic = LLMCriticConfig{Enabled: true, Provider: "anthropic-other"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected model error")
+	}
+}
+
+func TestValidateLLMRetrieval(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.LLM.Retrieval = LLMRetrievalConfig{Enabled: true, TopK: 5, RefreshMS: 30000}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.LLM.Retrieval.TopK = -1
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected top_k error")
+	}
+}
+
+func TestValidateLLMCassette(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.LLM.Provider = "openai"
+	cfg.LLM.Model = "gpt-4o-mini"
+	cfg.LLM.Cassette = LLMCassetteConfig{Mode: "replay", Dir: "testdata/cassettes"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("replay without api key: %v", err)
+	}
+	cfg.LLM.Cassette.Dir = ""
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected dir error")
+	}
+	cfg.LLM.Cassette = LLMCassetteConfig{Mode: "rewind", Dir: "x"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected mode error")
+	}
+}
+
+func TestValidatePolicyMode(t *testing.T) {
+	cfg := baseValidConfig()
+	cfg.Policy = PolicyConfig{Mode: "embedded"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("embedded without opa_url: %v", err)
+	}
+	cfg.Policy.Mode = "sidecar"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected mode error")
+	}
+	cfg.Policy = PolicyConfig{Mode: "remote", OPAURL: "http://opa", CacheMaxEntries: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected cache_max_entries error")
+	}
+}
+
+func TestValidatePolicyBundle(t *testing.T) {
+	cfg := baseValidConfig()
+	valid := []PolicyBundleConfig{
+		{Source: "dir", Path: "/bundle", PublicKeyFile: "/key.pem"},
+		{Source: "git", URL: "https://git/policies.git", PublicKeyFile: "/key.pem", PollSecs: 60},
+		{Source: "object", ObjectRef: "s3://b/policy.tar.gz", PublicKeyFile: "/key.pem"},
+	}
+	for _, b := range valid {
+		cfg.Policy = PolicyConfig{Mode: "bundle", Bundle: b}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("%#v: %v", b, err)
+		}
+	}
+	invalid := []PolicyBundleConfig{
+		{},
+		{Source: "http", PublicKeyFile: "/key.pem"},
+		{Source: "dir", PublicKeyFile: "/key.pem"},
+		{Source: "git", PublicKeyFile: "/key.pem"},
+		{Source: "object", PublicKeyFile: "/key.pem"},
+		{Source: "dir", Path: "/bundle"},
+		{Source: "dir", Path: "/bundle", PublicKeyFile: "/key.pem", PollSecs: -1},
+	}
+	for _, b := range invalid {
+		cfg.Policy = PolicyConfig{Mode: "bundle", Bundle: b}
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("expected error for %#v", b)
+		}
+	}
+}
+
+func TestValidateRiskLookback(t *testing.T) {
+	cfg := Config{}
+	cfg.Gateway.HTTPAddr = ":8080"
+	cfg.Storage.PostgresDSN = "dsn"
+	cfg.Risk.FailureLookbackHours = -1
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for negative lookback")
+	}
+}
+
+func TestValidateApprovalQuorum(t *testing.T) {
+	cfg := Config{}
+	cfg.Gateway.HTTPAddr = ":8080"
+	cfg.Storage.PostgresDSN = "dsn"
+	cfg.Approvals.Quorum = map[string]QuorumConfig{"high": {Count: 2, Groups: []string{"sre"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.Approvals.Quorum = map[string]QuorumConfig{"critical": {Count: 2}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for unknown level")
+	}
+	cfg.Approvals.Quorum = map[string]QuorumConfig{"medium": {Count: -1}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for negative count")
+	}
+}
+
+func TestValidateApprovalAttestation(t *testing.T) {
+	cfg := Config{}
+	cfg.Gateway.HTTPAddr = ":8080"
+	cfg.Storage.PostgresDSN = "dsn"
+	cfg.Approvals.Attestation = AttestationConfig{PrivateKeyFile: "attest.pem"}
+	if err := cfg.Validate(); err != nil || !cfg.Approvals.Attestation.Enabled() {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.Approvals.Attestation.VaultTransitKey = "approvals"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for two signing keys")
+	}
+	cfg.Approvals.Attestation = AttestationConfig{VaultTransitKey: "approvals"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error without vault addr")
+	}
+	cfg.Connectors.Vault.Addr = "http://vault:8200"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.Approvals.Attestation.PublicKeyFiles = map[string]string{"old": ""}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for empty public key path")
+	}
+	if (AttestationConfig{}).Enabled() {
+		t.Fatalf("empty config enabled")
+	}
+}
+
+func TestValidateApprovalExpiry(t *testing.T) {
+	cfg := Config{}
+	cfg.Gateway.HTTPAddr = ":8080"
+	cfg.Storage.PostgresDSN = "dsn"
+	cfg.Approvals.TTLHours = map[string]int{"high": 2, "low": 72}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, ttl := range []map[string]int{{"urgent": 1}, {"high": 0}} {
+		cfg.Approvals.TTLHours = ttl
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("expected error for %v", ttl)
+		}
+	}
+	cfg.Approvals.TTLHours = nil
+
+	cfg.Approvals.Reminders = ReminderConfig{IntervalMinutes: 30, SlackChannel: "#approvals"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error without slack bot token")
+	}
+	cfg.ChatOps.SlackBotToken = "xoxb"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cfg.Approvals.Reminders.SlackChannel = ""
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error without a channel")
+	}
+	cfg.Approvals.Reminders.SlackChannels = map[string]string{"sre": "#sre"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cfg.Approvals.Escalation = EscalationConfig{PagerDutyRoutingKey: "R0UT1NG"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error without tool router")
+	}
+	cfg.ToolRouter.BaseURL = "http://tool-router:8081"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package collectors
+
+import (
+	"context"
+	"strings"
+
+	ctxmodel "carapulse/internal/context"
+)
+
+// ClusterPoller runs a cluster-local poller and scopes its node IDs by
+// cluster, so snapshots from several clusters can share one context graph.
+type ClusterPoller struct {
+	Poller    ctxmodel.Poller
+	ClusterID string
+}
+
+func (p *ClusterPoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot, error) {
+	snap, err := p.Poller.Snapshot(ctx)
+	if err != nil {
+		return ctxmodel.Snapshot{}, err
+	}
+	return ScopeSnapshot(snap, p.ClusterID), nil
+}
+
+// ClusterWatcher is the watcher counterpart of ClusterPoller.
+type ClusterWatcher struct {
+	Watcher   ctxmodel.Watcher
+	ClusterID string
+}
+
+func (w *ClusterWatcher) Watch(ctx context.Context, out chan<- ctxmodel.Snapshot) error {
+	inner := make(chan ctxmodel.Snapshot)
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Watcher.Watch(ctx, inner) }()
+	for {
+		select {
+		case snap := <-inner:
+			if ctx.Err() != nil {
+				// Keep draining so the inner watcher can observe cancellation.
+				continue
+			}
+			select {
+			case out <- ScopeSnapshot(snap, w.ClusterID):
+			case <-ctx.Done():
+			}
+		case err := <-errCh:
+			return err
+		}
+	}
+}
+
+// ScopeSnapshot prefixes cluster-local node and edge IDs (k8s, helm, argocd)
+// with the cluster, labels them with cluster_id and links every namespace to
+// its cluster node.
+func ScopeSnapshot(snap ctxmodel.Snapshot, clusterID string) ctxmodel.Snapshot {
+	clusterID = strings.TrimSpace(clusterID)
+	if clusterID == "" {
+		return snap
+	}
+	clusterNodeID := nodeID("cluster", clusterID)
+	var out ctxmodel.Snapshot
+	linked := map[string]bool{}
+	for _, n := range snap.Nodes {
+		n.NodeID = scopedNodeID(n.NodeID, clusterID)
+		labels := map[string]string{}
+		for k, v := range n.Labels {
+			labels[k] = v
+		}
+		labels["cluster_id"] = clusterID
+		n.Labels = labels
+		out.Nodes = append(out.Nodes, n)
+		if n.Kind == "k8s.namespace" && !linked[n.NodeID] {
+			linked[n.NodeID] = true
+			out.Edges = append(out.Edges, edge(nodeID("edge", clusterNodeID, n.NodeID), clusterNodeID, n.NodeID, "contains"))
+		}
+	}
+	if len(linked) > 0 {
+		out.Nodes = append(out.Nodes, node("cluster", clusterNodeID, clusterID, map[string]string{"cluster_id": clusterID}))
+	}
+	for _, e := range snap.Edges {
+		from := scopedNodeID(e.FromNodeID, clusterID)
+		to := scopedNodeID(e.ToNodeID, clusterID)
+		if from != e.FromNodeID || to != e.ToNodeID {
+			e.EdgeID = nodeID("edge", from, to)
+		}
+		e.FromNodeID, e.ToNodeID = from, to
+		out.Edges = append(out.Edges, e)
+	}
+	return out
+}
+
+func scopedNodeID(id, clusterID string) string {
+	for _, prefix := range []string{"k8s/", "helm/", "argocd/"} {
+		if strings.HasPrefix(id, prefix) {
+			return nodeID("cluster", clusterID, id)
+		}
+	}
+	return id
+}
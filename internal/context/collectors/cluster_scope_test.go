@@ -0,0 +1,73 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+
+	ctxmodel "carapulse/internal/context"
+)
+
+type fakeScopePoller struct {
+	snap ctxmodel.Snapshot
+}
+
+func (f fakeScopePoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot, error) {
+	return f.snap, nil
+}
+
+func TestScopeSnapshot(t *testing.T) {
+	ns := nodeID("k8s", "namespace", "payments")
+	svc := nodeID("service", "api")
+	snap := ctxmodel.Snapshot{
+		Nodes: []ctxmodel.Node{
+			node("k8s.namespace", ns, "payments", map[string]string{"team": "core"}),
+			node("service", svc, "api", nil),
+		},
+		Edges: []ctxmodel.Edge{edge(nodeID("edge", svc, ns), svc, ns, "runs_in")},
+	}
+	out := ScopeSnapshot(snap, "prod-eu")
+	scopedNS := "cluster/prod-eu/k8s/namespace/payments"
+	nodes := map[string]ctxmodel.Node{}
+	for _, n := range out.Nodes {
+		nodes[n.NodeID] = n
+	}
+	if nodes[scopedNS].Labels["cluster_id"] != "prod-eu" || nodes[scopedNS].Labels["team"] != "core" {
+		t.Fatalf("namespace: %+v", nodes[scopedNS])
+	}
+	if _, ok := nodes[svc]; !ok {
+		t.Fatalf("service node should keep its id: %v", out.Nodes)
+	}
+	if _, ok := nodes["cluster/prod-eu"]; !ok {
+		t.Fatalf("missing cluster node")
+	}
+	if snap.Nodes[0].Labels["cluster_id"] != "" {
+		t.Fatalf("input labels mutated")
+	}
+	var contains, runsIn bool
+	for _, e := range out.Edges {
+		switch e.Relation {
+		case "contains":
+			contains = e.FromNodeID == "cluster/prod-eu" && e.ToNodeID == scopedNS
+		case "runs_in":
+			runsIn = e.FromNodeID == svc && e.ToNodeID == scopedNS && e.EdgeID == nodeID("edge", svc, scopedNS)
+		}
+	}
+	if !contains || !runsIn {
+		t.Fatalf("edges: %+v", out.Edges)
+	}
+	if got := ScopeSnapshot(snap, " "); len(got.Nodes) != 2 || got.Nodes[0].NodeID != ns {
+		t.Fatalf("empty cluster should not scope: %+v", got)
+	}
+}
+
+func TestClusterPollerScopes(t *testing.T) {
+	ns := nodeID("k8s", "namespace", "payments")
+	p := &ClusterPoller{Poller: fakeScopePoller{snap: ctxmodel.Snapshot{Nodes: []ctxmodel.Node{node("k8s.namespace", ns, "payments", nil)}}}, ClusterID: "prod-us"}
+	snap, err := p.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(snap.Nodes) != 2 || snap.Nodes[0].NodeID != "cluster/prod-us/"+ns {
+		t.Fatalf("nodes: %+v", snap.Nodes)
+	}
+}
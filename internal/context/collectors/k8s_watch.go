@@ -198,9 +198,45 @@ func snapshotFromK8sObject(obj map[string]any, labels map[string]string) ([]ctxm
 		nodes = append(nodes, node("k8s.namespace", nsID, namespace, labels))
 		edges = append(edges, edge(nodeID("edge", nsID, resourceID), nsID, resourceID, "contains"))
 	}
+	if strings.EqualFold(kind, "Ingress") {
+		for _, backend := range ingressBackends(obj) {
+			svcID := nodeID("service", backend)
+			edges = append(edges, edge(nodeID("edge", resourceID, svcID), resourceID, svcID, ctxmodel.RelationRoutesTo))
+		}
+	}
 	return nodes, edges, resourceVersionFromMeta(meta)
 }
 
+// ingressBackends lists the Service names an Ingress routes to. They are
+// linked to the service node of the same name, so an ingress counts as a
+// dependent when that service changes.
+func ingressBackends(obj map[string]any) []string {
+	spec, _ := obj["spec"].(map[string]any)
+	seen := map[string]bool{}
+	var out []string
+	addBackend := func(backend any) {
+		b, _ := backend.(map[string]any)
+		svc, _ := b["service"].(map[string]any)
+		name, _ := svc["name"].(string)
+		if name = strings.TrimSpace(name); name != "" && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	addBackend(spec["defaultBackend"])
+	rules, _ := spec["rules"].([]any)
+	for _, rule := range rules {
+		r, _ := rule.(map[string]any)
+		httpRule, _ := r["http"].(map[string]any)
+		paths, _ := httpRule["paths"].([]any)
+		for _, path := range paths {
+			p, _ := path.(map[string]any)
+			addBackend(p["backend"])
+		}
+	}
+	return out
+}
+
 func resourceVersionFromMeta(meta any) string {
 	if m, ok := meta.(map[string]any); ok {
 		if v, ok := m["resourceVersion"].(string); ok {
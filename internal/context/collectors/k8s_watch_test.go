@@ -56,6 +56,20 @@ func TestSnapshotFromK8sWatch(t *testing.T) {
 	}
 }
 
+func TestSnapshotFromK8sIngressRoutesToServices(t *testing.T) {
+	line := `{"type":"ADDED","object":{"kind":"Ingress","metadata":{"name":"web","namespace":"ns"},"spec":{"defaultBackend":{"service":{"name":"api"}},"rules":[{"http":{"paths":[{"backend":{"service":{"name":"api"}}},{"backend":{"service":{"name":"static"}}}]}}]}}}`
+	snap, _ := snapshotFromK8sWatch([]byte(line), nil)
+	var routes []ctxmodel.Edge
+	for _, e := range snap.Edges {
+		if e.Relation == ctxmodel.RelationRoutesTo {
+			routes = append(routes, e)
+		}
+	}
+	if len(routes) != 2 || routes[0].FromNodeID != "k8s/ingress/ns/web" || routes[0].ToNodeID != "service/api" || routes[1].ToNodeID != "service/static" {
+		t.Fatalf("routes: %+v", routes)
+	}
+}
+
 func TestK8sWatcherUsesWatch(t *testing.T) {
 	runner := &fakeRunner{resp: tools.ExecuteResponse{Output: []byte(`{"type":"ADDED","object":{"kind":"Pod","metadata":{"name":"p","namespace":"ns","resourceVersion":"5"}}}`)}}
 	w := &K8sWatcher{Base: Base{Router: runner}, Resources: []string{"pods"}, Timeout: time.Second, PollInterval: time.Millisecond, SendInitialEvents: true, AllowBookmarks: true}
@@ -4,15 +4,27 @@ import (
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"sort"
+	"strings"
 
 	ctxmodel "carapulse/internal/context"
 	"carapulse/internal/tools"
 )
 
+// defaultMaxMetrics caps how many discovered metric names become context
+// nodes per snapshot.
+const defaultMaxMetrics = 500
+
+// PromPoller runs the configured queries and discovers metric names: from
+// the metadata endpoint and from the series the queries return. Each metric
+// becomes a prometheus.metric node whose label_names label lists the label
+// names seen on its series.
 type PromPoller struct {
 	Base
-	Queries []string
+	Queries    []string
+	MaxMetrics int
 }
 
 func (p *PromPoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot, error) {
@@ -24,18 +36,121 @@ func (p *PromPoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot, error) {
 		queries = []string{"up"}
 	}
 	var snap ctxmodel.Snapshot
+	metrics := map[string]*promMetric{}
 	for _, query := range queries {
-		_, err := p.Router.Execute(ctx, tools.ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": query}, Context: p.Context})
+		resp, err := p.Router.Execute(ctx, tools.ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": query}, Context: p.Context})
 		if err != nil {
 			continue
 		}
 		id := nodeID("prometheus", "query", hash(query))
 		labels := p.withLabels(map[string]string{"query": query})
 		snap.Nodes = append(snap.Nodes, node("prometheus.query", id, query, labels))
+		addSeriesLabels(metrics, resp.Output)
+	}
+	if resp, err := p.Router.Execute(ctx, tools.ExecuteRequest{Tool: "prometheus", Action: "metadata", Input: map[string]any{}, Context: p.Context}); err == nil {
+		addMetadata(metrics, resp.Output)
+	}
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	limit := p.MaxMetrics
+	if limit <= 0 {
+		limit = defaultMaxMetrics
+	}
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	for _, name := range names {
+		metric := metrics[name]
+		extra := map[string]string{}
+		if metric.Type != "" {
+			extra["type"] = metric.Type
+		}
+		if metric.Help != "" {
+			extra["help"] = metric.Help
+		}
+		if len(metric.Labels) > 0 {
+			labelNames := make([]string, 0, len(metric.Labels))
+			for label := range metric.Labels {
+				labelNames = append(labelNames, label)
+			}
+			sort.Strings(labelNames)
+			extra["label_names"] = strings.Join(labelNames, ",")
+		}
+		snap.Nodes = append(snap.Nodes, node("prometheus.metric", nodeID("prometheus", "metric", name), name, p.withLabels(extra)))
 	}
 	return snap, nil
 }
 
+type promMetric struct {
+	Type   string
+	Help   string
+	Labels map[string]struct{}
+}
+
+func metricEntry(metrics map[string]*promMetric, name string) *promMetric {
+	metric, ok := metrics[name]
+	if !ok {
+		metric = &promMetric{Labels: map[string]struct{}{}}
+		metrics[name] = metric
+	}
+	return metric
+}
+
+// addSeriesLabels records the metric and label names of an instant query
+// result.
+func addSeriesLabels(metrics map[string]*promMetric, output []byte) {
+	var resp struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return
+	}
+	for _, series := range resp.Data.Result {
+		name := series.Metric["__name__"]
+		if name == "" {
+			continue
+		}
+		metric := metricEntry(metrics, name)
+		for label := range series.Metric {
+			if label != "__name__" {
+				metric.Labels[label] = struct{}{}
+			}
+		}
+	}
+}
+
+// addMetadata records metric names with their type and help text from
+// /api/v1/metadata.
+func addMetadata(metrics map[string]*promMetric, output []byte) {
+	var resp struct {
+		Data map[string][]struct {
+			Type string `json:"type"`
+			Help string `json:"help"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return
+	}
+	for name, entries := range resp.Data {
+		metric := metricEntry(metrics, name)
+		if len(entries) > 0 {
+			metric.Type = entries[0].Type
+			help := entries[0].Help
+			if len(help) > 200 {
+				help = help[:200]
+			}
+			metric.Help = help
+		}
+	}
+}
+
 func hash(value string) string {
 	sum := sha1.Sum([]byte(value))
 	return hex.EncodeToString(sum[:8])
@@ -18,3 +18,48 @@ func TestPromPollerSnapshot(t *testing.T) {
 		t.Fatalf("expected nodes")
 	}
 }
+
+type actionRunner struct {
+	outputs map[string]string
+}
+
+func (a *actionRunner) Execute(ctx context.Context, req tools.ExecuteRequest) (tools.ExecuteResponse, error) {
+	out, ok := a.outputs[req.Tool+"."+req.Action]
+	if !ok {
+		return tools.ExecuteResponse{}, tools.ErrNoCLI
+	}
+	return tools.ExecuteResponse{Output: []byte(out)}, nil
+}
+
+func TestPromPollerDiscoversMetrics(t *testing.T) {
+	runner := &actionRunner{outputs: map[string]string{
+		"prometheus.query":    `{"status":"success","data":{"result":[{"metric":{"__name__":"http_requests_total","job":"api","code":"500"}}]}}`,
+		"prometheus.metadata": `{"status":"success","data":{"http_requests_total":[{"type":"counter","help":"Requests."}],"up":[{"type":"gauge"}]}}`,
+	}}
+	poller := &PromPoller{Base: Base{Router: runner, Labels: map[string]string{"tenant_id": "t1"}}, Queries: []string{"http_requests_total"}}
+	snap, err := poller.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	metrics := map[string]map[string]string{}
+	for _, n := range snap.Nodes {
+		if n.Kind == "prometheus.metric" {
+			metrics[n.Name] = n.Labels
+		}
+	}
+	requests := metrics["http_requests_total"]
+	if len(metrics) != 2 || requests["label_names"] != "code,job" || requests["type"] != "counter" || requests["tenant_id"] != "t1" {
+		t.Fatalf("metrics: %#v", metrics)
+	}
+	poller.MaxMetrics = 1
+	snap, _ = poller.Snapshot(context.Background())
+	count := 0
+	for _, n := range snap.Nodes {
+		if n.Kind == "prometheus.metric" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("expected cap, got %d", count)
+	}
+}
@@ -3,6 +3,7 @@ package collectors
 import (
 	"context"
 	"errors"
+	"strconv"
 	"strings"
 
 	ctxmodel "carapulse/internal/context"
@@ -16,11 +17,21 @@ type ServiceMapping struct {
 	PromQL      []string
 	TraceQL     []string
 	Dashboards  []string
+	OwnerTeam   string
+	// Criticality and TrafficShare (0-1) label the service node for risk
+	// scoring and blast radius.
+	Criticality  string
+	TrafficShare float64
+	// DependsOn names the services this one depends on.
+	DependsOn []string
 }
 
 type StaticMappingPoller struct {
 	Base
 	Mappings []ServiceMapping
+	// ScopeByCluster links services to cluster-scoped namespace nodes, as
+	// produced by ClusterPoller when collectors fan out across clusters.
+	ScopeByCluster bool
 }
 
 func (p *StaticMappingPoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot, error) {
@@ -40,7 +51,24 @@ func (p *StaticMappingPoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot,
 			"cluster_id":  mapping.ClusterID,
 			"namespace":   mapping.Namespace,
 		})
-		snap.Nodes = append(snap.Nodes, node("service", svcID, service, labels))
+		svcLabels := p.withLabels(labels)
+		if c := strings.TrimSpace(mapping.Criticality); c != "" {
+			svcLabels["criticality"] = c
+		}
+		if mapping.TrafficShare > 0 {
+			svcLabels["traffic_share"] = strconv.FormatFloat(mapping.TrafficShare, 'f', -1, 64)
+		}
+		svcNode := node("service", svcID, service, svcLabels)
+		svcNode.OwnerTeam = strings.TrimSpace(mapping.OwnerTeam)
+		snap.Nodes = append(snap.Nodes, svcNode)
+		for _, dep := range mapping.DependsOn {
+			dep = strings.TrimSpace(dep)
+			if dep == "" || dep == service {
+				continue
+			}
+			depID := nodeID("service", dep)
+			snap.Edges = append(snap.Edges, edge(nodeID("edge", svcID, depID), svcID, depID, ctxmodel.RelationDependsOn))
+		}
 		if env := strings.TrimSpace(mapping.Environment); env != "" {
 			envID := nodeID("env", env)
 			snap.Nodes = append(snap.Nodes, node("env", envID, env, labels))
@@ -53,6 +81,9 @@ func (p *StaticMappingPoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot,
 		}
 		if ns := strings.TrimSpace(mapping.Namespace); ns != "" {
 			nsID := nodeID("k8s", "namespace", ns)
+			if cluster := strings.TrimSpace(mapping.ClusterID); p.ScopeByCluster && cluster != "" {
+				nsID = scopedNodeID(nsID, cluster)
+			}
 			snap.Nodes = append(snap.Nodes, node("k8s.namespace", nsID, ns, labels))
 			snap.Edges = append(snap.Edges, edge(nodeID("edge", svcID, nsID), svcID, nsID, "owns"))
 		}
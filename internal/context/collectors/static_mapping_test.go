@@ -27,3 +27,22 @@ func TestStaticMappingPollerSnapshot(t *testing.T) {
 		t.Fatalf("expected edges")
 	}
 }
+
+func TestStaticMappingPollerDependencies(t *testing.T) {
+	poller := &StaticMappingPoller{
+		Mappings: []ServiceMapping{
+			{Service: "api", OwnerTeam: "core", Criticality: "tier-1", TrafficShare: 0.4, DependsOn: []string{"db", "api", " "}},
+		},
+	}
+	snap, err := poller.Snapshot(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	svc := snap.Nodes[0]
+	if svc.OwnerTeam != "core" || svc.Labels["criticality"] != "tier-1" || svc.Labels["traffic_share"] != "0.4" {
+		t.Fatalf("service: %+v", svc)
+	}
+	if len(snap.Edges) != 1 || snap.Edges[0].FromNodeID != "service/api" || snap.Edges[0].ToNodeID != "service/db" || snap.Edges[0].Relation != "depends_on" {
+		t.Fatalf("edges: %+v", snap.Edges)
+	}
+}
@@ -4,12 +4,22 @@ import (
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"sort"
+	"strings"
 
 	ctxmodel "carapulse/internal/context"
 	"carapulse/internal/tools"
 )
 
+// maxTempoNames caps the root span and tag names kept per node.
+const maxTempoNames = 200
+
+// TempoPoller runs the configured TraceQL queries and discovers what can
+// be queried: a tempo.service node per root service seen in the results,
+// with its root span names, and a tempo.tags node per attribute scope from
+// the tags endpoint.
 type TempoPoller struct {
 	Base
 	Queries []string
@@ -24,18 +34,89 @@ func (p *TempoPoller) Snapshot(ctx context.Context) (ctxmodel.Snapshot, error) {
 		queries = []string{"{ }"}
 	}
 	var snap ctxmodel.Snapshot
+	services := map[string]map[string]struct{}{}
 	for _, query := range queries {
-		_, err := p.Router.Execute(ctx, tools.ExecuteRequest{Tool: "tempo", Action: "traceql", Input: map[string]any{"query": query}, Context: p.Context})
+		resp, err := p.Router.Execute(ctx, tools.ExecuteRequest{Tool: "tempo", Action: "traceql", Input: map[string]any{"query": query}, Context: p.Context})
 		if err != nil {
 			continue
 		}
 		id := nodeID("tempo", "query", hashTempo(query))
 		labels := p.withLabels(map[string]string{"query": query})
 		snap.Nodes = append(snap.Nodes, node("tempo.query", id, query, labels))
+		addTraceServices(services, resp.Output)
+	}
+	for _, name := range sortedKeys(services) {
+		extra := map[string]string{}
+		if spans := sortedKeys(services[name]); len(spans) > 0 {
+			extra["root_spans"] = strings.Join(capNames(spans), ",")
+		}
+		snap.Nodes = append(snap.Nodes, node("tempo.service", nodeID("tempo", "service", name), name, p.withLabels(extra)))
+	}
+	if resp, err := p.Router.Execute(ctx, tools.ExecuteRequest{Tool: "tempo", Action: "tags", Input: map[string]any{}, Context: p.Context}); err == nil {
+		var tags struct {
+			Scopes []struct {
+				Name string   `json:"name"`
+				Tags []string `json:"tags"`
+			} `json:"scopes"`
+		}
+		if json.Unmarshal(resp.Output, &tags) == nil {
+			for _, scope := range tags.Scopes {
+				if scope.Name == "" || len(scope.Tags) == 0 {
+					continue
+				}
+				names := append([]string(nil), scope.Tags...)
+				sort.Strings(names)
+				labels := p.withLabels(map[string]string{"tags": strings.Join(capNames(names), ",")})
+				snap.Nodes = append(snap.Nodes, node("tempo.tags", nodeID("tempo", "tags", scope.Name), scope.Name, labels))
+			}
+		}
 	}
 	return snap, nil
 }
 
+// addTraceServices records root service and span names from a Tempo search
+// result.
+func addTraceServices(services map[string]map[string]struct{}, output []byte) {
+	var resp struct {
+		Traces []struct {
+			RootServiceName string `json:"rootServiceName"`
+			RootTraceName   string `json:"rootTraceName"`
+		} `json:"traces"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return
+	}
+	for _, trace := range resp.Traces {
+		if trace.RootServiceName == "" {
+			continue
+		}
+		spans, ok := services[trace.RootServiceName]
+		if !ok {
+			spans = map[string]struct{}{}
+			services[trace.RootServiceName] = spans
+		}
+		if trace.RootTraceName != "" {
+			spans[trace.RootTraceName] = struct{}{}
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func capNames(names []string) []string {
+	if len(names) > maxTempoNames {
+		return names[:maxTempoNames]
+	}
+	return names
+}
+
 func hashTempo(value string) string {
 	sum := sha1.Sum([]byte(value))
 	return hex.EncodeToString(sum[:8])
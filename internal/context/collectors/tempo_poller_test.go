@@ -18,3 +18,25 @@ func TestTempoPollerSnapshot(t *testing.T) {
 		t.Fatalf("expected nodes")
 	}
 }
+
+func TestTempoPollerDiscoversServicesAndTags(t *testing.T) {
+	runner := &actionRunner{outputs: map[string]string{
+		"tempo.traceql": `{"traces":[{"rootServiceName":"checkout","rootTraceName":"POST /pay"},{"rootServiceName":"checkout","rootTraceName":"GET /cart"}]}`,
+		"tempo.tags":    `{"scopes":[{"name":"span","tags":["http.status_code","http.method"]},{"name":"intrinsic","tags":[]}]}`,
+	}}
+	poller := &TempoPoller{Base: Base{Router: runner}}
+	snap, err := poller.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	byKind := map[string][]string{}
+	for _, n := range snap.Nodes {
+		byKind[n.Kind] = append(byKind[n.Kind], n.Name+"="+n.Labels["root_spans"]+n.Labels["tags"])
+	}
+	if got := byKind["tempo.service"]; len(got) != 1 || got[0] != "checkout=GET /cart,POST /pay" {
+		t.Fatalf("services: %#v", got)
+	}
+	if got := byKind["tempo.tags"]; len(got) != 1 || got[0] != "span=http.method,http.status_code" {
+		t.Fatalf("tags: %#v", got)
+	}
+}
@@ -0,0 +1,223 @@
+package context
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Relations whose from node depends on its to node: changing the to node
+// can impact the from node.
+const (
+	RelationDependsOn = "depends_on"
+	RelationCalls     = "calls"
+	RelationConsumes  = "consumes"
+	RelationRoutesTo  = "routes_to"
+)
+
+var dependentRelations = map[string]bool{
+	RelationDependsOn: true,
+	RelationCalls:     true,
+	RelationConsumes:  true,
+	RelationRoutesTo:  true,
+}
+
+const (
+	// MaxImpactDepth bounds how many dependency hops WalkImpact follows.
+	MaxImpactDepth = 5
+	// MaxImpactServices bounds how many services WalkImpact looks up.
+	MaxImpactServices = 50
+)
+
+// GraphReader reads the one-hop graph around a service.
+type GraphReader interface {
+	GetServiceGraph(ctx context.Context, service string) (ServiceGraph, error)
+}
+
+// Impact is the blast radius of changing a set of target services: the
+// targets plus everything downstream that depends on them.
+type Impact struct {
+	Services   []ImpactedNode `json:"services"`
+	OwnerTeams []string       `json:"owner_teams"`
+	// TrafficShare estimates the share of traffic (0-1) that passes through
+	// the impacted nodes: the largest traffic_share label among them.
+	TrafficShare float64  `json:"traffic_share"`
+	Namespaces   []string `json:"namespaces,omitempty"`
+	Clusters     []string `json:"clusters,omitempty"`
+	Accounts     []string `json:"accounts,omitempty"`
+	// Truncated is set when the walk hit MaxImpactDepth or
+	// MaxImpactServices, so more may be impacted than listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ImpactedNode is one node in an Impact. Depth is 0 for a target and the
+// number of dependency hops from the nearest target otherwise; Via is the
+// relation that reached it.
+type ImpactedNode struct {
+	Name         string  `json:"name"`
+	Kind         string  `json:"kind"`
+	OwnerTeam    string  `json:"owner_team,omitempty"`
+	Depth        int     `json:"depth"`
+	Via          string  `json:"via,omitempty"`
+	TrafficShare float64 `json:"traffic_share,omitempty"`
+}
+
+// Dependents counts the impacted nodes that are not targets.
+func (i Impact) Dependents() int {
+	n := 0
+	for _, svc := range i.Services {
+		if svc.Depth > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// WalkImpact walks the context graph from targets to their downstream
+// dependents, breadth first. Dependents of any kind (services, ingresses,
+// consumers) are reported; the walk continues through service nodes only,
+// since those are the graphs the reader can look up. Targets missing from
+// the graph are still reported, without owner or traffic. The first lookup
+// error is returned along with whatever was walked before it.
+func WalkImpact(ctx context.Context, graphs GraphReader, targets []string) (Impact, error) {
+	type queued struct {
+		name  string
+		depth int
+	}
+	var impact Impact
+	seen := map[string]bool{}
+	teams := map[string]bool{}
+	namespaces, clusters, accounts := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	add := func(n Node, name string, depth int, via string) {
+		item := ImpactedNode{Name: name, Kind: "service", Depth: depth, Via: via}
+		if n.NodeID != "" {
+			item.Kind = n.Kind
+			item.OwnerTeam = strings.TrimSpace(n.OwnerTeam)
+			item.TrafficShare = trafficShare(n.Labels)
+			collectLabel(namespaces, n.Labels, "namespace")
+			collectLabel(clusters, n.Labels, "cluster_id")
+			collectLabel(accounts, n.Labels, "aws_account_id")
+		}
+		if item.OwnerTeam != "" {
+			teams[item.OwnerTeam] = true
+		}
+		impact.TrafficShare = max(impact.TrafficShare, item.TrafficShare)
+		impact.Services = append(impact.Services, item)
+	}
+
+	var queue []queued
+	for _, target := range targets {
+		target = strings.TrimSpace(target)
+		if target == "" || seen[impactKey("service", target)] {
+			continue
+		}
+		seen[impactKey("service", target)] = true
+		queue = append(queue, queued{name: target})
+	}
+	var firstErr error
+	lookups := 0
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if lookups >= MaxImpactServices {
+			impact.Truncated = true
+			break
+		}
+		lookups++
+		graph, err := graphs.GetServiceGraph(ctx, next.name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		self := findServiceNode(graph, next.name)
+		if next.depth == 0 {
+			add(self, next.name, 0, "")
+		}
+		if self.NodeID == "" {
+			continue
+		}
+		byID := map[string]Node{}
+		for _, n := range graph.Nodes {
+			byID[n.NodeID] = n
+		}
+		for _, e := range graph.Edges {
+			if e.ToNodeID != self.NodeID || !dependentRelations[strings.ToLower(e.Relation)] {
+				continue
+			}
+			dep, ok := byID[e.FromNodeID]
+			if !ok || dep.NodeID == self.NodeID {
+				continue
+			}
+			key := impactKey(dep.Kind, dep.Name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			add(dep, dep.Name, next.depth+1, strings.ToLower(e.Relation))
+			if dep.Kind != "service" {
+				continue
+			}
+			if next.depth+1 >= MaxImpactDepth {
+				impact.Truncated = true
+				continue
+			}
+			queue = append(queue, queued{name: dep.Name, depth: next.depth + 1})
+		}
+	}
+	impact.OwnerTeams = sortedKeys(teams)
+	if impact.OwnerTeams == nil {
+		impact.OwnerTeams = []string{}
+	}
+	impact.Namespaces = sortedKeys(namespaces)
+	impact.Clusters = sortedKeys(clusters)
+	impact.Accounts = sortedKeys(accounts)
+	return impact, firstErr
+}
+
+func findServiceNode(graph ServiceGraph, name string) Node {
+	for _, n := range graph.Nodes {
+		if n.Kind == "service" && (strings.EqualFold(n.Name, name) || n.NodeID == name) {
+			return n
+		}
+	}
+	return Node{}
+}
+
+func impactKey(kind, name string) string {
+	if kind == "" {
+		kind = "service"
+	}
+	return kind + "/" + strings.ToLower(strings.TrimSpace(name))
+}
+
+// trafficShare reads a node's traffic_share label, a fraction of total
+// traffic in [0, 1].
+func trafficShare(labels map[string]string) float64 {
+	raw := strings.TrimSpace(labels["traffic_share"])
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return min(v, 1)
+}
+
+func collectLabel(into map[string]bool, labels map[string]string, key string) {
+	if v := strings.TrimSpace(labels[key]); v != "" {
+		into[v] = true
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,150 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// mapGraphs serves one-hop graphs cut from a full node and edge list, the
+// way the context store does.
+type mapGraphs struct {
+	nodes   []Node
+	edges   []Edge
+	errFor  string
+	lookups []string
+}
+
+func (m *mapGraphs) GetServiceGraph(ctx context.Context, service string) (ServiceGraph, error) {
+	m.lookups = append(m.lookups, service)
+	if service == m.errFor {
+		return ServiceGraph{}, errors.New("boom")
+	}
+	var graph ServiceGraph
+	selfID := ""
+	for _, n := range m.nodes {
+		if n.Kind == "service" && n.Name == service {
+			selfID = n.NodeID
+		}
+	}
+	if selfID == "" {
+		return graph, nil
+	}
+	ids := map[string]bool{selfID: true}
+	for _, e := range m.edges {
+		if e.FromNodeID == selfID || e.ToNodeID == selfID {
+			graph.Edges = append(graph.Edges, e)
+			ids[e.FromNodeID], ids[e.ToNodeID] = true, true
+		}
+	}
+	for _, n := range m.nodes {
+		if ids[n.NodeID] {
+			graph.Nodes = append(graph.Nodes, n)
+		}
+	}
+	return graph, nil
+}
+
+func testGraph() *mapGraphs {
+	return &mapGraphs{
+		nodes: []Node{
+			{NodeID: "service/db", Kind: "service", Name: "db", OwnerTeam: "data", Labels: map[string]string{"namespace": "data", "cluster_id": "c1"}},
+			{NodeID: "service/api", Kind: "service", Name: "api", OwnerTeam: "core", Labels: map[string]string{"namespace": "web", "cluster_id": "c1", "traffic_share": "0.7"}},
+			{NodeID: "service/billing", Kind: "service", Name: "billing", OwnerTeam: "payments", Labels: map[string]string{"namespace": "pay", "cluster_id": "c1"}},
+			{NodeID: "k8s/ingress/web/api", Kind: "k8s.ingress", Name: "api", Labels: map[string]string{"namespace": "web", "traffic_share": "0.9"}},
+			{NodeID: "service/leaf", Kind: "service", Name: "leaf", OwnerTeam: "core"},
+			{NodeID: "service/cron", Kind: "service", Name: "cron", OwnerTeam: "core"},
+			{NodeID: "env/prod", Kind: "env", Name: "prod"},
+		},
+		edges: []Edge{
+			{EdgeID: "e1", FromNodeID: "service/api", ToNodeID: "service/db", Relation: "depends_on"},
+			{EdgeID: "e2", FromNodeID: "service/billing", ToNodeID: "service/db", Relation: "consumes"},
+			{EdgeID: "e3", FromNodeID: "k8s/ingress/web/api", ToNodeID: "service/api", Relation: "routes_to"},
+			{EdgeID: "e4", FromNodeID: "service/db", ToNodeID: "env/prod", Relation: "runs_in"},
+			{EdgeID: "e5", FromNodeID: "service/db", ToNodeID: "service/leaf", Relation: "depends_on"},
+			{EdgeID: "e6", FromNodeID: "service/cron", ToNodeID: "service/db", Relation: "monitors"},
+		},
+	}
+}
+
+func TestWalkImpactSharedDependency(t *testing.T) {
+	impact, err := WalkImpact(context.Background(), testGraph(), []string{"db"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(impact.Services) != 4 || impact.Dependents() != 3 {
+		t.Fatalf("services: %+v", impact.Services)
+	}
+	if impact.Services[0].Name != "db" || impact.Services[0].Depth != 0 {
+		t.Fatalf("target: %+v", impact.Services[0])
+	}
+	last := impact.Services[3]
+	if last.Kind != "k8s.ingress" || last.Depth != 2 || last.Via != "routes_to" {
+		t.Fatalf("ingress: %+v", last)
+	}
+	if impact.TrafficShare != 0.9 {
+		t.Fatalf("traffic: %v", impact.TrafficShare)
+	}
+	if len(impact.OwnerTeams) != 3 || impact.OwnerTeams[0] != "core" || impact.OwnerTeams[2] != "payments" {
+		t.Fatalf("teams: %v", impact.OwnerTeams)
+	}
+	if len(impact.Namespaces) != 3 || len(impact.Clusters) != 1 || impact.Truncated {
+		t.Fatalf("scope: %+v", impact)
+	}
+}
+
+func TestWalkImpactLeafService(t *testing.T) {
+	impact, err := WalkImpact(context.Background(), testGraph(), []string{"cron", "Cron", ""})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(impact.Services) != 1 || impact.Dependents() != 0 || impact.TrafficShare != 0 {
+		t.Fatalf("impact: %+v", impact)
+	}
+	if len(impact.OwnerTeams) != 1 || impact.OwnerTeams[0] != "core" {
+		t.Fatalf("teams: %v", impact.OwnerTeams)
+	}
+}
+
+func TestWalkImpactUnknownTargetAndErrors(t *testing.T) {
+	graphs := testGraph()
+	graphs.errFor = "api"
+	impact, err := WalkImpact(context.Background(), graphs, []string{"db", "ghost"})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	var ghost *ImpactedNode
+	for i := range impact.Services {
+		if impact.Services[i].Name == "ghost" {
+			ghost = &impact.Services[i]
+		}
+	}
+	if ghost == nil || ghost.Kind != "service" || ghost.OwnerTeam != "" {
+		t.Fatalf("ghost: %+v", impact.Services)
+	}
+}
+
+func TestWalkImpactDepthLimit(t *testing.T) {
+	graphs := &mapGraphs{}
+	for i := 0; i <= MaxImpactDepth+1; i++ {
+		name := string(rune('a' + i))
+		graphs.nodes = append(graphs.nodes, Node{NodeID: "service/" + name, Kind: "service", Name: name})
+		if i > 0 {
+			prev := string(rune('a' + i - 1))
+			graphs.edges = append(graphs.edges, Edge{EdgeID: name, FromNodeID: "service/" + name, ToNodeID: "service/" + prev, Relation: "calls"})
+		}
+	}
+	impact, _ := WalkImpact(context.Background(), graphs, []string{"a"})
+	if !impact.Truncated || impact.Dependents() != MaxImpactDepth {
+		t.Fatalf("impact: %+v", impact)
+	}
+}
+
+func TestTrafficShareLabel(t *testing.T) {
+	cases := map[string]float64{"": 0, "0.25": 0.25, "3": 1, "-1": 0, "x": 0}
+	for raw, want := range cases {
+		if got := trafficShare(map[string]string{"traffic_share": raw}); got != want {
+			t.Fatalf("%q: got %v want %v", raw, got, want)
+		}
+	}
+}
@@ -35,3 +35,21 @@ func (d *DB) UpsertAlertEvent(ctx context.Context, fingerprint, status string, s
 	}
 	return alertID, nil
 }
+
+// CountFiringAlerts counts alerts still firing that were seen since since.
+// Alertmanager drops resolved alerts rather than reporting them, so alerts
+// not seen recently are taken to have resolved.
+func (d *DB) CountFiringAlerts(ctx context.Context, since time.Time) (int, error) {
+	if d == nil || d.conn == nil {
+		return 0, errors.New("db required")
+	}
+	row := d.conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM alert_events
+		WHERE status IN ('firing', 'active') AND updated_at >= $1
+	`, since.UTC())
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
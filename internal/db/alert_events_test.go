@@ -147,3 +147,20 @@ func TestUpsertAlertEventExecArgs(t *testing.T) {
 		t.Fatalf("status: %v", conn.lastExecArgs[2])
 	}
 }
+
+func TestCountFiringAlerts(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{2}}}
+	d := &DB{conn: conn}
+	since := time.Now().Add(-15 * time.Minute)
+	count, err := d.CountFiringAlerts(context.Background(), since)
+	if err != nil || count != 2 {
+		t.Fatalf("count=%d err=%v", count, err)
+	}
+	if !strings.Contains(conn.lastQuery, "status IN ('firing', 'active')") || !conn.lastArgs[0].(time.Time).Equal(since) {
+		t.Fatalf("query: %s args=%v", conn.lastQuery, conn.lastArgs)
+	}
+	var nilDB *DB
+	if _, err := nilDB.CountFiringAlerts(context.Background(), since); err == nil {
+		t.Fatalf("expected error")
+	}
+}
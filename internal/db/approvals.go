@@ -2,9 +2,29 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
+	"time"
 )
 
+type approvalAttestationPayload struct {
+	PlanHash string `json:"plan_hash"`
+	Approver struct {
+		ID string `json:"id"`
+	} `json:"approver"`
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"`
+}
+
+type approvalVotePayload struct {
+	PlanHash       string          `json:"plan_hash"`
+	ApproverID     string          `json:"approver_id"`
+	ApproverEmail  string          `json:"approver_email"`
+	ApproverGroups json.RawMessage `json:"approver_groups"`
+	Decision       string          `json:"decision"`
+}
+
 func (d *DB) GetApprovalStatus(ctx context.Context, planID string) (string, error) {
 	if d == nil || d.conn == nil {
 		return "", errors.New("db not initialized")
@@ -77,3 +97,163 @@ func (d *DB) GetApprovalStatusByToken(ctx context.Context, planID, approvalID st
 	}
 	return status, nil
 }
+
+// RecordApprovalVote stores one approver's decision on a plan revision. Voting
+// again on the same revision replaces the earlier decision.
+func (d *DB) RecordApprovalVote(ctx context.Context, planID string, payload []byte) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db not initialized")
+	}
+	var vote approvalVotePayload
+	if err := json.Unmarshal(payload, &vote); err != nil {
+		return err
+	}
+	approverID := strings.TrimSpace(vote.ApproverID)
+	if approverID == "" {
+		return errors.New("approver_id required")
+	}
+	if strings.TrimSpace(vote.PlanHash) == "" {
+		return errors.New("plan_hash required")
+	}
+	if strings.TrimSpace(vote.Decision) == "" {
+		return errors.New("decision required")
+	}
+	_, err := d.conn.ExecContext(ctx, `
+		INSERT INTO approval_votes(vote_id, plan_id, plan_hash, approver_id, approver_email, approver_groups_json, decision, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (plan_id, plan_hash, approver_id)
+		DO UPDATE SET decision=EXCLUDED.decision, approver_email=EXCLUDED.approver_email, approver_groups_json=EXCLUDED.approver_groups_json, created_at=EXCLUDED.created_at
+	`, newID("vote"), planID, vote.PlanHash, approverID, nullString(strings.TrimSpace(vote.ApproverEmail)), nullJSON(vote.ApproverGroups), vote.Decision, time.Now().UTC())
+	return err
+}
+
+// ListApprovalVotes returns every vote cast on a plan, across revisions,
+// oldest first.
+func (d *DB) ListApprovalVotes(ctx context.Context, planID string) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db not initialized")
+	}
+	row := d.conn.QueryRowContext(ctx, `SELECT COALESCE(jsonb_agg(
+		jsonb_build_object(
+			'vote_id', vote_id,
+			'plan_hash', plan_hash,
+			'approver_id', approver_id,
+			'approver_email', approver_email,
+			'approver_groups', approver_groups_json,
+			'decision', decision,
+			'created_at', created_at
+		) ORDER BY created_at
+	), '[]'::jsonb) FROM approval_votes WHERE plan_id=$1`, planID)
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RecordApprovalAttestation stores a signed approval attestation as given.
+// Re-approving the same revision replaces the approver's earlier one.
+func (d *DB) RecordApprovalAttestation(ctx context.Context, planID string, payload []byte) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db not initialized")
+	}
+	var att approvalAttestationPayload
+	if err := json.Unmarshal(payload, &att); err != nil {
+		return err
+	}
+	if strings.TrimSpace(att.PlanHash) == "" {
+		return errors.New("plan_hash required")
+	}
+	if strings.TrimSpace(att.KeyID) == "" || strings.TrimSpace(att.Signature) == "" {
+		return errors.New("key_id and signature required")
+	}
+	_, err := d.conn.ExecContext(ctx, `
+		INSERT INTO approval_attestations(attestation_id, plan_id, plan_hash, approver_id, key_id, attestation_json, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (plan_id, plan_hash, approver_id)
+		DO UPDATE SET key_id=EXCLUDED.key_id, attestation_json=EXCLUDED.attestation_json, created_at=EXCLUDED.created_at
+	`, newID("attestation"), planID, att.PlanHash, strings.TrimSpace(att.Approver.ID), att.KeyID, payload, time.Now().UTC())
+	return err
+}
+
+// ListApprovalAttestations returns a plan's attestations, across revisions,
+// oldest first.
+func (d *DB) ListApprovalAttestations(ctx context.Context, planID string) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db not initialized")
+	}
+	row := d.conn.QueryRowContext(ctx, `SELECT COALESCE(jsonb_agg(attestation_json ORDER BY created_at), '[]'::jsonb) FROM approval_attestations WHERE plan_id=$1`, planID)
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListPendingApprovals returns pending approvals, oldest first, with the
+// risk level, context and constraints of their plans.
+func (d *DB) ListPendingApprovals(ctx context.Context) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db not initialized")
+	}
+	row := d.conn.QueryRowContext(ctx, `SELECT COALESCE(jsonb_agg(
+		jsonb_build_object(
+			'approval_id', a.approval_id,
+			'plan_id', a.plan_id,
+			'source', a.source,
+			'created_at', a.created_at,
+			'expires_at', a.expires_at,
+			'reminded_at', a.reminded_at,
+			'escalated_at', a.escalated_at,
+			'risk_level', p.risk_level,
+			'summary', p.summary,
+			'intent', p.intent,
+			'context', p.context_json,
+			'constraints', p.constraints_json,
+			'created_by', p.created_by,
+			'session_id', p.session_id
+		) ORDER BY a.created_at NULLS FIRST
+	), '[]'::jsonb)
+	FROM approvals a JOIN plans p ON p.plan_id = a.plan_id
+	WHERE a.status='pending'`)
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExpireApproval marks a pending approval expired. It reports false when
+// the approval was already settled.
+func (d *DB) ExpireApproval(ctx context.Context, approvalID string) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, errors.New("db not initialized")
+	}
+	res, err := d.conn.ExecContext(ctx, `UPDATE approvals SET status='expired' WHERE approval_id=$1 AND status='pending'`, approvalID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// MarkApprovalReminded records when approvers were last reminded.
+func (d *DB) MarkApprovalReminded(ctx context.Context, approvalID string, at time.Time) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db not initialized")
+	}
+	_, err := d.conn.ExecContext(ctx, `UPDATE approvals SET reminded_at=$1 WHERE approval_id=$2`, at.UTC(), approvalID)
+	return err
+}
+
+// MarkApprovalEscalated records that a pending approval was paged out.
+func (d *DB) MarkApprovalEscalated(ctx context.Context, approvalID string, at time.Time) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db not initialized")
+	}
+	_, err := d.conn.ExecContext(ctx, `UPDATE approvals SET escalated_at=$1 WHERE approval_id=$2`, at.UTC(), approvalID)
+	return err
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetApprovalStatusNoDB(t *testing.T) {
@@ -180,3 +182,142 @@ func TestGetApprovalHashRowError(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestRecordApprovalVote(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	payload := []byte(`{"plan_hash":"h1","approver_id":"alice","approver_email":"alice@example.com","approver_groups":["sre"],"decision":"approved"}`)
+	if err := d.RecordApprovalVote(context.Background(), "plan", payload); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "ON CONFLICT (plan_id, plan_hash, approver_id)") {
+		t.Fatalf("query: %s", conn.lastExecQuery)
+	}
+	args := conn.lastExecArgs
+	if args[1] != "plan" || args[2] != "h1" || args[3] != "alice" || args[4] != "alice@example.com" || args[6] != "approved" {
+		t.Fatalf("args: %#v", args)
+	}
+}
+
+func TestRecordApprovalVoteInvalid(t *testing.T) {
+	d := &DB{conn: &fakeConn{}}
+	for _, payload := range []string{"{", `{"plan_hash":"h1","decision":"approved"}`, `{"approver_id":"a","decision":"approved"}`, `{"approver_id":"a","plan_hash":"h1"}`} {
+		if err := d.RecordApprovalVote(context.Background(), "plan", []byte(payload)); err == nil {
+			t.Fatalf("expected error for %s", payload)
+		}
+	}
+	var nilDB *DB
+	if err := nilDB.RecordApprovalVote(context.Background(), "plan", []byte(`{}`)); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestListApprovalVotes(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`[{"approver_id":"alice"}]`)}}}
+	d := &DB{conn: conn}
+	out, err := d.ListApprovalVotes(context.Background(), "plan")
+	if err != nil || !strings.Contains(string(out), "alice") {
+		t.Fatalf("out=%s err=%v", out, err)
+	}
+	if !strings.Contains(conn.lastQuery, "FROM approval_votes WHERE plan_id=$1") || conn.lastArgs[0] != "plan" {
+		t.Fatalf("query: %s", conn.lastQuery)
+	}
+	d = &DB{conn: &fakeConn{row: fakeRow{err: sql.ErrConnDone}}}
+	if _, err := d.ListApprovalVotes(context.Background(), "plan"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestRecordApprovalAttestation(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	payload := []byte(`{"plan_hash":"h1","approver":{"id":"alice"},"key_id":"k1","signature":"c2ln"}`)
+	if err := d.RecordApprovalAttestation(context.Background(), "plan", payload); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "INSERT INTO approval_attestations") {
+		t.Fatalf("query: %s", conn.lastExecQuery)
+	}
+	args := conn.lastExecArgs
+	if args[1] != "plan" || args[2] != "h1" || args[3] != "alice" || args[4] != "k1" || string(args[5].([]byte)) != string(payload) {
+		t.Fatalf("args: %#v", args)
+	}
+	for _, bad := range []string{"{", `{"key_id":"k1","signature":"c2ln"}`, `{"plan_hash":"h1","key_id":"k1"}`} {
+		if err := d.RecordApprovalAttestation(context.Background(), "plan", []byte(bad)); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestListApprovalAttestations(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`[{"key_id":"k1"}]`)}}}
+	d := &DB{conn: conn}
+	out, err := d.ListApprovalAttestations(context.Background(), "plan")
+	if err != nil || !strings.Contains(string(out), "k1") {
+		t.Fatalf("out=%s err=%v", out, err)
+	}
+	if !strings.Contains(conn.lastQuery, "FROM approval_attestations WHERE plan_id=$1") {
+		t.Fatalf("query: %s", conn.lastQuery)
+	}
+	var nilDB *DB
+	if _, err := nilDB.ListApprovalAttestations(context.Background(), "plan"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestListPendingApprovals(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`[{"approval_id":"a1"}]`)}}}
+	d := &DB{conn: conn}
+	out, err := d.ListPendingApprovals(context.Background())
+	if err != nil || !strings.Contains(string(out), "a1") {
+		t.Fatalf("out=%s err=%v", out, err)
+	}
+	if !strings.Contains(conn.lastQuery, "JOIN plans p") || !strings.Contains(conn.lastQuery, "a.status='pending'") {
+		t.Fatalf("query: %s", conn.lastQuery)
+	}
+	var nilDB *DB
+	if _, err := nilDB.ListPendingApprovals(context.Background()); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestExpireApproval(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	expired, err := d.ExpireApproval(context.Background(), "a1")
+	if err != nil || !expired {
+		t.Fatalf("expired=%v err=%v", expired, err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "status='expired'") || !strings.Contains(conn.lastExecQuery, "status='pending'") || conn.lastExecArgs[0] != "a1" {
+		t.Fatalf("query: %s args=%v", conn.lastExecQuery, conn.lastExecArgs)
+	}
+	d = &DB{conn: &fakeConn{execErr: errors.New("exec")}}
+	if _, err := d.ExpireApproval(context.Background(), "a1"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestMarkApprovalRemindedAndEscalated(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if err := d.MarkApprovalReminded(context.Background(), "a1", at); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "SET reminded_at=$1") || conn.lastExecArgs[0] != at || conn.lastExecArgs[1] != "a1" {
+		t.Fatalf("query: %s args=%v", conn.lastExecQuery, conn.lastExecArgs)
+	}
+	if err := d.MarkApprovalEscalated(context.Background(), "a1", at); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "SET escalated_at=$1") {
+		t.Fatalf("query: %s", conn.lastExecQuery)
+	}
+	var nilDB *DB
+	if err := nilDB.MarkApprovalReminded(context.Background(), "a1", at); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := nilDB.MarkApprovalEscalated(context.Background(), "a1", at); err == nil {
+		t.Fatalf("expected error")
+	}
+}
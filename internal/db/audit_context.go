@@ -14,8 +14,11 @@ type AuditFilter struct {
 	ActorID  string
 	Action   string
 	Decision string
-	Limit    int
-	Offset   int
+	// HasPolicyInput keeps only events recorded with the policy input
+	// they were decided on.
+	HasPolicyInput bool
+	Limit          int
+	Offset         int
 }
 
 func (d *DB) ListAuditEvents(ctx context.Context, filter AuditFilter) ([]byte, int, error) {
@@ -55,6 +58,9 @@ func (d *DB) ListAuditEvents(ctx context.Context, filter AuditFilter) ([]byte, i
 		args = append(args, filter.Decision)
 		arg++
 	}
+	if filter.HasPolicyInput {
+		where = append(where, "policy_input_json IS NOT NULL")
+	}
 	whereClause := ""
 	if len(where) > 0 {
 		whereClause = " WHERE " + strings.Join(where, " AND ")
@@ -70,7 +76,10 @@ func (d *DB) ListAuditEvents(ctx context.Context, filter AuditFilter) ([]byte, i
 			'decision', decision,
 			'context', context_json,
 			'evidence_refs', evidence_refs_json,
-			'hash', hash
+			'hash', hash,
+			'reasons', reasons_json,
+			'policy_input', policy_input_json,
+			'policy_revision', policy_revision
 		) ORDER BY occurred_at DESC
 	), '[]'::jsonb),
 	COALESCE((SELECT total_count FROM paged LIMIT 1), 0)
@@ -29,12 +29,13 @@ func TestListAuditEventsFilters(t *testing.T) {
 	conn := &fakeConn{row: row}
 	d := &DB{conn: conn}
 	filter := AuditFilter{
-		TenantID: "t",
-		From:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		To:       time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
-		ActorID:  "actor",
-		Action:   "deploy",
-		Decision: "allow",
+		TenantID:       "t",
+		From:           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		ActorID:        "actor",
+		Action:         "deploy",
+		Decision:       "allow",
+		HasPolicyInput: true,
 	}
 	if _, _, err := d.ListAuditEvents(context.Background(), filter); err != nil {
 		t.Fatalf("err: %v", err)
@@ -57,6 +58,9 @@ func TestListAuditEventsFilters(t *testing.T) {
 	if !strings.Contains(conn.lastQuery, "context_json") {
 		t.Fatalf("missing tenant filter")
 	}
+	if !strings.Contains(conn.lastQuery, "policy_input_json IS NOT NULL") {
+		t.Fatalf("missing policy input filter")
+	}
 	if got := len(conn.lastArgs); got != 8 {
 		t.Fatalf("args: got %d, want 8 (6 filters + limit + offset)", got)
 	}
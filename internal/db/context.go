@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 
 	ctxmodel "carapulse/internal/context"
 )
@@ -98,3 +99,41 @@ func (d *DB) GetServiceGraph(ctx context.Context, service string) ([]byte, error
 	}
 	return out, nil
 }
+
+// ListTelemetryVocabulary returns the metric, trace service and trace tag
+// nodes the Prometheus and Tempo pollers discovered for a tenant, as a JSON
+// array of {node_id, kind, name, labels}. limit applies to each kind, so a
+// large metric catalogue never crowds out the trace vocabulary.
+func (d *DB) ListTelemetryVocabulary(ctx context.Context, tenantID string, limit int) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db required")
+	}
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return nil, errors.New("tenant_id required")
+	}
+	if limit <= 0 {
+		limit = 1000
+	}
+	row := d.conn.QueryRowContext(ctx, `
+		SELECT COALESCE(jsonb_agg(jsonb_build_object(
+			'node_id', node_id,
+			'kind', kind,
+			'name', name,
+			'labels', labels_json
+		) ORDER BY kind, name), '[]'::jsonb)
+		FROM (
+			SELECT node_id, kind, name, labels_json,
+				row_number() OVER (PARTITION BY kind ORDER BY name) AS kind_rank
+			FROM context_nodes
+			WHERE kind IN ('prometheus.metric', 'tempo.service', 'tempo.tags')
+			  AND labels_json->>'tenant_id' = $1
+		) AS vocab
+		WHERE kind_rank <= $2
+	`, tenantID, limit)
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
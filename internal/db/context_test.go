@@ -162,3 +162,28 @@ func TestGetServiceGraphRowError(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestListTelemetryVocabulary(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`[{"kind":"prometheus.metric","name":"up"}]`)}}}
+	d := &DB{conn: conn}
+	out, err := d.ListTelemetryVocabulary(context.Background(), " t ", 0)
+	if err != nil || !strings.Contains(string(out), "prometheus.metric") {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+	if !strings.Contains(conn.lastQuery, "'tempo.service'") || conn.lastArgs[0] != "t" || conn.lastArgs[1] != 1000 {
+		t.Fatalf("query: %s args: %#v", conn.lastQuery, conn.lastArgs)
+	}
+	// The tenant filter and the limit run in SQL, the limit per kind.
+	for _, part := range []string{"labels_json->>'tenant_id' = $1", "PARTITION BY kind", "kind_rank <= $2"} {
+		if !strings.Contains(conn.lastQuery, part) {
+			t.Fatalf("query missing %s: %s", part, conn.lastQuery)
+		}
+	}
+	if _, err := d.ListTelemetryVocabulary(context.Background(), "", 10); err == nil {
+		t.Fatalf("expected tenant error")
+	}
+	d = &DB{conn: &fakeConn{row: fakeRow{err: sql.ErrConnDone}}}
+	if _, err := d.ListTelemetryVocabulary(context.Background(), "t", 10); err == nil {
+		t.Fatalf("expected error")
+	}
+}
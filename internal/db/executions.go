@@ -63,3 +63,24 @@ func (d *DB) UpdateToolCall(ctx context.Context, toolCallID, status, inputRef, o
 	`, status, nullString(inputRef), nullString(outputRef), toolCallID)
 	return err
 }
+
+// CountFailedExecutions counts executions completed as failed since the
+// given time whose plan has a step targeting service.
+func (d *DB) CountFailedExecutions(ctx context.Context, service string, since time.Time) (int, error) {
+	if service == "" {
+		return 0, errors.New("service required")
+	}
+	var count int
+	err := d.conn.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT e.execution_id)
+		FROM executions e
+		JOIN plan_steps s ON s.plan_id = e.plan_id
+		WHERE e.status = 'failed'
+		  AND e.completed_at >= $2
+		  AND $1 IN (s.input_json->>'service', s.input_json->>'app', s.input_json->>'application', s.input_json->>'release', s.input_json->>'deployment', s.input_json->>'name')
+	`, service, since).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestListExecutionsByStatusResults(t *testing.T) {
@@ -175,3 +176,19 @@ func TestUpdateToolCallWithRefs(t *testing.T) {
 		t.Fatalf("output_ref: %v", conn.lastExecArgs[2])
 	}
 }
+
+func TestCountFailedExecutions(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{3}}}
+	d := &DB{conn: conn}
+	since := time.Now().Add(-time.Hour)
+	count, err := d.CountFailedExecutions(context.Background(), "checkout", since)
+	if err != nil || count != 3 {
+		t.Fatalf("count %d err %v", count, err)
+	}
+	if !strings.Contains(conn.lastQuery, "e.status = 'failed'") || conn.lastArgs[0] != "checkout" || conn.lastArgs[1] != since {
+		t.Fatalf("query %s args %#v", conn.lastQuery, conn.lastArgs)
+	}
+	if _, err := d.CountFailedExecutions(context.Background(), "", since); err == nil {
+		t.Fatalf("expected error for empty service")
+	}
+}
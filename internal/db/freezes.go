@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+type freezePayload struct {
+	Name         string          `json:"name"`
+	Reason       string          `json:"reason"`
+	StartsAt     *time.Time      `json:"starts_at"`
+	EndsAt       *time.Time      `json:"ends_at"`
+	Recurrence   json.RawMessage `json:"recurrence"`
+	Timezone     string          `json:"timezone"`
+	Environments json.RawMessage `json:"environments"`
+	Services     json.RawMessage `json:"services"`
+	Source       string          `json:"source"`
+	SourceRef    string          `json:"source_ref"`
+	ExternalID   string          `json:"external_id"`
+	CreatedBy    string          `json:"created_by"`
+}
+
+const freezeObject = `jsonb_build_object(
+			'freeze_id', freeze_id,
+			'name', name,
+			'reason', reason,
+			'starts_at', starts_at,
+			'ends_at', ends_at,
+			'recurrence', recurrence_json,
+			'timezone', timezone,
+			'environments', environments_json,
+			'services', services_json,
+			'source', source,
+			'source_ref', source_ref,
+			'external_id', external_id,
+			'created_by', created_by,
+			'created_at', created_at
+		)`
+
+func (d *DB) CreateFreeze(ctx context.Context, payload []byte) (string, error) {
+	if d == nil || d.conn == nil {
+		return "", errors.New("db required")
+	}
+	var data freezePayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(data.Name)
+	if name == "" {
+		return "", errors.New("name required")
+	}
+	source := strings.TrimSpace(data.Source)
+	if source == "" {
+		source = "api"
+	}
+	id := newID("freeze")
+	_, err := d.conn.ExecContext(ctx, `
+		INSERT INTO freezes(freeze_id, name, reason, starts_at, ends_at, recurrence_json, timezone, environments_json, services_json, source, source_ref, external_id, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, id, name, nullString(strings.TrimSpace(data.Reason)), nullTimePtr(data.StartsAt), nullTimePtr(data.EndsAt), nullJSON(data.Recurrence),
+		nullString(strings.TrimSpace(data.Timezone)), nullJSON(data.Environments), nullJSON(data.Services), source,
+		nullString(strings.TrimSpace(data.SourceRef)), nullString(strings.TrimSpace(data.ExternalID)), nullString(strings.TrimSpace(data.CreatedBy)), time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// ListFreezes returns every freeze that has not ended, oldest first.
+// Recurring freezes without an end are always included.
+func (d *DB) ListFreezes(ctx context.Context) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db required")
+	}
+	row := d.conn.QueryRowContext(ctx, `
+		SELECT COALESCE(jsonb_agg(`+freezeObject+` ORDER BY created_at), '[]'::jsonb)
+		FROM freezes WHERE ends_at IS NULL OR ends_at > $1
+	`, time.Now().UTC())
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (d *DB) GetFreeze(ctx context.Context, freezeID string) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db required")
+	}
+	freezeID = strings.TrimSpace(freezeID)
+	if freezeID == "" {
+		return nil, errors.New("freeze_id required")
+	}
+	row := d.conn.QueryRowContext(ctx, `SELECT `+freezeObject+` FROM freezes WHERE freeze_id=$1`, freezeID)
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func (d *DB) DeleteFreeze(ctx context.Context, freezeID string) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db required")
+	}
+	freezeID = strings.TrimSpace(freezeID)
+	if freezeID == "" {
+		return errors.New("freeze_id required")
+	}
+	_, err := d.conn.ExecContext(ctx, `DELETE FROM freezes WHERE freeze_id=$1`, freezeID)
+	return err
+}
+
+// DeleteFreezesBySource removes the freezes imported from one calendar so
+// a re-import replaces them rather than piling up duplicates.
+func (d *DB) DeleteFreezesBySource(ctx context.Context, source, sourceRef string) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db required")
+	}
+	source, sourceRef = strings.TrimSpace(source), strings.TrimSpace(sourceRef)
+	if source == "" || sourceRef == "" {
+		return errors.New("source and source_ref required")
+	}
+	_, err := d.conn.ExecContext(ctx, `DELETE FROM freezes WHERE source=$1 AND source_ref=$2`, source, sourceRef)
+	return err
+}
+
+func nullTimePtr(value *time.Time) any {
+	if value == nil || value.IsZero() {
+		return nil
+	}
+	return value.UTC()
+}
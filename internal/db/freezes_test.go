@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateFreeze(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	start := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	payload, _ := json.Marshal(map[string]any{
+		"name":         "holidays",
+		"starts_at":    start,
+		"ends_at":      start.AddDate(0, 0, 14),
+		"environments": []string{"prod"},
+		"created_by":   "alice",
+	})
+	id, err := d.CreateFreeze(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.HasPrefix(id, "freeze_") {
+		t.Fatalf("id: %s", id)
+	}
+	if !strings.Contains(conn.lastExecQuery, "INSERT INTO freezes") {
+		t.Fatalf("query: %s", conn.lastExecQuery)
+	}
+	args := conn.lastExecArgs
+	if args[1] != "holidays" || args[3] != start || args[5] != nil || args[9] != "api" || args[12] != "alice" {
+		t.Fatalf("args: %#v", args)
+	}
+	if string(args[7].([]byte)) != `["prod"]` {
+		t.Fatalf("environments: %#v", args[7])
+	}
+}
+
+func TestCreateFreezeRequiresName(t *testing.T) {
+	d := &DB{conn: &fakeConn{}}
+	if _, err := d.CreateFreeze(context.Background(), []byte(`{"reason":"x"}`)); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := d.CreateFreeze(context.Background(), []byte(`{`)); err == nil {
+		t.Fatalf("expected error")
+	}
+	var nilDB *DB
+	if _, err := nilDB.CreateFreeze(context.Background(), []byte(`{"name":"x"}`)); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestListFreezes(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`[{"freeze_id":"freeze_1"}]`)}}}
+	d := &DB{conn: conn}
+	out, err := d.ListFreezes(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != `[{"freeze_id":"freeze_1"}]` {
+		t.Fatalf("out: %s", out)
+	}
+	if !strings.Contains(conn.lastQuery, "ends_at IS NULL OR ends_at >") {
+		t.Fatalf("query: %s", conn.lastQuery)
+	}
+}
+
+func TestGetFreeze(t *testing.T) {
+	d := &DB{conn: &fakeConn{row: fakeRow{err: sql.ErrNoRows}}}
+	out, err := d.GetFreeze(context.Background(), "freeze_1")
+	if err != nil || out != nil {
+		t.Fatalf("out=%s err=%v", out, err)
+	}
+	d = &DB{conn: &fakeConn{row: fakeRow{values: []any{[]byte(`{"freeze_id":"freeze_1"}`)}}}}
+	if out, err := d.GetFreeze(context.Background(), "freeze_1"); err != nil || len(out) == 0 {
+		t.Fatalf("out=%s err=%v", out, err)
+	}
+	if _, err := d.GetFreeze(context.Background(), " "); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestDeleteFreezes(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	if err := d.DeleteFreeze(context.Background(), "freeze_1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "DELETE FROM freezes WHERE freeze_id") {
+		t.Fatalf("query: %s", conn.lastExecQuery)
+	}
+	if err := d.DeleteFreezesBySource(context.Background(), "ical", "https://cal/x.ics"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conn.lastExecArgs[0] != "ical" || conn.lastExecArgs[1] != "https://cal/x.ics" {
+		t.Fatalf("args: %#v", conn.lastExecArgs)
+	}
+	if err := d.DeleteFreezesBySource(context.Background(), "ical", ""); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+type llmUsagePayload struct {
+	TenantID         string  `json:"tenant_id"`
+	PlanID           string  `json:"plan_id"`
+	Trigger          string  `json:"trigger"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	LatencyMS        int64   `json:"latency_ms"`
+	FallbackIndex    int     `json:"fallback_index"`
+}
+
+// InsertLLMUsage records the token usage of one plan draft.
+func (d *DB) InsertLLMUsage(ctx context.Context, payload []byte) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db required")
+	}
+	var data llmUsagePayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return err
+	}
+	provider := strings.TrimSpace(data.Provider)
+	if provider == "" {
+		return errors.New("provider required")
+	}
+	if data.TotalTokens == 0 {
+		data.TotalTokens = data.PromptTokens + data.CompletionTokens
+	}
+	_, err := d.conn.ExecContext(ctx, `
+		INSERT INTO llm_usage(usage_id, tenant_id, plan_id, trigger, provider, model, prompt_tokens, completion_tokens, total_tokens, cost_usd, latency_ms, fallback_index, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, newID("llmusage"), strings.TrimSpace(data.TenantID), nullString(strings.TrimSpace(data.PlanID)), nullString(strings.TrimSpace(data.Trigger)),
+		provider, data.Model, data.PromptTokens, data.CompletionTokens, data.TotalTokens, data.CostUSD, data.LatencyMS, data.FallbackIndex, time.Now().UTC())
+	return err
+}
+
+// LLMUsageSince sums a tenant's tokens and estimated cost from since on.
+func (d *DB) LLMUsageSince(ctx context.Context, tenantID string, since time.Time) (int64, float64, error) {
+	if d == nil || d.conn == nil {
+		return 0, 0, errors.New("db required")
+	}
+	var tokens int64
+	var cost float64
+	err := d.conn.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(total_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM llm_usage WHERE tenant_id=$1 AND created_at >= $2
+	`, strings.TrimSpace(tenantID), since).Scan(&tokens, &cost)
+	return tokens, cost, err
+}
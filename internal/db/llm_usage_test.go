@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInsertLLMUsage(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	payload, _ := json.Marshal(map[string]any{
+		"tenant_id":         "t1",
+		"plan_id":           "plan_1",
+		"provider":          "openai",
+		"model":             "gpt-4o",
+		"prompt_tokens":     100,
+		"completion_tokens": 20,
+		"cost_usd":          0.01,
+	})
+	if err := d.InsertLLMUsage(context.Background(), payload); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "INSERT INTO llm_usage") {
+		t.Fatalf("query: %s", conn.lastExecQuery)
+	}
+	if got := conn.lastExecArgs[8]; got != 120 {
+		t.Fatalf("total tokens: %v", got)
+	}
+}
+
+func TestInsertLLMUsageErrors(t *testing.T) {
+	d := &DB{conn: &fakeConn{}}
+	if err := d.InsertLLMUsage(context.Background(), []byte("{")); err == nil {
+		t.Fatalf("expected json error")
+	}
+	payload, _ := json.Marshal(map[string]any{"tenant_id": "t1"})
+	if err := d.InsertLLMUsage(context.Background(), payload); err == nil {
+		t.Fatalf("expected provider error")
+	}
+	var nilDB *DB
+	if err := nilDB.InsertLLMUsage(context.Background(), payload); err == nil {
+		t.Fatalf("expected db error")
+	}
+}
+
+func TestLLMUsageSince(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{int64(1500), 0.25}}}
+	d := &DB{conn: conn}
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	tokens, cost, err := d.LLMUsageSince(context.Background(), "t1", since)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if tokens != 1500 || cost != 0.25 {
+		t.Fatalf("tokens=%d cost=%v", tokens, cost)
+	}
+	if !strings.Contains(conn.lastQuery, "FROM llm_usage") || conn.lastArgs[1] != since {
+		t.Fatalf("query: %s args: %v", conn.lastQuery, conn.lastArgs)
+	}
+	conn.row = fakeRow{err: errTest}
+	if _, _, err := d.LLMUsageSince(context.Background(), "t1", since); err == nil {
+		t.Fatalf("expected error")
+	}
+}
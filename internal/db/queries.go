@@ -40,6 +40,10 @@ type auditPayload struct {
 	Context      json.RawMessage `json:"context"`
 	EvidenceRefs json.RawMessage `json:"evidence_refs"`
 	Hash         string          `json:"hash"`
+	Reasons      json.RawMessage `json:"reasons"`
+	PolicyInput  json.RawMessage `json:"policy_input"`
+	// PolicyRevision is the policy bundle revision behind Decision.
+	PolicyRevision string `json:"policy_revision"`
 }
 
 func (d *DB) CreatePlan(ctx context.Context, planJSON []byte) (string, error) {
@@ -54,6 +58,7 @@ func (d *DB) CreatePlan(ctx context.Context, planJSON []byte) (string, error) {
 	intent, _ := payload["intent"].(string)
 	planText, _ := payload["plan_text"].(string)
 	sessionID, _ := payload["session_id"].(string)
+	createdBy, _ := payload["created_by"].(string)
 	contextJSON, _ := json.Marshal(payload["context"])
 	var constraintsJSON any
 	if payload["constraints"] != nil {
@@ -65,6 +70,15 @@ func (d *DB) CreatePlan(ctx context.Context, planJSON []byte) (string, error) {
 		encoded, _ := json.Marshal(payload["meta"])
 		metaJSON = encoded
 	}
+	var riskScore any
+	if score, ok := payload["risk_score"].(float64); ok {
+		riskScore = int(score)
+	}
+	var riskFactorsJSON any
+	if payload["risk_factors"] != nil {
+		encoded, _ := json.Marshal(payload["risk_factors"])
+		riskFactorsJSON = encoded
+	}
 	steps, err := decodePlanSteps(payload["steps"])
 	if err != nil {
 		return "", err
@@ -72,9 +86,9 @@ func (d *DB) CreatePlan(ctx context.Context, planJSON []byte) (string, error) {
 	now := time.Now().UTC()
 	err = d.withTx(ctx, func(conn dbConn) error {
 		_, err := conn.ExecContext(ctx, `
-			INSERT INTO plans(plan_id, created_at, trigger, summary, context_json, risk_level, intent, constraints_json, plan_text, session_id, meta_json)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		`, planID, now, trigger, summary, contextJSON, risk, nullString(intent), constraintsJSON, nullString(planText), nullString(sessionID), metaJSON)
+			INSERT INTO plans(plan_id, created_at, trigger, summary, context_json, risk_level, intent, constraints_json, plan_text, session_id, meta_json, risk_score, risk_factors_json, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, planID, now, trigger, summary, contextJSON, risk, nullString(intent), constraintsJSON, nullString(planText), nullString(sessionID), metaJSON, riskScore, riskFactorsJSON, nullString(createdBy))
 		if err != nil {
 			return err
 		}
@@ -98,11 +112,14 @@ func (d *DB) GetPlan(ctx context.Context, planID string) ([]byte, error) {
 	var sessionID sql.NullString
 	var metaJSON []byte
 	var constraintsJSON []byte
+	var riskScore sql.NullInt64
+	var riskFactorsJSON []byte
+	var createdBy sql.NullString
 	row := d.conn.QueryRowContext(ctx, `
-		SELECT created_at, trigger, summary, context_json, risk_level, intent, constraints_json, plan_text, session_id, meta_json
+		SELECT created_at, trigger, summary, context_json, risk_level, intent, constraints_json, plan_text, session_id, meta_json, risk_score, risk_factors_json, created_by
 		FROM plans WHERE plan_id=$1
 	`, planID)
-	if err := row.Scan(&createdAt, &trigger, &summary, &contextJSON, &risk, &intent, &constraintsJSON, &planText, &sessionID, &metaJSON); err != nil {
+	if err := row.Scan(&createdAt, &trigger, &summary, &contextJSON, &risk, &intent, &constraintsJSON, &planText, &sessionID, &metaJSON, &riskScore, &riskFactorsJSON, &createdBy); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -131,6 +148,15 @@ func (d *DB) GetPlan(ctx context.Context, planID string) ([]byte, error) {
 	if len(metaJSON) > 0 {
 		out["meta"] = json.RawMessage(metaJSON)
 	}
+	if riskScore.Valid {
+		out["risk_score"] = riskScore.Int64
+	}
+	if len(riskFactorsJSON) > 0 {
+		out["risk_factors"] = json.RawMessage(riskFactorsJSON)
+	}
+	if createdBy.Valid {
+		out["created_by"] = createdBy.String
+	}
 	return json.Marshal(out)
 }
 
@@ -427,6 +453,9 @@ func (d *DB) InsertAuditEvent(ctx context.Context, payload []byte) (string, erro
 	actorJSON := []byte("{}")
 	contextJSON := []byte("{}")
 	evidenceJSON := []byte("[]")
+	reasonsJSON := []byte("[]")
+	var policyInputJSON any
+	policyRevision := ""
 	hash := ""
 	if len(payload) > 0 {
 		var data auditPayload
@@ -458,11 +487,18 @@ func (d *DB) InsertAuditEvent(ctx context.Context, payload []byte) (string, erro
 		if len(data.EvidenceRefs) > 0 {
 			evidenceJSON = data.EvidenceRefs
 		}
+		if len(data.Reasons) > 0 && string(data.Reasons) != "null" {
+			reasonsJSON = data.Reasons
+		}
+		if len(data.PolicyInput) > 0 && string(data.PolicyInput) != "null" {
+			policyInputJSON = []byte(data.PolicyInput)
+		}
+		policyRevision = data.PolicyRevision
 	}
 	_, err := d.conn.ExecContext(ctx, `
-		INSERT INTO audit_events(event_id, occurred_at, actor_json, action, decision, context_json, evidence_refs_json, hash)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`, id, occurredAt, actorJSON, action, decision, contextJSON, evidenceJSON, hash)
+		INSERT INTO audit_events(event_id, occurred_at, actor_json, action, decision, context_json, evidence_refs_json, hash, reasons_json, policy_input_json, policy_revision)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, id, occurredAt, actorJSON, action, decision, contextJSON, evidenceJSON, hash, reasonsJSON, policyInputJSON, policyRevision)
 	if err != nil {
 		return "", err
 	}
@@ -487,6 +523,7 @@ func (d *DB) ListPlans(ctx context.Context, limit, offset int) ([]byte, int, err
 			'summary', summary,
 			'context', context_json,
 			'risk_level', risk_level,
+			'risk_score', risk_score,
 			'intent', intent,
 			'status', COALESCE((SELECT status FROM approvals WHERE approvals.plan_id = sub.plan_id ORDER BY expires_at DESC NULLS LAST LIMIT 1), 'none')
 		) ORDER BY created_at DESC
@@ -543,12 +580,25 @@ func (d *DB) CancelExecution(ctx context.Context, executionID string) error {
 	return nil
 }
 
+// CreateApproval opens a pending approval. payload may set expires_at.
 func (d *DB) CreateApproval(ctx context.Context, planID string, payload []byte) (string, error) {
+	var req struct {
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return "", err
+		}
+	}
+	var expiresAt any
+	if !req.ExpiresAt.IsZero() {
+		expiresAt = req.ExpiresAt.UTC()
+	}
 	id := newID("approval")
 	_, err := d.conn.ExecContext(ctx, `
-		INSERT INTO approvals(approval_id, plan_id, status, source)
-		VALUES ($1, $2, $3, $4)
-	`, id, planID, "pending", "linear")
+		INSERT INTO approvals(approval_id, plan_id, status, source, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, id, planID, "pending", "linear", time.Now().UTC(), expiresAt)
 	if err != nil {
 		return "", err
 	}
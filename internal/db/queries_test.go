@@ -38,10 +38,16 @@ func (r fakeRow) Scan(dest ...any) error {
 			*d = r.values[i].(sql.NullTime)
 		case *sql.NullString:
 			*d = r.values[i].(sql.NullString)
+		case *sql.NullInt64:
+			*d = r.values[i].(sql.NullInt64)
 		case *bool:
 			*d = r.values[i].(bool)
 		case *int:
 			*d = r.values[i].(int)
+		case *int64:
+			*d = r.values[i].(int64)
+		case *float64:
+			*d = r.values[i].(float64)
 		default:
 			// ignore unsupported
 		}
@@ -132,6 +138,38 @@ func TestCreatePlanWithConstraints(t *testing.T) {
 	}
 }
 
+func TestCreatePlanRiskScore(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	payload := map[string]any{
+		"trigger":      "manual",
+		"summary":      "s",
+		"context":      map[string]any{"tenant_id": "t"},
+		"risk_level":   "medium",
+		"risk_score":   45,
+		"risk_factors": []map[string]any{{"name": "environment", "points": 15}},
+	}
+	data, _ := json.Marshal(payload)
+	if _, err := d.CreatePlan(context.Background(), data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	args := conn.execArgs[0]
+	if args[11] != 45 || !strings.Contains(string(args[12].([]byte)), "environment") {
+		t.Fatalf("args: %#v", args)
+	}
+
+	// Plans scored before the risk engine keep NULLs.
+	conn = &fakeConn{}
+	d = &DB{conn: conn}
+	data, _ = json.Marshal(map[string]any{"trigger": "manual", "summary": "s", "risk_level": "low"})
+	if _, err := d.CreatePlan(context.Background(), data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conn.execArgs[0][11] != nil || conn.execArgs[0][12] != nil {
+		t.Fatalf("args: %#v", conn.execArgs[0])
+	}
+}
+
 func TestCreatePlanBadJSON(t *testing.T) {
 	d := &DB{conn: &fakeConn{}}
 	if _, err := d.CreatePlan(context.Background(), []byte("{")); err == nil {
@@ -324,6 +362,9 @@ func TestGetPlan(t *testing.T) {
 		sql.NullString{String: "plan", Valid: true},
 		sql.NullString{String: "sess", Valid: true},
 		[]byte(`{"diagnostics":[]}`),
+		sql.NullInt64{Int64: 30, Valid: true},
+		[]byte(`[{"name":"environment","points":15}]`),
+		sql.NullString{String: "alice", Valid: true},
 	}}
 	d := &DB{conn: &fakeConn{row: row}}
 	out, err := d.GetPlan(context.Background(), "plan_1")
@@ -349,6 +390,12 @@ func TestGetPlan(t *testing.T) {
 	if decoded["meta"] == nil {
 		t.Fatalf("missing meta")
 	}
+	if decoded["risk_score"] != float64(30) || decoded["risk_factors"] == nil {
+		t.Fatalf("risk: %#v", decoded)
+	}
+	if decoded["created_by"] != "alice" {
+		t.Fatalf("created_by: %#v", decoded)
+	}
 }
 
 func TestGetPlanRowError(t *testing.T) {
@@ -553,13 +600,16 @@ func TestInsertAuditEvent(t *testing.T) {
 	conn := &fakeConn{}
 	d := &DB{conn: conn}
 	payload := map[string]any{
-		"occurred_at":   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
-		"actor":         map[string]any{"id": "actor"},
-		"action":        "deploy",
-		"decision":      "allow",
-		"context":       map[string]any{"tenant_id": "t"},
-		"evidence_refs": []string{"e1"},
-		"hash":          "h",
+		"occurred_at":     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		"actor":           map[string]any{"id": "actor"},
+		"action":          "deploy",
+		"decision":        "allow",
+		"context":         map[string]any{"tenant_id": "t"},
+		"evidence_refs":   []string{"e1"},
+		"hash":            "h",
+		"reasons":         []map[string]any{{"rule": "prod_write", "message": "m"}},
+		"policy_input":    map[string]any{"action": map[string]any{"name": "deploy", "type": "write"}},
+		"policy_revision": "r1",
 	}
 	data, _ := json.Marshal(payload)
 	id, err := d.InsertAuditEvent(context.Background(), data)
@@ -569,12 +619,21 @@ func TestInsertAuditEvent(t *testing.T) {
 	if id == "" {
 		t.Fatalf("empty id")
 	}
-	if len(conn.lastExecArgs) < 8 {
+	if len(conn.lastExecArgs) < 9 {
 		t.Fatalf("missing args")
 	}
 	if conn.lastExecArgs[3] != "deploy" || conn.lastExecArgs[4] != "allow" {
 		t.Fatalf("args: %#v", conn.lastExecArgs)
 	}
+	if reasons, _ := conn.lastExecArgs[8].([]byte); !strings.Contains(string(reasons), "prod_write") {
+		t.Fatalf("reasons: %#v", conn.lastExecArgs[8])
+	}
+	if input, _ := conn.lastExecArgs[9].([]byte); !strings.Contains(string(input), `"type":"write"`) {
+		t.Fatalf("policy input: %#v", conn.lastExecArgs[9])
+	}
+	if conn.lastExecArgs[10] != "r1" {
+		t.Fatalf("policy revision: %#v", conn.lastExecArgs[10])
+	}
 }
 
 func TestInsertAuditEventError(t *testing.T) {
@@ -610,6 +669,9 @@ func TestInsertAuditEventEmptyPayload(t *testing.T) {
 	if conn.lastExecArgs[3] != "unknown" || conn.lastExecArgs[4] != "allow" {
 		t.Fatalf("args: %#v", conn.lastExecArgs)
 	}
+	if conn.lastExecArgs[9] != nil {
+		t.Fatalf("policy input should be NULL: %#v", conn.lastExecArgs[9])
+	}
 }
 
 func TestCreateApproval(t *testing.T) {
@@ -623,6 +685,24 @@ func TestCreateApproval(t *testing.T) {
 	}
 }
 
+func TestCreateApprovalExpiresAt(t *testing.T) {
+	conn := &fakeConn{}
+	d := &DB{conn: conn}
+	if _, err := d.CreateApproval(context.Background(), "plan", []byte(`{"expires_at":"2026-10-01T14:00:00+02:00"}`)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if got, ok := conn.lastExecArgs[5].(time.Time); !ok || !got.Equal(want) || got.Location() != time.UTC {
+		t.Fatalf("expires_at: %#v", conn.lastExecArgs[5])
+	}
+	if _, err := d.CreateApproval(context.Background(), "plan", nil); err != nil || conn.lastExecArgs[5] != nil {
+		t.Fatalf("err=%v expires_at=%#v", err, conn.lastExecArgs[5])
+	}
+	if _, err := d.CreateApproval(context.Background(), "plan", []byte("{")); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestCreateApprovalError(t *testing.T) {
 	d := &DB{conn: &fakeConn{execErr: sql.ErrConnDone}}
 	if _, err := d.CreateApproval(context.Background(), "plan", nil); err == nil {
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// GetExecutionReportData returns what an execution recorded as one JSON
+// object of {tool_calls, evidence, audit_events}. Audit events are those
+// whose context names the execution or its plan, oldest first.
+func (d *DB) GetExecutionReportData(ctx context.Context, executionID, planID string) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db required")
+	}
+	executionID = strings.TrimSpace(executionID)
+	if executionID == "" {
+		return nil, errors.New("execution_id required")
+	}
+	row := d.conn.QueryRowContext(ctx, `
+		SELECT jsonb_build_object(
+			'tool_calls', COALESCE((
+				SELECT jsonb_agg(jsonb_build_object(
+					'tool_call_id', tool_call_id, 'tool_name', tool_name,
+					'input_ref', input_ref, 'output_ref', output_ref, 'status', status
+				) ORDER BY tool_call_id) FROM tool_calls WHERE execution_id=$1
+			), '[]'::jsonb),
+			'evidence', COALESCE((
+				SELECT jsonb_agg(jsonb_build_object(
+					'evidence_id', evidence_id, 'type', type, 'query', query,
+					'result_ref', result_ref, 'link', link, 'collected_at', collected_at,
+					'external_ids', external_ids
+				) ORDER BY collected_at, evidence_id) FROM evidence WHERE execution_id=$1
+			), '[]'::jsonb),
+			'audit_events', COALESCE((
+				SELECT jsonb_agg(jsonb_build_object(
+					'event_id', event_id, 'occurred_at', occurred_at, 'actor', actor_json,
+					'action', action, 'decision', decision, 'context', context_json,
+					'reasons', reasons_json
+				) ORDER BY occurred_at) FROM audit_events
+				WHERE context_json->>'execution_id' = $1
+				   OR ($2 <> '' AND context_json->>'plan_id' = $2)
+			), '[]'::jsonb)
+		)
+	`, executionID, strings.TrimSpace(planID))
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetExecutionNarrative returns the report narrative stored for an
+// execution, or nil when none has been generated yet.
+func (d *DB) GetExecutionNarrative(ctx context.Context, executionID string) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db required")
+	}
+	executionID = strings.TrimSpace(executionID)
+	if executionID == "" {
+		return nil, errors.New("execution_id required")
+	}
+	row := d.conn.QueryRowContext(ctx, `
+		SELECT report_narrative_json FROM executions WHERE execution_id=$1
+	`, executionID)
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetExecutionNarrative stores the report narrative of an execution. The
+// first narrative stored wins so concurrent readers agree on one text.
+func (d *DB) SetExecutionNarrative(ctx context.Context, executionID string, payload []byte) error {
+	if d == nil || d.conn == nil {
+		return errors.New("db required")
+	}
+	executionID = strings.TrimSpace(executionID)
+	if executionID == "" {
+		return errors.New("execution_id required")
+	}
+	_, err := d.conn.ExecContext(ctx, `
+		UPDATE executions SET report_narrative_json=$1
+		WHERE execution_id=$2 AND report_narrative_json IS NULL
+	`, payload, executionID)
+	return err
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestGetExecutionReportData(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`{"tool_calls":[]}`)}}}
+	d := &DB{conn: conn}
+	out, err := d.GetExecutionReportData(context.Background(), " exec_1 ", "plan_1")
+	if err != nil || string(out) != `{"tool_calls":[]}` {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+	for _, table := range []string{"FROM tool_calls", "FROM evidence", "FROM audit_events"} {
+		if !strings.Contains(conn.lastQuery, table) {
+			t.Fatalf("query missing %s: %s", table, conn.lastQuery)
+		}
+	}
+	if conn.lastArgs[0] != "exec_1" || conn.lastArgs[1] != "plan_1" {
+		t.Fatalf("args: %#v", conn.lastArgs)
+	}
+}
+
+func TestGetExecutionReportDataErrors(t *testing.T) {
+	var nilDB *DB
+	if _, err := nilDB.GetExecutionReportData(context.Background(), "exec_1", ""); err == nil {
+		t.Fatalf("expected error")
+	}
+	d := &DB{conn: &fakeConn{}}
+	if _, err := d.GetExecutionReportData(context.Background(), " ", ""); err == nil {
+		t.Fatalf("expected execution error")
+	}
+	d = &DB{conn: &fakeConn{row: fakeRow{err: sql.ErrConnDone}}}
+	if _, err := d.GetExecutionReportData(context.Background(), "exec_1", ""); err == nil {
+		t.Fatalf("expected scan error")
+	}
+}
+
+func TestExecutionNarrative(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`{"summary":"s"}`)}}}
+	d := &DB{conn: conn}
+	out, err := d.GetExecutionNarrative(context.Background(), " exec_1 ")
+	if err != nil || string(out) != `{"summary":"s"}` || conn.lastArgs[0] != "exec_1" {
+		t.Fatalf("out: %s err: %v args: %#v", out, err, conn.lastArgs)
+	}
+	if err := d.SetExecutionNarrative(context.Background(), "exec_1", []byte(`{}`)); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if !strings.Contains(conn.lastExecQuery, "report_narrative_json IS NULL") || conn.lastExecArgs[1] != "exec_1" {
+		t.Fatalf("query: %s args: %#v", conn.lastExecQuery, conn.lastExecArgs)
+	}
+	if _, err := d.GetExecutionNarrative(context.Background(), " "); err == nil {
+		t.Fatalf("expected execution error")
+	}
+	if err := d.SetExecutionNarrative(context.Background(), "", nil); err == nil {
+		t.Fatalf("expected execution error")
+	}
+	var nilDB *DB
+	if err := nilDB.SetExecutionNarrative(context.Background(), "exec_1", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ListRetrievalDocuments returns the tenant's runbooks, playbooks and
+// operator memory as one JSON array of {id, kind, title, service, tags,
+// text} for the planning retrieval index.
+func (d *DB) ListRetrievalDocuments(ctx context.Context, tenantID string) ([]byte, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("db required")
+	}
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return nil, errors.New("tenant_id required")
+	}
+	row := d.conn.QueryRowContext(ctx, `
+		SELECT COALESCE(jsonb_agg(doc), '[]'::jsonb) FROM (
+			SELECT jsonb_build_object(
+				'id', runbook_id, 'kind', 'runbook', 'title', name, 'service', service,
+				'tags', tags, 'text', concat_ws(E'\n', body, spec_json::text)
+			) AS doc FROM runbooks WHERE tenant_id=$1
+			UNION ALL
+			SELECT jsonb_build_object(
+				'id', playbook_id, 'kind', 'playbook', 'title', name,
+				'tags', tags, 'text', spec_json::text
+			) FROM playbooks WHERE tenant_id=$1
+			UNION ALL
+			SELECT jsonb_build_object(
+				'id', memory_id, 'kind', 'memory', 'title', title,
+				'tags', tags, 'text', body
+			) FROM operator_memory WHERE tenant_id=$1
+		) AS docs
+	`, tenantID)
+	var out []byte
+	if err := row.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestListRetrievalDocuments(t *testing.T) {
+	conn := &fakeConn{row: fakeRow{values: []any{[]byte(`[{"id":"runbook_1"}]`)}}}
+	d := &DB{conn: conn}
+	out, err := d.ListRetrievalDocuments(context.Background(), " t1 ")
+	if err != nil || string(out) != `[{"id":"runbook_1"}]` {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+	for _, table := range []string{"FROM runbooks", "FROM playbooks", "FROM operator_memory"} {
+		if !strings.Contains(conn.lastQuery, table) {
+			t.Fatalf("query missing %s: %s", table, conn.lastQuery)
+		}
+	}
+	if conn.lastArgs[0] != "t1" {
+		t.Fatalf("args: %#v", conn.lastArgs)
+	}
+}
+
+func TestListRetrievalDocumentsErrors(t *testing.T) {
+	var nilDB *DB
+	if _, err := nilDB.ListRetrievalDocuments(context.Background(), "t1"); err == nil {
+		t.Fatalf("expected error")
+	}
+	d := &DB{conn: &fakeConn{}}
+	if _, err := d.ListRetrievalDocuments(context.Background(), ""); err == nil {
+		t.Fatalf("expected tenant error")
+	}
+	d = &DB{conn: &fakeConn{row: fakeRow{err: sql.ErrConnDone}}}
+	if _, err := d.ListRetrievalDocuments(context.Background(), "t1"); err == nil {
+		t.Fatalf("expected scan error")
+	}
+}
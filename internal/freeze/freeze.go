@@ -0,0 +1,294 @@
+// Package freeze evaluates change freeze calendars: named freezes with a
+// start and end, and recurring blackouts such as weekends or the end of a
+// quarter, each scoped to environments and services.
+package freeze
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence kinds.
+const (
+	KindWeekends     = "weekends"
+	KindEndOfQuarter = "end_of_quarter"
+	KindWeekly       = "weekly"
+)
+
+// Sources a freeze can come from.
+const (
+	SourceAPI  = "api"
+	SourceICal = "ical"
+)
+
+// defaultQuarterDays is how many days before a quarter ends an
+// end_of_quarter freeze starts when LastDays is not set.
+const defaultQuarterDays = 7
+
+// Freeze is a named change freeze. A one-off freeze runs from StartsAt to
+// EndsAt; a recurring one is active whenever its Recurrence is, bounded by
+// StartsAt and EndsAt when they are set.
+type Freeze struct {
+	ID         string      `json:"freeze_id,omitempty"`
+	Name       string      `json:"name"`
+	Reason     string      `json:"reason,omitempty"`
+	StartsAt   *time.Time  `json:"starts_at,omitempty"`
+	EndsAt     *time.Time  `json:"ends_at,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	// Timezone is the IANA zone recurrences are evaluated in (UTC when empty).
+	Timezone string `json:"timezone,omitempty"`
+	// Environments and Services scope the freeze; empty means all.
+	Environments []string `json:"environments,omitempty"`
+	Services     []string `json:"services,omitempty"`
+	// Source is api or ical; SourceRef names the calendar an ical freeze
+	// was imported from and ExternalID its event UID.
+	Source     string     `json:"source,omitempty"`
+	SourceRef  string     `json:"source_ref,omitempty"`
+	ExternalID string     `json:"external_id,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+// Recurrence is a repeating blackout. weekends covers Saturday and Sunday;
+// end_of_quarter the last LastDays days of each quarter; weekly the given
+// Days between From and To (HH:MM, To may be 24:00).
+type Recurrence struct {
+	Kind     string   `json:"kind"`
+	Days     []string `json:"days,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       string   `json:"to,omitempty"`
+	LastDays int      `json:"last_days,omitempty"`
+}
+
+// Hit is a freeze in effect, and when the current period of it ends.
+type Hit struct {
+	Freeze Freeze    `json:"freeze"`
+	Until  time.Time `json:"until"`
+}
+
+// Reason explains the hit to whoever it blocks.
+func (h Hit) Reason() string {
+	msg := fmt.Sprintf("change freeze %q in effect until %s", h.Freeze.Name, h.Until.UTC().Format(time.RFC3339))
+	if reason := strings.TrimSpace(h.Freeze.Reason); reason != "" {
+		msg += ": " + reason
+	}
+	return msg
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Validate checks f is complete and its recurrence well formed.
+func (f Freeze) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("freeze: name required")
+	}
+	if _, err := f.location(); err != nil {
+		return err
+	}
+	if f.StartsAt != nil && f.EndsAt != nil && !f.EndsAt.After(*f.StartsAt) {
+		return errors.New("freeze: ends_at must be after starts_at")
+	}
+	if f.Recurrence == nil {
+		if f.StartsAt == nil || f.EndsAt == nil {
+			return errors.New("freeze: starts_at and ends_at required without a recurrence")
+		}
+		return nil
+	}
+	rec := f.Recurrence
+	switch rec.Kind {
+	case KindWeekends:
+	case KindEndOfQuarter:
+		if rec.LastDays < 0 || rec.LastDays > 31 {
+			return errors.New("freeze: last_days must be between 0 and 31")
+		}
+	case KindWeekly:
+		if len(rec.Days) == 0 {
+			return errors.New("freeze: weekly recurrence needs days")
+		}
+		for _, day := range rec.Days {
+			if _, ok := weekdays[dayKey(day)]; !ok {
+				return fmt.Errorf("freeze: unknown day %q", day)
+			}
+		}
+		from, to, err := rec.span()
+		if err != nil {
+			return err
+		}
+		if to <= from {
+			return errors.New("freeze: weekly to must be after from")
+		}
+	default:
+		return fmt.Errorf("freeze: unknown recurrence %q", rec.Kind)
+	}
+	return nil
+}
+
+// AppliesTo reports whether f covers a change to services in env. A
+// service-scoped freeze only covers changes naming one of its services.
+func (f Freeze) AppliesTo(env string, services []string) bool {
+	if len(f.Environments) > 0 && !containsFold(f.Environments, env) {
+		return false
+	}
+	if len(f.Services) == 0 {
+		return true
+	}
+	for _, svc := range services {
+		if containsFold(f.Services, svc) {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveAt reports whether f is in effect at now and, if so, when the
+// current period ends.
+func (f Freeze) ActiveAt(now time.Time) (time.Time, bool) {
+	if f.StartsAt != nil && now.Before(*f.StartsAt) {
+		return time.Time{}, false
+	}
+	if f.EndsAt != nil && !now.Before(*f.EndsAt) {
+		return time.Time{}, false
+	}
+	if f.Recurrence == nil {
+		if f.StartsAt == nil || f.EndsAt == nil {
+			return time.Time{}, false
+		}
+		return *f.EndsAt, true
+	}
+	loc, err := f.location()
+	if err != nil {
+		return time.Time{}, false
+	}
+	until, ok := f.Recurrence.activeAt(now.In(loc))
+	if !ok {
+		return time.Time{}, false
+	}
+	if f.EndsAt != nil && f.EndsAt.Before(until) {
+		until = *f.EndsAt
+	}
+	return until, true
+}
+
+// Active returns the freezes covering a change to services in env at now.
+func Active(freezes []Freeze, now time.Time, env string, services []string) []Hit {
+	var hits []Hit
+	for _, f := range freezes {
+		if !f.AppliesTo(env, services) {
+			continue
+		}
+		if until, ok := f.ActiveAt(now); ok {
+			hits = append(hits, Hit{Freeze: f, Until: until})
+		}
+	}
+	return hits
+}
+
+func (f Freeze) location() (*time.Location, error) {
+	tz := strings.TrimSpace(f.Timezone)
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("freeze: timezone: %w", err)
+	}
+	return loc, nil
+}
+
+// activeAt evaluates the recurrence at now, already in its timezone.
+func (r Recurrence) activeAt(now time.Time) (time.Time, bool) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch r.Kind {
+	case KindWeekends:
+		switch now.Weekday() {
+		case time.Saturday:
+			return midnight.AddDate(0, 0, 2), true
+		case time.Sunday:
+			return midnight.AddDate(0, 0, 1), true
+		}
+	case KindEndOfQuarter:
+		days := r.LastDays
+		if days <= 0 {
+			days = defaultQuarterDays
+		}
+		quarterEnd := time.Date(now.Year(), ((now.Month()-1)/3+1)*3+1, 1, 0, 0, 0, 0, now.Location())
+		if !now.Before(quarterEnd.AddDate(0, 0, -days)) {
+			return quarterEnd, true
+		}
+	case KindWeekly:
+		if !r.hasDay(now.Weekday()) {
+			return time.Time{}, false
+		}
+		from, to, err := r.span()
+		if err != nil {
+			return time.Time{}, false
+		}
+		offset := now.Sub(midnight)
+		if offset >= from && offset < to {
+			return midnight.Add(to), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r Recurrence) hasDay(day time.Weekday) bool {
+	for _, d := range r.Days {
+		if wd, ok := weekdays[dayKey(d)]; ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Recurrence) span() (time.Duration, time.Duration, error) {
+	from, to := strings.TrimSpace(r.From), strings.TrimSpace(r.To)
+	if from == "" {
+		from = "00:00"
+	}
+	if to == "" {
+		to = "24:00"
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func parseClock(raw string) (time.Duration, error) {
+	hourRaw, minuteRaw, ok := strings.Cut(raw, ":")
+	hour, herr := strconv.Atoi(hourRaw)
+	minute, merr := strconv.Atoi(minuteRaw)
+	if !ok || herr != nil || merr != nil || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("freeze: time %q must be HH:MM", raw)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
+
+func dayKey(day string) string {
+	day = strings.ToLower(strings.TrimSpace(day))
+	if len(day) > 3 {
+		day = day[:3]
+	}
+	return day
+}
+
+func containsFold(list []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
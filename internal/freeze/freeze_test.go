@@ -0,0 +1,141 @@
+package freeze
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func at(t *testing.T, raw string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		t.Fatalf("time: %v", err)
+	}
+	return ts
+}
+
+func ptr(t time.Time) *time.Time { return &t }
+
+func TestOneOffFreeze(t *testing.T) {
+	f := Freeze{Name: "holidays", StartsAt: ptr(at(t, "2026-12-20T00:00:00Z")), EndsAt: ptr(at(t, "2027-01-04T00:00:00Z"))}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if _, ok := f.ActiveAt(at(t, "2026-12-19T23:59:00Z")); ok {
+		t.Fatalf("active before start")
+	}
+	until, ok := f.ActiveAt(at(t, "2026-12-24T12:00:00Z"))
+	if !ok || !until.Equal(*f.EndsAt) {
+		t.Fatalf("until: %v %v", until, ok)
+	}
+	if _, ok := f.ActiveAt(*f.EndsAt); ok {
+		t.Fatalf("active at end")
+	}
+}
+
+func TestWeekendsFreeze(t *testing.T) {
+	f := Freeze{Name: "weekends", Recurrence: &Recurrence{Kind: KindWeekends}, Timezone: "America/New_York"}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	// Saturday 02:00 UTC is still Friday evening in New York.
+	if _, ok := f.ActiveAt(at(t, "2026-03-07T02:00:00Z")); ok {
+		t.Fatalf("friday evening frozen")
+	}
+	until, ok := f.ActiveAt(at(t, "2026-03-07T15:00:00Z"))
+	if !ok || until.UTC().Format(time.RFC3339) != "2026-03-09T04:00:00Z" {
+		t.Fatalf("until: %v %v", until.UTC(), ok)
+	}
+}
+
+func TestEndOfQuarterFreeze(t *testing.T) {
+	f := Freeze{Name: "eoq", Recurrence: &Recurrence{Kind: KindEndOfQuarter, LastDays: 3}}
+	if _, ok := f.ActiveAt(at(t, "2026-12-28T12:00:00Z")); ok {
+		t.Fatalf("active too early")
+	}
+	until, ok := f.ActiveAt(at(t, "2026-12-29T00:00:00Z"))
+	if !ok || until.Format(time.RFC3339) != "2027-01-01T00:00:00Z" {
+		t.Fatalf("until: %v %v", until, ok)
+	}
+	def := Freeze{Name: "eoq", Recurrence: &Recurrence{Kind: KindEndOfQuarter}}
+	if _, ok := def.ActiveAt(at(t, "2026-06-24T00:00:00Z")); !ok {
+		t.Fatalf("default window should cover the last 7 days")
+	}
+	if _, ok := def.ActiveAt(at(t, "2026-05-30T00:00:00Z")); ok {
+		t.Fatalf("end of may is not end of quarter")
+	}
+}
+
+func TestWeeklyFreezeBoundedByEndsAt(t *testing.T) {
+	f := Freeze{
+		Name:       "friday afternoons",
+		Recurrence: &Recurrence{Kind: KindWeekly, Days: []string{"Friday"}, From: "15:00"},
+		EndsAt:     ptr(at(t, "2026-03-06T18:00:00Z")),
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if _, ok := f.ActiveAt(at(t, "2026-03-06T14:59:00Z")); ok {
+		t.Fatalf("active before from")
+	}
+	until, ok := f.ActiveAt(at(t, "2026-03-06T16:00:00Z"))
+	if !ok || !until.Equal(*f.EndsAt) {
+		t.Fatalf("until: %v %v", until, ok)
+	}
+	if _, ok := f.ActiveAt(at(t, "2026-03-13T16:00:00Z")); ok {
+		t.Fatalf("active after ends_at")
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	start := at(t, "2026-01-01T00:00:00Z")
+	cases := []Freeze{
+		{},
+		{Name: "x"},
+		{Name: "x", StartsAt: ptr(start), EndsAt: ptr(start)},
+		{Name: "x", Recurrence: &Recurrence{Kind: "monthly"}},
+		{Name: "x", Recurrence: &Recurrence{Kind: KindWeekly}},
+		{Name: "x", Recurrence: &Recurrence{Kind: KindWeekly, Days: []string{"funday"}}},
+		{Name: "x", Recurrence: &Recurrence{Kind: KindWeekly, Days: []string{"mon"}, From: "18:00", To: "08:00"}},
+		{Name: "x", Recurrence: &Recurrence{Kind: KindWeekly, Days: []string{"mon"}, To: "25:00"}},
+		{Name: "x", Recurrence: &Recurrence{Kind: KindWeekends}, Timezone: "Nowhere/Else"},
+	}
+	for i, f := range cases {
+		if err := f.Validate(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestActiveScoping(t *testing.T) {
+	window := Freeze{StartsAt: ptr(at(t, "2026-01-01T00:00:00Z")), EndsAt: ptr(at(t, "2026-02-01T00:00:00Z"))}
+	all, prod, checkout := window, window, window
+	all.Name, prod.Name, checkout.Name = "all", "prod", "checkout"
+	prod.Environments = []string{"prod"}
+	checkout.Environments, checkout.Services = []string{"prod"}, []string{"checkout"}
+	freezes := []Freeze{all, prod, checkout}
+	now := at(t, "2026-01-15T00:00:00Z")
+	if hits := Active(freezes, now, "dev", []string{"checkout"}); len(hits) != 1 || hits[0].Freeze.Name != "all" {
+		t.Fatalf("dev: %+v", hits)
+	}
+	if hits := Active(freezes, now, "PROD", nil); len(hits) != 2 {
+		t.Fatalf("prod without services: %+v", hits)
+	}
+	if hits := Active(freezes, now, "prod", []string{"Checkout"}); len(hits) != 3 {
+		t.Fatalf("prod checkout: %+v", hits)
+	}
+	if hits := Active(freezes, at(t, "2026-03-01T00:00:00Z"), "prod", nil); len(hits) != 0 {
+		t.Fatalf("after: %+v", hits)
+	}
+}
+
+func TestHitReason(t *testing.T) {
+	hit := Hit{Freeze: Freeze{Name: "Q4 freeze", Reason: "peak season"}, Until: at(t, "2027-01-04T00:00:00Z")}
+	if got := hit.Reason(); got != `change freeze "Q4 freeze" in effect until 2027-01-04T00:00:00Z: peak season` {
+		t.Fatalf("reason: %s", got)
+	}
+	if strings.Contains(Hit{Freeze: Freeze{Name: "x"}}.Reason(), ": ") {
+		t.Fatalf("empty reason")
+	}
+}
@@ -0,0 +1,306 @@
+package freeze
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportOptions scope and label freezes imported from a calendar.
+type ImportOptions struct {
+	// SourceRef names the calendar (its URL or file name).
+	SourceRef    string
+	Environments []string
+	Services     []string
+	// Timezone applies to floating times and recurrences (UTC when empty).
+	Timezone string
+}
+
+// Skipped is a calendar event that could not be imported.
+type Skipped struct {
+	UID    string `json:"uid,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type icalProp struct {
+	params map[string]string
+	value  string
+}
+
+// ParseICal reads the VEVENTs of an iCalendar feed as freezes. Events
+// without a recurrence become one-off freezes; a weekly RRULE with BYDAY
+// on an event shorter than a day becomes a weekly recurrence. Other
+// recurrences are skipped, as are events without a usable start and end.
+func ParseICal(r io.Reader, opts ImportOptions) ([]Freeze, []Skipped, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defaultLoc := time.UTC
+	if tz := strings.TrimSpace(opts.Timezone); tz != "" {
+		if defaultLoc, err = time.LoadLocation(tz); err != nil {
+			return nil, nil, fmt.Errorf("freeze: timezone: %w", err)
+		}
+	}
+	var (
+		freezes []Freeze
+		skipped []Skipped
+		event   map[string]icalProp
+	)
+	for _, line := range lines {
+		name, prop, ok := parseICalLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event = map[string]icalProp{}
+		case name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if event == nil {
+				continue
+			}
+			f, err := eventFreeze(event, defaultLoc, opts)
+			if err != nil {
+				skipped = append(skipped, Skipped{UID: event["UID"].value, Name: unescapeICal(event["SUMMARY"].value), Reason: err.Error()})
+			} else {
+				freezes = append(freezes, f)
+			}
+			event = nil
+		case event != nil:
+			if _, seen := event[name]; !seen {
+				event[name] = prop
+			}
+		}
+	}
+	return freezes, skipped, nil
+}
+
+func eventFreeze(event map[string]icalProp, defaultLoc *time.Location, opts ImportOptions) (Freeze, error) {
+	if strings.EqualFold(event["STATUS"].value, "CANCELLED") {
+		return Freeze{}, fmt.Errorf("event cancelled")
+	}
+	start, startDate, err := parseICalTime(event["DTSTART"], defaultLoc)
+	if err != nil {
+		return Freeze{}, fmt.Errorf("DTSTART: %w", err)
+	}
+	var end time.Time
+	switch {
+	case event["DTEND"].value != "":
+		if end, _, err = parseICalTime(event["DTEND"], defaultLoc); err != nil {
+			return Freeze{}, fmt.Errorf("DTEND: %w", err)
+		}
+	case event["DURATION"].value != "":
+		d, err := parseICalDuration(event["DURATION"].value)
+		if err != nil {
+			return Freeze{}, err
+		}
+		end = start.Add(d)
+	case startDate:
+		end = start.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		return Freeze{}, fmt.Errorf("event has no duration")
+	}
+	name := unescapeICal(event["SUMMARY"].value)
+	if strings.TrimSpace(name) == "" {
+		name = "calendar freeze"
+	}
+	f := Freeze{
+		Name:         name,
+		Reason:       unescapeICal(event["DESCRIPTION"].value),
+		Environments: opts.Environments,
+		Services:     opts.Services,
+		Source:       SourceICal,
+		SourceRef:    opts.SourceRef,
+		ExternalID:   event["UID"].value,
+	}
+	rule := event["RRULE"].value
+	if rule == "" {
+		startUTC, endUTC := start.UTC(), end.UTC()
+		f.StartsAt, f.EndsAt = &startUTC, &endUTC
+		return f, f.Validate()
+	}
+	rec, until, err := weeklyFromRRule(rule, start, end, defaultLoc)
+	if err != nil {
+		return Freeze{}, err
+	}
+	startUTC := start.UTC()
+	f.StartsAt = &startUTC
+	if !until.IsZero() {
+		untilUTC := until.UTC()
+		f.EndsAt = &untilUTC
+	}
+	f.Recurrence = rec
+	f.Timezone = start.Location().String()
+	return f, f.Validate()
+}
+
+// weeklyFromRRule maps FREQ=WEEKLY;BYDAY=... onto a weekly recurrence over
+// the event's time of day.
+func weeklyFromRRule(rule string, start, end time.Time, defaultLoc *time.Location) (*Recurrence, time.Time, error) {
+	parts := map[string]string{}
+	for _, part := range strings.Split(rule, ";") {
+		if key, val, ok := strings.Cut(part, "="); ok {
+			parts[strings.ToUpper(strings.TrimSpace(key))] = strings.TrimSpace(val)
+		}
+	}
+	if !strings.EqualFold(parts["FREQ"], "WEEKLY") {
+		return nil, time.Time{}, fmt.Errorf("unsupported recurrence %q", rule)
+	}
+	if interval := parts["INTERVAL"]; interval != "" && interval != "1" {
+		return nil, time.Time{}, fmt.Errorf("unsupported recurrence interval %s", interval)
+	}
+	if parts["COUNT"] != "" {
+		return nil, time.Time{}, fmt.Errorf("unsupported recurrence count")
+	}
+	end = end.In(start.Location())
+	nextMidnight := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+	sameDay := end.Year() == start.Year() && end.YearDay() == start.YearDay()
+	if !sameDay && !end.Equal(nextMidnight) {
+		return nil, time.Time{}, fmt.Errorf("weekly events must fit within a day")
+	}
+	byDay := parts["BYDAY"]
+	if byDay == "" {
+		byDay = strings.ToUpper(start.Weekday().String()[:2])
+	}
+	rec := &Recurrence{Kind: KindWeekly, From: clock(start), To: clock(end)}
+	if end.Equal(nextMidnight) {
+		rec.To = "24:00"
+	}
+	for _, day := range strings.Split(byDay, ",") {
+		key, ok := icalDays[strings.ToUpper(strings.TrimSpace(day))]
+		if !ok {
+			return nil, time.Time{}, fmt.Errorf("unsupported BYDAY %q", day)
+		}
+		rec.Days = append(rec.Days, key)
+	}
+	var until time.Time
+	if raw := parts["UNTIL"]; raw != "" {
+		t, _, err := parseICalTime(icalProp{value: raw}, defaultLoc)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("UNTIL: %w", err)
+		}
+		until = t.Add(end.Sub(start))
+	}
+	return rec, until, nil
+}
+
+var icalDays = map[string]string{
+	"MO": "mon", "TU": "tue", "WE": "wed", "TH": "thu", "FR": "fri", "SA": "sat", "SU": "sun",
+}
+
+func clock(t time.Time) string {
+	return fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute())
+}
+
+// parseICalTime reads a DATE or DATE-TIME value; date reports a DATE.
+func parseICalTime(prop icalProp, defaultLoc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+	if value == "" {
+		return time.Time{}, false, fmt.Errorf("missing")
+	}
+	loc := defaultLoc
+	if tzid := strings.Trim(prop.params["TZID"], `"`); tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = l
+	}
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseICalDuration reads the day and time parts of an RFC 5545 duration,
+// e.g. P1D, PT4H30M or P2W.
+func parseICalDuration(raw string) (time.Duration, error) {
+	value := strings.ToUpper(strings.TrimSpace(raw))
+	value = strings.TrimPrefix(value, "+")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("invalid DURATION %q", raw)
+	}
+	var total time.Duration
+	num := ""
+	inTime := false
+	for _, ch := range value[1:] {
+		switch {
+		case ch >= '0' && ch <= '9':
+			num += string(ch)
+		case ch == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("invalid DURATION %q", raw)
+			}
+			num = ""
+			switch {
+			case ch == 'W' && !inTime:
+				total += time.Duration(n) * 7 * 24 * time.Hour
+			case ch == 'D' && !inTime:
+				total += time.Duration(n) * 24 * time.Hour
+			case ch == 'H' && inTime:
+				total += time.Duration(n) * time.Hour
+			case ch == 'M' && inTime:
+				total += time.Duration(n) * time.Minute
+			case ch == 'S' && inTime:
+				total += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid DURATION %q", raw)
+			}
+		}
+	}
+	if num != "" || total <= 0 {
+		return 0, fmt.Errorf("invalid DURATION %q", raw)
+	}
+	return total, nil
+}
+
+// unfoldICal joins RFC 5545 folded lines (continuations start with a space
+// or tab).
+func unfoldICal(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseICalLine(line string) (string, icalProp, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", icalProp{}, false
+	}
+	parts := strings.Split(head, ";")
+	prop := icalProp{params: map[string]string{}, value: value}
+	for _, param := range parts[1:] {
+		if key, val, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = val
+		}
+	}
+	return strings.ToUpper(strings.TrimSpace(parts[0])), prop, true
+}
+
+func unescapeICal(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(value))
+}
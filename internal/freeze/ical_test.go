@@ -0,0 +1,137 @@
+package freeze
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holidays@example.com\r\n" +
+	"SUMMARY:Holiday freeze\\, all teams\r\n" +
+	"DESCRIPTION:Peak season. Only emergency\r\n" +
+	"  fixes.\r\n" +
+	"DTSTART;VALUE=DATE:20261221\r\n" +
+	"DTEND;VALUE=DATE:20270104\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:release-night@example.com\r\n" +
+	"SUMMARY:Release night\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20260305T180000\r\n" +
+	"DURATION:PT6H\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=TH\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:launch@example.com\r\n" +
+	"SUMMARY:Launch\r\n" +
+	"DTSTART:20260401T080000Z\r\n" +
+	"DTEND:20260401T200000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:monthly@example.com\r\n" +
+	"SUMMARY:Month end\r\n" +
+	"DTSTART;VALUE=DATE:20260130\r\n" +
+	"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled@example.com\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART;VALUE=DATE:20260130\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICal(t *testing.T) {
+	opts := ImportOptions{SourceRef: "https://cal.example.com/freeze.ics", Environments: []string{"prod"}}
+	freezes, skipped, err := ParseICal(strings.NewReader(testCalendar), opts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(freezes) != 3 || len(skipped) != 2 {
+		t.Fatalf("freezes=%+v skipped=%+v", freezes, skipped)
+	}
+	holiday := freezes[0]
+	if holiday.Name != "Holiday freeze, all teams" || holiday.Reason != "Peak season. Only emergency fixes." {
+		t.Fatalf("holiday: %+v", holiday)
+	}
+	if holiday.ExternalID != "holidays@example.com" || holiday.Source != SourceICal || holiday.SourceRef != opts.SourceRef || holiday.Environments[0] != "prod" {
+		t.Fatalf("holiday source: %+v", holiday)
+	}
+	if holiday.StartsAt.Format(time.RFC3339) != "2026-12-21T00:00:00Z" || holiday.EndsAt.Format(time.RFC3339) != "2027-01-04T00:00:00Z" {
+		t.Fatalf("holiday times: %v %v", holiday.StartsAt, holiday.EndsAt)
+	}
+
+	weekly := freezes[1]
+	rec := weekly.Recurrence
+	if rec == nil || rec.Kind != KindWeekly || rec.From != "18:00" || rec.To != "24:00" || len(rec.Days) != 1 || rec.Days[0] != "thu" || weekly.Timezone != "Europe/Berlin" {
+		t.Fatalf("weekly: %+v %+v", weekly, rec)
+	}
+	// Thursday 19:30 Berlin is 18:30 UTC in March.
+	if _, ok := weekly.ActiveAt(time.Date(2026, 3, 12, 18, 30, 0, 0, time.UTC)); !ok {
+		t.Fatalf("weekly not active")
+	}
+
+	if freezes[2].EndsAt.Sub(*freezes[2].StartsAt) != 12*time.Hour {
+		t.Fatalf("launch: %+v", freezes[2])
+	}
+	if skipped[0].UID != "monthly@example.com" || !strings.Contains(skipped[0].Reason, "unsupported recurrence") {
+		t.Fatalf("skipped: %+v", skipped)
+	}
+	if skipped[1].Reason != "event cancelled" {
+		t.Fatalf("skipped: %+v", skipped)
+	}
+}
+
+func TestParseICalWeeklyUntil(t *testing.T) {
+	cal := "BEGIN:VEVENT\nSUMMARY:Sat\nDTSTART:20260307T090000\nDTEND:20260307T170000\nRRULE:FREQ=WEEKLY;UNTIL=20260328T090000Z\nEND:VEVENT\n"
+	freezes, skipped, err := ParseICal(strings.NewReader(cal), ImportOptions{Timezone: "UTC"})
+	if err != nil || len(freezes) != 1 || len(skipped) != 0 {
+		t.Fatalf("freezes=%+v skipped=%+v err=%v", freezes, skipped, err)
+	}
+	f := freezes[0]
+	if f.Recurrence.Days[0] != "sat" || f.Recurrence.From != "09:00" || f.Recurrence.To != "17:00" {
+		t.Fatalf("recurrence: %+v", f.Recurrence)
+	}
+	if f.EndsAt == nil || f.EndsAt.Format(time.RFC3339) != "2026-03-28T17:00:00Z" {
+		t.Fatalf("ends: %v", f.EndsAt)
+	}
+	if _, ok := f.ActiveAt(time.Date(2026, 4, 4, 10, 0, 0, 0, time.UTC)); ok {
+		t.Fatalf("active after until")
+	}
+}
+
+func TestParseICalRejects(t *testing.T) {
+	cases := map[string]string{
+		"no duration":  "BEGIN:VEVENT\nDTSTART:20260307T090000Z\nEND:VEVENT\n",
+		"bad start":    "BEGIN:VEVENT\nDTSTART:yesterday\nEND:VEVENT\n",
+		"multi day":    "BEGIN:VEVENT\nDTSTART:20260307T090000Z\nDTEND:20260309T090000Z\nRRULE:FREQ=WEEKLY\nEND:VEVENT\n",
+		"interval":     "BEGIN:VEVENT\nDTSTART:20260307T090000Z\nDURATION:PT1H\nRRULE:FREQ=WEEKLY;INTERVAL=2\nEND:VEVENT\n",
+		"bad tzid":     "BEGIN:VEVENT\nDTSTART;TZID=Mars/Base:20260307T090000\nDURATION:PT1H\nEND:VEVENT\n",
+		"bad duration": "BEGIN:VEVENT\nDTSTART:20260307T090000Z\nDURATION:P1X\nEND:VEVENT\n",
+	}
+	for name, cal := range cases {
+		freezes, skipped, err := ParseICal(strings.NewReader(cal), ImportOptions{})
+		if err != nil || len(freezes) != 0 || len(skipped) != 1 {
+			t.Fatalf("%s: freezes=%+v skipped=%+v err=%v", name, freezes, skipped, err)
+		}
+	}
+	if _, _, err := ParseICal(strings.NewReader(""), ImportOptions{Timezone: "Nowhere/Else"}); err == nil {
+		t.Fatalf("expected timezone error")
+	}
+}
+
+func TestParseICalDuration(t *testing.T) {
+	cases := map[string]time.Duration{"P1D": 24 * time.Hour, "PT4H30M": 4*time.Hour + 30*time.Minute, "P2W": 14 * 24 * time.Hour, "P1DT1S": 24*time.Hour + time.Second}
+	for raw, want := range cases {
+		got, err := parseICalDuration(raw)
+		if err != nil || got != want {
+			t.Fatalf("%s: got %v err %v", raw, got, err)
+		}
+	}
+	for _, bad := range []string{"", "1D", "PT", "P1H", "PT1D"} {
+		if _, err := parseICalDuration(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -27,24 +25,50 @@ type anthropicMessage struct {
 }
 
 type anthropicRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	Messages  []anthropicMessage `json:"messages"`
+	Model      string             `json:"model"`
+	MaxTokens  int                `json:"max_tokens"`
+	Messages   []anthropicMessage `json:"messages"`
+	Tools      []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice *anthropicChoice   `json:"tool_choice,omitempty"`
+	Stream     bool               `json:"stream,omitempty"`
+}
+
+// anthropicTool is how structured output is requested: the model is forced
+// to call a tool whose input schema is the expected shape.
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
 type anthropicResponse struct {
 	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"`
 	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
 }
 
 func (c *AnthropicClient) Complete(prompt string, maxTokens int) (string, error) {
+	text, _, err := c.completeUsage(prompt, maxTokens, nil)
+	return text, err
+}
+
+func (c *AnthropicClient) completeUsage(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error) {
 	if strings.TrimSpace(c.APIKey) == "" {
-		return "", errors.New("anthropic api key required")
+		return "", Usage{}, errors.New("anthropic api key required")
 	}
 	if strings.TrimSpace(c.Model) == "" {
-		return "", errors.New("anthropic model required")
+		return "", Usage{}, errors.New("anthropic model required")
 	}
 	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
 	if base == "" {
@@ -58,34 +82,35 @@ func (c *AnthropicClient) Complete(prompt string, maxTokens int) (string, error)
 		MaxTokens: maxTokens,
 		Messages:  []anthropicMessage{{Role: "user", Content: prompt}},
 	}
+	if len(schema) > 0 {
+		reqBody.Tools = []anthropicTool{{Name: planToolName, Description: "Submit the plan.", InputSchema: schema}}
+		reqBody.ToolChoice = &anthropicChoice{Type: "tool", Name: planToolName}
+	}
 	body, err := marshalJSON(reqBody)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	req, err := http.NewRequest(http.MethodPost, base+"/v1/messages", bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", c.APIKey)
 	req.Header.Set("anthropic-version", anthropicVersion)
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		payload, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return "", fmt.Errorf("anthropic status %d: %s", resp.StatusCode, strings.TrimSpace(string(payload)))
-	}
 	var out anthropicResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
+	if err := doJSON(c.HTTPClient, req, "anthropic", &out); err != nil {
+		return "", Usage{}, err
+	}
+	usage := tokenUsage(out.Usage.InputTokens, out.Usage.OutputTokens)
+	for _, block := range out.Content {
+		if block.Type == "tool_use" && len(block.Input) > 0 {
+			return string(block.Input), usage, nil
+		}
 	}
 	for _, block := range out.Content {
 		if strings.TrimSpace(block.Text) != "" {
-			return block.Text, nil
+			return block.Text, usage, nil
 		}
 	}
-	return "", errors.New("anthropic empty response")
+	return "", usage, errors.New("anthropic empty response")
 }
@@ -25,6 +25,10 @@ type AuthProfile struct {
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	ExpiresAt    time.Time `json:"expires_at,omitempty"`
 	Source       string    `json:"source,omitempty"`
+	// AWS keys for the bedrock provider.
+	AWSAccessKeyID     string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken    string `json:"aws_session_token,omitempty"`
 }
 
 type AuthProfiles struct {
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const azureOpenAIProvider = "azure-openai"
+const defaultAzureAPIVersion = "2024-06-01"
+
+// AzureOpenAIClient calls a chat deployment on an Azure OpenAI resource.
+// APIKey is sent as api-key; AccessToken (Entra ID) as a bearer token.
+type AzureOpenAIClient struct {
+	Endpoint    string
+	Deployment  string
+	APIVersion  string
+	APIKey      string
+	AccessToken string
+	HTTPClient  *http.Client
+}
+
+func (c *AzureOpenAIClient) Complete(prompt string, maxTokens int) (string, error) {
+	text, _, err := c.completeUsage(prompt, maxTokens, nil)
+	return text, err
+}
+
+func (c *AzureOpenAIClient) completeUsage(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(c.Endpoint), "/")
+	if endpoint == "" {
+		return "", Usage{}, errors.New("azure-openai endpoint required")
+	}
+	if strings.TrimSpace(c.Deployment) == "" {
+		return "", Usage{}, errors.New("azure-openai deployment required")
+	}
+	if strings.TrimSpace(c.APIKey) == "" && strings.TrimSpace(c.AccessToken) == "" {
+		return "", Usage{}, errors.New("azure-openai api key or access token required")
+	}
+	version := strings.TrimSpace(c.APIVersion)
+	if version == "" {
+		version = defaultAzureAPIVersion
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	body, err := marshalJSON(openAIRequest{
+		Messages:  []openAIMessage{{Role: "user", Content: prompt}},
+		MaxTokens: maxTokens,
+		// json_schema needs api-version 2024-08-01-preview or later.
+		ResponseFormat: openAIJSONFormat(schema),
+	})
+	if err != nil {
+		return "", Usage{}, err
+	}
+	target := endpoint + "/openai/deployments/" + url.PathEscape(c.Deployment) + "/chat/completions?api-version=" + url.QueryEscape(version)
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	if key := strings.TrimSpace(c.APIKey); key != "" {
+		req.Header.Set("api-key", key)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(c.AccessToken))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var out openAIResponse
+	if err := doJSON(c.HTTPClient, req, "azure-openai", &out); err != nil {
+		return "", Usage{}, err
+	}
+	usage := tokenUsage(out.Usage.PromptTokens, out.Usage.CompletionTokens)
+	if len(out.Choices) == 0 || strings.TrimSpace(out.Choices[0].Message.Content) == "" {
+		return "", usage, errors.New("azure-openai empty response")
+	}
+	return out.Choices[0].Message.Content, usage, nil
+}
@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"carapulse/internal/awsauth"
+)
+
+const bedrockProvider = "bedrock"
+
+// BedrockClient calls the Bedrock Runtime Converse API, signed with SigV4.
+type BedrockClient struct {
+	// APIBase overrides https://bedrock-runtime.<region>.amazonaws.com,
+	// e.g. for a VPC endpoint.
+	APIBase     string
+	Region      string
+	Model       string
+	Credentials awsauth.Credentials
+	HTTPClient  *http.Client
+	Now         func() time.Time
+}
+
+type bedrockContent struct {
+	Text    string          `json:"text,omitempty"`
+	ToolUse *bedrockToolUse `json:"toolUse,omitempty"`
+}
+
+type bedrockToolUse struct {
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+type bedrockMessage struct {
+	Role    string           `json:"role"`
+	Content []bedrockContent `json:"content"`
+}
+
+type bedrockRequest struct {
+	Messages        []bedrockMessage `json:"messages"`
+	InferenceConfig struct {
+		MaxTokens int `json:"maxTokens,omitempty"`
+	} `json:"inferenceConfig"`
+	ToolConfig *bedrockToolConfig `json:"toolConfig,omitempty"`
+}
+
+// bedrockToolConfig forces a single tool call whose input schema is the
+// expected output shape; Converse has no JSON mode of its own.
+type bedrockToolConfig struct {
+	Tools      []bedrockTool     `json:"tools"`
+	ToolChoice bedrockToolChoice `json:"toolChoice"`
+}
+
+type bedrockTool struct {
+	ToolSpec bedrockToolSpec `json:"toolSpec"`
+}
+
+type bedrockToolSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	InputSchema struct {
+		JSON json.RawMessage `json:"json"`
+	} `json:"inputSchema"`
+}
+
+type bedrockToolChoice struct {
+	Tool struct {
+		Name string `json:"name"`
+	} `json:"tool"`
+}
+
+func bedrockSchemaTool(schema json.RawMessage) *bedrockToolConfig {
+	if len(schema) == 0 {
+		return nil
+	}
+	spec := bedrockToolSpec{Name: planToolName, Description: "Submit the plan."}
+	spec.InputSchema.JSON = schema
+	cfg := &bedrockToolConfig{Tools: []bedrockTool{{ToolSpec: spec}}}
+	cfg.ToolChoice.Tool.Name = planToolName
+	return cfg
+}
+
+type bedrockResponse struct {
+	Output struct {
+		Message bedrockMessage `json:"message"`
+	} `json:"output"`
+	Usage struct {
+		InputTokens  int `json:"inputTokens"`
+		OutputTokens int `json:"outputTokens"`
+	} `json:"usage"`
+}
+
+func (c *BedrockClient) Complete(prompt string, maxTokens int) (string, error) {
+	text, _, err := c.completeUsage(prompt, maxTokens, nil)
+	return text, err
+}
+
+func (c *BedrockClient) completeUsage(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error) {
+	region := strings.TrimSpace(c.Region)
+	if region == "" {
+		return "", Usage{}, errors.New("bedrock region required")
+	}
+	if strings.TrimSpace(c.Model) == "" {
+		return "", Usage{}, errors.New("bedrock model required")
+	}
+	if !c.Credentials.Valid() {
+		return "", Usage{}, errors.New("bedrock aws credentials required")
+	}
+	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
+	if base == "" {
+		base = "https://bedrock-runtime." + region + ".amazonaws.com"
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	reqBody := bedrockRequest{Messages: []bedrockMessage{{Role: "user", Content: []bedrockContent{{Text: prompt}}}}}
+	reqBody.InferenceConfig.MaxTokens = maxTokens
+	reqBody.ToolConfig = bedrockSchemaTool(schema)
+	body, err := marshalJSON(reqBody)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/model/"+url.PathEscape(c.Model)+"/converse", bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signer := awsauth.Signer{Credentials: c.Credentials, Region: region, Service: "bedrock", Now: c.Now}
+	if err := signer.Sign(req, awsauth.PayloadHash(body)); err != nil {
+		return "", Usage{}, err
+	}
+	var out bedrockResponse
+	if err := doJSON(c.HTTPClient, req, "bedrock", &out); err != nil {
+		return "", Usage{}, err
+	}
+	usage := tokenUsage(out.Usage.InputTokens, out.Usage.OutputTokens)
+	for _, block := range out.Output.Message.Content {
+		if block.ToolUse != nil && len(block.ToolUse.Input) > 0 {
+			return string(block.ToolUse.Input), usage, nil
+		}
+	}
+	for _, block := range out.Output.Message.Content {
+		if strings.TrimSpace(block.Text) != "" {
+			return block.Text, usage, nil
+		}
+	}
+	return "", usage, errors.New("bedrock empty response")
+}
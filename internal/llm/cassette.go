@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cassette modes.
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// Cassette is an http.RoundTripper that records provider exchanges to Dir
+// and replays them without network access. Exchanges are keyed by the
+// prompt hash: a SHA-256 of the request path and the redacted request
+// body, so the same prompt, model and parameters replay against any
+// APIBase. Headers and query strings are never stored, which keeps API
+// keys out of the files; RedactPatterns are applied to both bodies before
+// hashing and writing.
+type Cassette struct {
+	Mode           string
+	Dir            string
+	RedactPatterns []string
+	// Next performs real requests in record mode; nil uses
+	// http.DefaultTransport.
+	Next http.RoundTripper
+}
+
+// CassetteEntry is one recorded exchange, stored as <prompt_hash>.json.
+type CassetteEntry struct {
+	PromptHash  string          `json:"prompt_hash"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Request     json.RawMessage `json:"request"`
+	StatusCode  int             `json:"status_code"`
+	ContentType string          `json:"content_type,omitempty"`
+	Response    string          `json:"response"`
+}
+
+// NewCassetteClient copies base (which may be nil) with a cassette
+// transport for mode and dir. An empty mode returns base unchanged.
+func NewCassetteClient(mode, dir string, redact []string, base *http.Client) (*http.Client, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		return base, nil
+	}
+	if mode != CassetteRecord && mode != CassetteReplay {
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("cassette dir required")
+	}
+	client := &http.Client{}
+	if base != nil {
+		*client = *base
+	}
+	client.Transport = &Cassette{Mode: mode, Dir: dir, RedactPatterns: redact, Next: client.Transport}
+	return client, nil
+}
+
+// IsCassetteReplay reports whether client replays a cassette.
+func IsCassetteReplay(client *http.Client) bool {
+	if client == nil {
+		return false
+	}
+	c, ok := client.Transport.(*Cassette)
+	return ok && c.Mode == CassetteReplay
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	redacted := c.redact(body)
+	hash := cassetteHash(req.URL.Path, redacted)
+	if c.Mode == CassetteReplay {
+		entry, err := c.load(hash)
+		if err != nil {
+			return nil, err
+		}
+		return entry.response(req), nil
+	}
+	next := c.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(payload))
+	entry := CassetteEntry{
+		PromptHash:  hash,
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Request:     redacted,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Response:    string(c.redact(payload)),
+	}
+	if !json.Valid(entry.Request) {
+		entry.Request, _ = json.Marshal(string(redacted))
+	}
+	if err := c.save(entry); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// cassetteHash is the PromptHash of the path and body. JSON bodies are
+// re-encoded first so key order and whitespace do not change it.
+func cassetteHash(path string, body []byte) string {
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		if canonical, err := json.Marshal(decoded); err == nil {
+			body = canonical
+		}
+	}
+	return PromptHash(path + "\n" + string(body))
+}
+
+func (c *Cassette) redact(body []byte) []byte {
+	if len(c.RedactPatterns) == 0 || len(body) == 0 {
+		return body
+	}
+	return []byte(Redact(string(body), c.RedactPatterns))
+}
+
+func (c *Cassette) load(hash string) (CassetteEntry, error) {
+	var entry CassetteEntry
+	data, err := os.ReadFile(filepath.Join(c.Dir, hash+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return entry, fmt.Errorf("cassette: no recording for prompt hash %s in %s", hash, c.Dir)
+	}
+	if err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("cassette: %s: %w", hash, err)
+	}
+	return entry, nil
+}
+
+func (c *Cassette) save(entry CassetteEntry) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.Dir, entry.PromptHash+".json"), append(data, '\n'), 0o644)
+}
+
+func (e CassetteEntry) response(req *http.Request) *http.Response {
+	status := e.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := http.Header{}
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(e.Response)),
+		ContentLength: int64(len(e.Response)),
+		Request:       req,
+	}
+}
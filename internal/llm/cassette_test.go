@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordThenReplay(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		payload, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": "1. check pods for tenant acme-secret"}}},
+			"usage":   map[string]any{"prompt_tokens": 12, "completion_tokens": 8},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	}))
+	dir := t.TempDir()
+	redact := []string{`acme-secret`}
+
+	client, err := NewCassetteClient("record", dir, redact, ts.Client())
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	recorder := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "sk-live", Model: "gpt-4o-mini", HTTPClient: client}
+	recorded, err := recorder.Plan("restart checkout", map[string]any{"tenant": "acme-secret"}, nil)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	ts.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 || calls != 1 {
+		t.Fatalf("files: %v calls: %d", files, calls)
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Contains(string(data), "acme-secret") || strings.Contains(string(data), "sk-live") {
+		t.Fatalf("cassette not redacted: %s", data)
+	}
+
+	client, err = NewCassetteClient("replay", dir, redact, nil)
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	replayer := &Router{Provider: "openai", APIBase: "http://offline.invalid", APIKey: "other", Model: "gpt-4o-mini", HTTPClient: client}
+	replayed, err := replayer.Plan("restart checkout", map[string]any{"tenant": "acme-secret"}, nil)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if replayed != strings.ReplaceAll(recorded, "acme-secret", "[REDACTED]") {
+		t.Fatalf("replayed %q, recorded %q", replayed, recorded)
+	}
+	if _, err := replayer.Plan("something else", nil, nil); err == nil || !strings.Contains(err.Error(), "no recording") {
+		t.Fatalf("expected miss, got %v", err)
+	}
+}
+
+func TestCassetteHashIgnoresKeyOrder(t *testing.T) {
+	a := cassetteHash("/v1/chat/completions", []byte(`{"model":"m","messages":[{"content":"x"}]}`))
+	b := cassetteHash("/v1/chat/completions", []byte(`{ "messages":[{"content":"x"}], "model":"m" }`))
+	if a != b {
+		t.Fatalf("hash differs: %s %s", a, b)
+	}
+	if a == cassetteHash("/v1/messages", []byte(`{"model":"m","messages":[{"content":"x"}]}`)) {
+		t.Fatalf("path not hashed")
+	}
+}
+
+func TestNewCassetteClientValidates(t *testing.T) {
+	base := &http.Client{}
+	if client, err := NewCassetteClient("", "", nil, base); err != nil || client != base {
+		t.Fatalf("empty mode: %v %v", client, err)
+	}
+	if _, err := NewCassetteClient("rewind", "dir", nil, nil); err == nil {
+		t.Fatalf("expected mode error")
+	}
+	if _, err := NewCassetteClient("replay", " ", nil, nil); err == nil {
+		t.Fatalf("expected dir error")
+	}
+}
@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CriticSchema is the JSON schema of a critic verdict.
+var CriticSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["suggested_risk", "recommendation"],
+  "properties": {
+    "summary": {"type": "string"},
+    "suggested_risk": {"type": "string", "enum": ["read", "low", "medium", "high"]},
+    "recommendation": {"type": "string", "enum": ["go", "no_go"]},
+    "concerns": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["step", "concern"],
+        "properties": {
+          "step": {"type": "integer", "minimum": 0},
+          "severity": {"type": "string", "enum": ["low", "medium", "high"]},
+          "concern": {"type": "string", "minLength": 1}
+        }
+      }
+    },
+    "missing_rollbacks": {"type": "array", "items": {"type": "integer", "minimum": 0}}
+  }
+}`)
+
+const criticShape = `{"summary":string,"suggested_risk":"read|low|medium|high","recommendation":"go|no_go","concerns":[{"step":int,"severity":"low|medium|high","concern":string}],"missing_rollbacks":[int]}`
+
+// CriticInput is what the critic reviews: the drafted steps and what the
+// planner knew when drafting them.
+type CriticInput struct {
+	Intent       string `json:"intent"`
+	RiskLevel    string `json:"risk_level"`
+	Steps        any    `json:"steps"`
+	Context      any    `json:"context,omitempty"`
+	ServiceGraph any    `json:"service_graph,omitempty"`
+	Constraints  any    `json:"constraints,omitempty"`
+}
+
+// StepConcern is a problem the critic found with one step, by index.
+type StepConcern struct {
+	Step     int    `json:"step"`
+	Severity string `json:"severity,omitempty"`
+	Concern  string `json:"concern"`
+}
+
+// CriticVerdict is the critic's review of a plan.
+type CriticVerdict struct {
+	Summary          string        `json:"summary,omitempty"`
+	SuggestedRisk    string        `json:"suggested_risk"`
+	Recommendation   string        `json:"recommendation"`
+	Concerns         []StepConcern `json:"concerns,omitempty"`
+	MissingRollbacks []int         `json:"missing_rollbacks,omitempty"`
+	Usage            *Usage        `json:"usage,omitempty"`
+}
+
+// NoGo reports whether the critic advised against running the plan.
+func (v CriticVerdict) NoGo() bool {
+	return v.Recommendation == "no_go"
+}
+
+// Critic reviews drafted plans.
+type Critic interface {
+	Critique(input CriticInput) (CriticVerdict, error)
+}
+
+// Critique asks the model for an independent review of a drafted plan.
+// The answer must match CriticSchema; unlike planning there is no repair.
+func (r *Router) Critique(input CriticInput) (CriticVerdict, error) {
+	if strings.TrimSpace(input.Intent) == "" {
+		return CriticVerdict{}, errors.New("intent required")
+	}
+	prompt, err := criticPrompt(input)
+	if err != nil {
+		return CriticVerdict{}, err
+	}
+	if len(r.RedactPatterns) > 0 {
+		prompt = Redact(prompt, r.RedactPatterns)
+	}
+	maxTokens := r.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 512
+	}
+	text, usage, err := r.completeChain(prompt, maxTokens, CriticSchema)
+	if err != nil {
+		return CriticVerdict{}, err
+	}
+	verdict, err := parseCriticVerdict(text)
+	if err != nil {
+		return CriticVerdict{}, err
+	}
+	verdict.Usage = &usage
+	return verdict, nil
+}
+
+func criticPrompt(input CriticInput) (string, error) {
+	section := func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return SanitizePromptInput(string(data)), nil
+	}
+	steps, err := section(input.Steps)
+	if err != nil {
+		return "", err
+	}
+	context, err := section(input.Context)
+	if err != nil {
+		return "", err
+	}
+	graph, err := section(input.ServiceGraph)
+	if err != nil {
+		return "", err
+	}
+	constraints, err := section(input.Constraints)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("You are reviewing an operations plan drafted by another assistant before anyone runs it. Do not rewrite the plan.\n")
+	fmt.Fprintf(&b, "Intent:\n%s\n", SanitizePromptInput(input.Intent))
+	fmt.Fprintf(&b, "Declared risk level:\n%s\n", SanitizePromptInput(input.RiskLevel))
+	fmt.Fprintf(&b, "Steps (indexed from 0):\n%s\n", steps)
+	fmt.Fprintf(&b, "Context:\n%s\n", context)
+	fmt.Fprintf(&b, "Service graph:\n%s\n", graph)
+	fmt.Fprintf(&b, "Policy constraints:\n%s\n", constraints)
+	b.WriteString("Flag every step that could cause an outage, data loss or a policy violation, or that does not serve the intent. ")
+	b.WriteString("List the indexes of changing steps that have no rollback. Suggest the overall risk level and recommend go or no_go.\n")
+	b.WriteString("Return JSON only with shape:\n")
+	b.WriteString(criticShape)
+	return b.String(), nil
+}
+
+func parseCriticVerdict(text string) (CriticVerdict, error) {
+	var verdict CriticVerdict
+	if err := json.Unmarshal([]byte(extractJSON(text)), &verdict); err != nil {
+		return CriticVerdict{}, fmt.Errorf("critic response is not valid JSON: %w", err)
+	}
+	verdict.SuggestedRisk = strings.ToLower(strings.TrimSpace(verdict.SuggestedRisk))
+	verdict.Recommendation = strings.ToLower(strings.TrimSpace(verdict.Recommendation))
+	switch verdict.SuggestedRisk {
+	case "read", "low", "medium", "high":
+	default:
+		return CriticVerdict{}, fmt.Errorf("critic suggested unknown risk %q", verdict.SuggestedRisk)
+	}
+	switch verdict.Recommendation {
+	case "go", "no_go":
+	default:
+		return CriticVerdict{}, fmt.Errorf("critic recommendation %q is not go or no_go", verdict.Recommendation)
+	}
+	return verdict, nil
+}
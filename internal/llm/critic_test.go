@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterCritique(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages       []openAIMessage      `json:"messages"`
+			ResponseFormat openAIResponseFormat `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[0].Content
+		if !strings.Contains(prompt, `"tool":"kubectl"`) || !strings.Contains(prompt, `"max_targets":5`) || req.ResponseFormat.JSONSchema == nil {
+			t.Errorf("prompt: %s", prompt)
+		}
+		verdict := `{"summary":"deletes the namespace","suggested_risk":"HIGH","recommendation":"no_go","concerns":[{"step":0,"severity":"high","concern":"irreversible"}],"missing_rollbacks":[0]}`
+		payload, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": verdict}}},
+			"usage":   map[string]any{"prompt_tokens": 50, "completion_tokens": 20},
+		})
+		_, _ = w.Write(payload)
+	}))
+	defer ts.Close()
+
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "gpt-4o-mini", HTTPClient: ts.Client()}
+	verdict, err := router.Critique(CriticInput{
+		Intent:      "clean up",
+		RiskLevel:   "low",
+		Steps:       []map[string]any{{"action": "delete", "tool": "kubectl", "input": map[string]any{"namespace": "prod"}}},
+		Constraints: map[string]any{"max_targets": 5},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !verdict.NoGo() || verdict.SuggestedRisk != "high" || len(verdict.Concerns) != 1 || verdict.MissingRollbacks[0] != 0 {
+		t.Fatalf("verdict: %#v", verdict)
+	}
+	if verdict.Usage == nil || verdict.Usage.TotalTokens != 70 || verdict.Usage.Model != "gpt-4o-mini" {
+		t.Fatalf("usage: %#v", verdict.Usage)
+	}
+}
+
+func TestParseCriticVerdictRejectsInvalid(t *testing.T) {
+	for _, text := range []string{
+		"not json",
+		`{"suggested_risk":"extreme","recommendation":"go"}`,
+		`{"suggested_risk":"low","recommendation":"maybe"}`,
+	} {
+		if _, err := parseCriticVerdict(text); err == nil {
+			t.Fatalf("expected error for %s", text)
+		}
+	}
+	if _, err := (&Router{}).Critique(CriticInput{}); err == nil {
+		t.Fatalf("expected intent error")
+	}
+}
@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrEmbeddingsUnsupported is returned when no embedding model is set or
+// the provider has no embeddings API wired up.
+var ErrEmbeddingsUnsupported = errors.New("llm embeddings unsupported")
+
+// embedder is implemented by clients whose provider serves embeddings.
+type embedder interface {
+	embed(texts []string, model string) ([][]float64, error)
+}
+
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns one vector per text from the primary provider's
+// EmbeddingModel. Fallbacks are not tried: vectors from different models
+// are not comparable.
+func (r *Router) Embed(texts []string) ([][]float64, error) {
+	model := strings.TrimSpace(r.EmbeddingModel)
+	if model == "" {
+		return nil, ErrEmbeddingsUnsupported
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	client, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	e, ok := client.(embedder)
+	if !ok {
+		return nil, fmt.Errorf("%w for provider %s", ErrEmbeddingsUnsupported, r.Provider)
+	}
+	return e.embed(texts, model)
+}
+
+func (c *OpenAIClient) embed(texts []string, model string) ([][]float64, error) {
+	if strings.TrimSpace(c.APIKey) == "" {
+		return nil, errors.New("openai api key required")
+	}
+	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
+	if base == "" {
+		base = defaultOpenAIBase
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	req, err := newEmbeddingRequest(base+"/v1/embeddings", openAIEmbeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	return doEmbeddings(c.HTTPClient, req, "openai", len(texts))
+}
+
+func (c *OpenAICompatibleClient) embed(texts []string, model string) ([][]float64, error) {
+	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
+	if base == "" {
+		return nil, errors.New("openai-compatible api base required")
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 2 * time.Minute}
+	}
+	req, err := newEmbeddingRequest(base+"/v1/embeddings", openAIEmbeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	if key := strings.TrimSpace(c.APIKey); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	return doEmbeddings(c.HTTPClient, req, "openai-compatible", len(texts))
+}
+
+// embed on Azure treats model as the embeddings deployment name.
+func (c *AzureOpenAIClient) embed(texts []string, model string) ([][]float64, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(c.Endpoint), "/")
+	if endpoint == "" {
+		return nil, errors.New("azure-openai endpoint required")
+	}
+	if strings.TrimSpace(c.APIKey) == "" && strings.TrimSpace(c.AccessToken) == "" {
+		return nil, errors.New("azure-openai api key or access token required")
+	}
+	version := strings.TrimSpace(c.APIVersion)
+	if version == "" {
+		version = defaultAzureAPIVersion
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	target := endpoint + "/openai/deployments/" + url.PathEscape(model) + "/embeddings?api-version=" + url.QueryEscape(version)
+	req, err := newEmbeddingRequest(target, openAIEmbeddingRequest{Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	if key := strings.TrimSpace(c.APIKey); key != "" {
+		req.Header.Set("api-key", key)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(c.AccessToken))
+	}
+	return doEmbeddings(c.HTTPClient, req, "azure-openai", len(texts))
+}
+
+func newEmbeddingRequest(target string, payload openAIEmbeddingRequest) (*http.Request, error) {
+	body, err := marshalJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// doEmbeddings decodes an OpenAI-style embeddings response in input order.
+func doEmbeddings(client *http.Client, req *http.Request, name string, want int) ([][]float64, error) {
+	var out openAIEmbeddingResponse
+	if err := doJSON(client, req, name, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Data) != want {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d inputs", name, len(out.Data), want)
+	}
+	vectors := make([][]float64, want)
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= want {
+			return nil, fmt.Errorf("%s embedding index %d out of range", name, d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterEmbed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIEmbeddingRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v1/embeddings" || req.Model != "text-embedding-3-small" || len(req.Input) != 2 {
+			t.Errorf("path: %s req: %#v", r.URL.Path, req)
+		}
+		// Out of order on purpose; index decides placement.
+		_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer ts.Close()
+
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", EmbeddingModel: "text-embedding-3-small", HTTPClient: ts.Client()}
+	vectors, err := router.Embed([]string{"a", "b"})
+	if err != nil || len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Fatalf("vectors: %v err: %v", vectors, err)
+	}
+}
+
+func TestRouterEmbedUnsupported(t *testing.T) {
+	router := &Router{Provider: "openai", APIKey: "k"}
+	if _, err := router.Embed([]string{"a"}); !errors.Is(err, ErrEmbeddingsUnsupported) {
+		t.Fatalf("err: %v", err)
+	}
+	router = &Router{Provider: "anthropic", APIKey: "k", EmbeddingModel: "m"}
+	if _, err := router.Embed([]string{"a"}); !errors.Is(err, ErrEmbeddingsUnsupported) {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// EvalCase is one golden planning scenario. Response is the recorded or
+// stubbed model answer, so a run needs no network.
+type EvalCase struct {
+	Name     string     `json:"name"`
+	Trigger  string     `json:"trigger"`
+	Intent   string     `json:"intent"`
+	Context  any        `json:"context,omitempty"`
+	Evidence any        `json:"evidence,omitempty"`
+	Response string     `json:"response"`
+	Expect   EvalExpect `json:"expect"`
+	// PromptSHA256 is the hash of the prompt Response was recorded for.
+	// A mismatch means the template changed and the recording is stale.
+	PromptSHA256 string `json:"prompt_sha256,omitempty"`
+}
+
+// EvalExpect is what a good plan for the case looks like. Empty fields
+// are not scored.
+type EvalExpect struct {
+	Tools     []string `json:"tools,omitempty"`
+	RiskLevel string   `json:"risk_level,omitempty"`
+	MinSteps  int      `json:"min_steps,omitempty"`
+}
+
+// EvalResult scores one case. ToolScore is the F1 of expected against
+// planned tools; Score averages validity, tools and risk.
+type EvalResult struct {
+	Name           string   `json:"name"`
+	PromptTemplate string   `json:"prompt_template"`
+	PromptSHA256   string   `json:"prompt_sha256"`
+	PromptChanged  bool     `json:"prompt_changed,omitempty"`
+	Valid          bool     `json:"valid"`
+	Problems       []string `json:"problems,omitempty"`
+	Tools          []string `json:"tools,omitempty"`
+	ToolScore      float64  `json:"tool_score"`
+	RiskLevel      string   `json:"risk_level,omitempty"`
+	RiskMatch      bool     `json:"risk_match"`
+	Score          float64  `json:"score"`
+	Passed         bool     `json:"passed"`
+}
+
+// EvalSummary totals a run. Stale counts cases whose recording was made
+// for another prompt; they never pass until re-recorded.
+type EvalSummary struct {
+	Cases     int          `json:"cases"`
+	Passed    int          `json:"passed"`
+	Stale     int          `json:"stale"`
+	MeanScore float64      `json:"mean_score"`
+	Results   []EvalResult `json:"results"`
+}
+
+// Eval renders each case's prompt with the router's templates and scores
+// its response. validate is applied to every step, as in planning.
+func (r *Router) Eval(cases []EvalCase, validate StepValidator) (EvalSummary, error) {
+	summary := EvalSummary{Cases: len(cases)}
+	for _, c := range cases {
+		if strings.TrimSpace(c.Intent) == "" {
+			return summary, errors.New("eval case " + c.Name + ": intent required")
+		}
+		context := map[string]any{"trigger": c.Trigger}
+		if m, ok := c.Context.(map[string]any); ok {
+			for k, v := range m {
+				context[k] = v
+			}
+			context["trigger"] = c.Trigger
+		} else if c.Context != nil {
+			context["context"] = c.Context
+		}
+		tmpl, err := r.promptTemplate(context)
+		if err != nil {
+			return summary, err
+		}
+		prompt, err := tmpl.Render(strings.TrimSpace(c.Intent), context, c.Evidence)
+		if err != nil {
+			return summary, err
+		}
+		if len(r.RedactPatterns) > 0 {
+			prompt = Redact(prompt, r.RedactPatterns)
+		}
+		res := scoreEvalCase(c, validate)
+		res.PromptTemplate = tmpl.ID()
+		res.PromptSHA256 = PromptHash(prompt)
+		res.PromptChanged = c.PromptSHA256 != "" && c.PromptSHA256 != res.PromptSHA256
+		if res.PromptChanged {
+			res.Passed = false
+			res.Problems = append(res.Problems, "prompt changed since the response was recorded")
+			summary.Stale++
+		}
+		if res.Passed {
+			summary.Passed++
+		}
+		summary.MeanScore += res.Score
+		summary.Results = append(summary.Results, res)
+	}
+	if len(cases) > 0 {
+		summary.MeanScore /= float64(len(cases))
+	}
+	return summary, nil
+}
+
+// PromptHash is the hex SHA-256 of a rendered prompt.
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+func scoreEvalCase(c EvalCase, validate StepValidator) EvalResult {
+	res := EvalResult{Name: c.Name}
+	res.Problems = ValidatePlan(c.Response, validate)
+	var plan struct {
+		RiskLevel string `json:"risk_level"`
+		Steps     []struct {
+			Tool string `json:"tool"`
+		} `json:"steps"`
+	}
+	_ = json.Unmarshal([]byte(extractJSON(c.Response)), &plan)
+	for _, step := range plan.Steps {
+		res.Tools = append(res.Tools, strings.ToLower(strings.TrimSpace(step.Tool)))
+	}
+	if c.Expect.MinSteps > 0 && len(plan.Steps) < c.Expect.MinSteps {
+		res.Problems = append(res.Problems, "fewer steps than expected")
+	}
+	res.Valid = len(res.Problems) == 0
+	res.ToolScore = toolF1(c.Expect.Tools, res.Tools)
+	res.RiskLevel = plan.RiskLevel
+	res.RiskMatch = c.Expect.RiskLevel == "" || strings.EqualFold(c.Expect.RiskLevel, plan.RiskLevel)
+	valid, risk := 0.0, 0.0
+	if res.Valid {
+		valid = 1
+	}
+	if res.RiskMatch {
+		risk = 1
+	}
+	res.Score = (valid + res.ToolScore + risk) / 3
+	res.Passed = res.Valid && res.ToolScore == 1 && res.RiskMatch
+	return res
+}
+
+// toolF1 compares tool sets; with nothing expected any choice scores 1.
+func toolF1(expected, actual []string) float64 {
+	if len(expected) == 0 {
+		return 1
+	}
+	want := map[string]bool{}
+	for _, t := range expected {
+		want[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	got := map[string]bool{}
+	for _, t := range actual {
+		got[t] = true
+	}
+	hits := 0
+	for t := range got {
+		if want[t] {
+			hits++
+		}
+	}
+	if hits == 0 {
+		return 0
+	}
+	precision := float64(hits) / float64(len(got))
+	recall := float64(hits) / float64(len(want))
+	return 2 * precision * recall / (precision + recall)
+}
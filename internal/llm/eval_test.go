@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestEvalGoldenSet(t *testing.T) {
+	data, err := os.ReadFile("testdata/eval/golden.json")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var cases []EvalCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	summary, err := (&Router{}).Eval(cases, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if summary.Cases != 3 || summary.Passed != 3 || summary.MeanScore != 1 {
+		t.Fatalf("summary: %#v", summary)
+	}
+	if summary.Results[1].PromptTemplate != "alert/v2" || summary.Results[2].PromptTemplate != "schedule/v2" {
+		t.Fatalf("templates: %#v", summary.Results)
+	}
+}
+
+func TestEvalScoresFailures(t *testing.T) {
+	cases := []EvalCase{{
+		Name:         "wrong-tool",
+		Trigger:      "manual",
+		Intent:       "roll back checkout",
+		Response:     `{"summary":"s","risk_level":"low","steps":[{"action":"rollback","tool":"helm","input":{"release":"checkout"}}]}`,
+		Expect:       EvalExpect{Tools: []string{"argocd"}, RiskLevel: "high"},
+		PromptSHA256: "stale",
+	}}
+	reject := func(tool, action string, input any) error { return errors.New("nope") }
+	summary, err := (&Router{}).Eval(cases, reject)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	res := summary.Results[0]
+	if res.Passed || res.Valid || res.ToolScore != 0 || res.RiskMatch || res.Score != 0 {
+		t.Fatalf("result: %#v", res)
+	}
+	if !res.PromptChanged || res.PromptSHA256 == "" {
+		t.Fatalf("prompt hash: %#v", res)
+	}
+	if _, err := (&Router{}).Eval([]EvalCase{{Name: "empty"}}, nil); err == nil {
+		t.Fatalf("expected intent error")
+	}
+}
+
+func TestEvalStaleRecordingFails(t *testing.T) {
+	c := EvalCase{
+		Name:     "restart",
+		Trigger:  "manual",
+		Intent:   "restart checkout",
+		Response: `{"summary":"s","risk_level":"low","steps":[{"action":"restart","tool":"kubectl","input":{"resource":"deployment/checkout"}}]}`,
+		Expect:   EvalExpect{Tools: []string{"kubectl"}},
+	}
+	summary, err := (&Router{}).Eval([]EvalCase{c}, nil)
+	if err != nil || summary.Passed != 1 || summary.Stale != 0 {
+		t.Fatalf("summary: %#v err: %v", summary, err)
+	}
+	c.PromptSHA256 = summary.Results[0].PromptSHA256
+	if summary, _ = (&Router{}).Eval([]EvalCase{c}, nil); summary.Passed != 1 || summary.Results[0].PromptChanged {
+		t.Fatalf("matching hash: %#v", summary)
+	}
+	c.PromptSHA256 = PromptHash("an older prompt")
+	summary, err = (&Router{}).Eval([]EvalCase{c}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	res := summary.Results[0]
+	if res.Passed || !res.PromptChanged || summary.Passed != 0 || summary.Stale != 1 || len(res.Problems) != 1 {
+		t.Fatalf("stale: %#v", summary)
+	}
+}
+
+func TestToolF1(t *testing.T) {
+	if got := toolF1([]string{"kubectl", "prometheus"}, []string{"kubectl"}); got < 0.66 || got > 0.67 {
+		t.Fatalf("f1: %v", got)
+	}
+	if got := toolF1(nil, []string{"kubectl"}); got != 1 {
+		t.Fatalf("f1: %v", got)
+	}
+}
@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"carapulse/internal/metrics"
+)
+
+// Fallback is one entry of the provider chain. Endpoint and credential
+// fields only inherit from the primary when the provider is the same, so a
+// model-only fallback needs just Model.
+type Fallback struct {
+	Provider    string
+	Model       string
+	APIBase     string
+	APIPath     string
+	APIVersion  string
+	Region      string
+	APIKey      string
+	AuthProfile string
+}
+
+// chain returns the primary followed by the configured fallbacks.
+func (r *Router) chain() []*Router {
+	out := []*Router{r}
+	for _, fb := range r.Fallbacks {
+		t := &Router{
+			Provider:       fb.Provider,
+			Model:          fb.Model,
+			APIBase:        fb.APIBase,
+			APIPath:        fb.APIPath,
+			APIVersion:     fb.APIVersion,
+			Region:         fb.Region,
+			APIKey:         fb.APIKey,
+			AuthProfile:    fb.AuthProfile,
+			AuthPath:       r.AuthPath,
+			MaxTokens:      r.MaxTokens,
+			HTTPClient:     r.HTTPClient,
+			RedactPatterns: r.RedactPatterns,
+		}
+		if strings.TrimSpace(t.Provider) == "" {
+			t.Provider = r.Provider
+		}
+		if strings.EqualFold(t.Provider, r.Provider) {
+			t.APIBase = firstNonEmpty(t.APIBase, r.APIBase)
+			t.APIPath = firstNonEmpty(t.APIPath, r.APIPath)
+			t.APIVersion = firstNonEmpty(t.APIVersion, r.APIVersion)
+			t.Region = firstNonEmpty(t.Region, r.Region)
+			t.APIKey = firstNonEmpty(t.APIKey, r.APIKey)
+			t.AuthProfile = firstNonEmpty(t.AuthProfile, r.AuthProfile)
+		}
+		if strings.TrimSpace(t.Model) == "" {
+			t.Model = r.Model
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func (r *Router) completeChain(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error) {
+	return r.completeChainStream(prompt, maxTokens, schema, nil)
+}
+
+func (r *Router) completeChainStream(prompt string, maxTokens int, schema json.RawMessage, stream *draftStream) (string, Usage, error) {
+	start := now()
+	targets := r.chain()
+	var attempts []Attempt
+	var lastErr error
+	for _, idx := range r.order(targets, start) {
+		t := targets[idx]
+		attemptStart := now()
+		text, usage, err := r.attempt(t, prompt, maxTokens, schema, stream)
+		if err != nil {
+			attempts = append(attempts, Attempt{
+				Provider:  t.Provider,
+				Model:     t.Model,
+				Error:     err.Error(),
+				LatencyMS: now().Sub(attemptStart).Milliseconds(),
+			})
+			lastErr = err
+			continue
+		}
+		usage.Provider = strings.ToLower(strings.TrimSpace(t.Provider))
+		usage.Model = t.Model
+		usage.LatencyMS = now().Sub(start).Milliseconds()
+		usage.CostUSD = estimateCost(r.Pricing, usage.Provider, usage.Model, usage)
+		usage.FallbackIndex = idx
+		usage.Attempts = attempts
+		if idx > 0 {
+			metrics.LLMFallbacksTotal.WithLabelValues(usage.Provider, usage.Model).Inc()
+		}
+		return text, usage, nil
+	}
+	if len(targets) > 1 {
+		return "", Usage{Attempts: attempts}, fmt.Errorf("all llm providers failed: %w", lastErr)
+	}
+	return "", Usage{Attempts: attempts}, lastErr
+}
+
+// attempt calls one provider, waiting out a short Retry-After once.
+func (r *Router) attempt(t *Router, prompt string, maxTokens int, schema json.RawMessage, stream *draftStream) (string, Usage, error) {
+	provider := strings.ToLower(strings.TrimSpace(t.Provider))
+	client, err := t.client()
+	if err != nil {
+		metrics.LLMRequestsTotal.WithLabelValues(provider, t.Model, "error").Inc()
+		return "", Usage{}, err
+	}
+	for try := 0; ; try++ {
+		callStart := now()
+		text, usage, err := complete(client, prompt, maxTokens, schema, stream.next())
+		metrics.LLMRequestDuration.WithLabelValues(provider, t.Model).Observe(now().Sub(callStart).Seconds())
+		if err == nil {
+			metrics.LLMRequestsTotal.WithLabelValues(provider, t.Model, "ok").Inc()
+			return text, usage, nil
+		}
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.RetryAfter <= 0 {
+			metrics.LLMRequestsTotal.WithLabelValues(provider, t.Model, "error").Inc()
+			return "", usage, err
+		}
+		metrics.LLMRequestsTotal.WithLabelValues(provider, t.Model, "rate_limited").Inc()
+		if try > 0 || statusErr.RetryAfter > r.MaxRetryWait {
+			r.coolDown(t, statusErr.RetryAfter)
+			return "", usage, err
+		}
+		r.wait(statusErr.RetryAfter)
+	}
+}
+
+// complete streams when asked and the client can, and otherwise reports
+// the finished completion as a single delta.
+func complete(client completer, prompt string, maxTokens int, schema json.RawMessage, onDelta func(string)) (string, Usage, error) {
+	if onDelta == nil {
+		return client.completeUsage(prompt, maxTokens, schema)
+	}
+	if s, ok := client.(streamer); ok {
+		return s.streamUsage(prompt, maxTokens, schema, onDelta)
+	}
+	text, usage, err := client.completeUsage(prompt, maxTokens, schema)
+	if err == nil {
+		onDelta(text)
+	}
+	return text, usage, err
+}
+
+// order skips providers still inside a Retry-After window. When every
+// provider is cooling down it tries the one that frees up first.
+func (r *Router) order(targets []*Router, at time.Time) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ready []int
+	earliest := -1
+	var earliestAt time.Time
+	for i, t := range targets {
+		until, ok := r.cooldown[cooldownKey(t)]
+		if !ok || !at.Before(until) {
+			ready = append(ready, i)
+			continue
+		}
+		if earliest < 0 || until.Before(earliestAt) {
+			earliest, earliestAt = i, until
+		}
+	}
+	if len(ready) == 0 && earliest >= 0 {
+		ready = []int{earliest}
+	}
+	return ready
+}
+
+func (r *Router) coolDown(t *Router, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cooldown == nil {
+		r.cooldown = map[string]time.Time{}
+	}
+	r.cooldown[cooldownKey(t)] = now().Add(d)
+}
+
+func (r *Router) wait(d time.Duration) {
+	if r.sleep != nil {
+		r.sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+func cooldownKey(t *Router) string {
+	return strings.ToLower(strings.TrimSpace(t.Provider)) + "/" + t.Model
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func openAIServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request) bool) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler(w, r) {
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"plan"}}],"usage":{"prompt_tokens":1000,"completion_tokens":500}}`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestPlanWithUsageFallsBack(t *testing.T) {
+	primary := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return false
+	})
+	fallback := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool { return true })
+
+	router := &Router{
+		Provider:  "openai",
+		APIBase:   primary.URL,
+		APIKey:    "k",
+		Model:     "gpt-4o",
+		Fallbacks: []Fallback{{Provider: "openai-compatible", APIBase: fallback.URL, Model: "llama3.1"}},
+		Pricing:   map[string]Price{"llama3.1": {PromptPer1K: 0.001, CompletionPer1K: 0.002}},
+	}
+	text, usage, err := router.PlanWithUsage("intent", nil, nil)
+	if err != nil || text != "plan" {
+		t.Fatalf("text: %s err: %v", text, err)
+	}
+	if usage.Provider != "openai-compatible" || usage.Model != "llama3.1" || usage.FallbackIndex != 1 {
+		t.Fatalf("usage: %#v", usage)
+	}
+	if usage.TotalTokens != 1500 || usage.CostUSD < 0.00199 || usage.CostUSD > 0.00201 {
+		t.Fatalf("tokens/cost: %#v", usage)
+	}
+	if len(usage.Attempts) != 1 || !strings.Contains(usage.Attempts[0].Error, "status 503") {
+		t.Fatalf("attempts: %#v", usage.Attempts)
+	}
+}
+
+func TestPlanWithUsageAllFail(t *testing.T) {
+	ts := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(http.StatusBadGateway)
+		return false
+	})
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "a", Fallbacks: []Fallback{{Model: "b"}}}
+	_, usage, err := router.PlanWithUsage("intent", nil, nil)
+	var statusErr *StatusError
+	if err == nil || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err: %v", err)
+	}
+	if len(usage.Attempts) != 2 || usage.Attempts[1].Model != "b" {
+		t.Fatalf("attempts: %#v", usage.Attempts)
+	}
+}
+
+func TestPlanWithUsageHonoursShortRetryAfter(t *testing.T) {
+	var calls int32
+	ts := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return false
+		}
+		return true
+	})
+	var slept time.Duration
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "m", MaxRetryWait: 5 * time.Second}
+	router.sleep = func(d time.Duration) { slept = d }
+	if _, usage, err := router.PlanWithUsage("intent", nil, nil); err != nil || usage.FallbackIndex != 0 {
+		t.Fatalf("usage: %#v err: %v", usage, err)
+	}
+	if slept != 2*time.Second || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("slept: %v calls: %d", slept, calls)
+	}
+}
+
+func TestPlanWithUsageCoolsDownRateLimitedProvider(t *testing.T) {
+	var primaryCalls int32
+	primary := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		atomic.AddInt32(&primaryCalls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return false
+	})
+	fallback := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool { return true })
+	router := &Router{
+		Provider:  "openai",
+		APIBase:   primary.URL,
+		APIKey:    "k",
+		Model:     "m",
+		Fallbacks: []Fallback{{Provider: "openai-compatible", APIBase: fallback.URL, Model: "local"}},
+	}
+	router.sleep = func(time.Duration) { t.Fatalf("should not wait past MaxRetryWait") }
+	for i := 0; i < 2; i++ {
+		if _, usage, err := router.PlanWithUsage("intent", nil, nil); err != nil || usage.FallbackIndex != 1 {
+			t.Fatalf("usage: %#v err: %v", usage, err)
+		}
+	}
+	if got := atomic.LoadInt32(&primaryCalls); got != 1 {
+		t.Fatalf("primary should be skipped while cooling down, calls: %d", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := parseRetryAfter("7", at); got != 7*time.Second {
+		t.Fatalf("seconds: %v", got)
+	}
+	if got := parseRetryAfter(at.Add(time.Minute).Format(http.TimeFormat), at); got != time.Minute {
+		t.Fatalf("date: %v", got)
+	}
+	if got := parseRetryAfter("soon", at); got != 0 {
+		t.Fatalf("invalid: %v", got)
+	}
+}
+
+func TestChainInheritsOnlySameProvider(t *testing.T) {
+	router := &Router{
+		Provider: "openai",
+		APIBase:  "https://proxy",
+		APIKey:   "k",
+		Model:    "gpt-4o",
+		Fallbacks: []Fallback{
+			{Model: "gpt-4o-mini"},
+			{Provider: "anthropic", Model: "claude"},
+		},
+	}
+	chain := router.chain()
+	if len(chain) != 3 || chain[1].APIBase != "https://proxy" || chain[1].APIKey != "k" || chain[1].Provider != "openai" {
+		t.Fatalf("same-provider fallback: %#v", chain[1])
+	}
+	if chain[2].APIBase != "" || chain[2].APIKey != "" {
+		t.Fatalf("cross-provider fallback inherited credentials: %#v", chain[2])
+	}
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const geminiProvider = "gemini"
+const defaultGeminiBase = "https://generativelanguage.googleapis.com"
+
+// GeminiClient calls generateContent on the Gemini API. APIKey is sent as
+// x-goog-api-key; AccessToken (OAuth) as a bearer token.
+type GeminiClient struct {
+	APIBase     string
+	APIKey      string
+	AccessToken string
+	Model       string
+	HTTPClient  *http.Client
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	Contents         []geminiContent `json:"contents"`
+	GenerationConfig struct {
+		MaxOutputTokens int `json:"maxOutputTokens,omitempty"`
+		// ResponseMimeType application/json enables JSON mode. responseSchema
+		// is not sent: its OpenAPI subset cannot express free-form objects.
+		ResponseMimeType string `json:"responseMimeType,omitempty"`
+	} `json:"generationConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func (c *GeminiClient) Complete(prompt string, maxTokens int) (string, error) {
+	text, _, err := c.completeUsage(prompt, maxTokens, nil)
+	return text, err
+}
+
+func (c *GeminiClient) completeUsage(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error) {
+	if strings.TrimSpace(c.APIKey) == "" && strings.TrimSpace(c.AccessToken) == "" {
+		return "", Usage{}, errors.New("gemini api key or access token required")
+	}
+	model := strings.TrimPrefix(strings.TrimSpace(c.Model), "models/")
+	if model == "" {
+		return "", Usage{}, errors.New("gemini model required")
+	}
+	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
+	if base == "" {
+		base = defaultGeminiBase
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	reqBody := geminiRequest{Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: prompt}}}}}
+	reqBody.GenerationConfig.MaxOutputTokens = maxTokens
+	if len(schema) > 0 {
+		reqBody.GenerationConfig.ResponseMimeType = "application/json"
+	}
+	body, err := marshalJSON(reqBody)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/v1beta/models/"+url.PathEscape(model)+":generateContent", bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	if key := strings.TrimSpace(c.APIKey); key != "" {
+		req.Header.Set("x-goog-api-key", key)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(c.AccessToken))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var out geminiResponse
+	if err := doJSON(c.HTTPClient, req, "gemini", &out); err != nil {
+		return "", Usage{}, err
+	}
+	usage := tokenUsage(out.UsageMetadata.PromptTokenCount, out.UsageMetadata.CandidatesTokenCount)
+	for _, candidate := range out.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if strings.TrimSpace(part.Text) != "" {
+				return part.Text, usage, nil
+			}
+		}
+	}
+	return "", usage, errors.New("gemini empty response")
+}
@@ -1,5 +1,115 @@
 package llm
 
-import "encoding/json"
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
 var marshalJSON = json.Marshal
+
+// StatusError is a non-2xx provider response. RetryAfter is set from the
+// Retry-After header on 429 and 503 responses.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether another attempt (here or on a fallback) may
+// succeed: rate limits, timeouts and server errors.
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout || e.StatusCode >= 500
+}
+
+// doJSON sends req and decodes a 2xx JSON response into out. name prefixes
+// status errors, matching the per-provider clients.
+func doJSON(client *http.Client, req *http.Request, name string, out any) error {
+	resp, err := send(client, req, name)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send performs req and turns a non-2xx response into a StatusError. The
+// caller closes the body of a successful response.
+func send(client *http.Client, req *http.Request, name string) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		payload, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		statusErr := &StatusError{Provider: name, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(payload))}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), now())
+		}
+		return nil, statusErr
+	}
+	return resp, nil
+}
+
+// readSSE calls fn for every server-sent event in body until fn returns
+// an error or the stream ends. Comment lines are skipped and multi-line
+// data is joined with newlines.
+func readSSE(body io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var event string
+	var data []string
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := fn(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
+
+// parseRetryAfter accepts delay-seconds or an HTTP date.
+func parseRetryAfter(value string, at time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if ts, err := http.ParseTime(value); err == nil && ts.After(at) {
+		return ts.Sub(at)
+	}
+	return 0
+}
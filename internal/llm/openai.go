@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -21,11 +19,16 @@ type OpenAIClient struct {
 }
 
 func (c *OpenAIClient) Complete(prompt string, maxTokens int) (string, error) {
+	text, _, err := c.completeUsage(prompt, maxTokens, nil)
+	return text, err
+}
+
+func (c *OpenAIClient) completeUsage(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error) {
 	if strings.TrimSpace(c.APIKey) == "" {
-		return "", errors.New("openai api key required")
+		return "", Usage{}, errors.New("openai api key required")
 	}
 	if strings.TrimSpace(c.Model) == "" {
-		return "", errors.New("openai model required")
+		return "", Usage{}, errors.New("openai model required")
 	}
 	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
 	if base == "" {
@@ -35,35 +38,28 @@ func (c *OpenAIClient) Complete(prompt string, maxTokens int) (string, error) {
 		c.HTTPClient = &http.Client{Timeout: 5 * time.Second}
 	}
 	reqBody := openAIRequest{
-		Model:     c.Model,
-		Messages:  []openAIMessage{{Role: "user", Content: prompt}},
-		MaxTokens: maxTokens,
+		Model:          c.Model,
+		Messages:       []openAIMessage{{Role: "user", Content: prompt}},
+		MaxTokens:      maxTokens,
+		ResponseFormat: openAISchemaFormat(schema),
 	}
 	body, err := marshalJSON(reqBody)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	req, err := http.NewRequest(http.MethodPost, base+"/v1/chat/completions", bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.APIKey)
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		payload, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return "", fmt.Errorf("openai status %d: %s", resp.StatusCode, strings.TrimSpace(string(payload)))
-	}
 	var out openAIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
+	if err := doJSON(c.HTTPClient, req, "openai", &out); err != nil {
+		return "", Usage{}, err
 	}
+	usage := tokenUsage(out.Usage.PromptTokens, out.Usage.CompletionTokens)
 	if len(out.Choices) == 0 || strings.TrimSpace(out.Choices[0].Message.Content) == "" {
-		return "", errors.New("openai empty response")
+		return "", usage, errors.New("openai empty response")
 	}
-	return out.Choices[0].Message.Content, nil
+	return out.Choices[0].Message.Content, usage, nil
 }
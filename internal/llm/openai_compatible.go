@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const openAICompatibleProvider = "openai-compatible"
+const defaultChatCompletionsPath = "/v1/chat/completions"
+
+// OpenAICompatibleClient talks to self-hosted servers that expose the OpenAI
+// chat completions API (Ollama, vLLM, llama.cpp). The key is optional.
+type OpenAICompatibleClient struct {
+	APIBase    string
+	APIPath    string
+	APIKey     string
+	Model      string
+	HTTPClient *http.Client
+}
+
+func (c *OpenAICompatibleClient) Complete(prompt string, maxTokens int) (string, error) {
+	text, _, err := c.completeUsage(prompt, maxTokens, nil)
+	return text, err
+}
+
+func (c *OpenAICompatibleClient) completeUsage(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error) {
+	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
+	if base == "" {
+		return "", Usage{}, errors.New("openai-compatible api base required")
+	}
+	if strings.TrimSpace(c.Model) == "" {
+		return "", Usage{}, errors.New("openai-compatible model required")
+	}
+	path := strings.TrimSpace(c.APIPath)
+	if path == "" {
+		path = defaultChatCompletionsPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if c.HTTPClient == nil {
+		// Local models on CPU routinely take longer than hosted APIs.
+		c.HTTPClient = &http.Client{Timeout: 2 * time.Minute}
+	}
+	body, err := marshalJSON(openAIRequest{
+		Model:     c.Model,
+		Messages:  []openAIMessage{{Role: "user", Content: prompt}},
+		MaxTokens: maxTokens,
+		// JSON mode is common to Ollama, vLLM and llama.cpp; json_schema is not.
+		ResponseFormat: openAIJSONFormat(schema),
+	})
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, base+path, bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	if key := strings.TrimSpace(c.APIKey); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var out openAIResponse
+	if err := doJSON(c.HTTPClient, req, "openai-compatible", &out); err != nil {
+		return "", Usage{}, err
+	}
+	usage := tokenUsage(out.Usage.PromptTokens, out.Usage.CompletionTokens)
+	if len(out.Choices) == 0 || strings.TrimSpace(out.Choices[0].Message.Content) == "" {
+		return "", usage, errors.New("openai-compatible empty response")
+	}
+	return out.Choices[0].Message.Content, usage, nil
+}
@@ -1,7 +1,6 @@
 package llm
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -9,13 +8,28 @@ import (
 )
 
 func (r *Router) Plan(intent string, context any, evidence any) (string, error) {
+	text, _, err := r.PlanWithUsage(intent, context, evidence)
+	return text, err
+}
+
+// PlanWithUsage drafts a plan, trying the fallback chain in order, and
+// reports the token usage of the call that answered.
+func (r *Router) PlanWithUsage(intent string, context any, evidence any) (string, Usage, error) {
+	return r.plan(intent, context, evidence, nil)
+}
+
+func (r *Router) plan(intent string, context any, evidence any, stream *draftStream) (string, Usage, error) {
 	intent = strings.TrimSpace(intent)
 	if intent == "" {
-		return "", errors.New("intent required")
+		return "", Usage{}, errors.New("intent required")
+	}
+	tmpl, err := r.promptTemplate(context)
+	if err != nil {
+		return "", Usage{}, err
 	}
-	prompt, err := buildPrompt(intent, context, evidence)
+	prompt, err := tmpl.Render(intent, context, evidence)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	if len(r.RedactPatterns) > 0 {
 		prompt = Redact(prompt, r.RedactPatterns)
@@ -24,12 +38,40 @@ func (r *Router) Plan(intent string, context any, evidence any) (string, error)
 	if maxTokens <= 0 {
 		maxTokens = 512
 	}
+	text, usage, err := r.completeChainStream(prompt, maxTokens, PlanSchema, stream)
+	usage.PromptTemplate = tmpl.ID()
+	if err != nil {
+		return "", usage, err
+	}
+	// Repair loop: send validation errors back and keep the last answer,
+	// so a failed repair still leaves the caller a draft to report on.
+	for usage.Repairs < r.MaxRepairs {
+		problems := ValidatePlan(text, r.ValidateStep)
+		if len(problems) == 0 {
+			break
+		}
+		fix := repairPrompt(prompt, text, problems)
+		if len(r.RedactPatterns) > 0 {
+			fix = Redact(fix, r.RedactPatterns)
+		}
+		repaired, next, err := r.completeChainStream(fix, maxTokens, PlanSchema, stream)
+		usage = usage.add(next)
+		if err != nil {
+			break
+		}
+		text = repaired
+	}
+	return text, usage, nil
+}
+
+// client builds the provider client for r.
+func (r *Router) client() (completer, error) {
 	provider := strings.ToLower(strings.TrimSpace(r.Provider))
 	switch provider {
 	case "openai":
-		key := r.APIKey
-		if key == "" {
-			key = os.Getenv("OPENAI_API_KEY")
+		key, err := r.keyOrProfile("openai", "OPENAI_API_KEY")
+		if err != nil {
+			return nil, err
 		}
 		client := &OpenAIClient{
 			APIBase:    r.APIBase,
@@ -37,11 +79,11 @@ func (r *Router) Plan(intent string, context any, evidence any) (string, error)
 			Model:      r.Model,
 			HTTPClient: r.HTTPClient,
 		}
-		return client.Complete(prompt, maxTokens)
+		return client, nil
 	case "anthropic":
-		key := r.APIKey
-		if key == "" {
-			key = os.Getenv("ANTHROPIC_API_KEY")
+		key, err := r.keyOrProfile("anthropic", "ANTHROPIC_API_KEY")
+		if err != nil {
+			return nil, err
 		}
 		client := &AnthropicClient{
 			APIBase:    r.APIBase,
@@ -49,11 +91,72 @@ func (r *Router) Plan(intent string, context any, evidence any) (string, error)
 			Model:      r.Model,
 			HTTPClient: r.HTTPClient,
 		}
-		return client.Complete(prompt, maxTokens)
+		return client, nil
+	case openAICompatibleProvider:
+		key, err := r.keyOrProfile(openAICompatibleProvider, "OPENAI_COMPATIBLE_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		client := &OpenAICompatibleClient{
+			APIBase:    r.APIBase,
+			APIPath:    r.APIPath,
+			APIKey:     key,
+			Model:      r.Model,
+			HTTPClient: r.HTTPClient,
+		}
+		return client, nil
+	case azureOpenAIProvider:
+		client := &AzureOpenAIClient{
+			Endpoint:   r.APIBase,
+			Deployment: r.Model,
+			APIVersion: r.APIVersion,
+			APIKey:     r.staticKey("AZURE_OPENAI_API_KEY"),
+			HTTPClient: r.HTTPClient,
+		}
+		if client.APIKey == "" {
+			token, err := r.profileToken(azureOpenAIProvider)
+			if err != nil {
+				return nil, err
+			}
+			client.AccessToken = token
+		}
+		return client, nil
+	case bedrockProvider:
+		creds, err := r.bedrockCredentials()
+		if err != nil {
+			return nil, err
+		}
+		region := strings.TrimSpace(r.Region)
+		if region == "" {
+			region = strings.TrimSpace(os.Getenv("AWS_REGION"))
+		}
+		client := &BedrockClient{
+			APIBase:     r.APIBase,
+			Region:      region,
+			Model:       r.Model,
+			Credentials: creds,
+			HTTPClient:  r.HTTPClient,
+		}
+		return client, nil
+	case geminiProvider:
+		client := &GeminiClient{
+			APIBase:    r.APIBase,
+			APIKey:     r.staticKey("GEMINI_API_KEY", "GOOGLE_API_KEY"),
+			Model:      r.Model,
+			HTTPClient: r.HTTPClient,
+		}
+		if client.APIKey == "" {
+			token, err := r.profileToken(geminiProvider)
+			if err != nil {
+				return nil, err
+			}
+			client.AccessToken = token
+		}
+		return client, nil
 	case codexProvider:
 		token, err := r.resolveCodexToken()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		client := &CodexClient{
 			APIBase:     r.APIBase,
@@ -61,29 +164,15 @@ func (r *Router) Plan(intent string, context any, evidence any) (string, error)
 			Model:       r.Model,
 			HTTPClient:  r.HTTPClient,
 		}
-		return client.Complete(prompt, maxTokens)
+		return client, nil
 	default:
-		return "", fmt.Errorf("unknown provider: %s", provider)
+		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
 }
 
-func buildPrompt(intent string, context any, evidence any) (string, error) {
-	ctxJSON, err := json.Marshal(context)
-	if err != nil {
-		return "", err
-	}
-	evJSON, err := json.Marshal(evidence)
-	if err != nil {
-		return "", err
-	}
-	// Sanitize all external inputs to defend against prompt injection.
-	sanitizedIntent := SanitizePromptInput(intent)
-	sanitizedCtx := SanitizePromptInput(string(ctxJSON))
-	sanitizedEv := SanitizePromptInput(string(evJSON))
-	return fmt.Sprintf(
-		"Intent:\n%s\nContext:\n%s\nEvidence:\n%s\nReturn JSON only with shape:\n{\"summary\":string,\"risk_level\":\"low|medium|high\",\"steps\":[{\"action\":string,\"tool\":string,\"input\":object,\"preconditions\":array,\"rollback\":object}]}",
-		sanitizedIntent,
-		sanitizedCtx,
-		sanitizedEv,
-	), nil
+// promptTemplate picks the template for the plan's trigger, honouring a
+// pinned version.
+func (r *Router) promptTemplate(context any) (PromptTemplate, error) {
+	trigger := PromptTrigger(triggerOf(context))
+	return LoadPromptTemplate(r.PromptDir, trigger, r.PromptVersions[trigger])
 }
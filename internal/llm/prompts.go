@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Built-in planning prompts, one directory per trigger holding v<N>.tmpl.
+//
+//go:embed prompts/*/*.tmpl
+var promptFS embed.FS
+
+const planShape = `{"summary":string,"risk_level":"read|low|medium|high","steps":[{"stage":"act|verify","action":string,"tool":string,"input":object,"preconditions":array,"rollback":object}]}`
+
+// PromptTemplate is one version of the planning prompt for a trigger.
+type PromptTemplate struct {
+	Trigger string
+	Version int
+	tmpl    *template.Template
+}
+
+// ID is recorded on plans, e.g. "alert/v2".
+func (p PromptTemplate) ID() string {
+	return p.Trigger + "/v" + strconv.Itoa(p.Version)
+}
+
+type promptData struct {
+	Intent     string
+	Context    string
+	Evidence   string
+	References string
+	Shape      string
+}
+
+// Reference is a retrieved runbook, playbook or memory entry offered to
+// the model. Plans cite references by ID.
+type Reference struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	Excerpt string `json:"excerpt"`
+}
+
+// Render fills the template. All external input is sanitized against
+// prompt injection first. References under the context's "references"
+// key are rendered as their own section rather than inside the context.
+func (p PromptTemplate) Render(intent string, context any, evidence any) (string, error) {
+	context, refs := splitReferences(context)
+	ctxJSON, err := json.Marshal(context)
+	if err != nil {
+		return "", err
+	}
+	evJSON, err := json.Marshal(evidence)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = p.tmpl.Execute(&buf, promptData{
+		Intent:     SanitizePromptInput(intent),
+		Context:    SanitizePromptInput(string(ctxJSON)),
+		Evidence:   SanitizePromptInput(string(evJSON)),
+		References: SanitizePromptInput(formatReferences(refs)),
+		Shape:      planShape,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// splitReferences removes "references" from a context map without
+// modifying the caller's map.
+func splitReferences(context any) (any, []Reference) {
+	m, ok := context.(map[string]any)
+	if !ok {
+		return context, nil
+	}
+	refs, ok := m["references"].([]Reference)
+	if !ok {
+		return context, nil
+	}
+	rest := make(map[string]any, len(m))
+	for k, v := range m {
+		if k != "references" {
+			rest[k] = v
+		}
+	}
+	return rest, refs
+}
+
+func formatReferences(refs []Reference) string {
+	if len(refs) == 0 {
+		return "none"
+	}
+	var b strings.Builder
+	for i, ref := range refs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n%s\n", ref.ID, ref.Kind, ref.Title, strings.TrimSpace(ref.Excerpt))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// PromptTrigger maps a plan trigger to its template set; anything
+// unrecognised is planned as manual.
+func PromptTrigger(trigger string) string {
+	switch strings.ToLower(strings.TrimSpace(trigger)) {
+	case "alert", "webhook":
+		return "alert"
+	case "schedule", "scheduled":
+		return "schedule"
+	case "workflow":
+		return "workflow"
+	default:
+		return "manual"
+	}
+}
+
+// LoadPromptTemplate loads trigger's template from dir, or from the
+// built-in set when dir is empty or has none for trigger. Version 0
+// selects the highest version available.
+func LoadPromptTemplate(dir, trigger string, version int) (PromptTemplate, error) {
+	trigger = PromptTrigger(trigger)
+	if strings.TrimSpace(dir) != "" {
+		fsys := os.DirFS(dir)
+		if versions := promptVersions(fsys, trigger); len(versions) > 0 {
+			return parsePromptTemplate(fsys, trigger, version, versions)
+		}
+	}
+	sub, err := fs.Sub(promptFS, "prompts")
+	if err != nil {
+		return PromptTemplate{}, err
+	}
+	return parsePromptTemplate(sub, trigger, version, promptVersions(sub, trigger))
+}
+
+func parsePromptTemplate(fsys fs.FS, trigger string, version int, versions []int) (PromptTemplate, error) {
+	if len(versions) == 0 {
+		return PromptTemplate{}, fmt.Errorf("no prompt templates for trigger %s", trigger)
+	}
+	if version <= 0 {
+		version = versions[len(versions)-1]
+	}
+	name := path.Join(trigger, "v"+strconv.Itoa(version)+".tmpl")
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return PromptTemplate{}, fmt.Errorf("prompt template %s: %w", name, err)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return PromptTemplate{}, err
+	}
+	return PromptTemplate{Trigger: trigger, Version: version, tmpl: tmpl}, nil
+}
+
+// promptVersions lists the versions present for trigger, ascending.
+func promptVersions(fsys fs.FS, trigger string) []int {
+	matches, err := fs.Glob(fsys, trigger+"/v*.tmpl")
+	if err != nil {
+		return nil
+	}
+	var versions []int
+	for _, m := range matches {
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path.Base(m), "v"), ".tmpl"))
+		if err == nil && n > 0 {
+			versions = append(versions, n)
+		}
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// triggerOf reads "trigger" from a plan context map.
+func triggerOf(context any) string {
+	if m, ok := context.(map[string]any); ok {
+		if trigger, ok := m["trigger"].(string); ok {
+			return trigger
+		}
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPromptTemplateBuiltins(t *testing.T) {
+	for _, trigger := range []string{"manual", "alert", "webhook", "scheduled", "workflow", ""} {
+		tmpl, err := LoadPromptTemplate("", trigger, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", trigger, err)
+		}
+		prompt, err := tmpl.Render("restart api", map[string]any{"trigger": trigger}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", trigger, err)
+		}
+		if !strings.Contains(prompt, "restart api") || !strings.Contains(prompt, planShape) {
+			t.Fatalf("%s prompt: %s", trigger, prompt)
+		}
+	}
+	if tmpl, _ := LoadPromptTemplate("", "webhook", 0); tmpl.ID() != "alert/v2" {
+		t.Fatalf("id: %s", tmpl.ID())
+	}
+	if _, err := LoadPromptTemplate("", "manual", 9); err == nil {
+		t.Fatalf("expected missing version error")
+	}
+}
+
+func TestLoadPromptTemplateDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "alert"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, body := range map[string]string{"v2.tmpl": "v2 {{.Intent}}", "v10.tmpl": "v10 {{.Intent}}"} {
+		if err := os.WriteFile(filepath.Join(dir, "alert", name), []byte(body), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	tmpl, err := LoadPromptTemplate(dir, "alert", 0)
+	if err != nil || tmpl.ID() != "alert/v10" {
+		t.Fatalf("tmpl: %s err: %v", tmpl.ID(), err)
+	}
+	pinned, err := LoadPromptTemplate(dir, "alert", 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if prompt, _ := pinned.Render("x", nil, nil); prompt != "v2 x" {
+		t.Fatalf("prompt: %s", prompt)
+	}
+	// Triggers without overrides fall back to the built-ins.
+	if tmpl, err := LoadPromptTemplate(dir, "manual", 0); err != nil || tmpl.ID() != "manual/v2" {
+		t.Fatalf("tmpl: %s err: %v", tmpl.ID(), err)
+	}
+}
+
+func TestPlanWithUsageRecordsPromptTemplate(t *testing.T) {
+	ts := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool { return true })
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "gpt-4o"}
+	_, usage, err := router.PlanWithUsage("intent", map[string]any{"trigger": "alert"}, nil)
+	if err != nil || usage.PromptTemplate != "alert/v2" {
+		t.Fatalf("usage: %#v err: %v", usage, err)
+	}
+}
+
+func TestRenderReferences(t *testing.T) {
+	tmpl, err := LoadPromptTemplate("", "manual", 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	context := map[string]any{
+		"trigger":    "manual",
+		"references": []Reference{{ID: "runbook_1", Kind: "runbook", Title: "Restart api", Excerpt: "kubectl rollout restart"}},
+	}
+	prompt, err := tmpl.Render("restart api", context, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(prompt, "[runbook_1] runbook: Restart api\nkubectl rollout restart") || strings.Contains(prompt, `"references"`) {
+		t.Fatalf("prompt: %s", prompt)
+	}
+	if _, ok := context["references"]; !ok {
+		t.Fatalf("caller context modified")
+	}
+	prompt, _ = tmpl.Render("restart api", map[string]any{"trigger": "manual"}, nil)
+	if !strings.Contains(prompt, "tenant):\nnone") {
+		t.Fatalf("prompt: %s", prompt)
+	}
+}
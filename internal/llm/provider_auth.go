@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"carapulse/internal/awsauth"
+)
+
+// staticKey returns the configured API key, else the first env var set.
+func (r *Router) staticKey(envs ...string) string {
+	if key := strings.TrimSpace(r.APIKey); key != "" {
+		return key
+	}
+	for _, name := range envs {
+		if key := strings.TrimSpace(os.Getenv(name)); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// profileFor selects the auth profile for provider. A configured profile ID
+// that does not exist is an error; no matching profile at all is not, so
+// providers that need no credentials keep working.
+func (r *Router) profileFor(provider string) (AuthProfile, bool, error) {
+	profileID := strings.TrimSpace(r.AuthProfile)
+	if profileID == "" {
+		profileID = strings.TrimSpace(os.Getenv("CARAPULSE_AUTH_PROFILE"))
+	}
+	path := strings.TrimSpace(r.AuthPath)
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv("CARAPULSE_AUTH_PATH"))
+	}
+	profiles, err := LoadAuthProfiles(path)
+	if err != nil {
+		return AuthProfile{}, false, err
+	}
+	profile, ok := SelectProfile(profiles, provider, profileID)
+	if !ok {
+		if profileID != "" {
+			return AuthProfile{}, false, errors.New("auth profile not found")
+		}
+		return AuthProfile{}, false, nil
+	}
+	if profile.Expired(now()) {
+		return AuthProfile{}, false, errors.New(provider + " access token expired")
+	}
+	return profile, true, nil
+}
+
+// profileToken returns the access token of the provider's auth profile, or
+// "" when there is none.
+func (r *Router) profileToken(provider string) (string, error) {
+	profile, ok, err := r.profileFor(provider)
+	if err != nil || !ok {
+		return "", err
+	}
+	return strings.TrimSpace(profile.AccessToken), nil
+}
+
+// keyOrProfile resolves an API key from config, env, then the auth profile.
+func (r *Router) keyOrProfile(provider string, envs ...string) (string, error) {
+	if key := r.staticKey(envs...); key != "" {
+		return key, nil
+	}
+	return r.profileToken(provider)
+}
+
+// bedrockCredentials prefers AWS keys stored on an auth profile and falls
+// back to the AWS_* environment.
+func (r *Router) bedrockCredentials() (awsauth.Credentials, error) {
+	profile, ok, err := r.profileFor(bedrockProvider)
+	if err != nil {
+		return awsauth.Credentials{}, err
+	}
+	if ok && profile.AWSAccessKeyID != "" {
+		return awsauth.Credentials{
+			AccessKeyID:     profile.AWSAccessKeyID,
+			SecretAccessKey: profile.AWSSecretAccessKey,
+			SessionToken:    profile.AWSSessionToken,
+			Expires:         profile.ExpiresAt,
+		}, nil
+	}
+	return awsauth.CredentialsFromEnv(), nil
+}
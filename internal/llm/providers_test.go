@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanOpenAICompatibleNoKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("unexpected auth: %s", got)
+		}
+		if r.URL.Path != "/ollama/v1/chat/completions" {
+			t.Errorf("path: %s", r.URL.Path)
+		}
+		var req openAIRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3.1" {
+			t.Errorf("model: %s", req.Model)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"plan"}}]}`))
+	}))
+	defer ts.Close()
+
+	t.Setenv("CARAPULSE_AUTH_PATH", filepath.Join(t.TempDir(), "none.json"))
+	router := &Router{Provider: "openai-compatible", APIBase: ts.URL + "/ollama", Model: "llama3.1", HTTPClient: ts.Client()}
+	out, err := router.Plan("intent", nil, nil)
+	if err != nil || out != "plan" {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+}
+
+func TestOpenAICompatibleCustomPath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer k" {
+			t.Errorf("path: %s auth: %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer ts.Close()
+
+	client := &OpenAICompatibleClient{APIBase: ts.URL, APIPath: "chat/completions", APIKey: "k", Model: "m", HTTPClient: ts.Client()}
+	if out, err := client.Complete("p", 10); err != nil || out != "ok" {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+	if _, err := (&OpenAICompatibleClient{Model: "m"}).Complete("p", 10); err == nil {
+		t.Fatalf("expected api base error")
+	}
+}
+
+func TestPlanAzureOpenAI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt4o-prod/chat/completions" || r.URL.Query().Get("api-version") != "2024-10-21" {
+			t.Errorf("url: %s", r.URL.String())
+		}
+		if r.Header.Get("api-key") != "azkey" {
+			t.Errorf("api-key: %s", r.Header.Get("api-key"))
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"plan"}}]}`))
+	}))
+	defer ts.Close()
+
+	router := &Router{Provider: "azure-openai", APIBase: ts.URL, APIVersion: "2024-10-21", Model: "gpt4o-prod", APIKey: "azkey", HTTPClient: ts.Client()}
+	out, err := router.Plan("intent", nil, nil)
+	if err != nil || out != "plan" {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+}
+
+func TestPlanAzureOpenAIProfileToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer entra" || r.Header.Get("api-key") != "" {
+			t.Errorf("auth headers: %v", r.Header)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"plan"}}]}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "auth.json")
+	profiles := AuthProfiles{Profiles: []AuthProfile{{ID: "az", Provider: "azure-openai", AccessToken: "entra"}}}
+	if err := SaveAuthProfiles(path, profiles); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	router := &Router{Provider: "azure-openai", APIBase: ts.URL, Model: "d", AuthPath: path, HTTPClient: ts.Client()}
+	if out, err := router.Plan("intent", nil, nil); err != nil || out != "plan" {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+}
+
+func TestPlanBedrockProfileCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/model/anthropic.claude-3-haiku-20240307-v1%3A0/converse" {
+			t.Errorf("path: %s", r.URL.EscapedPath())
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-central-1/bedrock/aws4_request") {
+			t.Errorf("auth: %s", auth)
+		}
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("missing session token")
+		}
+		var req bedrockRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.InferenceConfig.MaxTokens != 64 || req.Messages[0].Content[0].Text == "" {
+			t.Errorf("request: %#v", req)
+		}
+		_, _ = w.Write([]byte(`{"output":{"message":{"role":"assistant","content":[{"text":"plan"}]}},"stopReason":"end_turn"}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "auth.json")
+	profiles := AuthProfiles{Profiles: []AuthProfile{{ID: "aws", Provider: "bedrock", AWSAccessKeyID: "AKID", AWSSecretAccessKey: "secret", AWSSessionToken: "session"}}}
+	if err := SaveAuthProfiles(path, profiles); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	router := &Router{
+		Provider:   "bedrock",
+		APIBase:    ts.URL,
+		Region:     "eu-central-1",
+		Model:      "anthropic.claude-3-haiku-20240307-v1:0",
+		AuthPath:   path,
+		MaxTokens:  64,
+		HTTPClient: ts.Client(),
+	}
+	if out, err := router.Plan("intent", nil, nil); err != nil || out != "plan" {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+}
+
+func TestBedrockRequiresCredentials(t *testing.T) {
+	client := &BedrockClient{Region: "us-east-1", Model: "m"}
+	if _, err := client.Complete("p", 10); err == nil {
+		t.Fatalf("expected credentials error")
+	}
+}
+
+func TestPlanGemini(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-1.5-pro:generateContent" {
+			t.Errorf("path: %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "gkey" {
+			t.Errorf("key: %s", r.Header.Get("x-goog-api-key"))
+		}
+		var req geminiRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.GenerationConfig.MaxOutputTokens != 512 {
+			t.Errorf("max tokens: %d", req.GenerationConfig.MaxOutputTokens)
+		}
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"plan"}]}}]}`))
+	}))
+	defer ts.Close()
+
+	router := &Router{Provider: "gemini", APIBase: ts.URL, Model: "models/gemini-1.5-pro", APIKey: "gkey", HTTPClient: ts.Client()}
+	if out, err := router.Plan("intent", nil, nil); err != nil || out != "plan" {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+}
+
+func TestGeminiEmptyAndStatusErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer bad" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"candidates":[]}`))
+	}))
+	defer ts.Close()
+
+	if _, err := (&GeminiClient{APIBase: ts.URL, APIKey: "k", Model: "m", HTTPClient: ts.Client()}).Complete("p", 1); err == nil {
+		t.Fatalf("expected empty response error")
+	}
+	if _, err := (&GeminiClient{APIBase: ts.URL, AccessToken: "bad", Model: "m", HTTPClient: ts.Client()}).Complete("p", 1); err == nil || !strings.Contains(err.Error(), "gemini status 401") {
+		t.Fatalf("expected status error: %v", err)
+	}
+}
+
+func TestProfileForExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.json")
+	profiles := AuthProfiles{Profiles: []AuthProfile{{ID: "g", Provider: "gemini", AccessToken: "tok", ExpiresAt: time.Now().Add(-time.Minute)}}}
+	if err := SaveAuthProfiles(path, profiles); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	router := &Router{AuthPath: path}
+	if _, _, err := router.profileFor("gemini"); err == nil {
+		t.Fatalf("expected expired error")
+	}
+	if _, ok, err := router.profileFor("bedrock"); ok || err != nil {
+		t.Fatalf("expected no profile: %v %v", ok, err)
+	}
+	router.AuthProfile = "missing"
+	if _, _, err := router.profileFor("gemini"); err == nil {
+		t.Fatalf("expected missing profile error")
+	}
+}
@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// QuerySchema is the JSON schema of a translated query.
+var QuerySchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["language", "query"],
+  "properties": {
+    "language": {"type": "string", "enum": ["promql", "traceql"]},
+    "query": {"type": "string", "minLength": 1},
+    "explanation": {"type": "string"}
+  }
+}`)
+
+const queryShape = `{"language":"promql|traceql","query":string,"explanation":string}`
+
+// maxPromptMetrics caps how many metric names are listed in the prompt;
+// the ones sharing the most words with the question come first.
+const maxPromptMetrics = 150
+
+// MetricName is a metric the Prometheus poller discovered.
+type MetricName struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type,omitempty"`
+	Help   string   `json:"help,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// TraceService is a root service the Tempo poller discovered.
+type TraceService struct {
+	Name      string   `json:"name"`
+	RootSpans []string `json:"root_spans,omitempty"`
+}
+
+// QueryVocabulary is what the question may be translated against.
+// TraceTags maps an attribute scope (span, resource) to its tag names.
+type QueryVocabulary struct {
+	Metrics       []MetricName        `json:"metrics,omitempty"`
+	TraceServices []TraceService      `json:"trace_services,omitempty"`
+	TraceTags     map[string][]string `json:"trace_tags,omitempty"`
+}
+
+// QueryRequest is a question to translate. Language is promql, traceql or
+// empty to let the model choose.
+type QueryRequest struct {
+	Question   string
+	Language   string
+	Service    string
+	Vocabulary QueryVocabulary
+}
+
+// QueryTranslation is a generated query.
+type QueryTranslation struct {
+	Language    string `json:"language"`
+	Query       string `json:"query"`
+	Explanation string `json:"explanation,omitempty"`
+	Usage       *Usage `json:"usage,omitempty"`
+}
+
+// QueryTranslator turns questions into PromQL or TraceQL.
+type QueryTranslator interface {
+	TranslateQuery(req QueryRequest) (QueryTranslation, error)
+}
+
+// TranslateQuery asks the model for a PromQL or TraceQL query answering
+// the question, restricted to the discovered vocabulary. A PromQL query
+// that names no discovered metric is rejected.
+func (r *Router) TranslateQuery(req QueryRequest) (QueryTranslation, error) {
+	if strings.TrimSpace(req.Question) == "" {
+		return QueryTranslation{}, errors.New("question required")
+	}
+	language := strings.ToLower(strings.TrimSpace(req.Language))
+	switch language {
+	case "", "promql", "traceql":
+	default:
+		return QueryTranslation{}, fmt.Errorf("unsupported query language %q", req.Language)
+	}
+	prompt, err := queryPrompt(req, language)
+	if err != nil {
+		return QueryTranslation{}, err
+	}
+	if len(r.RedactPatterns) > 0 {
+		prompt = Redact(prompt, r.RedactPatterns)
+	}
+	maxTokens := r.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 512
+	}
+	text, usage, err := r.completeChain(prompt, maxTokens, QuerySchema)
+	if err != nil {
+		return QueryTranslation{}, err
+	}
+	out, err := parseQueryTranslation(text, language, req.Vocabulary)
+	if err != nil {
+		return QueryTranslation{}, err
+	}
+	out.Usage = &usage
+	return out, nil
+}
+
+func queryPrompt(req QueryRequest, language string) (string, error) {
+	metrics := rankMetrics(req.Vocabulary.Metrics, req.Question+" "+req.Service)
+	if len(metrics) > maxPromptMetrics {
+		metrics = metrics[:maxPromptMetrics]
+	}
+	metricsJSON, err := json.Marshal(metrics)
+	if err != nil {
+		return "", err
+	}
+	servicesJSON, err := json.Marshal(req.Vocabulary.TraceServices)
+	if err != nil {
+		return "", err
+	}
+	tagsJSON, err := json.Marshal(req.Vocabulary.TraceTags)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("Translate an engineer's question into one read-only observability query.\n")
+	fmt.Fprintf(&b, "Question:\n%s\n", SanitizePromptInput(req.Question))
+	if req.Service != "" {
+		fmt.Fprintf(&b, "Service:\n%s\n", SanitizePromptInput(req.Service))
+	}
+	switch language {
+	case "promql":
+		b.WriteString("Language: PromQL.\n")
+	case "traceql":
+		b.WriteString("Language: TraceQL.\n")
+	default:
+		b.WriteString("Language: PromQL for metrics questions, TraceQL for questions about individual requests or traces.\n")
+	}
+	fmt.Fprintf(&b, "Prometheus metrics (name, type, help, label names):\n%s\n", SanitizePromptInput(string(metricsJSON)))
+	fmt.Fprintf(&b, "Tempo root services and span names:\n%s\n", SanitizePromptInput(string(servicesJSON)))
+	fmt.Fprintf(&b, "Tempo attribute names by scope:\n%s\n", SanitizePromptInput(string(tagsJSON)))
+	b.WriteString("Use only the metric, label, service and attribute names listed above. Explain the query in one sentence.\n")
+	b.WriteString("Return JSON only with shape:\n")
+	b.WriteString(queryShape)
+	return b.String(), nil
+}
+
+func parseQueryTranslation(text, language string, vocab QueryVocabulary) (QueryTranslation, error) {
+	var out QueryTranslation
+	if err := json.Unmarshal([]byte(extractJSON(text)), &out); err != nil {
+		return QueryTranslation{}, fmt.Errorf("query response is not valid JSON: %w", err)
+	}
+	out.Language = strings.ToLower(strings.TrimSpace(out.Language))
+	out.Query = strings.TrimSpace(out.Query)
+	if out.Query == "" {
+		return QueryTranslation{}, errors.New("query response has no query")
+	}
+	if language != "" && out.Language != language {
+		return QueryTranslation{}, fmt.Errorf("asked for %s, got %s", language, out.Language)
+	}
+	switch out.Language {
+	case "promql":
+		if len(vocab.Metrics) > 0 && !mentionsMetric(out.Query, vocab.Metrics) {
+			return QueryTranslation{}, fmt.Errorf("promql %q uses no discovered metric", out.Query)
+		}
+	case "traceql":
+		if !strings.HasPrefix(out.Query, "{") {
+			return QueryTranslation{}, fmt.Errorf("traceql %q must start with a span selector", out.Query)
+		}
+	default:
+		return QueryTranslation{}, fmt.Errorf("unsupported query language %q", out.Language)
+	}
+	return out, nil
+}
+
+var promIdentifier = regexp.MustCompile(`[a-zA-Z_:][a-zA-Z0-9_:]*`)
+
+func mentionsMetric(query string, metrics []MetricName) bool {
+	known := make(map[string]bool, len(metrics))
+	for _, m := range metrics {
+		known[m.Name] = true
+	}
+	for _, ident := range promIdentifier.FindAllString(query, -1) {
+		if known[ident] {
+			return true
+		}
+		// Histogram and summary series carry suffixes the metadata omits.
+		for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+			if known[strings.TrimSuffix(ident, suffix)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rankMetrics orders metrics by how many words of text appear in their
+// name or help, keeping the discovered order for ties.
+func rankMetrics(metrics []MetricName, text string) []MetricName {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	score := func(m MetricName) int {
+		haystack := strings.ToLower(m.Name + " " + m.Help)
+		n := 0
+		for _, w := range words {
+			if len(w) > 2 && strings.Contains(haystack, w) {
+				n++
+			}
+		}
+		return n
+	}
+	out := append([]MetricName(nil), metrics...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return score(out[i]) > score(out[j])
+	})
+	return out
+}
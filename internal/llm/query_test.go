@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterTranslateQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []openAIMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[0].Content
+		if !strings.Contains(prompt, "Language: PromQL.") || strings.Index(prompt, "http_requests_total") > strings.Index(prompt, `"up"`) {
+			t.Errorf("prompt: %s", prompt)
+		}
+		answer := `{"language":"promql","query":"sum(rate(http_requests_total{code=~\"5..\"}[5m]))","explanation":"5xx rate"}`
+		payload, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": answer}}},
+			"usage":   map[string]any{"prompt_tokens": 40, "completion_tokens": 10},
+		})
+		_, _ = w.Write(payload)
+	}))
+	defer ts.Close()
+
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "gpt-4o-mini", HTTPClient: ts.Client()}
+	out, err := router.TranslateQuery(QueryRequest{
+		Question: "What is the rate of failed requests?",
+		Language: "PromQL",
+		Vocabulary: QueryVocabulary{Metrics: []MetricName{
+			{Name: "up"},
+			{Name: "http_requests_total", Help: "Total failed and successful requests.", Labels: []string{"code"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Language != "promql" || !strings.HasPrefix(out.Query, "sum(rate(http_requests_total") || out.Usage == nil || out.Usage.TotalTokens != 50 {
+		t.Fatalf("out: %#v", out)
+	}
+}
+
+func TestParseQueryTranslationValidates(t *testing.T) {
+	vocab := QueryVocabulary{Metrics: []MetricName{{Name: "http_request_duration_seconds"}}}
+	if _, err := parseQueryTranslation(`{"language":"promql","query":"histogram_quantile(0.9, rate(http_request_duration_seconds_bucket[5m]))"}`, "", vocab); err != nil {
+		t.Fatalf("histogram suffix: %v", err)
+	}
+	for _, tc := range []struct{ text, language string }{
+		{"not json", ""},
+		{`{"language":"promql","query":""}`, ""},
+		{`{"language":"promql","query":"made_up_metric"}`, ""},
+		{`{"language":"traceql","query":"{ .service.name = \"api\" }"}`, "promql"},
+		{`{"language":"traceql","query":"service.name = api"}`, ""},
+		{`{"language":"logql","query":"{app=\"x\"}"}`, ""},
+	} {
+		if _, err := parseQueryTranslation(tc.text, tc.language, vocab); err == nil {
+			t.Fatalf("expected error for %s", tc.text)
+		}
+	}
+	if _, err := (&Router{}).TranslateQuery(QueryRequest{Question: "x", Language: "sql"}); err == nil {
+		t.Fatalf("expected language error")
+	}
+	if _, err := (&Router{}).TranslateQuery(QueryRequest{}); err == nil {
+		t.Fatalf("expected question error")
+	}
+}
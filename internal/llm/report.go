@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ReportSchema is the JSON schema of a post-incident report narrative.
+var ReportSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["summary"],
+  "properties": {
+    "summary": {"type": "string", "minLength": 1},
+    "diagnosis": {"type": "string"},
+    "impact": {"type": "string"},
+    "follow_ups": {"type": "array", "items": {"type": "string"}}
+  }
+}`)
+
+const reportShape = `{"summary":string,"diagnosis":string,"impact":string,"follow_ups":[string]}`
+
+// ReportInput is what an execution recorded, gathered for the report
+// narrative.
+type ReportInput struct {
+	Intent       string `json:"intent"`
+	Trigger      string `json:"trigger,omitempty"`
+	Status       string `json:"status"`
+	Alert        any    `json:"alert,omitempty"`
+	Diagnostics  any    `json:"diagnostics,omitempty"`
+	Timeline     any    `json:"timeline,omitempty"`
+	Actions      any    `json:"actions,omitempty"`
+	Verification any    `json:"verification,omitempty"`
+}
+
+// ReportNarrative is the prose part of a post-incident report.
+type ReportNarrative struct {
+	Summary   string   `json:"summary"`
+	Diagnosis string   `json:"diagnosis,omitempty"`
+	Impact    string   `json:"impact,omitempty"`
+	FollowUps []string `json:"follow_ups,omitempty"`
+	Usage     *Usage   `json:"usage,omitempty"`
+}
+
+// Reporter writes report narratives.
+type Reporter interface {
+	Narrate(input ReportInput) (ReportNarrative, error)
+}
+
+// Narrate asks the model to write the narrative of an execution's
+// post-incident report from what was recorded. It only describes the
+// records; facts not in the input must not appear.
+func (r *Router) Narrate(input ReportInput) (ReportNarrative, error) {
+	if strings.TrimSpace(input.Intent) == "" {
+		return ReportNarrative{}, errors.New("intent required")
+	}
+	prompt, err := reportPrompt(input)
+	if err != nil {
+		return ReportNarrative{}, err
+	}
+	if len(r.RedactPatterns) > 0 {
+		prompt = Redact(prompt, r.RedactPatterns)
+	}
+	maxTokens := r.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 1024
+	}
+	text, usage, err := r.completeChain(prompt, maxTokens, ReportSchema)
+	if err != nil {
+		return ReportNarrative{}, err
+	}
+	var narrative ReportNarrative
+	if err := json.Unmarshal([]byte(extractJSON(text)), &narrative); err != nil {
+		return ReportNarrative{}, fmt.Errorf("report response is not valid JSON: %w", err)
+	}
+	if strings.TrimSpace(narrative.Summary) == "" {
+		return ReportNarrative{}, errors.New("report response has no summary")
+	}
+	narrative.Usage = &usage
+	return narrative, nil
+}
+
+func reportPrompt(input ReportInput) (string, error) {
+	section := func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return SanitizePromptInput(string(data)), nil
+	}
+	alert, err := section(input.Alert)
+	if err != nil {
+		return "", err
+	}
+	diagnostics, err := section(input.Diagnostics)
+	if err != nil {
+		return "", err
+	}
+	timeline, err := section(input.Timeline)
+	if err != nil {
+		return "", err
+	}
+	actions, err := section(input.Actions)
+	if err != nil {
+		return "", err
+	}
+	verification, err := section(input.Verification)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("You are drafting a blameless post-incident report from the records of an automated operations run.\n")
+	fmt.Fprintf(&b, "Intent:\n%s\n", SanitizePromptInput(input.Intent))
+	fmt.Fprintf(&b, "Trigger:\n%s\n", SanitizePromptInput(input.Trigger))
+	fmt.Fprintf(&b, "Execution status:\n%s\n", SanitizePromptInput(input.Status))
+	fmt.Fprintf(&b, "Triggering alert:\n%s\n", alert)
+	fmt.Fprintf(&b, "Diagnostics collected before planning:\n%s\n", diagnostics)
+	fmt.Fprintf(&b, "Timeline:\n%s\n", timeline)
+	fmt.Fprintf(&b, "Actions taken:\n%s\n", actions)
+	fmt.Fprintf(&b, "Verification results:\n%s\n", verification)
+	b.WriteString("Summarize what happened, what the diagnosis was, the impact, and follow-ups worth doing. ")
+	b.WriteString("Use only facts present in the records; say so when something is unknown.\n")
+	b.WriteString("Return JSON only with shape:\n")
+	b.WriteString(reportShape)
+	return b.String(), nil
+}
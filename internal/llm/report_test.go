@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterNarrate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages       []openAIMessage      `json:"messages"`
+			ResponseFormat openAIResponseFormat `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[0].Content
+		if !strings.Contains(prompt, `"alertname":"HighLatency"`) || !strings.Contains(prompt, "succeeded") || req.ResponseFormat.JSONSchema == nil {
+			t.Errorf("prompt: %s", prompt)
+		}
+		narrative := `{"summary":"checkout latency was fixed by a rollback","diagnosis":"bad deploy","follow_ups":["add a canary"]}`
+		payload, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": narrative}}},
+			"usage":   map[string]any{"prompt_tokens": 80, "completion_tokens": 30},
+		})
+		_, _ = w.Write(payload)
+	}))
+	defer ts.Close()
+
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "gpt-4o-mini", HTTPClient: ts.Client()}
+	narrative, err := router.Narrate(ReportInput{
+		Intent: "rollback checkout",
+		Status: "succeeded",
+		Alert:  map[string]any{"labels": map[string]any{"alertname": "HighLatency"}},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if narrative.Diagnosis != "bad deploy" || len(narrative.FollowUps) != 1 {
+		t.Fatalf("narrative: %#v", narrative)
+	}
+	if narrative.Usage == nil || narrative.Usage.TotalTokens != 110 {
+		t.Fatalf("usage: %#v", narrative.Usage)
+	}
+}
+
+func TestNarrateRequiresIntent(t *testing.T) {
+	if _, err := (&Router{}).Narrate(ReportInput{}); err == nil {
+		t.Fatalf("expected intent error")
+	}
+}
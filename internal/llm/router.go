@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 )
 
@@ -12,15 +14,46 @@ type Planner interface {
 }
 
 type Router struct {
-	Provider       string
-	Model          string
-	APIBase        string
+	Provider string
+	Model    string
+	APIBase  string
+	// APIPath overrides the chat completions path for openai-compatible.
+	APIPath string
+	// APIVersion is the azure-openai api-version query parameter.
+	APIVersion string
+	// Region is the AWS region for bedrock.
+	Region         string
 	APIKey         string
 	MaxTokens      int
 	HTTPClient     *http.Client
 	AuthProfile    string
 	AuthPath       string
 	RedactPatterns []string
+	// Fallbacks are tried in order when the primary provider fails.
+	Fallbacks []Fallback
+	// MaxRetryWait is the longest Retry-After honoured by waiting and
+	// retrying the same provider; longer waits move on to the next one.
+	MaxRetryWait time.Duration
+	// Pricing maps model (or "provider/*", "*") to USD per 1k tokens.
+	Pricing map[string]Price
+	// ValidateStep checks drafted steps beyond PlanSchema, typically
+	// against the tool registry and per-tool input schemas.
+	ValidateStep StepValidator
+	// PromptDir holds <trigger>/v<N>.tmpl overrides of the built-in
+	// planning prompts.
+	PromptDir string
+	// PromptVersions pins a template version per trigger; unpinned
+	// triggers use the latest.
+	PromptVersions map[string]int
+	// EmbeddingModel enables Embed for retrieval; empty disables it.
+	EmbeddingModel string
+	// MaxRepairs bounds how often an invalid draft is sent back to the
+	// model with its validation errors. Zero disables repair.
+	MaxRepairs int
+
+	mu       sync.Mutex
+	cooldown map[string]time.Time
+	sleep    func(time.Duration)
 }
 
 func Redact(input string, patterns []string) string {
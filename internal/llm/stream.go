@@ -0,0 +1,259 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamTimeout bounds a whole streamed completion when the router has no
+// HTTP client of its own; the 5s default of buffered calls is too short.
+const streamTimeout = 2 * time.Minute
+
+// DraftDelta is a piece of a plan draft as the provider generates it.
+// Attempt increases with every completion (retry, fallback or repair); a
+// new attempt replaces the text received so far.
+type DraftDelta struct {
+	Attempt int    `json:"attempt"`
+	Text    string `json:"text"`
+}
+
+// StreamPlanner is a UsagePlanner that can report the draft while it is
+// being generated.
+type StreamPlanner interface {
+	PlanStream(intent string, context any, evidence any, onDelta func(DraftDelta)) (string, Usage, error)
+}
+
+// streamer is implemented by provider clients with native streaming.
+// Other providers deliver their completion as a single delta.
+type streamer interface {
+	streamUsage(prompt string, maxTokens int, schema json.RawMessage, onDelta func(string)) (string, Usage, error)
+}
+
+// draftStream numbers completions for DraftDelta.Attempt. A nil
+// draftStream disables streaming.
+type draftStream struct {
+	onDelta func(DraftDelta)
+	attempt int
+}
+
+func newDraftStream(onDelta func(DraftDelta)) *draftStream {
+	if onDelta == nil {
+		return nil
+	}
+	return &draftStream{onDelta: onDelta}
+}
+
+// next opens a new attempt and returns its delta callback.
+func (s *draftStream) next() func(string) {
+	if s == nil {
+		return nil
+	}
+	s.attempt++
+	attempt := s.attempt
+	return func(text string) {
+		if text != "" {
+			s.onDelta(DraftDelta{Attempt: attempt, Text: text})
+		}
+	}
+}
+
+// PlanStream drafts a plan like PlanWithUsage and calls onDelta with the
+// text as it arrives. OpenAI and Anthropic stream natively; other
+// providers send the whole completion as one delta.
+func (r *Router) PlanStream(intent string, context any, evidence any, onDelta func(DraftDelta)) (string, Usage, error) {
+	return r.plan(intent, context, evidence, newDraftStream(onDelta))
+}
+
+func (c *OpenAIClient) streamUsage(prompt string, maxTokens int, schema json.RawMessage, onDelta func(string)) (string, Usage, error) {
+	if strings.TrimSpace(c.APIKey) == "" {
+		return "", Usage{}, errors.New("openai api key required")
+	}
+	if strings.TrimSpace(c.Model) == "" {
+		return "", Usage{}, errors.New("openai model required")
+	}
+	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
+	if base == "" {
+		base = defaultOpenAIBase
+	}
+	reqBody := openAIRequest{
+		Model:          c.Model,
+		Messages:       []openAIMessage{{Role: "user", Content: prompt}},
+		MaxTokens:      maxTokens,
+		ResponseFormat: openAISchemaFormat(schema),
+		Stream:         true,
+		StreamOptions:  &openAIStreamOptions{IncludeUsage: true},
+	}
+	body, err := marshalJSON(reqBody)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := send(streamClient(c.HTTPClient), req, "openai")
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer resp.Body.Close()
+	var text strings.Builder
+	var usage Usage
+	err = readSSE(resp.Body, func(_, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("openai stream: %w", err)
+		}
+		if chunk.Usage != nil {
+			usage = tokenUsage(chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+		for _, choice := range chunk.Choices {
+			text.WriteString(choice.Delta.Content)
+			onDelta(choice.Delta.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", usage, err
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return "", usage, errors.New("openai empty response")
+	}
+	return text.String(), usage, nil
+}
+
+func (c *AnthropicClient) streamUsage(prompt string, maxTokens int, schema json.RawMessage, onDelta func(string)) (string, Usage, error) {
+	if strings.TrimSpace(c.APIKey) == "" {
+		return "", Usage{}, errors.New("anthropic api key required")
+	}
+	if strings.TrimSpace(c.Model) == "" {
+		return "", Usage{}, errors.New("anthropic model required")
+	}
+	base := strings.TrimRight(strings.TrimSpace(c.APIBase), "/")
+	if base == "" {
+		base = defaultAnthropicBase
+	}
+	reqBody := anthropicRequest{
+		Model:     c.Model,
+		MaxTokens: maxTokens,
+		Messages:  []anthropicMessage{{Role: "user", Content: prompt}},
+		Stream:    true,
+	}
+	if len(schema) > 0 {
+		reqBody.Tools = []anthropicTool{{Name: planToolName, Description: "Submit the plan.", InputSchema: schema}}
+		reqBody.ToolChoice = &anthropicChoice{Type: "tool", Name: planToolName}
+	}
+	body, err := marshalJSON(reqBody)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("x-api-key", c.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	resp, err := send(streamClient(c.HTTPClient), req, "anthropic")
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer resp.Body.Close()
+	// Tool input (structured output) wins over prose, as in completeUsage.
+	var text, toolInput strings.Builder
+	var promptTokens, completionTokens int
+	err = readSSE(resp.Body, func(_, data string) error {
+		var ev anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("anthropic stream: %w", err)
+		}
+		switch ev.Type {
+		case "message_start":
+			promptTokens = ev.Message.Usage.InputTokens
+			completionTokens = ev.Message.Usage.OutputTokens
+		case "content_block_delta":
+			switch ev.Delta.Type {
+			case "text_delta":
+				text.WriteString(ev.Delta.Text)
+				onDelta(ev.Delta.Text)
+			case "input_json_delta":
+				toolInput.WriteString(ev.Delta.PartialJSON)
+				onDelta(ev.Delta.PartialJSON)
+			}
+		case "message_delta":
+			if ev.Usage.OutputTokens > 0 {
+				completionTokens = ev.Usage.OutputTokens
+			}
+		case "error":
+			return fmt.Errorf("anthropic stream %s: %s", ev.Error.Type, ev.Error.Message)
+		}
+		return nil
+	})
+	usage := tokenUsage(promptTokens, completionTokens)
+	if err != nil {
+		return "", usage, err
+	}
+	if strings.TrimSpace(toolInput.String()) != "" {
+		return toolInput.String(), usage, nil
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return "", usage, errors.New("anthropic empty response")
+	}
+	return text.String(), usage, nil
+}
+
+func streamClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: streamTimeout}
+	}
+	return client
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
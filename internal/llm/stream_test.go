@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPlanStreamOpenAI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["stream"] != true {
+			t.Errorf("request: %#v", req)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{`{"summary":"s",`, `"risk_level":"low","steps":[]}`} {
+			chunk, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]any{"content": piece}}}})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":20,\"completion_tokens\":7}}\n\ndata: [DONE]\n\n")
+	}))
+	defer ts.Close()
+
+	var deltas []DraftDelta
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "gpt-4o-mini", HTTPClient: ts.Client()}
+	text, usage, err := router.PlanStream("check pods", nil, nil, func(d DraftDelta) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if text != `{"summary":"s","risk_level":"low","steps":[]}` || usage.TotalTokens != 27 || usage.Provider != "openai" {
+		t.Fatalf("text: %s usage: %#v", text, usage)
+	}
+	if len(deltas) != 2 || deltas[0].Attempt != 1 || deltas[1].Text != `"risk_level":"low","steps":[]}` {
+		t.Fatalf("deltas: %#v", deltas)
+	}
+}
+
+func TestPlanStreamAnthropicToolInput(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":30,\"output_tokens\":1}}}\n\n")
+		fmt.Fprint(w, "event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"summary\\\":\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"\\\"s\\\"}\"}}\n\n")
+		fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":12}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer ts.Close()
+
+	client := &AnthropicClient{APIBase: ts.URL, APIKey: "k", Model: "claude", HTTPClient: ts.Client()}
+	var got strings.Builder
+	text, usage, err := client.streamUsage("p", 100, PlanSchema, func(s string) { got.WriteString(s) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if text != `{"summary":"s"}` || got.String() != text || usage.TotalTokens != 42 {
+		t.Fatalf("text: %s got: %s usage: %#v", text, got.String(), usage)
+	}
+}
+
+func TestPlanStreamFallbackStartsNewAttempt(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	fallback := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool { return true })
+
+	var deltas []DraftDelta
+	router := &Router{
+		Provider:  "openai",
+		APIBase:   primary.URL,
+		APIKey:    "k",
+		Model:     "gpt-4o",
+		Fallbacks: []Fallback{{Provider: "openai-compatible", APIBase: fallback.URL, Model: "llama3.1"}},
+	}
+	text, _, err := router.PlanStream("intent", nil, nil, func(d DraftDelta) { deltas = append(deltas, d) })
+	if err != nil || text != "plan" {
+		t.Fatalf("text: %s err: %v", text, err)
+	}
+	// The non-streaming fallback reports its whole answer as attempt 2.
+	if len(deltas) != 1 || deltas[0].Attempt != 2 || deltas[0].Text != "plan" {
+		t.Fatalf("deltas: %#v", deltas)
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	input := ": keepalive\n\nevent: a\ndata: one\ndata: two\n\ndata: three"
+	var got []string
+	err := readSSE(strings.NewReader(input), func(event, data string) error {
+		got = append(got, event+"="+data)
+		return nil
+	})
+	if err != nil || len(got) != 2 || got[0] != "a=one\ntwo" || got[1] != "=three" {
+		t.Fatalf("got: %q err: %v", got, err)
+	}
+}
+
+func TestStreamStatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+	client := &OpenAIClient{APIBase: ts.URL, APIKey: "k", Model: "m", HTTPClient: ts.Client()}
+	_, _, err := client.streamUsage("p", 10, nil, func(string) {})
+	statusErr, ok := err.(*StatusError)
+	if !ok || statusErr.StatusCode != http.StatusTooManyRequests || statusErr.RetryAfter == 0 {
+		t.Fatalf("err: %#v", err)
+	}
+}
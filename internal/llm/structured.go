@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// PlanSchema is the JSON schema plan drafts must satisfy. It is sent to
+// providers that support structured output and used to validate answers.
+var PlanSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["summary", "risk_level", "steps"],
+  "properties": {
+    "summary": {"type": "string"},
+    "risk_level": {"type": "string", "enum": ["read", "low", "medium", "high"]},
+    "citations": {"type": "array", "items": {"type": "string"}},
+    "steps": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["action", "tool", "input"],
+        "properties": {
+          "stage": {"type": "string", "enum": ["act", "verify"]},
+          "action": {"type": "string", "minLength": 1},
+          "tool": {"type": "string", "minLength": 1},
+          "input": {"type": "object"},
+          "preconditions": {"type": "array"},
+          "rollback": {"type": "object"}
+        }
+      }
+    }
+  }
+}`)
+
+const planToolName = "submit_plan"
+
+// maxRepairEcho caps how much of a rejected answer is sent back for repair.
+const maxRepairEcho = 8 << 10
+
+// StepValidator checks one drafted step's tool, action and input.
+type StepValidator func(tool, action string, input any) error
+
+// ValidatePlan returns the problems with a plan draft: JSON syntax, the
+// PlanSchema, then validate for each step. An empty result means valid.
+func ValidatePlan(text string, validate StepValidator) []string {
+	var doc any
+	if err := json.Unmarshal([]byte(extractJSON(text)), &doc); err != nil {
+		return []string{"response is not valid JSON: " + err.Error()}
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(PlanSchema), gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, e := range result.Errors() {
+		problems = append(problems, e.String())
+	}
+	if validate == nil {
+		return problems
+	}
+	obj, _ := doc.(map[string]any)
+	steps, _ := obj["steps"].([]any)
+	for i, raw := range steps {
+		step, _ := raw.(map[string]any)
+		tool, _ := step["tool"].(string)
+		action, _ := step["action"].(string)
+		if strings.TrimSpace(tool) == "" || strings.TrimSpace(action) == "" {
+			continue
+		}
+		if err := validate(tool, action, step["input"]); err != nil {
+			problems = append(problems, fmt.Sprintf("steps.%d (%s %s): %v", i, tool, action, err))
+		}
+	}
+	return problems
+}
+
+// extractJSON strips a Markdown code fence around the answer, if any.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	idx := strings.Index(text, "```")
+	if idx == -1 {
+		return text
+	}
+	rest := text[idx+3:]
+	if nl := strings.Index(rest, "\n"); nl != -1 {
+		rest = rest[nl+1:]
+	}
+	if end := strings.Index(rest, "```"); end != -1 {
+		rest = rest[:end]
+	}
+	return strings.TrimSpace(rest)
+}
+
+func repairPrompt(prompt, answer string, problems []string) string {
+	if len(answer) > maxRepairEcho {
+		answer = answer[:maxRepairEcho]
+	}
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\nYour previous answer was rejected.\nPrevious answer:\n")
+	b.WriteString(SanitizePromptInput(answer))
+	b.WriteString("\nValidation errors:\n")
+	for _, p := range problems {
+		b.WriteString("- ")
+		b.WriteString(SanitizePromptInput(p))
+		b.WriteString("\n")
+	}
+	b.WriteString("Return the corrected plan as JSON only. Keep valid steps unchanged and drop steps you cannot fix.")
+	return b.String()
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+// openAISchemaFormat requests schema-constrained output. Strict mode is off
+// because step inputs are free-form objects.
+func openAISchemaFormat(schema json.RawMessage) *openAIResponseFormat {
+	if len(schema) == 0 {
+		return nil
+	}
+	return &openAIResponseFormat{Type: "json_schema", JSONSchema: &openAIJSONSchema{Name: "plan", Schema: schema}}
+}
+
+// openAIJSONFormat requests JSON mode, for servers without json_schema.
+func openAIJSONFormat(schema json.RawMessage) *openAIResponseFormat {
+	if len(schema) == 0 {
+		return nil
+	}
+	return &openAIResponseFormat{Type: "json_object"}
+}
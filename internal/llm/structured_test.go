@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"carapulse/internal/awsauth"
+)
+
+const validPlan = `{"summary":"s","risk_level":"low","steps":[{"action":"status","tool":"helm","input":{"release":"app"}}]}`
+
+func TestValidatePlan(t *testing.T) {
+	if problems := ValidatePlan("```json\n"+validPlan+"\n```", nil); len(problems) != 0 {
+		t.Fatalf("problems: %v", problems)
+	}
+	if problems := ValidatePlan("not json", nil); len(problems) != 1 || !strings.Contains(problems[0], "not valid JSON") {
+		t.Fatalf("problems: %v", problems)
+	}
+	problems := ValidatePlan(`{"summary":"s","risk_level":"urgent","steps":[{"tool":"helm","input":{}}]}`, nil)
+	if len(problems) != 2 {
+		t.Fatalf("problems: %v", problems)
+	}
+	reject := func(tool, action string, input any) error {
+		if tool == "helm" {
+			return errors.New("release required")
+		}
+		return nil
+	}
+	problems = ValidatePlan(validPlan, reject)
+	if len(problems) != 1 || problems[0] != "steps.0 (helm status): release required" {
+		t.Fatalf("problems: %v", problems)
+	}
+}
+
+func TestPlanWithUsageRepairsInvalidDraft(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages       []openAIMessage      `json:"messages"`
+			ResponseFormat openAIResponseFormat `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.ResponseFormat.Type != "json_schema" || req.ResponseFormat.JSONSchema == nil {
+			t.Errorf("response_format: %#v", req.ResponseFormat)
+		}
+		content := `{"summary":"s","risk_level":"low","steps":[{"action":"deploy","tool":"helm","input":{}}]}`
+		if atomic.AddInt32(&calls, 1) == 2 {
+			if !strings.Contains(req.Messages[0].Content, "steps.0 (helm deploy): unsupported action") {
+				t.Errorf("repair prompt: %s", req.Messages[0].Content)
+			}
+			content = validPlan
+		}
+		payload, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": content}}},
+			"usage":   map[string]any{"prompt_tokens": 100, "completion_tokens": 10},
+		})
+		_, _ = w.Write(payload)
+	}))
+	defer ts.Close()
+
+	router := &Router{
+		Provider:   "openai",
+		APIBase:    ts.URL,
+		APIKey:     "k",
+		Model:      "gpt-4o",
+		HTTPClient: ts.Client(),
+		MaxRepairs: 2,
+		ValidateStep: func(tool, action string, input any) error {
+			if action != "status" {
+				return errors.New("unsupported action")
+			}
+			return nil
+		},
+	}
+	text, usage, err := router.PlanWithUsage("intent", nil, nil)
+	if err != nil || text != validPlan {
+		t.Fatalf("text: %s err: %v", text, err)
+	}
+	if calls != 2 || usage.Repairs != 1 || usage.TotalTokens != 220 || usage.Provider != "openai" {
+		t.Fatalf("calls: %d usage: %#v", calls, usage)
+	}
+}
+
+func TestPlanWithUsageRepairIsBounded(t *testing.T) {
+	var calls int32
+	ts := openAIServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		atomic.AddInt32(&calls, 1)
+		return true
+	})
+	router := &Router{Provider: "openai", APIBase: ts.URL, APIKey: "k", Model: "gpt-4o", MaxRepairs: 2}
+	text, usage, err := router.PlanWithUsage("intent", nil, nil)
+	if err != nil || text != "plan" {
+		t.Fatalf("text: %s err: %v", text, err)
+	}
+	if calls != 3 || usage.Repairs != 2 {
+		t.Fatalf("calls: %d repairs: %d", calls, usage.Repairs)
+	}
+}
+
+func TestAnthropicStructuredOutput(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Tools) != 1 || req.ToolChoice == nil || req.ToolChoice.Name != planToolName {
+			t.Errorf("tools: %#v choice: %#v", req.Tools, req.ToolChoice)
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"thinking"},{"type":"tool_use","name":"submit_plan","input":` + validPlan + `}]}`))
+	}))
+	defer ts.Close()
+
+	client := &AnthropicClient{APIBase: ts.URL, APIKey: "k", Model: "claude", HTTPClient: ts.Client()}
+	out, _, err := client.completeUsage("p", 10, PlanSchema)
+	if err != nil || out != validPlan {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+}
+
+func TestBedrockStructuredOutput(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req bedrockRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.ToolConfig == nil || req.ToolConfig.ToolChoice.Tool.Name != planToolName || len(req.ToolConfig.Tools[0].ToolSpec.InputSchema.JSON) == 0 {
+			t.Errorf("tool config: %#v", req.ToolConfig)
+		}
+		_, _ = w.Write([]byte(`{"output":{"message":{"role":"assistant","content":[{"toolUse":{"toolUseId":"t1","name":"submit_plan","input":` + validPlan + `}}]}}}`))
+	}))
+	defer ts.Close()
+
+	client := &BedrockClient{
+		APIBase:     ts.URL,
+		Region:      "us-east-1",
+		Model:       "m",
+		Credentials: awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		HTTPClient:  ts.Client(),
+		Now:         func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+	out, _, err := client.completeUsage("p", 10, PlanSchema)
+	if err != nil || out != validPlan {
+		t.Fatalf("out: %s err: %v", out, err)
+	}
+}
+
+func TestJSONModeRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(r.URL.Path, "generateContent") {
+			cfg, _ := req["generationConfig"].(map[string]any)
+			if cfg["responseMimeType"] != "application/json" {
+				t.Errorf("gemini config: %#v", cfg)
+			}
+			_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"{}"}]}}]}`))
+			return
+		}
+		format, _ := req["response_format"].(map[string]any)
+		if format["type"] != "json_object" {
+			t.Errorf("response_format: %#v", req["response_format"])
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"}}]}`))
+	}))
+	defer ts.Close()
+
+	clients := []completer{
+		&OpenAICompatibleClient{APIBase: ts.URL, Model: "m", HTTPClient: ts.Client()},
+		&AzureOpenAIClient{Endpoint: ts.URL, Deployment: "d", APIKey: "k", HTTPClient: ts.Client()},
+		&GeminiClient{APIBase: ts.URL, APIKey: "k", Model: "g", HTTPClient: ts.Client()},
+	}
+	for _, c := range clients {
+		if _, _, err := c.completeUsage("p", 10, PlanSchema); err != nil {
+			t.Fatalf("%T: %v", c, err)
+		}
+	}
+}
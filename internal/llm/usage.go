@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Usage is the token accounting for one plan draft.
+type Usage struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	LatencyMS        int64   `json:"latency_ms"`
+	CostUSD          float64 `json:"cost_usd"`
+	// FallbackIndex is 0 when the primary provider answered, N for the Nth
+	// fallback.
+	FallbackIndex int       `json:"fallback_index"`
+	Attempts      []Attempt `json:"attempts,omitempty"`
+	// PromptTemplate identifies the prompt version, e.g. "alert/v1".
+	PromptTemplate string `json:"prompt_template,omitempty"`
+	// Repairs counts follow-up calls made to fix an invalid draft.
+	Repairs int `json:"repairs,omitempty"`
+}
+
+// add folds a repair call's usage into u. The answering provider is
+// updated only when the repair call got an answer.
+func (u Usage) add(next Usage) Usage {
+	u.PromptTokens += next.PromptTokens
+	u.CompletionTokens += next.CompletionTokens
+	u.TotalTokens += next.TotalTokens
+	u.LatencyMS += next.LatencyMS
+	u.CostUSD += next.CostUSD
+	u.Attempts = append(u.Attempts, next.Attempts...)
+	u.Repairs++
+	if next.Provider != "" {
+		u.Provider, u.Model, u.FallbackIndex = next.Provider, next.Model, next.FallbackIndex
+	}
+	return u
+}
+
+// Attempt records a failed provider call that preceded the answer.
+type Attempt struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Error     string `json:"error"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// Price is USD per 1000 tokens.
+type Price struct {
+	PromptPer1K     float64 `json:"prompt_per_1k_usd"`
+	CompletionPer1K float64 `json:"completion_per_1k_usd"`
+}
+
+// UsagePlanner is a Planner that also reports token usage.
+type UsagePlanner interface {
+	PlanWithUsage(intent string, context any, evidence any) (string, Usage, error)
+}
+
+// completer is implemented by every provider client. A non-nil schema
+// asks for provider-native structured output where the provider has it.
+type completer interface {
+	completeUsage(prompt string, maxTokens int, schema json.RawMessage) (string, Usage, error)
+}
+
+func tokenUsage(prompt, completion int) Usage {
+	return Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+// estimateCost prices usage by model, falling back to "provider/*" and "*".
+func estimateCost(pricing map[string]Price, provider, model string, u Usage) float64 {
+	if len(pricing) == 0 {
+		return 0
+	}
+	price, ok := pricing[model]
+	if !ok {
+		price, ok = pricing[strings.ToLower(provider)+"/*"]
+	}
+	if !ok {
+		price, ok = pricing["*"]
+	}
+	if !ok {
+		return 0
+	}
+	return float64(u.PromptTokens)/1000*price.PromptPer1K + float64(u.CompletionTokens)/1000*price.CompletionPer1K
+}
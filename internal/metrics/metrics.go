@@ -37,6 +37,91 @@ var (
 		Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60},
 	}, []string{"tool", "action"})
 
+	ToolCacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "tool_cache_requests_total",
+		Help:      "Tool result cache lookups by tool and result (hit, miss, shared).",
+	}, []string{"tool", "result"})
+
+	ToolCacheHitRatio = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "carapulse",
+		Name:      "tool_cache_hit_ratio",
+		Help:      "Share of tool result cache lookups served without calling the backend.",
+	}, []string{"tool"})
+
+	ToolCacheInvalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "tool_cache_invalidations_total",
+		Help:      "Cached tool results dropped by write actions, by writing tool.",
+	}, []string{"tool"})
+
+	ToolBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "carapulse",
+		Name:      "tool_breaker_state",
+		Help:      "Circuit breaker state per tool backend (0 closed, 1 half-open, 2 open).",
+	}, []string{"backend"})
+
+	ToolBreakerRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "tool_breaker_rejections_total",
+		Help:      "Tool calls rejected before reaching the backend, by backend and reason.",
+	}, []string{"backend", "reason"})
+
+	ToolInflight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "carapulse",
+		Name:      "tool_inflight",
+		Help:      "Tool calls currently holding a concurrency slot, by tool.",
+	}, []string{"tool"})
+
+	ToolCredentialsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "tool_credentials_total",
+		Help:      "Per-call credentials by provider and result (issued, failed, revoked, revoke_failed, expiring).",
+	}, []string{"provider", "result"})
+
+	LLMRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "llm_requests_total",
+		Help:      "LLM provider calls by provider, model and outcome (ok, error, rate_limited).",
+	}, []string{"provider", "model", "outcome"})
+
+	LLMRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "carapulse",
+		Name:      "llm_request_duration_seconds",
+		Help:      "LLM provider call latency in seconds.",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"provider", "model"})
+
+	LLMFallbacksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "llm_fallbacks_total",
+		Help:      "Plan drafts answered by a fallback provider, by the provider and model that answered.",
+	}, []string{"provider", "model"})
+
+	LLMTokensTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "llm_tokens_total",
+		Help:      "LLM tokens consumed by tenant, provider, model and kind (prompt, completion).",
+	}, []string{"tenant", "provider", "model", "kind"})
+
+	LLMCostUSDTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "llm_cost_usd_total",
+		Help:      "Estimated LLM spend in USD by tenant, provider and model.",
+	}, []string{"tenant", "provider", "model"})
+
+	LLMBudgetRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "llm_budget_rejections_total",
+		Help:      "Plan drafts refused because the tenant's daily LLM budget was spent.",
+	}, []string{"tenant"})
+
+	LLMCriticVerdictsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "llm_critic_verdicts_total",
+		Help:      "Plan critic reviews, by recommendation (go, no_go or error).",
+	}, []string{"recommendation"})
+
 	WorkflowExecutionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "carapulse",
 		Name:      "workflow_executions_total",
@@ -49,6 +134,25 @@ var (
 		Help:      "Total OPA policy decisions by decision type.",
 	}, []string{"decision"})
 
+	PolicyEvaluationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "carapulse",
+		Name:      "policy_evaluation_duration_seconds",
+		Help:      "Policy evaluation latency in seconds, by mode (remote or embedded).",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	}, []string{"mode"})
+
+	PolicyCacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "policy_cache_requests_total",
+		Help:      "Embedded policy decision cache lookups by result (hit, miss).",
+	}, []string{"result"})
+
+	PolicyBundleReloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "carapulse",
+		Name:      "policy_bundle_reloads_total",
+		Help:      "Policy bundle reloads by result (activated, rejected, fetch_error).",
+	}, []string{"result"})
+
 	ApprovalsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "carapulse",
 		Name:      "approvals_total",
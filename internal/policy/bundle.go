@@ -0,0 +1,235 @@
+package policy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// BundleManifestFile holds {"revision": "..."}; it is covered by the
+	// signature.
+	BundleManifestFile = ".manifest"
+	// BundleSignatureFile holds the base64 ed25519 signature of the
+	// bundle's digest.
+	BundleSignatureFile = ".signature"
+
+	maxBundleBytes = 32 << 20
+)
+
+// ErrBundleUnsigned is returned when a bundle has no signature file.
+var ErrBundleUnsigned = errors.New("policy: bundle is not signed")
+
+// Bundle is a policy bundle's files keyed by slash-separated path relative
+// to the bundle root.
+type Bundle struct {
+	Files map[string][]byte
+	// Revision names the bundle: the manifest's revision, else whatever the
+	// source knows (e.g. the git commit), else a digest prefix.
+	Revision string
+}
+
+type bundleManifest struct {
+	Revision string `json:"revision"`
+}
+
+// ReadBundleFS reads a bundle from fsys. Hidden files and directories
+// (.git and the like) are skipped, except the manifest and signature at
+// the root.
+func ReadBundleFS(fsys fs.FS) (Bundle, error) {
+	b := Bundle{Files: map[string][]byte{}}
+	total := 0
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "." {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != BundleManifestFile && p != BundleSignatureFile {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		total += len(data)
+		if total > maxBundleBytes {
+			return fmt.Errorf("bundle exceeds %d bytes", maxBundleBytes)
+		}
+		b.Files[p] = data
+		return nil
+	})
+	if err != nil {
+		return Bundle{}, fmt.Errorf("policy: read bundle: %w", err)
+	}
+	return b, b.setRevision()
+}
+
+// ReadBundleArchive reads a bundle from a .tar.gz archive.
+func ReadBundleArchive(data []byte) (Bundle, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return Bundle{}, fmt.Errorf("policy: read bundle archive: %w", err)
+	}
+	defer gz.Close()
+	b := Bundle{Files: map[string][]byte{}}
+	tr := tar.NewReader(io.LimitReader(gz, maxBundleBytes))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Bundle{}, fmt.Errorf("policy: read bundle archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return Bundle{}, fmt.Errorf("policy: bundle archive path %q escapes the bundle", hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return Bundle{}, fmt.Errorf("policy: read bundle archive: %w", err)
+		}
+		b.Files[name] = data
+	}
+	return b, b.setRevision()
+}
+
+func (b *Bundle) setRevision() error {
+	raw, ok := b.Files[BundleManifestFile]
+	if !ok {
+		return nil
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return fmt.Errorf("policy: bundle manifest: %w", err)
+	}
+	b.Revision = strings.TrimSpace(manifest.Revision)
+	return nil
+}
+
+// SignedContent is the message a bundle signature covers: one
+// "<sha256>  <path>" line per file, sorted by path, signature excluded.
+func (b Bundle) SignedContent() []byte {
+	names := make([]string, 0, len(b.Files))
+	for name := range b.Files {
+		if name != BundleSignatureFile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		sum := sha256.Sum256(b.Files[name])
+		fmt.Fprintf(&buf, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+	return buf.Bytes()
+}
+
+// Digest identifies the bundle's signed content.
+func (b Bundle) Digest() string {
+	sum := sha256.Sum256(b.SignedContent())
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign returns the signature file content for b.
+func (b Bundle) Sign(key ed25519.PrivateKey) []byte {
+	sig := ed25519.Sign(key, b.SignedContent())
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
+
+// Verify checks the bundle's signature against key.
+func (b Bundle) Verify(key ed25519.PublicKey) error {
+	raw, ok := b.Files[BundleSignatureFile]
+	if !ok {
+		return ErrBundleUnsigned
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return fmt.Errorf("policy: bundle signature: %w", err)
+	}
+	if !ed25519.Verify(key, b.SignedContent(), sig) {
+		return errors.New("policy: bundle signature does not match")
+	}
+	return nil
+}
+
+// modules returns the bundle's non-test Rego files.
+func (b Bundle) modules() []regoModule {
+	var modules []regoModule
+	for name, data := range b.Files {
+		if path.Ext(name) == ".rego" && !strings.HasSuffix(name, "_test.rego") {
+			modules = append(modules, regoModule{name: name, src: string(data)})
+		}
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].name < modules[j].name })
+	return modules
+}
+
+// revision is b.Revision, or a digest prefix when nothing named the bundle.
+func (b Bundle) revision() string {
+	if b.Revision != "" {
+		return b.Revision
+	}
+	return "sha256:" + b.Digest()[:12]
+}
+
+// ParsePublicKey reads a PEM (PKIX) ed25519 public key, as written by
+// `openssl pkey -pubout`.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("policy: public key is not PEM")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("policy: public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("policy: public key is not ed25519")
+	}
+	return pub, nil
+}
+
+// ParsePrivateKey reads a PEM (PKCS#8) ed25519 private key, as written by
+// `openssl genpkey -algorithm ed25519`.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("policy: private key is not PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("policy: private key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("policy: private key is not ed25519")
+	}
+	return priv, nil
+}
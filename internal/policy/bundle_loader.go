@@ -0,0 +1,274 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"carapulse/internal/config"
+	"carapulse/internal/metrics"
+)
+
+const (
+	BundleSourceDir    = "dir"
+	BundleSourceGit    = "git"
+	BundleSourceObject = "object"
+
+	defaultBundlePollInterval = 30 * time.Second
+)
+
+// BundleSource fetches the latest policy bundle.
+type BundleSource interface {
+	Fetch(ctx context.Context) (Bundle, error)
+}
+
+// ObjectGetter reads an object store ref; storage.ObjectStore satisfies it.
+type ObjectGetter interface {
+	Get(ctx context.Context, ref string) ([]byte, error)
+}
+
+// DirSource reads a bundle from a local directory, e.g. a mounted volume.
+type DirSource struct {
+	Path string
+}
+
+func (s DirSource) Fetch(ctx context.Context) (Bundle, error) {
+	return ReadBundleFS(os.DirFS(s.Path))
+}
+
+// ObjectSource reads a .tar.gz bundle from the object store.
+type ObjectSource struct {
+	Store ObjectGetter
+	Ref   string
+}
+
+func (s ObjectSource) Fetch(ctx context.Context) (Bundle, error) {
+	if s.Store == nil {
+		return Bundle{}, errors.New("policy: object store unavailable")
+	}
+	data, err := s.Store.Get(ctx, s.Ref)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("policy: fetch bundle %s: %w", s.Ref, err)
+	}
+	return ReadBundleArchive(data)
+}
+
+// GitSource keeps a shallow checkout of Ref from URL in CacheDir and reads
+// the bundle from Path inside it. Bundles without a manifest revision are
+// named after the commit.
+type GitSource struct {
+	URL      string
+	Ref      string
+	Path     string
+	CacheDir string
+}
+
+var runGit = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (s GitSource) Fetch(ctx context.Context) (Bundle, error) {
+	ref := s.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := os.Stat(filepath.Join(s.CacheDir, ".git")); err != nil {
+		if err := os.MkdirAll(s.CacheDir, 0o700); err != nil {
+			return Bundle{}, err
+		}
+		if _, err := runGit(ctx, s.CacheDir, "init", "--quiet"); err != nil {
+			return Bundle{}, err
+		}
+		if _, err := runGit(ctx, s.CacheDir, "remote", "add", "origin", s.URL); err != nil {
+			return Bundle{}, err
+		}
+	}
+	if _, err := runGit(ctx, s.CacheDir, "fetch", "--quiet", "--depth", "1", "origin", ref); err != nil {
+		return Bundle{}, err
+	}
+	if _, err := runGit(ctx, s.CacheDir, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return Bundle{}, err
+	}
+	commit, err := runGit(ctx, s.CacheDir, "rev-parse", "HEAD")
+	if err != nil {
+		return Bundle{}, err
+	}
+	bundle, err := ReadBundleFS(os.DirFS(filepath.Join(s.CacheDir, filepath.FromSlash(s.Path))))
+	if err != nil {
+		return Bundle{}, err
+	}
+	if bundle.Revision == "" {
+		bundle.Revision = "git:" + strings.TrimSpace(string(commit))
+	}
+	return bundle, nil
+}
+
+// NewBundleSource builds the source cfg selects.
+func NewBundleSource(cfg config.PolicyBundleConfig, store ObjectGetter) (BundleSource, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Source)) {
+	case BundleSourceDir:
+		return DirSource{Path: cfg.Path}, nil
+	case BundleSourceGit:
+		cacheDir := cfg.CacheDir
+		if cacheDir == "" {
+			cacheDir = filepath.Join(os.TempDir(), "carapulse-policy-bundle")
+		}
+		return GitSource{URL: cfg.URL, Ref: cfg.Ref, Path: cfg.Path, CacheDir: cacheDir}, nil
+	case BundleSourceObject:
+		return ObjectSource{Store: store, Ref: cfg.ObjectRef}, nil
+	default:
+		return nil, fmt.Errorf("policy: unknown bundle source %q", cfg.Source)
+	}
+}
+
+// BundleChecker evaluates the active signed bundle and polls its source
+// for new ones. A bundle is activated only once its signature verifies and
+// it compiles; otherwise the last good bundle keeps serving.
+type BundleChecker struct {
+	Source        BundleSource
+	PublicKey     ed25519.PublicKey
+	PolicyPackage string
+	MaxEntries    int
+	Interval      time.Duration
+
+	mu       sync.RWMutex
+	active   *RegoChecker
+	digest   string
+	rejected string
+}
+
+// NewBundleChecker loads the first bundle from the source cfg.Bundle
+// selects. It fails when that bundle cannot be activated, since there is
+// no last good bundle to fall back to yet.
+func NewBundleChecker(ctx context.Context, cfg config.PolicyConfig, store ObjectGetter) (*BundleChecker, error) {
+	keyPEM, err := os.ReadFile(cfg.Bundle.PublicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("policy: bundle public key: %w", err)
+	}
+	key, err := ParsePublicKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	source, err := NewBundleSource(cfg.Bundle, store)
+	if err != nil {
+		return nil, err
+	}
+	checker := &BundleChecker{
+		Source:        source,
+		PublicKey:     key,
+		PolicyPackage: cfg.PolicyPackage,
+		MaxEntries:    cfg.CacheMaxEntries,
+		Interval:      time.Duration(cfg.Bundle.PollSecs) * time.Second,
+	}
+	if _, err := checker.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return checker, nil
+}
+
+// Reload fetches the source and activates its bundle if it changed. It
+// reports whether a new bundle was activated; on error the active bundle
+// is left in place.
+func (c *BundleChecker) Reload(ctx context.Context) (bool, error) {
+	bundle, err := c.Source.Fetch(ctx)
+	if err != nil {
+		metrics.PolicyBundleReloadsTotal.WithLabelValues("fetch_error").Inc()
+		return false, err
+	}
+	digest := bundle.Digest()
+	c.mu.RLock()
+	unchanged := digest == c.digest || digest == c.rejected
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	checker, err := c.activate(bundle)
+	if err != nil {
+		metrics.PolicyBundleReloadsTotal.WithLabelValues("rejected").Inc()
+		c.mu.Lock()
+		c.rejected = digest
+		c.mu.Unlock()
+		return false, fmt.Errorf("policy: bundle %s rejected: %w", bundle.revision(), err)
+	}
+	c.mu.Lock()
+	c.active, c.digest, c.rejected = checker, digest, ""
+	c.mu.Unlock()
+	metrics.PolicyBundleReloadsTotal.WithLabelValues("activated").Inc()
+	slog.Info("policy bundle activated", "revision", checker.Revision)
+	return true, nil
+}
+
+func (c *BundleChecker) activate(bundle Bundle) (*RegoChecker, error) {
+	if err := bundle.Verify(c.PublicKey); err != nil {
+		return nil, err
+	}
+	return newBundleRegoChecker(bundle, c.PolicyPackage, c.MaxEntries)
+}
+
+// Run polls the source until ctx is done. Failed reloads are logged and
+// keep the active bundle.
+func (c *BundleChecker) Run(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultBundlePollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := c.Reload(ctx); err != nil {
+				slog.Warn("policy bundle reload failed", "error", err, "revision", c.Revision())
+			}
+		}
+	}
+}
+
+// Revision is the active bundle's revision.
+func (c *BundleChecker) Revision() string {
+	if checker := c.current(); checker != nil {
+		return checker.Revision
+	}
+	return ""
+}
+
+func (c *BundleChecker) current() *RegoChecker {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.active
+}
+
+func (c *BundleChecker) Evaluate(input PolicyInput) (PolicyDecision, error) {
+	checker := c.current()
+	if checker == nil {
+		return PolicyDecision{}, errors.New("policy: no active bundle")
+	}
+	return checker.Evaluate(input)
+}
+
+func (c *BundleChecker) Explain(input PolicyInput, mode string) (PolicyDecision, []string, error) {
+	checker := c.current()
+	if checker == nil {
+		return PolicyDecision{}, nil, errors.New("policy: no active bundle")
+	}
+	return checker.Explain(input, mode)
+}
@@ -0,0 +1,310 @@
+package policy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"carapulse/internal/config"
+)
+
+const allowBundleRego = "package p\n\ndecision := \"allow\"\n"
+
+func testBundleKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	return pub, priv
+}
+
+// signedBundle returns files plus a manifest for revision and a signature.
+func signedBundle(t *testing.T, priv ed25519.PrivateKey, revision string, files map[string]string) Bundle {
+	t.Helper()
+	b := Bundle{Files: map[string][]byte{}, Revision: revision}
+	for name, src := range files {
+		b.Files[name] = []byte(src)
+	}
+	if revision != "" {
+		b.Files[BundleManifestFile] = []byte(`{"revision":"` + revision + `"}`)
+	}
+	b.Files[BundleSignatureFile] = b.Sign(priv)
+	return b
+}
+
+type fakeBundleSource struct {
+	bundle Bundle
+	err    error
+}
+
+func (f *fakeBundleSource) Fetch(ctx context.Context) (Bundle, error) {
+	return f.bundle, f.err
+}
+
+func TestBundleSignAndVerify(t *testing.T) {
+	pub, priv := testBundleKey(t)
+	b := signedBundle(t, priv, "r1", map[string]string{"p/policy.rego": allowBundleRego})
+	if err := b.Verify(pub); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	otherPub, _ := testBundleKey(t)
+	if err := b.Verify(otherPub); err == nil {
+		t.Fatalf("expected wrong key error")
+	}
+	b.Files["p/policy.rego"] = []byte("package p\n\ndecision := \"deny\"\n")
+	if err := b.Verify(pub); err == nil {
+		t.Fatalf("expected tamper error")
+	}
+	delete(b.Files, BundleSignatureFile)
+	if err := b.Verify(pub); !errors.Is(err, ErrBundleUnsigned) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestReadBundleFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".manifest":          {Data: []byte(`{"revision":"v7"}`)},
+		".signature":         {Data: []byte("sig")},
+		"p/policy.rego":      {Data: []byte(allowBundleRego)},
+		"p/policy_test.rego": {Data: []byte("package p\n")},
+		".git/config":        {Data: []byte("[core]")},
+		"p/.hidden.rego":     {Data: []byte("package hidden")},
+	}
+	b, err := ReadBundleFS(fsys)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if b.Revision != "v7" || len(b.Files) != 4 {
+		t.Fatalf("bundle: %q %v", b.Revision, len(b.Files))
+	}
+	if modules := b.modules(); len(modules) != 1 || modules[0].name != "p/policy.rego" {
+		t.Fatalf("modules: %#v", modules)
+	}
+	if _, err := ReadBundleFS(fstest.MapFS{".manifest": {Data: []byte("{")}}); err == nil {
+		t.Fatalf("expected manifest error")
+	}
+	unnamed, _ := ReadBundleFS(fstest.MapFS{"p.rego": {Data: []byte(allowBundleRego)}})
+	if rev := unnamed.revision(); !strings.HasPrefix(rev, "sha256:") || len(rev) != len("sha256:")+12 {
+		t.Fatalf("revision: %q", rev)
+	}
+}
+
+func TestReadBundleArchive(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, src := range map[string]string{"/p/policy.rego": allowBundleRego, ".manifest": `{"revision":"v2"}`} {
+		_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(src)), Typeflag: tar.TypeReg})
+		_, _ = tw.Write([]byte(src))
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	b, err := ReadBundleArchive(buf.Bytes())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if b.Revision != "v2" || string(b.Files["p/policy.rego"]) != allowBundleRego {
+		t.Fatalf("bundle: %#v", b)
+	}
+	if _, err := ReadBundleArchive([]byte("not gzip")); err == nil {
+		t.Fatalf("expected gzip error")
+	}
+
+	buf.Reset()
+	gz = gzip.NewWriter(&buf)
+	tw = tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "../escape.rego", Mode: 0o644, Size: 1, Typeflag: tar.TypeReg})
+	_, _ = tw.Write([]byte("x"))
+	_ = tw.Close()
+	_ = gz.Close()
+	if _, err := ReadBundleArchive(buf.Bytes()); err == nil {
+		t.Fatalf("expected escape error")
+	}
+}
+
+func TestParseBundleKeys(t *testing.T) {
+	pub, priv := testBundleKey(t)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	gotPub, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if err != nil || !gotPub.Equal(pub) {
+		t.Fatalf("public: %v", err)
+	}
+	gotPriv, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if err != nil || !gotPriv.Equal(priv) {
+		t.Fatalf("private: %v", err)
+	}
+	if _, err := ParsePublicKey([]byte("nope")); err == nil {
+		t.Fatalf("expected pem error")
+	}
+	if _, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})); err == nil {
+		t.Fatalf("expected pkcs8 error")
+	}
+}
+
+func TestBundleCheckerKeepsLastGoodBundle(t *testing.T) {
+	pub, priv := testBundleKey(t)
+	source := &fakeBundleSource{bundle: signedBundle(t, priv, "r1", map[string]string{"p.rego": allowBundleRego})}
+	checker := &BundleChecker{Source: source, PublicKey: pub, PolicyPackage: "p"}
+	if _, err := checker.Evaluate(PolicyInput{}); err == nil {
+		t.Fatalf("expected no active bundle error")
+	}
+	if changed, err := checker.Reload(context.Background()); err != nil || !changed {
+		t.Fatalf("reload: %v %v", changed, err)
+	}
+	dec, err := checker.Evaluate(PolicyInput{})
+	if err != nil || dec.Decision != "allow" || dec.Revision != "r1" {
+		t.Fatalf("decision: %#v err: %v", dec, err)
+	}
+	if changed, err := checker.Reload(context.Background()); err != nil || changed {
+		t.Fatalf("unchanged reload: %v %v", changed, err)
+	}
+
+	// A bundle that does not compile is rejected once and r1 keeps serving.
+	source.bundle = signedBundle(t, priv, "r2", map[string]string{"p.rego": "package p\n\ndecision := \n"})
+	if _, err := checker.Reload(context.Background()); err == nil || !strings.Contains(err.Error(), "r2") {
+		t.Fatalf("expected compile rejection: %v", err)
+	}
+	if changed, err := checker.Reload(context.Background()); err != nil || changed {
+		t.Fatalf("rejected bundle retried: %v %v", changed, err)
+	}
+	// So is a bundle signed by another key.
+	_, otherPriv := testBundleKey(t)
+	source.bundle = signedBundle(t, otherPriv, "r3", map[string]string{"p.rego": allowBundleRego})
+	if _, err := checker.Reload(context.Background()); err == nil {
+		t.Fatalf("expected signature rejection")
+	}
+	source.err = errors.New("unreachable")
+	if _, err := checker.Reload(context.Background()); err == nil {
+		t.Fatalf("expected fetch error")
+	}
+	if checker.Revision() != "r1" {
+		t.Fatalf("revision: %q", checker.Revision())
+	}
+
+	source.err = nil
+	source.bundle = signedBundle(t, priv, "r4", map[string]string{"p.rego": "package p\n\ndecision := \"deny\"\n"})
+	if changed, err := checker.Reload(context.Background()); err != nil || !changed {
+		t.Fatalf("reload: %v %v", changed, err)
+	}
+	dec, _, err = checker.Explain(PolicyInput{}, "notes")
+	if err != nil || dec.Decision != "deny" || dec.Revision != "r4" {
+		t.Fatalf("decision: %#v err: %v", dec, err)
+	}
+}
+
+func TestNewBundleCheckerFromDir(t *testing.T) {
+	pub, priv := testBundleKey(t)
+	dir := t.TempDir()
+	b := signedBundle(t, priv, "dir-1", map[string]string{"p/policy.rego": allowBundleRego})
+	for name, data := range b.Files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644)
+
+	cfg := config.PolicyConfig{PolicyPackage: "p", Bundle: config.PolicyBundleConfig{Source: "dir", Path: dir, PublicKeyFile: keyFile}}
+	checker, err := NewBundleChecker(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if checker.Revision() != "dir-1" {
+		t.Fatalf("revision: %q", checker.Revision())
+	}
+
+	_ = os.Remove(filepath.Join(dir, BundleSignatureFile))
+	if _, err := NewBundleChecker(context.Background(), cfg, nil); !errors.Is(err, ErrBundleUnsigned) {
+		t.Fatalf("expected unsigned error: %v", err)
+	}
+	cfg.Bundle.PublicKeyFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := NewBundleChecker(context.Background(), cfg, nil); err == nil {
+		t.Fatalf("expected key error")
+	}
+}
+
+type fakeObjectGetter struct {
+	data []byte
+	ref  string
+}
+
+func (f *fakeObjectGetter) Get(ctx context.Context, ref string) ([]byte, error) {
+	f.ref = ref
+	if f.data == nil {
+		return nil, errors.New("not found")
+	}
+	return f.data, nil
+}
+
+func TestObjectSource(t *testing.T) {
+	store := &fakeObjectGetter{}
+	source, err := NewBundleSource(config.PolicyBundleConfig{Source: "object", ObjectRef: "s3://b/policy.tar.gz"}, store)
+	if err != nil {
+		t.Fatalf("source: %v", err)
+	}
+	if _, err := source.Fetch(context.Background()); err == nil || store.ref != "s3://b/policy.tar.gz" {
+		t.Fatalf("expected fetch error for %q: %v", store.ref, err)
+	}
+	if _, err := (ObjectSource{}).Fetch(context.Background()); err == nil {
+		t.Fatalf("expected missing store error")
+	}
+	if _, err := NewBundleSource(config.PolicyBundleConfig{Source: "http"}, nil); err == nil {
+		t.Fatalf("expected unknown source error")
+	}
+}
+
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "--quiet", "--initial-branch", "main")
+	_ = os.MkdirAll(filepath.Join(repo, "bundle"), 0o755)
+	_ = os.WriteFile(filepath.Join(repo, "bundle", "p.rego"), []byte(allowBundleRego), 0o644)
+	git("add", ".")
+	git("commit", "--quiet", "-m", "policies")
+
+	source := GitSource{URL: repo, Ref: "main", Path: "bundle", CacheDir: filepath.Join(t.TempDir(), "cache")}
+	b, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if !strings.HasPrefix(b.Revision, "git:") || string(b.Files["p.rego"]) != allowBundleRego {
+		t.Fatalf("bundle: %q %v", b.Revision, b.Files)
+	}
+
+	_ = os.WriteFile(filepath.Join(repo, "bundle", "p.rego"), []byte("package p\n\ndecision := \"deny\"\n"), 0o644)
+	git("commit", "--quiet", "-am", "deny")
+	next, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("refetch: %v", err)
+	}
+	if next.Revision == b.Revision || !strings.Contains(string(next.Files["p.rego"]), "deny") {
+		t.Fatalf("bundle not updated: %q", next.Revision)
+	}
+}
@@ -1,13 +1,27 @@
 package policy
 
+import (
+	ctxmodel "carapulse/internal/context"
+	"carapulse/internal/risk"
+)
+
 type Action struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
 }
 
 type Risk struct {
-	Level       string `json:"level"`
-	Targets     int    `json:"targets,omitempty"`
-	BlastRadius string `json:"blast_radius,omitempty"`
-	Tier        string `json:"tier,omitempty"`
+	Level string `json:"level"`
+	// Score and Factors are the risk engine's 0-100 score and its
+	// breakdown; Level and Tier are derived from them.
+	Score       int           `json:"score,omitempty"`
+	Factors     []risk.Factor `json:"factors,omitempty"`
+	Targets     int           `json:"targets,omitempty"`
+	BlastRadius string        `json:"blast_radius,omitempty"`
+	// Impact is the context graph walk behind BlastRadius: the targets'
+	// downstream dependents, their owner teams and traffic share.
+	Impact *ctxmodel.Impact `json:"impact,omitempty"`
+	Tier   string           `json:"tier,omitempty"`
+	// Critic summarises the plan critic's verdict, when there is one.
+	Critic any `json:"critic,omitempty"`
 }
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
+
+	"carapulse/internal/metrics"
 )
 
 type PolicyService struct {
@@ -30,10 +33,40 @@ type PolicyDecision struct {
 	Decision    string         `json:"decision"`
 	Constraints map[string]any `json:"constraints"`
 	TTL         int            `json:"ttl"`
+	Reasons     []Reason       `json:"reasons,omitempty"`
+	// Revision is the policy bundle revision that made the decision, when
+	// the checker knows it (embedded and bundle modes).
+	Revision string `json:"revision,omitempty"`
+}
+
+// Reason explains a deny or require_approval decision: the rule that
+// fired, a human-readable message and the input field that triggered it.
+type Reason struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// DeniedError is returned when a check did not end in allow.
+type DeniedError struct {
+	Decision string
+	Reasons  []Reason
+}
+
+func (e *DeniedError) Error() string {
+	return "policy decision " + e.Decision
+}
+
+// Explainer evaluates an input and returns a trace of the evaluation
+// alongside the decision. Mode is one of notes, fails, full or debug, as
+// for OPA's explain parameter.
+type Explainer interface {
+	Explain(input PolicyInput, mode string) (PolicyDecision, []string, error)
 }
 
 type opaResponse struct {
-	Result PolicyDecision `json:"result"`
+	Result      PolicyDecision `json:"result"`
+	Explanation []string       `json:"explanation"`
 }
 
 func (p *PolicyService) httpClient() *http.Client {
@@ -46,25 +79,42 @@ func (p *PolicyService) httpClient() *http.Client {
 }
 
 func (p *PolicyService) Evaluate(input PolicyInput) (PolicyDecision, error) {
+	out, err := p.query(input, "")
+	return out.Result, err
+}
+
+// Explain asks OPA for a pretty-printed explanation of the evaluation.
+func (p *PolicyService) Explain(input PolicyInput, mode string) (PolicyDecision, []string, error) {
+	mode = strings.TrimSpace(mode)
+	if mode == "" {
+		mode = "full"
+	}
+	out, err := p.query(input, "?explain="+url.QueryEscape(mode)+"&pretty=true")
+	return out.Result, out.Explanation, err
+}
+
+func (p *PolicyService) query(input PolicyInput, params string) (opaResponse, error) {
 	body, err := json.Marshal(map[string]any{"input": input})
 	if err != nil {
-		return PolicyDecision{}, err
+		return opaResponse{}, err
 	}
 	pkg := strings.Trim(strings.TrimSpace(p.PolicyPackage), "/")
 	pkg = strings.ReplaceAll(pkg, ".", "/")
 	base := strings.TrimRight(strings.TrimSpace(p.OPAURL), "/")
-	url := fmt.Sprintf("%s/v1/data/%s", base, pkg)
+	url := fmt.Sprintf("%s/v1/data/%s%s", base, pkg, params)
+	start := time.Now()
 	resp, err := p.httpClient().Post(url, "application/json", bytes.NewReader(body))
+	metrics.PolicyEvaluationDuration.WithLabelValues(ModeRemote).Observe(time.Since(start).Seconds())
 	if err != nil {
-		return PolicyDecision{}, err
+		return opaResponse{}, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return PolicyDecision{}, fmt.Errorf("opa status %d", resp.StatusCode)
+		return opaResponse{}, fmt.Errorf("opa status %d", resp.StatusCode)
 	}
 	var out opaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return PolicyDecision{}, err
+		return opaResponse{}, err
 	}
-	return out.Result, nil
+	return out, nil
 }
@@ -0,0 +1,295 @@
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"carapulse/internal/config"
+	"carapulse/internal/metrics"
+	"carapulse/policies"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/topdown"
+	"github.com/open-policy-agent/opa/v1/topdown/lineage"
+)
+
+const (
+	ModeRemote   = "remote"
+	ModeEmbedded = "embedded"
+	ModeBundle   = "bundle"
+
+	DefaultPackage = "policy.assistant.v1"
+
+	defaultDecisionCacheMaxEntries = 1000
+)
+
+// RegoChecker evaluates a compiled Rego package in-process. Decisions are
+// cached for the TTL the policy returns, keyed on the normalized input;
+// a TTL of zero (all writes in the bundled policy) is never cached.
+type RegoChecker struct {
+	PolicyPackage string
+	MaxEntries    int
+	// Revision is stamped on every decision.
+	Revision string
+
+	query   rego.PreparedEvalQuery
+	mu      sync.Mutex
+	entries map[string]decisionEntry
+	now     func() time.Time
+}
+
+type decisionEntry struct {
+	decision PolicyDecision
+	expires  time.Time
+}
+
+// NewEmbeddedChecker compiles the policies bundled with the binary. Its
+// revision is the digest of the embedded bundle.
+func NewEmbeddedChecker(cfg config.PolicyConfig) (*RegoChecker, error) {
+	bundle, err := ReadBundleFS(policies.FS)
+	if err != nil {
+		return nil, err
+	}
+	return newBundleRegoChecker(bundle, cfg.PolicyPackage, cfg.CacheMaxEntries)
+}
+
+// NewRegoChecker compiles every non-test .rego file in fsys and prepares a
+// query for pkg (dotted, e.g. policy.assistant.v1).
+func NewRegoChecker(fsys fs.FS, pkg string, maxEntries int) (*RegoChecker, error) {
+	modules, err := loadRegoModules(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return compileRegoChecker(modules, pkg, maxEntries)
+}
+
+func newBundleRegoChecker(bundle Bundle, pkg string, maxEntries int) (*RegoChecker, error) {
+	checker, err := compileRegoChecker(bundle.modules(), pkg, maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	checker.Revision = bundle.revision()
+	return checker, nil
+}
+
+func compileRegoChecker(modules []regoModule, pkg string, maxEntries int) (*RegoChecker, error) {
+	pkg = strings.Trim(strings.TrimSpace(pkg), ".")
+	if pkg == "" {
+		pkg = DefaultPackage
+	}
+	if len(modules) == 0 {
+		return nil, errors.New("policy: no rego modules found")
+	}
+	opts := []func(*rego.Rego){rego.Query("data." + pkg)}
+	for _, m := range modules {
+		opts = append(opts, rego.Module(m.name, m.src))
+	}
+	query, err := rego.New(opts...).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("policy: compile %s: %w", pkg, err)
+	}
+	return &RegoChecker{PolicyPackage: pkg, MaxEntries: maxEntries, query: query}, nil
+}
+
+type regoModule struct {
+	name string
+	src  string
+}
+
+func loadRegoModules(fsys fs.FS) ([]regoModule, error) {
+	var modules []regoModule
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".rego" || strings.HasSuffix(p, "_test.rego") {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		modules = append(modules, regoModule{name: p, src: string(src)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("policy: load modules: %w", err)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].name < modules[j].name })
+	return modules, nil
+}
+
+func (c *RegoChecker) Evaluate(input PolicyInput) (PolicyDecision, error) {
+	doc, key, err := normalizeInput(input)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	if dec, ok := c.cached(key); ok {
+		metrics.PolicyCacheRequestsTotal.WithLabelValues("hit").Inc()
+		return dec, nil
+	}
+	metrics.PolicyCacheRequestsTotal.WithLabelValues("miss").Inc()
+
+	start := time.Now()
+	rs, err := c.query.Eval(context.Background(), rego.EvalInput(doc))
+	metrics.PolicyEvaluationDuration.WithLabelValues(ModeEmbedded).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("policy: eval: %w", err)
+	}
+	dec, err := decisionFromResult(rs)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	dec.Revision = c.Revision
+	if dec.TTL > 0 {
+		c.store(key, dec)
+	}
+	return dec, nil
+}
+
+// Explain evaluates input without the cache and returns the trace, filtered
+// by mode like OPA's explain parameter (notes, fails, full or debug).
+func (c *RegoChecker) Explain(input PolicyInput, mode string) (PolicyDecision, []string, error) {
+	var filter func([]*topdown.Event) []*topdown.Event
+	switch strings.TrimSpace(mode) {
+	case "", "full":
+		filter = lineage.Full
+	case "notes":
+		filter = lineage.Notes
+	case "fails":
+		filter = lineage.Fails
+	case "debug":
+		filter = lineage.Debug
+	default:
+		return PolicyDecision{}, nil, fmt.Errorf("policy: unknown explain mode %q", mode)
+	}
+	doc, _, err := normalizeInput(input)
+	if err != nil {
+		return PolicyDecision{}, nil, err
+	}
+	tracer := topdown.NewBufferTracer()
+	rs, err := c.query.Eval(context.Background(), rego.EvalInput(doc), rego.EvalQueryTracer(tracer))
+	if err != nil {
+		return PolicyDecision{}, nil, fmt.Errorf("policy: eval: %w", err)
+	}
+	dec, err := decisionFromResult(rs)
+	if err != nil {
+		return PolicyDecision{}, nil, err
+	}
+	dec.Revision = c.Revision
+	var buf strings.Builder
+	topdown.PrettyTraceWithLocation(&buf, filter(*tracer))
+	return dec, strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"), nil
+}
+
+// decisionFromResult reads the package document the same way the remote
+// mode reads OPA's {"result": ...} body.
+func decisionFromResult(rs rego.ResultSet) (PolicyDecision, error) {
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return PolicyDecision{}, errors.New("policy: undefined result")
+	}
+	raw, err := json.Marshal(rs[0].Expressions[0].Value)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	var dec PolicyDecision
+	if err := json.Unmarshal(raw, &dec); err != nil {
+		return PolicyDecision{}, err
+	}
+	return dec, nil
+}
+
+func (c *RegoChecker) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *RegoChecker) cached(key string) (PolicyDecision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return PolicyDecision{}, false
+	}
+	if !c.clock().Before(entry.expires) {
+		delete(c.entries, key)
+		return PolicyDecision{}, false
+	}
+	return entry.decision, true
+}
+
+func (c *RegoChecker) store(key string, dec PolicyDecision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]decisionEntry{}
+	}
+	max := c.MaxEntries
+	if max <= 0 {
+		max = defaultDecisionCacheMaxEntries
+	}
+	now := c.clock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= max {
+		oldestKey := ""
+		var oldest time.Time
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldestKey == "" || e.expires.Before(oldest) {
+				oldestKey, oldest = k, e.expires
+			}
+		}
+		if len(c.entries) >= max && oldestKey != "" {
+			delete(c.entries, oldestKey)
+		}
+	}
+	c.entries[key] = decisionEntry{decision: dec, expires: now.Add(time.Duration(dec.TTL) * time.Second)}
+}
+
+// Purge drops every cached decision, e.g. after the policies changed.
+func (c *RegoChecker) Purge() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
+
+// normalizeInput decodes input into plain JSON values for evaluation and
+// derives the cache key from its canonical encoding. The request time is
+// left out of the key: it changes on every call, and the TTL already
+// bounds how long a decision may be reused.
+func normalizeInput(input PolicyInput) (any, string, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, "", err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, "", err
+	}
+	keyDoc := make(map[string]any, len(doc))
+	for k, v := range doc {
+		if k != "time" {
+			keyDoc[k] = v
+		}
+	}
+	canonical, err := json.Marshal(keyDoc)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return doc, hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"carapulse/internal/config"
+
+	"github.com/open-policy-agent/opa/v1/tester"
+)
+
+// TestBundledRegoTests runs the *_test.rego files, as `opa test policies/`
+// would.
+func TestBundledRegoTests(t *testing.T) {
+	results, err := tester.Run(context.Background(), "../../policies/policy")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatalf("no rego tests found")
+	}
+	for _, r := range results {
+		if !r.Pass() {
+			t.Errorf("%s.%s: %v", r.Package, r.Name, r.String())
+		}
+	}
+}
+
+func TestEmbeddedCheckerDecisions(t *testing.T) {
+	checker, err := NewEmbeddedChecker(config.PolicyConfig{})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	operator := map[string]any{"id": "a", "roles": []string{"operator"}}
+	cases := []struct {
+		name  string
+		input PolicyInput
+		want  string
+		ttl   int
+	}{
+		{"read", PolicyInput{Actor: map[string]any{"id": "a"}, Action: Action{Name: "x", Type: "read"}, Risk: Risk{Level: "read"}}, "allow", 300},
+		{"low write", PolicyInput{Actor: operator, Action: Action{Type: "write"}, Context: map[string]any{"environment": "dev"}, Risk: Risk{Level: "low", Targets: 1, BlastRadius: "service"}}, "allow", 0},
+		{"prod write", PolicyInput{Actor: operator, Action: Action{Type: "write"}, Context: map[string]any{"environment": "prod"}, Risk: Risk{Level: "low", Targets: 1}}, "require_approval", 0},
+		{"no roles", PolicyInput{Actor: map[string]any{"id": "a"}, Action: Action{Type: "write"}, Context: map[string]any{"environment": "dev"}, Risk: Risk{Level: "low"}}, "deny", 0},
+	}
+	for _, tc := range cases {
+		dec, err := checker.Evaluate(tc.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if dec.Decision != tc.want || dec.TTL != tc.ttl || !strings.HasPrefix(dec.Revision, "sha256:") {
+			t.Fatalf("%s: %#v", tc.name, dec)
+		}
+	}
+	high := PolicyInput{Actor: operator, Action: Action{Type: "write"}, Context: map[string]any{"environment": "dev"}, Risk: Risk{Level: "high"}}
+	dec, err := checker.Evaluate(high)
+	if err != nil || dec.Constraints["break_glass_required"] != true {
+		t.Fatalf("constraints: %#v err: %v", dec, err)
+	}
+}
+
+func TestRegoCheckerCachesForTTL(t *testing.T) {
+	fsys := fstest.MapFS{
+		"p/policy.rego":      {Data: []byte("package p\n\ndecision := \"allow\"\nttl := input.context.ttl\n")},
+		"p/policy_test.rego": {Data: []byte("package p\n\ntest_broken if { undefined_rule }\n")},
+	}
+	checker, err := NewRegoChecker(fsys, "p", 0)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	now := time.Unix(1000, 0)
+	checker.now = func() time.Time { return now }
+
+	read := PolicyInput{Action: Action{Type: "read"}, Resources: map[string]any{"b": 1, "a": 2}, Time: "t1"}
+	cachedInput := read
+	cachedInput.Actor = map[string]any{"id": "u"}
+	if _, err := checker.Evaluate(withTTL(cachedInput, 60)); err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if len(checker.entries) != 1 {
+		t.Fatalf("entries: %d", len(checker.entries))
+	}
+	again := withTTL(cachedInput, 60)
+	again.Time = "t2"
+	if _, ok := checker.cached(cacheKeyOf(t, again)); !ok {
+		t.Fatalf("time should not change the cache key")
+	}
+	now = now.Add(61 * time.Second)
+	if _, ok := checker.cached(cacheKeyOf(t, again)); ok {
+		t.Fatalf("expected expiry")
+	}
+	if _, err := checker.Evaluate(withTTL(read, 0)); err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if len(checker.entries) != 0 {
+		t.Fatalf("ttl 0 cached: %d", len(checker.entries))
+	}
+}
+
+func TestRegoCheckerEvictsWhenFull(t *testing.T) {
+	fsys := fstest.MapFS{"p.rego": {Data: []byte("package p\n\ndecision := \"allow\"\nttl := 60\n")}}
+	checker, err := NewRegoChecker(fsys, "p", 2)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := checker.Evaluate(PolicyInput{Action: Action{Name: name}}); err != nil {
+			t.Fatalf("eval: %v", err)
+		}
+	}
+	if len(checker.entries) != 2 {
+		t.Fatalf("entries: %d", len(checker.entries))
+	}
+	checker.Purge()
+	if len(checker.entries) != 0 {
+		t.Fatalf("purge left %d", len(checker.entries))
+	}
+}
+
+func TestNewRegoCheckerErrors(t *testing.T) {
+	if _, err := NewRegoChecker(fstest.MapFS{}, "p", 0); err == nil {
+		t.Fatalf("expected no modules error")
+	}
+	if _, err := NewRegoChecker(fstest.MapFS{"p.rego": {Data: []byte("package p\n\nx := \n")}}, "p", 0); err == nil {
+		t.Fatalf("expected compile error")
+	}
+}
+
+// withTTL sets the TTL the test policy returns for input.
+func withTTL(input PolicyInput, ttl int) PolicyInput {
+	input.Context = map[string]any{"ttl": ttl}
+	return input
+}
+
+func cacheKeyOf(t *testing.T, input PolicyInput) string {
+	t.Helper()
+	_, key, err := normalizeInput(input)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	return key
+}
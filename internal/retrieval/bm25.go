@@ -0,0 +1,169 @@
+package retrieval
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters; the usual defaults for short technical documents.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// serviceBoost multiplies the score of documents for the plan's service.
+const serviceBoost = 1.5
+
+// Document is one runbook, playbook or operator memory entry.
+type Document struct {
+	ID      string   `json:"id"`
+	Kind    string   `json:"kind"`
+	Title   string   `json:"title"`
+	Service string   `json:"service,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Text    string   `json:"text"`
+}
+
+// Hit is a matched document and its score.
+type Hit struct {
+	Document
+	Score float64 `json:"score"`
+}
+
+// Index is an immutable BM25 index over one tenant's documents.
+type Index struct {
+	docs    []Document
+	terms   []map[string]int
+	lengths []int
+	avgLen  float64
+	df      map[string]int
+	vectors [][]float64
+}
+
+// NewIndex tokenizes docs. Title and tags count alongside the body.
+func NewIndex(docs []Document) *Index {
+	idx := &Index{docs: docs, df: map[string]int{}}
+	total := 0
+	for _, doc := range docs {
+		tf := map[string]int{}
+		tokens := tokenize(doc.Title + " " + strings.Join(doc.Tags, " ") + " " + doc.Service + " " + doc.Text)
+		for _, tok := range tokens {
+			tf[tok]++
+		}
+		for tok := range tf {
+			idx.df[tok]++
+		}
+		idx.terms = append(idx.terms, tf)
+		idx.lengths = append(idx.lengths, len(tokens))
+		total += len(tokens)
+	}
+	if len(docs) > 0 {
+		idx.avgLen = float64(total) / float64(len(docs))
+	}
+	return idx
+}
+
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Search returns up to k documents for query, best first. Documents whose
+// Service equals service rank higher; documents sharing no term with the
+// query are never returned.
+func (idx *Index) Search(query, service string, k int) []Hit {
+	return idx.search(query, service, k, nil)
+}
+
+// search blends normalised BM25 with cosine similarity when both the
+// index and the query have embeddings.
+func (idx *Index) search(query, service string, k int, queryVec []float64) []Hit {
+	if k <= 0 || len(idx.docs) == 0 {
+		return nil
+	}
+	queryTerms := map[string]bool{}
+	for _, tok := range tokenize(query) {
+		queryTerms[tok] = true
+	}
+	n := float64(len(idx.docs))
+	scores := make([]float64, len(idx.docs))
+	maxScore := 0.0
+	for i, tf := range idx.terms {
+		score := 0.0
+		for tok := range queryTerms {
+			freq := float64(tf[tok])
+			if freq == 0 {
+				continue
+			}
+			df := float64(idx.df[tok])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(idx.lengths[i])/idx.avgLen
+			score += idf * freq * (bm25K1 + 1) / (freq + bm25K1*norm)
+		}
+		scores[i] = score
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+	useVectors := len(queryVec) > 0 && len(idx.vectors) == len(idx.docs)
+	var hits []Hit
+	for i, doc := range idx.docs {
+		score := scores[i]
+		if useVectors {
+			lexical := 0.0
+			if maxScore > 0 {
+				lexical = score / maxScore
+			}
+			score = (lexical + cosine(queryVec, idx.vectors[i])) / 2
+		}
+		if score <= 0 || (!useVectors && scores[i] == 0) {
+			continue
+		}
+		if service != "" && strings.EqualFold(doc.Service, service) {
+			score *= serviceBoost
+		}
+		hits = append(hits, Hit{Document: doc, Score: score})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "the": true, "this": true, "to": true, "when": true, "with": true,
+}
+
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if len(f) < 2 || stopwords[f] {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,114 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var docs = []Document{
+	{ID: "runbook_1", Kind: "runbook", Title: "Restart checkout pods", Service: "checkout", Text: "When checkout latency is high, restart the deployment with kubectl rollout restart."},
+	{ID: "runbook_2", Kind: "runbook", Title: "Roll back payments", Service: "payments", Text: "Use argocd to roll back payments to the previous revision when error rate spikes."},
+	{ID: "memory_1", Kind: "memory", Title: "Checkout cache", Text: "The checkout session cache must be flushed after a restart."},
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := NewIndex(docs)
+	hits := idx.Search("checkout latency restart", "", 5)
+	if len(hits) != 2 || hits[0].ID != "runbook_1" || hits[1].ID != "memory_1" {
+		t.Fatalf("hits: %#v", hits)
+	}
+	if hits := idx.Search("rollback payments error rate", "", 1); len(hits) != 1 || hits[0].ID != "runbook_2" {
+		t.Fatalf("hits: %#v", hits)
+	}
+	if hits := idx.Search("nothing matches", "", 5); len(hits) != 0 {
+		t.Fatalf("hits: %#v", hits)
+	}
+}
+
+func TestIndexSearchBoostsService(t *testing.T) {
+	idx := NewIndex([]Document{
+		{ID: "a", Title: "restart", Service: "orders", Text: "restart restart"},
+		{ID: "b", Title: "restart", Service: "checkout", Text: "restart restart"},
+	})
+	hits := idx.Search("restart", "checkout", 2)
+	if len(hits) != 2 || hits[0].ID != "b" {
+		t.Fatalf("hits: %#v", hits)
+	}
+}
+
+type fakeEmbedder struct {
+	calls int
+	err   error
+}
+
+// Embed maps texts mentioning "redis" to one axis and the rest to another.
+func (f *fakeEmbedder) Embed(texts []string) ([][]float64, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "redis") || strings.Contains(text, "cache") {
+			out[i] = []float64{1, 0}
+		} else {
+			out[i] = []float64{0, 1}
+		}
+	}
+	return out, nil
+}
+
+func TestRetrieverEmbeddingsAndCache(t *testing.T) {
+	loads := 0
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	embedder := &fakeEmbedder{}
+	r := &Retriever{
+		Load: func(ctx context.Context, tenantID string) ([]Document, error) {
+			loads++
+			if tenantID != "t1" {
+				t.Fatalf("tenant: %s", tenantID)
+			}
+			return docs, nil
+		},
+		Embedder: embedder,
+		TopK:     1,
+		now:      func() time.Time { return clock },
+	}
+	// "purge" matches no document term; only the embedding finds it.
+	hits, err := r.Retrieve(context.Background(), "t1", "purge redis", "")
+	if err != nil || len(hits) != 1 || hits[0].ID != "memory_1" || embedder.calls != 2 {
+		t.Fatalf("hits: %#v calls: %d err: %v", hits, embedder.calls, err)
+	}
+	if _, err := r.Retrieve(context.Background(), "t1", "checkout", ""); err != nil || loads != 1 {
+		t.Fatalf("loads: %d err: %v", loads, err)
+	}
+	clock = clock.Add(2 * time.Minute)
+	if _, err := r.Retrieve(context.Background(), "t1", "checkout", ""); err != nil || loads != 2 {
+		t.Fatalf("loads: %d err: %v", loads, err)
+	}
+	r.Invalidate("t1")
+	if _, err := r.Retrieve(context.Background(), "t1", "checkout", ""); err != nil || loads != 3 {
+		t.Fatalf("loads: %d err: %v", loads, err)
+	}
+}
+
+func TestRetrieverEmbeddingErrorFallsBack(t *testing.T) {
+	r := &Retriever{
+		Load:     func(ctx context.Context, tenantID string) ([]Document, error) { return docs, nil },
+		Embedder: &fakeEmbedder{err: errors.New("unsupported")},
+	}
+	hits, err := r.Retrieve(context.Background(), "t1", "roll back payments", "payments")
+	if err != nil || len(hits) == 0 || hits[0].ID != "runbook_2" {
+		t.Fatalf("hits: %#v err: %v", hits, err)
+	}
+	if _, err := r.Retrieve(context.Background(), " ", "q", ""); err == nil {
+		t.Fatalf("expected tenant error")
+	}
+	r.Load = func(ctx context.Context, tenantID string) ([]Document, error) { return nil, errors.New("db down") }
+	if _, err := r.Retrieve(context.Background(), "t2", "q", ""); err == nil {
+		t.Fatalf("expected load error")
+	}
+}
@@ -0,0 +1,125 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTopK    = 3
+	defaultRefresh = time.Minute
+)
+
+// Loader returns every document visible to a tenant.
+type Loader func(ctx context.Context, tenantID string) ([]Document, error)
+
+// Embedder turns texts into vectors, one per text.
+type Embedder interface {
+	Embed(texts []string) ([][]float64, error)
+}
+
+// Retriever keeps one index per tenant, rebuilt from Load once it is
+// older than Refresh. Embedding failures fall back to BM25 alone.
+type Retriever struct {
+	Load     Loader
+	Embedder Embedder
+	TopK     int
+	Refresh  time.Duration
+
+	mu      sync.Mutex
+	indexes map[string]cachedIndex
+	now     func() time.Time
+}
+
+type cachedIndex struct {
+	index *Index
+	built time.Time
+}
+
+// Retrieve returns the tenant's best matches for query.
+func (r *Retriever) Retrieve(ctx context.Context, tenantID, query, service string) ([]Hit, error) {
+	if r == nil || r.Load == nil {
+		return nil, nil
+	}
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return nil, errors.New("tenant_id required")
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	idx, err := r.index(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	topK := r.TopK
+	if topK <= 0 {
+		topK = defaultTopK
+	}
+	var queryVec []float64
+	if r.Embedder != nil && len(idx.vectors) > 0 {
+		vectors, err := r.Embedder.Embed([]string{query})
+		if err != nil {
+			slog.Warn("retrieval embed failed", "tenant_id", tenantID, "error", err)
+		} else if len(vectors) == 1 {
+			queryVec = vectors[0]
+		}
+	}
+	return idx.search(query, service, topK, queryVec), nil
+}
+
+// Invalidate drops the tenant's index so the next Retrieve reloads it.
+func (r *Retriever) Invalidate(tenantID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	delete(r.indexes, strings.TrimSpace(tenantID))
+	r.mu.Unlock()
+}
+
+func (r *Retriever) index(ctx context.Context, tenantID string) (*Index, error) {
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	refresh := r.Refresh
+	if refresh <= 0 {
+		refresh = defaultRefresh
+	}
+	r.mu.Lock()
+	cached, ok := r.indexes[tenantID]
+	r.mu.Unlock()
+	if ok && now().Sub(cached.built) < refresh {
+		return cached.index, nil
+	}
+	docs, err := r.Load(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	idx := NewIndex(docs)
+	if r.Embedder != nil && len(docs) > 0 {
+		texts := make([]string, len(docs))
+		for i, doc := range docs {
+			texts[i] = doc.Title + "\n" + doc.Text
+		}
+		vectors, err := r.Embedder.Embed(texts)
+		switch {
+		case err != nil:
+			slog.Warn("retrieval embed failed", "tenant_id", tenantID, "error", err)
+		case len(vectors) == len(docs):
+			idx.vectors = vectors
+		}
+	}
+	r.mu.Lock()
+	if r.indexes == nil {
+		r.indexes = map[string]cachedIndex{}
+	}
+	r.indexes[tenantID] = cachedIndex{index: idx, built: now()}
+	r.mu.Unlock()
+	return idx, nil
+}
@@ -0,0 +1,382 @@
+// Package risk scores proposed changes. A score combines the base risk of
+// the plan's actions with what is known about where and when they run;
+// the resulting level and tier drive approval and break-glass rules.
+package risk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"carapulse/internal/config"
+)
+
+const (
+	LevelRead   = "read"
+	LevelLow    = "low"
+	LevelMedium = "medium"
+	LevelHigh   = "high"
+
+	TierRead       = "read"
+	TierSafe       = "safe"
+	TierBreakGlass = "break_glass"
+)
+
+// Factor names, as they appear in a Score's breakdown.
+const (
+	FactorAction         = "action"
+	FactorEnvironment    = "environment"
+	FactorTargets        = "targets"
+	FactorCriticality    = "criticality"
+	FactorTimeOfDay      = "time_of_day"
+	FactorRecentFailures = "recent_failures"
+	FactorRollback       = "rollback"
+)
+
+// Points each factor contributes. The base points of a level are also the
+// score at which the total is promoted to that level, so a factor can
+// escalate a plan but never lower it below its own actions' risk.
+const (
+	pointsLow    = 15
+	pointsMedium = 40
+	pointsHigh   = 70
+
+	pointsProd              = 15
+	pointsStaging           = 5
+	pointsManyTargets       = 5
+	pointsAccountTargets    = 15
+	pointsCritical          = 10
+	pointsOffHours          = 10
+	pointsPerFailure        = 5
+	maxFailurePoints        = 15
+	pointsRollbackMissing   = 5
+	pointsRollbackAvailable = -5
+
+	defaultFailureLookback = 24 * time.Hour
+)
+
+// Factor is one line of a score's breakdown.
+type Factor struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Score is a plan's risk: Value is 0-100, Level and Tier follow from it.
+type Score struct {
+	Value   int      `json:"score"`
+	Level   string   `json:"level"`
+	Tier    string   `json:"tier"`
+	Factors []Factor `json:"factors"`
+}
+
+// Step is a plan step as the scorer sees it. Level is the base risk of
+// the step's tool and action, e.g. from tools.ActionRisk.
+type Step struct {
+	Tool     string
+	Action   string
+	Level    string
+	Rollback bool
+}
+
+// Service is a target service and its criticality in the context graph.
+type Service struct {
+	Name        string `json:"name"`
+	Criticality string `json:"criticality,omitempty"`
+}
+
+// Input is everything known about a change when it is scored. Steps may
+// be empty before a plan is drafted; Intent then carries the base risk.
+type Input struct {
+	Intent         string
+	Steps          []Step
+	Environment    string
+	Targets        int
+	Services       []Service
+	RecentFailures int
+}
+
+// Scorer scores changes. The zero value is usable: it ignores time of day
+// and looks back 24h for failed executions.
+type Scorer struct {
+	// BusinessHours, when set, makes writes outside it score higher.
+	BusinessHours *Window
+	// FailureLookback is how far back failed executions count.
+	FailureLookback time.Duration
+	Now             func() time.Time
+}
+
+// NewScorer builds the scorer cfg describes.
+func NewScorer(cfg config.RiskConfig) (Scorer, error) {
+	scorer := Scorer{FailureLookback: time.Duration(cfg.FailureLookbackHours) * time.Hour}
+	if strings.TrimSpace(cfg.BusinessHours) != "" {
+		window, err := ParseWindow(cfg.BusinessHours, cfg.Timezone)
+		if err != nil {
+			return Scorer{}, err
+		}
+		scorer.BusinessHours = window
+	}
+	return scorer, nil
+}
+
+// Lookback is the window recent failures are counted over.
+func (s Scorer) Lookback() time.Duration {
+	if s.FailureLookback > 0 {
+		return s.FailureLookback
+	}
+	return defaultFailureLookback
+}
+
+func (s Scorer) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// Score scores in. Read-only changes score 0 and skip the other factors,
+// which only weigh on writes.
+func (s Scorer) Score(in Input) Score {
+	base, detail := baseLevel(in)
+	if base == LevelRead {
+		return Score{
+			Level:   LevelRead,
+			Tier:    TierRead,
+			Factors: []Factor{{Name: FactorAction, Points: 0, Detail: detail}},
+		}
+	}
+	factors := []Factor{
+		{Name: FactorAction, Points: levelPoints(base), Detail: detail},
+		environmentFactor(in.Environment),
+		targetsFactor(in.Targets),
+		criticalityFactor(in.Services),
+		s.timeOfDayFactor(),
+		failuresFactor(in.RecentFailures),
+		rollbackFactor(in.Steps),
+	}
+	total := 0
+	for _, f := range factors {
+		total += f.Points
+	}
+	total = max(0, min(100, total))
+	level := levelForPoints(total)
+	if Ord(base) > Ord(level) {
+		level = base
+	}
+	return Score{Value: total, Level: level, Tier: TierFor(level), Factors: factors}
+}
+
+// AtLeast returns s with its level raised to level when that is riskier,
+// so rescoring a plan never lowers what it was already assessed at.
+func (s Score) AtLeast(level string) Score {
+	if Ord(level) > Ord(s.Level) {
+		s.Level = level
+		s.Tier = TierFor(level)
+	}
+	return s
+}
+
+func baseLevel(in Input) (string, string) {
+	level := IntentLevel(in.Intent)
+	detail := fmt.Sprintf("intent is %s risk", level)
+	for _, step := range in.Steps {
+		if Ord(step.Level) > Ord(level) {
+			level = step.Level
+			detail = fmt.Sprintf("%s %s is %s risk", step.Tool, step.Action, step.Level)
+		}
+	}
+	if level == LevelRead {
+		detail = "read-only"
+	}
+	return level, detail
+}
+
+func environmentFactor(env string) Factor {
+	env = strings.ToLower(strings.TrimSpace(env))
+	f := Factor{Name: FactorEnvironment, Detail: env}
+	switch env {
+	case "prod", "production":
+		f.Points = pointsProd
+	case "staging", "stage":
+		f.Points = pointsStaging
+	case "":
+		f.Detail = "unknown"
+	}
+	return f
+}
+
+func targetsFactor(targets int) Factor {
+	f := Factor{Name: FactorTargets, Detail: fmt.Sprintf("%d targets", targets)}
+	switch {
+	case targets > 50:
+		f.Points = pointsAccountTargets
+	case targets > 10:
+		f.Points = pointsManyTargets
+	}
+	return f
+}
+
+func criticalityFactor(services []Service) Factor {
+	f := Factor{Name: FactorCriticality, Detail: "no critical targets"}
+	var critical []string
+	for _, svc := range services {
+		if IsCritical(svc.Criticality) {
+			critical = append(critical, svc.Name)
+		}
+	}
+	if len(critical) > 0 {
+		f.Points = pointsCritical
+		f.Detail = "critical: " + strings.Join(critical, ", ")
+	}
+	return f
+}
+
+func (s Scorer) timeOfDayFactor() Factor {
+	f := Factor{Name: FactorTimeOfDay}
+	if s.BusinessHours == nil {
+		f.Detail = "business hours not configured"
+		return f
+	}
+	if s.BusinessHours.Contains(s.now()) {
+		f.Detail = "within business hours " + s.BusinessHours.String()
+		return f
+	}
+	f.Points = pointsOffHours
+	f.Detail = "outside business hours " + s.BusinessHours.String()
+	return f
+}
+
+func failuresFactor(failures int) Factor {
+	return Factor{
+		Name:   FactorRecentFailures,
+		Points: min(max(failures, 0)*pointsPerFailure, maxFailurePoints),
+		Detail: fmt.Sprintf("%d failed executions", max(failures, 0)),
+	}
+}
+
+func rollbackFactor(steps []Step) Factor {
+	f := Factor{Name: FactorRollback}
+	writes, missing := 0, 0
+	for _, step := range steps {
+		if Ord(step.Level) == Ord(LevelRead) {
+			continue
+		}
+		writes++
+		if !step.Rollback {
+			missing++
+		}
+	}
+	switch {
+	case writes == 0:
+		f.Detail = "no write steps drafted"
+	case missing == 0:
+		f.Points = pointsRollbackAvailable
+		f.Detail = "every write step has a rollback"
+	default:
+		f.Points = pointsRollbackMissing
+		f.Detail = fmt.Sprintf("%d of %d write steps have no rollback", missing, writes)
+	}
+	return f
+}
+
+func levelPoints(level string) int {
+	switch level {
+	case LevelLow:
+		return pointsLow
+	case LevelMedium:
+		return pointsMedium
+	case LevelHigh:
+		return pointsHigh
+	default:
+		return 0
+	}
+}
+
+func levelForPoints(points int) string {
+	switch {
+	case points >= pointsHigh:
+		return LevelHigh
+	case points >= pointsMedium:
+		return LevelMedium
+	default:
+		return LevelLow
+	}
+}
+
+// Ord orders risk levels for comparison; unknown levels sort as read.
+func Ord(level string) int {
+	switch level {
+	case LevelLow:
+		return 1
+	case LevelMedium:
+		return 2
+	case LevelHigh:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Higher returns whichever of a and b is riskier.
+func Higher(a, b string) string {
+	if Ord(b) > Ord(a) {
+		return b
+	}
+	return a
+}
+
+// TierFor maps a risk level to its execution tier.
+func TierFor(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case LevelRead:
+		return TierRead
+	case LevelLow, LevelMedium:
+		return TierSafe
+	default:
+		return TierBreakGlass
+	}
+}
+
+// IsCritical reports whether a context graph criticality label marks a
+// service as critical.
+func IsCritical(criticality string) bool {
+	switch strings.ToLower(strings.TrimSpace(criticality)) {
+	case "critical", "high", "tier-0", "tier0", "tier-1", "tier1":
+		return true
+	default:
+		return false
+	}
+}
+
+// IntentLevel classifies a free-text intent by keyword. It is the base
+// risk before a plan has steps, and a floor once it does.
+func IntentLevel(intent string) string {
+	intent = strings.ToLower(intent)
+	switch {
+	case strings.Contains(intent, "destroy"),
+		strings.Contains(intent, "delete"),
+		strings.Contains(intent, "terminate"),
+		strings.Contains(intent, "iam"),
+		strings.Contains(intent, "policy"),
+		strings.Contains(intent, "role"),
+		strings.Contains(intent, "user"),
+		strings.Contains(intent, "network"),
+		strings.Contains(intent, "vpc"),
+		strings.Contains(intent, "subnet"),
+		strings.Contains(intent, "security group"),
+		strings.Contains(intent, "firewall"):
+		return LevelHigh
+	case strings.Contains(intent, "sync"),
+		strings.Contains(intent, "restart"),
+		strings.Contains(intent, "rollout"),
+		strings.Contains(intent, "migrate"),
+		strings.Contains(intent, "upgrade"):
+		return LevelMedium
+	case strings.Contains(intent, "deploy"),
+		strings.Contains(intent, "scale"),
+		strings.Contains(intent, "rollback"):
+		return LevelLow
+	default:
+		return LevelRead
+	}
+}
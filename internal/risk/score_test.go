@@ -0,0 +1,186 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"carapulse/internal/config"
+)
+
+func factor(t *testing.T, score Score, name string) Factor {
+	t.Helper()
+	for _, f := range score.Factors {
+		if f.Name == name {
+			return f
+		}
+	}
+	t.Fatalf("factor %s missing from %+v", name, score.Factors)
+	return Factor{}
+}
+
+func TestScoreReadOnly(t *testing.T) {
+	score := Scorer{}.Score(Input{Intent: "show status", Environment: "prod", Steps: []Step{{Tool: "prometheus", Action: "query", Level: LevelRead}}})
+	if score.Value != 0 || score.Level != LevelRead || score.Tier != TierRead || len(score.Factors) != 1 {
+		t.Fatalf("score: %+v", score)
+	}
+}
+
+func TestScoreBaseLevelFromIntentAndSteps(t *testing.T) {
+	cases := []struct {
+		name  string
+		in    Input
+		level string
+		value int
+	}{
+		{"intent low", Input{Intent: "deploy api"}, LevelLow, 15},
+		{"intent medium", Input{Intent: "restart api"}, LevelMedium, 40},
+		{"intent high", Input{Intent: "delete namespace"}, LevelHigh, 70},
+		{"step escalates", Input{Intent: "deploy api", Steps: []Step{{Tool: "kubectl", Action: "delete", Level: LevelHigh}}}, LevelHigh, 75},
+		{"steps never lower intent", Input{Intent: "delete user", Steps: []Step{{Tool: "prometheus", Action: "query", Level: LevelRead}}}, LevelHigh, 70},
+	}
+	for _, c := range cases {
+		score := Scorer{}.Score(c.in)
+		if score.Level != c.level || score.Value != c.value {
+			t.Fatalf("%s: got %s/%d want %s/%d (%+v)", c.name, score.Level, score.Value, c.level, c.value, score.Factors)
+		}
+	}
+}
+
+func TestScoreFactorsEscalate(t *testing.T) {
+	offHours := time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC) // a Wednesday
+	window, err := ParseWindow("08:00-18:00", "")
+	if err != nil {
+		t.Fatalf("window: %v", err)
+	}
+	scorer := Scorer{BusinessHours: window, Now: func() time.Time { return offHours }}
+
+	// Prod alone keeps a low-risk deploy low.
+	low := Scorer{}.Score(Input{Intent: "deploy api", Environment: "prod", Targets: 1})
+	if low.Level != LevelLow || low.Value != 30 {
+		t.Fatalf("prod deploy: %+v", low)
+	}
+
+	score := scorer.Score(Input{
+		Intent:         "restart api",
+		Environment:    "prod",
+		Targets:        12,
+		Services:       []Service{{Name: "api", Criticality: "tier-1"}, {Name: "worker"}},
+		RecentFailures: 5,
+		Steps:          []Step{{Tool: "kubectl", Action: "rollout", Level: LevelMedium}},
+	})
+	if score.Level != LevelHigh || score.Tier != TierBreakGlass || score.Value != 100 {
+		t.Fatalf("score: %+v", score)
+	}
+	if f := factor(t, score, FactorRecentFailures); f.Points != maxFailurePoints {
+		t.Fatalf("failures: %+v", f)
+	}
+	if f := factor(t, score, FactorCriticality); f.Points != pointsCritical || f.Detail != "critical: api" {
+		t.Fatalf("criticality: %+v", f)
+	}
+	if f := factor(t, score, FactorTimeOfDay); f.Points != pointsOffHours {
+		t.Fatalf("time: %+v", f)
+	}
+	if f := factor(t, score, FactorRollback); f.Points != pointsRollbackMissing || f.Detail != "1 of 1 write steps have no rollback" {
+		t.Fatalf("rollback: %+v", f)
+	}
+}
+
+func TestScoreRollbackLowersWithinLevel(t *testing.T) {
+	steps := []Step{{Tool: "helm", Action: "upgrade", Level: LevelMedium, Rollback: true}}
+	score := Scorer{}.Score(Input{Intent: "upgrade api", Steps: steps})
+	if score.Value != 35 || score.Level != LevelMedium {
+		t.Fatalf("score: %+v", score)
+	}
+	if f := factor(t, score, FactorRollback); f.Points != pointsRollbackAvailable {
+		t.Fatalf("rollback: %+v", f)
+	}
+}
+
+func TestScoreWithinBusinessHours(t *testing.T) {
+	window, _ := ParseWindow("08:00-18:00", "UTC")
+	noon := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	score := Scorer{BusinessHours: window, Now: func() time.Time { return noon }}.Score(Input{Intent: "deploy api"})
+	if f := factor(t, score, FactorTimeOfDay); f.Points != 0 {
+		t.Fatalf("time: %+v", f)
+	}
+	saturday := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	score = Scorer{BusinessHours: window, Now: func() time.Time { return saturday }}.Score(Input{Intent: "deploy api"})
+	if f := factor(t, score, FactorTimeOfDay); f.Points != pointsOffHours {
+		t.Fatalf("weekend: %+v", f)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("09:30-17:00", "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if w.String() != "09:30-17:00 Europe/Berlin weekdays" {
+		t.Fatalf("window: %s", w)
+	}
+	for _, bad := range []string{"", "9-17", "18:00-08:00", "08:00-25:00", "aa:00-18:00"} {
+		if _, err := ParseWindow(bad, ""); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+	if _, err := ParseWindow("08:00-18:00", "Nowhere/Else"); err == nil {
+		t.Fatalf("expected timezone error")
+	}
+}
+
+func TestTierFor(t *testing.T) {
+	if TierFor("read") != TierRead || TierFor("low") != TierSafe || TierFor("medium") != TierSafe || TierFor("high") != TierBreakGlass {
+		t.Fatalf("tiers")
+	}
+}
+
+func TestIntentLevel(t *testing.T) {
+	cases := map[string]string{
+		"deploy service":     LevelLow,
+		"scale api":          LevelLow,
+		"rollback release":   LevelLow,
+		"sync argo":          LevelMedium,
+		"restart deployment": LevelMedium,
+		"delete user":        LevelHigh,
+		"update iam role":    LevelHigh,
+		"show status":        LevelRead,
+	}
+	for intent, want := range cases {
+		if got := IntentLevel(intent); got != want {
+			t.Fatalf("intent %q: want %s got %s", intent, want, got)
+		}
+	}
+}
+
+func TestOrdAndHigher(t *testing.T) {
+	if !(Ord(LevelRead) < Ord(LevelLow) && Ord(LevelLow) < Ord(LevelMedium) && Ord(LevelMedium) < Ord(LevelHigh)) {
+		t.Fatalf("ordering")
+	}
+	if Higher(LevelLow, LevelHigh) != LevelHigh || Higher(LevelMedium, LevelLow) != LevelMedium {
+		t.Fatalf("higher")
+	}
+}
+
+func TestNewScorer(t *testing.T) {
+	scorer, err := NewScorer(config.RiskConfig{})
+	if err != nil || scorer.BusinessHours != nil || scorer.Lookback() != 24*time.Hour {
+		t.Fatalf("default scorer: %+v %v", scorer, err)
+	}
+	scorer, err = NewScorer(config.RiskConfig{BusinessHours: "08:00-18:00", Timezone: "America/New_York", FailureLookbackHours: 6})
+	if err != nil || scorer.BusinessHours == nil || scorer.Lookback() != 6*time.Hour {
+		t.Fatalf("scorer: %+v %v", scorer, err)
+	}
+	if _, err := NewScorer(config.RiskConfig{BusinessHours: "late"}); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestScoreAtLeast(t *testing.T) {
+	score := Score{Value: 15, Level: LevelLow, Tier: TierSafe}
+	if got := score.AtLeast(LevelHigh); got.Level != LevelHigh || got.Tier != TierBreakGlass || got.Value != 15 {
+		t.Fatalf("raised: %+v", got)
+	}
+	if got := score.AtLeast(LevelRead); got.Level != LevelLow {
+		t.Fatalf("lowered: %+v", got)
+	}
+}
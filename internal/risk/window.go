@@ -0,0 +1,84 @@
+package risk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is a daily time range on weekdays, in Location.
+type Window struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// ParseWindow parses "HH:MM-HH:MM" in the named timezone (UTC when empty).
+func ParseWindow(spec, timezone string) (*Window, error) {
+	startRaw, endRaw, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, fmt.Errorf("risk: business hours %q must be HH:MM-HH:MM", spec)
+	}
+	start, err := parseClock(startRaw)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(endRaw)
+	if err != nil {
+		return nil, err
+	}
+	if end <= start {
+		return nil, fmt.Errorf("risk: business hours %q must end after they start", spec)
+	}
+	loc := time.UTC
+	if strings.TrimSpace(timezone) != "" {
+		loc, err = time.LoadLocation(strings.TrimSpace(timezone))
+		if err != nil {
+			return nil, fmt.Errorf("risk: timezone: %w", err)
+		}
+	}
+	return &Window{Start: start, End: end, Location: loc}, nil
+}
+
+func parseClock(raw string) (time.Duration, error) {
+	hourRaw, minuteRaw, ok := strings.Cut(strings.TrimSpace(raw), ":")
+	if !ok {
+		return 0, fmt.Errorf("risk: time %q must be HH:MM", raw)
+	}
+	hour, err := strconv.Atoi(hourRaw)
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, fmt.Errorf("risk: time %q must be HH:MM", raw)
+	}
+	minute, err := strconv.Atoi(minuteRaw)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("risk: time %q must be HH:MM", raw)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
+
+// Contains reports whether t falls on a weekday inside the window.
+func (w Window) Contains(t time.Time) bool {
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return offset >= w.Start && offset < w.End
+}
+
+func (w Window) String() string {
+	loc := "UTC"
+	if w.Location != nil {
+		loc = w.Location.String()
+	}
+	return fmt.Sprintf("%s-%s %s weekdays", clock(w.Start), clock(w.End), loc)
+}
+
+func clock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
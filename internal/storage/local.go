@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalBackend stores objects as files under Root. It is meant for
+// air-gapped and single-node installs where no S3 endpoint is available.
+type LocalBackend struct {
+	Root string
+}
+
+func (l *LocalBackend) Ref(key string) string {
+	return "file://" + filepath.Join(l.root(), filepath.FromSlash(strings.TrimPrefix(key, "/")))
+}
+
+// Put writes to a temporary file next to the target and renames it into
+// place, so readers never see a partial object.
+func (l *LocalBackend) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (string, error) {
+	target, err := l.resolve(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return "file://" + target, nil
+}
+
+func (l *LocalBackend) Get(ctx context.Context, ref string) (io.ReadCloser, error) {
+	target, err := l.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (l *LocalBackend) Exists(ctx context.Context, ref string) (bool, error) {
+	target, err := l.resolve(ref)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Presign has nothing to sign for local files; the ref is the locator.
+func (l *LocalBackend) Presign(ctx context.Context, ref string, ttl time.Duration) (string, error) {
+	target, err := l.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	return "file://" + target, nil
+}
+
+func (l *LocalBackend) root() string {
+	root, err := filepath.Abs(l.Root)
+	if err != nil {
+		return filepath.Clean(l.Root)
+	}
+	return root
+}
+
+// resolve maps a key or file:// ref to a path that must stay under Root.
+func (l *LocalBackend) resolve(ref string) (string, error) {
+	if strings.TrimSpace(l.Root) == "" {
+		return "", ErrObjectStoreDisabled
+	}
+	root := l.root()
+	var target string
+	if strings.HasPrefix(ref, "file://") {
+		target = filepath.Clean(strings.TrimPrefix(ref, "file://"))
+	} else {
+		key := strings.TrimPrefix(strings.TrimSpace(ref), "/")
+		if key == "" {
+			return "", ErrInvalidObjectKey
+		}
+		target = filepath.Join(root, filepath.FromSlash(key))
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidObjectKey
+	}
+	return target, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalBackendRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	store := ObjectStore{Backend: &LocalBackend{Root: root}}
+	ref, err := store.Put(context.Background(), "tool-output/e1/c1.json", []byte("data"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ref != "file://"+filepath.Join(root, "tool-output", "e1", "c1.json") {
+		t.Fatalf("ref: %s", ref)
+	}
+	got, err := store.Get(context.Background(), ref)
+	if err != nil || string(got) != "data" {
+		t.Fatalf("get: %q %v", got, err)
+	}
+	got, err = store.Get(context.Background(), "tool-output/e1/c1.json")
+	if err != nil || string(got) != "data" {
+		t.Fatalf("get by key: %q %v", got, err)
+	}
+	url, err := store.Presign(context.Background(), "tool-output/e1/c1.json", 0)
+	if err != nil || url != ref {
+		t.Fatalf("presign: %s %v", url, err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "tool-output", "e1"))
+	if len(entries) != 1 {
+		t.Fatalf("temp files left behind: %v", entries)
+	}
+}
+
+func TestLocalBackendContentAddressed(t *testing.T) {
+	root := t.TempDir()
+	store := ObjectStore{Backend: &LocalBackend{Root: root}, ContentAddressed: true}
+	first, err := store.PutStream(context.Background(), "evidence/a.txt", strings.NewReader("same"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	second, err := store.Put(context.Background(), "evidence/b.txt", []byte("same"))
+	if err != nil || first != second {
+		t.Fatalf("refs: %s %s err: %v", first, second, err)
+	}
+	if !strings.Contains(first, filepath.Join("evidence", "sha256")) || !strings.HasSuffix(first, ".txt") {
+		t.Fatalf("ref: %s", first)
+	}
+}
+
+func TestLocalBackendRejectsEscape(t *testing.T) {
+	root := t.TempDir()
+	backend := &LocalBackend{Root: root}
+	if _, err := backend.Get(context.Background(), "file:///etc/passwd"); !errors.Is(err, ErrInvalidObjectKey) {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := backend.Put(context.Background(), "../x", strings.NewReader("x"), PutOptions{}); !errors.Is(err, ErrInvalidObjectKey) {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := backend.Get(context.Background(), "missing"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("err: %v", err)
+	}
+	if ok, err := backend.Exists(context.Background(), "missing"); ok || err != nil {
+		t.Fatalf("exists: %v %v", ok, err)
+	}
+}
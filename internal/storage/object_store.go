@@ -3,78 +3,184 @@ package storage
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"os/exec"
-	"strconv"
+	"hash"
+	"io"
+	"os"
+	"path"
 	"strings"
 	"time"
+
+	"carapulse/internal/config"
 )
 
 var ErrObjectStoreDisabled = errors.New("object store disabled")
 var ErrInvalidObjectKey = errors.New("invalid object key")
+var ErrObjectNotFound = errors.New("object not found")
 
-var runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	if stdin != nil {
-		cmd.Stdin = bytes.NewReader(stdin)
-	}
-	return cmd.CombinedOutput()
+// Backend is an object store implementation. Refs are the URIs returned by
+// Put (s3://bucket/key, file:///path) and are accepted by Get and Presign.
+type Backend interface {
+	Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (string, error)
+	Get(ctx context.Context, ref string) (io.ReadCloser, error)
+	Exists(ctx context.Context, ref string) (bool, error)
+	Presign(ctx context.Context, ref string, ttl time.Duration) (string, error)
+	// Ref returns the ref Put would return for key.
+	Ref(key string) string
 }
 
-// ObjectStore uses aws CLI for S3-compatible storage.
+// PutOptions describe an upload. Size is -1 when unknown; SHA256 is the hex
+// digest of the body when the caller already has it.
+type PutOptions struct {
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+// ObjectStore stores evidence and tool output. With no Backend set it talks
+// S3 (or an S3-compatible endpoint) natively using credentials from the
+// environment.
 type ObjectStore struct {
 	Endpoint string
 	Bucket   string
+	Backend  Backend
+	// ContentAddressed replaces the file name of every key with the SHA-256
+	// of its content, so identical payloads are stored once.
+	ContentAddressed bool
+}
+
+// New builds the object store selected by cfg.
+func New(cfg config.ObjectStoreConfig) (*ObjectStore, error) {
+	store := &ObjectStore{Endpoint: cfg.Endpoint, Bucket: cfg.Bucket, ContentAddressed: cfg.ContentAddressed}
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "s3":
+		if strings.TrimSpace(cfg.Bucket) == "" {
+			return store, nil
+		}
+		store.Backend = &S3Backend{
+			Endpoint: cfg.Endpoint,
+			Region:   cfg.Region,
+			Bucket:   cfg.Bucket,
+			SSE:      cfg.SSE,
+			KMSKeyID: cfg.KMSKeyID,
+			PartSize: int64(cfg.PartSizeMB) << 20,
+		}
+	case "local":
+		if strings.TrimSpace(cfg.LocalDir) == "" {
+			return nil, errors.New("object_store.local_dir required for local backend")
+		}
+		store.Backend = &LocalBackend{Root: cfg.LocalDir}
+	default:
+		return nil, fmt.Errorf("unknown object store backend %q", cfg.Backend)
+	}
+	return store, nil
 }
 
 func (o ObjectStore) Enabled() bool {
-	return strings.TrimSpace(o.Bucket) != ""
+	return o.Backend != nil || strings.TrimSpace(o.Bucket) != ""
+}
+
+func (o ObjectStore) backend() Backend {
+	if o.Backend != nil {
+		return o.Backend
+	}
+	return &S3Backend{Endpoint: o.Endpoint, Bucket: o.Bucket}
 }
 
+// Put stores data under key and returns its ref.
 func (o ObjectStore) Put(ctx context.Context, key string, data []byte) (string, error) {
-	uri, err := o.objectURI(key)
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	return o.put(ctx, key, bytes.NewReader(data), PutOptions{Size: int64(len(data)), SHA256: digest})
+}
+
+// PutStream uploads r without holding it in memory. Content-addressed
+// stores spool it to a temporary file first to learn its digest.
+func (o ObjectStore) PutStream(ctx context.Context, key string, r io.Reader) (string, error) {
+	if !o.ContentAddressed {
+		return o.put(ctx, key, r, PutOptions{Size: -1})
+	}
+	spool, err := os.CreateTemp("", "carapulse-object-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(spool, h), r)
+	if err != nil {
+		return "", err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return o.put(ctx, key, spool, PutOptions{Size: size, SHA256: hexDigest(h)})
+}
+
+func (o ObjectStore) put(ctx context.Context, key string, body io.Reader, opts PutOptions) (string, error) {
+	key, err := o.objectKey(key)
 	if err != nil {
 		return "", err
 	}
-	args := []string{"s3", "cp", "--only-show-errors"}
-	if o.Endpoint != "" {
-		args = append(args, "--endpoint-url", o.Endpoint)
+	backend := o.backend()
+	if o.ContentAddressed && opts.SHA256 != "" && !isRef(key) {
+		key = ContentKey(key, opts.SHA256)
+		if ok, err := backend.Exists(ctx, backend.Ref(key)); err == nil && ok {
+			return backend.Ref(key), nil
+		}
+	}
+	if opts.ContentType == "" && path.Ext(key) == ".json" {
+		opts.ContentType = "application/json"
+	}
+	return backend.Put(ctx, key, body, opts)
+}
+
+// Get reads the object at ref (or key).
+func (o ObjectStore) Get(ctx context.Context, ref string) ([]byte, error) {
+	ref, err := o.objectKey(ref)
+	if err != nil {
+		return nil, err
 	}
-	args = append(args, "-", uri)
-	out, err := runCommand(ctx, "aws", args, data)
+	backend := o.backend()
+	if !isRef(ref) {
+		ref = backend.Ref(ref)
+	}
+	rc, err := backend.Get(ctx, ref)
 	if err != nil {
-		return "", fmt.Errorf("aws s3 cp failed: %w: %s", err, strings.TrimSpace(string(out)))
+		return nil, err
 	}
-	return uri, nil
+	defer rc.Close()
+	return io.ReadAll(rc)
 }
 
 func (o ObjectStore) Presign(ctx context.Context, key string, ttl time.Duration) (string, error) {
-	uri, err := o.objectURI(key)
+	ref, err := o.objectKey(key)
 	if err != nil {
 		return "", err
 	}
 	if ttl <= 0 {
 		ttl = 15 * time.Minute
 	}
-	args := []string{"s3", "presign"}
-	if o.Endpoint != "" {
-		args = append(args, "--endpoint-url", o.Endpoint)
-	}
-	args = append(args, uri, "--expires-in", strconv.Itoa(int(ttl.Seconds())))
-	out, err := runCommand(ctx, "aws", args, nil)
-	if err != nil {
-		return "", fmt.Errorf("aws s3 presign failed: %w: %s", err, strings.TrimSpace(string(out)))
+	backend := o.backend()
+	if !isRef(ref) {
+		ref = backend.Ref(ref)
 	}
-	return strings.TrimSpace(string(out)), nil
+	return backend.Presign(ctx, ref, ttl)
 }
 
-func (o ObjectStore) objectURI(key string) (string, error) {
+// objectKey validates key. Full refs pass through; bare keys need an
+// enabled store and must not escape it.
+func (o ObjectStore) objectKey(key string) (string, error) {
 	trimmed := strings.TrimSpace(key)
 	if trimmed == "" {
 		return "", ErrInvalidObjectKey
 	}
-	if strings.HasPrefix(trimmed, "s3://") {
+	if isRef(trimmed) {
 		return trimmed, nil
 	}
 	if !o.Enabled() {
@@ -84,5 +190,35 @@ func (o ObjectStore) objectURI(key string) (string, error) {
 	if trimmed == "" {
 		return "", ErrInvalidObjectKey
 	}
-	return fmt.Sprintf("s3://%s/%s", o.Bucket, trimmed), nil
+	for _, part := range strings.Split(trimmed, "/") {
+		if part == ".." {
+			return "", ErrInvalidObjectKey
+		}
+	}
+	return trimmed, nil
+}
+
+// ContentKey keeps the first segment of key as a namespace and names the
+// object after its digest: tool-output/<exec>/<call>.json becomes
+// tool-output/sha256/ab/<digest>.json.
+func ContentKey(key, digest string) string {
+	namespace := key
+	if idx := strings.Index(key, "/"); idx >= 0 {
+		namespace = key[:idx]
+	} else {
+		namespace = "objects"
+	}
+	shard := digest
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return namespace + "/sha256/" + shard + "/" + digest + path.Ext(key)
+}
+
+func isRef(key string) bool {
+	return strings.HasPrefix(key, "s3://") || strings.HasPrefix(key, "file://")
+}
+
+func hexDigest(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
 }
@@ -2,27 +2,118 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
 	"errors"
-	"runtime"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"carapulse/internal/awsauth"
+	"carapulse/internal/config"
 )
 
-func TestRunCommand(t *testing.T) {
-	ctx := context.Background()
-	var out []byte
-	var err error
-	if runtime.GOOS == "windows" {
-		out, err = runCommand(ctx, "cmd", []string{"/C", "echo", "ok"}, []byte("input"))
-	} else {
-		out, err = runCommand(ctx, "sh", []string{"-c", "cat >/dev/null; echo ok"}, []byte("input"))
+// fakeS3 is a path-style in-memory S3 covering the calls S3Backend makes.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+	parts   map[string]map[string][]byte
+	calls   []string
+	fail    int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{objects: map[string][]byte{}, headers: map[string]http.Header{}, parts: map[string]map[string][]byte{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := r.URL.Query()
+	op := r.Method
+	switch {
+	case q.Has("uploads"):
+		op = "CreateMultipartUpload"
+	case q.Has("partNumber"):
+		op = "UploadPart"
+	case q.Has("uploadId") && r.Method == http.MethodPost:
+		op = "CompleteMultipartUpload"
+	case q.Has("uploadId") && r.Method == http.MethodDelete:
+		op = "AbortMultipartUpload"
 	}
-	if err != nil {
-		t.Fatalf("err: %v out: %s", err, strings.TrimSpace(string(out)))
+	f.calls = append(f.calls, op)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if f.fail > 0 {
+		f.fail--
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("<Error><Code>InternalError</Code><Message>boom</Message></Error>"))
+		return
 	}
-	if !strings.Contains(string(out), "ok") {
-		t.Fatalf("out: %s", out)
+	body, _ := io.ReadAll(r.Body)
+	if sum := sha256.Sum256(body); r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	path := r.URL.Path
+	switch op {
+	case http.MethodPut:
+		f.objects[path] = body
+		f.headers[path] = r.Header.Clone()
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case "CreateMultipartUpload":
+		f.parts[path] = map[string][]byte{}
+		f.headers[path] = r.Header.Clone()
+		_, _ = w.Write([]byte("<InitiateMultipartUploadResult><UploadId>up-1</UploadId></InitiateMultipartUploadResult>"))
+	case "UploadPart":
+		f.parts[path][q.Get("partNumber")] = body
+		w.Header().Set("ETag", `"etag-`+q.Get("partNumber")+`"`)
+	case "CompleteMultipartUpload":
+		var data []byte
+		for i := 1; i <= len(f.parts[path]); i++ {
+			data = append(data, f.parts[path][strconv.Itoa(i)]...)
+		}
+		var complete struct {
+			Parts []struct {
+				ETag string `xml:"ETag"`
+			} `xml:"Part"`
+		}
+		if xml.Unmarshal(body, &complete) != nil || len(complete.Parts) != len(f.parts[path]) || complete.Parts[0].ETag != `"etag-1"` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[path] = data
+	case "AbortMultipartUpload":
+		delete(f.parts, path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testS3(srv *httptest.Server) *S3Backend {
+	return &S3Backend{
+		Endpoint:    srv.URL,
+		Bucket:      "bucket",
+		Credentials: awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
 	}
 }
 
@@ -38,30 +129,17 @@ func TestObjectStorePutInvalidKey(t *testing.T) {
 	if _, err := store.Put(context.Background(), " ", []byte("data")); !errors.Is(err, ErrInvalidObjectKey) {
 		t.Fatalf("err: %v", err)
 	}
-}
-
-func TestObjectStorePutTrimmedSlash(t *testing.T) {
-	store := ObjectStore{Bucket: "bucket"}
 	if _, err := store.Put(context.Background(), "/", []byte("data")); !errors.Is(err, ErrInvalidObjectKey) {
 		t.Fatalf("err: %v", err)
 	}
+	if _, err := store.Put(context.Background(), "a/../../b", []byte("data")); !errors.Is(err, ErrInvalidObjectKey) {
+		t.Fatalf("err: %v", err)
+	}
 }
 
 func TestObjectStorePut(t *testing.T) {
-	store := ObjectStore{Bucket: "bucket", Endpoint: "http://endpoint"}
-	oldRun := runCommand
-	defer func() { runCommand = oldRun }()
-	var gotArgs []string
-	runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-		if name != "aws" {
-			t.Fatalf("cmd: %s", name)
-		}
-		gotArgs = append([]string(nil), args...)
-		if string(stdin) != "data" {
-			t.Fatalf("stdin: %s", string(stdin))
-		}
-		return []byte("ok"), nil
-	}
+	fake, srv := newFakeS3(t)
+	store := ObjectStore{Bucket: "bucket", Backend: testS3(srv)}
 	uri, err := store.Put(context.Background(), "tool-output/key.json", []byte("data"))
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -69,122 +147,157 @@ func TestObjectStorePut(t *testing.T) {
 	if uri != "s3://bucket/tool-output/key.json" {
 		t.Fatalf("uri: %s", uri)
 	}
-	joined := strings.Join(gotArgs, " ")
-	if !strings.Contains(joined, "--endpoint-url http://endpoint") {
-		t.Fatalf("args: %v", gotArgs)
+	if string(fake.objects["/bucket/tool-output/key.json"]) != "data" {
+		t.Fatalf("objects: %v", fake.objects)
 	}
-	if !strings.Contains(joined, "s3://bucket/tool-output/key.json") {
-		t.Fatalf("args: %v", gotArgs)
+	if ct := fake.headers["/bucket/tool-output/key.json"].Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content type: %s", ct)
+	}
+	got, err := store.Get(context.Background(), uri)
+	if err != nil || string(got) != "data" {
+		t.Fatalf("get: %q %v", got, err)
 	}
 }
 
 func TestObjectStorePutS3URI(t *testing.T) {
-	store := ObjectStore{}
-	oldRun := runCommand
-	defer func() { runCommand = oldRun }()
-	var gotURI string
-	runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-		gotURI = args[len(args)-1]
-		return []byte("ok"), nil
-	}
+	fake, srv := newFakeS3(t)
+	store := ObjectStore{Backend: testS3(srv)}
 	uri, err := store.Put(context.Background(), "s3://other/key", []byte("data"))
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if uri != "s3://other/key" || gotURI != "s3://other/key" {
-		t.Fatalf("uri: %s got: %s", uri, gotURI)
+	if uri != "s3://other/key" || string(fake.objects["/other/key"]) != "data" {
+		t.Fatalf("uri: %s objects: %v", uri, fake.objects)
 	}
 }
 
 func TestObjectStorePutError(t *testing.T) {
-	store := ObjectStore{Bucket: "bucket"}
-	oldRun := runCommand
-	defer func() { runCommand = oldRun }()
-	runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-		return []byte("boom"), errors.New("fail")
+	fake, srv := newFakeS3(t)
+	fake.fail = 1
+	store := ObjectStore{Bucket: "bucket", Backend: testS3(srv)}
+	if _, err := store.Put(context.Background(), "key", []byte("data")); err == nil || !strings.Contains(err.Error(), "InternalError") {
+		t.Fatalf("expected error: %v", err)
 	}
-	if _, err := store.Put(context.Background(), "key", []byte("data")); err == nil {
-		t.Fatalf("expected error")
+}
+
+func TestObjectStoreGetNotFound(t *testing.T) {
+	_, srv := newFakeS3(t)
+	store := ObjectStore{Bucket: "bucket", Backend: testS3(srv)}
+	if _, err := store.Get(context.Background(), "missing"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("err: %v", err)
 	}
 }
 
-func TestObjectStorePresign(t *testing.T) {
-	store := ObjectStore{Bucket: "bucket"}
-	oldRun := runCommand
-	defer func() { runCommand = oldRun }()
-	runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-		if name != "aws" {
-			t.Fatalf("cmd: %s", name)
-		}
-		if stdin != nil {
-			t.Fatalf("stdin expected nil")
+func TestObjectStoreContentAddressed(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	store := ObjectStore{Bucket: "bucket", Backend: testS3(srv), ContentAddressed: true}
+	sum := sha256.Sum256([]byte("data"))
+	digest := hex.EncodeToString(sum[:])
+	want := "s3://bucket/tool-output/sha256/" + digest[:2] + "/" + digest + ".json"
+
+	first, err := store.Put(context.Background(), "tool-output/e1/c1.json", []byte("data"))
+	if err != nil || first != want {
+		t.Fatalf("ref: %s err: %v", first, err)
+	}
+	second, err := store.PutStream(context.Background(), "tool-output/e2/c2.json", strings.NewReader("data"))
+	if err != nil || second != want {
+		t.Fatalf("ref: %s err: %v", second, err)
+	}
+	puts := 0
+	for _, call := range fake.calls {
+		if call == http.MethodPut {
+			puts++
 		}
-		return []byte("https://signed"), nil
 	}
-	url, err := store.Presign(context.Background(), "tool-output/key.json", time.Minute)
+	if puts != 1 {
+		t.Fatalf("expected one upload, calls: %v", fake.calls)
+	}
+}
+
+func TestObjectStorePutStreamMultipart(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	backend := testS3(srv)
+	backend.SSE = "aws:kms"
+	backend.KMSKeyID = "key-1"
+	backend.PartSize = minPartSize
+	store := ObjectStore{Bucket: "bucket", Backend: backend}
+	payload := strings.Repeat("x", minPartSize+10)
+
+	ref, err := store.PutStream(context.Background(), "bundles/big.tar", strings.NewReader(payload))
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if url != "https://signed" {
-		t.Fatalf("url: %s", url)
+	if ref != "s3://bucket/bundles/big.tar" {
+		t.Fatalf("ref: %s", ref)
+	}
+	if got := fake.objects["/bucket/bundles/big.tar"]; string(got) != payload {
+		t.Fatalf("object size: %d", len(got))
+	}
+	want := []string{"CreateMultipartUpload", "UploadPart", "UploadPart", "CompleteMultipartUpload"}
+	if strings.Join(fake.calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("calls: %v", fake.calls)
+	}
+	h := fake.headers["/bucket/bundles/big.tar"]
+	if h.Get("X-Amz-Server-Side-Encryption") != "aws:kms" || h.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id") != "key-1" {
+		t.Fatalf("sse headers: %v", h)
 	}
 }
 
-func TestObjectStorePresignError(t *testing.T) {
-	store := ObjectStore{Bucket: "bucket"}
-	oldRun := runCommand
-	defer func() { runCommand = oldRun }()
-	runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-		return []byte("boom"), errors.New("fail")
-	}
-	if _, err := store.Presign(context.Background(), "tool-output/key.json", time.Minute); err == nil {
+func TestObjectStoreMultipartAbort(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	backend := testS3(srv)
+	backend.PartSize = minPartSize
+	store := ObjectStore{Bucket: "bucket", Backend: backend}
+	body := io.MultiReader(strings.NewReader(strings.Repeat("x", minPartSize)), errReader{})
+	if _, err := store.PutStream(context.Background(), "bundles/big.tar", body); err == nil {
 		t.Fatalf("expected error")
 	}
+	if fake.calls[len(fake.calls)-1] != "AbortMultipartUpload" {
+		t.Fatalf("calls: %v", fake.calls)
+	}
+	if _, ok := fake.objects["/bucket/bundles/big.tar"]; ok {
+		t.Fatalf("partial object stored")
+	}
 }
 
-func TestObjectStorePresignEndpoint(t *testing.T) {
-	store := ObjectStore{Bucket: "bucket", Endpoint: "http://endpoint"}
-	oldRun := runCommand
-	defer func() { runCommand = oldRun }()
-	var gotArgs []string
-	runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-		gotArgs = args
-		return []byte("ok"), nil
+func TestReadPartSizesBuffer(t *testing.T) {
+	part, err := readPart(strings.NewReader("small"), minPartSize, 5)
+	if err != nil || string(part) != "small" || cap(part) != 5 {
+		t.Fatalf("known size: %q cap=%d err=%v", part, cap(part), err)
 	}
-	if _, err := store.Presign(context.Background(), "tool-output/key.json", time.Minute); err != nil {
-		t.Fatalf("err: %v", err)
+	part, err = readPart(strings.NewReader("small"), minPartSize, 0)
+	if err != nil || string(part) != "small" || cap(part) >= minPartSize {
+		t.Fatalf("unknown size: %q cap=%d err=%v", part, cap(part), err)
 	}
-	found := false
-	for i := 0; i < len(gotArgs); i++ {
-		if gotArgs[i] == "--endpoint-url" && i+1 < len(gotArgs) && gotArgs[i+1] == "http://endpoint" {
-			found = true
-		}
+	// A body longer than announced still fills the part.
+	part, err = readPart(strings.NewReader(strings.Repeat("x", 20)), 16, 4)
+	if err != nil || len(part) != 16 {
+		t.Fatalf("long body: %d err=%v", len(part), err)
 	}
-	if !found {
-		t.Fatalf("args: %v", gotArgs)
+	if _, err := readPart(errReader{}, 16, 4); err == nil {
+		t.Fatalf("expected read error")
 	}
 }
 
-func TestObjectStorePresignDefaultTTL(t *testing.T) {
-	store := ObjectStore{Bucket: "bucket"}
-	oldRun := runCommand
-	defer func() { runCommand = oldRun }()
-	var gotArgs []string
-	runCommand = func(ctx context.Context, name string, args []string, stdin []byte) ([]byte, error) {
-		gotArgs = args
-		return []byte("ok"), nil
-	}
-	if _, err := store.Presign(context.Background(), "tool-output/key.json", 0); err != nil {
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("read failed") }
+
+func TestObjectStorePresign(t *testing.T) {
+	_, srv := newFakeS3(t)
+	backend := testS3(srv)
+	backend.Now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	store := ObjectStore{Bucket: "bucket", Backend: backend}
+	url, err := store.Presign(context.Background(), "tool-output/key.json", time.Minute)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	found := false
-	for i := 0; i < len(gotArgs); i++ {
-		if gotArgs[i] == "--expires-in" && i+1 < len(gotArgs) && gotArgs[i+1] == "900" {
-			found = true
-		}
+	if !strings.HasPrefix(url, srv.URL+"/bucket/tool-output/key.json?") || !strings.Contains(url, "X-Amz-Expires=60") || !strings.Contains(url, "X-Amz-Signature=") {
+		t.Fatalf("url: %s", url)
 	}
-	if !found {
-		t.Fatalf("args: %v", gotArgs)
+	url, err = store.Presign(context.Background(), "tool-output/key.json", 0)
+	if err != nil || !strings.Contains(url, "X-Amz-Expires=900") {
+		t.Fatalf("default ttl url: %s err: %v", url, err)
 	}
 }
 
@@ -201,3 +314,39 @@ func TestObjectStorePresignDisabled(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 }
+
+func TestS3BackendVirtualHostedURL(t *testing.T) {
+	s := &S3Backend{Region: "eu-west-1", Bucket: "bucket"}
+	if got := s.objectURL("bucket", "a/b.json", nil); got != "https://bucket.s3.eu-west-1.amazonaws.com/a/b.json" {
+		t.Fatalf("url: %s", got)
+	}
+	s.Endpoint = "http://minio:9000/"
+	if got := s.objectURL("bucket", "a/b.json", nil); got != "http://minio:9000/bucket/a/b.json" {
+		t.Fatalf("url: %s", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(config.ObjectStoreConfig{})
+	if err != nil || store.Enabled() {
+		t.Fatalf("expected disabled store: %v", err)
+	}
+	store, err = New(config.ObjectStoreConfig{Bucket: "b", SSE: "AES256", PartSizeMB: 16})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s3, ok := store.Backend.(*S3Backend)
+	if !ok || s3.SSE != "AES256" || s3.PartSize != 16<<20 {
+		t.Fatalf("backend: %#v", store.Backend)
+	}
+	store, err = New(config.ObjectStoreConfig{Backend: "local", LocalDir: t.TempDir(), ContentAddressed: true})
+	if err != nil || !store.Enabled() || !store.ContentAddressed {
+		t.Fatalf("store: %#v err: %v", store, err)
+	}
+	if _, err := New(config.ObjectStoreConfig{Backend: "local"}); err == nil {
+		t.Fatalf("expected local_dir error")
+	}
+	if _, err := New(config.ObjectStoreConfig{Backend: "gcs"}); err == nil {
+		t.Fatalf("expected backend error")
+	}
+}
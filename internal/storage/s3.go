@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"carapulse/internal/awsauth"
+)
+
+const (
+	minPartSize     = 5 << 20
+	defaultPartSize = 8 << 20
+)
+
+// S3Backend is a SigV4 S3 client for AWS and S3-compatible stores such as
+// MinIO. Custom endpoints are addressed path-style.
+type S3Backend struct {
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Credentials default to the AWS_* environment at call time.
+	Credentials awsauth.Credentials
+	// SSE is "AES256" or "aws:kms"; KMSKeyID selects the KMS key.
+	SSE      string
+	KMSKeyID string
+	PartSize int64
+	Client   *http.Client
+	Now      func() time.Time
+}
+
+func (s *S3Backend) Ref(key string) string {
+	return "s3://" + s.Bucket + "/" + strings.TrimPrefix(key, "/")
+}
+
+// Put uploads body with a single PUT when it fits in one part, and as a
+// multipart upload otherwise, so only one part is buffered at a time.
+func (s *S3Backend) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (string, error) {
+	bucket, key, err := s.split(key)
+	if err != nil {
+		return "", err
+	}
+	partSize := s.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+	first, err := readPart(body, partSize, opts.Size)
+	if err != nil {
+		return "", err
+	}
+	ref := "s3://" + bucket + "/" + key
+	if int64(len(first)) < partSize {
+		headers := s.objectHeaders(opts)
+		resp, err := s.do(ctx, http.MethodPut, bucket, key, nil, first, headers)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		return ref, nil
+	}
+	return ref, s.multipart(ctx, bucket, key, first, body, partSize, opts)
+}
+
+func (s *S3Backend) multipart(ctx context.Context, bucket, key string, first []byte, body io.Reader, partSize int64, opts PutOptions) error {
+	resp, err := s.do(ctx, http.MethodPost, bucket, key, url.Values{"uploads": {""}}, nil, s.objectHeaders(opts))
+	if err != nil {
+		return err
+	}
+	var created struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if err != nil || created.UploadID == "" {
+		return fmt.Errorf("s3 create multipart upload: %v", err)
+	}
+	uploadID := created.UploadID
+	abort := func() {
+		actx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if resp, err := s.do(actx, http.MethodDelete, bucket, key, url.Values{"uploadId": {uploadID}}, nil, nil); err == nil {
+			resp.Body.Close()
+		}
+	}
+	type completedPart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	var parts []completedPart
+	part := first
+	sent := int64(0)
+	for n := 1; len(part) > 0; n++ {
+		query := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+		resp, err := s.do(ctx, http.MethodPut, bucket, key, query, part, nil)
+		if err != nil {
+			abort()
+			return err
+		}
+		resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+		sent += int64(len(part))
+		remaining := int64(0)
+		if opts.Size > sent {
+			remaining = opts.Size - sent
+		}
+		if part, err = readPart(body, partSize, remaining); err != nil {
+			abort()
+			return err
+		}
+	}
+	payload, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		abort()
+		return err
+	}
+	resp, err = s.do(ctx, http.MethodPost, bucket, key, url.Values{"uploadId": {uploadID}}, payload, nil)
+	if err != nil {
+		abort()
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 can report a failed completion with a 200 and an Error body.
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if bytes.Contains(data, []byte("<Error>")) {
+		abort()
+		return fmt.Errorf("s3 complete multipart upload: %s", strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+func (s *S3Backend) Get(ctx context.Context, ref string) (io.ReadCloser, error) {
+	bucket, key, err := s.split(ref)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Backend) Exists(ctx context.Context, ref string) (bool, error) {
+	bucket, key, err := s.split(ref)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, bucket, key, nil, nil, nil)
+	if errors.Is(err, ErrObjectNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+func (s *S3Backend) Presign(ctx context.Context, ref string, ttl time.Duration) (string, error) {
+	bucket, key, err := s.split(ref)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(bucket, key, nil), nil)
+	if err != nil {
+		return "", err
+	}
+	return s.signer().Presign(req, ttl)
+}
+
+// split accepts s3://bucket/key refs (any bucket) or keys in s.Bucket.
+func (s *S3Backend) split(ref string) (string, string, error) {
+	ref = strings.TrimSpace(ref)
+	bucket := s.Bucket
+	key := strings.TrimPrefix(ref, "/")
+	if strings.HasPrefix(ref, "s3://") {
+		rest := strings.TrimPrefix(ref, "s3://")
+		idx := strings.Index(rest, "/")
+		if idx <= 0 {
+			return "", "", ErrInvalidObjectKey
+		}
+		bucket, key = rest[:idx], rest[idx+1:]
+	}
+	if strings.TrimSpace(bucket) == "" {
+		return "", "", ErrObjectStoreDisabled
+	}
+	if key == "" {
+		return "", "", ErrInvalidObjectKey
+	}
+	return bucket, key, nil
+}
+
+func (s *S3Backend) region() string {
+	if r := strings.TrimSpace(s.Region); r != "" {
+		return r
+	}
+	if r := strings.TrimSpace(os.Getenv("AWS_REGION")); r != "" {
+		return r
+	}
+	return "us-east-1"
+}
+
+func (s *S3Backend) objectURL(bucket, key string, query url.Values) string {
+	u := url.URL{Scheme: "https"}
+	endpoint := strings.TrimRight(strings.TrimSpace(s.Endpoint), "/")
+	if endpoint == "" {
+		u.Host = bucket + ".s3." + s.region() + ".amazonaws.com"
+		u.Path = "/" + key
+	} else {
+		if parsed, err := url.Parse(endpoint); err == nil && parsed.Host != "" {
+			u.Scheme, u.Host = parsed.Scheme, parsed.Host
+			u.Path = strings.TrimRight(parsed.Path, "/")
+		} else {
+			u.Host = endpoint
+		}
+		u.Path += "/" + bucket + "/" + key
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+func (s *S3Backend) signer() awsauth.Signer {
+	creds := s.Credentials
+	if !creds.Valid() {
+		creds = awsauth.CredentialsFromEnv()
+	}
+	return awsauth.Signer{Credentials: creds, Region: s.region(), Service: "s3", Now: s.Now}
+}
+
+func (s *S3Backend) objectHeaders(opts PutOptions) http.Header {
+	h := http.Header{}
+	if opts.ContentType != "" {
+		h.Set("Content-Type", opts.ContentType)
+	}
+	switch strings.TrimSpace(s.SSE) {
+	case "":
+	case "aws:kms":
+		h.Set("X-Amz-Server-Side-Encryption", "aws:kms")
+		if s.KMSKeyID != "" {
+			h.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", s.KMSKeyID)
+		}
+	default:
+		h.Set("X-Amz-Server-Side-Encryption", s.SSE)
+	}
+	return h
+}
+
+func (s *S3Backend) do(ctx context.Context, method, bucket, key string, query url.Values, body []byte, headers http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(bucket, key, query), reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	if err := s.signer().Sign(req, awsauth.PayloadHash(body)); err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: s3://%s/%s", ErrObjectNotFound, bucket, key)
+	}
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if xml.Unmarshal(data, &s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", method, key, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("s3 %s %s: status %d", method, key, resp.StatusCode)
+}
+
+// readPart reads up to size bytes; a short read means body is exhausted.
+// sizeHint, the bytes expected when known, caps the upfront buffer so
+// small objects don't allocate a whole part.
+func readPart(r io.Reader, size, sizeHint int64) ([]byte, error) {
+	if sizeHint <= 0 || sizeHint >= size {
+		if sizeHint == size {
+			return readFull(r, make([]byte, size))
+		}
+		return io.ReadAll(io.LimitReader(r, size))
+	}
+	buf, err := readFull(r, make([]byte, sizeHint))
+	if err != nil || int64(len(buf)) < sizeHint {
+		return buf, err
+	}
+	// The body is longer than announced; read on up to a full part.
+	rest, err := io.ReadAll(io.LimitReader(r, size-sizeHint))
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, rest...), nil
+}
+
+func readFull(r io.Reader, buf []byte) ([]byte, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return buf[:n], nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
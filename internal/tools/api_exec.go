@@ -22,6 +22,8 @@ func (r *Router) ExecuteAPI(ctx context.Context, tool string, action string, inp
 			return clients.Prometheus.Do(ctx, "GET", "/api/v1/rules", nil)
 		case "query_exemplars":
 			return clients.Prometheus.Do(ctx, "POST", "/api/v1/query_exemplars", input)
+		case "metadata":
+			return clients.Prometheus.Do(ctx, "GET", "/api/v1/metadata", nil)
 		default:
 			return nil, ErrNoCLI
 		}
@@ -98,6 +100,8 @@ func (r *Router) ExecuteAPI(ctx context.Context, tool string, action string, inp
 				return nil, ErrNoCLI
 			}
 			return clients.Tempo.Do(ctx, "GET", "/api/v2/traces/"+url.PathEscape(traceID), nil)
+		case "tags":
+			return clients.Tempo.Do(ctx, "GET", "/api/v2/search/tags", nil)
 		default:
 			return nil, ErrNoCLI
 		}
@@ -42,6 +42,12 @@ func TestExecuteAPIPrometheusActions(t *testing.T) {
 	if gotPath != "/api/v1/query_exemplars" {
 		t.Fatalf("exemplars path: %s", gotPath)
 	}
+	if out, err := router.ExecuteAPI(context.Background(), "prometheus", "metadata", nil, clients); err != nil || string(out) != "ok" {
+		t.Fatalf("metadata: %v %s", err, string(out))
+	}
+	if gotPath != "/api/v1/metadata" || gotMethod != http.MethodGet {
+		t.Fatalf("metadata path: %s method: %s", gotPath, gotMethod)
+	}
 }
 
 func TestExecuteAPIThanosParams(t *testing.T) {
@@ -133,6 +139,12 @@ func TestExecuteAPITempoActions(t *testing.T) {
 	if gotPath != "/api/v2/traces/abc" || gotMethod != http.MethodGet {
 		t.Fatalf("trace path: %s method: %s", gotPath, gotMethod)
 	}
+	if out, err := router.ExecuteAPI(context.Background(), "tempo", "tags", nil, clients); err != nil || string(out) != "ok" {
+		t.Fatalf("tags: %v %s", err, string(out))
+	}
+	if gotPath != "/api/v2/search/tags" || gotMethod != http.MethodGet {
+		t.Fatalf("tags path: %s method: %s", gotPath, gotMethod)
+	}
 }
 
 func TestExecuteAPIVaultBoundaryArgoAWS(t *testing.T) {
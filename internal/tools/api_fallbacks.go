@@ -20,9 +20,37 @@ type APIClient struct {
 	Allowlist []string
 	TokenFile string
 	MaxOutputBytes int
+	Guard     *BackendGuard
 }
 
+// Do sends a JSON request to the backend. When a Guard is set, calls are
+// rejected while the breaker for this BaseURL is open.
 func (c *APIClient) Do(ctx context.Context, method, path string, body any) ([]byte, error) {
+	return c.do(ctx, method, path, body, nil)
+}
+
+func (c *APIClient) do(ctx context.Context, method, path string, body any, status *int) ([]byte, error) {
+	if c.Guard == nil {
+		return c.doStatus(ctx, method, path, body, status)
+	}
+	breaker := c.Guard.Breaker("api:" + strings.TrimRight(strings.TrimSpace(c.BaseURL), "/"))
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+	var code int
+	data, err := c.doStatus(ctx, method, path, body, &code)
+	if reachedBackend(ctx, err) {
+		breaker.Record(isBackendFailure(err) || code >= 500 || code == http.StatusTooManyRequests)
+	} else {
+		breaker.Release()
+	}
+	if status != nil {
+		*status = code
+	}
+	return data, err
+}
+
+func (c *APIClient) doStatus(ctx context.Context, method, path string, body any, status *int) ([]byte, error) {
 	if c.Client == nil {
 		c.Client = &http.Client{}
 	}
@@ -58,6 +86,9 @@ func (c *APIClient) Do(ctx context.Context, method, path string, body any) ([]by
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if status != nil {
+		*status = resp.StatusCode
+	}
 	reader := io.Reader(resp.Body)
 	if c.MaxOutputBytes > 0 {
 		reader = io.LimitReader(resp.Body, int64(c.MaxOutputBytes)+1)
@@ -1,12 +1,16 @@
 package tools
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
+
+	"carapulse/internal/storage"
 )
 
 type ArtifactRef struct {
@@ -25,6 +29,20 @@ var runCommand = func(name string, args ...string) ([]byte, error) {
 	return cmd.CombinedOutput()
 }
 
+// objectStore serves object_store artifacts. Refs name their own bucket,
+// so the default only needs credentials from the environment.
+var objectStore = &storage.ObjectStore{Backend: &storage.S3Backend{}}
+
+const objectFetchTimeout = 2 * time.Minute
+
+// SetObjectStore sets the store used to resolve object_store artifacts.
+func SetObjectStore(store *storage.ObjectStore) {
+	if store == nil || store.Backend == nil {
+		store = &storage.ObjectStore{Backend: &storage.S3Backend{}}
+	}
+	objectStore = store
+}
+
 func ResolveArtifact(ref ArtifactRef) ([]byte, error) {
 	switch ref.Kind {
 	case "git_path":
@@ -76,14 +94,13 @@ func resolveObjectStore(ref string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if _, err := lookPath("aws"); err != nil {
-		return nil, ErrNotImplemented
-	}
-	out, err := runCommand("aws", "s3", "cp", "--only-show-errors", uri, "-")
+	ctx, cancel := context.WithTimeout(context.Background(), objectFetchTimeout)
+	defer cancel()
+	data, err := objectStore.Get(ctx, uri)
 	if err != nil {
-		return nil, fmt.Errorf("aws s3 cp failed: %w: %s", err, strings.TrimSpace(string(out)))
+		return nil, fmt.Errorf("object store get failed: %w", err)
 	}
-	return out, nil
+	return data, nil
 }
 
 func parseGitRef(ref string) (string, string, string, error) {
@@ -122,7 +139,7 @@ func parseObjectRef(ref string) (string, error) {
 	if trimmed == "" {
 		return "", ErrInvalidArtifact
 	}
-	if strings.HasPrefix(trimmed, "s3://") {
+	if strings.HasPrefix(trimmed, "s3://") || strings.HasPrefix(trimmed, "file://") {
 		return trimmed, nil
 	}
 	if strings.Contains(trimmed, "://") {
@@ -1,9 +1,15 @@
 package tools
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"carapulse/internal/awsauth"
+	"carapulse/internal/storage"
 )
 
 func TestResolveArtifactInline(t *testing.T) {
@@ -214,14 +220,11 @@ func TestResolveArtifactGitSHAMismatch(t *testing.T) {
 	}
 }
 
-func TestResolveArtifactObjectStoreMissingCLI(t *testing.T) {
-	oldLook := lookPath
-	lookPath = func(name string) (string, error) { return "", errors.New("missing") }
-	defer func() { lookPath = oldLook }()
-
-	if _, err := ResolveArtifact(ArtifactRef{Kind: "object_store", Ref: "bucket/key"}); !errors.Is(err, ErrNotImplemented) {
-		t.Fatalf("expected not implemented: %v", err)
-	}
+func withObjectStore(t *testing.T, store *storage.ObjectStore) {
+	t.Helper()
+	old := objectStore
+	SetObjectStore(store)
+	t.Cleanup(func() { objectStore = old })
 }
 
 func TestResolveArtifactObjectStoreBadRef(t *testing.T) {
@@ -230,34 +233,30 @@ func TestResolveArtifactObjectStoreBadRef(t *testing.T) {
 	}
 }
 
-func TestResolveArtifactObjectStoreExecError(t *testing.T) {
-	oldLook := lookPath
-	oldRun := runCommand
-	lookPath = func(name string) (string, error) { return "/usr/bin/aws", nil }
-	runCommand = func(name string, args ...string) ([]byte, error) { return []byte("boom"), errors.New("fail") }
-	defer func() {
-		lookPath = oldLook
-		runCommand = oldRun
-	}()
+func TestResolveArtifactObjectStoreGetError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code><Message>denied</Message></Error>"))
+	}))
+	defer srv.Close()
+	withObjectStore(t, &storage.ObjectStore{Backend: &storage.S3Backend{Endpoint: srv.URL, Credentials: awsauth.Credentials{AccessKeyID: "a", SecretAccessKey: "s"}}})
 
-	if _, err := ResolveArtifact(ArtifactRef{Kind: "object_store", Ref: "bucket/key"}); err == nil {
-		t.Fatalf("expected error")
+	if _, err := ResolveArtifact(ArtifactRef{Kind: "object_store", Ref: "bucket/key"}); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("expected error: %v", err)
 	}
 }
 
 func TestResolveArtifactObjectStoreOK(t *testing.T) {
-	oldLook := lookPath
-	oldRun := runCommand
-	lookPath = func(name string) (string, error) { return "/usr/bin/aws", nil }
-	var got []string
-	runCommand = func(name string, args ...string) ([]byte, error) {
-		got = append([]string{name}, args...)
-		return []byte("data"), nil
-	}
-	defer func() {
-		lookPath = oldLook
-		runCommand = oldRun
-	}()
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Errorf("unsigned request")
+		}
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer srv.Close()
+	withObjectStore(t, &storage.ObjectStore{Backend: &storage.S3Backend{Endpoint: srv.URL, Credentials: awsauth.Credentials{AccessKeyID: "a", SecretAccessKey: "s"}}})
 
 	data, err := ResolveArtifact(ArtifactRef{Kind: "object_store", Ref: "bucket/key"})
 	if err != nil {
@@ -266,22 +265,33 @@ func TestResolveArtifactObjectStoreOK(t *testing.T) {
 	if string(data) != "data" {
 		t.Fatalf("data: %s", string(data))
 	}
-	if len(got) != 6 || got[0] != "aws" || got[1] != "s3" || got[2] != "cp" || got[3] != "--only-show-errors" || got[4] != "s3://bucket/key" || got[5] != "-" {
-		t.Fatalf("cmd: %v", got)
+	if gotPath != "/bucket/key" {
+		t.Fatalf("path: %s", gotPath)
+	}
+}
+
+func TestResolveArtifactObjectStoreLocal(t *testing.T) {
+	backend := &storage.LocalBackend{Root: t.TempDir()}
+	withObjectStore(t, &storage.ObjectStore{Backend: backend})
+	ref, err := objectStore.Put(context.Background(), "values/app.yaml", []byte("data"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	data, err := ResolveArtifact(ArtifactRef{Kind: "object_store", Ref: ref})
+	if err != nil || string(data) != "data" {
+		t.Fatalf("data: %q err: %v", data, err)
 	}
 }
 
 func TestResolveArtifactObjectStoreSHAMismatch(t *testing.T) {
-	oldLook := lookPath
-	oldRun := runCommand
-	lookPath = func(name string) (string, error) { return "/usr/bin/aws", nil }
-	runCommand = func(name string, args ...string) ([]byte, error) { return []byte("data"), nil }
-	defer func() {
-		lookPath = oldLook
-		runCommand = oldRun
-	}()
+	backend := &storage.LocalBackend{Root: t.TempDir()}
+	withObjectStore(t, &storage.ObjectStore{Backend: backend})
+	ref, err := objectStore.Put(context.Background(), "values/app.yaml", []byte("data"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
 
-	if _, err := ResolveArtifact(ArtifactRef{Kind: "object_store", Ref: "bucket/key", SHA: "0000000000000000000000000000000000000000000000000000000000000000"}); !errors.Is(err, ErrInvalidArtifact) {
+	if _, err := ResolveArtifact(ArtifactRef{Kind: "object_store", Ref: ref, SHA: "0000000000000000000000000000000000000000000000000000000000000000"}); !errors.Is(err, ErrInvalidArtifact) {
 		t.Fatalf("expected invalid: %v", err)
 	}
 }
@@ -0,0 +1,429 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"carapulse/internal/config"
+	"carapulse/internal/metrics"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultBreakerHalfOpenProbes   = 1
+	defaultBreakerQueueTimeout     = 5 * time.Second
+)
+
+var ErrBackendUnavailable = errors.New("backend unavailable")
+
+// BackendUnavailableError is returned without contacting a backend when its
+// circuit is open or its concurrency limit is exhausted. Callers may retry
+// after RetryAfter.
+type BackendUnavailableError struct {
+	Backend    string        `json:"backend"`
+	Reason     string        `json:"reason"`
+	RetryAfter time.Duration `json:"retry_after"`
+}
+
+func (e *BackendUnavailableError) Error() string {
+	return fmt.Sprintf("backend unavailable: %s (%s)", e.Backend, e.Reason)
+}
+
+func (e *BackendUnavailableError) Is(target error) bool {
+	return target == ErrBackendUnavailable
+}
+
+// Retryable reports that the call was rejected before reaching the backend.
+func (e *BackendUnavailableError) Retryable() bool {
+	return true
+}
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+func (s BreakerState) gaugeValue() float64 {
+	switch s {
+	case BreakerOpen:
+		return 2
+	case BreakerHalfOpen:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CircuitBreaker opens after FailureThreshold consecutive failures, rejects
+// calls for OpenTimeout, then lets HalfOpenProbes calls through to decide
+// whether to close again.
+type CircuitBreaker struct {
+	Name             string
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenProbes   int
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+	now      func() time.Time
+}
+
+func NewCircuitBreaker(name string, threshold int, openTimeout time.Duration, probes int) *CircuitBreaker {
+	return &CircuitBreaker{Name: name, FailureThreshold: threshold, OpenTimeout: openTimeout, HalfOpenProbes: probes, state: BreakerClosed}
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+func (b *CircuitBreaker) threshold() int {
+	if b.FailureThreshold <= 0 {
+		return defaultBreakerFailureThreshold
+	}
+	return b.FailureThreshold
+}
+
+func (b *CircuitBreaker) openTimeout() time.Duration {
+	if b.OpenTimeout <= 0 {
+		return defaultBreakerOpenTimeout
+	}
+	return b.OpenTimeout
+}
+
+func (b *CircuitBreaker) probeLimit() int {
+	if b.HalfOpenProbes <= 0 {
+		return defaultBreakerHalfOpenProbes
+	}
+	return b.HalfOpenProbes
+}
+
+// Allow returns a BackendUnavailableError when the call must not proceed.
+// Every allowed call must be followed by Record.
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		remaining := b.openTimeout() - b.clock().Sub(b.openedAt)
+		if remaining > 0 {
+			metrics.ToolBreakerRejectionsTotal.WithLabelValues(b.Name, "circuit_open").Inc()
+			return &BackendUnavailableError{Backend: b.Name, Reason: "circuit_open", RetryAfter: remaining}
+		}
+		b.setStateLocked(BreakerHalfOpen)
+		b.probes = 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probes >= b.probeLimit() {
+			metrics.ToolBreakerRejectionsTotal.WithLabelValues(b.Name, "circuit_half_open").Inc()
+			return &BackendUnavailableError{Backend: b.Name, Reason: "circuit_half_open", RetryAfter: time.Second}
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// Release returns the probe slot of an allowed call whose outcome says
+// nothing about the backend, such as one the caller cancelled.
+func (b *CircuitBreaker) Release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// Record reports the outcome of an allowed call.
+func (b *CircuitBreaker) Record(failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setStateLocked(BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold() {
+		b.openedAt = b.clock()
+		b.setStateLocked(BreakerOpen)
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == "" {
+		return BreakerClosed
+	}
+	if b.state == BreakerOpen && b.clock().Sub(b.openedAt) >= b.openTimeout() {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) setStateLocked(state BreakerState) {
+	b.state = state
+	metrics.ToolBreakerState.WithLabelValues(b.Name).Set(state.gaugeValue())
+}
+
+// BackendGuard owns the circuit breakers and concurrency limits for every
+// tool backend the router talks to. Breakers are created on first use.
+type BackendGuard struct {
+	FailureThreshold     int
+	OpenTimeout          time.Duration
+	HalfOpenProbes       int
+	DefaultMaxConcurrent int
+	MaxConcurrent        map[string]int
+	QueueTimeout         time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+	slots    map[string]chan struct{}
+}
+
+// BuildBackendGuard returns nil when breakers are disabled.
+func BuildBackendGuard(cfg config.ToolBreakerConfig) *BackendGuard {
+	if !cfg.Enabled {
+		return nil
+	}
+	limits := map[string]int{}
+	for tool, n := range cfg.ToolMaxConcurrent {
+		limits[strings.TrimSpace(tool)] = n
+	}
+	return &BackendGuard{
+		FailureThreshold:     cfg.FailureThreshold,
+		OpenTimeout:          time.Duration(cfg.OpenSecs) * time.Second,
+		HalfOpenProbes:       cfg.HalfOpenProbes,
+		DefaultMaxConcurrent: cfg.MaxConcurrent,
+		MaxConcurrent:        limits,
+		QueueTimeout:         time.Duration(cfg.QueueTimeoutMS) * time.Millisecond,
+	}
+}
+
+// Breaker returns the breaker for name, creating it if needed.
+func (g *BackendGuard) Breaker(name string) *CircuitBreaker {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.breakers == nil {
+		g.breakers = map[string]*CircuitBreaker{}
+	}
+	b, ok := g.breakers[name]
+	if !ok {
+		b = NewCircuitBreaker(name, g.FailureThreshold, g.OpenTimeout, g.HalfOpenProbes)
+		g.breakers[name] = b
+	}
+	return b
+}
+
+// Acquire waits for a concurrency slot for tool. The returned release func
+// must be called once the call finishes.
+func (g *BackendGuard) Acquire(ctx context.Context, tool string) (func(), error) {
+	if g == nil {
+		return func() {}, nil
+	}
+	limit := g.DefaultMaxConcurrent
+	if n, ok := g.MaxConcurrent[tool]; ok {
+		limit = n
+	}
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	g.mu.Lock()
+	if g.slots == nil {
+		g.slots = map[string]chan struct{}{}
+	}
+	slots, ok := g.slots[tool]
+	if !ok {
+		slots = make(chan struct{}, limit)
+		g.slots[tool] = slots
+	}
+	g.mu.Unlock()
+	wait := g.QueueTimeout
+	if wait <= 0 {
+		wait = defaultBreakerQueueTimeout
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		metrics.ToolBreakerRejectionsTotal.WithLabelValues(tool, "concurrency_limit").Inc()
+		return nil, &BackendUnavailableError{Backend: tool, Reason: "concurrency_limit", RetryAfter: wait}
+	}
+	metrics.ToolInflight.WithLabelValues(tool).Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-slots
+			metrics.ToolInflight.WithLabelValues(tool).Dec()
+		})
+	}, nil
+}
+
+// Run executes fn under the concurrency limit and breaker for tool.
+func (g *BackendGuard) Run(ctx context.Context, tool string, fn func() error) error {
+	return g.RunClassified(ctx, tool, fn, isBackendFailure)
+}
+
+// RunClassified is Run with failed deciding which errors count against the
+// breaker. Calls that never reached the backend release their probe slot
+// without being recorded.
+func (g *BackendGuard) RunClassified(ctx context.Context, tool string, fn func() error, failed func(error) bool) error {
+	if g == nil {
+		return fn()
+	}
+	release, err := g.Acquire(ctx, tool)
+	if err != nil {
+		return err
+	}
+	defer release()
+	breaker := g.Breaker(tool)
+	if err := breaker.Allow(); err != nil {
+		return err
+	}
+	err = fn()
+	if !reachedBackend(ctx, err) {
+		breaker.Release()
+		return err
+	}
+	breaker.Record(failed(err))
+	return err
+}
+
+// States returns the current state of every known breaker.
+func (g *BackendGuard) States() map[string]BreakerState {
+	out := map[string]BreakerState{}
+	if g == nil {
+		return out
+	}
+	g.mu.Lock()
+	names := make([]string, 0, len(g.breakers))
+	breakers := make([]*CircuitBreaker, 0, len(g.breakers))
+	for name, b := range g.breakers {
+		names = append(names, name)
+		breakers = append(breakers, b)
+	}
+	g.mu.Unlock()
+	for i, b := range breakers {
+		out[names[i]] = b.State()
+	}
+	return out
+}
+
+// OpenBreakers lists breakers currently rejecting calls, sorted by name.
+func (g *BackendGuard) OpenBreakers() []string {
+	var open []string
+	for name, state := range g.States() {
+		if state == BreakerOpen {
+			open = append(open, name)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
+
+// reachedBackend reports whether a call's outcome says anything about the
+// backend's health. Caller cancellations, rejections and missing CLIs do not.
+func reachedBackend(ctx context.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return !errors.Is(err, ErrNoCLI) && !errors.Is(err, ErrBackendUnavailable)
+}
+
+// isBackendFailure decides whether err should count against a breaker.
+// Only transport errors and timeouts do; everything else is the caller's
+// problem, such as bad input or a denied request.
+func isBackendFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// cliBackendFailureMarkers are output fragments of a CLI that exited
+// non-zero because it could not reach its backend or the backend failed.
+var cliBackendFailureMarkers = []string{
+	"unable to connect to the server",
+	"connection refused",
+	"was refused",
+	"connection reset",
+	"no such host",
+	"i/o timeout",
+	"timed out",
+	"deadline exceeded",
+	"tls handshake",
+	"unexpected eof",
+	"internal error",
+	"internalerror",
+	"internal server error",
+	"bad gateway",
+	"service unavailable",
+	"serviceunavailable",
+	"gateway timeout",
+	"servertimeout",
+	"too many requests",
+	"toomanyrequests",
+	"rate limit",
+	"throttl",
+}
+
+// isCLIBackendFailure is isBackendFailure for a CLI call. A non-zero exit
+// only counts when output shows a transport or server failure; NotFound,
+// Forbidden, invalid arguments and credential errors are the caller's.
+func isCLIBackendFailure(err error, output []byte) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return isBackendFailure(err)
+	}
+	text := strings.ToLower(string(output) + " " + err.Error())
+	for _, marker := range cliBackendFailureMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"carapulse/internal/config"
+	"carapulse/internal/policy"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewCircuitBreaker("prometheus", 2, 10*time.Second, 1)
+	b.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("allow %d: %v", i, err)
+		}
+		b.Record(true)
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("state: %s", b.State())
+	}
+	err := b.Allow()
+	var unavailable *BackendUnavailableError
+	if !errors.As(err, &unavailable) || !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("err: %v", err)
+	}
+	if unavailable.RetryAfter != 10*time.Second || !unavailable.Retryable() {
+		t.Fatalf("unavailable: %+v", unavailable)
+	}
+	now = now.Add(11 * time.Second)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state: %s", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := b.Allow(); err == nil {
+		t.Fatalf("expected second probe rejected")
+	}
+	b.Record(true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("failed probe should reopen: %s", b.State())
+	}
+	now = now.Add(11 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	b.Record(false)
+	if b.State() != BreakerClosed {
+		t.Fatalf("state: %s", b.State())
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	b := NewCircuitBreaker("argocd", 2, time.Minute, 1)
+	b.Record(true)
+	b.Record(false)
+	b.Record(true)
+	if b.State() != BreakerClosed {
+		t.Fatalf("state: %s", b.State())
+	}
+}
+
+func TestBackendGuardConcurrencyLimit(t *testing.T) {
+	g := &BackendGuard{MaxConcurrent: map[string]int{"kubectl": 1}, QueueTimeout: 10 * time.Millisecond}
+	release, err := g.Acquire(context.Background(), "kubectl")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := g.Acquire(context.Background(), "kubectl"); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if rel, err := g.Acquire(context.Background(), "helm"); err != nil {
+		t.Fatalf("unlimited tool: %v", err)
+	} else {
+		rel()
+	}
+	release()
+	release()
+	release, err = g.Acquire(context.Background(), "kubectl")
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
+
+func TestBackendGuardRunIgnoresCancellation(t *testing.T) {
+	g := &BackendGuard{FailureThreshold: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = g.Run(ctx, "tempo", func() error { return context.Canceled })
+	_ = g.Run(context.Background(), "tempo", func() error { return ErrNoCLI })
+	if g.Breaker("tempo").State() != BreakerClosed {
+		t.Fatalf("state: %s", g.Breaker("tempo").State())
+	}
+	_ = g.Run(context.Background(), "tempo", func() error { return errors.New("invalid query") })
+	if g.Breaker("tempo").State() != BreakerClosed {
+		t.Fatalf("client error opened breaker: %s", g.Breaker("tempo").State())
+	}
+	_ = g.Run(context.Background(), "tempo", func() error { return &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED} })
+	if got := g.OpenBreakers(); len(got) != 1 || got[0] != "tempo" {
+		t.Fatalf("open: %v", got)
+	}
+	called := false
+	err := g.Run(context.Background(), "tempo", func() error { called = true; return nil })
+	if called || !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("called=%v err=%v", called, err)
+	}
+}
+
+func TestBackendGuardCancelledProbeKeepsBreakerHalfOpen(t *testing.T) {
+	now := time.Unix(0, 0)
+	g := &BackendGuard{FailureThreshold: 1, OpenTimeout: time.Second}
+	b := g.Breaker("kubectl")
+	b.now = func() time.Time { return now }
+	b.Record(true)
+	now = now.Add(2 * time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = g.Run(ctx, "kubectl", func() error { return ctx.Err() })
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("cancelled probe changed state: %s", b.State())
+	}
+	// The probe slot was released, so the next caller can probe.
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+}
+
+func TestIsCLIBackendFailure(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+	if _, ok := exitErr.(*exec.ExitError); !ok {
+		t.Skipf("no exit error: %v", exitErr)
+	}
+	cases := map[string]bool{
+		`Error from server (NotFound): deployments.apps "web" not found`:                         false,
+		`Error from server (Forbidden): pods is forbidden: User "u" cannot list resource "pods"`: false,
+		`error: unknown flag: --bogus`: false,
+		`The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?`: true,
+		`Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout`:                             true,
+		`Error from server (ServiceUnavailable): the server is currently unable to handle the request`:     true,
+		`Error from server (InternalError): an error on the server has prevented the request`:              true,
+		`Error from server (TooManyRequests): the server has received too many requests`:                   true,
+	}
+	for output, want := range cases {
+		if got := isCLIBackendFailure(exitErr, []byte(output)); got != want {
+			t.Fatalf("%q: got %v", output, got)
+		}
+	}
+	if isCLIBackendFailure(nil, nil) || !isCLIBackendFailure(context.DeadlineExceeded, nil) {
+		t.Fatalf("non-exit errors")
+	}
+}
+
+func TestBuildBackendGuard(t *testing.T) {
+	if BuildBackendGuard(config.ToolBreakerConfig{}) != nil {
+		t.Fatalf("expected nil when disabled")
+	}
+	g := BuildBackendGuard(config.ToolBreakerConfig{Enabled: true, FailureThreshold: 3, OpenSecs: 5, MaxConcurrent: 4, ToolMaxConcurrent: map[string]int{"helm": 1}, QueueTimeoutMS: 100})
+	if g.FailureThreshold != 3 || g.OpenTimeout != 5*time.Second || g.DefaultMaxConcurrent != 4 || g.MaxConcurrent["helm"] != 1 || g.QueueTimeout != 100*time.Millisecond {
+		t.Fatalf("guard: %+v", g)
+	}
+	var nilGuard *BackendGuard
+	if len(nilGuard.States()) != 0 || nilGuard.Breaker("x") != nil {
+		t.Fatalf("nil guard")
+	}
+}
+
+func TestAPIClientBreakerPerBaseURL(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	guard := &BackendGuard{FailureThreshold: 2, OpenTimeout: time.Minute}
+	client := &APIClient{BaseURL: srv.URL, Guard: guard}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Do(context.Background(), "GET", "/api/v1/rules", nil); err != nil {
+			t.Fatalf("do: %v", err)
+		}
+	}
+	if _, err := client.Do(context.Background(), "GET", "/api/v1/rules", nil); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("expected open breaker, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls: %d", calls)
+	}
+	if guard.States()["api:"+srv.URL] != BreakerOpen {
+		t.Fatalf("states: %v", guard.States())
+	}
+}
+
+func TestRouterExecuteBreakerOpen(t *testing.T) {
+	router := NewRouter()
+	router.Guard = &BackendGuard{FailureThreshold: 1, OpenTimeout: time.Minute}
+	clients := HTTPClients{Prometheus: &APIClient{BaseURL: "http://127.0.0.1:1"}}
+	req := ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": "up"}}
+	if _, err := router.Execute(context.Background(), req, &Sandbox{}, clients); err == nil || errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("first call should reach backend: %v", err)
+	}
+	resp, err := router.Execute(context.Background(), req, &Sandbox{}, clients)
+	if !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("expected fail fast, got %v", err)
+	}
+	if resp.ToolCallID == "" {
+		t.Fatalf("missing tool call id")
+	}
+}
+
+func TestToolRouterServerBackendUnavailable(t *testing.T) {
+	router := NewRouter()
+	router.Guard = &BackendGuard{FailureThreshold: 1, OpenTimeout: time.Minute}
+	router.Guard.Breaker("prometheus").Record(true)
+	srv := NewServer(router, NewSandbox(), HTTPClients{})
+	srv.Auth.Token = "token"
+	srv.Policy = &policy.Evaluator{Checker: policy.CheckerFunc(func(input policy.PolicyInput) (policy.PolicyDecision, error) {
+		return policy.PolicyDecision{Decision: "allow"}, nil
+	})}
+	body, _ := json.Marshal(ExecuteRequest{
+		Tool:    "prometheus",
+		Action:  "query",
+		Input:   map[string]any{"query": "up"},
+		Context: ContextRef{TenantID: "t", Environment: "prod", ClusterID: "c", Namespace: "ns", AWSAccountID: "a", Region: "r", ArgoCDProject: "p", GrafanaOrgID: "g"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status: %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatalf("missing retry-after")
+	}
+
+	remote := httptest.NewServer(srv)
+	defer remote.Close()
+	client := &RouterClient{BaseURL: remote.URL, Token: "token"}
+	_, err := client.Execute(context.Background(), ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": "up"}, Context: ContextRef{TenantID: "t", Environment: "prod", ClusterID: "c", Namespace: "ns", AWSAccountID: "a", Region: "r", ArgoCDProject: "p", GrafanaOrgID: "g"}})
+	var unavailable *BackendUnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("err: %v", err)
+	}
+	if unavailable.Backend != "prometheus" || unavailable.Reason != "circuit_open" || unavailable.RetryAfter <= 0 {
+		t.Fatalf("unavailable: %+v", unavailable)
+	}
+}
@@ -0,0 +1,376 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"carapulse/internal/config"
+	"carapulse/internal/metrics"
+)
+
+const (
+	defaultCacheTTL        = 30 * time.Second
+	defaultCacheMaxEntries = 1000
+)
+
+// ResultCache is a read-through cache for read-only tool calls. Identical
+// in-flight calls are coalesced so that only one of them reaches the backend.
+type ResultCache struct {
+	DefaultTTL time.Duration
+	ToolTTLs   map[string]time.Duration
+	MaxEntries int
+
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*cacheCall
+	stats    map[string]*CacheStats
+	gen      uint64
+	writes   []*cacheWrite
+	now      func() time.Time
+}
+
+// CacheStats counts cache lookups for a single tool.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Shared int64 `json:"shared"`
+}
+
+// HitRatio returns the share of lookups served without calling the backend.
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses + s.Shared
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.Shared) / float64(total)
+}
+
+type cacheEntry struct {
+	target  cacheScope
+	resp    ExecuteResponse
+	expires time.Time
+}
+
+type cacheCall struct {
+	wg    sync.WaitGroup
+	resp  ExecuteResponse
+	err   error
+	start uint64
+}
+
+// cacheWrite is a write that started at generation start and finished at
+// end, or is still running when end is zero. Reads that overlap it in time
+// and scope must not be cached.
+type cacheWrite struct {
+	target cacheScope
+	start  uint64
+	end    uint64
+}
+
+func NewResultCache(defaultTTL time.Duration, toolTTLs map[string]time.Duration, maxEntries int) *ResultCache {
+	return &ResultCache{DefaultTTL: defaultTTL, ToolTTLs: toolTTLs, MaxEntries: maxEntries}
+}
+
+// BuildResultCache returns nil when caching is disabled. A tool TTL of zero or
+// less turns caching off for that tool.
+func BuildResultCache(cfg config.ToolCacheConfig) *ResultCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	ttls := map[string]time.Duration{}
+	for tool, secs := range cfg.ToolTTLSecs {
+		ttls[strings.TrimSpace(tool)] = time.Duration(secs) * time.Second
+	}
+	return NewResultCache(time.Duration(cfg.DefaultTTLSecs)*time.Second, ttls, cfg.MaxEntries)
+}
+
+func (c *ResultCache) ttlFor(tool string) time.Duration {
+	if ttl, ok := c.ToolTTLs[tool]; ok {
+		return ttl
+	}
+	if c.DefaultTTL == 0 {
+		return defaultCacheTTL
+	}
+	return c.DefaultTTL
+}
+
+func (c *ResultCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *ResultCache) init() {
+	if c.entries == nil {
+		c.entries = map[string]cacheEntry{}
+	}
+	if c.inflight == nil {
+		c.inflight = map[string]*cacheCall{}
+	}
+	if c.stats == nil {
+		c.stats = map[string]*CacheStats{}
+	}
+}
+
+// Do returns a cached response for req when one is fresh, joins an identical
+// in-flight call when there is one, and otherwise runs fn and caches its
+// successful result.
+func (c *ResultCache) Do(req ExecuteRequest, fn func() (ExecuteResponse, error)) (ExecuteResponse, error) {
+	tool := strings.TrimSpace(req.Tool)
+	ttl := c.ttlFor(tool)
+	if ttl <= 0 {
+		return fn()
+	}
+	key := cacheKey(req)
+	c.mu.Lock()
+	c.init()
+	if entry, ok := c.entries[key]; ok {
+		if c.clock().Before(entry.expires) {
+			c.recordLocked(tool, "hit")
+			c.mu.Unlock()
+			return entry.resp, nil
+		}
+		delete(c.entries, key)
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.recordLocked(tool, "shared")
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.resp, call.err
+	}
+	call := &cacheCall{start: c.gen}
+	call.wg.Add(1)
+	c.inflight[key] = call
+	c.recordLocked(tool, "miss")
+	c.mu.Unlock()
+
+	call.resp, call.err = fn()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	target := cacheTarget(tool, req.Context)
+	if call.err == nil && !c.racedWriteLocked(target, call.start) {
+		c.storeLocked(key, cacheEntry{target: target, resp: call.resp, expires: c.clock().Add(ttl)})
+	}
+	c.pruneWritesLocked()
+	c.mu.Unlock()
+	call.wg.Done()
+	return call.resp, call.err
+}
+
+// BeginWrite marks a write to tool in ref as started and drops the cached
+// results it affects. Until the returned func is called, and for reads that
+// were in flight meanwhile, overlapping results are not cached. The func
+// drops results again once the write has finished.
+func (c *ResultCache) BeginWrite(tool string, ref ContextRef) func() {
+	tool = strings.TrimSpace(tool)
+	c.mu.Lock()
+	c.gen++
+	write := &cacheWrite{target: cacheTarget(tool, ref), start: c.gen}
+	c.writes = append(c.writes, write)
+	c.invalidateLocked(tool, write.target)
+	c.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.gen++
+			write.end = c.gen
+			c.invalidateLocked(tool, write.target)
+			c.pruneWritesLocked()
+		})
+	}
+}
+
+// Invalidate drops every cached result whose target overlaps a write to
+// tool in ref: reads of the same scope and reads of a wider or narrower
+// one, such as a cluster-wide read after a namespaced write.
+func (c *ResultCache) Invalidate(tool string, ref ContextRef) {
+	tool = strings.TrimSpace(tool)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked(tool, cacheTarget(tool, ref))
+}
+
+func (c *ResultCache) invalidateLocked(tool string, target cacheScope) {
+	removed := 0
+	for key, entry := range c.entries {
+		if entry.target.overlaps(target) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		metrics.ToolCacheInvalidationsTotal.WithLabelValues(tool).Add(float64(removed))
+	}
+}
+
+// racedWriteLocked reports whether a write overlapping target was running
+// at any point since generation start.
+func (c *ResultCache) racedWriteLocked(target cacheScope, start uint64) bool {
+	for _, w := range c.writes {
+		if (w.end == 0 || w.end > start) && w.target.overlaps(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneWritesLocked forgets finished writes no in-flight read overlaps.
+func (c *ResultCache) pruneWritesLocked() {
+	oldest := c.gen
+	for _, call := range c.inflight {
+		if call.start < oldest {
+			oldest = call.start
+		}
+	}
+	kept := c.writes[:0]
+	for _, w := range c.writes {
+		if w.end == 0 || w.end > oldest {
+			kept = append(kept, w)
+		}
+	}
+	for i := len(kept); i < len(c.writes); i++ {
+		c.writes[i] = nil
+	}
+	c.writes = kept
+}
+
+// Stats returns a snapshot of per-tool lookup counters.
+func (c *ResultCache) Stats() map[string]CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]CacheStats, len(c.stats))
+	for tool, s := range c.stats {
+		out[tool] = *s
+	}
+	return out
+}
+
+func (c *ResultCache) recordLocked(tool, result string) {
+	s := c.stats[tool]
+	if s == nil {
+		s = &CacheStats{}
+		c.stats[tool] = s
+	}
+	switch result {
+	case "hit":
+		s.Hits++
+	case "shared":
+		s.Shared++
+	default:
+		s.Misses++
+	}
+	metrics.ToolCacheRequestsTotal.WithLabelValues(tool, result).Inc()
+	metrics.ToolCacheHitRatio.WithLabelValues(tool).Set(s.HitRatio())
+}
+
+func (c *ResultCache) storeLocked(key string, entry cacheEntry) {
+	max := c.MaxEntries
+	if max <= 0 {
+		max = defaultCacheMaxEntries
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= max {
+		now := c.clock()
+		oldestKey := ""
+		var oldest time.Time
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldestKey == "" || e.expires.Before(oldest) {
+				oldestKey, oldest = k, e.expires
+			}
+		}
+		if len(c.entries) >= max && oldestKey != "" {
+			delete(c.entries, oldestKey)
+		}
+	}
+	c.entries[key] = entry
+}
+
+// cacheKey identifies a call by tool, action, normalized input and context.
+func cacheKey(req ExecuteRequest) string {
+	ref, _ := json.Marshal(req.Context)
+	return strings.Join([]string{
+		strings.TrimSpace(req.Tool),
+		strings.TrimSpace(req.Action),
+		normalizeCacheInput(req.Input),
+		string(ref),
+	}, "|")
+}
+
+// normalizeCacheInput renders input as canonical JSON so that equivalent
+// inputs (map order, raw JSON vs decoded maps) share a cache key.
+func normalizeCacheInput(input any) string {
+	if input == nil {
+		return "null"
+	}
+	var decoded any
+	switch v := input.(type) {
+	case []byte:
+		if err := json.Unmarshal(v, &decoded); err != nil {
+			return string(v)
+		}
+	case json.RawMessage:
+		if err := json.Unmarshal(v, &decoded); err != nil {
+			return string(v)
+		}
+	case string:
+		if err := json.Unmarshal([]byte(v), &decoded); err != nil {
+			return v
+		}
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return string(data)
+		}
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// cacheScope is the backend state a call observes: a tool group and the
+// context fields that narrow it. An empty field covers every value.
+type cacheScope struct {
+	group  string
+	fields [6]string
+}
+
+// overlaps reports whether two scopes can observe the same state: the
+// groups match and every field is equal or unset on either side.
+func (s cacheScope) overlaps(other cacheScope) bool {
+	if s.group != other.group {
+		return false
+	}
+	for i, v := range s.fields {
+		if v != "" && other.fields[i] != "" && v != other.fields[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheTarget groups tools that observe the same backend state, so that a
+// write through one of them invalidates reads made through the others.
+func cacheTarget(tool string, ref ContextRef) cacheScope {
+	group := tool
+	switch tool {
+	case "kubectl", "helm", "argocd":
+		group = "k8s"
+	case "prometheus", "thanos":
+		group = "metrics"
+	}
+	return cacheScope{group: group, fields: [6]string{ref.TenantID, ref.Environment, ref.ClusterID, ref.Namespace, ref.AWSAccountID, ref.Region}}
+}
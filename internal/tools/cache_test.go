@@ -0,0 +1,293 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"carapulse/internal/config"
+)
+
+func TestResultCacheHitAndExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := NewResultCache(time.Minute, nil, 0)
+	cache.now = func() time.Time { return now }
+	calls := 0
+	fn := func() (ExecuteResponse, error) {
+		calls++
+		return ExecuteResponse{Output: []byte("ok")}, nil
+	}
+	req := ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": "up", "time": "1"}}
+	if _, err := cache.Do(req, fn); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	reordered := ExecuteRequest{Tool: "prometheus", Action: "query", Input: `{"time":"1","query":"up"}`}
+	resp, err := cache.Do(reordered, fn)
+	if err != nil || string(resp.Output) != "ok" {
+		t.Fatalf("resp: %s err: %v", resp.Output, err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls: %d", calls)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.Do(req, fn); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls after expiry: %d", calls)
+	}
+	stats := cache.Stats()["prometheus"]
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Fatalf("stats: %+v", stats)
+	}
+	if ratio := stats.HitRatio(); ratio < 0.33 || ratio > 0.34 {
+		t.Fatalf("ratio: %v", ratio)
+	}
+}
+
+func TestResultCacheKeyIncludesContext(t *testing.T) {
+	cache := NewResultCache(time.Minute, nil, 0)
+	calls := 0
+	fn := func() (ExecuteResponse, error) {
+		calls++
+		return ExecuteResponse{}, nil
+	}
+	_, _ = cache.Do(ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ContextRef{ClusterID: "a"}}, fn)
+	_, _ = cache.Do(ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ContextRef{ClusterID: "b"}}, fn)
+	if calls != 2 {
+		t.Fatalf("calls: %d", calls)
+	}
+}
+
+func TestResultCacheSkipsErrorsAndDisabledTools(t *testing.T) {
+	cache := NewResultCache(time.Minute, map[string]time.Duration{"tempo": 0}, 0)
+	calls := 0
+	fail := func() (ExecuteResponse, error) {
+		calls++
+		return ExecuteResponse{}, errors.New("boom")
+	}
+	req := ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": "up"}}
+	_, _ = cache.Do(req, fail)
+	_, _ = cache.Do(req, fail)
+	if calls != 2 {
+		t.Fatalf("errors cached: %d", calls)
+	}
+	ok := func() (ExecuteResponse, error) {
+		calls++
+		return ExecuteResponse{}, nil
+	}
+	tempo := ExecuteRequest{Tool: "tempo", Action: "query", Input: map[string]any{"query": "x"}}
+	_, _ = cache.Do(tempo, ok)
+	_, _ = cache.Do(tempo, ok)
+	if calls != 4 {
+		t.Fatalf("disabled tool cached: %d", calls)
+	}
+}
+
+func TestResultCacheCoalescesInflight(t *testing.T) {
+	cache := NewResultCache(time.Minute, nil, 0)
+	var calls int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := func() (ExecuteResponse, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return ExecuteResponse{Output: []byte("shared")}, nil
+	}
+	req := ExecuteRequest{Tool: "argocd", Action: "status", Input: map[string]any{"app": "web"}}
+	var wg sync.WaitGroup
+	results := make([]string, 3)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, _ := cache.Do(req, fn)
+		results[0] = string(resp.Output)
+	}()
+	<-started
+	for i := 1; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, _ := cache.Do(req, fn)
+			results[i] = string(resp.Output)
+		}(i)
+	}
+	for {
+		if cache.Stats()["argocd"].Shared == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("calls: %d", calls)
+	}
+	for _, r := range results {
+		if r != "shared" {
+			t.Fatalf("results: %v", results)
+		}
+	}
+}
+
+func TestResultCacheInvalidateSharedTarget(t *testing.T) {
+	cache := NewResultCache(time.Minute, nil, 0)
+	calls := 0
+	fn := func() (ExecuteResponse, error) {
+		calls++
+		return ExecuteResponse{}, nil
+	}
+	ref := ContextRef{TenantID: "t", ClusterID: "c", Namespace: "ns"}
+	get := ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ref}
+	prom := ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": "up"}, Context: ref}
+	_, _ = cache.Do(get, fn)
+	_, _ = cache.Do(prom, fn)
+	cache.Invalidate("helm", ContextRef{TenantID: "t", ClusterID: "c", Namespace: "other"})
+	_, _ = cache.Do(get, fn)
+	if calls != 2 {
+		t.Fatalf("unrelated invalidation: %d", calls)
+	}
+	cache.Invalidate("helm", ref)
+	_, _ = cache.Do(get, fn)
+	_, _ = cache.Do(prom, fn)
+	if calls != 3 {
+		t.Fatalf("calls: %d", calls)
+	}
+}
+
+func TestResultCacheInvalidateWiderScope(t *testing.T) {
+	cache := NewResultCache(time.Minute, nil, 0)
+	calls := 0
+	fn := func() (ExecuteResponse, error) {
+		calls++
+		return ExecuteResponse{}, nil
+	}
+	clusterWide := ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ContextRef{TenantID: "t", ClusterID: "c"}}
+	otherCluster := ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ContextRef{TenantID: "t", ClusterID: "c2"}}
+	_, _ = cache.Do(clusterWide, fn)
+	_, _ = cache.Do(otherCluster, fn)
+	cache.Invalidate("kubectl", ContextRef{TenantID: "t", ClusterID: "c", Namespace: "ns"})
+	_, _ = cache.Do(clusterWide, fn)
+	_, _ = cache.Do(otherCluster, fn)
+	if calls != 3 {
+		t.Fatalf("calls: %d", calls)
+	}
+}
+
+func TestResultCacheSkipsReadsRacingWrite(t *testing.T) {
+	cache := NewResultCache(time.Minute, nil, 0)
+	ref := ContextRef{TenantID: "t", ClusterID: "c", Namespace: "ns"}
+	get := ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ref}
+	// A read that started before the write and finishes after it.
+	_, _ = cache.Do(get, func() (ExecuteResponse, error) {
+		cache.BeginWrite("helm", ref)()
+		return ExecuteResponse{Output: []byte("stale")}, nil
+	})
+	// A read that runs while the write is still in progress.
+	done := cache.BeginWrite("helm", ref)
+	_, _ = cache.Do(get, func() (ExecuteResponse, error) {
+		return ExecuteResponse{Output: []byte("stale")}, nil
+	})
+	done()
+	resp, _ := cache.Do(get, func() (ExecuteResponse, error) {
+		return ExecuteResponse{Output: []byte("fresh")}, nil
+	})
+	if string(resp.Output) != "fresh" {
+		t.Fatalf("served %s", resp.Output)
+	}
+	if len(cache.writes) != 0 {
+		t.Fatalf("writes kept: %d", len(cache.writes))
+	}
+	resp, _ = cache.Do(get, func() (ExecuteResponse, error) {
+		return ExecuteResponse{Output: []byte("refetched")}, nil
+	})
+	if string(resp.Output) != "fresh" {
+		t.Fatalf("fresh read not cached: %s", resp.Output)
+	}
+}
+
+func TestResultCacheEvictsWhenFull(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := NewResultCache(time.Minute, nil, 2)
+	cache.now = func() time.Time { return now }
+	fn := func() (ExecuteResponse, error) { return ExecuteResponse{}, nil }
+	for _, q := range []string{"a", "b", "c"} {
+		now = now.Add(time.Second)
+		_, _ = cache.Do(ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": q}}, fn)
+	}
+	if len(cache.entries) != 2 {
+		t.Fatalf("entries: %d", len(cache.entries))
+	}
+	if _, ok := cache.entries[cacheKey(ExecuteRequest{Tool: "prometheus", Action: "query", Input: map[string]any{"query": "a"}})]; ok {
+		t.Fatalf("oldest entry kept")
+	}
+}
+
+func TestRouterExecuteUsesCacheForReads(t *testing.T) {
+	router := NewRouter()
+	router.Cache = NewResultCache(time.Minute, nil, 0)
+	runs := 0
+	sandbox := &Sandbox{RunFunc: func(ctx context.Context, cmd []string) ([]byte, error) {
+		runs++
+		return []byte("out"), nil
+	}}
+	withFakeCLI(t, "kubectl")
+	read := ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}}
+	first, err := router.Execute(context.Background(), read, sandbox, HTTPClients{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	second, err := router.Execute(context.Background(), read, sandbox, HTTPClients{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if runs != 1 {
+		t.Fatalf("runs: %d", runs)
+	}
+	if first.ToolCallID == second.ToolCallID || string(second.Output) != "out" {
+		t.Fatalf("second: %+v", second)
+	}
+	write := ExecuteRequest{Tool: "kubectl", Action: "scale", Input: map[string]any{"resource": "deploy/x", "replicas": 1}}
+	if _, err := router.Execute(context.Background(), write, sandbox, HTTPClients{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := router.Execute(context.Background(), read, sandbox, HTTPClients{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if runs != 3 {
+		t.Fatalf("runs after write: %d", runs)
+	}
+}
+
+func TestBuildResultCache(t *testing.T) {
+	if BuildResultCache(config.ToolCacheConfig{}) != nil {
+		t.Fatalf("expected nil when disabled")
+	}
+	cache := BuildResultCache(config.ToolCacheConfig{Enabled: true, DefaultTTLSecs: 5, ToolTTLSecs: map[string]int{"tempo": 0, "argocd": 10}})
+	if cache.ttlFor("prometheus") != 5*time.Second || cache.ttlFor("argocd") != 10*time.Second || cache.ttlFor("tempo") != 0 {
+		t.Fatalf("ttls: %+v", cache.ToolTTLs)
+	}
+	if NewResultCache(0, nil, 0).ttlFor("x") != defaultCacheTTL {
+		t.Fatalf("default ttl")
+	}
+}
+
+func withFakeCLI(t *testing.T, name string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts unsupported")
+	}
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, name), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatalf("write cli: %v", err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
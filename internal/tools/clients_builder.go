@@ -18,6 +18,7 @@ type APIConfig struct {
 	Tokens           map[string]string
 	EgressAllowlist  []string
 	MaxOutputBytes   int
+	Guard            *BackendGuard
 }
 
 func BuildHTTPClients(cfg config.Config, api APIConfig) HTTPClients {
@@ -118,16 +119,16 @@ func BuildHTTPClients(cfg config.Config, api APIConfig) HTTPClients {
 		maxOutput = cfg.Sandbox.MaxOutputBytes
 	}
 	return HTTPClients{
-		Prometheus:   &APIClient{BaseURL: promBase, Auth: AuthHeaders{BearerToken: tokens["prometheus"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		Alertmanager: &APIClient{BaseURL: alertBase, Auth: AuthHeaders{BearerToken: tokens["alertmanager"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		Thanos:       &APIClient{BaseURL: thanosBase, Auth: AuthHeaders{BearerToken: tokens["thanos"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		Grafana:      &APIClient{BaseURL: grafBase, Auth: AuthHeaders{BearerToken: tokens["grafana"], Extra: map[string]string{"X-Grafana-Org-Id": grafanaOrg}}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		Tempo:        &APIClient{BaseURL: tempoBase, Auth: AuthHeaders{BearerToken: tokens["tempo"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		Linear:       &APIClient{BaseURL: linearBase, Auth: AuthHeaders{BearerToken: tokens["linear"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		PagerDuty:    &APIClient{BaseURL: pdBase, Auth: AuthHeaders{BearerToken: tokens["pagerduty"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		Vault:        &APIClient{BaseURL: vaultBase, Auth: AuthHeaders{BearerToken: tokens["vault"]}, Allowlist: api.EgressAllowlist, TokenFile: vaultTokenFile, MaxOutputBytes: maxOutput},
-		Boundary:     &APIClient{BaseURL: boundaryBase, Auth: AuthHeaders{BearerToken: tokens["boundary"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		ArgoCD:       &APIClient{BaseURL: argoBase, Auth: AuthHeaders{BearerToken: tokens["argocd"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
-		AWS:          &APIClient{BaseURL: awsBase, Auth: AuthHeaders{BearerToken: tokens["aws"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput},
+		Prometheus:   &APIClient{BaseURL: promBase, Auth: AuthHeaders{BearerToken: tokens["prometheus"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		Alertmanager: &APIClient{BaseURL: alertBase, Auth: AuthHeaders{BearerToken: tokens["alertmanager"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		Thanos:       &APIClient{BaseURL: thanosBase, Auth: AuthHeaders{BearerToken: tokens["thanos"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		Grafana:      &APIClient{BaseURL: grafBase, Auth: AuthHeaders{BearerToken: tokens["grafana"], Extra: map[string]string{"X-Grafana-Org-Id": grafanaOrg}}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		Tempo:        &APIClient{BaseURL: tempoBase, Auth: AuthHeaders{BearerToken: tokens["tempo"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		Linear:       &APIClient{BaseURL: linearBase, Auth: AuthHeaders{BearerToken: tokens["linear"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		PagerDuty:    &APIClient{BaseURL: pdBase, Auth: AuthHeaders{BearerToken: tokens["pagerduty"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		Vault:        &APIClient{BaseURL: vaultBase, Auth: AuthHeaders{BearerToken: tokens["vault"]}, Allowlist: api.EgressAllowlist, TokenFile: vaultTokenFile, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		Boundary:     &APIClient{BaseURL: boundaryBase, Auth: AuthHeaders{BearerToken: tokens["boundary"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		ArgoCD:       &APIClient{BaseURL: argoBase, Auth: AuthHeaders{BearerToken: tokens["argocd"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
+		AWS:          &APIClient{BaseURL: awsBase, Auth: AuthHeaders{BearerToken: tokens["aws"]}, Allowlist: api.EgressAllowlist, MaxOutputBytes: maxOutput, Guard: api.Guard},
 	}
 }
@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"carapulse/internal/config"
+)
+
+var ErrUnknownCluster = errors.New("cluster not registered")
+
+type Cluster struct {
+	ID             string
+	KubeconfigPath string
+	Context        string
+	ArgoCDAddr     string
+	ArgoCDToken    string
+}
+
+// ClusterRegistry maps ContextRef.ClusterID to the kubeconfig context and
+// ArgoCD credentials used for cluster-scoped tools. With no clusters
+// registered, calls run against the default kubeconfig as before.
+type ClusterRegistry struct {
+	DefaultKubeconfig string
+	clusters          map[string]Cluster
+}
+
+func NewClusterRegistry(defaultKubeconfig string, clusters []Cluster) *ClusterRegistry {
+	reg := &ClusterRegistry{DefaultKubeconfig: strings.TrimSpace(defaultKubeconfig), clusters: map[string]Cluster{}}
+	for _, c := range clusters {
+		c.ID = strings.TrimSpace(c.ID)
+		if c.ID == "" {
+			continue
+		}
+		reg.clusters[c.ID] = c
+	}
+	return reg
+}
+
+// BuildClusterRegistry returns nil when neither a kubeconfig path nor any
+// clusters are configured.
+func BuildClusterRegistry(cfg config.K8sConfig) *ClusterRegistry {
+	if strings.TrimSpace(cfg.KubeconfigPath) == "" && len(cfg.Clusters) == 0 {
+		return nil
+	}
+	clusters := make([]Cluster, 0, len(cfg.Clusters))
+	for _, c := range cfg.Clusters {
+		clusters = append(clusters, Cluster{
+			ID:             c.ID,
+			KubeconfigPath: strings.TrimSpace(c.KubeconfigPath),
+			Context:        strings.TrimSpace(c.Context),
+			ArgoCDAddr:     strings.TrimSpace(c.ArgoCDAddr),
+			ArgoCDToken:    c.ArgoCDToken,
+		})
+	}
+	return NewClusterRegistry(cfg.KubeconfigPath, clusters)
+}
+
+// Enabled reports whether calls must name a registered cluster.
+func (r *ClusterRegistry) Enabled() bool {
+	return r != nil && len(r.clusters) > 0
+}
+
+// IDs returns the registered cluster IDs in sorted order.
+func (r *ClusterRegistry) IDs() []string {
+	if r == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(r.clusters))
+	for id := range r.clusters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Resolve returns the cluster a call for tool in ref must run against.
+// Tools that don't talk to a cluster resolve to the zero Cluster.
+func (r *ClusterRegistry) Resolve(tool string, ref ContextRef) (Cluster, error) {
+	if r == nil || !clusterScopedTool(tool) {
+		return Cluster{}, nil
+	}
+	if !r.Enabled() {
+		return Cluster{KubeconfigPath: r.DefaultKubeconfig}, nil
+	}
+	id := strings.TrimSpace(ref.ClusterID)
+	c, ok := r.clusters[id]
+	if !ok {
+		if id == "" {
+			return Cluster{}, errors.New("cluster_id required")
+		}
+		return Cluster{}, ErrUnknownCluster
+	}
+	if c.KubeconfigPath == "" {
+		c.KubeconfigPath = r.DefaultKubeconfig
+	}
+	return c, nil
+}
+
+func clusterScopedTool(tool string) bool {
+	switch tool {
+	case "kubectl", "helm", "argocd":
+		return true
+	default:
+		return false
+	}
+}
+
+// Args inserts the cluster selection flags right after the binary name.
+func (c Cluster) Args(tool string, cmd []string) []string {
+	if len(cmd) == 0 {
+		return cmd
+	}
+	var flags []string
+	switch tool {
+	case "kubectl":
+		if c.KubeconfigPath != "" {
+			flags = append(flags, "--kubeconfig", c.KubeconfigPath)
+		}
+		if c.Context != "" {
+			flags = append(flags, "--context", c.Context)
+		}
+	case "helm":
+		if c.KubeconfigPath != "" {
+			flags = append(flags, "--kubeconfig", c.KubeconfigPath)
+		}
+		if c.Context != "" {
+			flags = append(flags, "--kube-context", c.Context)
+		}
+	case "argocd":
+		if c.ArgoCDAddr != "" {
+			flags = append(flags, "--server", argoServer(c.ArgoCDAddr))
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(c.ArgoCDAddr)), "http://") {
+				flags = append(flags, "--plaintext")
+			}
+		}
+	}
+	if len(flags) == 0 {
+		return cmd
+	}
+	out := make([]string, 0, len(cmd)+len(flags))
+	out = append(out, cmd[0])
+	out = append(out, flags...)
+	return append(out, cmd[1:]...)
+}
+
+// Env returns credentials that must only be visible to this call.
+func (c Cluster) Env(tool string) map[string]string {
+	if tool == "argocd" && c.ArgoCDToken != "" {
+		return map[string]string{"ARGOCD_AUTH_TOKEN": c.ArgoCDToken}
+	}
+	return nil
+}
+
+// Clients points the ArgoCD API fallback at this cluster's ArgoCD when one
+// is configured.
+func (c Cluster) Clients(clients HTTPClients) HTTPClients {
+	if c.ArgoCDAddr == "" {
+		return clients
+	}
+	argo := &APIClient{BaseURL: strings.TrimRight(c.ArgoCDAddr, "/"), Auth: AuthHeaders{BearerToken: c.ArgoCDToken}}
+	if base := clients.ArgoCD; base != nil {
+		argo.Client = base.Client
+		argo.Allowlist = base.Allowlist
+		argo.MaxOutputBytes = base.MaxOutputBytes
+		argo.Guard = base.Guard
+	}
+	clients.ArgoCD = argo
+	return clients
+}
+
+// argoServer strips the scheme since the argocd CLI expects host[:port].
+func argoServer(addr string) string {
+	addr = strings.TrimRight(strings.TrimSpace(addr), "/")
+	addr = strings.TrimPrefix(addr, "https://")
+	return strings.TrimPrefix(addr, "http://")
+}
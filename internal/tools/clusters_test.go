@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"carapulse/internal/config"
+)
+
+func TestClusterRegistryResolve(t *testing.T) {
+	var nilReg *ClusterRegistry
+	if c, err := nilReg.Resolve("kubectl", ContextRef{}); err != nil || c != (Cluster{}) {
+		t.Fatalf("nil registry: %+v %v", c, err)
+	}
+	if BuildClusterRegistry(config.K8sConfig{}) != nil {
+		t.Fatalf("expected nil registry")
+	}
+	single := BuildClusterRegistry(config.K8sConfig{KubeconfigPath: "/etc/kube/config"})
+	if c, err := single.Resolve("kubectl", ContextRef{ClusterID: "anything"}); err != nil || c.KubeconfigPath != "/etc/kube/config" {
+		t.Fatalf("single: %+v %v", c, err)
+	}
+
+	reg := BuildClusterRegistry(config.K8sConfig{
+		KubeconfigPath: "/etc/kube/config",
+		Clusters: []config.ClusterConfig{
+			{ID: "prod-eu", Context: "eu"},
+			{ID: "prod-us", KubeconfigPath: "/etc/kube/us", Context: "us", ArgoCDAddr: "https://argo-us:8080", ArgoCDToken: "tok"},
+		},
+	})
+	if got := reg.IDs(); !reflect.DeepEqual(got, []string{"prod-eu", "prod-us"}) {
+		t.Fatalf("ids: %v", got)
+	}
+	c, err := reg.Resolve("helm", ContextRef{ClusterID: "prod-eu"})
+	if err != nil || c.KubeconfigPath != "/etc/kube/config" || c.Context != "eu" {
+		t.Fatalf("resolve: %+v %v", c, err)
+	}
+	if _, err := reg.Resolve("kubectl", ContextRef{ClusterID: "staging"}); !errors.Is(err, ErrUnknownCluster) {
+		t.Fatalf("expected unknown cluster, got %v", err)
+	}
+	if _, err := reg.Resolve("kubectl", ContextRef{}); err == nil {
+		t.Fatalf("expected cluster_id required")
+	}
+	if c, err := reg.Resolve("prometheus", ContextRef{}); err != nil || c != (Cluster{}) {
+		t.Fatalf("non-cluster tool: %+v %v", c, err)
+	}
+}
+
+func TestClusterArgs(t *testing.T) {
+	c := Cluster{KubeconfigPath: "/k", Context: "ctx", ArgoCDAddr: "http://argo:8080/"}
+	cases := []struct {
+		tool string
+		cmd  []string
+		want []string
+	}{
+		{"kubectl", []string{"kubectl", "get", "pods"}, []string{"kubectl", "--kubeconfig", "/k", "--context", "ctx", "get", "pods"}},
+		{"helm", []string{"helm", "list"}, []string{"helm", "--kubeconfig", "/k", "--kube-context", "ctx", "list"}},
+		{"argocd", []string{"argocd", "app", "list"}, []string{"argocd", "--server", "argo:8080", "--plaintext", "app", "list"}},
+		{"aws", []string{"aws", "sts"}, []string{"aws", "sts"}},
+	}
+	for _, tc := range cases {
+		if got := c.Args(tc.tool, tc.cmd); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: %v", tc.tool, got)
+		}
+	}
+	if got := (Cluster{}).Args("kubectl", []string{"kubectl", "get"}); !reflect.DeepEqual(got, []string{"kubectl", "get"}) {
+		t.Fatalf("zero cluster: %v", got)
+	}
+}
+
+func TestClusterEnvAndClients(t *testing.T) {
+	c := Cluster{ArgoCDAddr: "https://argo-us/", ArgoCDToken: "tok"}
+	if env := c.Env("argocd"); env["ARGOCD_AUTH_TOKEN"] != "tok" {
+		t.Fatalf("env: %v", env)
+	}
+	if env := c.Env("kubectl"); env != nil {
+		t.Fatalf("unexpected env: %v", env)
+	}
+	guard := &BackendGuard{}
+	base := HTTPClients{ArgoCD: &APIClient{BaseURL: "https://argo-default", Auth: AuthHeaders{BearerToken: "global"}, Guard: guard}}
+	clients := c.Clients(base)
+	if clients.ArgoCD.BaseURL != "https://argo-us" || clients.ArgoCD.Auth.BearerToken != "tok" || clients.ArgoCD.Guard != guard {
+		t.Fatalf("clients: %+v", clients.ArgoCD)
+	}
+	if base.ArgoCD.BaseURL != "https://argo-default" {
+		t.Fatalf("base mutated")
+	}
+	if got := (Cluster{}).Clients(base); got.ArgoCD != base.ArgoCD {
+		t.Fatalf("expected base clients")
+	}
+}
+
+func TestRouterExecuteRoutesByCluster(t *testing.T) {
+	withFakeCLI(t, "kubectl")
+	withFakeCLI(t, "argocd")
+	router := NewRouter()
+	router.Clusters = NewClusterRegistry("", []Cluster{
+		{ID: "prod-eu", KubeconfigPath: "/k", Context: "eu"},
+		{ID: "prod-us", ArgoCDAddr: "https://argo-us", ArgoCDToken: "tok"},
+	})
+	var gotCmd []string
+	var gotEnv map[string]string
+	sandbox := &Sandbox{RunFunc: func(ctx context.Context, cmd []string) ([]byte, error) {
+		gotCmd = cmd
+		gotEnv = CallEnv(ctx)
+		return []byte("ok"), nil
+	}}
+	req := ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ContextRef{ClusterID: "prod-eu"}}
+	if _, err := router.Execute(context.Background(), req, sandbox, HTTPClients{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(gotCmd) < 5 || gotCmd[1] != "--kubeconfig" || gotCmd[3] != "--context" || gotCmd[4] != "eu" {
+		t.Fatalf("cmd: %v", gotCmd)
+	}
+	if len(gotEnv) != 0 {
+		t.Fatalf("kubectl env: %v", gotEnv)
+	}
+
+	req = ExecuteRequest{Tool: "argocd", Action: "list", Input: map[string]any{}, Context: ContextRef{ClusterID: "prod-us"}}
+	if _, err := router.Execute(context.Background(), req, sandbox, HTTPClients{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if gotEnv["ARGOCD_AUTH_TOKEN"] != "tok" {
+		t.Fatalf("argocd env: %v", gotEnv)
+	}
+	for _, arg := range gotCmd {
+		if arg == "tok" {
+			t.Fatalf("token leaked into argv: %v", gotCmd)
+		}
+	}
+
+	gotCmd = nil
+	req.Context.ClusterID = "staging"
+	resp, err := router.Execute(context.Background(), req, sandbox, HTTPClients{})
+	if !errors.Is(err, ErrUnknownCluster) || gotCmd != nil {
+		t.Fatalf("err=%v cmd=%v", err, gotCmd)
+	}
+	if resp.ToolCallID == "" {
+		t.Fatalf("missing tool call id")
+	}
+}
+
+func TestWithCallEnvMerges(t *testing.T) {
+	ctx := WithCallEnv(context.Background(), map[string]string{"A": "1"})
+	ctx = WithCallEnv(ctx, map[string]string{"B": "2"})
+	env := CallEnv(ctx)
+	if env["A"] != "1" || env["B"] != "2" {
+		t.Fatalf("env: %v", env)
+	}
+	if CallEnv(context.Background()) != nil {
+		t.Fatalf("expected empty env")
+	}
+}
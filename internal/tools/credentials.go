@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"carapulse/internal/awsauth"
+	"carapulse/internal/config"
+	"carapulse/internal/metrics"
+)
+
+// CredentialRequest identifies the tool call credentials are minted for.
+type CredentialRequest struct {
+	Tool        string
+	Action      string
+	ToolCallID  string
+	ExecutionID string
+	PlanID      string
+	StepID      string
+	Context     ContextRef
+}
+
+// Credential is a short-lived secret scoped to a single tool call. Env holds
+// the secret material; Subject and LeaseID are safe to record in audit.
+type Credential struct {
+	Provider   string
+	Subject    string
+	LeaseID    string
+	ExpiresAt  time.Time
+	Env        map[string]string
+	Kubeconfig string
+	// Revoke invalidates the credential early. Nil means it simply expires.
+	Revoke func(ctx context.Context) error
+}
+
+type CredentialProvider interface {
+	Name() string
+	Applies(tool string, ref ContextRef) bool
+	Issue(ctx context.Context, req CredentialRequest) (Credential, error)
+}
+
+type CredentialAuditWriter interface {
+	InsertAuditEvent(ctx context.Context, payload []byte) (string, error)
+}
+
+// CallCredentials is what a call sees of the credentials minted for it.
+type CallCredentials struct {
+	Env        map[string]string
+	Kubeconfig string
+}
+
+// CredentialBroker mints credentials from every provider that applies to a
+// call, and revokes them once the call finishes. Issue and release are
+// written to the audit trail with the plan and step that used them.
+type CredentialBroker struct {
+	Providers     []CredentialProvider
+	Audit         CredentialAuditWriter
+	RevokeTimeout time.Duration
+	now           func() time.Time
+}
+
+func NewCredentialBroker(providers ...CredentialProvider) *CredentialBroker {
+	return &CredentialBroker{Providers: providers}
+}
+
+// BuildCredentialBroker returns nil when brokering is disabled or no
+// provider is configured. vault is the Vault API client used to request
+// kubernetes service-account tokens.
+func BuildCredentialBroker(cfg config.Config, vault *APIClient) (*CredentialBroker, error) {
+	creds := cfg.ToolRouter.Credentials
+	if !creds.Enabled {
+		return nil, nil
+	}
+	var providers []CredentialProvider
+	roleARN := strings.TrimSpace(creds.AWS.RoleARN)
+	if roleARN == "" {
+		roleARN = strings.TrimSpace(cfg.Connectors.AWS.RoleARN)
+	}
+	if roleARN != "" {
+		providers = append(providers, &AWSRoleProvider{
+			RoleARN:    roleARN,
+			ExternalID: creds.AWS.ExternalID,
+			Duration:   time.Duration(creds.AWS.SessionSecs) * time.Second,
+			STS:        &awsauth.STSClient{Endpoint: creds.AWS.STSEndpoint, Region: creds.AWS.STSRegion},
+		})
+	}
+	for _, role := range creds.Vault.Kubernetes {
+		if vault == nil || strings.TrimSpace(vault.BaseURL) == "" {
+			return nil, errors.New("vault client required for kubernetes credentials")
+		}
+		providers = append(providers, &VaultKubernetesProvider{
+			Client:         vault,
+			ClusterID:      strings.TrimSpace(role.ClusterID),
+			Mount:          role.Mount,
+			Role:           role.Role,
+			KubernetesHost: role.KubernetesHost,
+			CAFile:         role.CAFile,
+			TTL:            time.Duration(role.TTLSecs) * time.Second,
+		})
+	}
+	if strings.TrimSpace(creds.GitHub.AppID) != "" {
+		key, err := os.ReadFile(creds.GitHub.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		gh, err := NewGitHubAppProvider(creds.GitHub.AppID, creds.GitHub.InstallationID, key, creds.GitHub.APIURL)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, gh)
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return NewCredentialBroker(providers...), nil
+}
+
+// Issue mints the credentials for req. The returned release func revokes
+// them and must be called once the call has finished. A provider failure
+// fails the call rather than falling back to static credentials.
+func (b *CredentialBroker) Issue(ctx context.Context, req CredentialRequest) (CallCredentials, func(), error) {
+	noop := func() {}
+	if b == nil {
+		return CallCredentials{}, noop, nil
+	}
+	var issued []Credential
+	release := func() {
+		for i := len(issued) - 1; i >= 0; i-- {
+			b.release(req, issued[i])
+		}
+	}
+	var out CallCredentials
+	for _, p := range b.Providers {
+		if p == nil || !p.Applies(req.Tool, req.Context) {
+			continue
+		}
+		cred, err := p.Issue(ctx, req)
+		if err != nil {
+			metrics.ToolCredentialsTotal.WithLabelValues(p.Name(), "failed").Inc()
+			b.audit(req, "credential.issue", "deny", Credential{Provider: p.Name()}, err.Error())
+			release()
+			return CallCredentials{}, noop, err
+		}
+		if cred.Provider == "" {
+			cred.Provider = p.Name()
+		}
+		metrics.ToolCredentialsTotal.WithLabelValues(cred.Provider, "issued").Inc()
+		b.audit(req, "credential.issue", "allow", cred, "")
+		issued = append(issued, cred)
+		out.Env = mergeEnv(out.Env, cred.Env)
+		if cred.Kubeconfig != "" {
+			out.Kubeconfig = cred.Kubeconfig
+		}
+	}
+	if len(issued) == 0 {
+		return CallCredentials{}, noop, nil
+	}
+	return out, release, nil
+}
+
+func (b *CredentialBroker) release(req CredentialRequest, cred Credential) {
+	if cred.Revoke == nil {
+		metrics.ToolCredentialsTotal.WithLabelValues(cred.Provider, "expiring").Inc()
+		b.audit(req, "credential.expire", "allow", cred, "")
+		return
+	}
+	timeout := b.RevokeTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	// The call's context may already be cancelled; revocation must still run.
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := cred.Revoke(ctx); err != nil {
+		metrics.ToolCredentialsTotal.WithLabelValues(cred.Provider, "revoke_failed").Inc()
+		b.audit(req, "credential.revoke", "error", cred, err.Error())
+		return
+	}
+	metrics.ToolCredentialsTotal.WithLabelValues(cred.Provider, "revoked").Inc()
+	b.audit(req, "credential.revoke", "allow", cred, "")
+}
+
+func (b *CredentialBroker) audit(req CredentialRequest, action, decision string, cred Credential, note string) {
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	ctxFields := map[string]any{
+		"plan_id":        req.PlanID,
+		"step_id":        req.StepID,
+		"execution_id":   req.ExecutionID,
+		"tool_call_id":   req.ToolCallID,
+		"tool":           req.Tool,
+		"action":         req.Action,
+		"provider":       cred.Provider,
+		"subject":        cred.Subject,
+		"lease_id":       cred.LeaseID,
+		"tenant_id":      req.Context.TenantID,
+		"environment":    req.Context.Environment,
+		"cluster_id":     req.Context.ClusterID,
+		"namespace":      req.Context.Namespace,
+		"aws_account_id": req.Context.AWSAccountID,
+	}
+	if !cred.ExpiresAt.IsZero() {
+		ctxFields["expires_at"] = cred.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if note != "" {
+		ctxFields["error"] = note
+	}
+	if b.Audit == nil {
+		slog.Info("tool credential", "action", action, "decision", decision, "provider", cred.Provider,
+			"plan_id", req.PlanID, "step_id", req.StepID, "tool_call_id", req.ToolCallID, "lease_id", cred.LeaseID)
+		return
+	}
+	payload, err := json.Marshal(map[string]any{
+		"occurred_at": now().UTC().Format(time.RFC3339),
+		"actor":       map[string]any{"type": "service", "id": "credential-broker"},
+		"action":      action,
+		"decision":    decision,
+		"context":     ctxFields,
+	})
+	if err != nil {
+		return
+	}
+	if _, err := b.Audit.InsertAuditEvent(context.Background(), payload); err != nil {
+		slog.Warn("credential audit failed", "action", action, "error", err)
+	}
+}
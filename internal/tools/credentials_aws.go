@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"carapulse/internal/awsauth"
+)
+
+var awsAccountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+var awsSessionNameUnsafe = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// AWSRoleProvider assumes a role per aws call. The STS client signs with
+// the router's own long-lived credentials, which never reach the sandbox.
+type AWSRoleProvider struct {
+	RoleARN    string
+	ExternalID string
+	Duration   time.Duration
+	STS        *awsauth.STSClient
+}
+
+func (p *AWSRoleProvider) Name() string { return "aws_sts" }
+
+func (p *AWSRoleProvider) Applies(tool string, ref ContextRef) bool {
+	if tool != "aws" {
+		return false
+	}
+	return strings.TrimSpace(ref.AWSAccountID) != "" || !strings.Contains(p.RoleARN, "{account_id}")
+}
+
+func (p *AWSRoleProvider) Issue(ctx context.Context, req CredentialRequest) (Credential, error) {
+	if p.STS == nil {
+		return Credential{}, errors.New("sts client required")
+	}
+	roleARN := p.RoleARN
+	if strings.Contains(roleARN, "{account_id}") {
+		account := strings.TrimSpace(req.Context.AWSAccountID)
+		if !awsAccountIDPattern.MatchString(account) {
+			return Credential{}, errors.New("invalid aws_account_id")
+		}
+		roleARN = strings.ReplaceAll(roleARN, "{account_id}", account)
+	}
+	duration := p.Duration
+	if duration < 15*time.Minute {
+		// STS rejects sessions shorter than 15 minutes.
+		duration = 15 * time.Minute
+	}
+	sts := *p.STS
+	if !sts.Credentials.Valid() {
+		sts.Credentials = awsauth.CredentialsFromEnv()
+	}
+	session := awsSessionName(req)
+	creds, err := sts.AssumeRole(ctx, awsauth.AssumeRoleInput{
+		RoleARN:         roleARN,
+		SessionName:     session,
+		ExternalID:      p.ExternalID,
+		DurationSeconds: int(duration / time.Second),
+	})
+	if err != nil {
+		return Credential{}, err
+	}
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":     creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY": creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN":     creds.SessionToken,
+	}
+	if region := strings.TrimSpace(req.Context.Region); region != "" {
+		env["AWS_REGION"] = region
+		env["AWS_DEFAULT_REGION"] = region
+	}
+	return Credential{
+		Provider:  p.Name(),
+		Subject:   roleARN,
+		LeaseID:   session,
+		ExpiresAt: creds.Expires,
+		Env:       env,
+	}, nil
+}
+
+// awsSessionName ties the role session to the call so CloudTrail entries
+// can be matched back to the plan step.
+func awsSessionName(req CredentialRequest) string {
+	id := req.ToolCallID
+	if id == "" {
+		id = req.ExecutionID
+	}
+	name := awsSessionNameUnsafe.ReplaceAllString("carapulse-"+id, "-")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubAppProvider exchanges a GitHub App JWT for an installation token
+// for each github call.
+type GitHubAppProvider struct {
+	AppID          string
+	InstallationID string
+	APIURL         string
+	Client         *http.Client
+	key            *rsa.PrivateKey
+	now            func() time.Time
+}
+
+func NewGitHubAppProvider(appID, installationID string, privateKeyPEM []byte, apiURL string) (*GitHubAppProvider, error) {
+	key, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	apiURL = strings.TrimRight(strings.TrimSpace(apiURL), "/")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+	return &GitHubAppProvider{
+		AppID:          strings.TrimSpace(appID),
+		InstallationID: strings.TrimSpace(installationID),
+		APIURL:         apiURL,
+		key:            key,
+	}, nil
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("github app private key: no PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("github app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key: not RSA")
+	}
+	return key, nil
+}
+
+func (p *GitHubAppProvider) Name() string { return "github_app" }
+
+func (p *GitHubAppProvider) Applies(tool string, ref ContextRef) bool {
+	return tool == "github"
+}
+
+func (p *GitHubAppProvider) Issue(ctx context.Context, req CredentialRequest) (Credential, error) {
+	jwt, err := p.appJWT()
+	if err != nil {
+		return Credential{}, err
+	}
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+		Message   string    `json:"message"`
+	}
+	status, err := p.call(ctx, http.MethodPost, "/app/installations/"+p.InstallationID+"/access_tokens", jwt, &out)
+	if err != nil {
+		return Credential{}, err
+	}
+	if status != http.StatusCreated || out.Token == "" {
+		return Credential{}, fmt.Errorf("github installation token: status %d: %s", status, out.Message)
+	}
+	token := out.Token
+	return Credential{
+		Provider:  p.Name(),
+		Subject:   "app/" + p.AppID + "/installations/" + p.InstallationID,
+		ExpiresAt: out.ExpiresAt,
+		Env:       map[string]string{"GH_TOKEN": token, "GITHUB_TOKEN": token},
+		Revoke: func(ctx context.Context) error {
+			status, err := p.call(ctx, http.MethodDelete, "/installation/token", token, nil)
+			if err != nil {
+				return err
+			}
+			if status != http.StatusNoContent {
+				return fmt.Errorf("github token revoke: status %d", status)
+			}
+			return nil
+		},
+	}, nil
+}
+
+func (p *GitHubAppProvider) call(ctx context.Context, method, path, bearer string, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.APIURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if out != nil && len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// appJWT signs the short-lived RS256 JWT GitHub requires to act as the app.
+func (p *GitHubAppProvider) appJWT() (string, error) {
+	if p.key == nil {
+		return "", errors.New("github app private key required")
+	}
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	t := now().UTC()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": t.Add(-time.Minute).Unix(),
+		"exp": t.Add(9 * time.Minute).Unix(),
+		"iss": p.AppID,
+	})
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,333 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"carapulse/internal/awsauth"
+	"carapulse/internal/config"
+)
+
+type fakeCredentialProvider struct {
+	name    string
+	tool    string
+	err     error
+	revoked *[]string
+}
+
+func (p *fakeCredentialProvider) Name() string { return p.name }
+
+func (p *fakeCredentialProvider) Applies(tool string, ref ContextRef) bool { return tool == p.tool }
+
+func (p *fakeCredentialProvider) Issue(ctx context.Context, req CredentialRequest) (Credential, error) {
+	if p.err != nil {
+		return Credential{}, p.err
+	}
+	return Credential{
+		LeaseID: p.name + "-lease",
+		Env:     map[string]string{strings.ToUpper(p.name) + "_TOKEN": "secret-" + p.name},
+		Revoke: func(ctx context.Context) error {
+			*p.revoked = append(*p.revoked, p.name)
+			return nil
+		},
+	}, nil
+}
+
+type fakeAuditWriter struct {
+	mu     sync.Mutex
+	events []map[string]any
+}
+
+func (f *fakeAuditWriter) InsertAuditEvent(ctx context.Context, payload []byte) (string, error) {
+	var ev map[string]any
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, ev)
+	return "audit", nil
+}
+
+func TestCredentialBrokerIssueAndRelease(t *testing.T) {
+	var revoked []string
+	audit := &fakeAuditWriter{}
+	broker := NewCredentialBroker(
+		&fakeCredentialProvider{name: "a", tool: "kubectl", revoked: &revoked},
+		&fakeCredentialProvider{name: "b", tool: "kubectl", revoked: &revoked},
+		&fakeCredentialProvider{name: "c", tool: "aws", revoked: &revoked},
+	)
+	broker.Audit = audit
+	req := CredentialRequest{Tool: "kubectl", PlanID: "plan_1", StepID: "step_1", ToolCallID: "call_1"}
+	creds, release, err := broker.Issue(context.Background(), req)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if creds.Env["A_TOKEN"] != "secret-a" || creds.Env["B_TOKEN"] != "secret-b" || creds.Env["C_TOKEN"] != "" {
+		t.Fatalf("env: %v", creds.Env)
+	}
+	release()
+	if strings.Join(revoked, ",") != "b,a" {
+		t.Fatalf("revoked: %v", revoked)
+	}
+	if len(audit.events) != 4 {
+		t.Fatalf("events: %d", len(audit.events))
+	}
+	for _, ev := range audit.events {
+		ctx := ev["context"].(map[string]any)
+		if ctx["plan_id"] != "plan_1" || ctx["step_id"] != "step_1" || ctx["tool_call_id"] != "call_1" {
+			t.Fatalf("context: %v", ctx)
+		}
+		data, _ := json.Marshal(ev)
+		if strings.Contains(string(data), "secret-") {
+			t.Fatalf("secret leaked into audit: %s", data)
+		}
+	}
+	if audit.events[0]["action"] != "credential.issue" || audit.events[3]["action"] != "credential.revoke" {
+		t.Fatalf("actions: %v %v", audit.events[0]["action"], audit.events[3]["action"])
+	}
+}
+
+func TestCredentialBrokerFailureRevokesIssued(t *testing.T) {
+	var revoked []string
+	audit := &fakeAuditWriter{}
+	broker := NewCredentialBroker(
+		&fakeCredentialProvider{name: "a", tool: "kubectl", revoked: &revoked},
+		&fakeCredentialProvider{name: "b", tool: "kubectl", err: errors.New("vault down"), revoked: &revoked},
+	)
+	broker.Audit = audit
+	if _, _, err := broker.Issue(context.Background(), CredentialRequest{Tool: "kubectl"}); err == nil {
+		t.Fatalf("expected error")
+	}
+	if strings.Join(revoked, ",") != "a" {
+		t.Fatalf("revoked: %v", revoked)
+	}
+	if audit.events[1]["decision"] != "deny" {
+		t.Fatalf("events: %v", audit.events)
+	}
+
+	var nilBroker *CredentialBroker
+	creds, release, err := nilBroker.Issue(context.Background(), CredentialRequest{Tool: "kubectl"})
+	if err != nil || creds.Env != nil {
+		t.Fatalf("nil broker: %+v %v", creds, err)
+	}
+	release()
+}
+
+func TestBuildCredentialBroker(t *testing.T) {
+	if b, err := BuildCredentialBroker(config.Config{}, nil); b != nil || err != nil {
+		t.Fatalf("disabled: %v %v", b, err)
+	}
+	cfg := config.Config{}
+	cfg.ToolRouter.Credentials.Enabled = true
+	cfg.Connectors.AWS.RoleARN = "arn:aws:iam::{account_id}:role/carapulse"
+	cfg.ToolRouter.Credentials.Vault.Kubernetes = []config.VaultKubernetesCredentialsConfig{{Role: "ops", KubernetesHost: "https://k8s"}}
+	if _, err := BuildCredentialBroker(cfg, nil); err == nil {
+		t.Fatalf("expected vault client error")
+	}
+	b, err := BuildCredentialBroker(cfg, &APIClient{BaseURL: "http://vault"})
+	if err != nil || len(b.Providers) != 2 {
+		t.Fatalf("broker: %+v %v", b, err)
+	}
+	if aws := b.Providers[0].(*AWSRoleProvider); aws.RoleARN != cfg.Connectors.AWS.RoleARN {
+		t.Fatalf("role: %s", aws.RoleARN)
+	}
+}
+
+func TestAWSRoleProvider(t *testing.T) {
+	var form string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		form = string(data)
+		_, _ = w.Write([]byte(`<AssumeRoleResponse><AssumeRoleResult><Credentials><AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>s</SecretAccessKey><SessionToken>tok</SessionToken><Expiration>2030-01-01T00:00:00Z</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`))
+	}))
+	defer srv.Close()
+	p := &AWSRoleProvider{
+		RoleARN: "arn:aws:iam::{account_id}:role/carapulse",
+		STS:     &awsauth.STSClient{Endpoint: srv.URL, Credentials: awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "x"}},
+	}
+	if p.Applies("aws", ContextRef{}) || p.Applies("kubectl", ContextRef{AWSAccountID: "123456789012"}) {
+		t.Fatalf("applies")
+	}
+	req := CredentialRequest{Tool: "aws", ToolCallID: "call_1", Context: ContextRef{AWSAccountID: "123456789012", Region: "eu-west-1"}}
+	cred, err := p.Issue(context.Background(), req)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if cred.Env["AWS_SESSION_TOKEN"] != "tok" || cred.Env["AWS_REGION"] != "eu-west-1" || cred.Revoke != nil {
+		t.Fatalf("cred: %+v", cred)
+	}
+	if cred.Subject != "arn:aws:iam::123456789012:role/carapulse" || cred.LeaseID != "carapulse-call_1" {
+		t.Fatalf("subject: %s lease: %s", cred.Subject, cred.LeaseID)
+	}
+	if !strings.Contains(form, "DurationSeconds=900") || !strings.Contains(form, "RoleSessionName=carapulse-call_1") {
+		t.Fatalf("form: %s", form)
+	}
+	req.Context.AWSAccountID = "123:role/admin"
+	if _, err := p.Issue(context.Background(), req); err == nil {
+		t.Fatalf("expected invalid account error")
+	}
+}
+
+func TestVaultKubernetesProvider(t *testing.T) {
+	var revokedLease string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/kubernetes/creds/ops":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["kubernetes_namespace"] != "payments" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["namespace"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"lease_id":"kubernetes/creds/ops/abc","lease_duration":600,"data":{"service_account_token":"sa-token","service_account_name":"sa"}}`))
+		case "/v1/sys/leases/revoke":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			revokedLease = body["lease_id"]
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	p := &VaultKubernetesProvider{Client: &APIClient{BaseURL: srv.URL}, ClusterID: "prod-eu", Role: "ops", KubernetesHost: "https://k8s:6443"}
+	if p.Applies("kubectl", ContextRef{ClusterID: "prod-us"}) || !p.Applies("helm", ContextRef{ClusterID: "prod-eu"}) {
+		t.Fatalf("applies")
+	}
+	cred, err := p.Issue(context.Background(), CredentialRequest{Tool: "kubectl", Context: ContextRef{ClusterID: "prod-eu", Namespace: "payments"}})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	data, err := os.ReadFile(cred.Kubeconfig)
+	if err != nil || !strings.Contains(string(data), "sa-token") || !strings.Contains(string(data), "https://k8s:6443") {
+		t.Fatalf("kubeconfig: %s %v", data, err)
+	}
+	if cred.Env["KUBECONFIG"] != cred.Kubeconfig || cred.LeaseID != "kubernetes/creds/ops/abc" {
+		t.Fatalf("cred: %+v", cred)
+	}
+	if err := cred.Revoke(context.Background()); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if revokedLease != "kubernetes/creds/ops/abc" {
+		t.Fatalf("revoked: %s", revokedLease)
+	}
+	if _, err := os.Stat(cred.Kubeconfig); !os.IsNotExist(err) {
+		t.Fatalf("kubeconfig not removed: %v", err)
+	}
+	if _, err := p.Issue(context.Background(), CredentialRequest{Tool: "kubectl", Context: ContextRef{Namespace: "other"}}); err == nil {
+		t.Fatalf("expected vault error")
+	}
+}
+
+func TestGitHubAppProvider(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	var revoked bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/app/installations/42/access_tokens":
+			if parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), "."); len(parts) != 3 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"token":"ghs_abc","expires_at":"2030-01-01T00:00:00Z"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/installation/token":
+			revoked = r.Header.Get("Authorization") == "Bearer ghs_abc"
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	p, err := NewGitHubAppProvider("7", "42", pemKey, srv.URL+"/")
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	cred, err := p.Issue(context.Background(), CredentialRequest{Tool: "github"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if cred.Env["GH_TOKEN"] != "ghs_abc" || !cred.ExpiresAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("cred: %+v", cred)
+	}
+	if err := cred.Revoke(context.Background()); err != nil || !revoked {
+		t.Fatalf("revoke: %v %v", err, revoked)
+	}
+	if _, err := NewGitHubAppProvider("7", "42", []byte("nope"), ""); err == nil {
+		t.Fatalf("expected key error")
+	}
+}
+
+func TestRouterExecuteInjectsCallCredentials(t *testing.T) {
+	withFakeCLI(t, "kubectl")
+	var revoked []string
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	router := NewRouter()
+	router.Clusters = NewClusterRegistry("", []Cluster{{ID: "prod-eu", KubeconfigPath: "/k", Context: "eu"}})
+	router.Credentials = NewCredentialBroker(&kubeconfigProvider{path: kubeconfig, revoked: &revoked})
+	var gotCmd []string
+	var gotEnv map[string]string
+	sandbox := &Sandbox{RunFunc: func(ctx context.Context, cmd []string) ([]byte, error) {
+		gotCmd = cmd
+		gotEnv = CallEnv(ctx)
+		if len(revoked) != 0 {
+			t.Fatalf("revoked before run")
+		}
+		return []byte("ok"), nil
+	}}
+	req := ExecuteRequest{Tool: "kubectl", Action: "get", Input: map[string]any{"resource": "pods"}, Context: ContextRef{ClusterID: "prod-eu"}}
+	if _, err := router.Execute(context.Background(), req, sandbox, HTTPClients{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if gotEnv["KUBECONFIG"] != kubeconfig || gotCmd[2] != kubeconfig {
+		t.Fatalf("cmd=%v env=%v", gotCmd, gotEnv)
+	}
+	for _, arg := range gotCmd {
+		if arg == "--context" {
+			t.Fatalf("brokered kubeconfig should replace the cluster context: %v", gotCmd)
+		}
+	}
+	if len(revoked) != 1 {
+		t.Fatalf("expected revoke after call: %v", revoked)
+	}
+}
+
+type kubeconfigProvider struct {
+	path    string
+	revoked *[]string
+}
+
+func (p *kubeconfigProvider) Name() string { return "kubeconfig" }
+
+func (p *kubeconfigProvider) Applies(tool string, ref ContextRef) bool { return tool == "kubectl" }
+
+func (p *kubeconfigProvider) Issue(ctx context.Context, req CredentialRequest) (Credential, error) {
+	return Credential{
+		Kubeconfig: p.path,
+		Env:        map[string]string{"KUBECONFIG": p.path},
+		Revoke: func(ctx context.Context) error {
+			*p.revoked = append(*p.revoked, req.ToolCallID)
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultKubernetesProvider requests a service-account token from Vault's
+// kubernetes secrets engine for kubectl and helm calls, and writes a
+// kubeconfig for it that only lives as long as the call.
+type VaultKubernetesProvider struct {
+	Client         *APIClient
+	ClusterID      string
+	Mount          string
+	Role           string
+	KubernetesHost string
+	CAFile         string
+	TTL            time.Duration
+	now            func() time.Time
+}
+
+func (p *VaultKubernetesProvider) Name() string { return "vault_kubernetes" }
+
+func (p *VaultKubernetesProvider) Applies(tool string, ref ContextRef) bool {
+	if tool != "kubectl" && tool != "helm" {
+		return false
+	}
+	return p.ClusterID == "" || p.ClusterID == strings.TrimSpace(ref.ClusterID)
+}
+
+type vaultLeaseResponse struct {
+	LeaseID       string `json:"lease_id"`
+	LeaseDuration int    `json:"lease_duration"`
+	Data          struct {
+		ServiceAccountToken string `json:"service_account_token"`
+		ServiceAccountName  string `json:"service_account_name"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+func (p *VaultKubernetesProvider) Issue(ctx context.Context, req CredentialRequest) (Credential, error) {
+	if p.Client == nil {
+		return Credential{}, errors.New("vault client required")
+	}
+	namespace := strings.TrimSpace(req.Context.Namespace)
+	if namespace == "" {
+		return Credential{}, errors.New("namespace required for kubernetes credentials")
+	}
+	mount := strings.Trim(strings.TrimSpace(p.Mount), "/")
+	if mount == "" {
+		mount = "kubernetes"
+	}
+	ttl := p.TTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	body := map[string]any{
+		"kubernetes_namespace": namespace,
+		"ttl":                  fmt.Sprintf("%ds", int(ttl/time.Second)),
+	}
+	var status int
+	data, err := p.Client.do(ctx, "POST", "/v1/"+mount+"/creds/"+url.PathEscape(p.Role), body, &status)
+	if err != nil {
+		return Credential{}, err
+	}
+	var lease vaultLeaseResponse
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return Credential{}, fmt.Errorf("vault kubernetes creds: %w", err)
+	}
+	if status/100 != 2 {
+		return Credential{}, fmt.Errorf("vault kubernetes creds: status %d: %s", status, strings.Join(lease.Errors, "; "))
+	}
+	if lease.Data.ServiceAccountToken == "" {
+		return Credential{}, errors.New("vault kubernetes creds: empty token")
+	}
+	revokeLease := func(ctx context.Context) error {
+		if lease.LeaseID == "" {
+			return nil
+		}
+		var status int
+		if _, err := p.Client.do(ctx, "PUT", "/v1/sys/leases/revoke", map[string]any{"lease_id": lease.LeaseID}, &status); err != nil {
+			return err
+		}
+		if status/100 != 2 {
+			return fmt.Errorf("vault lease revoke: status %d", status)
+		}
+		return nil
+	}
+	path, err := p.writeKubeconfig(namespace, lease.Data.ServiceAccountToken)
+	if err != nil {
+		_ = revokeLease(context.Background())
+		return Credential{}, err
+	}
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	expires := now().Add(ttl)
+	if lease.LeaseDuration > 0 {
+		expires = now().Add(time.Duration(lease.LeaseDuration) * time.Second)
+	}
+	return Credential{
+		Provider:   p.Name(),
+		Subject:    mount + "/roles/" + p.Role + ":" + lease.Data.ServiceAccountName,
+		LeaseID:    lease.LeaseID,
+		ExpiresAt:  expires,
+		Kubeconfig: path,
+		Env:        map[string]string{"KUBECONFIG": path},
+		Revoke: func(ctx context.Context) error {
+			_ = removeFile(path)
+			return revokeLease(ctx)
+		},
+	}, nil
+}
+
+func (p *VaultKubernetesProvider) writeKubeconfig(namespace, token string) (string, error) {
+	cluster := map[string]any{"server": p.KubernetesHost}
+	if p.CAFile != "" {
+		cluster["certificate-authority"] = p.CAFile
+	}
+	kubeconfig := map[string]any{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"current-context": "carapulse",
+		"clusters":        []any{map[string]any{"name": "carapulse", "cluster": cluster}},
+		"users":           []any{map[string]any{"name": "carapulse", "user": map[string]any{"token": token}}},
+		"contexts": []any{map[string]any{"name": "carapulse", "context": map[string]any{
+			"cluster":   "carapulse",
+			"user":      "carapulse",
+			"namespace": namespace,
+		}}},
+	}
+	data, err := json.Marshal(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	file, err := createTempFile("", "kubeconfig-*.json")
+	if err != nil {
+		return "", err
+	}
+	if _, err := writeTempFile(file, data); err != nil {
+		_ = closeTempFile(file)
+		_ = removeFile(file.Name())
+		return "", err
+	}
+	if err := closeTempFile(file); err != nil {
+		_ = removeFile(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
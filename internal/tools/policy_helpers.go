@@ -2,6 +2,12 @@ package tools
 
 import "strings"
 
+// ActionType classifies a tool action as "read" or "write", as the router
+// does for policy checks. Unknown tools and actions are writes.
+func ActionType(tool, action string) string {
+	return actionTypeForTool(tool, action)
+}
+
 func actionTypeForTool(tool, action string) string {
 	tool = strings.ToLower(strings.TrimSpace(tool))
 	action = strings.ToLower(strings.TrimSpace(action))
@@ -71,17 +77,6 @@ func riskForToolAction(tool, action string) string {
 	}
 }
 
-func tierForRisk(risk string) string {
-	switch strings.ToLower(strings.TrimSpace(risk)) {
-	case "read":
-		return "read"
-	case "low", "medium":
-		return "safe"
-	default:
-		return "break_glass"
-	}
-}
-
 func blastRadiusForContext(ctx ContextRef) string {
 	if strings.TrimSpace(ctx.Namespace) != "" {
 		return "namespace"
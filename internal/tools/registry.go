@@ -1,18 +1,33 @@
 package tools
 
+import "strings"
+
 type Tool struct {
 	Name        string
 	CLI         string
 	SupportsAPI bool
+	// HighRiskActions and MediumRiskActions are substrings of action names
+	// that carry that base risk when scoring a plan.
+	HighRiskActions   []string `json:",omitempty"`
+	MediumRiskActions []string `json:",omitempty"`
 }
 
 var Registry = []Tool{
-	{Name: "aws", CLI: "aws", SupportsAPI: true},
-	{Name: "vault", CLI: "vault", SupportsAPI: true},
-	{Name: "kubectl", CLI: "kubectl", SupportsAPI: true},
-	{Name: "helm", CLI: "helm", SupportsAPI: true},
+	{Name: "aws", CLI: "aws", SupportsAPI: true,
+		HighRiskActions:   []string{"delete", "terminate", "remove", "destroy", "put-role-policy", "create-role", "delete-role", "attach-role-policy"},
+		MediumRiskActions: []string{"update", "modify", "put", "create", "run"}},
+	{Name: "vault", CLI: "vault", SupportsAPI: true,
+		HighRiskActions: []string{"delete", "revoke", "destroy"}},
+	{Name: "kubectl", CLI: "kubectl", SupportsAPI: true,
+		HighRiskActions:   []string{"delete", "exec", "apply", "patch", "replace", "drain", "cordon", "taint"},
+		MediumRiskActions: []string{"scale", "rollout"}},
+	{Name: "helm", CLI: "helm", SupportsAPI: true,
+		HighRiskActions:   []string{"uninstall", "delete"},
+		MediumRiskActions: []string{"upgrade", "install", "rollback"}},
 	{Name: "boundary", CLI: "boundary", SupportsAPI: true},
-	{Name: "argocd", CLI: "argocd", SupportsAPI: true},
+	{Name: "argocd", CLI: "argocd", SupportsAPI: true,
+		HighRiskActions:   []string{"delete"},
+		MediumRiskActions: []string{"sync"}},
 	{Name: "git", CLI: "git", SupportsAPI: false},
 	{Name: "prometheus", CLI: "", SupportsAPI: true},
 	{Name: "alertmanager", CLI: "", SupportsAPI: true},
@@ -24,3 +39,42 @@ var Registry = []Tool{
 	{Name: "linear", CLI: "", SupportsAPI: true},
 	{Name: "pagerduty", CLI: "", SupportsAPI: true},
 }
+
+// readOnlyActions are verbs that never change anything, whatever the tool.
+var readOnlyActions = map[string]bool{
+	"get": true, "list": true, "describe": true, "status": true, "query": true, "search": true, "show": true,
+}
+
+// ActionRisk is the base risk of running action with tool: "high" or
+// "medium" when the action matches the tool's registered patterns, "read"
+// for read-only verbs and "low" for any other action.
+func ActionRisk(tool, action string) string {
+	tool = strings.ToLower(strings.TrimSpace(tool))
+	action = strings.ToLower(strings.TrimSpace(action))
+	var high, medium []string
+	for _, t := range Registry {
+		if t.Name == tool {
+			high, medium = t.HighRiskActions, t.MediumRiskActions
+			break
+		}
+	}
+	switch {
+	case matchesAnyAction(action, high):
+		return "high"
+	case matchesAnyAction(action, medium):
+		return "medium"
+	case action == "" || readOnlyActions[action]:
+		return "read"
+	default:
+		return "low"
+	}
+}
+
+func matchesAnyAction(action string, patterns []string) bool {
+	for _, p := range patterns {
+		if strings.Contains(action, p) {
+			return true
+		}
+	}
+	return false
+}
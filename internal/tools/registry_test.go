@@ -12,3 +12,26 @@ func TestRegistryNonEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestActionRisk(t *testing.T) {
+	cases := []struct {
+		tool, action, want string
+	}{
+		{"prometheus", "query", "read"},
+		{"kubectl", "get", "read"},
+		{"kubectl", "delete", "high"},
+		{"kubectl", "exec", "high"},
+		{"helm", "upgrade", "medium"},
+		{"helm", "uninstall", "high"},
+		{"argocd", "sync", "medium"},
+		{"argocd", "delete", "high"},
+		{"aws", "terminate-instances", "high"},
+		{"grafana", "annotate", "low"},
+		{"Kubectl", " Rollout ", "medium"},
+	}
+	for _, c := range cases {
+		if got := ActionRisk(c.tool, c.action); got != c.want {
+			t.Fatalf("%s %s: want %s got %s", c.tool, c.action, c.want, got)
+		}
+	}
+}
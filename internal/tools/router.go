@@ -12,7 +12,12 @@ var ErrNoCLI = errors.New("cli not available")
 type Router struct {
 	Logs     *LogHub
 	Redactor *Redactor
-	logsOnce sync.Once
+	Cache    *ResultCache
+	Guard    *BackendGuard
+	Clusters *ClusterRegistry
+	// Credentials mints per-call credentials for CLI calls when set.
+	Credentials *CredentialBroker
+	logsOnce    sync.Once
 }
 
 func NewRouter() *Router {
@@ -38,6 +43,13 @@ func (r *Router) logHub() *LogHub {
 	return r.Logs
 }
 
+func (r *Router) credentials() *CredentialBroker {
+	if r == nil {
+		return nil
+	}
+	return r.Credentials
+}
+
 func (r *Router) redactor() *Redactor {
 	if r == nil {
 		return nil
@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type RouterClient struct {
@@ -39,6 +41,9 @@ func (c *RouterClient) Execute(ctx context.Context, req ExecuteRequest) (Execute
 		return ExecuteResponse{}, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return ExecuteResponse{}, decodeBackendUnavailable(req.Tool, resp)
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return ExecuteResponse{}, errors.New("tool router status " + resp.Status)
 	}
@@ -48,3 +53,16 @@ func (c *RouterClient) Execute(ctx context.Context, req ExecuteRequest) (Execute
 	}
 	return out, nil
 }
+
+// decodeBackendUnavailable turns a 503 from the router into a typed,
+// retryable error so callers don't need to inspect HTTP status codes.
+func decodeBackendUnavailable(tool string, resp *http.Response) error {
+	out := &BackendUnavailableError{Backend: tool, Reason: "unavailable"}
+	_ = json.NewDecoder(resp.Body).Decode(out)
+	if out.RetryAfter <= 0 {
+		if secs, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && secs > 0 {
+			out.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return out
+}
@@ -13,6 +13,8 @@ type ExecuteRequest struct {
 	Input       any
 	ToolCallID  string
 	ExecutionID string
+	PlanID      string
+	StepID      string
 	Context     ContextRef
 }
 
@@ -45,6 +47,54 @@ func (r *Router) Execute(ctx context.Context, req ExecuteRequest, sandbox *Sandb
 	if sandbox.RequireEgressAllowlist && !sandbox.Enabled {
 		return ExecuteResponse{ToolCallID: callID}, errors.New("sandbox required for egress")
 	}
+	var cluster Cluster
+	if r != nil {
+		cluster, err = r.Clusters.Resolve(tool.Name, req.Context)
+		if err != nil {
+			return ExecuteResponse{ToolCallID: callID}, err
+		}
+	}
+	ctx = WithCallEnv(ctx, cluster.Env(tool.Name))
+	clients = cluster.Clients(clients)
+	if r != nil && r.Cache != nil {
+		if actionType == "read" {
+			resp, err := r.Cache.Do(req, func() (ExecuteResponse, error) {
+				return r.guardedDispatch(ctx, req, tool, cluster, callID, sandbox, clients)
+			})
+			resp.ToolCallID = callID
+			return resp, err
+		}
+		defer r.Cache.BeginWrite(tool.Name, req.Context)()
+	}
+	return r.guardedDispatch(ctx, req, tool, cluster, callID, sandbox, clients)
+}
+
+func (r *Router) guardedDispatch(ctx context.Context, req ExecuteRequest, tool *Tool, cluster Cluster, callID string, sandbox *Sandbox, clients HTTPClients) (ExecuteResponse, error) {
+	if r == nil || r.Guard == nil {
+		return r.dispatch(ctx, req, tool, cluster, callID, sandbox, clients)
+	}
+	var resp ExecuteResponse
+	err := r.Guard.RunClassified(ctx, tool.Name, func() error {
+		var err error
+		resp, err = r.dispatch(ctx, req, tool, cluster, callID, sandbox, clients)
+		return err
+	}, func(err error) bool {
+		switch resp.Used {
+		case "cli":
+			return isCLIBackendFailure(err, resp.Output)
+		case "api":
+			return isBackendFailure(err)
+		}
+		// Credential, argument and input errors fail before any backend call.
+		return false
+	})
+	if resp.ToolCallID == "" {
+		resp.ToolCallID = callID
+	}
+	return resp, err
+}
+
+func (r *Router) dispatch(ctx context.Context, req ExecuteRequest, tool *Tool, cluster Cluster, callID string, sandbox *Sandbox, clients HTTPClients) (ExecuteResponse, error) {
 	hub := r.logHub()
 	redactor := r.redactor()
 	started := LogLine{
@@ -74,7 +124,26 @@ func (r *Router) Execute(ctx context.Context, req ExecuteRequest, sandbox *Sandb
 			if cleanup != nil {
 				defer cleanup()
 			}
-			cmd := buildCmd(tool.Name, req.Action, input)
+			creds, release, err := r.credentials().Issue(ctx, CredentialRequest{
+				Tool:        tool.Name,
+				Action:      req.Action,
+				ToolCallID:  callID,
+				ExecutionID: strings.TrimSpace(req.ExecutionID),
+				PlanID:      strings.TrimSpace(req.PlanID),
+				StepID:      strings.TrimSpace(req.StepID),
+				Context:     req.Context,
+			})
+			if err != nil {
+				return ExecuteResponse{ToolCallID: callID}, err
+			}
+			defer release()
+			ctx := WithCallEnv(ctx, creds.Env)
+			if creds.Kubeconfig != "" {
+				// The brokered kubeconfig carries its own server and context.
+				cluster.KubeconfigPath = creds.Kubeconfig
+				cluster.Context = ""
+			}
+			cmd := cluster.Args(tool.Name, buildCmd(tool.Name, req.Action, input))
 			if err := ValidateToolArgs(cmd); err != nil {
 				return ExecuteResponse{ToolCallID: callID}, err
 			}
@@ -110,6 +110,7 @@ func (s *Sandbox) Run(ctx context.Context, cmd []string) ([]byte, error) {
 	if s != nil && len(s.Env) > 0 {
 		env = mergeEnv(env, s.Env)
 	}
+	env = mergeEnv(env, CallEnv(ctx))
 	c := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
 	if len(env) > 0 {
 		c.Env = append(os.Environ(), formatEnv(env)...)
@@ -185,6 +186,15 @@ func (s *Sandbox) runContainer(ctx context.Context, cmd []string) ([]byte, error
 		}
 		args = append(args, "-e", fmt.Sprintf("%s=%s", key, val))
 	}
+	// Per-call values are passed through the runtime's environment rather
+	// than its argv so they don't show up in process listings.
+	callEnv := CallEnv(ctx)
+	for key := range callEnv {
+		if strings.TrimSpace(key) == "" {
+			continue
+		}
+		args = append(args, "-e", key)
+	}
 	for _, mount := range s.Mounts {
 		if strings.TrimSpace(mount) == "" {
 			continue
@@ -194,6 +204,9 @@ func (s *Sandbox) runContainer(ctx context.Context, cmd []string) ([]byte, error
 	args = append(args, s.Image)
 	args = append(args, cmd...)
 	c := exec.CommandContext(ctx, runtime, args...)
+	if len(callEnv) > 0 {
+		c.Env = append(os.Environ(), formatEnv(callEnv)...)
+	}
 	out, err := c.CombinedOutput()
 	return s.limitOutput(out), err
 }
@@ -206,6 +219,27 @@ func (s *Sandbox) limitOutput(output []byte) []byte {
 	return append(trimmed, []byte("...(truncated)")...)
 }
 
+type callEnvKey struct{}
+
+// WithCallEnv attaches environment variables that apply only to tool calls
+// made with the returned context. Later values override earlier ones.
+func WithCallEnv(ctx context.Context, env map[string]string) context.Context {
+	if len(env) == 0 {
+		return ctx
+	}
+	merged := mergeEnv(mergeEnv(nil, CallEnv(ctx)), env)
+	return context.WithValue(ctx, callEnvKey{}, merged)
+}
+
+// CallEnv returns the per-call environment attached by WithCallEnv.
+func CallEnv(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	env, _ := ctx.Value(callEnvKey{}).(map[string]string)
+	return env
+}
+
 func mergeEnv(base map[string]string, extra map[string]string) map[string]string {
 	if base == nil {
 		base = map[string]string{}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"carapulse/internal/policy"
+	"carapulse/internal/risk"
 )
 
 type Server struct {
@@ -85,8 +88,8 @@ func (s *Server) authorize(ctx *http.Request, tool, action string, ctxRef Contex
 		return errors.New("policy required")
 	}
 	actionType := actionTypeForTool(tool, action)
-	risk := riskForToolAction(tool, action)
-	tier := tierForRisk(risk)
+	level := riskForToolAction(tool, action)
+	tier := risk.TierFor(level)
 	blast := blastRadiusForContext(ctxRef)
 	breakGlass := strings.EqualFold(strings.TrimSpace(ctx.Header.Get("X-Break-Glass")), "true")
 	if err := validateContextRefStrict(ctxRef); err != nil {
@@ -103,7 +106,7 @@ func (s *Server) authorize(ctx *http.Request, tool, action string, ctxRef Contex
 		Actor:     actor,
 		Action:    policy.Action{Name: "tool.execute", Type: actionType},
 		Context:   ctxRef,
-		Risk:      policy.Risk{Level: risk, Tier: tier, BlastRadius: blast},
+		Risk:      policy.Risk{Level: level, Tier: tier, BlastRadius: blast},
 		Time:      time.Now().UTC().Format(time.RFC3339),
 		Resources: map[string]any{"break_glass": breakGlass},
 	})
@@ -113,7 +116,7 @@ func (s *Server) authorize(ctx *http.Request, tool, action string, ctxRef Contex
 		}
 		return err
 	}
-	if risk == "high" && !breakGlass {
+	if level == "high" && !breakGlass {
 		return errors.New("break-glass required")
 	}
 	switch dec.Decision {
@@ -154,12 +157,27 @@ func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
 	}
 	resp, err := s.Router.Execute(r.Context(), req, s.Sandbox, s.Clients)
 	if err != nil {
+		var unavailable *BackendUnavailableError
+		if errors.As(err, &unavailable) {
+			writeBackendUnavailable(w, unavailable)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+func writeBackendUnavailable(w http.ResponseWriter, err *BackendUnavailableError) {
+	secs := int(math.Ceil(err.RetryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(w).Encode(err)
+}
+
 func (s *Server) handleListTools(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -40,6 +40,26 @@ func validateExecuteRequest(req ExecuteRequest) (*Tool, error) {
 	}
 }
 
+// ValidatePlanStep checks a drafted plan step: the tool must be registered
+// and the input must match the tool's schema for action. Execution-time
+// argument checks still run when the step is executed.
+func ValidatePlanStep(tool, action string, input any) error {
+	tool = strings.ToLower(strings.TrimSpace(tool))
+	if tool == "" {
+		return errToolRequired
+	}
+	if strings.TrimSpace(action) == "" {
+		return errActionRequired
+	}
+	if findTool(tool) == nil {
+		return fmt.Errorf("unknown tool %q", tool)
+	}
+	if raw, ok := input.(json.RawMessage); ok && len(raw) == 0 {
+		input = nil
+	}
+	return validateSchema(tool, strings.TrimSpace(action), input)
+}
+
 func validateContextRefStrict(ctx ContextRef) error {
 	if strings.TrimSpace(ctx.TenantID) == "" {
 		return errors.New("tenant_id required")
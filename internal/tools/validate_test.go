@@ -332,3 +332,18 @@ func TestIntFromAnyOK(t *testing.T) {
 		t.Fatalf("expected false")
 	}
 }
+
+func TestValidatePlanStep(t *testing.T) {
+	if err := ValidatePlanStep("helm", "status", json.RawMessage(`{"release":"app"}`)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := ValidatePlanStep("helm", "status", json.RawMessage(nil)); err == nil {
+		t.Fatalf("expected missing release error")
+	}
+	if err := ValidatePlanStep("nope", "status", nil); err == nil {
+		t.Fatalf("expected unknown tool error")
+	}
+	if err := ValidatePlanStep("helm", " ", nil); err == nil {
+		t.Fatalf("expected action error")
+	}
+}
@@ -0,0 +1,389 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"carapulse/internal/risk"
+	"carapulse/internal/tools"
+)
+
+// DefaultApprovalTTL is how long an approval stays pending when its risk
+// level has no TTL of its own.
+const DefaultApprovalTTL = 24 * time.Hour
+
+// ApprovalSweepStore lists pending approvals and records what the sweeper
+// did with them.
+type ApprovalSweepStore interface {
+	ListPendingApprovals(ctx context.Context) ([]byte, error)
+	ExpireApproval(ctx context.Context, approvalID string) (bool, error)
+	MarkApprovalReminded(ctx context.Context, approvalID string, at time.Time) error
+	MarkApprovalEscalated(ctx context.Context, approvalID string, at time.Time) error
+}
+
+// FiringAlertCounter counts alerts firing since a point in time; any means
+// an incident is under way.
+type FiringAlertCounter interface {
+	CountFiringAlerts(ctx context.Context, since time.Time) (int, error)
+}
+
+// ApprovalNotifier delivers a reminder to a chat channel or user.
+type ApprovalNotifier interface {
+	NotifyApproval(ctx context.Context, channel, text string) error
+}
+
+// PendingApproval is an approval still waiting for a decision.
+type PendingApproval struct {
+	ApprovalID  string         `json:"approval_id"`
+	PlanID      string         `json:"plan_id"`
+	Source      string         `json:"source"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+	RemindedAt  time.Time      `json:"reminded_at"`
+	EscalatedAt time.Time      `json:"escalated_at"`
+	RiskLevel   string         `json:"risk_level"`
+	Summary     string         `json:"summary"`
+	Intent      string         `json:"intent"`
+	Context     ContextRef     `json:"context"`
+	Constraints map[string]any `json:"constraints"`
+	CreatedBy   string         `json:"created_by"`
+	SessionID   string         `json:"session_id"`
+}
+
+// approvalTTLFor returns the TTL for approvals at the given risk level.
+func (s *Server) approvalTTLFor(level string) time.Duration {
+	if ttl := s.ApprovalTTLs[strings.ToLower(strings.TrimSpace(level))]; ttl > 0 {
+		return ttl
+	}
+	return DefaultApprovalTTL
+}
+
+// ApprovalTTL returns how long an approval of the plan stays pending, by
+// the plan's risk level.
+func (s *Server) ApprovalTTL(ctx context.Context, planID string) time.Duration {
+	if s.DB == nil {
+		return s.approvalTTLFor("")
+	}
+	payload, err := s.DB.GetPlan(ctx, planID)
+	if err != nil || payload == nil {
+		return s.approvalTTLFor("")
+	}
+	var plan struct {
+		RiskLevel string `json:"risk_level"`
+	}
+	_ = json.Unmarshal(payload, &plan)
+	return s.approvalTTLFor(plan.RiskLevel)
+}
+
+// ApprovalExpired reports whether the plan's latest approval has expired.
+func (s *Server) ApprovalExpired(ctx context.Context, planID string) bool {
+	status, err := s.approvalStatus(ctx, planID, "")
+	return err == nil && status == "expired"
+}
+
+// ExpireApproval marks the plan's approvals expired on behalf of source,
+// such as the Linear watcher. Expiring an already expired plan is a no-op.
+func (s *Server) ExpireApproval(ctx context.Context, planID, source string) error {
+	if s.DB == nil {
+		return errors.New("db unavailable")
+	}
+	if s.ApprovalExpired(ctx, planID) {
+		return nil
+	}
+	if err := s.DB.UpdateApprovalStatusByPlan(ctx, planID, "expired"); err != nil {
+		return err
+	}
+	s.approvalExpired(ctx, PendingApproval{PlanID: planID, Source: source})
+	return nil
+}
+
+// approvalExpired audits an expiry and tells the plan's watchers.
+func (s *Server) approvalExpired(ctx context.Context, p PendingApproval) {
+	data := map[string]any{
+		"plan_id":     p.PlanID,
+		"approval_id": p.ApprovalID,
+		"status":      "expired",
+		"source":      p.Source,
+	}
+	if !p.ExpiresAt.IsZero() {
+		data["expires_at"] = p.ExpiresAt
+	}
+	s.auditEvent(ctx, "approval.expire", "allow", data, "")
+	s.emit("approval.expired", data, p.SessionID)
+	s.emit("plan.updated", map[string]any{"plan_id": p.PlanID}, p.SessionID)
+}
+
+// ApprovalSweeper expires pending approvals once their TTL has passed,
+// reminds the requested approvers over Slack every RemindEvery and pages
+// PagerDuty when a high-risk approval has waited EscalateAfter while alerts
+// are firing.
+type ApprovalSweeper struct {
+	Server   *Server
+	Store    ApprovalSweepStore
+	Notifier ApprovalNotifier
+	// RemindEvery spaces reminders; zero disables them.
+	RemindEvery time.Duration
+	// SlackChannels maps approver groups to the channels or users to remind;
+	// SlackChannel is reminded when none of the plan's groups is mapped.
+	SlackChannel  string
+	SlackChannels map[string]string
+	// RoutingKey is the PagerDuty Events API v2 integration key; empty
+	// disables escalation.
+	RoutingKey    string
+	EscalateAfter time.Duration
+	// IncidentWindow is how recently an alert must have fired to count.
+	IncidentWindow time.Duration
+	PollInterval   time.Duration
+	Now            func() time.Time
+}
+
+func NewApprovalSweeper(s *Server, store ApprovalSweepStore) *ApprovalSweeper {
+	return &ApprovalSweeper{
+		Server:         s,
+		Store:          store,
+		EscalateAfter:  30 * time.Minute,
+		IncidentWindow: 15 * time.Minute,
+		PollInterval:   time.Minute,
+		Now:            time.Now,
+	}
+}
+
+func (a *ApprovalSweeper) Run(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if a.Server == nil || a.Store == nil {
+		return errors.New("server and store required")
+	}
+	if a.PollInterval <= 0 {
+		a.PollInterval = time.Minute
+	}
+	if _, err := a.RunOnce(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(a.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := a.RunOnce(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// RunOnce makes one pass over pending approvals and returns how many it
+// expired. Slack and PagerDuty failures are logged and retried on the next
+// pass; store failures are returned.
+func (a *ApprovalSweeper) RunOnce(ctx context.Context) (int, error) {
+	if a.Server == nil || a.Store == nil {
+		return 0, errors.New("server and store required")
+	}
+	if a.Now == nil {
+		a.Now = time.Now
+	}
+	data, err := a.Store.ListPendingApprovals(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var pending []PendingApproval
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &pending); err != nil {
+			return 0, err
+		}
+	}
+	now := a.Now().UTC()
+	incident := -1
+	expired := 0
+	for _, p := range pending {
+		if !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt) {
+			ok, err := a.Store.ExpireApproval(ctx, p.ApprovalID)
+			if err != nil {
+				return expired, err
+			}
+			if ok {
+				a.Server.approvalExpired(ctx, p)
+				expired++
+			}
+			continue
+		}
+		if err := a.remind(ctx, p, now); err != nil {
+			return expired, err
+		}
+		if !a.shouldEscalate(p, now) {
+			continue
+		}
+		if incident < 0 {
+			if incident, err = a.firingAlerts(ctx, now); err != nil {
+				return expired, err
+			}
+		}
+		if incident == 0 {
+			continue
+		}
+		if err := a.escalate(ctx, p, now, incident); err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// remind notifies the plan's approvers when RemindEvery has passed since
+// the approval was opened or they were last reminded.
+func (a *ApprovalSweeper) remind(ctx context.Context, p PendingApproval, now time.Time) error {
+	if a.Notifier == nil || a.RemindEvery <= 0 {
+		return nil
+	}
+	last := p.RemindedAt
+	if last.IsZero() {
+		last = p.CreatedAt
+	}
+	if last.IsZero() || now.Sub(last) < a.RemindEvery {
+		return nil
+	}
+	rule := a.Server.quorumFor(p.RiskLevel, p.Constraints)
+	text := reminderText(p, rule, now)
+	sent := false
+	for _, channel := range a.reminderChannels(rule) {
+		if err := a.Notifier.NotifyApproval(ctx, channel, text); err != nil {
+			slog.Warn("approval reminder failed", "plan_id", p.PlanID, "channel", channel, "error", err)
+			continue
+		}
+		sent = true
+	}
+	if !sent {
+		return nil
+	}
+	return a.Store.MarkApprovalReminded(ctx, p.ApprovalID, now)
+}
+
+// reminderChannels maps the rule's approver groups to channels, falling
+// back to SlackChannel.
+func (a *ApprovalSweeper) reminderChannels(rule QuorumRule) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, group := range rule.Groups {
+		for name, channel := range a.SlackChannels {
+			channel = strings.TrimSpace(channel)
+			if !strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(group)) || channel == "" || seen[channel] {
+				continue
+			}
+			seen[channel] = true
+			out = append(out, channel)
+		}
+	}
+	if len(out) == 0 && strings.TrimSpace(a.SlackChannel) != "" {
+		out = append(out, strings.TrimSpace(a.SlackChannel))
+	}
+	return out
+}
+
+func reminderText(p PendingApproval, rule QuorumRule, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Approval pending for plan %s (%s risk)", p.PlanID, firstNonEmpty(p.RiskLevel, "unknown"))
+	if summary := firstNonEmpty(p.Summary, p.Intent); summary != "" {
+		fmt.Fprintf(&b, ": %s", summary)
+	}
+	if !p.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "\nWaiting %s", roundDuration(now.Sub(p.CreatedAt)))
+	}
+	if !p.ExpiresAt.IsZero() {
+		fmt.Fprintf(&b, ", expires in %s", roundDuration(p.ExpiresAt.Sub(now)))
+	}
+	fmt.Fprintf(&b, ".\nNeeds %d approval(s)", rule.Required)
+	if len(rule.Groups) > 0 {
+		fmt.Fprintf(&b, " from %s", strings.Join(rule.Groups, ", "))
+	}
+	fmt.Fprintf(&b, ". Approve with: approve %s", p.PlanID)
+	return b.String()
+}
+
+// roundDuration renders d to the minute, e.g. "2h", "45m" or "1h30m".
+func roundDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
+
+func (a *ApprovalSweeper) shouldEscalate(p PendingApproval, now time.Time) bool {
+	if strings.TrimSpace(a.RoutingKey) == "" || a.Server.ToolRouter == nil {
+		return false
+	}
+	if !strings.EqualFold(strings.TrimSpace(p.RiskLevel), risk.LevelHigh) || !p.EscalatedAt.IsZero() || p.CreatedAt.IsZero() {
+		return false
+	}
+	return now.Sub(p.CreatedAt) >= a.EscalateAfter
+}
+
+func (a *ApprovalSweeper) firingAlerts(ctx context.Context, now time.Time) (int, error) {
+	counter, ok := a.Store.(FiringAlertCounter)
+	if !ok {
+		return 0, nil
+	}
+	window := a.IncidentWindow
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+	return counter.CountFiringAlerts(ctx, now.Add(-window))
+}
+
+// escalate triggers a PagerDuty incident for the approval through the
+// pagerduty tool. The dedup key keeps retries to one incident.
+func (a *ApprovalSweeper) escalate(ctx context.Context, p PendingApproval, now time.Time, firing int) error {
+	waiting := roundDuration(now.Sub(p.CreatedAt))
+	summary := fmt.Sprintf("High-risk approval for plan %s pending %s during an active incident", p.PlanID, waiting)
+	details := map[string]any{
+		"plan_id":       p.PlanID,
+		"approval_id":   p.ApprovalID,
+		"risk_level":    p.RiskLevel,
+		"summary":       firstNonEmpty(p.Summary, p.Intent),
+		"waiting":       waiting,
+		"firing_alerts": firing,
+	}
+	if !p.ExpiresAt.IsZero() {
+		details["expires_at"] = p.ExpiresAt
+	}
+	_, err := a.Server.ToolRouter.Execute(ctx, tools.ExecuteRequest{
+		Tool:   "pagerduty",
+		Action: "create",
+		Input: map[string]any{
+			"routing_key":  strings.TrimSpace(a.RoutingKey),
+			"event_action": "trigger",
+			"dedup_key":    "carapulse-approval-" + p.ApprovalID,
+			"payload": map[string]any{
+				"summary":        summary,
+				"source":         "carapulse",
+				"severity":       "critical",
+				"custom_details": details,
+			},
+		},
+		PlanID:  p.PlanID,
+		Context: toToolContext(p.Context),
+	})
+	if err != nil {
+		slog.Warn("approval escalation failed", "plan_id", p.PlanID, "error", err)
+		return nil
+	}
+	if err := a.Store.MarkApprovalEscalated(ctx, p.ApprovalID, now); err != nil {
+		return err
+	}
+	data := map[string]any{"plan_id": p.PlanID, "approval_id": p.ApprovalID, "risk_level": p.RiskLevel, "firing_alerts": firing}
+	a.Server.auditEvent(ctx, "approval.escalate", "allow", data, "")
+	a.Server.emit("approval.escalated", data, p.SessionID)
+	return nil
+}
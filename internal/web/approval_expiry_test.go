@@ -0,0 +1,192 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"carapulse/internal/policy"
+)
+
+// sweepDB serves pending approvals and records what the sweeper does.
+type sweepDB struct {
+	fakeDB
+	pending   []PendingApproval
+	firing    int
+	created   []byte
+	expired   []string
+	reminded  map[string]time.Time
+	escalated map[string]time.Time
+}
+
+func (s *sweepDB) CreateApproval(ctx context.Context, planID string, payload []byte) (string, error) {
+	s.created = payload
+	return "approval_1", nil
+}
+
+func (s *sweepDB) ListPendingApprovals(ctx context.Context) ([]byte, error) {
+	return json.Marshal(s.pending)
+}
+
+func (s *sweepDB) ExpireApproval(ctx context.Context, approvalID string) (bool, error) {
+	s.expired = append(s.expired, approvalID)
+	return true, nil
+}
+
+func (s *sweepDB) MarkApprovalReminded(ctx context.Context, approvalID string, at time.Time) error {
+	s.reminded[approvalID] = at
+	return nil
+}
+
+func (s *sweepDB) MarkApprovalEscalated(ctx context.Context, approvalID string, at time.Time) error {
+	s.escalated[approvalID] = at
+	return nil
+}
+
+func (s *sweepDB) CountFiringAlerts(ctx context.Context, since time.Time) (int, error) {
+	return s.firing, nil
+}
+
+type fakeNotifier struct {
+	sent map[string]string
+	err  error
+}
+
+func (f *fakeNotifier) NotifyApproval(ctx context.Context, channel, text string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent[channel] = text
+	return nil
+}
+
+func newSweepDB() *sweepDB {
+	return &sweepDB{reminded: map[string]time.Time{}, escalated: map[string]time.Time{}}
+}
+
+func TestCreateApprovalExpiresByRiskTier(t *testing.T) {
+	db := newSweepDB()
+	db.planID = "plan_1"
+	db.lastPlan = []byte(`{"plan_id":"plan_1","risk_level":"high"}`)
+	server := &Server{DB: db, ApprovalTTLs: map[string]time.Duration{"high": 2 * time.Hour}}
+	if _, err := server.createApproval(context.Background(), "plan_1", false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var payload struct {
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	_ = json.Unmarshal(db.created, &payload)
+	if ttl := time.Until(payload.ExpiresAt); ttl < 119*time.Minute || ttl > 2*time.Hour {
+		t.Fatalf("expires_at: %s", payload.ExpiresAt)
+	}
+	if ttl := server.ApprovalTTL(context.Background(), "plan_unknown"); ttl != DefaultApprovalTTL {
+		t.Fatalf("default ttl: %s", ttl)
+	}
+}
+
+func TestApprovalSweeperExpiresRemindsAndEscalates(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	db := newSweepDB()
+	db.firing = 1
+	db.pending = []PendingApproval{
+		{ApprovalID: "a1", PlanID: "plan_1", RiskLevel: "medium", CreatedAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(-time.Minute), SessionID: "sess_1"},
+		{ApprovalID: "a2", PlanID: "plan_2", RiskLevel: "high", Summary: "drop replica", CreatedAt: now.Add(-90 * time.Minute), ExpiresAt: now.Add(30 * time.Minute)},
+		{ApprovalID: "a3", PlanID: "plan_3", RiskLevel: "low", CreatedAt: now.Add(-2 * time.Hour), RemindedAt: now.Add(-10 * time.Minute), ExpiresAt: now.Add(time.Hour)},
+	}
+	audit := &auditLog{}
+	router := &fakeToolRunner{}
+	server := &Server{DB: db, Audit: audit, ToolRouter: router, ApprovalQuorum: map[string]QuorumRule{"high": {Required: 2, Groups: []string{"SRE", "dba"}}}}
+	events, cancel := server.eventHub().Subscribe("sess_1")
+	defer cancel()
+	notifier := &fakeNotifier{sent: map[string]string{}}
+	sweeper := NewApprovalSweeper(server, db)
+	sweeper.Notifier = notifier
+	sweeper.RemindEvery = time.Hour
+	sweeper.SlackChannel = "#approvals"
+	sweeper.SlackChannels = map[string]string{"sre": "#sre-oncall", "dba": "#sre-oncall"}
+	sweeper.RoutingKey = "R0UT1NG"
+	sweeper.Now = func() time.Time { return now }
+
+	expired, err := sweeper.RunOnce(context.Background())
+	if err != nil || expired != 1 || len(db.expired) != 1 || db.expired[0] != "a1" {
+		t.Fatalf("expired=%d err=%v ids=%v", expired, err, db.expired)
+	}
+	if event := audit.find("approval.expire"); event == nil {
+		t.Fatalf("expiry not audited: %+v", audit.events)
+	}
+	select {
+	case ev := <-events:
+		if ev.Event != "approval.expired" || ev.Data.(map[string]any)["plan_id"] != "plan_1" {
+			t.Fatalf("event: %+v", ev)
+		}
+	default:
+		t.Fatalf("no approval.expired event")
+	}
+
+	// Only a2 is due a reminder, sent once to the channel of its groups.
+	text, ok := notifier.sent["#sre-oncall"]
+	if len(notifier.sent) != 1 || !ok || !strings.Contains(text, "plan_2 (high risk): drop replica") || !strings.Contains(text, "Waiting 1h30m, expires in 30m") || !strings.Contains(text, "from SRE, dba") {
+		t.Fatalf("sent: %+v", notifier.sent)
+	}
+	if !db.reminded["a2"].Equal(now) || len(db.reminded) != 1 {
+		t.Fatalf("reminded: %+v", db.reminded)
+	}
+
+	req := router.req
+	input, _ := req.Input.(map[string]any)
+	if req.Tool != "pagerduty" || req.Action != "create" || req.PlanID != "plan_2" || input["routing_key"] != "R0UT1NG" || input["dedup_key"] != "carapulse-approval-a2" {
+		t.Fatalf("escalation: %+v", req)
+	}
+	if len(db.escalated) != 1 || audit.find("approval.escalate") == nil {
+		t.Fatalf("escalated: %+v", db.escalated)
+	}
+}
+
+func TestApprovalSweeperEscalatesOnlyDuringIncidents(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	db := newSweepDB()
+	db.pending = []PendingApproval{{ApprovalID: "a1", PlanID: "plan_1", RiskLevel: "high", CreatedAt: now.Add(-time.Hour)}}
+	router := &fakeToolRunner{}
+	sweeper := NewApprovalSweeper(&Server{DB: db, ToolRouter: router}, db)
+	sweeper.RoutingKey = "R0UT1NG"
+	sweeper.Now = func() time.Time { return now }
+	if _, err := sweeper.RunOnce(context.Background()); err != nil || router.req.Tool != "" {
+		t.Fatalf("escalated without incident: err=%v req=%+v", err, router.req)
+	}
+
+	// A failed page is retried on the next pass.
+	db.firing = 3
+	router.err = errors.New("pagerduty down")
+	if _, err := sweeper.RunOnce(context.Background()); err != nil || len(db.escalated) != 0 {
+		t.Fatalf("err=%v escalated=%+v", err, db.escalated)
+	}
+	router.err = nil
+	if _, err := sweeper.RunOnce(context.Background()); err != nil || len(db.escalated) != 1 {
+		t.Fatalf("err=%v escalated=%+v", err, db.escalated)
+	}
+}
+
+func TestExpireApprovalAndVoteAfterExpiry(t *testing.T) {
+	db := &fakeDB{planID: "plan_1", approvalStatus: "pending"}
+	audit := &auditLog{}
+	server := &Server{Mux: http.NewServeMux(), DB: db, Audit: audit, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	if err := server.ExpireApproval(context.Background(), "plan_1", "linear"); err != nil || db.updateStatus != "expired" {
+		t.Fatalf("err=%v status=%s", err, db.updateStatus)
+	}
+	if event := audit.find("approval.expire"); event == nil {
+		t.Fatalf("expiry not audited")
+	}
+
+	db.approvalStatus = "expired"
+	db.updateStatus = ""
+	if err := server.ExpireApproval(context.Background(), "plan_1", "linear"); err != nil || db.updateStatus != "" {
+		t.Fatalf("expired twice: err=%v status=%s", err, db.updateStatus)
+	}
+	if w := approve(server, bearerFor("alice"), "plan_1"); w.Code != http.StatusConflict {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
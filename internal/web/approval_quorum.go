@@ -0,0 +1,239 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"carapulse/internal/attest"
+	"carapulse/internal/policy"
+)
+
+// ApprovalVoteStore records who approved which revision of a plan.
+type ApprovalVoteStore interface {
+	RecordApprovalVote(ctx context.Context, planID string, payload []byte) error
+	ListApprovalVotes(ctx context.Context, planID string) ([]byte, error)
+}
+
+// QuorumRule is the number of distinct approvers a write needs, each in at
+// least one of Groups when Groups is set. The plan author never counts.
+type QuorumRule struct {
+	Required int      `json:"required"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// strict reports whether the rule needs more than the single approval
+// status that Linear and auto-approval already provide.
+func (q QuorumRule) strict() bool {
+	return q.Required > 1 || len(q.Groups) > 0
+}
+
+// ApprovalVote is one approver's decision on one plan revision.
+type ApprovalVote struct {
+	PlanHash       string    `json:"plan_hash"`
+	ApproverID     string    `json:"approver_id"`
+	ApproverEmail  string    `json:"approver_email,omitempty"`
+	ApproverGroups []string  `json:"approver_groups,omitempty"`
+	Decision       string    `json:"decision"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+var errApprovalVotesUnavailable = errors.New("approval votes unavailable")
+
+// quorumFor resolves the rule for a plan at the given risk level. Policy
+// constraints can raise the count with approvals_required and name the
+// groups with approver_groups; neither can lower the configured rule.
+func (s *Server) quorumFor(level string, constraints map[string]any) QuorumRule {
+	rule := s.ApprovalQuorum[strings.ToLower(strings.TrimSpace(level))]
+	if rule.Required < 1 {
+		rule.Required = 1
+	}
+	if n := intFromAny(constraints["approvals_required"]); n > rule.Required {
+		rule.Required = n
+	}
+	if groups := stringSliceFromAny(constraints["approver_groups"]); len(groups) > 0 {
+		rule.Groups = groups
+	}
+	return rule
+}
+
+func planAuthor(plan map[string]any) string {
+	author, _ := plan["created_by"].(string)
+	return strings.TrimSpace(author)
+}
+
+func inApproverGroups(groups, roles []string) bool {
+	if len(groups) == 0 {
+		return true
+	}
+	for _, group := range groups {
+		for _, role := range roles {
+			if strings.EqualFold(strings.TrimSpace(group), strings.TrimSpace(role)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkApprover enforces separation of duties: the plan author may not
+// approve their own plan and approvers must hold one of the rule's groups.
+func checkApprover(actor Actor, author string, rule QuorumRule) error {
+	if author != "" && strings.EqualFold(strings.TrimSpace(actor.ID), author) {
+		return &policy.DeniedError{Decision: "deny", Reasons: []policy.Reason{{
+			Rule:    "separation_of_duties",
+			Message: "the plan author cannot approve their own plan",
+			Field:   "actor.id",
+		}}}
+	}
+	if rule.strict() && strings.TrimSpace(actor.ID) == "" {
+		return &policy.DeniedError{Decision: "deny", Reasons: []policy.Reason{{
+			Rule:    "approver_identity",
+			Message: "quorum approvals need an identified approver",
+			Field:   "actor.id",
+		}}}
+	}
+	if !inApproverGroups(rule.Groups, actor.Roles) {
+		return &policy.DeniedError{Decision: "deny", Reasons: []policy.Reason{{
+			Rule:    "approver_group",
+			Message: "approver must be in one of: " + strings.Join(rule.Groups, ", "),
+			Field:   "actor.roles",
+		}}}
+	}
+	return nil
+}
+
+func (s *Server) recordApprovalVote(ctx context.Context, planID string, vote ApprovalVote) error {
+	store, ok := s.DB.(ApprovalVoteStore)
+	if !ok {
+		return errApprovalVotesUnavailable
+	}
+	payload, err := json.Marshal(vote)
+	if err != nil {
+		return err
+	}
+	return store.RecordApprovalVote(ctx, planID, payload)
+}
+
+func (s *Server) approvalVotes(ctx context.Context, planID string) ([]ApprovalVote, error) {
+	store, ok := s.DB.(ApprovalVoteStore)
+	if !ok {
+		return nil, errApprovalVotesUnavailable
+	}
+	data, err := store.ListApprovalVotes(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	var votes []ApprovalVote
+	if err := json.Unmarshal(data, &votes); err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+// quorumApprovers returns the approvers that count towards rule for the
+// plan revision identified by hash, in the order they approved.
+func quorumApprovers(votes []ApprovalVote, hash, author string, rule QuorumRule) []ApprovalVote {
+	seen := map[string]bool{}
+	var out []ApprovalVote
+	for _, vote := range votes {
+		id := strings.ToLower(strings.TrimSpace(vote.ApproverID))
+		if vote.PlanHash != hash || vote.Decision != "approved" || id == "" || seen[id] {
+			continue
+		}
+		if author != "" && id == strings.ToLower(author) {
+			continue
+		}
+		if !inApproverGroups(rule.Groups, vote.ApproverGroups) {
+			continue
+		}
+		seen[id] = true
+		out = append(out, vote)
+	}
+	return out
+}
+
+type approvalProgress struct {
+	Hash      string
+	Approvals int
+	Required  int
+}
+
+// voteOnPlan records the caller's decision on the plan's current revision
+// and reports how far the approval quorum has got. Approving is subject to
+// checkApprover and is attested when a signer is configured; anyone allowed
+// to approve may deny.
+func (s *Server) voteOnPlan(ctx context.Context, planID string, plan map[string]any, decision string) (approvalProgress, error) {
+	actor, _ := ActorFromContext(ctx)
+	intent, _ := plan["intent"].(string)
+	level, _ := plan["risk_level"].(string)
+	rule := s.quorumFor(level, constraintsFromPlan(plan))
+	author := planAuthor(plan)
+	steps := s.planSteps(ctx, planID, plan)
+	progress := approvalProgress{Hash: ComputePlanHash(intent, steps), Required: rule.Required}
+	if decision == "approved" {
+		if err := checkApprover(actor, author, rule); err != nil {
+			return progress, err
+		}
+		if err := s.attestPlan(ctx, planID, plan, steps, actor, attest.SourceAPI); err != nil {
+			return progress, err
+		}
+	}
+	if decision != "approved" && decision != "denied" {
+		return progress, nil
+	}
+	if strings.TrimSpace(actor.ID) != "" {
+		err := s.recordApprovalVote(ctx, planID, ApprovalVote{
+			PlanHash:       progress.Hash,
+			ApproverID:     actor.ID,
+			ApproverEmail:  actor.Email,
+			ApproverGroups: actor.Roles,
+			Decision:       decision,
+			CreatedAt:      time.Now().UTC(),
+		})
+		// Without a vote store a denial still settles the plan and the
+		// default rule still works from the approval status alone.
+		if errors.Is(err, errApprovalVotesUnavailable) && (decision == "denied" || !rule.strict()) {
+			err = nil
+		}
+		if err != nil {
+			return progress, err
+		}
+	}
+	if decision == "denied" {
+		return progress, nil
+	}
+	if !rule.strict() {
+		progress.Approvals = 1
+		return progress, nil
+	}
+	votes, err := s.approvalVotes(ctx, planID)
+	if err != nil {
+		return progress, err
+	}
+	progress.Approvals = len(quorumApprovers(votes, progress.Hash, author, rule))
+	return progress, nil
+}
+
+// checkQuorum is the execution gate for strict rules: enough distinct
+// approvers must have approved exactly the revision about to run.
+func (s *Server) checkQuorum(ctx context.Context, planID, hash, author string, rule QuorumRule) error {
+	if !rule.strict() {
+		return nil
+	}
+	votes, err := s.approvalVotes(ctx, planID)
+	if err != nil {
+		return err
+	}
+	if n := len(quorumApprovers(votes, hash, author, rule)); n < rule.Required {
+		return &policy.DeniedError{Decision: "deny", Reasons: []policy.Reason{{
+			Rule:    "approval_quorum",
+			Message: fmt.Sprintf("%d of %d required approvals for the current plan", n, rule.Required),
+			Field:   "approval",
+		}}}
+	}
+	return nil
+}
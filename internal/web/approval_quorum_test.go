@@ -0,0 +1,198 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"carapulse/internal/policy"
+)
+
+// voteDB keeps approval votes in memory alongside a plan.
+type voteDB struct {
+	fakeDB
+	votes []ApprovalVote
+}
+
+func (v *voteDB) RecordApprovalVote(ctx context.Context, planID string, payload []byte) error {
+	var vote ApprovalVote
+	if err := json.Unmarshal(payload, &vote); err != nil {
+		return err
+	}
+	for i := range v.votes {
+		if v.votes[i].PlanHash == vote.PlanHash && v.votes[i].ApproverID == vote.ApproverID {
+			v.votes[i] = vote
+			return nil
+		}
+	}
+	v.votes = append(v.votes, vote)
+	return nil
+}
+
+func (v *voteDB) ListApprovalVotes(ctx context.Context, planID string) ([]byte, error) {
+	return json.Marshal(v.votes)
+}
+
+func bearerFor(sub string, groups ...string) string {
+	claims, _ := json.Marshal(map[string]any{"sub": sub, "email": sub + "@example.com", "groups": groups})
+	return "Bearer aaa." + base64.RawURLEncoding.EncodeToString(claims) + ".bbb"
+}
+
+func quorumPlanDB(t *testing.T, status string) *voteDB {
+	plan := map[string]any{"plan_id": "plan_1", "risk_level": "medium", "intent": "restart api", "context": validContext(), "created_by": "u"}
+	return &voteDB{fakeDB: fakeDB{planID: "plan_1", lastPlan: mustPlanJSON(t, plan), approvalStatus: status}}
+}
+
+func quorumServer(db DBWriter, audit AuditWriter) *Server {
+	return &Server{
+		Mux:            http.NewServeMux(),
+		DB:             db,
+		Audit:          audit,
+		Policy:         &policy.Evaluator{Checker: allowChecker{}},
+		ApprovalQuorum: map[string]QuorumRule{"medium": {Required: 2, Groups: []string{"sre"}}},
+	}
+}
+
+func approve(server *Server, token, planID string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(ApprovalCreateRequest{PlanID: planID, Status: "approved"})
+	req := httptest.NewRequest(http.MethodPost, "/v1/approvals", bytes.NewReader(body))
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleApprovals)).ServeHTTP(w, req)
+	return w
+}
+
+func deniedRule(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp PolicyDeniedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Reasons) != 1 {
+		t.Fatalf("decode: %v %s", err, w.Body.String())
+	}
+	return resp.Reasons[0].Rule
+}
+
+func TestHandleApprovalsRejectsPlanAuthor(t *testing.T) {
+	db := quorumPlanDB(t, "pending")
+	audit := &auditLog{}
+	server := quorumServer(db, audit)
+	server.ApprovalQuorum = nil
+	w := approve(server, testToken, "plan_1")
+	if rule := deniedRule(t, w); rule != "separation_of_duties" {
+		t.Fatalf("rule: %s", rule)
+	}
+	if db.updateStatus != "" || len(db.votes) != 0 {
+		t.Fatalf("approval recorded: %s %+v", db.updateStatus, db.votes)
+	}
+	if event := audit.find("approval.create"); event == nil || event["decision"] != "deny" {
+		t.Fatalf("audit: %+v", audit.events)
+	}
+
+	// Without a quorum rule a single other approver is enough.
+	w = approve(server, bearerFor("alice"), "plan_1")
+	if w.Code != http.StatusOK || db.updateStatus != "approved" {
+		t.Fatalf("status=%d update=%s body=%s", w.Code, db.updateStatus, w.Body.String())
+	}
+	if len(db.votes) != 1 || db.votes[0].ApproverID != "alice" || db.votes[0].ApproverEmail != "alice@example.com" || db.votes[0].CreatedAt.IsZero() {
+		t.Fatalf("votes: %+v", db.votes)
+	}
+}
+
+func TestHandleApprovalsQuorum(t *testing.T) {
+	db := quorumPlanDB(t, "pending")
+	server := quorumServer(db, nil)
+	progress := func(w *httptest.ResponseRecorder) map[string]any {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+		}
+		var out map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return out
+	}
+	out := progress(approve(server, bearerFor("alice", "sre"), "plan_1"))
+	if out["status"] != "pending" || out["approvals"] != float64(1) || out["required"] != float64(2) || db.updateStatus != "" {
+		t.Fatalf("first approval: %+v update=%s", out, db.updateStatus)
+	}
+	// Approving twice still counts once.
+	if out := progress(approve(server, bearerFor("alice", "sre"), "plan_1")); out["approvals"] != float64(1) {
+		t.Fatalf("repeat approval: %+v", out)
+	}
+	if rule := deniedRule(t, approve(server, bearerFor("bob", "dev"), "plan_1")); rule != "approver_group" {
+		t.Fatalf("rule: %s", rule)
+	}
+	out = progress(approve(server, bearerFor("carol", "SRE"), "plan_1"))
+	if out["status"] != "approved" || out["approvals"] != float64(2) || db.updateStatus != "approved" {
+		t.Fatalf("quorum: %+v update=%s", out, db.updateStatus)
+	}
+	if len(db.votes) != 2 || db.votes[1].ApproverGroups[0] != "SRE" {
+		t.Fatalf("votes: %+v", db.votes)
+	}
+}
+
+func TestHandlePlanExecuteRequiresQuorum(t *testing.T) {
+	db := quorumPlanDB(t, "approved")
+	audit := &auditLog{}
+	server := quorumServer(db, audit)
+	hash := ComputePlanHash("restart api", nil)
+	db.votes = []ApprovalVote{
+		{PlanHash: hash, ApproverID: "alice", ApproverGroups: []string{"sre"}, Decision: "approved"},
+		{PlanHash: "stale", ApproverID: "carol", ApproverGroups: []string{"sre"}, Decision: "approved"},
+		{PlanHash: hash, ApproverID: "u", ApproverGroups: []string{"sre"}, Decision: "approved"},
+	}
+	execute := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+		req.Header.Set("Authorization", testToken)
+		w := httptest.NewRecorder()
+		AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+		return w
+	}
+	if rule := deniedRule(t, execute()); rule != "approval_quorum" {
+		t.Fatalf("rule: %s", rule)
+	}
+	if db.execID != "" {
+		t.Fatalf("execution created without quorum")
+	}
+	if event := audit.find("plan.execute"); event == nil || event["decision"] != "deny" {
+		t.Fatalf("audit: %+v", audit.events)
+	}
+	db.votes = append(db.votes, ApprovalVote{PlanHash: hash, ApproverID: "dave", ApproverGroups: []string{"sre"}, Decision: "approved"})
+	if w := execute(); w.Code != http.StatusOK || db.execID == "" {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlePlanExecuteQuorumNeedsVoteStore(t *testing.T) {
+	plan := map[string]any{"plan_id": "plan_1", "risk_level": "medium", "context": validContext()}
+	db := &fakeDB{planID: "plan_1", lastPlan: mustPlanJSON(t, plan), approvalStatus: "approved"}
+	req := httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(quorumServer(db, nil).handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable || db.execID != "" {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestQuorumFor(t *testing.T) {
+	server := &Server{ApprovalQuorum: map[string]QuorumRule{"medium": {Required: 2}}}
+	if rule := server.quorumFor("low", nil); rule.Required != 1 || rule.strict() {
+		t.Fatalf("low: %+v", rule)
+	}
+	if rule := server.quorumFor("Medium", nil); rule.Required != 2 || !rule.strict() {
+		t.Fatalf("medium: %+v", rule)
+	}
+	rule := server.quorumFor("medium", map[string]any{"approvals_required": float64(3), "approver_groups": []any{"dba"}})
+	if rule.Required != 3 || len(rule.Groups) != 1 || rule.Groups[0] != "dba" {
+		t.Fatalf("constraints: %+v", rule)
+	}
+	if rule := server.quorumFor("medium", map[string]any{"approvals_required": 1}); rule.Required != 2 {
+		t.Fatalf("constraint lowered quorum: %+v", rule)
+	}
+}
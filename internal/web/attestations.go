@@ -0,0 +1,132 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"carapulse/internal/attest"
+)
+
+// ApprovalAttestationStore keeps signed approval attestations.
+type ApprovalAttestationStore interface {
+	RecordApprovalAttestation(ctx context.Context, planID string, payload []byte) error
+	ListApprovalAttestations(ctx context.Context, planID string) ([]byte, error)
+}
+
+// AutoApprover is the approver recorded for auto-approved plans.
+const AutoApprover = "auto-approve"
+
+var errAttestationFailed = errors.New("approval attestation failed")
+
+// AttestApproval signs the plan's current revision as approved by approver
+// and stores the attestation. It is a no-op when no signer is configured.
+func (s *Server) AttestApproval(ctx context.Context, planID string, approver Actor, source string) error {
+	if s.Attestations == nil {
+		return nil
+	}
+	payload, err := s.DB.GetPlan(ctx, planID)
+	if err != nil {
+		return err
+	}
+	if payload == nil {
+		return fmt.Errorf("%w: plan %s not found", errAttestationFailed, planID)
+	}
+	var plan map[string]any
+	if err := json.Unmarshal(payload, &plan); err != nil {
+		return err
+	}
+	return s.attestPlan(ctx, planID, plan, s.planSteps(ctx, planID, plan), approver, source)
+}
+
+// attestPlan signs plan with steps as its current revision. The statement
+// hash is the one the execute handler and the orchestrator recompute.
+func (s *Server) attestPlan(ctx context.Context, planID string, plan map[string]any, steps []PlanStep, approver Actor, source string) error {
+	if s.Attestations == nil {
+		return nil
+	}
+	store, ok := s.DB.(ApprovalAttestationStore)
+	if !ok {
+		return fmt.Errorf("%w: attestation store unavailable", errAttestationFailed)
+	}
+	intent, _ := plan["intent"].(string)
+	signed := attestSteps(steps)
+	statement := attest.Statement{
+		PlanID:     planID,
+		PlanHash:   attest.PlanHash(intent, signed),
+		Intent:     intent,
+		Steps:      signed,
+		Context:    plan["context"],
+		Approver:   attest.Approver{ID: strings.TrimSpace(approver.ID), Email: approver.Email, Groups: approver.Roles},
+		Decision:   "approved",
+		Source:     source,
+		ApprovedAt: time.Now().UTC(),
+	}
+	att, err := attest.Sign(ctx, s.Attestations, statement)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errAttestationFailed, err)
+	}
+	data, err := json.Marshal(att)
+	if err != nil {
+		return err
+	}
+	if err := store.RecordApprovalAttestation(ctx, planID, data); err != nil {
+		return fmt.Errorf("%w: %v", errAttestationFailed, err)
+	}
+	return nil
+}
+
+func attestSteps(steps []PlanStep) []attest.Step {
+	out := make([]attest.Step, len(steps))
+	for i, step := range steps {
+		out[i] = attest.Step(step)
+	}
+	return out
+}
+
+// handlePlanAttestations serves GET /v1/plans/{id}/attestations.
+func (s *Server) handlePlanAttestations(w http.ResponseWriter, r *http.Request, planID string) {
+	if s.DB == nil {
+		http.Error(w, "db unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store, ok := s.DB.(ApprovalAttestationStore)
+	if !ok {
+		http.Error(w, "attestations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	payload, err := s.DB.GetPlan(r.Context(), planID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if payload == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var plan map[string]any
+	if err := json.Unmarshal(payload, &plan); err != nil {
+		http.Error(w, "decode error", http.StatusInternalServerError)
+		return
+	}
+	if _, err := s.policyCheckReadPlan(r, plan, "plan.attestations"); err != nil {
+		writePolicyDenied(w, err)
+		return
+	}
+	data, err := store.ListApprovalAttestations(r.Context(), planID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if len(data) == 0 {
+		data = []byte("[]")
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"plan_id":      planID,
+		"attestations": json.RawMessage(data),
+	})
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"carapulse/internal/attest"
+)
+
+// attestDB keeps attestations in memory alongside votes.
+type attestDB struct {
+	voteDB
+	attestations []json.RawMessage
+}
+
+func (a *attestDB) RecordApprovalAttestation(ctx context.Context, planID string, payload []byte) error {
+	a.attestations = append(a.attestations, json.RawMessage(payload))
+	return nil
+}
+
+func (a *attestDB) ListApprovalAttestations(ctx context.Context, planID string) ([]byte, error) {
+	return json.Marshal(a.attestations)
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Sign(ctx context.Context, payload []byte) (string, []byte, error) {
+	return "", nil, errors.New("vault sealed")
+}
+
+func attestPlanDB(t *testing.T) *attestDB {
+	t.Helper()
+	steps := []any{map[string]any{"step_id": "s1", "action": "scale", "tool": "kubectl", "input": map[string]any{"replicas": 3}}}
+	plan := map[string]any{"plan_id": "plan_1", "risk_level": "medium", "intent": "scale api", "context": validContext(), "created_by": "u", "steps": steps}
+	return &attestDB{voteDB: voteDB{fakeDB: fakeDB{planID: "plan_1", lastPlan: mustPlanJSON(t, plan), approvalStatus: "pending"}}}
+}
+
+func TestHandleApprovalsSignsAttestation(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	db := attestPlanDB(t)
+	server := quorumServer(db, nil)
+	server.ApprovalQuorum = nil
+	server.Attestations = attest.NewLocalKey("k1", priv)
+	if w := approve(server, bearerFor("alice", "sre"), "plan_1"); w.Code != http.StatusOK || db.updateStatus != "approved" {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if len(db.attestations) != 1 {
+		t.Fatalf("attestations: %d", len(db.attestations))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/plans/plan_1/attestations", nil)
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Attestations json.RawMessage `json:"attestations"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	list, err := attest.ParseList(resp.Attestations)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list: %v %s", err, resp.Attestations)
+	}
+	a := list[0]
+	if err := attest.Verify(context.Background(), attest.PublicKeys{"k1": priv.Public().(ed25519.PublicKey)}, a); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	var plan map[string]any
+	_ = json.Unmarshal(db.lastPlan, &plan)
+	if a.PlanHash != ComputePlanHash("scale api", server.planSteps(context.Background(), "plan_1", plan)) {
+		t.Fatalf("hash: %s", a.PlanHash)
+	}
+	if a.Approver.ID != "alice" || a.Approver.Groups[0] != "sre" || a.Source != attest.SourceAPI || !a.SameContext(validContext()) {
+		t.Fatalf("statement: %+v", a.Statement)
+	}
+}
+
+func TestHandleApprovalsAttestationFailure(t *testing.T) {
+	db := attestPlanDB(t)
+	server := quorumServer(db, nil)
+	server.ApprovalQuorum = nil
+	server.Attestations = failingSigner{}
+	w := approve(server, bearerFor("alice", "sre"), "plan_1")
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if db.updateStatus != "" || len(db.votes) != 0 || len(db.attestations) != 0 {
+		t.Fatalf("approval recorded without attestation: %s %+v", db.updateStatus, db.votes)
+	}
+}
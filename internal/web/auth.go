@@ -69,7 +69,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 		actor := Actor{ID: claims.Sub, Email: claims.Email, Roles: claims.Groups}
-		r = r.WithContext(WithActor(r.Context(), actor))
+		r = r.WithContext(withPolicyInputs(WithActor(r.Context(), actor)))
 		next.ServeHTTP(w, r)
 	})
 }
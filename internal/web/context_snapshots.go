@@ -39,8 +39,8 @@ func (s *Server) handleContextSnapshotByID(w http.ResponseWriter, r *http.Reques
 	snapshotID := parts[0]
 	if len(parts) == 1 {
 		if err := policyCheckTenantRead(s, r, "context.snapshot.get", tenantID); err != nil {
-			s.auditEvent(r.Context(), "context.snapshot.get", "deny", map[string]any{"snapshot_id": snapshotID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "context.snapshot.get", map[string]any{"snapshot_id": snapshotID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := s.DB.GetContextSnapshot(r.Context(), snapshotID)
@@ -66,8 +66,8 @@ func (s *Server) handleContextSnapshotByID(w http.ResponseWriter, r *http.Reques
 	}
 	if len(parts) == 2 && parts[1] == "diff" {
 		if err := policyCheckTenantRead(s, r, "context.snapshot.diff", tenantID); err != nil {
-			s.auditEvent(r.Context(), "context.snapshot.diff", "deny", map[string]any{"snapshot_id": snapshotID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "context.snapshot.diff", map[string]any{"snapshot_id": snapshotID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		baseID := strings.TrimSpace(r.URL.Query().Get("base"))
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"carapulse/internal/llm"
+	"carapulse/internal/metrics"
+	"carapulse/internal/risk"
+)
+
+// critiquePlan has the critic review a drafted plan's steps and stores the
+// verdict under meta.critic. Critic failures are logged, not fatal: the
+// plan still goes through policy and approval.
+func (s *Server) critiquePlan(ctx context.Context, plan map[string]any, ctxRef ContextRef) *llm.CriticVerdict {
+	if s.Critic == nil {
+		return nil
+	}
+	steps, ok := plan["steps"]
+	if !ok {
+		return nil
+	}
+	tenantID := strings.TrimSpace(ctxRef.TenantID)
+	if err := s.checkLLMBudget(ctx, tenantID); err != nil {
+		slog.Warn("plan critic skipped", "tenant_id", tenantID, "error", err)
+		return nil
+	}
+	input := llm.CriticInput{
+		Steps:       steps,
+		Context:     plan["context"],
+		Constraints: plan["constraints"],
+	}
+	input.Intent, _ = plan["intent"].(string)
+	input.RiskLevel, _ = plan["risk_level"].(string)
+	if meta, ok := plan["meta"].(map[string]any); ok {
+		input.ServiceGraph = meta["service_graph"]
+	}
+	verdict, err := s.Critic.Critique(input)
+	if err != nil {
+		metrics.LLMCriticVerdictsTotal.WithLabelValues("error").Inc()
+		slog.Warn("plan critic failed", "tenant_id", tenantID, "error", err)
+		return nil
+	}
+	metrics.LLMCriticVerdictsTotal.WithLabelValues(verdict.Recommendation).Inc()
+	setPlanMeta(plan, "critic", verdict)
+	return &verdict
+}
+
+// recordCriticUsage counts the critic's tokens against the tenant budget.
+func (s *Server) recordCriticUsage(ctx context.Context, planID string, ctxRef ContextRef, verdict *llm.CriticVerdict) {
+	if verdict == nil {
+		return
+	}
+	s.recordLLMUsage(ctx, planID, "critic", ctxRef, verdict.Usage)
+}
+
+// criticFromPlan returns the stored verdict, or nil when the plan was not
+// reviewed.
+func criticFromPlan(plan map[string]any) *llm.CriticVerdict {
+	meta, ok := plan["meta"].(map[string]any)
+	if !ok || meta["critic"] == nil {
+		return nil
+	}
+	data, err := json.Marshal(meta["critic"])
+	if err != nil {
+		return nil
+	}
+	var verdict llm.CriticVerdict
+	if err := json.Unmarshal(data, &verdict); err != nil || verdict.Recommendation == "" {
+		return nil
+	}
+	return &verdict
+}
+
+// criticPolicyInput is the part of the verdict passed to OPA as
+// input.risk.critic. It returns an untyped nil without a verdict so the
+// field is omitted.
+func criticPolicyInput(verdict *llm.CriticVerdict) any {
+	if verdict == nil {
+		return nil
+	}
+	maxSeverity := ""
+	for _, c := range verdict.Concerns {
+		if risk.Ord(c.Severity) > risk.Ord(maxSeverity) {
+			maxSeverity = c.Severity
+		}
+	}
+	return map[string]any{
+		"recommendation":    verdict.Recommendation,
+		"suggested_risk":    verdict.SuggestedRisk,
+		"concerns":          len(verdict.Concerns),
+		"max_severity":      maxSeverity,
+		"missing_rollbacks": len(verdict.MissingRollbacks),
+	}
+}
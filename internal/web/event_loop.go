@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"carapulse/internal/risk"
 )
 
 type EventLoopResult struct {
@@ -31,13 +34,14 @@ func (s *Server) runAlertEventLoop(ctx context.Context, source string, payload m
 	}
 	summary := hookSummary(source, payload)
 	intent := summary
-	risk := riskFromIntent(intent)
+	service := serviceFromHook(payload)
+	score := s.scorePlanRisk(ctx, ctxRef, intent, nil, service)
 	actionType := "read"
-	if risk != "read" {
+	if score.Level != risk.LevelRead {
 		actionType = "write"
 	}
 	req := (&http.Request{Header: http.Header{}}).WithContext(ctx)
-	dec, err := s.policyDecision(req, "plan.create", actionType, ctxRef, risk, 0)
+	dec, err := s.planPolicyDecision(req, "plan.create", actionType, ctxRef, score, 0, s.planImpact(ctx, nil, service), nil)
 	if err != nil {
 		return EventLoopResult{}, err
 	}
@@ -48,12 +52,12 @@ func (s *Server) runAlertEventLoop(ctx context.Context, source string, payload m
 			return EventLoopResult{}, errors.New("approval required")
 		}
 	default:
-		return EventLoopResult{}, errors.New("policy decision " + dec.Decision)
+		return EventLoopResult{}, deniedError(dec)
 	}
 	mergedConstraints := mergeConstraints(nil, dec.Constraints)
 	var serviceGraph any
 	if s.Context != nil {
-		if service := serviceFromHook(payload); service != "" {
+		if service != "" {
 			if graph, err := s.Context.GetServiceGraph(ctx, service); err == nil && (len(graph.Nodes) > 0 || len(graph.Edges) > 0) {
 				serviceGraph = graph
 			}
@@ -69,7 +73,7 @@ func (s *Server) runAlertEventLoop(ctx context.Context, source string, payload m
 		}
 	}
 	createdAt := time.Now().UTC()
-	var planText string
+	var draft planDraft
 	if s.Planner != nil && intent != "" {
 		planContext := map[string]any{
 			"context":     ctxRef,
@@ -82,25 +86,31 @@ func (s *Server) runAlertEventLoop(ctx context.Context, source string, payload m
 		if serviceGraph != nil {
 			planContext["service_graph"] = serviceGraph
 		}
-		draft, err := s.Planner.Plan(intent, planContext, diagnostics)
-		if err != nil {
+		if service != "" {
+			planContext["service"] = service
+		}
+		var err error
+		draft, err = s.draftPlan(ctx, ctxRef, intent, planContext, diagnostics)
+		switch {
+		case errors.Is(err, ErrLLMBudgetExceeded):
+			// Over budget: fall through to the default verify steps.
+			slog.Warn("event loop planning skipped", "tenant_id", ctxRef.TenantID, "error", err)
+		case err != nil:
 			return EventLoopResult{}, err
 		}
-		planText = draft
 	}
 	plan := map[string]any{
 		"trigger":     "alert",
 		"summary":     summary,
 		"context":     ctxRef,
-		"risk_level":  risk,
 		"intent":      intent,
 		"constraints": mergedConstraints,
 		"created_at":  createdAt,
 	}
 	var stepsDraft []planStepDraft
-	if planText != "" {
-		plan["plan_text"] = planText
-		stepsDraft = parsePlanSteps(planText)
+	if draft.Text != "" {
+		plan["plan_text"] = draft.Text
+		stepsDraft = planDraftSteps(plan, draft.Text)
 		if len(stepsDraft) > 0 {
 			if actionType == "write" {
 				stepsDraft = ensureVerifySteps(stepsDraft, diagnostics, intent)
@@ -117,24 +127,28 @@ func (s *Server) runAlertEventLoop(ctx context.Context, source string, payload m
 	// Re-assess risk based on actual plan steps, not just intent keywords.
 	// The step-based risk can only escalate, never downgrade.
 	if len(stepsDraft) > 0 {
-		risk = effectiveRiskDrafts(risk, stepsDraft)
-		plan["risk_level"] = risk
-		if risk != "read" {
+		score = s.scorePlanRisk(ctx, ctxRef, intent, planStepsFromDrafts(stepsDraft), service).AtLeast(score.Level)
+		if score.Level != risk.LevelRead {
 			actionType = "write"
 		}
 	}
+	setPlanRisk(plan, score)
 	if len(diagnostics) > 0 {
 		if data, err := json.Marshal(diagnostics); err == nil {
 			plan["diagnostics"] = json.RawMessage(data)
 		}
 	}
 	if serviceGraph != nil {
-		if meta, ok := plan["meta"].(map[string]any); ok {
-			meta["service_graph"] = serviceGraph
-		} else {
-			plan["meta"] = map[string]any{"service_graph": serviceGraph}
-		}
+		setPlanMeta(plan, "service_graph", serviceGraph)
+	}
+	// Kept for the execution report: the triggering alert and what was
+	// observed before planning.
+	setPlanMeta(plan, "alert", map[string]any{"source": source, "payload": payload})
+	if len(diagnostics) > 0 {
+		setPlanMeta(plan, "diagnostics", diagnostics)
 	}
+	draft.annotate(plan)
+	verdict := s.critiquePlan(ctx, plan, ctxRef)
 	data, err := marshalJSON(plan)
 	if err != nil {
 		return EventLoopResult{}, err
@@ -143,6 +157,8 @@ func (s *Server) runAlertEventLoop(ctx context.Context, source string, payload m
 	if err != nil {
 		return EventLoopResult{}, err
 	}
+	s.recordLLMUsage(ctx, planID, "alert", ctxRef, draft.Usage)
+	s.recordCriticUsage(ctx, planID, ctxRef, verdict)
 	// LLM-generated plans from webhooks/alerts always require human approval.
 	// Never auto-approve or auto-execute: the risk classification is based on
 	// intent keywords which can be gamed, and the LLM output is not trusted.
@@ -57,25 +57,32 @@ func (h *EventHub) Subscribe(sessionID string) (<-chan Event, func()) {
 func (h *EventHub) Publish(ev Event, sessionID string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	if h.subs == nil {
+	h.deliver(ev, sessionID)
+	if sessionID != "" {
+		h.deliver(ev, "")
+	}
+}
+
+// PublishSession delivers ev only to subscribers of sessionID, without the
+// fan-out to unscoped subscribers. It is for events carrying session data
+// such as plan bodies; an empty sessionID drops the event.
+func (h *EventHub) PublishSession(ev Event, sessionID string) {
+	if sessionID == "" {
 		return
 	}
-	if subs, ok := h.subs[sessionID]; ok {
-		for _, ch := range subs {
-			select {
-			case ch <- ev:
-			default:
-			}
-		}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deliver(ev, sessionID)
+}
+
+func (h *EventHub) deliver(ev Event, sessionID string) {
+	if h.subs == nil {
+		return
 	}
-	if sessionID != "" {
-		if subs, ok := h.subs[""]; ok {
-			for _, ch := range subs {
-				select {
-				case ch <- ev:
-				default:
-				}
-			}
+	for _, ch := range h.subs[sessionID] {
+		select {
+		case ch <- ev:
+		default:
 		}
 	}
 }
@@ -41,6 +41,24 @@ func TestEventHubCancelNilSubs(t *testing.T) {
 	cancel()
 }
 
+func TestEventHubPublishSessionSkipsUnscoped(t *testing.T) {
+	hub := NewEventHub()
+	session, cancelSession := hub.Subscribe("s1")
+	defer cancelSession()
+	global, cancelGlobal := hub.Subscribe("")
+	defer cancelGlobal()
+	hub.PublishSession(Event{Event: "plan.created"}, "s1")
+	hub.PublishSession(Event{Event: "plan.created"}, "")
+	if ev := <-session; ev.Event != "plan.created" {
+		t.Fatalf("event: %+v", ev)
+	}
+	select {
+	case ev := <-global:
+		t.Fatalf("unscoped subscriber got %+v", ev)
+	default:
+	}
+}
+
 func TestEventHubPublishNoSubs(t *testing.T) {
 	hub := &EventHub{}
 	hub.Publish(Event{Event: "noop"}, "s1")
@@ -0,0 +1,360 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"carapulse/internal/freeze"
+	"carapulse/internal/policy"
+	"carapulse/internal/risk"
+)
+
+// FreezeLister reads the change freeze calendar.
+type FreezeLister interface {
+	ListFreezes(ctx context.Context) ([]byte, error)
+}
+
+type FreezeStore interface {
+	FreezeLister
+	CreateFreeze(ctx context.Context, payload []byte) (string, error)
+	GetFreeze(ctx context.Context, freezeID string) ([]byte, error)
+	DeleteFreeze(ctx context.Context, freezeID string) error
+	DeleteFreezesBySource(ctx context.Context, source, sourceRef string) error
+}
+
+// FreezeImportRequest imports an iCalendar feed, given inline or as a URL
+// the gateway fetches. Re-importing the same source_ref replaces the
+// freezes it created last time.
+type FreezeImportRequest struct {
+	ICal         string   `json:"ical"`
+	URL          string   `json:"url"`
+	SourceRef    string   `json:"source_ref"`
+	Environments []string `json:"environments"`
+	Services     []string `json:"services"`
+	Timezone     string   `json:"timezone"`
+}
+
+type FreezeImportResponse struct {
+	SourceRef string           `json:"source_ref"`
+	FreezeIDs []string         `json:"freeze_ids"`
+	Skipped   []freeze.Skipped `json:"skipped,omitempty"`
+}
+
+const (
+	freezeFetchTimeout = 10 * time.Second
+	maxFreezeCalendar  = 1 << 20
+)
+
+var errFreezeCalendarUnavailable = errors.New("freeze calendar unavailable")
+
+func (s *Server) handleFreezes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	store, ok := s.DB.(FreezeStore)
+	if !ok || store == nil {
+		http.Error(w, "store unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		var req freeze.Freeze
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.policyCheck(r, "freeze.create", "write", ContextRef{}, "low", 0); err != nil {
+			s.auditDenied(r.Context(), "freeze.create", map[string]any{"name": req.Name}, err)
+			writePolicyDenied(w, err)
+			return
+		}
+		actor, _ := ActorFromContext(r.Context())
+		req.ID, req.CreatedAt = "", nil
+		req.Source, req.SourceRef, req.ExternalID = freeze.SourceAPI, "", ""
+		req.CreatedBy = actor.ID
+		data, err := marshalJSON(req)
+		if err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+		id, err := store.CreateFreeze(r.Context(), data)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		s.auditEvent(r.Context(), "freeze.create", "allow", map[string]any{"freeze_id": id, "name": req.Name}, "")
+		writeJSON(w, http.StatusOK, map[string]any{"freeze_id": id})
+	case http.MethodGet:
+		if err := s.policyCheckRead(r, "freeze.list"); err != nil {
+			s.auditDenied(r.Context(), "freeze.list", nil, err)
+			writePolicyDenied(w, err)
+			return
+		}
+		freezes, err := loadFreezes(r.Context(), store)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		query := r.URL.Query()
+		env := strings.TrimSpace(query.Get("environment"))
+		var services []string
+		if svc := strings.TrimSpace(query.Get("service")); svc != "" {
+			services = []string{svc}
+		}
+		if strings.EqualFold(query.Get("active"), "true") {
+			writeJSON(w, http.StatusOK, freeze.Active(freezes, time.Now().UTC(), env, services))
+			return
+		}
+		out := []freeze.Freeze{}
+		for _, f := range freezes {
+			if (env == "" && services == nil) || f.AppliesTo(env, services) {
+				out = append(out, f)
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleFreezeByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	store, ok := s.DB.(FreezeStore)
+	if !ok || store == nil {
+		http.Error(w, "store unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	freezeID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/freezes/"), "/")
+	if freezeID == "" {
+		http.Error(w, "freeze_id required", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if err := s.policyCheckRead(r, "freeze.get"); err != nil {
+			s.auditDenied(r.Context(), "freeze.get", map[string]any{"freeze_id": freezeID}, err)
+			writePolicyDenied(w, err)
+			return
+		}
+		payload, err := store.GetFreeze(r.Context(), freezeID)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		if payload == nil {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(payload)
+	case http.MethodDelete:
+		if err := s.policyCheck(r, "freeze.delete", "write", ContextRef{}, "low", 0); err != nil {
+			s.auditDenied(r.Context(), "freeze.delete", map[string]any{"freeze_id": freezeID}, err)
+			writePolicyDenied(w, err)
+			return
+		}
+		if err := store.DeleteFreeze(r.Context(), freezeID); err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		s.auditEvent(r.Context(), "freeze.delete", "allow", map[string]any{"freeze_id": freezeID}, "")
+		writeJSON(w, http.StatusOK, map[string]any{"freeze_id": freezeID, "status": "deleted"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleFreezeImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	store, ok := s.DB.(FreezeStore)
+	if !ok || store == nil {
+		http.Error(w, "store unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxFreezeCalendar+maxRequestBody)
+	var req FreezeImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	calURL := strings.TrimSpace(req.URL)
+	if (req.ICal == "") == (calURL == "") {
+		http.Error(w, "one of ical or url required", http.StatusBadRequest)
+		return
+	}
+	sourceRef := strings.TrimSpace(req.SourceRef)
+	if sourceRef == "" {
+		sourceRef = calURL
+	}
+	if sourceRef == "" {
+		http.Error(w, "source_ref required", http.StatusBadRequest)
+		return
+	}
+	if err := s.policyCheck(r, "freeze.import", "write", ContextRef{}, "low", 0); err != nil {
+		s.auditDenied(r.Context(), "freeze.import", map[string]any{"source_ref": sourceRef}, err)
+		writePolicyDenied(w, err)
+		return
+	}
+	calendar := []byte(req.ICal)
+	if calURL != "" {
+		fetched, err := fetchCalendar(r.Context(), calURL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		calendar = fetched
+	}
+	freezes, skipped, err := freeze.ParseICal(bytes.NewReader(calendar), freeze.ImportOptions{
+		SourceRef:    sourceRef,
+		Environments: req.Environments,
+		Services:     req.Services,
+		Timezone:     req.Timezone,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := store.DeleteFreezesBySource(r.Context(), freeze.SourceICal, sourceRef); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	actor, _ := ActorFromContext(r.Context())
+	resp := FreezeImportResponse{SourceRef: sourceRef, FreezeIDs: []string{}, Skipped: skipped}
+	for _, f := range freezes {
+		f.CreatedBy = actor.ID
+		data, err := marshalJSON(f)
+		if err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+		id, err := store.CreateFreeze(r.Context(), data)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		resp.FreezeIDs = append(resp.FreezeIDs, id)
+	}
+	s.auditEvent(r.Context(), "freeze.import", "allow", map[string]any{
+		"source_ref": sourceRef,
+		"imported":   len(resp.FreezeIDs),
+		"skipped":    len(skipped),
+	}, "")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// fetchCalendar downloads an iCalendar feed over http(s).
+func fetchCalendar(ctx context.Context, raw string) ([]byte, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("url must be http or https")
+	}
+	ctx, cancel := context.WithTimeout(ctx, freezeFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch calendar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch calendar: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFreezeCalendar+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch calendar: %w", err)
+	}
+	if len(data) > maxFreezeCalendar {
+		return nil, errors.New("fetch calendar: calendar too large")
+	}
+	return data, nil
+}
+
+func loadFreezes(ctx context.Context, lister FreezeLister) ([]freeze.Freeze, error) {
+	data, err := lister.ListFreezes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var freezes []freeze.Freeze
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &freezes); err != nil {
+			return nil, err
+		}
+	}
+	return freezes, nil
+}
+
+// freezeOverrideRoles may override a change freeze when policy requires
+// approval for it.
+var freezeOverrideRoles = []string{"admin"}
+
+// checkFreezes blocks a write to services in ctxRef's environment while a
+// change freeze covers it. The X-Break-Glass: true header asks to override
+// the freeze: the override is checked as a break-glass freeze.override
+// write, and when policy requires approval only freezeOverrideRoles may
+// proceed. Overrides are audited.
+func (s *Server) checkFreezes(r *http.Request, action string, ctxRef ContextRef, services []string, ref map[string]any) error {
+	lister, ok := s.DB.(FreezeLister)
+	if !ok {
+		return nil
+	}
+	freezes, err := loadFreezes(r.Context(), lister)
+	if err != nil {
+		slog.Warn("freeze calendar unavailable", "action", action, "error", err)
+		return errFreezeCalendarUnavailable
+	}
+	hits := freeze.Active(freezes, time.Now().UTC(), ctxRef.Environment, services)
+	if len(hits) == 0 {
+		return nil
+	}
+	reasons := make([]policy.Reason, 0, len(hits))
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		reasons = append(reasons, policy.Reason{Rule: "change_freeze", Message: hit.Reason(), Field: "time"})
+		ids = append(ids, hit.Freeze.ID)
+	}
+	if !strings.EqualFold(strings.TrimSpace(r.Header.Get("X-Break-Glass")), "true") {
+		return &policy.DeniedError{Decision: "deny", Reasons: reasons}
+	}
+	dec, err := s.policyDecision(r, "freeze.override", "write", ctxRef, risk.LevelHigh, len(services))
+	if err != nil {
+		return err
+	}
+	actor, _ := ActorFromContext(r.Context())
+	switch dec.Decision {
+	case "allow":
+	case "require_approval":
+		if !inApproverGroups(freezeOverrideRoles, actor.Roles) {
+			reasons = append(reasons, policy.Reason{Rule: "freeze_override", Message: "overriding a change freeze requires the admin role", Field: "actor.roles"})
+			return &policy.DeniedError{Decision: "deny", Reasons: reasons}
+		}
+	default:
+		return &policy.DeniedError{Decision: "deny", Reasons: append(reasons, dec.Reasons...)}
+	}
+	override := map[string]any{"action": action}
+	for k, v := range ref {
+		override[k] = v
+	}
+	s.auditEventWith(r.Context(), "freeze.override", "allow", override, "break-glass override of "+reasons[0].Message, map[string]any{
+		"freeze_ids": ids,
+		"reasons":    reasons,
+	})
+	return nil
+}
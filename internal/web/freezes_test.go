@@ -0,0 +1,366 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"carapulse/internal/freeze"
+	"carapulse/internal/policy"
+)
+
+// freezeDB keeps a freeze calendar in memory alongside a plan.
+type freezeDB struct {
+	fakeDB
+	freezes []freeze.Freeze
+	listErr error
+}
+
+func (f *freezeDB) ListFreezes(ctx context.Context) ([]byte, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return json.Marshal(f.freezes)
+}
+
+func (f *freezeDB) CreateFreeze(ctx context.Context, payload []byte) (string, error) {
+	var item freeze.Freeze
+	if err := json.Unmarshal(payload, &item); err != nil {
+		return "", err
+	}
+	item.ID = fmt.Sprintf("freeze_%d", len(f.freezes)+1)
+	f.freezes = append(f.freezes, item)
+	return item.ID, nil
+}
+
+func (f *freezeDB) GetFreeze(ctx context.Context, freezeID string) ([]byte, error) {
+	for _, item := range f.freezes {
+		if item.ID == freezeID {
+			return json.Marshal(item)
+		}
+	}
+	return nil, nil
+}
+
+func (f *freezeDB) DeleteFreeze(ctx context.Context, freezeID string) error {
+	return f.deleteWhere(func(item freeze.Freeze) bool { return item.ID == freezeID })
+}
+
+func (f *freezeDB) DeleteFreezesBySource(ctx context.Context, source, sourceRef string) error {
+	return f.deleteWhere(func(item freeze.Freeze) bool { return item.Source == source && item.SourceRef == sourceRef })
+}
+
+func (f *freezeDB) deleteWhere(match func(freeze.Freeze) bool) error {
+	kept := f.freezes[:0]
+	for _, item := range f.freezes {
+		if !match(item) {
+			kept = append(kept, item)
+		}
+	}
+	f.freezes = kept
+	return nil
+}
+
+// auditLog records every audit event written.
+type auditLog struct {
+	events []map[string]any
+}
+
+func (a *auditLog) InsertAuditEvent(ctx context.Context, payload []byte) (string, error) {
+	var event map[string]any
+	_ = json.Unmarshal(payload, &event)
+	a.events = append(a.events, event)
+	return "audit_1", nil
+}
+
+func (a *auditLog) find(action string) map[string]any {
+	for _, event := range a.events {
+		if event["action"] == action {
+			return event
+		}
+	}
+	return nil
+}
+
+func activeFreeze(name string, envs ...string) freeze.Freeze {
+	start, end := time.Now().UTC().Add(-time.Hour), time.Now().UTC().Add(time.Hour)
+	return freeze.Freeze{ID: "freeze_" + name, Name: name, Reason: "peak traffic", StartsAt: &start, EndsAt: &end, Environments: envs}
+}
+
+func frozenPlanDB(t *testing.T, freezes ...freeze.Freeze) *freezeDB {
+	plan := map[string]any{"plan_id": "plan_1", "risk_level": "low", "context": validContext()}
+	return &freezeDB{fakeDB: fakeDB{planID: "plan_1", lastPlan: mustPlanJSON(t, plan), approvalStatus: "approved"}, freezes: freezes}
+}
+
+func TestHandleFreezesCreateAndList(t *testing.T) {
+	enableDevMode(t)
+	db := &freezeDB{}
+	audit := &auditLog{}
+	server := &Server{Mux: http.NewServeMux(), DB: db, Audit: audit, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	body := `{"name":"weekends","recurrence":{"kind":"weekends"},"environments":["prod"],"source":"ical","freeze_id":"mine"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/freezes", strings.NewReader(body))
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezes)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"freeze_1"`) {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if got := db.freezes[0]; got.Source != freeze.SourceAPI || got.CreatedBy != "u" {
+		t.Fatalf("stored: %+v", got)
+	}
+	if audit.find("freeze.create") == nil {
+		t.Fatalf("missing audit: %+v", audit.events)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/freezes?environment=dev", nil)
+	req.Header.Set("Authorization", testToken)
+	w = httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezes)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleFreezesRejectsInvalid(t *testing.T) {
+	enableDevMode(t)
+	server := &Server{Mux: http.NewServeMux(), DB: &freezeDB{}, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/freezes", strings.NewReader(`{"name":"open ended","starts_at":"2026-01-01T00:00:00Z"}`))
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezes)).ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status: %d", w.Code)
+	}
+}
+
+func TestHandleFreezesActive(t *testing.T) {
+	enableDevMode(t)
+	db := &freezeDB{freezes: []freeze.Freeze{activeFreeze("prod", "prod"), activeFreeze("dev", "dev")}}
+	server := &Server{Mux: http.NewServeMux(), DB: db, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	req := httptest.NewRequest(http.MethodGet, "/v1/freezes?active=true&environment=prod", nil)
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezes)).ServeHTTP(w, req)
+	var hits []freeze.Hit
+	if err := json.Unmarshal(w.Body.Bytes(), &hits); err != nil {
+		t.Fatalf("decode: %v %s", err, w.Body.String())
+	}
+	if len(hits) != 1 || hits[0].Freeze.Name != "prod" {
+		t.Fatalf("hits: %+v", hits)
+	}
+}
+
+func TestHandleFreezeByID(t *testing.T) {
+	enableDevMode(t)
+	db := &freezeDB{freezes: []freeze.Freeze{activeFreeze("prod")}}
+	server := &Server{Mux: http.NewServeMux(), DB: db, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	req := httptest.NewRequest(http.MethodGet, "/v1/freezes/freeze_prod", nil)
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezeByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"prod"`) {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	req = httptest.NewRequest(http.MethodDelete, "/v1/freezes/freeze_prod", nil)
+	req.Header.Set("Authorization", testToken)
+	w = httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezeByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK || len(db.freezes) != 0 {
+		t.Fatalf("status=%d freezes=%+v", w.Code, db.freezes)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/v1/freezes/freeze_prod", nil)
+	req.Header.Set("Authorization", testToken)
+	w = httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezeByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status: %d", w.Code)
+	}
+}
+
+func TestHandleFreezeImportReplacesSource(t *testing.T) {
+	enableDevMode(t)
+	cal := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nSUMMARY:Launch\nDTSTART:20260401T080000Z\nDTEND:20260401T200000Z\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nUID:b\nSUMMARY:Monthly\nDTSTART:20260401T080000Z\nDURATION:PT1H\nRRULE:FREQ=MONTHLY\nEND:VEVENT\nEND:VCALENDAR\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(cal))
+	}))
+	defer srv.Close()
+	db := &freezeDB{freezes: []freeze.Freeze{{ID: "old", Name: "stale", Source: freeze.SourceICal, SourceRef: srv.URL}, activeFreeze("manual")}}
+	server := &Server{Mux: http.NewServeMux(), DB: db, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	body, _ := json.Marshal(FreezeImportRequest{URL: srv.URL, Environments: []string{"prod"}})
+	req := httptest.NewRequest(http.MethodPost, "/v1/freezes:import", bytes.NewReader(body))
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(http.HandlerFunc(server.handleFreezeImport)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp FreezeImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.FreezeIDs) != 1 || len(resp.Skipped) != 1 || resp.SourceRef != srv.URL {
+		t.Fatalf("resp: %+v", resp)
+	}
+	if len(db.freezes) != 2 || db.freezes[0].Name != "manual" || db.freezes[1].Environments[0] != "prod" {
+		t.Fatalf("freezes: %+v", db.freezes)
+	}
+}
+
+func TestHandleFreezeImportValidation(t *testing.T) {
+	enableDevMode(t)
+	server := &Server{Mux: http.NewServeMux(), DB: &freezeDB{}, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	cases := map[string]FreezeImportRequest{
+		"neither":  {},
+		"both":     {ICal: "BEGIN:VCALENDAR", URL: "https://cal.example.com/x.ics"},
+		"no ref":   {ICal: "BEGIN:VCALENDAR"},
+		"bad url":  {URL: "file:///etc/passwd"},
+		"bad zone": {ICal: "BEGIN:VCALENDAR", SourceRef: "x", Timezone: "Nowhere/Else"},
+	}
+	for name, c := range cases {
+		body, _ := json.Marshal(c)
+		req := httptest.NewRequest(http.MethodPost, "/v1/freezes:import", bytes.NewReader(body))
+		req.Header.Set("Authorization", testToken)
+		w := httptest.NewRecorder()
+		AuthMiddleware(http.HandlerFunc(server.handleFreezeImport)).ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest && w.Code != http.StatusBadGateway {
+			t.Fatalf("%s: status=%d", name, w.Code)
+		}
+	}
+}
+
+func TestHandlePlanExecuteBlockedByFreeze(t *testing.T) {
+	db := frozenPlanDB(t, activeFreeze("Q4 freeze", "dev"))
+	audit := &auditLog{}
+	req := httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	server := &Server{Mux: http.NewServeMux(), DB: db, Audit: audit, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp PolicyDeniedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Reasons) != 1 || resp.Reasons[0].Rule != "change_freeze" || !strings.Contains(resp.Reasons[0].Message, `"Q4 freeze" in effect until`) {
+		t.Fatalf("reasons: %+v", resp.Reasons)
+	}
+	if db.execID != "" {
+		t.Fatalf("execution created during freeze")
+	}
+	if event := audit.find("plan.execute"); event == nil || event["decision"] != "deny" {
+		t.Fatalf("audit: %+v", audit.events)
+	}
+}
+
+func TestHandlePlanExecuteFreezeOtherEnvironment(t *testing.T) {
+	db := frozenPlanDB(t, activeFreeze("prod only", "prod"))
+	req := httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	server := &Server{Mux: http.NewServeMux(), DB: db, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlePlanExecuteFreezeBreakGlass(t *testing.T) {
+	db := frozenPlanDB(t, activeFreeze("Q4 freeze"))
+	audit := &auditLog{}
+	req := httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+	req.Header.Set("Authorization", bearerFor("u", "admin"))
+	req.Header.Set("X-Break-Glass", "true")
+	w := httptest.NewRecorder()
+	var inputs []policy.PolicyInput
+	checker := policy.CheckerFunc(func(input policy.PolicyInput) (policy.PolicyDecision, error) {
+		inputs = append(inputs, input)
+		return policy.PolicyDecision{Decision: "allow"}, nil
+	})
+	server := &Server{Mux: http.NewServeMux(), DB: db, Audit: audit, Policy: &policy.Evaluator{Checker: checker}}
+	AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK || db.execID == "" {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var overrideInput policy.PolicyInput
+	for _, input := range inputs {
+		if input.Action.(policy.Action).Name == "freeze.override" {
+			overrideInput = input
+		}
+	}
+	action, _ := overrideInput.Action.(policy.Action)
+	resources, _ := overrideInput.Resources.(map[string]any)
+	riskInput, _ := overrideInput.Risk.(policy.Risk)
+	if action.Type != "write" || resources["break_glass"] != true || riskInput.Tier != "break_glass" {
+		t.Fatalf("policy input: %+v", overrideInput)
+	}
+	override := audit.find("freeze.override")
+	if override == nil || override["decision"] != "allow" {
+		t.Fatalf("audit: %+v", audit.events)
+	}
+	ids, _ := override["freeze_ids"].([]any)
+	if len(ids) != 1 || ids[0] != "freeze_Q4 freeze" || !strings.Contains(fmt.Sprint(override["note"]), "break-glass") {
+		t.Fatalf("override: %+v", override)
+	}
+}
+
+func TestHandlePlanExecuteFreezeBreakGlassNeedsAdmin(t *testing.T) {
+	db := frozenPlanDB(t, activeFreeze("Q4 freeze"))
+	audit := &auditLog{}
+	req := httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+	req.Header.Set("Authorization", bearerFor("bob", "operator"))
+	req.Header.Set("X-Break-Glass", "true")
+	w := httptest.NewRecorder()
+	server := &Server{Mux: http.NewServeMux(), DB: db, Audit: audit, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || db.execID != "" {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp PolicyDeniedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Reasons) != 2 || resp.Reasons[1].Rule != "freeze_override" {
+		t.Fatalf("reasons: %+v", resp.Reasons)
+	}
+	if audit.find("freeze.override") != nil {
+		t.Fatalf("override audited as allowed: %+v", audit.events)
+	}
+
+	// A policy deny stops the override even for admins.
+	req = httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+	req.Header.Set("Authorization", bearerFor("u", "admin"))
+	req.Header.Set("X-Break-Glass", "true")
+	w = httptest.NewRecorder()
+	server.Policy = &policy.Evaluator{Checker: policy.CheckerFunc(func(input policy.PolicyInput) (policy.PolicyDecision, error) {
+		if input.Action.(policy.Action).Name == "freeze.override" {
+			return policy.PolicyDecision{Decision: "deny", Reasons: []policy.Reason{{Rule: "no_overrides"}}}, nil
+		}
+		return policy.PolicyDecision{Decision: "allow"}, nil
+	})}
+	AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || db.execID != "" || !strings.Contains(w.Body.String(), "no_overrides") {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlePlanExecuteFreezeCalendarError(t *testing.T) {
+	db := frozenPlanDB(t)
+	db.listErr = errTest
+	req := httptest.NewRequest(http.MethodPost, "/v1/plans/plan_1:execute", nil)
+	req.Header.Set("Authorization", testToken)
+	w := httptest.NewRecorder()
+	server := &Server{Mux: http.NewServeMux(), DB: db, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	AuthMiddleware(http.HandlerFunc(server.handlePlanByID)).ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status: %d", w.Code)
+	}
+}
@@ -14,11 +14,14 @@ import (
 	"sync"
 	"time"
 
+	"carapulse/internal/attest"
 	ctxmodel "carapulse/internal/context"
 	"carapulse/internal/db"
 	"carapulse/internal/llm"
 	"carapulse/internal/metrics"
 	"carapulse/internal/policy"
+	"carapulse/internal/retrieval"
+	"carapulse/internal/risk"
 )
 
 // PaginationMeta carries pagination metadata in list responses.
@@ -78,9 +81,20 @@ type Server struct {
 	Scheduler        *Scheduler
 	Executor         ExecutionStarter
 	Planner          llm.Planner
+	LLMBudget        LLMBudget
+	TenantLLMBudgets map[string]LLMBudget
+	Retriever        *retrieval.Retriever
+	Critic           llm.Critic
+	Reporter         llm.Reporter
+	QueryTranslator  llm.QueryTranslator
+	ToolRouter       ToolRunner
 	EventGate        *EventGate
 	WorkspaceDir     string
+	Risk             risk.Scorer
 	AutoApproveLow   bool
+	ApprovalQuorum   map[string]QuorumRule
+	ApprovalTTLs     map[string]time.Duration
+	Attestations     attest.Signer
 	EnableEventLoop  bool
 	EventLoopSources []string
 	SessionRequired  bool
@@ -221,7 +235,11 @@ func (s *Server) registerRoutes() {
 	s.Mux.Handle("/v1/approvals", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleApprovals))))
 	s.Mux.Handle("/v1/schedules", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleSchedules))))
 	s.Mux.Handle("/v1/schedules/", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleScheduleByID))))
+	s.Mux.Handle("/v1/freezes", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleFreezes))))
+	s.Mux.Handle("/v1/freezes/", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleFreezeByID))))
+	s.Mux.Handle("/v1/freezes:import", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleFreezeImport))))
 	s.Mux.Handle("/v1/context/refresh", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleContextRefresh))))
+	s.Mux.Handle("/v1/query", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleQuery))))
 	s.Mux.Handle("/v1/playbooks", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handlePlaybooks))))
 	s.Mux.Handle("/v1/runbooks", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleRunbooks))))
 	s.Mux.Handle("/v1/memory", s.withRateLimit(AuthMiddleware(http.HandlerFunc(s.handleOperatorMemory))))
@@ -275,21 +293,40 @@ func (s *Server) emit(event string, data any, sessionID string) {
 	hub.Publish(Event{Event: event, Data: data, TS: time.Now().UTC(), SessionID: sessionID}, sessionID)
 }
 
+// emitSession is emit for events only the session may see.
+func (s *Server) emitSession(event string, data any, sessionID string) {
+	hub := s.eventHub()
+	hub.PublishSession(Event{Event: event, Data: data, TS: time.Now().UTC(), SessionID: sessionID}, sessionID)
+}
+
 func (s *Server) auditEvent(ctx context.Context, action, decision string, ctxRef any, note string) {
+	s.auditEventWith(ctx, action, decision, ctxRef, note, nil)
+}
+
+// auditEventWith is auditEvent with extra top-level payload fields.
+func (s *Server) auditEventWith(ctx context.Context, action, decision string, ctxRef any, note string, extra map[string]any) {
 	if s.Audit == nil {
 		return
 	}
 	actor, _ := ActorFromContext(ctx)
-	payload := map[string]any{
-		"occurred_at": time.Now().UTC().Format(time.RFC3339),
-		"actor":       actor,
-		"action":      action,
-		"decision":    decision,
-		"context":     ctxRef,
-	}
+	payload := map[string]any{}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	payload["occurred_at"] = time.Now().UTC().Format(time.RFC3339)
+	payload["actor"] = actor
+	payload["action"] = action
+	payload["decision"] = decision
+	payload["context"] = ctxRef
 	if note != "" {
 		payload["note"] = note
 	}
+	if rec, ok := recordedPolicyInput(ctx, action); ok {
+		payload["policy_input"] = rec.input
+		if rec.revision != "" {
+			payload["policy_revision"] = rec.revision
+		}
+	}
 	data, err := marshalJSON(payload)
 	if err != nil {
 		return
@@ -297,8 +334,23 @@ func (s *Server) auditEvent(ctx context.Context, action, decision string, ctxRef
 	_, _ = s.Audit.InsertAuditEvent(ctx, data)
 }
 
+// auditDenied records a refused request. Policy denials also carry the
+// policy's reasons.
+func (s *Server) auditDenied(ctx context.Context, action string, ctxRef any, err error) {
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) {
+		s.auditEvent(ctx, action, "deny", ctxRef, err.Error())
+		return
+	}
+	s.auditEventWith(ctx, action, "deny", ctxRef, err.Error(), map[string]any{"reasons": denied.Reasons})
+}
+
 func (s *Server) createApproval(ctx context.Context, planID string, external bool) (string, error) {
-	approvalID, err := s.DB.CreateApproval(ctx, planID, nil)
+	payload, err := json.Marshal(map[string]any{"expires_at": time.Now().UTC().Add(s.ApprovalTTL(ctx, planID))})
+	if err != nil {
+		return "", err
+	}
+	approvalID, err := s.DB.CreateApproval(ctx, planID, payload)
 	if err != nil {
 		return "", err
 	}
@@ -325,8 +377,8 @@ func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "plan.list", tenantID); err != nil {
-			s.auditEvent(r.Context(), "plan.list", "deny", map[string]any{}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.list", map[string]any{}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		limit, offset := parsePagination(r)
@@ -357,20 +409,24 @@ func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid json", http.StatusBadRequest)
 			return
 		}
-		risk := riskFromIntent(req.Intent)
-		actionType := "read"
-		if risk != "read" {
-			actionType = "write"
+		if len(req.DraftID) > 64 {
+			http.Error(w, "draft_id too long", http.StatusBadRequest)
+			return
 		}
 		if err := validateContextRefMinimal(req.Context); err != nil {
 			s.auditEvent(r.Context(), "plan.create", "deny", req.Context, err.Error())
 			http.Error(w, "invalid context", http.StatusBadRequest)
 			return
 		}
-		dec, err := s.policyDecision(r, "plan.create", actionType, req.Context, risk, 0)
+		score := s.scorePlanRisk(r.Context(), req.Context, req.Intent, nil)
+		actionType := "read"
+		if score.Level != risk.LevelRead {
+			actionType = "write"
+		}
+		dec, err := s.planPolicyDecision(r, "plan.create", actionType, req.Context, score, 0, nil, nil)
 		if err != nil {
-			s.auditEvent(r.Context(), "plan.create", "deny", req.Context, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.create", req.Context, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		mergedConstraints := mergeConstraints(req.Constraints, dec.Constraints)
@@ -378,13 +434,13 @@ func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
 		case "allow":
 		case "require_approval":
 			if actionType != "write" {
-				s.auditEvent(r.Context(), "plan.create", "deny", req.Context, "approval required")
-				http.Error(w, "policy denied", http.StatusForbidden)
+				s.auditDenied(r.Context(), "plan.create", req.Context, deniedError(dec))
+				writePolicyDenied(w, deniedError(dec))
 				return
 			}
 		default:
-			s.auditEvent(r.Context(), "plan.create", "deny", req.Context, "policy decision "+dec.Decision)
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.create", req.Context, deniedError(dec))
+			writePolicyDenied(w, deniedError(dec))
 			return
 		}
 		createdAt := time.Now().UTC()
@@ -400,13 +456,18 @@ func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "session mismatch", http.StatusForbidden)
 			return
 		}
+		if req.Stream && sessionID == "" {
+			// Draft deltas are only published to the requesting session.
+			http.Error(w, "session_id required for stream", http.StatusBadRequest)
+			return
+		}
 		var diagnostics []DiagnosticEvidence
 		if s.Diagnostics != nil {
 			if collected, err := s.Diagnostics.Collect(r.Context(), req.Context, req.Intent, req.Constraints); err == nil {
 				diagnostics = collected
 			}
 		}
-		var planText string
+		var draft planDraft
 		if s.Planner != nil && strings.TrimSpace(req.Intent) != "" {
 			planContext := map[string]any{
 				"context":     req.Context,
@@ -415,33 +476,57 @@ func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
 				"trigger":     req.Trigger,
 				"session_id":  sessionID,
 			}
-			draft, err := s.Planner.Plan(req.Intent, planContext, diagnostics)
+			var onDelta func(llm.DraftDelta)
+			var emitter *draftDeltaEmitter
+			if req.Stream {
+				if strings.TrimSpace(req.DraftID) == "" {
+					req.DraftID = newDraftID()
+				}
+				emitter = &draftDeltaEmitter{s: s, sessionID: sessionID, draftID: req.DraftID}
+				onDelta = emitter.add
+			}
+			var err error
+			draft, err = s.draftPlanStream(r.Context(), req.Context, req.Intent, planContext, diagnostics, onDelta)
+			if emitter != nil {
+				emitter.flush()
+			}
+			if errors.Is(err, ErrLLMBudgetExceeded) {
+				http.Error(w, "llm budget exceeded", http.StatusTooManyRequests)
+				return
+			}
 			if err != nil {
 				s.auditEvent(r.Context(), "plan.create", "deny", req.Context, err.Error())
 				http.Error(w, "planner error", http.StatusBadGateway)
 				return
 			}
-			planText = draft
 		}
 		plan := map[string]any{
 			"trigger":     req.Trigger,
 			"summary":     req.Summary,
 			"context":     req.Context,
 			"constraints": mergedConstraints,
-			"risk_level":  risk,
 			"intent":      req.Intent,
 			"created_at":  createdAt,
 			"session_id":  sessionID,
 		}
+		if actor, ok := ActorFromContext(r.Context()); ok && actor.ID != "" {
+			plan["created_by"] = actor.ID
+		}
 		if len(diagnostics) > 0 {
 			plan["meta"] = map[string]any{"diagnostics": diagnostics}
 		}
-		if planText != "" {
-			plan["plan_text"] = planText
-			if steps := parsePlanSteps(planText); len(steps) > 0 {
+		draft.annotate(plan)
+		if draft.Text != "" {
+			plan["plan_text"] = draft.Text
+			if steps := planDraftSteps(plan, draft.Text); len(steps) > 0 {
 				plan["steps"] = steps
+				// Rescore with the drafted steps; they can raise the level
+				// the policy saw but never lower it.
+				score = s.scorePlanRisk(r.Context(), req.Context, req.Intent, planStepsFromDrafts(steps)).AtLeast(score.Level)
 			}
 		}
+		setPlanRisk(plan, score)
+		verdict := s.critiquePlan(r.Context(), plan, req.Context)
 		payload, err := marshalJSON(plan)
 		if err != nil {
 			http.Error(w, "encode error", http.StatusInternalServerError)
@@ -453,13 +538,21 @@ func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		plan["plan_id"] = planID
+		s.recordLLMUsage(r.Context(), planID, req.Trigger, req.Context, draft.Usage)
+		s.recordCriticUsage(r.Context(), planID, req.Context, verdict)
 		if actionType == "write" {
-			if risk == "low" && s.AutoApproveLow && dec.Decision != "require_approval" {
+			// A critic no_go always goes to a human, as does a plan that
+			// needs an approval quorum.
+			if score.Level == risk.LevelLow && s.AutoApproveLow && dec.Decision != "require_approval" && (verdict == nil || !verdict.NoGo()) && !s.quorumFor(score.Level, mergedConstraints).strict() {
 				approvalID, err := s.createApproval(r.Context(), planID, false)
 				if err != nil {
 					http.Error(w, "approval error", http.StatusBadGateway)
 					return
 				}
+				if err := s.AttestApproval(r.Context(), planID, Actor{ID: AutoApprover}, attest.SourceAuto); err != nil {
+					http.Error(w, "attestation error", http.StatusBadGateway)
+					return
+				}
 				if err := s.DB.UpdateApprovalStatusByPlan(r.Context(), planID, "approved"); err != nil {
 					http.Error(w, "db error", http.StatusInternalServerError)
 					return
@@ -488,11 +581,19 @@ func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
 			"intent":  req.Intent,
 		}, "")
 		s.emit("plan.updated", map[string]any{"plan_id": planID}, sessionID)
+		created := map[string]any{"plan_id": planID, "plan": plan}
+		if req.DraftID != "" {
+			created["draft_id"] = req.DraftID
+		}
+		s.emitSession("plan.created", created, sessionID)
 		resp := map[string]any{
 			"plan":       plan,
 			"plan_id":    planID,
 			"created_at": createdAt,
 		}
+		if req.DraftID != "" {
+			resp["draft_id"] = req.DraftID
+		}
 		writeJSON(w, http.StatusOK, resp)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -522,7 +623,7 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if _, err := s.policyCheckReadPlan(r, plan, "plan.diff"); err != nil {
-			http.Error(w, "policy denied", http.StatusForbidden)
+			writePolicyDenied(w, err)
 			return
 		}
 		steps, _ := parsePlanStepsPayload(plan["steps"])
@@ -534,6 +635,10 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusOK, diff)
 		return
 	}
+	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/attestations") {
+		s.handlePlanAttestations(w, r, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/plans/"), "/attestations"))
+		return
+	}
 	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/risk") {
 		if s.DB == nil {
 			http.Error(w, "db unavailable", http.StatusServiceUnavailable)
@@ -556,22 +661,33 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 		}
 		ctxRef, err := s.policyCheckReadPlan(r, plan, "plan.risk")
 		if err != nil {
-			http.Error(w, "policy denied", http.StatusForbidden)
+			writePolicyDenied(w, err)
 			return
 		}
-		risk := "medium"
-		if val, ok := plan["risk_level"].(string); ok && strings.TrimSpace(val) != "" {
-			risk = strings.TrimSpace(val)
-		}
 		steps, _ := parsePlanStepsPayload(plan["steps"])
 		targets := estimateTargets(steps)
+		score, ok := planRisk(plan)
+		if !ok || score.Factors == nil {
+			// Plans scored before the risk engine only have a level.
+			intent, _ := plan["intent"].(string)
+			score = s.scorePlanRisk(r.Context(), ctxRef, intent, steps).AtLeast(score.Level)
+		}
+		impact := s.planImpact(r.Context(), steps)
 		out := map[string]any{
 			"plan_id":      planID,
-			"risk_level":   risk,
-			"tier":         tierForRisk(risk),
-			"blast_radius": blastRadius(ctxRef, targets),
+			"risk_level":   score.Level,
+			"risk_score":   score.Value,
+			"factors":      score.Factors,
+			"tier":         score.Tier,
+			"blast_radius": blastRadius(ctxRef, targets, impact),
 			"targets":      targets,
 		}
+		if impact != nil {
+			out["impact"] = impact
+		}
+		if verdict := criticFromPlan(plan); verdict != nil {
+			out["critic"] = verdict
+		}
 		writeJSON(w, http.StatusOK, out)
 		return
 	}
@@ -613,15 +729,10 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "session denied", http.StatusForbidden)
 			return
 		}
-		risk := "medium"
-		if val, ok := plan["risk_level"].(string); ok && strings.TrimSpace(val) != "" {
-			risk = strings.TrimSpace(val)
-		} else if intent, ok := plan["intent"].(string); ok && strings.TrimSpace(intent) != "" {
-			risk = riskFromIntent(intent)
-		}
-		actionType := "write"
-		if risk == "read" {
-			actionType = "read"
+		intent, _ := plan["intent"].(string)
+		stored, ok := planRisk(plan)
+		if !ok && strings.TrimSpace(intent) == "" {
+			stored.Level = risk.LevelMedium
 		}
 		ctxRef := ContextRef{}
 		if ctxVal, ok := plan["context"].(map[string]any); ok {
@@ -633,38 +744,35 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		constraints := constraintsFromPlan(plan)
-		steps, _ := parsePlanStepsPayload(plan["steps"])
-		if len(steps) == 0 {
-			if details, ok := s.DB.(PlanDetailsProvider); ok {
-				if data, err := details.ListPlanSteps(r.Context(), planID); err == nil {
-					parsed, err := parsePlanStepsJSON(data)
-					if err == nil {
-						steps = parsed
-					}
-				}
-			}
+		steps := s.planSteps(r.Context(), planID, plan)
+		// Rescore at execution time: failures and the clock have moved on
+		// since the plan was created, but its level never drops.
+		score := s.scorePlanRisk(r.Context(), ctxRef, intent, steps).AtLeast(stored.Level)
+		actionType := "write"
+		if score.Level == risk.LevelRead {
+			actionType = "read"
 		}
-		dec, err := s.policyDecision(r, "plan.execute", actionType, ctxRef, risk, estimateTargets(steps))
+		dec, err := s.planPolicyDecision(r, "plan.execute", actionType, ctxRef, score, estimateTargets(steps), s.planImpact(r.Context(), steps), criticFromPlan(plan))
 		if err != nil {
-			s.auditEvent(r.Context(), "plan.execute", "deny", map[string]any{"plan_id": planID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.execute", map[string]any{"plan_id": planID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		switch dec.Decision {
 		case "allow":
 		case "require_approval":
 			if actionType != "write" {
-				s.auditEvent(r.Context(), "plan.execute", "deny", map[string]any{"plan_id": planID}, "approval required")
-				http.Error(w, "policy denied", http.StatusForbidden)
+				s.auditDenied(r.Context(), "plan.execute", map[string]any{"plan_id": planID}, deniedError(dec))
+				writePolicyDenied(w, deniedError(dec))
 				return
 			}
 		case "deny":
-			s.auditEvent(r.Context(), "plan.execute", "deny", map[string]any{"plan_id": planID}, "policy decision deny")
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.execute", map[string]any{"plan_id": planID}, deniedError(dec))
+			writePolicyDenied(w, deniedError(dec))
 			return
 		default:
-			s.auditEvent(r.Context(), "plan.execute", "deny", map[string]any{"plan_id": planID}, "policy decision "+dec.Decision)
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.execute", map[string]any{"plan_id": planID}, deniedError(dec))
+			writePolicyDenied(w, deniedError(dec))
 			return
 		}
 		if err := enforceConstraints(ctxRef, constraints, steps, time.Now().UTC(), actionType); err != nil {
@@ -673,6 +781,15 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if actionType == "write" {
+			if err := s.checkFreezes(r, "plan.execute", ctxRef, planServices(steps), map[string]any{"plan_id": planID}); err != nil {
+				if errors.Is(err, errFreezeCalendarUnavailable) {
+					http.Error(w, err.Error(), http.StatusServiceUnavailable)
+					return
+				}
+				s.auditDenied(r.Context(), "plan.execute", map[string]any{"plan_id": planID}, err)
+				writePolicyDenied(w, err)
+				return
+			}
 			status, err := s.approvalStatus(r.Context(), planID, execReq.ApprovalToken)
 			if err != nil {
 				s.auditEvent(r.Context(), "plan.execute", "deny", map[string]any{"plan_id": planID}, err.Error())
@@ -697,6 +814,22 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+		if actionType == "write" {
+			rule := s.quorumFor(score.Level, constraints)
+			if err := s.checkQuorum(r.Context(), planID, ComputePlanHash(intent, steps), planAuthor(plan), rule); err != nil {
+				var denied *policy.DeniedError
+				switch {
+				case errors.As(err, &denied):
+					s.auditDenied(r.Context(), "plan.execute", map[string]any{"plan_id": planID}, err)
+					writePolicyDenied(w, err)
+				case errors.Is(err, errApprovalVotesUnavailable):
+					http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				default:
+					http.Error(w, "db error", http.StatusInternalServerError)
+				}
+				return
+			}
+		}
 		if checker, ok := s.DB.(ActiveExecutionChecker); ok {
 			active, err := checker.HasActiveExecution(r.Context(), planID)
 			if err != nil {
@@ -750,7 +883,7 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if _, err := s.policyCheckReadPlan(r, plan, "plan.get"); err != nil {
-			http.Error(w, "policy denied", http.StatusForbidden)
+			writePolicyDenied(w, err)
 			return
 		}
 		if details, ok := s.DB.(PlanDetailsProvider); ok {
@@ -771,6 +904,14 @@ func (s *Server) handlePlanByID(w http.ResponseWriter, r *http.Request) {
 				plan["approvals"] = json.RawMessage(approvals)
 			}
 		}
+		if votes, ok := s.DB.(ApprovalVoteStore); ok {
+			data, err := votes.ListApprovalVotes(r.Context(), planID)
+			if err != nil {
+				http.Error(w, "db error", http.StatusInternalServerError)
+				return
+			}
+			plan["approval_votes"] = json.RawMessage(data)
+		}
 		_ = json.NewEncoder(w).Encode(plan)
 		return
 	}
@@ -820,16 +961,16 @@ func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid context", http.StatusBadRequest)
 			return
 		}
-		risk := riskFromIntent(req.Intent)
-		dec, err := s.policyDecision(r, "schedule.create", "write", req.Context, risk, 0)
+		score := s.scorePlanRisk(r.Context(), req.Context, req.Intent, nil)
+		dec, err := s.planPolicyDecision(r, "schedule.create", "write", req.Context, score, 0, nil, nil)
 		if err != nil {
-			s.auditEvent(r.Context(), "schedule.create", "deny", req.Context, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "schedule.create", req.Context, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		if dec.Decision != "allow" {
-			s.auditEvent(r.Context(), "schedule.create", "deny", req.Context, "policy decision "+dec.Decision)
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "schedule.create", req.Context, deniedError(dec))
+			writePolicyDenied(w, deniedError(dec))
 			return
 		}
 		payload := map[string]any{
@@ -921,14 +1062,15 @@ func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := s.policyCheck(r, "approval.create", "read", ContextRef{}, "read", 0); err != nil {
-		s.auditEvent(r.Context(), "approval.create", "deny", map[string]any{
+		s.auditDenied(r.Context(), "approval.create", map[string]any{
 			"plan_id": req.PlanID,
 			"status":  status,
-		}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	approvalID := ""
+	resp := map[string]any{}
 	if status == "pending" {
 		var err error
 		approvalID, err = s.createApproval(r.Context(), req.PlanID, true)
@@ -937,29 +1079,71 @@ func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
-		if err := s.DB.UpdateApprovalStatusByPlan(r.Context(), req.PlanID, status); err != nil {
+		if s.ApprovalExpired(r.Context(), req.PlanID) {
+			// Request approval again (status pending) to reopen it.
+			http.Error(w, "approval expired", http.StatusConflict)
+			return
+		}
+		planPayload, err := s.DB.GetPlan(r.Context(), req.PlanID)
+		if err != nil {
 			http.Error(w, "db error", http.StatusInternalServerError)
 			return
 		}
-		if status == "approved" {
-			if hashWriter, ok := s.DB.(ApprovalHashWriter); ok {
-				if planPayload, err := s.DB.GetPlan(r.Context(), req.PlanID); err == nil && planPayload != nil {
-					var planData map[string]any
-					if err := json.Unmarshal(planPayload, &planData); err == nil {
-						approvedSteps, _ := parsePlanStepsPayload(planData["steps"])
-						approvedIntent, _ := planData["intent"].(string)
-						_ = hashWriter.SetApprovalHash(r.Context(), req.PlanID, ComputePlanHash(approvedIntent, approvedSteps))
-					}
+		var progress approvalProgress
+		if planPayload != nil {
+			var planData map[string]any
+			if err := json.Unmarshal(planPayload, &planData); err != nil {
+				http.Error(w, "decode error", http.StatusInternalServerError)
+				return
+			}
+			progress, err = s.voteOnPlan(r.Context(), req.PlanID, planData, status)
+			if err != nil {
+				var denied *policy.DeniedError
+				switch {
+				case errors.As(err, &denied):
+					s.auditDenied(r.Context(), "approval.create", map[string]any{
+						"plan_id": req.PlanID,
+						"status":  status,
+					}, err)
+					writePolicyDenied(w, err)
+				case errors.Is(err, errApprovalVotesUnavailable):
+					http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				case errors.Is(err, errAttestationFailed):
+					http.Error(w, "attestation error", http.StatusBadGateway)
+				default:
+					http.Error(w, "db error", http.StatusInternalServerError)
+				}
+				return
+			}
+			if status == "approved" {
+				resp["approvals"] = progress.Approvals
+				resp["required"] = progress.Required
+				if progress.Approvals < progress.Required {
+					// Not enough approvers yet; the plan stays pending.
+					status = "pending"
 				}
 			}
 		}
+		if status != "pending" {
+			if err := s.DB.UpdateApprovalStatusByPlan(r.Context(), req.PlanID, status); err != nil {
+				http.Error(w, "db error", http.StatusInternalServerError)
+				return
+			}
+		}
+		if status == "approved" && progress.Hash != "" {
+			if hashWriter, ok := s.DB.(ApprovalHashWriter); ok {
+				_ = hashWriter.SetApprovalHash(r.Context(), req.PlanID, progress.Hash)
+			}
+		}
 	}
 	s.auditEvent(r.Context(), "approval.create", "allow", map[string]any{
 		"plan_id":     req.PlanID,
 		"status":      status,
 		"approval_id": approvalID,
 	}, "")
-	writeJSON(w, http.StatusOK, map[string]any{"approval_id": approvalID, "status": status})
+	resp["approval_id"] = approvalID
+	resp["status"] = status
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleExecutions(w http.ResponseWriter, r *http.Request) {
@@ -978,8 +1162,8 @@ func (s *Server) handleExecutions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := policyCheckTenantRead(s, r, "execution.list", tenantID); err != nil {
-		s.auditEvent(r.Context(), "execution.list", "deny", map[string]any{}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "execution.list", map[string]any{}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	limit, offset := parsePagination(r)
@@ -1005,7 +1189,7 @@ func (s *Server) handleExecutionByID(w http.ResponseWriter, r *http.Request) {
 							var plan map[string]any
 							if err := json.Unmarshal(planJSON, &plan); err == nil {
 								if _, err := s.policyCheckReadPlan(r, plan, "execution.logs"); err != nil {
-									http.Error(w, "policy denied", http.StatusForbidden)
+									writePolicyDenied(w, err)
 									return
 								}
 							}
@@ -1017,6 +1201,11 @@ func (s *Server) handleExecutionByID(w http.ResponseWriter, r *http.Request) {
 		s.handleExecutionLogs(w, r, execID)
 		return
 	}
+	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/report") {
+		execID := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/report"), "/v1/executions/")
+		s.handleExecutionReport(w, r, strings.TrimSuffix(execID, "/"))
+		return
+	}
 	if r.Method == http.MethodGet {
 		if s.DB == nil {
 			http.Error(w, "db unavailable", http.StatusServiceUnavailable)
@@ -1039,7 +1228,7 @@ func (s *Server) handleExecutionByID(w http.ResponseWriter, r *http.Request) {
 					var plan map[string]any
 					if err := json.Unmarshal(planJSON, &plan); err == nil {
 						if _, err := s.policyCheckReadPlan(r, plan, "execution.get"); err != nil {
-							http.Error(w, "policy denied", http.StatusForbidden)
+							writePolicyDenied(w, err)
 							return
 						}
 					}
@@ -1057,8 +1246,8 @@ func (s *Server) handleExecutionByID(w http.ResponseWriter, r *http.Request) {
 		execID := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/cancel"), "/v1/executions/")
 		execID = strings.TrimSuffix(execID, "/")
 		if err := s.policyCheck(r, "execution.cancel", "write", ContextRef{}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "execution.cancel", "deny", map[string]any{"execution_id": execID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "execution.cancel", map[string]any{"execution_id": execID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		err := s.DB.CancelExecution(r.Context(), execID)
@@ -1098,8 +1287,8 @@ func (s *Server) handleAuditEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := policyCheckTenantRead(s, r, "audit.list", tenantID); err != nil {
-		s.auditEvent(r.Context(), "audit.list", "deny", map[string]any{"tenant_id": tenantID}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "audit.list", map[string]any{"tenant_id": tenantID}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	filter.TenantID = tenantID
@@ -1127,8 +1316,8 @@ func (s *Server) handleContextServices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := policyCheckTenantRead(s, r, "context.services", tenantID); err != nil {
-		s.auditEvent(r.Context(), "context.services", "deny", map[string]any{}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "context.services", map[string]any{}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	limit, offset := parsePagination(r)
@@ -1163,8 +1352,8 @@ func (s *Server) handleContextSnapshots(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if err := policyCheckTenantRead(s, r, "context.snapshots", tenantID); err != nil {
-		s.auditEvent(r.Context(), "context.snapshots", "deny", map[string]any{}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "context.snapshots", map[string]any{}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	limit, offset := parsePagination(r)
@@ -1200,8 +1389,8 @@ func (s *Server) handleContextRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := s.policyCheck(r, "context.refresh", "read", ContextRef{}, "read", 0); err != nil {
-		s.auditEvent(r.Context(), "context.refresh", "deny", map[string]any{"service": req.Service}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "context.refresh", map[string]any{"service": req.Service}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	if len(req.Nodes) > 0 || len(req.Edges) > 0 {
@@ -1239,8 +1428,8 @@ func (s *Server) handleContextGraph(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := s.policyCheckRead(r, "context.graph"); err != nil {
-		s.auditEvent(r.Context(), "context.graph", "deny", map[string]any{"service": service}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "context.graph", map[string]any{"service": service}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	graph, err := s.Context.GetServiceGraph(r.Context(), service)
@@ -1303,7 +1492,7 @@ func (s *Server) handleHook(w http.ResponseWriter, r *http.Request) {
 			}
 			res, err := s.runAlertEventLoop(r.Context(), source, payload)
 			if err != nil {
-				s.auditEvent(r.Context(), "event.loop", "deny", map[string]any{"source": source}, err.Error())
+				s.auditDenied(r.Context(), "event.loop", map[string]any{"source": source}, err)
 				http.Error(w, "event loop error", http.StatusBadRequest)
 				return
 			}
@@ -1318,15 +1507,16 @@ func (s *Server) handleHook(w http.ResponseWriter, r *http.Request) {
 		ctxRef := contextFromHook(payload)
 		summary := hookSummary(source, payload)
 		intent := summary
-		risk := riskFromIntent(intent)
+		service := serviceFromHook(payload)
+		score := s.scorePlanRisk(r.Context(), ctxRef, intent, nil, service)
 		actionType := "read"
-		if risk != "read" {
+		if score.Level != risk.LevelRead {
 			actionType = "write"
 		}
-		dec, err := s.policyDecision(r, "plan.create", actionType, ctxRef, risk, 0)
+		dec, err := s.planPolicyDecision(r, "plan.create", actionType, ctxRef, score, 0, s.planImpact(r.Context(), nil, service), nil)
 		if err != nil {
-			s.auditEvent(r.Context(), "plan.create", "deny", ctxRef, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.create", ctxRef, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		mergedConstraints := mergeConstraints(nil, dec.Constraints)
@@ -1334,17 +1524,17 @@ func (s *Server) handleHook(w http.ResponseWriter, r *http.Request) {
 		case "allow":
 		case "require_approval":
 			if actionType != "write" {
-				s.auditEvent(r.Context(), "plan.create", "deny", ctxRef, "approval required")
-				http.Error(w, "policy denied", http.StatusForbidden)
+				s.auditDenied(r.Context(), "plan.create", ctxRef, deniedError(dec))
+				writePolicyDenied(w, deniedError(dec))
 				return
 			}
 		default:
-			s.auditEvent(r.Context(), "plan.create", "deny", ctxRef, "policy decision "+dec.Decision)
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "plan.create", ctxRef, deniedError(dec))
+			writePolicyDenied(w, deniedError(dec))
 			return
 		}
 		createdAt := time.Now().UTC()
-		var planText string
+		var draft planDraft
 		if s.Planner != nil && strings.TrimSpace(intent) != "" {
 			planContext := map[string]any{
 				"context": ctxRef,
@@ -1353,29 +1543,40 @@ func (s *Server) handleHook(w http.ResponseWriter, r *http.Request) {
 				"payload": payload,
 				"trigger": "webhook",
 			}
-			draft, err := s.Planner.Plan(intent, planContext, nil)
+			if service != "" {
+				planContext["service"] = service
+			}
+			var err error
+			draft, err = s.draftPlan(r.Context(), ctxRef, intent, planContext, nil)
+			if errors.Is(err, ErrLLMBudgetExceeded) {
+				http.Error(w, "llm budget exceeded", http.StatusTooManyRequests)
+				return
+			}
 			if err != nil {
 				s.auditEvent(r.Context(), "plan.create", "deny", ctxRef, err.Error())
 				http.Error(w, "planner error", http.StatusBadGateway)
 				return
 			}
-			planText = draft
 		}
 		plan := map[string]any{
 			"trigger":     "webhook",
 			"summary":     summary,
 			"context":     ctxRef,
-			"risk_level":  risk,
 			"intent":      intent,
 			"constraints": mergedConstraints,
 			"created_at":  createdAt,
 		}
-		if planText != "" {
-			plan["plan_text"] = planText
-			if steps := parsePlanSteps(planText); len(steps) > 0 {
+		setPlanMeta(plan, "alert", map[string]any{"source": source, "payload": payload})
+		draft.annotate(plan)
+		if draft.Text != "" {
+			plan["plan_text"] = draft.Text
+			if steps := planDraftSteps(plan, draft.Text); len(steps) > 0 {
 				plan["steps"] = steps
+				score = s.scorePlanRisk(r.Context(), ctxRef, intent, planStepsFromDrafts(steps), service).AtLeast(score.Level)
 			}
 		}
+		setPlanRisk(plan, score)
+		verdict := s.critiquePlan(r.Context(), plan, ctxRef)
 		data, err := marshalJSON(plan)
 		if err != nil {
 			http.Error(w, "encode error", http.StatusInternalServerError)
@@ -1386,6 +1587,8 @@ func (s *Server) handleHook(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "db error", http.StatusInternalServerError)
 			return
 		}
+		s.recordLLMUsage(r.Context(), planID, "webhook", ctxRef, draft.Usage)
+		s.recordCriticUsage(r.Context(), planID, ctxRef, verdict)
 		// Webhook-triggered plans always require human approval regardless
 		// of risk level. The risk classification is based on intent keywords
 		// which can be gamed, and LLM-generated plans are not trusted.
@@ -1427,27 +1630,28 @@ func (s *Server) approvalStatus(ctx context.Context, planID, approvalToken strin
 	return "", errors.New("approval status unavailable")
 }
 
+// planSteps returns the plan's steps, reading them from the store when the
+// plan payload does not carry them.
+func (s *Server) planSteps(ctx context.Context, planID string, plan map[string]any) []PlanStep {
+	steps, _ := parsePlanStepsPayload(plan["steps"])
+	if len(steps) > 0 {
+		return steps
+	}
+	if details, ok := s.DB.(PlanDetailsProvider); ok {
+		if data, err := details.ListPlanSteps(ctx, planID); err == nil {
+			if parsed, err := parsePlanStepsJSON(data); err == nil {
+				return parsed
+			}
+		}
+	}
+	return steps
+}
+
 // ComputePlanHash computes a SHA-256 hash over the plan's intent and steps.
 // The hash is stored at approval time and verified before execution to detect
 // plan tampering between approval and execution (SEC-01).
 func ComputePlanHash(intent string, steps []PlanStep) string {
-	normalized := make([]PlanStep, len(steps))
-	copy(normalized, steps)
-	for i := range normalized {
-		if strings.TrimSpace(normalized[i].Stage) == "" {
-			normalized[i].Stage = "act"
-		}
-	}
-	canonical := struct {
-		Intent string     `json:"intent"`
-		Steps  []PlanStep `json:"steps"`
-	}{Intent: intent, Steps: normalized}
-	data, err := json.Marshal(canonical)
-	if err != nil {
-		return ""
-	}
-	sum := sha256.Sum256(data)
-	return hex.EncodeToString(sum[:])
+	return attest.PlanHash(intent, attestSteps(steps))
 }
 
 func hookSummary(source string, payload map[string]any) string {
@@ -1590,6 +1794,13 @@ func parseAuditFilter(r *http.Request) (db.AuditFilter, error) {
 	filter.ActorID = query.Get("actor_id")
 	filter.Action = query.Get("action")
 	filter.Decision = query.Get("decision")
+	if v := query.Get("has_policy_input"); v != "" {
+		has, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.HasPolicyInput = has
+	}
 	filter.Limit, filter.Offset = parsePagination(r)
 	return filter, nil
 }
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"carapulse/internal/llm"
+	"carapulse/internal/metrics"
+	"carapulse/internal/retrieval"
+)
+
+var ErrLLMBudgetExceeded = errors.New("llm budget exceeded")
+
+// LLMUsageStore persists per-draft token usage and sums it for budgets.
+type LLMUsageStore interface {
+	InsertLLMUsage(ctx context.Context, payload []byte) error
+	LLMUsageSince(ctx context.Context, tenantID string, since time.Time) (int64, float64, error)
+}
+
+// LLMBudget caps a tenant's LLM consumption per UTC day. Zero fields are
+// unlimited.
+type LLMBudget struct {
+	DailyTokens  int64
+	DailyCostUSD float64
+}
+
+func (b LLMBudget) enabled() bool {
+	return b.DailyTokens > 0 || b.DailyCostUSD > 0
+}
+
+func (s *Server) llmBudget(tenantID string) LLMBudget {
+	if budget, ok := s.TenantLLMBudgets[tenantID]; ok {
+		return budget
+	}
+	return s.LLMBudget
+}
+
+// checkLLMBudget refuses a draft once the tenant has spent today's budget.
+// A store error does not block planning.
+func (s *Server) checkLLMBudget(ctx context.Context, tenantID string) error {
+	budget := s.llmBudget(tenantID)
+	if !budget.enabled() {
+		return nil
+	}
+	store, ok := s.DB.(LLMUsageStore)
+	if !ok {
+		return nil
+	}
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	tokens, cost, err := store.LLMUsageSince(ctx, tenantID, dayStart)
+	if err != nil {
+		slog.Warn("llm budget check failed", "tenant_id", tenantID, "error", err)
+		return nil
+	}
+	if budget.DailyTokens > 0 && tokens >= budget.DailyTokens {
+		return fmt.Errorf("%w: %d of %d daily tokens used", ErrLLMBudgetExceeded, tokens, budget.DailyTokens)
+	}
+	if budget.DailyCostUSD > 0 && cost >= budget.DailyCostUSD {
+		return fmt.Errorf("%w: $%.2f of $%.2f daily spend used", ErrLLMBudgetExceeded, cost, budget.DailyCostUSD)
+	}
+	return nil
+}
+
+// planDraft is a planner answer with what went into it.
+type planDraft struct {
+	Text       string
+	Usage      *llm.Usage
+	References []retrieval.Hit
+}
+
+// annotate records the draft's usage, references and citations in
+// plan["meta"].
+func (d planDraft) annotate(plan map[string]any) {
+	if d.Usage != nil {
+		setPlanMeta(plan, "llm_usage", d.Usage)
+	}
+	if len(d.References) > 0 {
+		refs, citations := planReferences(d.References, d.Text)
+		setPlanMeta(plan, "references", refs)
+		if len(citations) > 0 {
+			setPlanMeta(plan, "citations", citations)
+		}
+	}
+}
+
+// draftPlan asks the planner for a draft after checking the tenant's
+// budget, adding retrieved references to planContext. Usage is nil when
+// the planner does not report it.
+func (s *Server) draftPlan(ctx context.Context, ctxRef ContextRef, intent string, planContext, evidence any) (planDraft, error) {
+	return s.draftPlanStream(ctx, ctxRef, intent, planContext, evidence, nil)
+}
+
+// draftPlanStream is draftPlan reporting the draft to onDelta as it is
+// generated, when the planner can stream.
+func (s *Server) draftPlanStream(ctx context.Context, ctxRef ContextRef, intent string, planContext, evidence any, onDelta func(llm.DraftDelta)) (planDraft, error) {
+	tenantID := strings.TrimSpace(ctxRef.TenantID)
+	if err := s.checkLLMBudget(ctx, tenantID); err != nil {
+		metrics.LLMBudgetRejectionsTotal.WithLabelValues(tenantID).Inc()
+		s.auditEvent(ctx, "llm.budget", "deny", ctxRef, err.Error())
+		return planDraft{}, err
+	}
+	hits := s.retrieveReferences(ctx, tenantID, intent, planContext)
+	usagePlanner, ok := s.Planner.(llm.UsagePlanner)
+	if !ok {
+		text, err := s.Planner.Plan(intent, planContext, evidence)
+		if err != nil {
+			return planDraft{}, err
+		}
+		return planDraft{Text: text, References: hits}, nil
+	}
+	var text string
+	var usage llm.Usage
+	var err error
+	if streamPlanner, ok := s.Planner.(llm.StreamPlanner); ok && onDelta != nil {
+		text, usage, err = streamPlanner.PlanStream(intent, planContext, evidence, onDelta)
+	} else {
+		text, usage, err = usagePlanner.PlanWithUsage(intent, planContext, evidence)
+	}
+	if err != nil {
+		return planDraft{}, err
+	}
+	metrics.LLMTokensTotal.WithLabelValues(tenantID, usage.Provider, usage.Model, "prompt").Add(float64(usage.PromptTokens))
+	metrics.LLMTokensTotal.WithLabelValues(tenantID, usage.Provider, usage.Model, "completion").Add(float64(usage.CompletionTokens))
+	if usage.CostUSD > 0 {
+		metrics.LLMCostUSDTotal.WithLabelValues(tenantID, usage.Provider, usage.Model).Add(usage.CostUSD)
+	}
+	return planDraft{Text: text, Usage: &usage, References: hits}, nil
+}
+
+// recordLLMUsage stores usage against the plan for budget accounting.
+func (s *Server) recordLLMUsage(ctx context.Context, planID, trigger string, ctxRef ContextRef, usage *llm.Usage) {
+	if usage == nil {
+		return
+	}
+	store, ok := s.DB.(LLMUsageStore)
+	if !ok {
+		return
+	}
+	payload, err := marshalJSON(map[string]any{
+		"tenant_id":         strings.TrimSpace(ctxRef.TenantID),
+		"plan_id":           planID,
+		"trigger":           trigger,
+		"provider":          usage.Provider,
+		"model":             usage.Model,
+		"prompt_tokens":     usage.PromptTokens,
+		"completion_tokens": usage.CompletionTokens,
+		"total_tokens":      usage.TotalTokens,
+		"cost_usd":          usage.CostUSD,
+		"latency_ms":        usage.LatencyMS,
+		"fallback_index":    usage.FallbackIndex,
+	})
+	if err != nil {
+		return
+	}
+	if err := store.InsertLLMUsage(ctx, payload); err != nil {
+		slog.Warn("record llm usage failed", "plan_id", planID, "tenant_id", ctxRef.TenantID, "error", err)
+	}
+}
+
+// setPlanMeta adds key to plan["meta"], creating it if needed.
+func setPlanMeta(plan map[string]any, key string, value any) {
+	if meta, ok := plan["meta"].(map[string]any); ok {
+		meta[key] = value
+		return
+	}
+	plan["meta"] = map[string]any{key: value}
+}
@@ -50,8 +50,8 @@ func (s *Server) handleOperatorMemory(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := s.policyCheck(r, "memory.create", "write", ContextRef{TenantID: tenant}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "memory.create", "deny", map[string]any{"tenant_id": tenant}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "memory.create", map[string]any{"tenant_id": tenant}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload := map[string]any{
@@ -73,6 +73,7 @@ func (s *Server) handleOperatorMemory(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "db error", http.StatusInternalServerError)
 			return
 		}
+		s.Retriever.Invalidate(tenant)
 		s.auditEvent(r.Context(), "memory.create", "allow", map[string]any{"memory_id": id, "tenant_id": tenant}, "")
 		_ = json.NewEncoder(w).Encode(map[string]any{"memory_id": id})
 	case http.MethodGet:
@@ -85,8 +86,8 @@ func (s *Server) handleOperatorMemory(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "memory.list", tenant); err != nil {
-			s.auditEvent(r.Context(), "memory.list", "deny", map[string]any{"tenant_id": tenant}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "memory.list", map[string]any{"tenant_id": tenant}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := store.ListOperatorMemory(r.Context(), tenant)
@@ -162,8 +163,8 @@ func (s *Server) handleOperatorMemoryByID(w http.ResponseWriter, r *http.Request
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "memory.get", tenantID); err != nil {
-			s.auditEvent(r.Context(), "memory.get", "deny", map[string]any{"memory_id": memoryID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "memory.get", map[string]any{"memory_id": memoryID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := store.GetOperatorMemory(r.Context(), memoryID)
@@ -223,8 +224,8 @@ func (s *Server) handleOperatorMemoryByID(w http.ResponseWriter, r *http.Request
 			return
 		}
 		if err := s.policyCheck(r, "memory.update", "write", ContextRef{TenantID: tenant}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "memory.update", "deny", map[string]any{"memory_id": memoryID, "tenant_id": tenant}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "memory.update", map[string]any{"memory_id": memoryID, "tenant_id": tenant}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload := map[string]any{
@@ -253,8 +254,8 @@ func (s *Server) handleOperatorMemoryByID(w http.ResponseWriter, r *http.Request
 			return
 		}
 		if err := s.policyCheck(r, "memory.delete", "write", ContextRef{TenantID: tenant}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "memory.delete", "deny", map[string]any{"memory_id": memoryID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "memory.delete", map[string]any{"memory_id": memoryID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		if err := store.DeleteOperatorMemory(r.Context(), memoryID); err != nil {
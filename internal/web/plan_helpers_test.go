@@ -22,104 +22,33 @@ func TestIsWriteAction(t *testing.T) {
 	}
 }
 
-func TestRiskFromIntent(t *testing.T) {
-	cases := []struct {
-		intent string
-		want   string
-	}{
-		{"deploy service", "low"},
-		{"scale api", "low"},
-		{"rollback release", "low"},
-		{"sync argo", "medium"},
-		{"restart deployment", "medium"},
-		{"delete user", "high"},
-		{"update iam role", "high"},
-		{"show status", "read"},
-	}
-	for _, c := range cases {
-		got := riskFromIntent(c.intent)
-		if got != c.want {
-			t.Fatalf("intent %q: want %s got %s", c.intent, c.want, got)
-		}
-	}
-}
-
-func TestRiskFromSteps(t *testing.T) {
-	cases := []struct {
-		name  string
-		steps []PlanStep
-		want  string
-	}{
-		{"empty", nil, "read"},
-		{"read only", []PlanStep{{Tool: "prometheus", Action: "query"}}, "read"},
-		{"kubectl get", []PlanStep{{Tool: "kubectl", Action: "get"}}, "read"},
-		{"kubectl delete is high", []PlanStep{{Tool: "kubectl", Action: "delete"}}, "high"},
-		{"kubectl exec is high", []PlanStep{{Tool: "kubectl", Action: "exec"}}, "high"},
-		{"helm upgrade is medium", []PlanStep{{Tool: "helm", Action: "upgrade"}}, "medium"},
-		{"helm uninstall is high", []PlanStep{{Tool: "helm", Action: "uninstall"}}, "high"},
-		{"argocd sync is medium", []PlanStep{{Tool: "argocd", Action: "sync"}}, "medium"},
-		{"argocd delete is high", []PlanStep{{Tool: "argocd", Action: "delete"}}, "high"},
-		{"aws terminate is high", []PlanStep{{Tool: "aws", Action: "terminate-instances"}}, "high"},
-		{"mixed read+high returns high", []PlanStep{
-			{Tool: "prometheus", Action: "query"},
-			{Tool: "kubectl", Action: "delete"},
-		}, "high"},
-		{"mixed medium+low returns medium", []PlanStep{
-			{Tool: "helm", Action: "upgrade"},
-			{Tool: "grafana", Action: "annotate"},
-		}, "medium"},
-	}
-	for _, c := range cases {
-		got := riskFromSteps(c.steps)
-		if got != c.want {
-			t.Fatalf("%s: want %s got %s", c.name, c.want, got)
-		}
-	}
-}
-
-func TestEffectiveRiskEscalatesOnly(t *testing.T) {
-	// Intent says "low" but steps contain kubectl delete -> should escalate to high.
-	steps := []PlanStep{{Tool: "kubectl", Action: "delete"}}
-	got := effectiveRisk("low", steps)
-	if got != "high" {
-		t.Fatalf("want high, got %s", got)
-	}
-
-	// Intent says "high" but steps are read-only -> should stay high (never downgrade).
-	readSteps := []PlanStep{{Tool: "prometheus", Action: "query"}}
-	got = effectiveRisk("high", readSteps)
-	if got != "high" {
-		t.Fatalf("want high, got %s", got)
-	}
-}
-
-func TestRiskFromStepDrafts(t *testing.T) {
-	drafts := []planStepDraft{
-		{Tool: "kubectl", Action: "apply"},
+func TestRiskSteps(t *testing.T) {
+	steps := riskSteps([]PlanStep{
 		{Tool: "prometheus", Action: "query"},
+		{Tool: "Kubectl", Action: "Delete", Rollback: map[string]any{"action": "apply"}},
+		{Tool: "helm", Action: "upgrade", Rollback: map[string]any{}},
+		{Tool: "", Action: "noop"},
+	})
+	if len(steps) != 3 {
+		t.Fatalf("steps: %#v", steps)
 	}
-	got := riskFromStepDrafts(drafts)
-	if got != "high" {
-		t.Fatalf("want high, got %s", got)
+	if steps[0].Level != "read" || steps[1].Level != "high" || !steps[1].Rollback || steps[2].Level != "medium" || steps[2].Rollback {
+		t.Fatalf("steps: %#v", steps)
 	}
 }
 
-func TestEffectiveRiskDraftsEscalates(t *testing.T) {
-	drafts := []planStepDraft{{Tool: "helm", Action: "uninstall"}}
-	got := effectiveRiskDrafts("low", drafts)
-	if got != "high" {
-		t.Fatalf("want high, got %s", got)
-	}
-}
-
-func TestRiskOrd(t *testing.T) {
-	if riskOrd("read") >= riskOrd("low") {
-		t.Fatalf("read should be less than low")
+func TestPlanStepsFromDraftsAndServices(t *testing.T) {
+	drafts := []planStepDraft{
+		{Tool: "kubectl", Action: "rollout", Input: []byte(`{"deployment":"checkout"}`)},
+		{Tool: "helm", Action: "upgrade", Input: []byte(`{"release":"payments"}`), Rollback: []byte(`{"action":"rollback"}`)},
+		{Tool: "prometheus", Action: "query", Input: []byte(`{"query":"up"}`)},
 	}
-	if riskOrd("low") >= riskOrd("medium") {
-		t.Fatalf("low should be less than medium")
+	steps := planStepsFromDrafts(drafts)
+	if len(steps) != 3 || steps[0].Stage != "act" || !hasRollback(steps[1].Rollback) {
+		t.Fatalf("steps: %#v", steps)
 	}
-	if riskOrd("medium") >= riskOrd("high") {
-		t.Fatalf("medium should be less than high")
+	services := planServices(steps)
+	if len(services) != 2 || services[0] != "checkout" || services[1] != "payments" {
+		t.Fatalf("services: %v", services)
 	}
 }
@@ -30,23 +30,49 @@ func isRegisteredTool(tool string) bool {
 	return registeredTools()[strings.ToLower(strings.TrimSpace(tool))]
 }
 
+// DroppedStep is a drafted step that failed validation and was left out
+// of the plan, reported under meta.dropped_steps.
+type DroppedStep struct {
+	Index  int    `json:"index"`
+	Action string `json:"action,omitempty"`
+	Tool   string `json:"tool,omitempty"`
+	Reason string `json:"reason"`
+}
+
 func parsePlanSteps(planText string) []planStepDraft {
+	steps, _ := parsePlanStepsReport(planText)
+	return steps
+}
+
+// parsePlanStepsReport parses a draft and returns the valid steps along
+// with the ones it dropped and why.
+func parsePlanStepsReport(planText string) ([]planStepDraft, []DroppedStep) {
 	text := strings.TrimSpace(planText)
 	if text == "" {
-		return nil
+		return nil, nil
 	}
 	text = extractJSONBlock(text)
 	steps := decodePlanStepsPayload(text)
 	if len(steps) == 0 {
-		return nil
+		return nil, nil
 	}
 	out := make([]planStepDraft, 0, len(steps))
-	for _, step := range steps {
+	var dropped []DroppedStep
+	for i, step := range steps {
+		drop := func(reason string) {
+			dropped = append(dropped, DroppedStep{Index: i, Action: step.Action, Tool: step.Tool, Reason: reason})
+		}
 		if strings.TrimSpace(step.Action) == "" || strings.TrimSpace(step.Tool) == "" {
+			drop("action and tool required")
 			continue
 		}
 		// Reject steps referencing tools not in the registry.
 		if !isRegisteredTool(step.Tool) {
+			drop("unknown tool")
+			continue
+		}
+		if err := tools.ValidatePlanStep(step.Tool, step.Action, step.Input); err != nil {
+			drop(err.Error())
 			continue
 		}
 		if strings.TrimSpace(step.Stage) == "" {
@@ -54,7 +80,17 @@ func parsePlanSteps(planText string) []planStepDraft {
 		}
 		out = append(out, step)
 	}
-	return out
+	return out, dropped
+}
+
+// planDraftSteps parses planText for plan and records dropped steps in
+// plan["meta"] so a partial plan explains itself.
+func planDraftSteps(plan map[string]any, planText string) []planStepDraft {
+	steps, dropped := parsePlanStepsReport(planText)
+	if len(dropped) > 0 {
+		setPlanMeta(plan, "dropped_steps", dropped)
+	}
+	return steps
 }
 
 func extractJSONBlock(text string) string {
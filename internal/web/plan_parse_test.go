@@ -1,6 +1,9 @@
 package web
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParsePlanStepsObject(t *testing.T) {
 	text := `{"steps":[{"action":"deploy","tool":"helm","input":{"release":"app"}}]}`
@@ -76,13 +79,30 @@ func TestParsePlanStepsAllUnknownTools(t *testing.T) {
 }
 
 func TestParsePlanStepsAcceptsRegisteredTools(t *testing.T) {
-	text := `[{"action":"sync","tool":"argocd","input":{}},{"action":"query","tool":"prometheus","input":{}}]`
+	text := `[{"action":"sync","tool":"argocd","input":{"app":"web"}},{"action":"query","tool":"prometheus","input":{"query":"up"}}]`
 	steps := parsePlanSteps(text)
 	if len(steps) != 2 {
 		t.Fatalf("expected 2 steps, got %d", len(steps))
 	}
 }
 
+func TestParsePlanStepsReportsDropped(t *testing.T) {
+	text := `{"steps":[{"action":"sync","tool":"argocd","input":{}},{"action":"deploy","tool":"curl","input":{}},{"tool":"helm"},{"action":"status","tool":"helm","input":{"release":"app"}}]}`
+	steps, dropped := parsePlanStepsReport(text)
+	if len(steps) != 1 || steps[0].Action != "status" {
+		t.Fatalf("steps: %#v", steps)
+	}
+	if len(dropped) != 3 {
+		t.Fatalf("dropped: %#v", dropped)
+	}
+	if dropped[0].Index != 0 || !strings.Contains(dropped[0].Reason, "app") {
+		t.Fatalf("schema drop: %#v", dropped[0])
+	}
+	if dropped[1].Reason != "unknown tool" || dropped[2].Reason != "action and tool required" {
+		t.Fatalf("reasons: %#v", dropped)
+	}
+}
+
 func TestIsRegisteredTool(t *testing.T) {
 	cases := []struct {
 		tool string
@@ -107,3 +127,19 @@ func TestIsRegisteredTool(t *testing.T) {
 		}
 	}
 }
+
+func TestPlanDraftStepsRecordsDroppedInMeta(t *testing.T) {
+	plan := map[string]any{"meta": map[string]any{"diagnostics": []any{}}}
+	steps := planDraftSteps(plan, `{"steps":[{"action":"status","tool":"helm","input":{}}]}`)
+	if len(steps) != 0 {
+		t.Fatalf("steps: %#v", steps)
+	}
+	meta := plan["meta"].(map[string]any)
+	dropped, ok := meta["dropped_steps"].([]DroppedStep)
+	if !ok || len(dropped) != 1 || dropped[0].Tool != "helm" {
+		t.Fatalf("meta: %#v", meta)
+	}
+	if _, ok := meta["diagnostics"]; !ok {
+		t.Fatalf("existing meta lost: %#v", meta)
+	}
+}
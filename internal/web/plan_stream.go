@@ -0,0 +1,58 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"carapulse/internal/llm"
+)
+
+// draftDeltaFlushBytes batches streamed tokens into plan.draft.delta
+// events; subscriber queues are short and drop when full.
+const draftDeltaFlushBytes = 64
+
+// draftDeltaEmitter publishes a streamed plan draft on the session's event
+// channel only; unscoped subscribers never see draft text. Offset is the
+// position of Delta in the attempt's text, so a client can detect a
+// dropped event and wait for plan.created.
+type draftDeltaEmitter struct {
+	s         *Server
+	sessionID string
+	draftID   string
+	attempt   int
+	offset    int
+	buf       strings.Builder
+}
+
+func (e *draftDeltaEmitter) add(d llm.DraftDelta) {
+	if d.Attempt != e.attempt {
+		e.flush()
+		e.attempt, e.offset = d.Attempt, 0
+	}
+	e.buf.WriteString(d.Text)
+	if e.buf.Len() >= draftDeltaFlushBytes || strings.Contains(d.Text, "\n") {
+		e.flush()
+	}
+}
+
+func (e *draftDeltaEmitter) flush() {
+	if e.buf.Len() == 0 {
+		return
+	}
+	delta := e.buf.String()
+	e.buf.Reset()
+	e.s.emitSession("plan.draft.delta", map[string]any{
+		"draft_id": e.draftID,
+		"attempt":  e.attempt,
+		"offset":   e.offset,
+		"delta":    delta,
+	}, e.sessionID)
+	e.offset += len(delta)
+}
+
+func newDraftID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return "draft_" + hex.EncodeToString(b[:])
+}
@@ -39,8 +39,8 @@ func (s *Server) handlePlaybooks(w http.ResponseWriter, r *http.Request) {
 		}
 		req.TenantID = tenantID
 		if err := s.policyCheck(r, "playbook.create", "write", ContextRef{TenantID: tenantID}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "playbook.create", "deny", map[string]any{"name": req.Name}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "playbook.create", map[string]any{"name": req.Name}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload := map[string]any{
@@ -61,6 +61,7 @@ func (s *Server) handlePlaybooks(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "db error", http.StatusInternalServerError)
 			return
 		}
+		s.Retriever.Invalidate(tenantID)
 		s.auditEvent(r.Context(), "playbook.create", "allow", map[string]any{"playbook_id": id, "name": req.Name}, "")
 		_ = json.NewEncoder(w).Encode(map[string]any{"playbook_id": id})
 	case http.MethodGet:
@@ -74,8 +75,8 @@ func (s *Server) handlePlaybooks(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "playbook.list", tenantID); err != nil {
-			s.auditEvent(r.Context(), "playbook.list", "deny", map[string]any{}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "playbook.list", map[string]any{}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		limit, offset := parsePagination(r)
@@ -114,8 +115,8 @@ func (s *Server) handlePlaybookByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "playbook.get", tenantID); err != nil {
-			s.auditEvent(r.Context(), "playbook.get", "deny", map[string]any{"playbook_id": playbookID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "playbook.get", map[string]any{"playbook_id": playbookID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := s.DB.GetPlaybook(r.Context(), playbookID)
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -8,15 +9,27 @@ import (
 	"sync"
 	"time"
 
+	ctxmodel "carapulse/internal/context"
+	"carapulse/internal/llm"
 	"carapulse/internal/policy"
+	"carapulse/internal/risk"
 )
 
 var warnPolicyAllowAllOnce sync.Once
 
-func (s *Server) policyDecision(r *http.Request, actionName string, actionType string, ctxRef ContextRef, risk string, targets int) (policy.PolicyDecision, error) {
+func (s *Server) policyDecision(r *http.Request, actionName string, actionType string, ctxRef ContextRef, level string, targets int) (policy.PolicyDecision, error) {
+	return s.planPolicyDecision(r, actionName, actionType, ctxRef, risk.Score{Level: level}, targets, nil, nil)
+}
+
+// planPolicyDecision is policyDecision for a scored plan: the score and
+// its breakdown, the blast radius impact and the critic's verdict, when
+// there are any, are passed to OPA, and a critic no_go never auto-allows a
+// write.
+func (s *Server) planPolicyDecision(r *http.Request, actionName string, actionType string, ctxRef ContextRef, score risk.Score, targets int, impact *ctxmodel.Impact, critic *llm.CriticVerdict) (policy.PolicyDecision, error) {
 	actor, _ := ActorFromContext(r.Context())
-	tier := tierForRisk(risk)
-	blast := blastRadius(ctxRef, targets)
+	level := score.Level
+	tier := risk.TierFor(level)
+	blast := blastRadius(ctxRef, targets, impact)
 	breakGlass := strings.EqualFold(strings.TrimSpace(r.Header.Get("X-Break-Glass")), "true")
 	dec := policy.PolicyDecision{Decision: "allow"}
 	if s.Policy == nil || s.Policy.Checker == nil {
@@ -26,15 +39,17 @@ func (s *Server) policyDecision(r *http.Request, actionName string, actionType s
 		return policy.PolicyDecision{Decision: "allow"}, nil
 	}
 	if s.Policy != nil {
-		var err error
-		dec, err = s.Policy.Check(r.Context(), policy.PolicyInput{
+		input := policy.PolicyInput{
 			Actor:     actor,
 			Action:    policy.Action{Name: actionName, Type: actionType},
 			Context:   ctxRef,
-			Risk:      policy.Risk{Level: risk, Targets: targets, BlastRadius: blast, Tier: tier},
+			Risk:      policy.Risk{Level: level, Score: score.Value, Factors: score.Factors, Targets: targets, BlastRadius: blast, Impact: impact, Tier: tier, Critic: criticPolicyInput(critic)},
 			Time:      time.Now().UTC().Format(time.RFC3339),
 			Resources: map[string]any{"break_glass": breakGlass},
-		})
+		}
+		var err error
+		dec, err = s.Policy.Check(r.Context(), input)
+		recordPolicyInput(r.Context(), actionName, input, dec.Revision)
 		if err != nil {
 			if actionType == "read" {
 				return policy.PolicyDecision{Decision: "allow"}, nil
@@ -46,27 +61,98 @@ func (s *Server) policyDecision(r *http.Request, actionName string, actionType s
 		}
 	}
 	if actionType == "write" && strings.EqualFold(strings.TrimSpace(ctxRef.Environment), "prod") && dec.Decision == "allow" {
-		dec.Decision = "require_approval"
+		overrideDecision(&dec, "require_approval", policy.Reason{Rule: "prod_write", Message: "writes to prod require approval", Field: "context.environment"})
 	}
-	if actionType == "write" && dec.Decision == "allow" && risk != "read" && risk != "low" {
-		dec.Decision = "require_approval"
+	if actionType == "write" && dec.Decision == "allow" && level != "read" && level != "low" {
+		overrideDecision(&dec, "require_approval", policy.Reason{Rule: "risk_level", Message: level + " risk writes require approval", Field: "risk.level"})
 	}
 	if actionType == "write" && blast == "account" {
-		dec.Decision = "require_approval"
+		overrideDecision(&dec, "require_approval", policy.Reason{Rule: "account_blast_radius", Message: "account-wide changes require approval", Field: "risk.blast_radius"})
+	}
+	if actionType == "write" && dec.Decision == "allow" && critic != nil && critic.NoGo() {
+		overrideDecision(&dec, "require_approval", policy.Reason{Rule: "critic_no_go", Message: "the plan critic recommended no_go", Field: "risk.critic.recommendation"})
 	}
 	if actionType == "write" && tier == "break_glass" && !breakGlass {
-		dec.Decision = "deny"
+		overrideDecision(&dec, "deny", policy.Reason{Rule: "break_glass_header_required", Message: "break-glass actions need the X-Break-Glass: true header", Field: "resources.break_glass"})
 	}
 	return dec, nil
 }
 
-func (s *Server) policyCheck(r *http.Request, actionName string, actionType string, ctxRef ContextRef, risk string, targets int) error {
-	dec, err := s.policyDecision(r, actionName, actionType, ctxRef, risk, targets)
+type policyInputsKey struct{}
+
+// policyInputs remembers, per action, the last policy input evaluated while
+// serving a request and the bundle revision that decided it, so the audit
+// event for that action can store both for `assistantctl policy simulate`.
+type policyInputs struct {
+	mu       sync.Mutex
+	byAction map[string]recordedPolicy
+}
+
+type recordedPolicy struct {
+	input    policy.PolicyInput
+	revision string
+}
+
+func withPolicyInputs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, policyInputsKey{}, &policyInputs{byAction: map[string]recordedPolicy{}})
+}
+
+func recordPolicyInput(ctx context.Context, action string, input policy.PolicyInput, revision string) {
+	inputs, ok := ctx.Value(policyInputsKey{}).(*policyInputs)
+	if !ok {
+		return
+	}
+	inputs.mu.Lock()
+	inputs.byAction[action] = recordedPolicy{input: input, revision: revision}
+	inputs.mu.Unlock()
+}
+
+func recordedPolicyInput(ctx context.Context, action string) (recordedPolicy, bool) {
+	inputs, ok := ctx.Value(policyInputsKey{}).(*policyInputs)
+	if !ok {
+		return recordedPolicy{}, false
+	}
+	inputs.mu.Lock()
+	defer inputs.mu.Unlock()
+	rec, ok := inputs.byAction[action]
+	return rec, ok
+}
+
+// overrideDecision applies one of the gateway's own guards on top of the
+// policy. When it changes the decision, the policy's reasons no longer
+// explain it and are replaced by the guard's.
+func overrideDecision(dec *policy.PolicyDecision, decision string, reason policy.Reason) {
+	if dec.Decision == decision {
+		return
+	}
+	dec.Decision = decision
+	dec.Reasons = []policy.Reason{reason}
+}
+
+// deniedError reports a decision that does not let the request proceed.
+func deniedError(dec policy.PolicyDecision) error {
+	return &policy.DeniedError{Decision: dec.Decision, Reasons: dec.Reasons}
+}
+
+// writePolicyDenied writes the 403 body for a refused request, including
+// the policy's reasons when err carries them.
+func writePolicyDenied(w http.ResponseWriter, err error) {
+	resp := PolicyDeniedResponse{Error: "policy denied"}
+	var denied *policy.DeniedError
+	if errors.As(err, &denied) {
+		resp.Decision = denied.Decision
+		resp.Reasons = denied.Reasons
+	}
+	writeJSON(w, http.StatusForbidden, resp)
+}
+
+func (s *Server) policyCheck(r *http.Request, actionName string, actionType string, ctxRef ContextRef, level string, targets int) error {
+	dec, err := s.policyDecision(r, actionName, actionType, ctxRef, level, targets)
 	if err != nil {
 		return err
 	}
 	if dec.Decision != "allow" {
-		return errors.New("policy decision " + dec.Decision)
+		return deniedError(dec)
 	}
 	return nil
 }
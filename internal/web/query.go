@@ -0,0 +1,207 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"carapulse/internal/llm"
+	"carapulse/internal/tools"
+)
+
+// telemetryVocabularyLimit caps each kind of discovered name passed to the
+// query translator.
+const telemetryVocabularyLimit = 1000
+
+// TelemetryVocabularyStore lists the metric and trace names the context
+// pollers discovered for a tenant, up to limit of each kind.
+type TelemetryVocabularyStore interface {
+	ListTelemetryVocabulary(ctx context.Context, tenantID string, limit int) ([]byte, error)
+}
+
+// ToolRunner executes tool calls through the tool router.
+type ToolRunner interface {
+	Execute(ctx context.Context, req tools.ExecuteRequest) (tools.ExecuteResponse, error)
+}
+
+// QueryStep is the generated query as a plan or playbook step.
+type QueryStep struct {
+	Stage  string         `json:"stage"`
+	Tool   string         `json:"tool"`
+	Action string         `json:"action"`
+	Input  map[string]any `json:"input"`
+}
+
+// QueryResponse is a translated query and, unless it was a dry run, its
+// result. DiagnosticHint and Step are ready to paste into plan constraints
+// and playbook specs.
+type QueryResponse struct {
+	Question       string              `json:"question"`
+	Language       string              `json:"language"`
+	Query          string              `json:"query"`
+	Explanation    string              `json:"explanation,omitempty"`
+	Step           QueryStep           `json:"step"`
+	DiagnosticHint map[string][]string `json:"diagnostic_hint"`
+	Result         json.RawMessage     `json:"result,omitempty"`
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	var req QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		http.Error(w, "question required", http.StatusBadRequest)
+		return
+	}
+	req.Language = strings.ToLower(strings.TrimSpace(req.Language))
+	if req.Language != "" && req.Language != "promql" && req.Language != "traceql" {
+		http.Error(w, "language must be promql or traceql", http.StatusBadRequest)
+		return
+	}
+	if err := validateContextRefMinimal(req.Context); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.policyCheck(r, "query.run", "read", req.Context, "read", 0); err != nil {
+		s.auditDenied(r.Context(), "query.run", req.Context, err)
+		writePolicyDenied(w, err)
+		return
+	}
+	if s.QueryTranslator == nil {
+		http.Error(w, "query translation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if !req.DryRun && s.ToolRouter == nil {
+		http.Error(w, "tool router unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID := strings.TrimSpace(req.Context.TenantID)
+	if err := s.checkLLMBudget(r.Context(), tenantID); err != nil {
+		http.Error(w, "llm budget exceeded", http.StatusTooManyRequests)
+		return
+	}
+	vocab, err := s.telemetryVocabulary(r.Context(), tenantID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	translation, err := s.QueryTranslator.TranslateQuery(llm.QueryRequest{
+		Question:   req.Question,
+		Language:   req.Language,
+		Service:    strings.TrimSpace(req.Service),
+		Vocabulary: vocab,
+	})
+	if err != nil {
+		slog.Warn("query translation failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "query translation failed", http.StatusBadGateway)
+		return
+	}
+	s.recordLLMUsage(r.Context(), "", "query", req.Context, translation.Usage)
+	resp := QueryResponse{
+		Question:       req.Question,
+		Language:       translation.Language,
+		Query:          translation.Query,
+		Explanation:    translation.Explanation,
+		Step:           queryStep(translation, req),
+		DiagnosticHint: map[string][]string{translation.Language: {translation.Query}},
+	}
+	auditCtx := map[string]any{"context": req.Context, "question": req.Question, "language": resp.Language, "query": resp.Query}
+	if req.DryRun {
+		s.auditEvent(r.Context(), "query.run", "allow", auditCtx, "dry run")
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	out, err := s.ToolRouter.Execute(r.Context(), tools.ExecuteRequest{
+		Tool:    resp.Step.Tool,
+		Action:  resp.Step.Action,
+		Input:   resp.Step.Input,
+		Context: toToolContext(req.Context),
+	})
+	if err != nil {
+		s.auditEvent(r.Context(), "query.run", "deny", auditCtx, err.Error())
+		http.Error(w, "query failed", http.StatusBadGateway)
+		return
+	}
+	s.auditEvent(r.Context(), "query.run", "allow", auditCtx, "")
+	if json.Valid(out.Output) {
+		resp.Result = out.Output
+	} else if encoded, err := json.Marshal(string(out.Output)); err == nil {
+		resp.Result = encoded
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// queryStep maps a translated query to the read-only tool call that runs
+// it: an instant or range Prometheus query, or a Tempo search.
+func queryStep(translation llm.QueryTranslation, req QueryRequest) QueryStep {
+	if translation.Language == "traceql" {
+		return QueryStep{Stage: "verify", Tool: "tempo", Action: "traceql", Input: map[string]any{"query": translation.Query}}
+	}
+	if strings.TrimSpace(req.Start) != "" && strings.TrimSpace(req.End) != "" {
+		step := strings.TrimSpace(req.Step)
+		if step == "" {
+			step = "60s"
+		}
+		return QueryStep{Stage: "verify", Tool: "prometheus", Action: "query_range", Input: map[string]any{
+			"query": translation.Query, "start": req.Start, "end": req.End, "step": step,
+		}}
+	}
+	return QueryStep{Stage: "verify", Tool: "prometheus", Action: "query", Input: map[string]any{"query": translation.Query}}
+}
+
+// telemetryVocabulary reads the tenant's discovered metric and trace names
+// from the context graph.
+func (s *Server) telemetryVocabulary(ctx context.Context, tenantID string) (llm.QueryVocabulary, error) {
+	var vocab llm.QueryVocabulary
+	store, ok := s.DB.(TelemetryVocabularyStore)
+	if !ok {
+		return vocab, nil
+	}
+	payload, err := store.ListTelemetryVocabulary(ctx, tenantID, telemetryVocabularyLimit)
+	if err != nil {
+		return vocab, err
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(payload, &items); err != nil {
+		return vocab, err
+	}
+	for _, item := range filterItemsByLabelTenant(items, tenantID, false) {
+		kind, _ := item["kind"].(string)
+		name, _ := item["name"].(string)
+		labels, _ := item["labels"].(map[string]any)
+		label := func(key string) string {
+			value, _ := labels[key].(string)
+			return value
+		}
+		switch kind {
+		case "prometheus.metric":
+			vocab.Metrics = append(vocab.Metrics, llm.MetricName{Name: name, Type: label("type"), Help: label("help"), Labels: splitNames(label("label_names"))})
+		case "tempo.service":
+			vocab.TraceServices = append(vocab.TraceServices, llm.TraceService{Name: name, RootSpans: splitNames(label("root_spans"))})
+		case "tempo.tags":
+			if vocab.TraceTags == nil {
+				vocab.TraceTags = map[string][]string{}
+			}
+			vocab.TraceTags[name] = splitNames(label("tags"))
+		}
+	}
+	return vocab, nil
+}
+
+func splitNames(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
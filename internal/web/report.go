@@ -0,0 +1,440 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"carapulse/internal/llm"
+)
+
+// reportLinkTTL is how long presigned links to stored tool outputs in a
+// report stay valid.
+const reportLinkTTL = time.Hour
+
+// ExecutionReportStore returns what an execution recorded: its tool calls,
+// evidence and the audit events of the execution and its plan.
+type ExecutionReportStore interface {
+	GetExecutionReportData(ctx context.Context, executionID, planID string) ([]byte, error)
+}
+
+// ExecutionNarrativeStore keeps the model-written narrative of a completed
+// execution so the report is only narrated once.
+type ExecutionNarrativeStore interface {
+	GetExecutionNarrative(ctx context.Context, executionID string) ([]byte, error)
+	SetExecutionNarrative(ctx context.Context, executionID string, payload []byte) error
+}
+
+// ReportEntry is one line of a report timeline.
+type ReportEntry struct {
+	At   time.Time `json:"at"`
+	Kind string    `json:"kind"`
+	Text string    `json:"text"`
+}
+
+// ReportAction is a tool call the execution made.
+type ReportAction struct {
+	ToolCallID string `json:"tool_call_id"`
+	Tool       string `json:"tool_name"`
+	Status     string `json:"status"`
+	InputRef   string `json:"input_ref,omitempty"`
+	OutputRef  string `json:"output_ref,omitempty"`
+	OutputLink string `json:"output_link,omitempty"`
+}
+
+// ReportVerification is an evidence row collected during the execution.
+type ReportVerification struct {
+	EvidenceID  string    `json:"evidence_id"`
+	Type        string    `json:"type"`
+	Query       string    `json:"query,omitempty"`
+	ResultRef   string    `json:"result_ref,omitempty"`
+	Link        string    `json:"link,omitempty"`
+	CollectedAt time.Time `json:"collected_at"`
+}
+
+// ExecutionReport is a post-incident report draft for one execution.
+// NarrativeSource is "llm" when a model wrote the narrative and "template"
+// otherwise.
+type ExecutionReport struct {
+	ExecutionID     string               `json:"execution_id"`
+	PlanID          string               `json:"plan_id"`
+	Status          string               `json:"status"`
+	Trigger         string               `json:"trigger,omitempty"`
+	Intent          string               `json:"intent,omitempty"`
+	RiskLevel       string               `json:"risk_level,omitempty"`
+	StartedAt       time.Time            `json:"started_at"`
+	CompletedAt     time.Time            `json:"completed_at"`
+	Alert           any                  `json:"alert,omitempty"`
+	Diagnostics     []DiagnosticEvidence `json:"diagnostics,omitempty"`
+	Narrative       llm.ReportNarrative  `json:"narrative"`
+	NarrativeSource string               `json:"narrative_source"`
+	Timeline        []ReportEntry        `json:"timeline"`
+	Actions         []ReportAction       `json:"actions"`
+	Verification    []ReportVerification `json:"verification"`
+	Links           []string             `json:"links,omitempty"`
+}
+
+func (s *Server) handleExecutionReport(w http.ResponseWriter, r *http.Request, execID string) {
+	if s.DB == nil {
+		http.Error(w, "db unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format != "" && format != "json" && format != "markdown" {
+		http.Error(w, "invalid format", http.StatusBadRequest)
+		return
+	}
+	payload, err := s.DB.GetExecution(r.Context(), execID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if payload == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var exec map[string]any
+	if err := json.Unmarshal(payload, &exec); err != nil {
+		http.Error(w, "decode error", http.StatusInternalServerError)
+		return
+	}
+	planID, _ := exec["plan_id"].(string)
+	planJSON, err := s.DB.GetPlan(r.Context(), planID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if planJSON == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var plan map[string]any
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		http.Error(w, "decode error", http.StatusInternalServerError)
+		return
+	}
+	ctxRef, err := s.policyCheckReadPlan(r, plan, "execution.report")
+	if err != nil {
+		writePolicyDenied(w, err)
+		return
+	}
+	report, err := s.executionReport(r.Context(), exec, plan)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	s.narrateReport(r.Context(), &report, ctxRef)
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		_, _ = w.Write([]byte(renderReportMarkdown(report)))
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// executionReport gathers the execution's records into a report with the
+// template narrative.
+func (s *Server) executionReport(ctx context.Context, exec, plan map[string]any) (ExecutionReport, error) {
+	report := ExecutionReport{}
+	report.ExecutionID, _ = exec["execution_id"].(string)
+	report.PlanID, _ = exec["plan_id"].(string)
+	report.Status, _ = exec["status"].(string)
+	report.StartedAt = reportTime(exec["started_at"])
+	report.CompletedAt = reportTime(exec["completed_at"])
+	report.Trigger, _ = plan["trigger"].(string)
+	report.Intent, _ = plan["intent"].(string)
+	if report.Intent == "" {
+		report.Intent, _ = plan["summary"].(string)
+	}
+	report.RiskLevel, _ = plan["risk_level"].(string)
+	if meta, ok := plan["meta"].(map[string]any); ok {
+		report.Alert = meta["alert"]
+		if data, err := json.Marshal(meta["diagnostics"]); err == nil {
+			report.Diagnostics, _ = diagnosticsFromJSON(data)
+		}
+	}
+
+	var records struct {
+		ToolCalls   []ReportAction       `json:"tool_calls"`
+		Evidence    []ReportVerification `json:"evidence"`
+		AuditEvents []struct {
+			OccurredAt time.Time      `json:"occurred_at"`
+			Actor      map[string]any `json:"actor"`
+			Action     string         `json:"action"`
+			Decision   string         `json:"decision"`
+		} `json:"audit_events"`
+	}
+	if store, ok := s.DB.(ExecutionReportStore); ok {
+		data, err := store.GetExecutionReportData(ctx, report.ExecutionID, report.PlanID)
+		if err != nil {
+			return ExecutionReport{}, err
+		}
+		if err := json.Unmarshal(data, &records); err != nil {
+			return ExecutionReport{}, err
+		}
+	}
+	report.Actions = records.ToolCalls
+	report.Verification = records.Evidence
+	if report.Actions == nil {
+		report.Actions = []ReportAction{}
+	}
+	if report.Verification == nil {
+		report.Verification = []ReportVerification{}
+	}
+	for i, action := range report.Actions {
+		if action.OutputRef == "" {
+			continue
+		}
+		if s.ObjectStore != nil {
+			if link, err := s.ObjectStore.Presign(ctx, action.OutputRef, reportLinkTTL); err == nil {
+				report.Actions[i].OutputLink = link
+			}
+		}
+		report.Links = append(report.Links, firstNonEmpty(report.Actions[i].OutputLink, action.OutputRef))
+	}
+	for _, diag := range report.Diagnostics {
+		if link := firstNonEmpty(diag.Link, diag.ResultRef); link != "" {
+			report.Links = append(report.Links, link)
+		}
+	}
+	for _, ev := range report.Verification {
+		if link := firstNonEmpty(ev.Link, ev.ResultRef); link != "" {
+			report.Links = append(report.Links, link)
+		}
+	}
+
+	if created := reportTime(plan["created_at"]); !created.IsZero() {
+		report.Timeline = append(report.Timeline, ReportEntry{At: created, Kind: "plan", Text: fmt.Sprintf("Plan %s created from %s trigger", report.PlanID, firstNonEmpty(report.Trigger, "unknown"))})
+	}
+	for _, ev := range records.AuditEvents {
+		actor, _ := ev.Actor["id"].(string)
+		text := fmt.Sprintf("%s: %s", ev.Action, ev.Decision)
+		if actor != "" {
+			text += " by " + actor
+		}
+		report.Timeline = append(report.Timeline, ReportEntry{At: ev.OccurredAt, Kind: "audit", Text: text})
+	}
+	if !report.StartedAt.IsZero() {
+		report.Timeline = append(report.Timeline, ReportEntry{At: report.StartedAt, Kind: "execution", Text: "Execution started"})
+	}
+	for _, ev := range report.Verification {
+		text := "Collected " + ev.Type + " evidence"
+		if ev.Query != "" {
+			text += ": " + ev.Query
+		}
+		report.Timeline = append(report.Timeline, ReportEntry{At: ev.CollectedAt, Kind: "evidence", Text: text})
+	}
+	if !report.CompletedAt.IsZero() {
+		report.Timeline = append(report.Timeline, ReportEntry{At: report.CompletedAt, Kind: "execution", Text: "Execution " + report.Status})
+	}
+	sort.SliceStable(report.Timeline, func(i, j int) bool {
+		return report.Timeline[i].At.Before(report.Timeline[j].At)
+	})
+	if report.Timeline == nil {
+		report.Timeline = []ReportEntry{}
+	}
+	report.Narrative = templateNarrative(report)
+	report.NarrativeSource = "template"
+	return report, nil
+}
+
+// narrateReport replaces the template narrative with one written by
+// s.Reporter. The narrative is generated once per completed execution and
+// served from the store afterwards; without a store, reporter errors or
+// exhausted budgets the template stays.
+func (s *Server) narrateReport(ctx context.Context, report *ExecutionReport, ctxRef ContextRef) {
+	store, ok := s.DB.(ExecutionNarrativeStore)
+	if !ok {
+		return
+	}
+	stored, err := store.GetExecutionNarrative(ctx, report.ExecutionID)
+	if err != nil {
+		slog.Warn("report narrative lookup failed", "execution_id", report.ExecutionID, "error", err)
+		return
+	}
+	if len(stored) > 0 {
+		var narrative llm.ReportNarrative
+		if err := json.Unmarshal(stored, &narrative); err != nil {
+			slog.Warn("report narrative decode failed", "execution_id", report.ExecutionID, "error", err)
+			return
+		}
+		report.Narrative = narrative
+		report.NarrativeSource = "llm"
+		return
+	}
+	if s.Reporter == nil || report.CompletedAt.IsZero() || strings.TrimSpace(report.Intent) == "" {
+		return
+	}
+	if err := s.checkLLMBudget(ctx, strings.TrimSpace(ctxRef.TenantID)); err != nil {
+		slog.Warn("report narrative skipped", "execution_id", report.ExecutionID, "error", err)
+		return
+	}
+	narrative, err := s.Reporter.Narrate(llm.ReportInput{
+		Intent:       report.Intent,
+		Trigger:      report.Trigger,
+		Status:       report.Status,
+		Alert:        report.Alert,
+		Diagnostics:  report.Diagnostics,
+		Timeline:     report.Timeline,
+		Actions:      report.Actions,
+		Verification: report.Verification,
+	})
+	if err != nil {
+		slog.Warn("report narrative failed", "execution_id", report.ExecutionID, "error", err)
+		return
+	}
+	s.recordLLMUsage(ctx, report.PlanID, "report", ctxRef, narrative.Usage)
+	narrative.Usage = nil
+	if payload, err := json.Marshal(narrative); err == nil {
+		if err := store.SetExecutionNarrative(ctx, report.ExecutionID, payload); err != nil {
+			slog.Warn("store report narrative failed", "execution_id", report.ExecutionID, "error", err)
+		}
+	}
+	report.Narrative = narrative
+	report.NarrativeSource = "llm"
+}
+
+// templateNarrative is the deterministic narrative used when no model is
+// configured or the model fails.
+func templateNarrative(report ExecutionReport) llm.ReportNarrative {
+	failed := 0
+	for _, action := range report.Actions {
+		if action.Status == "failed" {
+			failed++
+		}
+	}
+	out := llm.ReportNarrative{
+		Summary: fmt.Sprintf("Execution %s of plan %s (%q) finished with status %s after %d tool call(s), %d failed, and %d verification result(s).",
+			report.ExecutionID, report.PlanID, report.Intent, firstNonEmpty(report.Status, "unknown"), len(report.Actions), failed, len(report.Verification)),
+		Impact: "Not recorded.",
+	}
+	if len(report.Diagnostics) == 0 {
+		out.Diagnosis = "No diagnostics were collected before planning."
+	} else {
+		parts := make([]string, 0, len(report.Diagnostics))
+		for _, diag := range report.Diagnostics {
+			parts = append(parts, strings.TrimSpace(diag.Type+" "+diag.Query))
+		}
+		out.Diagnosis = "Diagnostics collected before planning: " + strings.Join(parts, "; ") + "."
+	}
+	for _, action := range report.Actions {
+		if action.Status == "failed" {
+			out.FollowUps = append(out.FollowUps, fmt.Sprintf("Investigate the failed %s call %s.", action.Tool, action.ToolCallID))
+		}
+	}
+	if len(report.Verification) == 0 {
+		out.FollowUps = append(out.FollowUps, "Confirm recovery manually; the execution recorded no verification results.")
+	}
+	return out
+}
+
+// renderReportMarkdown renders a report as a Markdown postmortem draft.
+func renderReportMarkdown(report ExecutionReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Post-incident report: %s\n\n", firstNonEmpty(report.Intent, report.ExecutionID))
+	fmt.Fprintf(&b, "- Execution: `%s`\n- Plan: `%s`\n- Status: %s\n", report.ExecutionID, report.PlanID, firstNonEmpty(report.Status, "unknown"))
+	if report.Trigger != "" {
+		fmt.Fprintf(&b, "- Trigger: %s\n", report.Trigger)
+	}
+	if report.RiskLevel != "" {
+		fmt.Fprintf(&b, "- Risk level: %s\n", report.RiskLevel)
+	}
+	if !report.StartedAt.IsZero() {
+		fmt.Fprintf(&b, "- Started: %s\n", report.StartedAt.UTC().Format(time.RFC3339))
+	}
+	if !report.CompletedAt.IsZero() {
+		fmt.Fprintf(&b, "- Completed: %s\n", report.CompletedAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "\n## Summary\n\n%s\n", report.Narrative.Summary)
+
+	b.WriteString("\n## Triggering alert\n\n")
+	if report.Alert == nil {
+		b.WriteString("None recorded.\n")
+	} else if data, err := json.MarshalIndent(report.Alert, "", "  "); err == nil {
+		fmt.Fprintf(&b, "```json\n%s\n```\n", data)
+	}
+
+	b.WriteString("\n## Diagnosis\n\n")
+	if report.Narrative.Diagnosis != "" {
+		fmt.Fprintf(&b, "%s\n", report.Narrative.Diagnosis)
+	}
+	if len(report.Diagnostics) > 0 {
+		b.WriteString("\n")
+		for _, diag := range report.Diagnostics {
+			fmt.Fprintf(&b, "- %s `%s`%s\n", diag.Type, diag.Query, markdownLink(firstNonEmpty(diag.Link, diag.ResultRef)))
+		}
+	}
+
+	b.WriteString("\n## Timeline\n\n")
+	if len(report.Timeline) == 0 {
+		b.WriteString("No events recorded.\n")
+	}
+	for _, entry := range report.Timeline {
+		fmt.Fprintf(&b, "- %s %s\n", entry.At.UTC().Format(time.RFC3339), entry.Text)
+	}
+
+	b.WriteString("\n## Actions taken\n\n")
+	if len(report.Actions) == 0 {
+		b.WriteString("No tool calls recorded.\n")
+	}
+	for _, action := range report.Actions {
+		fmt.Fprintf(&b, "- `%s` %s (%s)%s\n", action.Tool, action.Status, action.ToolCallID, markdownLink(firstNonEmpty(action.OutputLink, action.OutputRef)))
+	}
+
+	b.WriteString("\n## Verification\n\n")
+	if len(report.Verification) == 0 {
+		b.WriteString("No verification results recorded.\n")
+	}
+	for _, ev := range report.Verification {
+		fmt.Fprintf(&b, "- %s %s `%s`%s\n", ev.CollectedAt.UTC().Format(time.RFC3339), ev.Type, ev.Query, markdownLink(firstNonEmpty(ev.Link, ev.ResultRef)))
+	}
+
+	if report.Narrative.Impact != "" {
+		fmt.Fprintf(&b, "\n## Impact\n\n%s\n", report.Narrative.Impact)
+	}
+	if len(report.Narrative.FollowUps) > 0 {
+		b.WriteString("\n## Follow-ups\n\n")
+		for _, item := range report.Narrative.FollowUps {
+			fmt.Fprintf(&b, "- [ ] %s\n", item)
+		}
+	}
+	fmt.Fprintf(&b, "\n_Narrative source: %s. This is a draft; review before sharing._\n", report.NarrativeSource)
+	return b.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func markdownLink(target string) string {
+	if target == "" {
+		return ""
+	}
+	return " [output](" + target + ")"
+}
+
+// reportTime reads a timestamp decoded from JSON. Zero times stand for
+// unset columns.
+func reportTime(v any) time.Time {
+	switch val := v.(type) {
+	case time.Time:
+		return val
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, val)
+		if err != nil || parsed.Year() <= 1 {
+			return time.Time{}
+		}
+		return parsed
+	}
+	return time.Time{}
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"carapulse/internal/llm"
+	"carapulse/internal/retrieval"
+)
+
+// maxReferenceExcerpt caps how much of each retrieved document is quoted
+// in the planning prompt.
+const maxReferenceExcerpt = 1200
+
+// RetrievalStore lists a tenant's runbooks, playbooks and operator memory
+// for the planning retrieval index.
+type RetrievalStore interface {
+	ListRetrievalDocuments(ctx context.Context, tenantID string) ([]byte, error)
+}
+
+// PlanReference is a document offered to the planner, stored under
+// meta.references. Cited is set when the draft listed it in citations.
+type PlanReference struct {
+	ID    string  `json:"id"`
+	Kind  string  `json:"kind"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+	Cited bool    `json:"cited,omitempty"`
+}
+
+// RetrievalDocuments loads the tenant's documents from the database and the
+// workspace files. It is the Load function for Server.Retriever.
+func (s *Server) RetrievalDocuments(ctx context.Context, tenantID string) ([]retrieval.Document, error) {
+	var docs []retrieval.Document
+	if store, ok := s.DB.(RetrievalStore); ok {
+		payload, err := store.ListRetrievalDocuments(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &docs); err != nil {
+			return nil, err
+		}
+	}
+	if s.WorkspaceDir == "" {
+		return docs, nil
+	}
+	if runbooks, err := LoadRunbooks(s.WorkspaceDir); err == nil {
+		for _, rb := range runbooks {
+			if !tenantMatch(rb.TenantID, tenantID, false) {
+				continue
+			}
+			var tags []string
+			_ = json.Unmarshal(rb.Tags, &tags)
+			docs = append(docs, retrieval.Document{
+				ID:      "file_runbook_" + runbookKey(map[string]any{"service": rb.Service, "name": rb.Name, "version": rb.Version}),
+				Kind:    "runbook",
+				Title:   rb.Name,
+				Service: rb.Service,
+				Tags:    tags,
+				Text:    strings.TrimSpace(rb.Body + "\n" + string(rb.Spec)),
+			})
+		}
+	}
+	if entries, err := LoadOperatorMemory(s.WorkspaceDir); err == nil {
+		for _, entry := range entries {
+			if !tenantMatch(entry.TenantID, tenantID, false) {
+				continue
+			}
+			docs = append(docs, retrieval.Document{
+				ID:    fileMemoryID(entry),
+				Kind:  "memory",
+				Title: entry.Title,
+				Tags:  entry.Tags,
+				Text:  entry.Body,
+			})
+		}
+	}
+	return docs, nil
+}
+
+// retrieveReferences finds documents for intent and adds them to
+// planContext["references"] for the prompt. Retrieval errors only skip
+// the references.
+func (s *Server) retrieveReferences(ctx context.Context, tenantID, intent string, planContext any) []retrieval.Hit {
+	if s.Retriever == nil {
+		return nil
+	}
+	pc, ok := planContext.(map[string]any)
+	if !ok {
+		return nil
+	}
+	hits, err := s.Retriever.Retrieve(ctx, tenantID, intent, planService(pc))
+	if err != nil {
+		slog.Warn("plan retrieval failed", "tenant_id", tenantID, "error", err)
+		return nil
+	}
+	if len(hits) == 0 {
+		return nil
+	}
+	refs := make([]llm.Reference, 0, len(hits))
+	for _, hit := range hits {
+		excerpt := hit.Text
+		if len(excerpt) > maxReferenceExcerpt {
+			excerpt = excerpt[:maxReferenceExcerpt]
+		}
+		refs = append(refs, llm.Reference{ID: hit.ID, Kind: hit.Kind, Title: hit.Title, Excerpt: excerpt})
+	}
+	pc["references"] = refs
+	return hits
+}
+
+// planService is the service a plan is about, from the plan context or
+// its constraints.
+func planService(planContext map[string]any) string {
+	if service, ok := planContext["service"].(string); ok && strings.TrimSpace(service) != "" {
+		return strings.TrimSpace(service)
+	}
+	if constraints, ok := planContext["constraints"].(map[string]any); ok {
+		if service, ok := constraints["service"].(string); ok {
+			return strings.TrimSpace(service)
+		}
+	}
+	return ""
+}
+
+// planReferences lists the retrieved documents and marks the ones the
+// draft cited. Citations of unknown IDs are ignored.
+func planReferences(hits []retrieval.Hit, planText string) ([]PlanReference, []string) {
+	var draft struct {
+		Citations []string `json:"citations"`
+	}
+	_ = json.Unmarshal([]byte(extractJSONBlock(strings.TrimSpace(planText))), &draft)
+	cited := map[string]bool{}
+	for _, id := range draft.Citations {
+		cited[strings.TrimSpace(id)] = true
+	}
+	refs := make([]PlanReference, 0, len(hits))
+	var citations []string
+	for _, hit := range hits {
+		ref := PlanReference{ID: hit.ID, Kind: hit.Kind, Title: hit.Title, Score: hit.Score, Cited: cited[hit.ID]}
+		if ref.Cited {
+			citations = append(citations, hit.ID)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, citations
+}
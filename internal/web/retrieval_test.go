@@ -0,0 +1,94 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"carapulse/internal/llm"
+	"carapulse/internal/policy"
+	"carapulse/internal/retrieval"
+)
+
+type retrievalDB struct {
+	fakeDB
+	tenant string
+}
+
+func (r *retrievalDB) ListRetrievalDocuments(ctx context.Context, tenantID string) ([]byte, error) {
+	r.tenant = tenantID
+	return []byte(`[
+		{"id":"runbook_1","kind":"runbook","title":"Deploy checkout","service":"checkout","text":"deploy with helm upgrade then watch the rollout"},
+		{"id":"memory_1","kind":"memory","title":"Unrelated","text":"rotate the vpn certificates"}
+	]`), nil
+}
+
+func TestHandlePlansAddsReferencesAndCitations(t *testing.T) {
+	db := &retrievalDB{}
+	planner := &fakePlanner{resp: `{"citations":["runbook_1","made_up"],"steps":[{"action":"status","tool":"helm","input":{"release":"app"}}]}`}
+	srv := &Server{Mux: http.NewServeMux(), DB: db, Planner: planner, Policy: &policy.Evaluator{Checker: allowChecker{}}}
+	srv.Retriever = &retrieval.Retriever{Load: srv.RetrievalDocuments}
+	if w := postPlan(t, srv); w.Code != http.StatusOK {
+		t.Fatalf("status: %d", w.Code)
+	}
+	if db.tenant != "t" {
+		t.Fatalf("tenant: %q", db.tenant)
+	}
+	refs, _ := planner.context.(map[string]any)["references"].([]llm.Reference)
+	if len(refs) != 1 || refs[0].ID != "runbook_1" || refs[0].Excerpt == "" {
+		t.Fatalf("planner references: %#v", refs)
+	}
+	var plan struct {
+		Meta struct {
+			References []PlanReference `json:"references"`
+			Citations  []string        `json:"citations"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(db.lastPlan, &plan); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(plan.Meta.References) != 1 || !plan.Meta.References[0].Cited || plan.Meta.References[0].Score <= 0 {
+		t.Fatalf("references: %#v", plan.Meta.References)
+	}
+	if len(plan.Meta.Citations) != 1 || plan.Meta.Citations[0] != "runbook_1" {
+		t.Fatalf("citations: %#v", plan.Meta.Citations)
+	}
+}
+
+func TestRetrievalDocumentsIncludesWorkspaceFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "memory"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	runbooks := `[{"tenant_id":"t1","service":"api","name":"restart","version":1,"body":"kubectl rollout restart"},{"tenant_id":"t2","service":"api","name":"other","body":"x"}]`
+	memory := `[{"tenant_id":"t1","title":"note","body":"api needs warmup"}]`
+	if err := os.WriteFile(filepath.Join(dir, "memory", "runbooks.json"), []byte(runbooks), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "memory", "operator_memory.json"), []byte(memory), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	srv := &Server{DB: &fakeDB{}, WorkspaceDir: dir}
+	docs, err := srv.RetrievalDocuments(context.Background(), "t1")
+	if err != nil || len(docs) != 2 {
+		t.Fatalf("docs: %#v err: %v", docs, err)
+	}
+	if docs[0].Kind != "runbook" || docs[0].Service != "api" || docs[1].Kind != "memory" || docs[1].Text != "api needs warmup" {
+		t.Fatalf("docs: %#v", docs)
+	}
+}
+
+func TestPlanServiceFromConstraints(t *testing.T) {
+	if got := planService(map[string]any{"service": " api "}); got != "api" {
+		t.Fatalf("service: %q", got)
+	}
+	if got := planService(map[string]any{"constraints": map[string]any{"service": "db"}}); got != "db" {
+		t.Fatalf("service: %q", got)
+	}
+	if got := planService(map[string]any{}); got != "" {
+		t.Fatalf("service: %q", got)
+	}
+}
@@ -1,27 +1,151 @@
 package web
 
-import "strings"
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
 
-func tierForRisk(risk string) string {
-	switch strings.ToLower(strings.TrimSpace(risk)) {
-	case "read":
-		return "read"
-	case "low", "medium":
-		return "safe"
-	default:
-		return "break_glass"
+	ctxmodel "carapulse/internal/context"
+	"carapulse/internal/risk"
+)
+
+// ExecutionFailureCounter counts a service's recent failed executions for
+// risk scoring.
+type ExecutionFailureCounter interface {
+	CountFailedExecutions(ctx context.Context, service string, since time.Time) (int, error)
+}
+
+// maxScoredServices bounds the context graph and failure lookups made
+// while scoring one plan.
+const maxScoredServices = 10
+
+// scorePlanRisk scores a plan with the gateway's view of its targets:
+// their criticality in the context graph and their services' recent failed
+// executions. services adds targets the steps do not name, such as the
+// service an alert fired for.
+func (s *Server) scorePlanRisk(ctx context.Context, ctxRef ContextRef, intent string, steps []PlanStep, services ...string) risk.Score {
+	in := risk.Input{
+		Intent:      intent,
+		Steps:       riskSteps(steps),
+		Environment: ctxRef.Environment,
+		Targets:     estimateTargets(steps),
+	}
+	counter, _ := s.DB.(ExecutionFailureCounter)
+	since := time.Now().UTC().Add(-s.Risk.Lookback())
+	seen := map[string]bool{}
+	for _, name := range append(services, planServices(steps)...) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] || len(seen) >= maxScoredServices {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		svc := risk.Service{Name: name}
+		if s.Context != nil {
+			if graph, err := s.Context.GetServiceGraph(ctx, name); err == nil {
+				svc.Criticality = serviceCriticality(graph, name)
+			}
+		}
+		in.Services = append(in.Services, svc)
+		if counter != nil {
+			if n, err := counter.CountFailedExecutions(ctx, name, since); err == nil {
+				in.RecentFailures += n
+			}
+		}
+	}
+	return s.Risk.Score(in)
+}
+
+// serviceCriticality reads the criticality (or tier) label of the graph
+// node named service.
+func serviceCriticality(graph ctxmodel.ServiceGraph, service string) string {
+	for _, node := range graph.Nodes {
+		if !strings.EqualFold(node.Name, service) {
+			continue
+		}
+		if c := strings.TrimSpace(node.Labels["criticality"]); c != "" {
+			return c
+		}
+		return strings.TrimSpace(node.Labels["tier"])
+	}
+	return ""
+}
+
+// setPlanRisk stores score on the plan: the level as risk_level, with the
+// score and its breakdown alongside.
+func setPlanRisk(plan map[string]any, score risk.Score) {
+	plan["risk_level"] = score.Level
+	plan["risk_score"] = score.Value
+	plan["risk_factors"] = score.Factors
+}
+
+// planRisk reads the score stored on a plan. Plans created before risk
+// scoring carry only a level; ok is false when there is not even that.
+func planRisk(plan map[string]any) (risk.Score, bool) {
+	level, _ := plan["risk_level"].(string)
+	level = strings.TrimSpace(level)
+	if level == "" {
+		return risk.Score{}, false
+	}
+	score := risk.Score{Level: level, Tier: risk.TierFor(level)}
+	switch v := plan["risk_score"].(type) {
+	case float64:
+		score.Value = int(v)
+	case int:
+		score.Value = v
+	}
+	if raw := plan["risk_factors"]; raw != nil {
+		if data, err := json.Marshal(raw); err == nil {
+			_ = json.Unmarshal(data, &score.Factors)
+		}
+	}
+	return score, true
+}
+
+// planImpact walks the context graph from the plan's target services (and
+// services) to everything downstream of them. It is nil without a context
+// graph or targets; a failed lookup leaves out what it would have reached.
+func (s *Server) planImpact(ctx context.Context, steps []PlanStep, services ...string) *ctxmodel.Impact {
+	if s.Context == nil {
+		return nil
+	}
+	var targets []string
+	for _, name := range append(services, planServices(steps)...) {
+		if name = strings.TrimSpace(name); name != "" {
+			targets = append(targets, name)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	impact, err := ctxmodel.WalkImpact(ctx, s.Context, targets)
+	if err != nil {
+		slog.Debug("blast radius walk incomplete", "error", err)
 	}
+	return &impact
 }
 
-func blastRadius(ctx ContextRef, targets int) string {
-	if targets <= 0 {
-		return "namespace"
+// blastRadius scopes a change from its target count, widened by the
+// namespaces, clusters and accounts its impact reaches.
+func blastRadius(ctx ContextRef, targets int, impact *ctxmodel.Impact) string {
+	scope := "account"
+	switch {
+	case targets <= 0:
+		scope = "namespace"
+	case targets <= 10 && strings.TrimSpace(ctx.Namespace) != "":
+		scope = "namespace"
+	case targets <= 50:
+		scope = "cluster"
 	}
-	if targets <= 10 && strings.TrimSpace(ctx.Namespace) != "" {
-		return "namespace"
+	if impact == nil {
+		return scope
 	}
-	if targets <= 50 {
+	switch {
+	case len(impact.Accounts) > 1 || len(impact.Clusters) > 1:
+		return "account"
+	case len(impact.Namespaces) > 1 && scope == "namespace":
 		return "cluster"
 	}
-	return "account"
+	return scope
 }
@@ -42,8 +42,8 @@ func (s *Server) handleRunbooks(w http.ResponseWriter, r *http.Request) {
 		}
 		req.TenantID = tenantID
 		if err := s.policyCheck(r, "runbook.create", "write", ContextRef{TenantID: tenantID}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "runbook.create", "deny", map[string]any{"name": req.Name}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "runbook.create", map[string]any{"name": req.Name}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := marshalJSON(req)
@@ -56,6 +56,7 @@ func (s *Server) handleRunbooks(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "db error", http.StatusInternalServerError)
 			return
 		}
+		s.Retriever.Invalidate(tenantID)
 		s.auditEvent(r.Context(), "runbook.create", "allow", map[string]any{"runbook_id": id, "name": req.Name}, "")
 		_ = json.NewEncoder(w).Encode(map[string]any{"runbook_id": id})
 	case http.MethodGet:
@@ -69,8 +70,8 @@ func (s *Server) handleRunbooks(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "runbook.list", tenantID); err != nil {
-			s.auditEvent(r.Context(), "runbook.list", "deny", map[string]any{}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "runbook.list", map[string]any{}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		limit, offset := parsePagination(r)
@@ -149,8 +150,8 @@ func (s *Server) handleRunbookByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "runbook.get", tenantID); err != nil {
-			s.auditEvent(r.Context(), "runbook.get", "deny", map[string]any{"runbook_id": runbookID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "runbook.get", map[string]any{"runbook_id": runbookID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := s.DB.GetRunbook(r.Context(), runbookID)
@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"strings"
 	"time"
 
+	"carapulse/internal/freeze"
+	"carapulse/internal/risk"
+
 	"github.com/robfig/cron/v3"
 )
 
@@ -34,6 +38,8 @@ type Scheduler struct {
 	PollInterval time.Duration
 	Now          func() time.Time
 	Parser       *cron.Parser
+	// Risk scores the plans schedules create.
+	Risk risk.Scorer
 }
 
 func NewScheduler(store ScheduleStore) *Scheduler {
@@ -94,6 +100,12 @@ func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
 		return 0, err
 	}
 	now := s.Now().UTC()
+	var freezes []freeze.Freeze
+	if lister, ok := s.Store.(FreezeLister); ok {
+		if freezes, err = loadFreezes(ctx, lister); err != nil {
+			return 0, err
+		}
+	}
 	count := 0
 	for _, schedule := range schedules {
 		if !schedule.Enabled {
@@ -111,19 +123,27 @@ func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
 		if next.After(now) {
 			continue
 		}
+		if hits := freeze.Active(freezes, now, schedule.Context.Environment, nil); len(hits) > 0 {
+			// A frozen occurrence is skipped, not deferred until the freeze ends.
+			s.skipFrozen(ctx, schedule, hits)
+			if err := s.Store.UpdateScheduleLastRun(ctx, schedule.ScheduleID, now); err != nil {
+				return count, err
+			}
+			continue
+		}
 		plan := map[string]any{
 			"trigger":     "scheduled",
 			"summary":     schedule.Summary,
 			"intent":      schedule.Intent,
 			"context":     schedule.Context,
 			"constraints": schedule.Constraints,
-			"risk_level":  riskFromIntent(schedule.Intent),
 			"created_at":  now,
 			"meta": map[string]any{
 				"schedule_id": schedule.ScheduleID,
 				"scheduled_at": now.Format(time.RFC3339),
 			},
 		}
+		setPlanRisk(plan, s.Risk.Score(risk.Input{Intent: schedule.Intent, Environment: schedule.Context.Environment, Targets: 1}))
 		data, err := marshalJSON(plan)
 		if err != nil {
 			return count, err
@@ -139,6 +159,27 @@ func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+func (s *Scheduler) skipFrozen(ctx context.Context, schedule Schedule, hits []freeze.Hit) {
+	reason := hits[0].Reason()
+	slog.Warn("scheduled plan skipped", "schedule_id", schedule.ScheduleID, "reason", reason)
+	audit, ok := s.Store.(AuditWriter)
+	if !ok {
+		return
+	}
+	data, err := marshalJSON(map[string]any{
+		"occurred_at": s.Now().UTC().Format(time.RFC3339),
+		"actor":       Actor{ID: "scheduler"},
+		"action":      "schedule.run",
+		"decision":    "deny",
+		"context":     map[string]any{"schedule_id": schedule.ScheduleID, "freeze_id": hits[0].Freeze.ID},
+		"note":        reason,
+	})
+	if err != nil {
+		return
+	}
+	_, _ = audit.InsertAuditEvent(ctx, data)
+}
+
 func parseSchedules(data []byte) ([]Schedule, error) {
 	if len(data) == 0 {
 		return nil, nil
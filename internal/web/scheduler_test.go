@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"carapulse/internal/freeze"
 )
 
 type scheduleStoreStub struct {
@@ -108,3 +111,44 @@ func TestSchedulerRunOnceStoreError(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+// frozenScheduleStore adds a freeze calendar and an audit log to the stub.
+type frozenScheduleStore struct {
+	scheduleStoreStub
+	auditLog
+	freezes []freeze.Freeze
+}
+
+func (s *frozenScheduleStore) ListFreezes(ctx context.Context) ([]byte, error) {
+	return json.Marshal(s.freezes)
+}
+
+func TestSchedulerRunOnceSkipsFrozen(t *testing.T) {
+	now := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	schedules := []Schedule{
+		{ScheduleID: "prod", CreatedAt: now.Add(-time.Hour), Cron: "* * * * *", Context: ContextRef{TenantID: "t", Environment: "prod"}, Enabled: true},
+		{ScheduleID: "dev", CreatedAt: now.Add(-time.Hour), Cron: "* * * * *", Context: ContextRef{TenantID: "t", Environment: "dev"}, Enabled: true},
+	}
+	payload, _ := json.Marshal(schedules)
+	store := &frozenScheduleStore{
+		scheduleStoreStub: scheduleStoreStub{payload: payload},
+		freezes: []freeze.Freeze{{
+			ID:           "freeze_1",
+			Name:         "weekends",
+			Recurrence:   &freeze.Recurrence{Kind: freeze.KindWeekends},
+			Environments: []string{"prod"},
+		}},
+	}
+	s := &Scheduler{Store: store, Now: func() time.Time { return now }}
+	count, err := s.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if count != 1 || store.created != 1 {
+		t.Fatalf("count=%d created=%d", count, store.created)
+	}
+	event := store.find("schedule.run")
+	if event == nil || event["decision"] != "deny" || !strings.Contains(event["note"].(string), `change freeze "weekends"`) {
+		t.Fatalf("audit: %+v", store.events)
+	}
+}
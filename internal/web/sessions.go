@@ -39,8 +39,8 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := s.policyCheck(r, "session.create", "write", ContextRef{TenantID: req.TenantID}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "session.create", "deny", map[string]any{"tenant_id": req.TenantID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "session.create", map[string]any{"tenant_id": req.TenantID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload := map[string]any{
@@ -69,8 +69,8 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := s.policyCheckRead(r, "session.list"); err != nil {
-			s.auditEvent(r.Context(), "session.list", "deny", map[string]any{}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "session.list", map[string]any{}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		limit, offset := parsePagination(r)
@@ -118,8 +118,8 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		if err := s.policyCheckRead(r, "session.get"); err != nil {
-			s.auditEvent(r.Context(), "session.get", "deny", map[string]any{"session_id": sessionID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "session.get", map[string]any{"session_id": sessionID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := s.DB.GetSession(r.Context(), sessionID)
@@ -144,8 +144,8 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := s.policyCheck(r, "session.update", "write", ContextRef{TenantID: req.TenantID}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "session.update", "deny", map[string]any{"session_id": sessionID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "session.update", map[string]any{"session_id": sessionID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload := map[string]any{
@@ -168,8 +168,8 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	case http.MethodDelete:
 		if err := s.policyCheck(r, "session.delete", "write", ContextRef{}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "session.delete", "deny", map[string]any{"session_id": sessionID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "session.delete", map[string]any{"session_id": sessionID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		if err := s.DB.DeleteSession(r.Context(), sessionID); err != nil {
@@ -208,8 +208,8 @@ func (s *Server) handleSessionMembers(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := s.policyCheck(r, "session.member.add", "write", ContextRef{}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "session.member.add", "deny", map[string]any{"session_id": sessionID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "session.member.add", map[string]any{"session_id": sessionID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload := map[string]any{"member_id": req.MemberID, "role": req.Role}
@@ -226,8 +226,8 @@ func (s *Server) handleSessionMembers(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	case http.MethodGet:
 		if err := s.policyCheckRead(r, "session.member.list"); err != nil {
-			s.auditEvent(r.Context(), "session.member.list", "deny", map[string]any{"session_id": sessionID}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "session.member.list", map[string]any{"session_id": sessionID}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		payload, err := s.DB.ListSessionMembers(r.Context(), sessionID)
@@ -92,16 +92,18 @@ func (s *Server) handleEventsSSE(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "stream unsupported", http.StatusInternalServerError)
 		return
 	}
+	// Subscribe before acknowledging so a client that waits for ":ok"
+	// before creating a plan sees every event for it.
+	hub := s.eventHub()
+	ch, cancel := hub.Subscribe(sessionID)
+	defer cancel()
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	_, _ = fmt.Fprint(w, ":ok\n\n")
 	flusher.Flush()
 
-	hub := s.eventHub()
-	ch, cancel := hub.Subscribe(sessionID)
-	defer cancel()
-
 	for {
 		select {
 		case <-r.Context().Done():
@@ -1,6 +1,9 @@
 package web
 
-import ctxmodel "carapulse/internal/context"
+import (
+	ctxmodel "carapulse/internal/context"
+	"carapulse/internal/policy"
+)
 
 type ContextRef struct {
 	TenantID      string `json:"tenant_id"`
@@ -20,6 +23,10 @@ type PlanCreateRequest struct {
 	Intent      string     `json:"intent"`
 	Constraints any        `json:"constraints"`
 	SessionID   string     `json:"session_id"`
+	// Stream publishes the draft as plan.draft.delta events tagged with
+	// DraftID (generated when empty) before plan.created.
+	Stream  bool   `json:"stream,omitempty"`
+	DraftID string `json:"draft_id,omitempty"`
 }
 
 type PlanExecuteRequest struct {
@@ -58,11 +65,32 @@ type AuditQueryRequest struct {
 	Decision string `json:"decision"`
 }
 
+// QueryRequest asks for a PromQL or TraceQL query answering Question.
+// Start and End make a PromQL query a range query; DryRun only translates.
+type QueryRequest struct {
+	Question string     `json:"question"`
+	Language string     `json:"language"`
+	Service  string     `json:"service"`
+	Context  ContextRef `json:"context"`
+	Start    string     `json:"start"`
+	End      string     `json:"end"`
+	Step     string     `json:"step"`
+	DryRun   bool       `json:"dry_run"`
+}
+
 type HookAck struct {
 	Received bool   `json:"received"`
 	EventID  string `json:"event_id"`
 }
 
+// PolicyDeniedResponse is the 403 body of a request the policy refused.
+// Decision and Reasons are empty when the check itself failed.
+type PolicyDeniedResponse struct {
+	Error    string          `json:"error"`
+	Decision string          `json:"decision,omitempty"`
+	Reasons  []policy.Reason `json:"reasons,omitempty"`
+}
+
 type ContextRefreshRequest struct {
 	Service string          `json:"service"`
 	Nodes   []ctxmodel.Node `json:"nodes"`
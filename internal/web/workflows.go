@@ -2,9 +2,12 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
+
+	"carapulse/internal/attest"
 )
 
 func findWorkflowByName(payload []byte, name string) (map[string]any, bool) {
@@ -51,8 +54,8 @@ func (s *Server) handleWorkflows(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := policyCheckTenantRead(s, r, "workflow.list", tenantID); err != nil {
-		s.auditEvent(r.Context(), "workflow.list", "deny", map[string]any{}, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "workflow.list", map[string]any{}, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	limit, offset := parsePagination(r)
@@ -94,8 +97,8 @@ func (s *Server) handleWorkflowByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := policyCheckTenantRead(s, r, "workflow.get", tenantID); err != nil {
-			s.auditEvent(r.Context(), "workflow.get", "deny", map[string]any{"workflow": name}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "workflow.get", map[string]any{"workflow": name}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		if s.DB != nil {
@@ -137,8 +140,8 @@ func (s *Server) handleWorkflowByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := s.policyCheck(r, "workflow.version.create", "write", ContextRef{TenantID: tenantID}, "low", 0); err != nil {
-			s.auditEvent(r.Context(), "workflow.version.create", "deny", map[string]any{"workflow": name}, err.Error())
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "workflow.version.create", map[string]any{"workflow": name}, err)
+			writePolicyDenied(w, err)
 			return
 		}
 		req["name"] = name
@@ -200,34 +203,49 @@ func (s *Server) handleWorkflowByID(w http.ResponseWriter, r *http.Request) {
 	}
 	dec, err := s.policyDecision(r, "plan.create", actionType, req.Context, risk, 0)
 	if err != nil {
-		s.auditEvent(r.Context(), "workflow.start", "deny", req.Context, err.Error())
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "workflow.start", req.Context, err)
+		writePolicyDenied(w, err)
 		return
 	}
 	switch dec.Decision {
 	case "allow":
 	case "require_approval":
 		if actionType != "write" {
-			s.auditEvent(r.Context(), "workflow.start", "deny", req.Context, "approval required")
-			http.Error(w, "policy denied", http.StatusForbidden)
+			s.auditDenied(r.Context(), "workflow.start", req.Context, deniedError(dec))
+			writePolicyDenied(w, deniedError(dec))
 			return
 		}
 	default:
-		s.auditEvent(r.Context(), "workflow.start", "deny", req.Context, "policy decision "+dec.Decision)
-		http.Error(w, "policy denied", http.StatusForbidden)
+		s.auditDenied(r.Context(), "workflow.start", req.Context, deniedError(dec))
+		writePolicyDenied(w, deniedError(dec))
 		return
 	}
 	if s.Executor == nil {
 		http.Error(w, `{"error":"temporal not configured"}`, http.StatusServiceUnavailable)
 		return
 	}
+	constraints := mergeConstraints(req.Constraints, dec.Constraints)
+	// Low risk writes are auto-approved unless they need an approval quorum.
+	autoApprove := actionType == "write" && risk == "low" && s.AutoApproveLow && !s.quorumFor(risk, constraints).strict()
+	if autoApprove {
+		// Auto-approved low risk writes execute straight away.
+		if err := s.checkFreezes(r, "workflow.start", req.Context, planServices(steps), map[string]any{"workflow": name}); err != nil {
+			if errors.Is(err, errFreezeCalendarUnavailable) {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			s.auditDenied(r.Context(), "workflow.start", req.Context, err)
+			writePolicyDenied(w, err)
+			return
+		}
+	}
 	plan := map[string]any{
 		"trigger":     "workflow",
 		"summary":     summary,
 		"context":     req.Context,
 		"risk_level":  risk,
 		"intent":      intent,
-		"constraints": mergeConstraints(req.Constraints, dec.Constraints),
+		"constraints": constraints,
 		"created_at":  time.Now().UTC(),
 		"steps":       steps,
 		"meta": map[string]any{
@@ -235,6 +253,9 @@ func (s *Server) handleWorkflowByID(w http.ResponseWriter, r *http.Request) {
 			"input":    req.Input,
 		},
 	}
+	if actor, ok := ActorFromContext(r.Context()); ok && actor.ID != "" {
+		plan["created_by"] = actor.ID
+	}
 	data, err := marshalJSON(plan)
 	if err != nil {
 		http.Error(w, "encode error", http.StatusInternalServerError)
@@ -248,12 +269,16 @@ func (s *Server) handleWorkflowByID(w http.ResponseWriter, r *http.Request) {
 	approvalRequired := actionType == "write"
 	execID := ""
 	if approvalRequired {
-		if risk == "low" && s.AutoApproveLow && dec.Decision != "require_approval" {
+		if autoApprove && dec.Decision != "require_approval" {
 			approvalID, err := s.createApproval(r.Context(), planID, false)
 			if err != nil {
 				http.Error(w, "approval error", http.StatusBadGateway)
 				return
 			}
+			if err := s.AttestApproval(r.Context(), planID, Actor{ID: AutoApprover}, attest.SourceAuto); err != nil {
+				http.Error(w, "attestation error", http.StatusBadGateway)
+				return
+			}
 			if err := s.DB.UpdateApprovalStatusByPlan(r.Context(), planID, "approved"); err != nil {
 				http.Error(w, "db error", http.StatusInternalServerError)
 				return
@@ -268,7 +293,7 @@ func (s *Server) handleWorkflowByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	if s.Executor != nil && (!approvalRequired || risk == "low" && s.AutoApproveLow) {
+	if s.Executor != nil && (!approvalRequired || autoApprove) {
 		if checker, ok := s.DB.(ActiveExecutionChecker); ok {
 			active, err := checker.HasActiveExecution(r.Context(), planID)
 			if err != nil {
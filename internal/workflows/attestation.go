@@ -0,0 +1,104 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"carapulse/internal/attest"
+	"carapulse/internal/tools"
+)
+
+// ApprovalAttestationReader lists a plan's signed approval attestations.
+type ApprovalAttestationReader interface {
+	ListApprovalAttestations(ctx context.Context, planID string) ([]byte, error)
+}
+
+var (
+	// ErrAttestationRequired is returned for a write step whose plan has no
+	// attestation for the revision about to run.
+	ErrAttestationRequired = errors.New("approval attestation required")
+	// ErrAttestationMismatch is returned when an attestation does not verify
+	// or does not cover what is about to run.
+	ErrAttestationMismatch = errors.New("approval attestation mismatch")
+)
+
+// checkAttestation refuses a write unless the plan's stored revision has an
+// attestation, and every attestation for that revision verifies, names the
+// context ctxRef and covers step. Reads and executors without a verifier
+// are not checked.
+func (e *Executor) checkAttestation(ctx context.Context, planID string, step PlanStep, tool, action string, ctxRef tools.ContextRef) error {
+	if e.Attestations == nil || tools.ActionType(tool, action) == "read" {
+		return nil
+	}
+	reader, ok := e.Store.(ApprovalAttestationReader)
+	if !ok || planID == "" {
+		return ErrAttestationRequired
+	}
+	hash, err := e.planHash(ctx, planID)
+	if err != nil {
+		return err
+	}
+	data, err := reader.ListApprovalAttestations(ctx, planID)
+	if err != nil {
+		return err
+	}
+	list, err := attest.ParseList(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAttestationMismatch, err)
+	}
+	target := attest.Step{StepID: step.StepID, Stage: step.Stage, Action: step.Action, Tool: step.Tool, Input: step.Input, Rollback: step.Rollback}
+	verified := 0
+	for _, a := range list {
+		if a.PlanHash != hash || a.Decision != "approved" {
+			continue
+		}
+		if err := attest.Verify(ctx, e.Attestations, a); err != nil {
+			return fmt.Errorf("%w: attestation by %q: %v", ErrAttestationMismatch, a.Approver.ID, err)
+		}
+		attested, _ := a.Context.(map[string]any)
+		if a.PlanID != planID || contextFromMap(attested) != ctxRef {
+			return fmt.Errorf("%w: attestation by %q is for another plan or context", ErrAttestationMismatch, a.Approver.ID)
+		}
+		if !a.Covers(target) {
+			return fmt.Errorf("%w: step %q is not in the attested plan", ErrAttestationMismatch, step.StepID)
+		}
+		verified++
+	}
+	if verified > 0 {
+		return nil
+	}
+	if len(list) > 0 {
+		return fmt.Errorf("%w: plan %s changed after it was attested", ErrAttestationMismatch, planID)
+	}
+	return fmt.Errorf("%w for plan %s", ErrAttestationRequired, planID)
+}
+
+// planHash recomputes the hash of the plan as stored, the revision the
+// gateway signs when it is approved.
+func (e *Executor) planHash(ctx context.Context, planID string) (string, error) {
+	payload, err := e.Store.GetPlan(ctx, planID)
+	if err != nil {
+		return "", err
+	}
+	var plan struct {
+		Intent string `json:"intent"`
+	}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &plan); err != nil {
+			return "", err
+		}
+	}
+	data, err := e.Store.ListPlanSteps(ctx, planID)
+	if err != nil {
+		return "", err
+	}
+	var steps []attest.Step
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &steps); err != nil {
+			return "", err
+		}
+	}
+	return attest.PlanHash(plan.Intent, steps), nil
+}
@@ -0,0 +1,131 @@
+package workflows
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"carapulse/internal/attest"
+	"carapulse/internal/db"
+	"carapulse/internal/tools"
+	"go.temporal.io/sdk/temporal"
+)
+
+// attestedStore serves stored attestations alongside a plan.
+type attestedStore struct {
+	fakeExecutionStore
+	attestations []attest.Attestation
+}
+
+func (s *attestedStore) ListApprovalAttestations(ctx context.Context, planID string) ([]byte, error) {
+	return json.Marshal(s.attestations)
+}
+
+var attestedContext = map[string]any{"tenant_id": "t1", "environment": "prod", "cluster_id": "c1", "namespace": "api"}
+
+func attestedPlan(t *testing.T, key attest.LocalKey, steps []map[string]any) *attestedStore {
+	t.Helper()
+	stepsJSON, _ := json.Marshal(steps)
+	planJSON, _ := json.Marshal(map[string]any{"intent": "scale api", "context": attestedContext})
+	var signed []attest.Step
+	_ = json.Unmarshal(stepsJSON, &signed)
+	a, err := attest.Sign(context.Background(), key, attest.Statement{
+		PlanID:     "plan_1",
+		PlanHash:   attest.PlanHash("scale api", signed),
+		Intent:     "scale api",
+		Steps:      signed,
+		Context:    attestedContext,
+		Approver:   attest.Approver{ID: "alice"},
+		Decision:   "approved",
+		Source:     attest.SourceAPI,
+		ApprovedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return &attestedStore{
+		fakeExecutionStore: fakeExecutionStore{executions: []db.ExecutionRef{{ExecutionID: "exec_1", PlanID: "plan_1"}}, stepsJSON: stepsJSON, planJSON: planJSON},
+		attestations:       []attest.Attestation{a},
+	}
+}
+
+func attestedExecutor(store ExecutionStore, verifier attest.Verifier, calls *int) *Executor {
+	sandbox := &tools.Sandbox{RunFunc: func(ctx context.Context, cmd []string) ([]byte, error) {
+		*calls++
+		return []byte("ok"), nil
+	}}
+	return &Executor{Store: store, Runtime: NewRuntime(tools.NewRouter(), sandbox, tools.HTTPClients{}), Attestations: verifier}
+}
+
+func TestExecutorVerifiesAttestation(t *testing.T) {
+	defer withTempCLI(t, "kubectl")()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	key := attest.NewLocalKey("k1", priv)
+	keys := attest.PublicKeys{"k1": priv.Public().(ed25519.PublicKey)}
+	steps := []map[string]any{{"step_id": "s1", "action": "scale", "tool": "kubectl", "input": map[string]any{"resource": "deploy/api", "replicas": 3}}}
+
+	calls := 0
+	store := attestedPlan(t, key, steps)
+	if _, err := attestedExecutor(store, keys, &calls).RunOnce(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if calls != 1 || store.completed[0] != "succeeded" {
+		t.Fatalf("calls=%d completed=%v", calls, store.completed)
+	}
+
+	// Steps edited after approval no longer match the attested revision.
+	calls = 0
+	store = attestedPlan(t, key, steps)
+	store.stepsJSON = []byte(`[{"step_id":"s1","action":"scale","tool":"kubectl","input":{"resource":"deploy/api","replicas":30}}]`)
+	_, err := attestedExecutor(store, keys, &calls).RunOnce(context.Background())
+	if !errors.Is(err, ErrAttestationMismatch) || calls != 0 || store.completed[0] != "failed" {
+		t.Fatalf("err=%v calls=%d completed=%v", err, calls, store.completed)
+	}
+
+	// A forged signature is refused even though the plan matches.
+	store = attestedPlan(t, key, steps)
+	store.attestations[0].Approver.ID = "mallory"
+	if _, err := attestedExecutor(store, keys, &calls).RunOnce(context.Background()); !errors.Is(err, ErrAttestationMismatch) || calls != 0 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+
+	store = attestedPlan(t, key, steps)
+	store.attestations = nil
+	if _, err := attestedExecutor(store, keys, &calls).RunOnce(context.Background()); !errors.Is(err, ErrAttestationRequired) || calls != 0 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+
+	// Reads need no attestation.
+	reads := []map[string]any{{"step_id": "s1", "action": "get", "tool": "kubectl", "input": map[string]any{"resource": "deploy/api"}}}
+	store = attestedPlan(t, key, reads)
+	store.attestations = nil
+	if _, err := attestedExecutor(store, keys, &calls).RunOnce(context.Background()); err != nil || calls != 1 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+}
+
+func TestExecuteStepActivityChecksAttestedContext(t *testing.T) {
+	defer withTempCLI(t, "kubectl")()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	steps := []map[string]any{{"step_id": "s1", "action": "scale", "tool": "kubectl", "input": map[string]any{"resource": "deploy/api", "replicas": 3}}}
+	store := attestedPlan(t, attest.NewLocalKey("k1", priv), steps)
+	calls := 0
+	exec := attestedExecutor(store, attest.PublicKeys{"k1": priv.Public().(ed25519.PublicKey)}, &calls)
+	acts := &Activities{Store: store, Runtime: exec.Runtime, Attestations: exec.Attestations}
+	step := PlanStep{StepID: "s1", Action: "scale", Tool: "kubectl", Input: map[string]any{"resource": "deploy/api", "replicas": 3}}
+	ctxRef := ContextRef{TenantID: "t1", Environment: "prod", ClusterID: "c1", Namespace: "api"}
+	if err := acts.ExecuteStep(context.Background(), StepActivityInput{PlanID: "plan_1", ExecutionID: "exec_1", Context: ctxRef, Step: step}); err != nil || calls != 1 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+
+	ctxRef.Namespace = "payments"
+	err := acts.ExecuteStep(context.Background(), StepActivityInput{PlanID: "plan_1", ExecutionID: "exec_1", Context: ctxRef, Step: step})
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || !appErr.NonRetryable() || !errors.Is(err, ErrAttestationMismatch) || calls != 1 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+}
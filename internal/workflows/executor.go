@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"carapulse/internal/attest"
 	"carapulse/internal/db"
 	"carapulse/internal/tools"
 )
@@ -39,6 +40,9 @@ type Executor struct {
 	Now           func() time.Time
 	DefaultStatus string
 	StepTimeout   time.Duration
+	// Attestations, when set, must verify a plan's approval attestation
+	// before any of its write steps or rollbacks run.
+	Attestations attest.Verifier
 }
 
 var marshalToolCall = json.Marshal
@@ -110,6 +114,7 @@ func (e *Executor) executePlan(ctx context.Context, exec db.ExecutionRef) error
 	if err := e.Store.UpdateExecutionStatus(ctx, exec.ExecutionID, "running"); err != nil {
 		return err
 	}
+	ctx = withPlanScope(ctx, exec.PlanID)
 	ctxRef, err := e.loadContext(ctx, exec.PlanID)
 	if err != nil {
 		_ = e.Store.CompleteExecution(ctx, exec.ExecutionID, "failed")
@@ -186,6 +191,9 @@ func (e *Executor) loadSteps(ctx context.Context, planID string) ([]PlanStep, er
 }
 
 func (e *Executor) executeStep(ctx context.Context, executionID string, step PlanStep, ctxRef tools.ContextRef) error {
+	if err := e.checkAttestation(ctx, scopeValue(ctx, planScopeKey{}), step, step.Tool, step.Action, ctxRef); err != nil {
+		return err
+	}
 	toolCallID, err := e.insertToolCall(ctx, executionID, step.Tool, "running", "", "")
 	if err != nil {
 		return err
@@ -199,7 +207,7 @@ func (e *Executor) executeStep(ctx context.Context, executionID string, step Pla
 	if stepTimeout <= 0 {
 		stepTimeout = 5 * time.Minute
 	}
-	stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+	stepCtx, cancel := context.WithTimeout(withStepScope(ctx, step.StepID), stepTimeout)
 	defer cancel()
 	resp, err := e.runTool(stepCtx, executionID, toolCallID, step.Tool, step.Action, step.Input, ctxRef)
 	if err != nil {
@@ -231,6 +239,9 @@ func (e *Executor) tryRollback(ctx context.Context, executionID string, step Pla
 	if strings.TrimSpace(tool) == "" || strings.TrimSpace(action) == "" {
 		return errors.New("invalid rollback")
 	}
+	if err := e.checkAttestation(ctx, scopeValue(ctx, planScopeKey{}), step, tool, action, ctxRef); err != nil {
+		return err
+	}
 	toolCallID, err := e.insertToolCall(ctx, executionID, tool, "running", "", "")
 	if err != nil {
 		return err
@@ -240,7 +251,7 @@ func (e *Executor) tryRollback(ctx context.Context, executionID string, step Pla
 		_ = e.Store.UpdateToolCall(ctx, toolCallID, "failed", inputRef, "")
 		return err
 	}
-	resp, err := e.runTool(ctx, executionID, toolCallID, tool, action, input, ctxRef)
+	resp, err := e.runTool(withStepScope(ctx, step.StepID), executionID, toolCallID, tool, action, input, ctxRef)
 	if err != nil {
 		_ = e.Store.UpdateToolCall(ctx, toolCallID, "failed", inputRef, "")
 		return err
@@ -263,6 +274,8 @@ func (e *Executor) runTool(ctx context.Context, executionID, toolCallID, tool, a
 		Input:       input,
 		ToolCallID:  toolCallID,
 		ExecutionID: executionID,
+		PlanID:      scopeValue(ctx, planScopeKey{}),
+		StepID:      scopeValue(ctx, stepScopeKey{}),
 		Context:     ctxRef,
 	}, e.Runtime.Sandbox, e.Runtime.Clients)
 	if err != nil {
@@ -271,6 +284,24 @@ func (e *Executor) runTool(ctx context.Context, executionID, toolCallID, tool, a
 	return resp.Output, nil
 }
 
+type planScopeKey struct{}
+type stepScopeKey struct{}
+
+// withPlanScope and withStepScope tag tool calls with the plan and step they
+// run for, so per-call credentials can be audited against them.
+func withPlanScope(ctx context.Context, planID string) context.Context {
+	return context.WithValue(ctx, planScopeKey{}, strings.TrimSpace(planID))
+}
+
+func withStepScope(ctx context.Context, stepID string) context.Context {
+	return context.WithValue(ctx, stepScopeKey{}, strings.TrimSpace(stepID))
+}
+
+func scopeValue(ctx context.Context, key any) string {
+	value, _ := ctx.Value(key).(string)
+	return value
+}
+
 func (e *Executor) storeInput(ctx context.Context, executionID, toolCallID string, input any) (string, error) {
 	if e.Objects == nil {
 		return "", nil
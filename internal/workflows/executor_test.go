@@ -745,3 +745,43 @@ func TestEvidenceTypeMapping(t *testing.T) {
 		t.Fatalf("unknown")
 	}
 }
+
+type recordingCredentialProvider struct {
+	reqs []tools.CredentialRequest
+}
+
+func (p *recordingCredentialProvider) Name() string { return "recording" }
+
+func (p *recordingCredentialProvider) Applies(tool string, ref tools.ContextRef) bool { return true }
+
+func (p *recordingCredentialProvider) Issue(ctx context.Context, req tools.CredentialRequest) (tools.Credential, error) {
+	p.reqs = append(p.reqs, req)
+	return tools.Credential{Env: map[string]string{"TOKEN": "t"}}, nil
+}
+
+func TestExecutorScopesCredentialsToPlanStep(t *testing.T) {
+	defer withTempCLI(t, "kubectl")()
+	steps := []map[string]any{{"step_id": "step_1", "action": "scale", "tool": "kubectl", "input": map[string]any{"resource": "deploy/app", "replicas": 1}}}
+	stepsJSON, _ := json.Marshal(steps)
+	store := &fakeExecutionStore{executions: []db.ExecutionRef{{ExecutionID: "exec_1", PlanID: "plan_1"}}, stepsJSON: stepsJSON}
+	provider := &recordingCredentialProvider{}
+	router := tools.NewRouter()
+	router.Credentials = tools.NewCredentialBroker(provider)
+	sandbox := &tools.Sandbox{RunFunc: func(ctx context.Context, cmd []string) ([]byte, error) {
+		if tools.CallEnv(ctx)["TOKEN"] != "t" {
+			t.Fatalf("missing call env")
+		}
+		return []byte("ok"), nil
+	}}
+	executor := &Executor{Store: store, Runtime: NewRuntime(router, sandbox, tools.HTTPClients{})}
+	if _, err := executor.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(provider.reqs) != 1 {
+		t.Fatalf("reqs: %+v", provider.reqs)
+	}
+	req := provider.reqs[0]
+	if req.PlanID != "plan_1" || req.StepID != "step_1" || req.ExecutionID != "exec_1" || req.ToolCallID == "" {
+		t.Fatalf("req: %+v", req)
+	}
+}
@@ -5,22 +5,26 @@ import (
 	"errors"
 	"time"
 
+	"carapulse/internal/attest"
 	"carapulse/internal/tools"
+	"go.temporal.io/sdk/temporal"
 )
 
 type Activities struct {
-	Store      ExecutionStore
-	Runtime    *Runtime
-	Objects    BlobStore
-	PresignTTL time.Duration
+	Store        ExecutionStore
+	Runtime      *Runtime
+	Objects      BlobStore
+	PresignTTL   time.Duration
+	Attestations attest.Verifier
 }
 
 func (a *Activities) executor() *Executor {
 	return &Executor{
-		Store:      a.Store,
-		Runtime:    a.Runtime,
-		Objects:    a.Objects,
-		PresignTTL: a.PresignTTL,
+		Store:        a.Store,
+		Runtime:      a.Runtime,
+		Objects:      a.Objects,
+		PresignTTL:   a.PresignTTL,
+		Attestations: a.Attestations,
 	}
 }
 
@@ -29,7 +33,7 @@ func (a *Activities) ExecuteStep(ctx context.Context, input StepActivityInput) e
 		return errors.New("runtime required")
 	}
 	exec := a.executor()
-	return exec.executeStep(ctx, input.ExecutionID, input.Step, contextToTools(input.Context))
+	return activityError(exec.executeStep(withPlanScope(ctx, input.PlanID), input.ExecutionID, input.Step, contextToTools(input.Context)))
 }
 
 func (a *Activities) RollbackStep(ctx context.Context, input StepActivityInput) error {
@@ -37,7 +41,30 @@ func (a *Activities) RollbackStep(ctx context.Context, input StepActivityInput)
 		return errors.New("runtime required")
 	}
 	exec := a.executor()
-	return exec.tryRollback(ctx, input.ExecutionID, input.Step, contextToTools(input.Context))
+	return activityError(exec.tryRollback(withPlanScope(ctx, input.PlanID), input.ExecutionID, input.Step, contextToTools(input.Context)))
+}
+
+// activityError stops Temporal from retrying a step refused for its
+// attestation; retrying cannot change the outcome.
+func activityError(err error) error {
+	if errors.Is(err, ErrAttestationRequired) || errors.Is(err, ErrAttestationMismatch) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "AttestationRefused", err)
+	}
+	return retryableToolError(err)
+}
+
+// retryableToolError passes the router's retry-after hint to Temporal when a
+// call was rejected by an open breaker or a full concurrency limit, so the
+// activity is retried once the backend is expected to accept calls again.
+func retryableToolError(err error) error {
+	var unavailable *tools.BackendUnavailableError
+	if !errors.As(err, &unavailable) {
+		return err
+	}
+	return temporal.NewApplicationErrorWithOptions(err.Error(), "BackendUnavailable", temporal.ApplicationErrorOptions{
+		NextRetryDelay: unavailable.RetryAfter,
+		Cause:          err,
+	})
 }
 
 func (a *Activities) UpdateExecutionStatus(ctx context.Context, executionID, status string) error {
@@ -0,0 +1,31 @@
+package workflows
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"carapulse/internal/tools"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestRetryableToolError(t *testing.T) {
+	plain := errors.New("boom")
+	if retryableToolError(plain) != plain {
+		t.Fatalf("plain errors should pass through")
+	}
+	if retryableToolError(nil) != nil {
+		t.Fatalf("nil should stay nil")
+	}
+	err := retryableToolError(&tools.BackendUnavailableError{Backend: "argocd", Reason: "circuit_open", RetryAfter: 7 * time.Second})
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("err: %T", err)
+	}
+	if appErr.Type() != "BackendUnavailable" || appErr.NonRetryable() || appErr.NextRetryDelay() != 7*time.Second {
+		t.Fatalf("app err: %v", appErr)
+	}
+	if !errors.Is(err, tools.ErrBackendUnavailable) {
+		t.Fatalf("cause lost")
+	}
+}
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS llm_usage (
+  usage_id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  plan_id TEXT,
+  trigger TEXT,
+  provider TEXT NOT NULL,
+  model TEXT NOT NULL,
+  prompt_tokens INT NOT NULL,
+  completion_tokens INT NOT NULL,
+  total_tokens INT NOT NULL,
+  cost_usd DOUBLE PRECISION NOT NULL,
+  latency_ms BIGINT NOT NULL,
+  fallback_index INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_tenant_created ON llm_usage(tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_plan ON llm_usage(plan_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS llm_usage CASCADE;
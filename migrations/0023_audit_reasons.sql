@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE audit_events ADD COLUMN reasons_json JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- +goose Down
+ALTER TABLE audit_events DROP COLUMN IF EXISTS reasons_json;
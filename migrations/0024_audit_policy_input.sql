@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE audit_events ADD COLUMN policy_input_json JSONB;
+
+-- +goose Down
+ALTER TABLE audit_events DROP COLUMN IF EXISTS policy_input_json;
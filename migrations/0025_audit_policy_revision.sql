@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE audit_events ADD COLUMN policy_revision TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE audit_events DROP COLUMN IF EXISTS policy_revision;
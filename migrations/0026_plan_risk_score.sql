@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS risk_score INTEGER;
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS risk_factors_json JSONB;
+CREATE INDEX IF NOT EXISTS idx_executions_status_completed_at ON executions(status, completed_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_executions_status_completed_at;
+ALTER TABLE plans DROP COLUMN IF EXISTS risk_factors_json;
+ALTER TABLE plans DROP COLUMN IF EXISTS risk_score;
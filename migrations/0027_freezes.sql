@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS freezes (
+  freeze_id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  reason TEXT,
+  starts_at TIMESTAMP WITH TIME ZONE,
+  ends_at TIMESTAMP WITH TIME ZONE,
+  recurrence_json JSONB,
+  timezone TEXT,
+  environments_json JSONB,
+  services_json JSONB,
+  source TEXT NOT NULL,
+  source_ref TEXT,
+  external_id TEXT,
+  created_by TEXT,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_freezes_ends_at ON freezes(ends_at);
+CREATE INDEX IF NOT EXISTS idx_freezes_source ON freezes(source, source_ref);
+
+-- +goose Down
+DROP TABLE IF EXISTS freezes CASCADE;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS created_by TEXT;
+
+CREATE TABLE IF NOT EXISTS approval_votes (
+  vote_id TEXT PRIMARY KEY,
+  plan_id TEXT NOT NULL REFERENCES plans(plan_id) ON DELETE CASCADE,
+  plan_hash TEXT NOT NULL,
+  approver_id TEXT NOT NULL,
+  approver_email TEXT,
+  approver_groups_json JSONB,
+  decision TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_votes_unique ON approval_votes(plan_id, plan_hash, approver_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS approval_votes CASCADE;
+ALTER TABLE plans DROP COLUMN IF EXISTS created_by;
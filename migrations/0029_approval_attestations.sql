@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS approval_attestations (
+  attestation_id TEXT PRIMARY KEY,
+  plan_id TEXT NOT NULL REFERENCES plans(plan_id) ON DELETE CASCADE,
+  plan_hash TEXT NOT NULL,
+  approver_id TEXT NOT NULL,
+  key_id TEXT NOT NULL,
+  attestation_json JSONB NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_attestations_unique ON approval_attestations(plan_id, plan_hash, approver_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS approval_attestations CASCADE;
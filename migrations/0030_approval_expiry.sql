@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE approvals ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE approvals ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE approvals ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_approvals_status_expires_at ON approvals(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_alert_events_status_updated_at ON alert_events(status, updated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_alert_events_status_updated_at;
+DROP INDEX IF EXISTS idx_approvals_status_expires_at;
+ALTER TABLE approvals DROP COLUMN IF EXISTS escalated_at;
+ALTER TABLE approvals DROP COLUMN IF EXISTS reminded_at;
+ALTER TABLE approvals DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS report_narrative_json JSONB;
+
+-- +goose Down
+ALTER TABLE executions DROP COLUMN IF EXISTS report_narrative_json;
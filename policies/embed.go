@@ -0,0 +1,10 @@
+// Package policies embeds the bundled Rego policies so services can
+// evaluate them in-process instead of querying an OPA sidecar.
+package policies
+
+import "embed"
+
+// FS holds the bundle's policy tree, rooted at "policy".
+//
+//go:embed policy
+var FS embed.FS
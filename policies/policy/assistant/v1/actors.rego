@@ -27,3 +27,8 @@ deny_write if {
 	write_action
 	not write_role_ok
 }
+
+deny_reasons contains {"rule": "write_role_required", "message": "writes require the admin or operator role", "field": "actor.roles"} if {
+	write_action
+	not write_role_ok
+}
@@ -11,3 +11,15 @@ decision := "allow" if {
 } else := "require_approval" if {
 	require_approval_write
 }
+
+# reasons explain the decision: what denied the action, or why it needs
+# approval. Each reason is {"rule", "message", "field"}, where field is
+# the input path that triggered it.
+reasons := deny_reasons if {
+	decision == "deny"
+	count(deny_reasons) > 0
+} else := {{"rule": "no_matching_rule", "message": "no policy rule allows this action", "field": "action.type"}} if {
+	decision == "deny"
+} else := approval_reasons if {
+	decision == "require_approval"
+} else := set()
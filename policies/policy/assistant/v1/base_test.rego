@@ -2,44 +2,126 @@ package policy.assistant.v1
 
 test_read_allows if {
 	inp := {"actor": {"id": "a", "roles": ["viewer"]}, "action": {"type": "read"}, "risk": {"level": "read"}}
-	decision with input as inp == "allow"
-	ttl with input as inp == 300
+	decision == "allow" with input as inp
+	ttl == 300 with input as inp
 }
 
 test_write_low_nonprod_allows if {
 	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service"}}
-	decision with input as inp == "allow"
+	decision == "allow" with input as inp
 }
 
 test_write_medium_requires_approval if {
 	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "medium", "targets": 1, "blast_radius": "service"}}
-	decision with input as inp == "require_approval"
+	decision == "require_approval" with input as inp
 }
 
 test_write_high_requires_approval_and_break_glass_flag if {
 	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "high", "targets": 1, "blast_radius": "service"}}
-	decision with input as inp == "require_approval"
+	decision == "require_approval" with input as inp
 	bg := constraints.break_glass_required with input as inp
 	bg == true
 }
 
 test_write_prod_requires_approval if {
 	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "prod"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service"}}
-	decision with input as inp == "require_approval"
+	decision == "require_approval" with input as inp
 }
 
 test_write_account_requires_approval if {
 	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "account"}}
-	decision with input as inp == "require_approval"
+	decision == "require_approval" with input as inp
 }
 
 test_write_no_roles_denies if {
 	inp := {"actor": {"id": "a", "roles": []}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service"}}
-	decision with input as inp == "deny"
+	decision == "deny" with input as inp
 }
 
 test_write_unknown_env_denies if {
 	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "nope"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service"}}
-	decision with input as inp == "deny"
+	decision == "deny" with input as inp
 }
 
+test_write_low_critic_no_go_requires_approval if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service", "critic": {"recommendation": "no_go", "suggested_risk": "high"}}}
+	decision == "require_approval" with input as inp
+	constraints.critic_no_go == true with input as inp
+}
+
+test_write_low_critic_go_allows if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service", "critic": {"recommendation": "go"}}}
+	decision == "allow" with input as inp
+}
+
+test_write_low_recent_failures_requires_approval if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "score": 25, "factors": [{"name": "action", "points": 15}, {"name": "recent_failures", "points": 10}], "targets": 1, "blast_radius": "service"}}
+	decision == "require_approval" with input as inp
+	"recent_failures" in reason_rules(inp)
+	risk_score == 25 with input as inp
+}
+
+test_write_low_without_failures_allows if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "score": 15, "factors": [{"name": "action", "points": 15}, {"name": "recent_failures", "points": 0}], "targets": 1, "blast_radius": "service"}}
+	decision == "allow" with input as inp
+}
+
+test_write_low_shared_dependency_requires_approval if {
+	impact := {"services": [{"name": "db", "depth": 0}, {"name": "api", "depth": 1}, {"name": "billing", "depth": 1}, {"name": "web", "depth": 2}], "traffic_share": 0.2}
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "namespace", "impact": impact}}
+	decision == "require_approval" with input as inp
+	"shared_dependency" in reason_rules(inp)
+}
+
+test_write_low_high_traffic_requires_approval if {
+	impact := {"services": [{"name": "edge", "depth": 0}], "traffic_share": 0.8}
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "namespace", "impact": impact}}
+	decision == "require_approval" with input as inp
+}
+
+test_write_low_leaf_service_allows if {
+	impact := {"services": [{"name": "cron", "depth": 0}, {"name": "report", "depth": 1}], "traffic_share": 0.1}
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "namespace", "impact": impact}}
+	decision == "allow" with input as inp
+}
+
+reason_rules(inp) := {r.rule | some r in reasons with input as inp}
+
+test_read_allow_has_no_reasons if {
+	inp := {"actor": {"id": "a", "roles": ["viewer"]}, "action": {"type": "read"}, "risk": {"level": "read"}}
+	reason_rules(inp) == set()
+}
+
+test_read_unauthenticated_reason if {
+	inp := {"actor": {}, "action": {"type": "read"}, "risk": {"level": "read"}}
+	reason_rules(inp) == {"unauthenticated_actor"}
+}
+
+test_write_no_roles_reason if {
+	inp := {"actor": {"id": "a", "roles": []}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service"}}
+	reason_rules(inp) == {"write_role_required"}
+}
+
+test_write_unknown_env_reason_field if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "nope"}, "risk": {"level": "low", "targets": 1, "blast_radius": "service"}}
+	some r in reasons with input as inp
+	r.rule == "unknown_environment"
+	r.field == "context.environment"
+}
+
+test_write_prod_target_limit_reason if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "prod"}, "risk": {"level": "low", "targets": 11, "blast_radius": "service"}}
+	decision == "deny" with input as inp
+	reason_rules(inp) == {"prod_target_limit"}
+}
+
+test_write_prod_high_approval_reasons if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "prod"}, "risk": {"level": "high", "targets": 1, "blast_radius": "service"}}
+	reason_rules(inp) == {"prod_write", "risk_level"}
+}
+
+test_write_unmatched_denies_with_reason if {
+	inp := {"actor": {"id": "a", "roles": ["operator"]}, "action": {"type": "write"}, "context": {"environment": "dev"}, "risk": {"level": "", "targets": 1, "blast_radius": "service"}}
+	decision == "deny" with input as inp
+	reason_rules(inp) == {"no_matching_rule"}
+}
@@ -14,3 +14,8 @@ constraints["break_glass_used"] := true if {
 	tier == "break_glass"
 	break_glass
 }
+
+approval_reasons contains {"rule": "break_glass_tier", "message": "break-glass actions require approval", "field": "risk.tier"} if {
+	write_action
+	tier == "break_glass"
+}
@@ -0,0 +1,28 @@
+package policy.assistant.v1
+
+default critic_no_go := false
+
+# input.risk.critic is the plan critic's verdict summary, when present.
+critic := object.get(input.risk, "critic", {})
+
+critic_no_go if {
+	critic.recommendation == "no_go"
+}
+
+# A critic no_go never runs unattended.
+require_approval_write if {
+	write_action
+	critic_no_go
+	write_role_ok
+	not deny_write
+}
+
+constraints["critic_no_go"] := true if {
+	write_action
+	critic_no_go
+}
+
+approval_reasons contains {"rule": "critic_no_go", "message": "the plan critic recommended no_go", "field": "risk.critic.recommendation"} if {
+	write_action
+	critic_no_go
+}
@@ -1,6 +1,6 @@
 package policy.assistant.v1
 
-default env_allowed := true
+default env_allowed := false
 
 allowed_envs := {"local", "dev", "staging", "prod"}
 
@@ -24,3 +24,14 @@ deny_write if {
 	env == "prod"
 	input.risk.targets > 10
 }
+
+deny_reasons contains {"rule": "unknown_environment", "message": sprintf("environment %q is not one of local, dev, staging, prod", [env]), "field": "context.environment"} if {
+	write_action
+	not env_allowed
+}
+
+deny_reasons contains {"rule": "prod_target_limit", "message": sprintf("%v targets exceed the prod limit of 10", [input.risk.targets]), "field": "risk.targets"} if {
+	write_action
+	env == "prod"
+	input.risk.targets > 10
+}
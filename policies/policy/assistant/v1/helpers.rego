@@ -8,5 +8,17 @@ risk_level := object.get(input.risk, "level", object.get(input.risk, "Level", ""
 blast_radius := object.get(input.risk, "blast_radius", object.get(input.risk, "BlastRadius", ""))
 tier := object.get(input.risk, "tier", object.get(input.risk, "Tier", ""))
 
+# risk_score is the plan's 0-100 risk score; risk_factors maps each factor
+# of its breakdown to the points it contributed.
+risk_score := object.get(input.risk, "score", 0)
+risk_factors := {f.name: f.points | some f in object.get(input.risk, "factors", [])}
+
+# impact is the blast radius walk of the context graph: impacted_dependents
+# counts what sits downstream of the targets, traffic_share is the largest
+# share of traffic passing through any of it.
+impact := object.get(input.risk, "impact", {})
+impacted_dependents := count([s | some s in object.get(impact, "services", []); s.depth > 0])
+traffic_share := object.get(impact, "traffic_share", 0)
+
 env := lower(object.get(input.context, "environment", object.get(input.context, "Environment", "")))
 prod_env := env == "prod"
@@ -10,3 +10,8 @@ allow_read if {
 ttl := 300 if {
 	allow_read
 }
+
+deny_reasons contains {"rule": "unauthenticated_actor", "message": "reads require an authenticated actor", "field": "actor.id"} if {
+	input.action.type == "read"
+	not authenticated_actor
+}
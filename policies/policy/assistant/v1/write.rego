@@ -8,6 +8,9 @@ allow_low_write if {
 	risk_level == "low"
 	not prod_env
 	blast_radius != "account"
+	not critic_no_go
+	not recent_failures
+	not shared_dependency
 	write_role_ok
 	not deny_write
 }
@@ -40,7 +43,56 @@ require_approval_write if {
 	not deny_write
 }
 
+# A low-risk write to a service that failed recently still gets a human look.
+recent_failures if {
+	object.get(risk_factors, "recent_failures", 0) > 0
+}
+
+require_approval_write if {
+	write_action
+	recent_failures
+	write_role_ok
+	not deny_write
+}
+
+# Changing a shared dependency reaches far more than a leaf service does.
+shared_dependency if impacted_dependents >= 3
+
+shared_dependency if traffic_share >= 0.5
+
+require_approval_write if {
+	write_action
+	shared_dependency
+	write_role_ok
+	not deny_write
+}
+
 constraints["break_glass_required"] := true if {
 	write_action
 	risk_level == "high"
 }
+
+approval_reasons contains {"rule": "prod_write", "message": "writes to prod require approval", "field": "context.environment"} if {
+	write_action
+	prod_env
+}
+
+approval_reasons contains {"rule": "account_blast_radius", "message": "account-wide changes require approval", "field": "risk.blast_radius"} if {
+	write_action
+	blast_radius == "account"
+}
+
+approval_reasons contains {"rule": "risk_level", "message": sprintf("%s risk writes require approval", [risk_level]), "field": "risk.level"} if {
+	write_action
+	risk_level in {"medium", "high"}
+}
+
+approval_reasons contains {"rule": "recent_failures", "message": "target services had failed executions recently", "field": "risk.factors"} if {
+	write_action
+	recent_failures
+}
+
+approval_reasons contains {"rule": "shared_dependency", "message": sprintf("change impacts %d dependents carrying %v of traffic", [impacted_dependents, traffic_share]), "field": "risk.impact"} if {
+	write_action
+	shared_dependency
+}
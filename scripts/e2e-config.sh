@@ -49,4 +49,17 @@ jq --arg grafana "$GRAFANA_TOKEN" --arg argocd "$ARGOCD_TOKEN" \
   '.connectors.grafana.token=$grafana | .connectors.argocd.token=$argocd' \
   "$TEMPLATE" > "$OUT"
 
+# Optional LLM cassette for the alert flow: record once against a real
+# provider, then replay offline (no api key needed) in CI.
+CASSETTE_MODE="${E2E_LLM_CASSETTE_MODE:-}"
+if [[ -n "$CASSETTE_MODE" ]]; then
+  CASSETTE_DIR="${E2E_LLM_CASSETTE_DIR:-$ROOT/e2e/cassettes}"
+  jq --arg mode "$CASSETTE_MODE" --arg dir "$CASSETTE_DIR" \
+    --arg provider "${E2E_LLM_PROVIDER:-openai}" --arg model "${E2E_LLM_MODEL:-gpt-4o-mini}" \
+    --arg key "${E2E_LLM_API_KEY:-}" \
+    '.llm.provider=$provider | .llm.model=$model | .llm.api_key=$key
+     | .llm.cassette={mode:$mode, dir:$dir} | .gateway.enable_event_loop=true' \
+    "$OUT" > "$OUT.tmp" && mv "$OUT.tmp" "$OUT"
+fi
+
 echo "wrote $OUT"